@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/olindenbaum/mcgonalds/internal/client"
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/olindenbaum/mcgonalds/internal/db"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/migrations"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newRootCommand builds the command tree. Without a subcommand the binary
+// serves the API, as it always has.
+func newRootCommand() *cobra.Command {
+	var configPath string
+	root := &cobra.Command{
+		Use:          "mcgonalds",
+		Short:        "Minecraft server manager",
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			serve(configPath)
+		},
+	}
+	root.PersistentFlags().StringVar(&configPath, "config", os.Getenv(config.EnvPrefix+"_CONFIG"),
+		"path to the config file (default "+config.DefaultPath+" if present)")
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Apply pending migrations and serve the API",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				serve(configPath)
+			},
+		},
+		&cobra.Command{
+			Use:   "agent",
+			Short: "Run the servers the control plane in agent.control_url schedules onto this node",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				runAgent(configPath)
+			},
+		},
+		newMigrateCommand(&configPath),
+		newAdminCommand(&configPath),
+		newLoginCommand(),
+		newServerCommand(),
+	)
+	return root
+}
+
+func newMigrateCommand(configPath *string) *cobra.Command {
+	var rollback int
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending database migrations, or roll back with --rollback",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadConfig(*configPath)
+			if err := db.InitDatabase(&cfg.Database); err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer db.Close()
+
+			if rollback > 0 {
+				count, err := db.Rollback(db.GetDB(), migrations.FS, rollback)
+				if err != nil {
+					return fmt.Errorf("failed to roll back migrations: %w", err)
+				}
+				slog.Info("Rolled back migrations", "count", count)
+				return nil
+			}
+			count, err := db.Migrate(db.GetDB(), migrations.FS)
+			if err != nil {
+				return fmt.Errorf("failed to migrate database: %w", err)
+			}
+			slog.Info("Database migrated", "applied", count)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&rollback, "rollback", 0, "roll back this many migrations instead")
+	return cmd
+}
+
+func newAdminCommand(configPath *string) *cobra.Command {
+	admin := &cobra.Command{
+		Use:   "admin",
+		Short: "Administer the database directly, e.g. to create the first admin",
+	}
+
+	var username, password, email, role string
+	createUser := &cobra.Command{
+		Use:   "create-user",
+		Short: "Create a user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !model.ValidRole(role) {
+				return fmt.Errorf("unknown role %q", role)
+			}
+			if password == "" {
+				password = os.Getenv(config.EnvPrefix + "_PASSWORD")
+			}
+			if username == "" || password == "" {
+				return fmt.Errorf("--username and --password (or %s_PASSWORD) are required", config.EnvPrefix)
+			}
+
+			cfg := loadConfig(*configPath)
+			if err := db.InitDatabase(&cfg.Database); err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer db.Close()
+			if _, err := db.Migrate(db.GetDB(), migrations.FS); err != nil {
+				return fmt.Errorf("failed to migrate database: %w", err)
+			}
+
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return fmt.Errorf("failed to hash password: %w", err)
+			}
+			user := model.User{Username: username, Password: string(hashedPassword), Role: role}
+			if email != "" {
+				user.Email = &email
+			}
+			if err := db.GetDB().Create(&user).Error; err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			fmt.Printf("Created %s %s (id %d)\n", role, username, user.ID)
+			return nil
+		},
+	}
+	createUser.Flags().StringVar(&username, "username", "", "username")
+	createUser.Flags().StringVar(&password, "password", "", "password, read from "+config.EnvPrefix+"_PASSWORD if empty")
+	createUser.Flags().StringVar(&email, "email", "", "email address")
+	createUser.Flags().StringVar(&role, "role", model.RoleAdmin, "viewer, operator or admin")
+
+	admin.AddCommand(createUser)
+	return admin
+}
+
+// apiFlags are the flags of the commands that call the API of a running
+// manager
+type apiFlags struct {
+	url   string
+	token string
+}
+
+func (f *apiFlags) register(cmd *cobra.Command) {
+	url := os.Getenv(config.EnvPrefix + "_API_URL")
+	if url == "" {
+		url = "http://localhost:8080"
+	}
+	cmd.PersistentFlags().StringVar(&f.url, "api-url", url, "address of the manager, "+config.EnvPrefix+"_API_URL")
+	cmd.PersistentFlags().StringVar(&f.token, "token", os.Getenv(config.EnvPrefix+"_TOKEN"), "API token, "+config.EnvPrefix+"_TOKEN")
+}
+
+func (f *apiFlags) client() *client.Client {
+	return client.New(f.url, f.token)
+}
+
+func newLoginCommand() *cobra.Command {
+	var api apiFlags
+	var username, password string
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Print an API token for " + config.EnvPrefix + "_TOKEN",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if password == "" {
+				password = os.Getenv(config.EnvPrefix + "_PASSWORD")
+			}
+			token, err := api.client().Login(username, password)
+			if err != nil {
+				return err
+			}
+			fmt.Println(token)
+			return nil
+		},
+	}
+	api.register(cmd)
+	cmd.Flags().StringVar(&username, "username", "", "username")
+	cmd.Flags().StringVar(&password, "password", "", "password, read from "+config.EnvPrefix+"_PASSWORD if empty")
+	return cmd
+}
+
+func newServerCommand() *cobra.Command {
+	var api apiFlags
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Manage servers through the API of a running manager",
+	}
+	api.register(cmd)
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List servers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			servers, err := api.client().ListServers()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tSTATUS")
+			for _, srv := range servers {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", srv.ID, srv.Name, srv.Status)
+			}
+			return w.Flush()
+		},
+	}
+
+	action := func(use, short string, run func(c *client.Client, id uint) error) *cobra.Command {
+		return &cobra.Command{
+			Use:   use + " <id>",
+			Short: short,
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				id, err := strconv.ParseUint(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid server ID %q", args[0])
+				}
+				return run(api.client(), uint(id))
+			},
+		}
+	}
+
+	cmd.AddCommand(
+		list,
+		action("start", "Start a server", (*client.Client).StartServer),
+		action("stop", "Stop a server", (*client.Client).StopServer),
+	)
+	return cmd
+}
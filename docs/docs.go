@@ -1,4 +1,4 @@
-// Code generated by swaggo/swag. DO NOT EDIT
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
 package docs
 
 import "github.com/swaggo/swag"
@@ -24,82 +24,62 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/jar-files": {
+        "/admin/audit-logs": {
             "get": {
-                "description": "Retrieve a list of common JAR files",
+                "description": "Get state-changing requests of all users, newest first. The total number of matches is sent in the X-Total-Count header. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "jar-files"
+                    "admin"
                 ],
-                "summary": "Get common JAR files",
+                "summary": "List the audit log",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "description": "Filter by common JAR files",
-                        "name": "common",
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.JarFile"
-                            }
-                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponse"
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Upload a shared JAR file to be used by multiple servers",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "jar-files"
-                ],
-                "summary": "Upload a shared JAR file",
-                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Nickname of the JAR file",
-                        "name": "name",
-                        "in": "formData",
-                        "required": true
+                        "type": "integer",
+                        "description": "Entries per page (default 50, at most 100)",
+                        "name": "per_page",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Version of the JAR file",
-                        "name": "version",
-                        "in": "formData",
-                        "required": true
+                        "type": "integer",
+                        "description": "Filter by user",
+                        "name": "user_id",
+                        "in": "query"
                     },
                     {
-                        "type": "file",
-                        "description": "The JAR file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "type": "integer",
+                        "description": "Filter by server",
+                        "name": "server_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action substring, e.g. /start",
+                        "name": "action",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.JarFile"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AuditLog"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "Total number of matching entries"
+                            }
                         }
                     },
                     "400": {
@@ -108,8 +88,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -117,33 +97,19 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
+        "/admin/config/reload": {
             "post": {
-                "description": "Authenticate a user and get a JWT token",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Re-read the configuration file and environment and apply the log level, default limits and JWT expiration without a restart. Other settings need a restart. Sending SIGHUP to the process does the same. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
-                ],
-                "summary": "Authenticate user",
-                "parameters": [
-                    {
-                        "description": "User login information",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "Reload the configuration",
                 "responses": {
                     "200": {
-                        "description": "Authentication successful",
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -151,41 +117,48 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Invalid email or password",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/mod-packs": {
+        "/admin/disk": {
             "get": {
-                "description": "Retrieve a list of common mod packs",
+                "description": "Get the free space of the server, shared and local backup directories of the control plane, the free space each node last reported, and the reserve uploads, backups and imports must leave free (storage.disk_reserve_mb). Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "mod-packs"
-                ],
-                "summary": "Get common mod packs",
-                "parameters": [
-                    {
-                        "type": "boolean",
-                        "description": "Filter by common mod packs",
-                        "name": "common",
-                        "in": "query"
-                    }
+                    "admin"
                 ],
+                "summary": "Get disk space",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.ModPack"
-                            }
+                            "$ref": "#/definitions/server_manager.DiskStats"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
                     "500": {
@@ -195,58 +168,27 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Upload a shared mod pack to be used by multiple servers",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+            }
+        },
+        "/admin/node": {
+            "get": {
+                "description": "Get the CPU cores, memory, load average and disk of the machine running the control plane, with the memory (-Xmx) configured for and used by its servers. oversubscribed is set when the servers together may use more memory than the machine has. Servers on node agents are not counted. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "mod-packs"
-                ],
-                "summary": "Upload a shared mod pack",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Name of the mod pack",
-                        "name": "name",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Version of the mod pack",
-                        "name": "version",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Type of the mod pack (e.g., zip, folder)",
-                        "name": "type",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "The mod pack file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "Get the capacity of this machine",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ModPack"
+                            "$ref": "#/definitions/server_manager.HostStats"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -260,26 +202,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/servers": {
+        "/admin/nodes": {
             "get": {
-                "description": "Get a list of all Minecraft servers",
+                "description": "Get the registered nodes with their last reported capacity, online state and the memory allocated to their servers. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "admin"
                 ],
-                "summary": "List all Minecraft servers",
+                "summary": "List nodes",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Server"
+                                "$ref": "#/definitions/model.Node"
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -289,7 +237,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new Minecraft server with specified jar file and additional files",
+                "description": "Register a machine that runs servers through a node agent. Start the agent with the returned token; new servers are scheduled onto the online node with the most free memory. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -297,54 +245,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "admin"
                 ],
-                "summary": "Create a new Minecraft server",
+                "summary": "Register a node",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Server Name",
-                        "name": "name",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Executable Command",
-                        "name": "executable_command",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "JAR File ID",
-                        "name": "jar_file_id",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "file",
-                        "description": "JAR File",
-                        "name": "jar_file",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Mod Pack ID",
-                        "name": "mod_pack_id",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "file",
-                        "description": "Mod Pack File",
-                        "name": "mod_pack",
-                        "in": "formData"
+                        "description": "Node",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateNodeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Server"
+                            "$ref": "#/definitions/handlers.CreateNodeResponse"
                         }
                     },
                     "400": {
@@ -353,8 +272,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -362,20 +281,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/servers/{id}": {
-            "get": {
-                "description": "Get details of a specific Minecraft server by name",
+        "/admin/nodes/{id}": {
+            "delete": {
+                "description": "Remove a node and revoke its token. Nodes with servers cannot be removed. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "admin"
                 ],
-                "summary": "Get a specific Minecraft server",
+                "summary": "Delete a node",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Server ID",
+                        "description": "Node ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -385,7 +304,16 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Server"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
                     "404": {
@@ -394,44 +322,34 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a specific Minecraft server by name",
+            }
+        },
+        "/admin/reconcile": {
+            "get": {
+                "description": "List directories in the servers directory that belong to no server, and servers whose directory is missing. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
-                ],
-                "summary": "Delete a Minecraft server",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Server ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "Compare servers with the disk",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/server_manager.ReconcileReport"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -443,11 +361,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/servers/{id}/command": {
+            },
             "post": {
-                "description": "Send a command to a specific Minecraft server by name",
+                "description": "Optionally adopt orphan directories as servers owned by the caller and soft delete servers whose directory is missing, then return the report. Orphan directories are never removed. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -455,27 +371,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "admin"
                 ],
-                "summary": "Send a command to a Minecraft server",
+                "summary": "Repair differences between servers and the disk",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Server ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Command to send",
-                        "name": "command",
+                        "description": "Repairs to perform",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.ReconcileRequest"
                         }
                     }
                 ],
@@ -483,10 +389,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/server_manager.ReconcileReport"
                         }
                     },
                     "400": {
@@ -495,8 +398,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -510,43 +413,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/servers/{id}/output": {
+        "/admin/users": {
             "get": {
-                "description": "Retrieve the output stream of a specific Minecraft server",
+                "description": "Get all users with their roles. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
-                ],
-                "summary": "Get server output",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Server ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "List users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.User"
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -554,62 +442,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/servers/{id}/output/ws": {
-            "get": {
-                "description": "Establish a WebSocket connection to receive real-time server output",
-                "tags": [
-                    "servers"
-                ],
-                "summary": "Get server output via WebSocket",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Server ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+        "/admin/users/{id}/quota": {
+            "put": {
+                "description": "Set the server count, allocated RAM and disk quotas of a user, for example to match a hosting plan. Null fields use the configured limits. Admin only.",
+                "consumes": [
+                    "application/json"
                 ],
-                "responses": {}
-            }
-        },
-        "/servers/{id}/restart": {
-            "post": {
-                "description": "Restart a specific Minecraft server by name",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "admin"
                 ],
-                "summary": "Restart a Minecraft server",
+                "summary": "Change a user's quotas",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Server ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Quotas",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetUserQuotaRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/model.User"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -617,9 +501,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/servers/{id}/start": {
-            "post": {
-                "description": "Start a specific Minecraft server by name with customizable RAM and port",
+        "/admin/users/{id}/role": {
+            "put": {
+                "description": "Make a user a viewer, operator or admin. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -627,24 +511,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "admin"
                 ],
-                "summary": "Start a Minecraft server",
+                "summary": "Change a user's role",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Server ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "RAM and Port",
-                        "name": "StartServerRequest",
+                        "description": "Role",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.StartServerRequest"
+                            "$ref": "#/definitions/handlers.SetUserRoleRequest"
                         }
                     }
                 ],
@@ -652,20 +536,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/model.User"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -673,40 +560,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/servers/{id}/stop": {
-            "post": {
-                "description": "Stop a specific Minecraft server by name",
+        "/alerts": {
+            "get": {
+                "description": "Get the most recent alerts of the current user's rules, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "alerts"
                 ],
-                "summary": "Stop a Minecraft server",
+                "summary": "List alerts",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Server ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only alerts of this server",
+                        "name": "server_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "firing to get only the alerts that have not resolved",
+                        "name": "state",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Alert"
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/alerts/channels": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "List alert channels",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AlertChannel"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -715,45 +629,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/servers/{name}/upload-modpack": {
+            },
             "post": {
-                "description": "Upload a mod pack to a specific server, either selecting a common mod pack or uploading a new one",
+                "description": "Register where alert notifications are sent: an email address, a Discord webhook URL, or a URL that receives the rule and alert as a JSON POST",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "alerts"
                 ],
-                "summary": "Upload mod pack for a server",
+                "summary": "Create an alert channel",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Server Name",
-                        "name": "name",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Mod pack file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "description": "Channel",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateAlertChannelRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Mod pack uploaded successfully",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/model.AlertChannel"
                         }
                     },
                     "400": {
@@ -761,62 +665,32 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/servers/{serverId}/upload-jar": {
-            "post": {
-                "description": "Upload a JAR file to a specific server, either selecting a common JAR or uploading a new one",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+        "/alerts/channels/{id}": {
+            "delete": {
+                "description": "Delete an alert channel that no alert rule uses",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "servers"
+                    "alerts"
                 ],
-                "summary": "Upload JAR file for a server",
+                "summary": "Delete an alert channel",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Server Name",
-                        "name": "name",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Version of the JAR file",
-                        "name": "version",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Server ID",
-                        "name": "serverID",
+                        "type": "integer",
+                        "description": "Channel ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "JAR file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "JAR file uploaded successfully",
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -824,14 +698,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -839,9 +713,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/signup": {
+        "/alerts/rules": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "List alert rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AlertRule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Create a new user account",
+                "description": "Create a rule the metrics sampler evaluates every minute: server_down (crashed, or an auto-start server not running), tps_below, cpu_above (percent) or disk_above (percent of the server's filesystem). It fires once the condition has held for the given duration and resolves when it clears, notifying its channels both times.",
                 "consumes": [
                     "application/json"
                 ],
@@ -849,23 +749,64 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "alerts"
                 ],
-                "summary": "Register a new user",
+                "summary": "Create an alert rule",
                 "parameters": [
                     {
-                        "description": "User signup information",
+                        "description": "Rule",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SignupRequest"
+                            "$ref": "#/definitions/handlers.CreateAlertRuleRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "User created successfully",
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.AlertRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts/rules/{id}": {
+            "delete": {
+                "description": "Delete an alert rule and its alert history",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Delete an alert rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -873,160 +814,10068 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid request payload or user creation error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/artifacts/gc": {
+            "post": {
+                "description": "Report files in the shared jar and mod pack directories that no record references, optionally removing them. Files written within the last hour are skipped, as they may belong to uploads in flight.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifacts"
+                ],
+                "summary": "Garbage collect shared artifacts",
+                "parameters": [
+                    {
+                        "description": "Set remove to delete unreferenced files",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ArtifactGCRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.GCReport"
                         }
                     },
                     "500": {
-                        "description": "Error processing password",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/providers": {
+            "get": {
+                "description": "Get the names of the configured OAuth login providers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "List OAuth providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/{provider}/callback": {
+            "get": {
+                "description": "Called by the provider after consent. Logs in the user linked to the provider account, links it to the account that started a linking flow, or creates a new account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Complete an OAuth login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/{provider}/login": {
+            "get": {
+                "description": "Redirect to the provider's consent page. The provider returns to the callback, which responds with a token like /login. A first login creates an account; to add a provider to an existing account use POST /me/identities/{provider} instead.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in with an OAuth provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/backups/{id}": {
+            "get": {
+                "description": "Get the status and size of a backup",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Get a backup",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Backup ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Backup"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
                 }
+            },
+            "delete": {
+                "description": "Delete a backup and its archive",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Delete a backup",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Backup ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/backups/{id}/download": {
+            "get": {
+                "description": "Stream the tar.gz archive of a backup. Range requests are supported for full backups so large downloads can be resumed.",
+                "produces": [
+                    "application/gzip"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Download a backup archive",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Backup ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/backups/{id}/restore": {
+            "post": {
+                "description": "Stop the server and replace its directory with the contents of the backup. Refused with 409 while a backup of the server is in progress.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Restore a backup",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Backup ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/backups/{id}/servers": {
+            "post": {
+                "description": "Create a new server from a backup, reusing the jar, mod pack and command of the backed up server and allocating a free port. The original server is left untouched.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Restore a backup into a new server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Backup ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New server name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RestoreBackupAsServerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/ws": {
+            "get": {
+                "description": "Establish a WebSocket connection that receives a JSON message for every start, stop, crash, backup and player event of the current user's servers and the servers shared with their teams. Team memberships are read when the connection opens.",
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream events via WebSocket",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/model.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/jar-files": {
+            "get": {
+                "description": "Retrieve a list of common JAR files",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jar-files"
+                ],
+                "summary": "Get common JAR files",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Filter by common JAR files",
+                        "name": "common",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.JarFile"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Upload a shared JAR file to be used by multiple servers",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jar-files"
+                ],
+                "summary": "Upload a shared JAR file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nickname of the JAR file",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version of the JAR file",
+                        "name": "version",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "The JAR file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to retry the request with without repeating it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.JarFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jar-files/import": {
+            "post": {
+                "description": "Download a jar file from a public http(s) URL and register it as a shared jar file. The download must be a jar of at most 2 GB and match the checksum if one is given. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jar-files"
+                ],
+                "summary": "Import a shared JAR file from a URL",
+                "parameters": [
+                    {
+                        "description": "Download URL and jar details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportJarFileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.JarFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jar-files/{id}": {
+            "delete": {
+                "description": "Delete a JAR file and its file on disk. Refused while a server or template still uses it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jar-files"
+                ],
+                "summary": "Delete a shared JAR file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "JAR file ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Authenticate a user and get a JWT token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Authenticate user",
+                "parameters": [
+                    {
+                        "description": "User login information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Authentication successful",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid username or password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/email": {
+            "put": {
+                "description": "Set the email address of the current user and mail a verification link to it. The address is used for password resets once verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Change the email address",
+                "parameters": [
+                    {
+                        "description": "Email address",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/identities": {
+            "get": {
+                "description": "Get the provider accounts linked to the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "List linked OAuth providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.UserIdentity"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/identities/{provider}": {
+            "post": {
+                "description": "Start an OAuth flow that links the provider account to the current user, so it can be used to log in. Open the returned URL in the browser.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Link an OAuth provider to the current account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a provider account from the current user. The last way to log in cannot be removed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Unlink an OAuth provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions": {
+            "get": {
+                "description": "List the current user's active sessions with the address and user agent they were created from. The session of the calling token is marked as current.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "List active sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Session"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Log out every session of the current user except the one making the request",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Revoke all other sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions/{id}": {
+            "delete": {
+                "description": "Log out one of the current user's sessions; its token stops working immediately",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Revoke a session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/usage": {
+            "get": {
+                "description": "Summarize servers, allocated RAM, disk, backups and API calls against the user's limits. A limit of 0 means unlimited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get resource usage of the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Usage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mod-packs": {
+            "get": {
+                "description": "Retrieve a list of common mod packs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mod-packs"
+                ],
+                "summary": "Get common mod packs",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Filter by common mod packs",
+                        "name": "common",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ModPack"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Upload a shared mod pack to be used by multiple servers. Mod packs with the same name are versions of one pack that servers can upgrade between.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mod-packs"
+                ],
+                "summary": "Upload a shared mod pack",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the mod pack, shared by its versions",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version of the mod pack",
+                        "name": "version",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of the mod pack (e.g., zip, folder)",
+                        "name": "type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "The mod pack file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to retry the request with without repeating it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ModPack"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mod-packs/import": {
+            "post": {
+                "description": "Download a mod pack from a public http(s) URL and register it as a shared mod pack, named after the downloaded file unless a name is given. The download must be a zip of at most 2 GB and match the checksum if one is given. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mod-packs"
+                ],
+                "summary": "Import a shared mod pack from a URL",
+                "parameters": [
+                    {
+                        "description": "Download URL",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportModPackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ModPack"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mod-packs/{id}": {
+            "delete": {
+                "description": "Delete a mod pack and its files on disk. Refused while a server or template still uses it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mod-packs"
+                ],
+                "summary": "Delete a shared mod pack",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mod pack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mod-packs/{id}/apply": {
+            "get": {
+                "description": "Get the most recent apply of a mod pack by the current user with a result for each server handled so far",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mod-packs"
+                ],
+                "summary": "Get the progress of a mod pack apply",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mod pack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ModPackApply"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Start re-provisioning a shared mod pack into every server of the current user that uses it, then reloading or restarting the running ones. Servers are handled in the background; poll GET /mod-packs/{id}/apply for a result per server. Reload is refused when a server runs Forge, NeoForge, Fabric or Quilt, which cannot reload mods.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mod-packs"
+                ],
+                "summary": "Apply a mod pack to the servers using it",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mod pack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Apply strategy",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ApplyModPackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ModPackApply"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password-reset": {
+            "post": {
+                "description": "Mail a password reset link to the verified email address of an account. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PasswordResetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password-reset/confirm": {
+            "post": {
+                "description": "Set a new password with the token from a password reset email. Each token works once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Set a new password",
+                "parameters": [
+                    {
+                        "description": "Token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConfirmPasswordResetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/players/{player}": {
+            "get": {
+                "description": "Get the UUID, current name and skin of a Minecraft account by name or UUID. Lookups are cached for an hour; a name that has changed hands resolves to its current holder.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Look up a player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player name or UUID",
+                        "name": "player",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mojang.Profile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/public/servers/{token}/status": {
+            "get": {
+                "description": "Report whether a server is online with its MOTD, player count, version and uptime. Needs no authentication, only the token of a server whose owner published its status, and may be fetched from any website.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "Get the public status of a server",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Public status token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.PublicStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers": {
+            "get": {
+                "description": "Get the servers of the current user with their config, jar file and mod pack. Without per_page all matching servers are returned. The total number of matches is sent in the X-Total-Count header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "List all Minecraft servers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Servers per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (stopped, queued, starting, running, crashed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search server and tag names",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id, name, status, created_at or updated_at; prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Server"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "Total number of matching servers"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new Minecraft server with specified jar file and additional files",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Create a new Minecraft server",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Server Name",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Executable Command",
+                        "name": "executable_command",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "JAR File ID",
+                        "name": "jar_file_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "JAR File",
+                        "name": "jar_file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Mod Pack ID",
+                        "name": "mod_pack_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Mod Pack File",
+                        "name": "mod_pack",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label to register in the DNS zone, e.g. smp for smp.example.com",
+                        "name": "dns_name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA zone the server logs in, e.g. Europe/Berlin; defaults to the zone of the host",
+                        "name": "timezone",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated IDs of additional files to attach, e.g. 3,7",
+                        "name": "additional_file_ids",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to retry the request with without repeating it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/batch": {
+            "post": {
+                "description": "Apply an action (start, stop or restart) to a list of servers concurrently. The response reports the outcome per server; a failure on one server does not affect the others.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Start, stop or restart several servers",
+                "parameters": [
+                    {
+                        "description": "Servers and action",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server_manager.BatchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/deleted": {
+            "get": {
+                "description": "Get the deleted servers of the current user that can still be restored",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "List deleted servers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Server"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/import": {
+            "post": {
+                "description": "Register a server directory that already exists on disk as a managed server without copying data. The jar and start command are detected unless given. The path must be below one of the configured storage.import_roots.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Import an existing server directory",
+                "parameters": [
+                    {
+                        "description": "Directory to import",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportServerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/import-bundle": {
+            "post": {
+                "description": "Create a new server from a bundle produced by the export endpoint of this or another instance",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Import a server from an export bundle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Server Name",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Export bundle",
+                        "name": "bundle",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}": {
+            "get": {
+                "description": "Get details of a specific Minecraft server, including its config with the jar file and mod pack",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get a specific Minecraft server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ServerDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop a server and mark it deleted. It can be restored until it is purged after storage.deleted_server_retention_days. With purge_files=true its directory, backups and config are removed immediately instead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Delete a Minecraft server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove files and records immediately",
+                        "name": "purge_files",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Rename a server (moving its directory), change its command, reassign its jar or mod pack, toggle auto start, edit its description and notes, or set the timezone its console timestamps are read in. Renaming stops the server and moves its directory, copying it across volumes; jar or mod pack changes require the server to be stopped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Update a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateServerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/additional-files": {
+            "get": {
+                "description": "Get the additional files attached to a server",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "List the additional files of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AdditionalFile"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Attach an additional file to a server and place it by its type: config files are copied into the server root, plugins linked into plugins/, mods into mods/ and datapacks into the datapacks directory of the world. A running server picks the file up on its next start. Mods cannot be attached to a server whose mods come from a mod pack.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Attach an additional file to a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Additional file",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AttachAdditionalFileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.AdditionalFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/additional-files/{file_id}": {
+            "delete": {
+                "description": "Detach an additional file from a server and remove it from the server directory. Config files are left in place since the server may have changed them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Detach an additional file from a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Additional file ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/addons": {
+            "get": {
+                "description": "Get the plugins the manager installed into a server",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "addons"
+                ],
+                "summary": "List the addons of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Addon"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/addons/{addon_id}": {
+            "delete": {
+                "description": "Delete the plugin jar of an addon from a stopped server. Its configuration is kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "addons"
+                ],
+                "summary": "Remove an addon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Addon ID",
+                        "name": "addon_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/announcements": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "announcements"
+                ],
+                "summary": "List the announcements of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Announcement"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Broadcast a message to the players of a server on a cron schedule or at an interval of at least a minute, with say or tellraw. Announcements due while the server is stopped are skipped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "announcements"
+                ],
+                "summary": "Schedule an announcement",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Announcement",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AnnouncementRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Announcement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/announcements/{announcement_id}": {
+            "put": {
+                "description": "Replace the message and schedule of an announcement",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "announcements"
+                ],
+                "summary": "Update an announcement",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Announcement ID",
+                        "name": "announcement_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Announcement",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AnnouncementRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Announcement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "announcements"
+                ],
+                "summary": "Delete an announcement",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Announcement ID",
+                        "name": "announcement_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/archive": {
+            "post": {
+                "description": "Stop a server, compress its directory into cold storage (the configured backup storage, or the local backups volume) and delete the directory to free disk. The server cannot be started, backed up or cloned until it is thawed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Archive a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/audit-logs": {
+            "get": {
+                "description": "Get state-changing requests made against a server, newest first. The total number of matches is sent in the X-Total-Count header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "List the audit log of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries per page (default 50, at most 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action substring, e.g. /start",
+                        "name": "action",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AuditLog"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "Total number of matching entries"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/backup-schedule": {
+            "get": {
+                "description": "Get the schedule, retention rules and outcome of the last scheduled run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Get the backup schedule of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BackupSchedule"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or replace the cron schedule scheduled backups run on. After each run, scheduled backups not kept by keep_last or by keep_daily_days (the newest backup of each day) are pruned. Manual backups are never pruned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Set the backup schedule of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule and retention",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BackupScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BackupSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop scheduled backups. Existing backups are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Delete the backup schedule of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/backups": {
+            "get": {
+                "description": "Get all backups of a server, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "List backups of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Backup"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Archive the server directory. Running servers have saving paused and the world flushed while the archive is written. The backup runs asynchronously. Incremental backups only store files changed since the previous incremental backup and restore like full ones.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backups"
+                ],
+                "summary": "Back up a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Backup name",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateBackupRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to retry the request with without repeating it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.Backup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/bans": {
+            "get": {
+                "description": "Get the entries of the server's banned-players.json with each player's current name and skin. banned_as holds the name a player was banned under when they have been renamed since; pardon them by their current name.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "List banned players",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server_manager.Ban"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/clone": {
+            "post": {
+                "description": "Duplicate a server's directory and config into a new server with its own name and port, optionally without its worlds",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Clone a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Clone options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CloneServerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/command": {
+            "post": {
+                "description": "Send a command to a specific Minecraft server by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Send a command to a Minecraft server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Command to send",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/config": {
+            "get": {
+                "description": "Get everything that decides how a server starts: its executable command, jar, mod pack, environment variables, memory settings read from the command, restart policy and placeholder settings",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get the config of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ServerConfigDocument"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the config of a server in one document. Memory sizes are written into the -Xms and -Xmx flags of the executable command. Servers with the on-failure restart policy are started again after a crash, at most max_restarts times in ten minutes. With the placeholder enabled, a lightweight listener answers server list pings on the port of the stopped server with the placeholder MOTD, and with start_on_join a player trying to join starts the server and is told when to try again. Such on-demand servers are stopped again after idle_shutdown_minutes without players. Jar or mod pack changes require the server to be stopped; placeholder settings apply right away and everything else on the next start.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Replace the config of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Server config",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ServerConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ServerConfigDocument"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/crashes": {
+            "get": {
+                "description": "Get the runs of a server that exited with an error, newest first, with the diagnosis and the crash reports each run wrote. The output of a crash is returned by GET /servers/{id}/crashes/{crash_id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "List the crashes of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Crash"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/crashes/{crash_id}": {
+            "get": {
+                "description": "Get a crash with the stderr and the last console lines of the run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get a crash of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Crash ID",
+                        "name": "crash_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Crash"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/crashes/{crash_id}/reports/{report_id}": {
+            "get": {
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Download a crash report",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Crash ID",
+                        "name": "crash_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Report ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/dns": {
+            "put": {
+                "description": "Create an A record for the host, if it is configured by IP address, and an SRV record for the server's port, so players can join by name. A name the server had before is removed. Records the manager did not create are never replaced; a name that exists in the zone is refused.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Register a DNS name for a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "DNS name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterDNSRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Remove the DNS name of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/export": {
+            "get": {
+                "description": "Stream a tar.gz bundle with the server directory (world, configs, jar and mods resolved from shared storage) and an mcgonalds.json with metadata and a mod manifest. The bundle can be imported on another instance.",
+                "produces": [
+                    "application/gzip"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Export a server as a portable bundle",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/gameplay": {
+            "patch": {
+                "description": "Apply gameplay settings with the difficulty, defaultgamemode and gamerule commands while the server runs, or by editing server.properties and the level.dat of its world while it is stopped. pvp takes effect on the next start. Game rules are typed: boolean rules take true or false, the others integers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Change difficulty, game mode, pvp and game rules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Settings to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GameplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.GameplayResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/geyser": {
+            "post": {
+                "description": "Download the latest Geyser and Floodgate into a stopped server and configure Geyser on a free UDP port, with Floodgate authenticating Bedrock players. Calling it again updates both plugins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "addons"
+                ],
+                "summary": "Set up Bedrock cross-play",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.GeyserInstall"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/grants": {
+            "get": {
+                "description": "Get the users granted permissions on a server. Only the owner and the managers and owners of the server's team can see them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "List server grants",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ServerGrant"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Give a user console, files, power and/or backups permissions on one server, replacing any earlier grant. Every grant includes read access to the server's status, stats and metrics.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Grant a user permissions on a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Grant",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GrantServerAccessRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerGrant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/grants/{user_id}": {
+            "delete": {
+                "description": "Remove a user's grant on a server. Users can always remove their own grant.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Revoke a user's permissions on a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/icon": {
+            "get": {
+                "description": "Download the server's server-icon.png",
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get a server icon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Upload a 64x64 PNG as the server's server-icon.png. Players see it in the server list after the next start.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Upload a server icon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "64x64 PNG",
+                        "name": "icon",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove the server's server-icon.png",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Delete a server icon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/install": {
+            "get": {
+                "description": "Report whether the most recent loader install is running, succeeded or failed, with the launch command it produced",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get the latest loader install of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.LoaderInstall"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Start the Forge/NeoForge installer attached as the server jar, or download and start the Fabric installer for the given Minecraft version, as the server's user. The server must be stopped and stays locked until the installer is done, after which the executable command is updated to the produced launch arguments. Poll GET /servers/{id}/install for the outcome.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Install a mod loader into a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Loader to install (detected from the jar when omitted)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.InstallLoaderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.LoaderInstall"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/jar": {
+            "get": {
+                "description": "Report whether the most recent jar swap is pending, confirmed or rolled back",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get the latest jar swap of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.JarSwap"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Snapshot the directory of a stopped server and switch to another jar. The change is rolled back if the next start does not reach \"Done\". Refused with 409 while the server is running or a backup is in progress.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Replace the jar of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New jar file",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwapJarRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.JarSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/maintenance": {
+            "get": {
+                "description": "Get the past, current and upcoming maintenance windows of a server. active is set on the window in effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "List the maintenance windows of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MaintenanceWindow"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Schedule a period during which the server is stopped, with its port answering pings with the message and turning players away, or limited to whitelisted players and operators. A stopped server that was running is started again when the window ends. Only admins can start, stop or restart the server during the window. Windows of a server cannot overlap. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "Schedule a maintenance window",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Maintenance window",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MaintenanceWindowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.MaintenanceWindow"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/maintenance/{window_id}": {
+            "put": {
+                "description": "Replace the times, mode and message of a maintenance window. Once the window has begun only its end and message can change. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "Reschedule a maintenance window",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maintenance window ID",
+                        "name": "window_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Maintenance window",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MaintenanceWindowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MaintenanceWindow"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a maintenance window. A window in effect is lifted first, as if it had ended. Admin only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "Delete a maintenance window",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maintenance window ID",
+                        "name": "window_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/metrics": {
+            "get": {
+                "description": "Get a time series of cpu, memory, players or tps. Samples are taken every minute while the server runs and averaged hourly after a day. TPS is only recorded for servers that report it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get metric history of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "cpu, memory, players or tps",
+                        "name": "metric",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "How far back to go, e.g. 6h or 7d (default 24h, at most 30d)",
+                        "name": "range",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MetricPoint"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/modpack/upgrade": {
+            "get": {
+                "description": "Report whether the most recent mod pack upgrade is pending, confirmed or rolled back, with the mods it added and removed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get the latest mod pack upgrade of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ModPackUpgrade"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Move a server to another version of its mod pack, the most recently uploaded one unless mod_pack_id is given. Versions of a pack share its name. The config directory is snapshotted, mods are relinked to the new version and a running server is restarted. The response lists the mods added and removed. If the next start does not reach \"Done\" the previous version and configs are restored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Upgrade the mod pack of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Mod pack version",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpgradeModPackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ModPackUpgrade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/mods": {
+            "post": {
+                "description": "Upload any number of mod jars, or zip files whose jars are extracted, into the mods directory of a server. Mods with the name of an existing mod replace it. Each mod succeeds or fails on its own. Servers whose mods come from a mod pack cannot take uploads.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Upload mods to a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Mod jars or zip files of mod jars; repeat the field for every file",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to retry the request with without repeating it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadModsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/mods/validate": {
+            "get": {
+                "description": "Read the metadata of every mod in the mods directory of a server and list what is likely to keep it from starting: missing dependencies such as Fabric API, mods that declare each other incompatible, mods installed twice, and mods for another loader or Minecraft version. The same checks run as a warning before every start.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Check the mods of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ModValidation"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/offline-mode": {
+            "post": {
+                "description": "Servers with online-mode=false refuse to start until the owner explicitly acknowledges that unauthenticated players can join",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Acknowledge offline mode for a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Acknowledgement",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OfflineModeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/output": {
+            "get": {
+                "description": "Retrieve the most recent console lines of a Minecraft server, up to 1000, as one string",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get server output",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/output/ws": {
+            "get": {
+                "description": "Establish a WebSocket connection to receive real-time server output. Each message holds one or more lines. The most recent lines are replayed first; a client too slow to keep up receives a note of how many lines it skipped. Browsers, which cannot set the Authorization header, pass the token as the subprotocols [\"bearer\", token] or as token query parameter.",
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get server output via WebSocket",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of recent lines to replay (default 100)",
+                        "name": "backlog",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/servers/{id}/players": {
+            "get": {
+                "description": "Get the players online on a server, tracked from join and leave messages since it started",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "List online players",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Kick, ban, pardon, op, deop or whisper to a player through the server console. The server must be running.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Manage a player",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Action",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PlayerActionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/players/profiles": {
+            "get": {
+                "description": "Get the players online on a server with their UUIDs and skins from Mojang, cached for an hour. Offline mode servers get the UUIDs the server derives from the names and no skins. Players Mojang cannot resolve are listed by name only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "List online players with their profiles",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/mojang.Profile"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/preflight": {
+            "get": {
+                "description": "Run the checks performed before a server starts: jar, EULA, port, Java version and free disk space. Failed checks prevent starting; warnings do not.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Check whether a server can start",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.PreflightReport"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/pregen": {
+            "get": {
+                "description": "Get the most recent pre-generation tasks of a server with their state and progress, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pregen"
+                ],
+                "summary": "List the pre-generation tasks of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.PregenTask"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Run a Chunky task generating the chunks within radius blocks of a center on a running server with the Chunky plugin or mod. Progress is read from the console. Unless pause_for_players is false, the task pauses while players are online and continues once the server is empty.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pregen"
+                ],
+                "summary": "Start pre-generating a world",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Task",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StartPregenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.PregenTask"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/pregen/{action}": {
+            "post": {
+                "description": "Pause, resume or cancel the active pre-generation task of a server. Tasks of a server that stopped are paused and can be resumed after it starts again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pregen"
+                ],
+                "summary": "Pause, resume or cancel pre-generation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pause, resume or cancel",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PregenTask"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/proxy": {
+            "put": {
+                "description": "Mark a server that runs Velocity or BungeeCord as proxy, so other servers can be attached to it, and generate its forwarding secret. An empty type turns it back into a game server once no servers are attached.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "Turn a server into a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Proxy type",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetProxyTypeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/proxy/autoscale": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "Get the scaling policy of a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Proxy server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ScalingPolicy"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or replace the policy the autoscaler applies to the servers behind a proxy. It keeps at least min_instances running, starts another instance from the template once there are scale_up_players players per running instance, up to max_instances, and stops instances that have been empty for idle_minutes. New instances are created next to the proxy, shared with its team and attached to it. Scaling steps appear in the activity feed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "Set the scaling policy of a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Proxy server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Scaling policy",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ScalingPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ScalingPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop autoscaling the servers behind a proxy. Instances created by the autoscaler are kept as they are.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "Delete the scaling policy of a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Proxy server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/proxy/backends": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "List the servers attached to a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Proxy server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Server"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a server to the proxy's server list and make it accept the players the proxy forwards: Velocity modern forwarding in config/paper-global.yml, or bungeecord in spigot.yml, with online-mode=false. Both servers pick up the change on their next start.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "Attach a server to a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Proxy server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Backend",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AttachProxyBackendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/proxy/backends/{server_id}": {
+            "delete": {
+                "description": "Remove a server from the proxy's server list and turn its proxy forwarding off and online-mode back on",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "Detach a server from a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Proxy server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Backend server ID",
+                        "name": "server_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/proxy/secret": {
+            "post": {
+                "description": "Generate a new forwarding secret and write it to the proxy and all attached servers. Restart them to use it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proxies"
+                ],
+                "summary": "Rotate the forwarding secret of a proxy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Proxy server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/public-status": {
+            "put": {
+                "description": "Opt a server into the unauthenticated status endpoint, which reports its MOTD, player count, version and uptime to anyone with the returned token, e.g. for a community website. Enabling it again returns the same token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Publish the status of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PublicStatusResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Take a server off the unauthenticated status endpoint. Its token stops working; publishing it again issues a new one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Stop publishing the status of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/restart": {
+            "post": {
+                "description": "Restart a specific Minecraft server by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Restart a Minecraft server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/restore": {
+            "post": {
+                "description": "Bring back a deleted server before the purge job removes it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Restore a deleted server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/start": {
+            "post": {
+                "description": "Start a specific Minecraft server by name with customizable RAM and port. When the process cannot be started the error has the code start_failed and a diagnosis of the likely causes; failures after the process started are diagnosed in the last_failure of the server details and in its crash records.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Start a Minecraft server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "RAM and Port",
+                        "name": "StartServerRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StartServerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "Queued behind other starts (server.max_concurrent_starts)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/stats": {
+            "get": {
+                "description": "Get CPU, resident memory and uptime of the server process, and the disk space used by the server directory. CPU is averaged since the previous request; 100 percent is one full core.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Get live resource usage of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.ServerStats"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/stop": {
+            "post": {
+                "description": "Stop a specific Minecraft server by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Stop a Minecraft server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/tags": {
+            "put": {
+                "description": "Replace the tags of a server. Tags are created on first use and normalized to lower case.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Set the tags of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetServerTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Tag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/team": {
+            "put": {
+                "description": "Give the members of a team access to a server, or stop sharing it with a null team_id. Team managers and owners hold every permission on it, plain members every one but manage. Only the server's owner can change this, and only to a team they belong to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Share a server with a team",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Team",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetServerTeamRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/thaw": {
+            "post": {
+                "description": "Restore the directory of an archived server from cold storage and delete the archive. The server is left stopped.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Thaw an archived server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/upload-jar": {
+            "post": {
+                "description": "Upload a JAR file to a specific server, either selecting a common JAR or uploading a new one",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Upload JAR file for a server",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Server Name",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version of the JAR file",
+                        "name": "version",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "JAR file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to retry the request with without repeating it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "JAR file uploaded successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/upload-modpack": {
+            "post": {
+                "description": "Upload a mod pack to a specific server, either selecting a common mod pack or uploading a new one",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Upload mod pack for a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Mod pack file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the mod pack, shared by its versions; defaults to the file name",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version of the mod pack",
+                        "name": "version",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to retry the request with without repeating it",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Mod pack uploaded successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/servers/{id}/world": {
+            "delete": {
+                "description": "Delete the world directories of a stopped server so a new world is generated on the next start. Refused with 409, naming the conflicting operation, while the server is running or a backup, restore or jar swap is in progress.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "Delete the world of a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeleteWorldResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Create a new user account. Usernames listed as admins in the configuration are reserved and can only be created with the admin create-user command.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User signup information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SignupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload, reserved username or user creation error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Error processing password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "description": "Get the tags in use on the current user's servers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "servers"
+                ],
+                "summary": "List tags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Tag"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams": {
+            "get": {
+                "description": "Get the teams of the current user with their role, including pending invitations",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "List teams",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Membership"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a team with the current user as its owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Create a team",
+                "parameters": [
+                    {
+                        "description": "Team",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateTeamRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Team"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/accept": {
+            "post": {
+                "description": "Join a team the current user was invited to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Accept a team invitation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Membership"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/invitations": {
+            "post": {
+                "description": "Invite a user by name. The invitation takes effect once the user accepts it. Owners and managers can invite; only owners can invite owners.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Invite a user to a team",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Invitation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.InviteTeamMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Membership"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/members": {
+            "get": {
+                "description": "Get the members and pending invitations of a team the current user belongs to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "List team members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Membership"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/{id}/members/{user_id}": {
+            "put": {
+                "description": "Make a member an owner, manager or member. Managers can only assign member and manager; a team always keeps one owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Change a team member's role",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetTeamMemberRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Membership"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a member or withdraw an invitation. Members can always remove themselves to leave the team.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "teams"
+                ],
+                "summary": "Remove a team member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Team ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates": {
+            "get": {
+                "description": "Get all server templates",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "List server templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Template"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Define a reusable blueprint of jar, mod pack, server.properties, memory and additional files",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Create a server template",
+                "parameters": [
+                    {
+                        "description": "Template definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Template"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}": {
+            "get": {
+                "description": "Get a server template by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Get a server template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Template"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a server template by ID. Servers created from it are unaffected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Delete a server template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}/servers": {
+            "post": {
+                "description": "Create a new Minecraft server using the jar, mod pack, command and server.properties of a template. The placeholders of the properties are rendered with the given variables and their defaults; {{port}} gets a free port.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Create a server from a template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Server name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateServerFromTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "description": "Create a session to follow an upload with. Pass its ID as the upload_id query parameter of a jar or mod pack upload and watch /uploads/{id}/ws for the bytes received, an ETA and whether the upload is being validated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Start an upload session",
+                "parameters": [
+                    {
+                        "description": "Upload size",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.UploadProgress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Get the progress of an upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.UploadProgress"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}/ws": {
+            "get": {
+                "description": "Establish a WebSocket connection that receives the progress of an upload as JSON whenever it changes, at most four times a second. The connection is closed once the upload is complete or failed.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Watch the progress of an upload via WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/server_manager.UploadProgress"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/limits": {
+            "get": {
+                "description": "Report the user's quotas for servers, allocated RAM, disk, backups and API calls with what is used and what remains. Quotas set on the user override the configured defaults; remaining is omitted for unlimited resources.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get remaining quota of the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Usage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify-email": {
+            "post": {
+                "description": "Confirm an email address with the token from a verification email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Verify an email address",
+                "parameters": [
+                    {
+                        "description": "Token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Get the webhooks of the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Webhook"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a URL that receives a JSON POST for server lifecycle, backup and player events. Each request carries an X-Mcgonalds-Signature header of the form sha256=\u003chex HMAC-SHA256 of the body keyed with the secret\u003e. Failed deliveries are retried with backoff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "description": "Delete a webhook and its delivery history",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Get the most recent deliveries of a webhook with their attempts and outcome",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "crash.Finding": {
+            "type": "object",
+            "properties": {
+                "analyzer": {
+                    "type": "string"
+                },
+                "cause": {
+                    "type": "string"
+                },
+                "suggestion": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AnnouncementRequest": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "cron": {
+                    "description": "Cron schedules the announcement; set either cron or interval",
+                    "type": "string",
+                    "example": "0 * * * *"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "format": {
+                    "description": "Format is say (default) or tellraw, which also takes a JSON text component",
+                    "type": "string",
+                    "enum": [
+                        "say",
+                        "tellraw"
+                    ],
+                    "example": "say"
+                },
+                "interval": {
+                    "description": "Interval repeats the announcement, e.g. 30m",
+                    "type": "string",
+                    "example": "30m"
+                },
+                "message": {
+                    "type": "string",
+                    "maxLength": 4096,
+                    "example": "Join our Discord at discord.gg/example"
+                }
+            }
+        },
+        "handlers.ApplyModPackRequest": {
+            "type": "object",
+            "properties": {
+                "strategy": {
+                    "description": "Strategy is \"reload\", \"restart\" or \"next-start\" (default)",
+                    "type": "string",
+                    "example": "restart"
+                }
+            }
+        },
+        "handlers.ArtifactGCRequest": {
+            "type": "object",
+            "properties": {
+                "remove": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.AttachAdditionalFileRequest": {
+            "type": "object",
+            "required": [
+                "additional_file_id"
+            ],
+            "properties": {
+                "additional_file_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AttachProxyBackendRequest": {
+            "type": "object",
+            "required": [
+                "server_id"
+            ],
+            "properties": {
+                "address": {
+                    "description": "Address is where the proxy reaches the server, its port on localhost by default",
+                    "type": "string",
+                    "example": "127.0.0.1:25566"
+                },
+                "server_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.BackupScheduleRequest": {
+            "type": "object",
+            "required": [
+                "cron"
+            ],
+            "properties": {
+                "cron": {
+                    "type": "string",
+                    "example": "0 4 * * *"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "incremental": {
+                    "type": "boolean"
+                },
+                "keep_daily_days": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0,
+                    "example": 7
+                },
+                "keep_last": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0,
+                    "example": 3
+                }
+            }
+        },
+        "handlers.BatchRequest": {
+            "type": "object",
+            "required": [
+                "server_ids"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "start",
+                        "stop",
+                        "restart"
+                    ],
+                    "example": "restart"
+                },
+                "server_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.CloneServerRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "exclude_worlds": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "survival-staging"
+                }
+            }
+        },
+        "handlers.ConfirmPasswordResetRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateAlertChannelRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "target",
+                "type"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "ops"
+                },
+                "target": {
+                    "description": "Target is the email address or URL notifications are sent to",
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://discord.com/api/webhooks/123/abc"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "email",
+                        "discord",
+                        "webhook"
+                    ],
+                    "example": "discord"
+                }
+            }
+        },
+        "handlers.CreateAlertRuleRequest": {
+            "type": "object",
+            "required": [
+                "channel_ids",
+                "condition",
+                "name"
+            ],
+            "properties": {
+                "channel_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "condition": {
+                    "type": "string",
+                    "enum": [
+                        "server_down",
+                        "tps_below",
+                        "cpu_above",
+                        "disk_above"
+                    ],
+                    "example": "tps_below"
+                },
+                "for": {
+                    "description": "For is how long the condition must hold before the rule fires, e.g. 5m",
+                    "type": "string",
+                    "example": "5m"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Low TPS"
+                },
+                "server_id": {
+                    "description": "ServerID limits the rule to one server; omit it for all servers",
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 15
+                }
+            }
+        },
+        "handlers.CreateBackupRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "Mode is \"full\" (default) or \"incremental\"",
+                    "type": "string",
+                    "enum": [
+                        "full",
+                        "incremental"
+                    ],
+                    "example": "incremental"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "before-1.21-upgrade"
+                }
+            }
+        },
+        "handlers.CreateNodeRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "node-1"
+                }
+            }
+        },
+        "handlers.CreateNodeResponse": {
+            "type": "object",
+            "properties": {
+                "allocated_memory_mb": {
+                    "description": "AllocatedMemoryMB sums the -Xmx of the servers scheduled onto the node",
+                    "type": "integer"
+                },
+                "cpu_cores": {
+                    "description": "Capacity last reported by the agent",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "free_disk_bytes": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_seen_at": {
+                    "description": "LastSeenAt is when the agent last reported; nodes silent for long are offline",
+                    "type": "string"
+                },
+                "memory_mb": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "online": {
+                    "type": "boolean"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateServerFromTemplateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "variables": {
+                    "description": "Variables sets the template's variables and motd or max_players, typed as declared",
+                    "type": "object"
+                }
+            }
+        },
+        "handlers.CreateServerResponse": {
+            "type": "object",
+            "properties": {
+                "server": {
+                    "$ref": "#/definitions/server.ServerDetails"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.CreateTeamRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "survival-crew"
+                }
+            }
+        },
+        "handlers.CreateTemplateRequest": {
+            "type": "object",
+            "required": [
+                "jar_file_id",
+                "name"
+            ],
+            "properties": {
+                "additional_file_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "executable_command": {
+                    "type": "string"
+                },
+                "jar_file_id": {
+                    "type": "integer"
+                },
+                "memory_mb": {
+                    "type": "integer",
+                    "maximum": 1048576,
+                    "minimum": 0,
+                    "example": 4096
+                },
+                "mod_pack_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "paper-1.21"
+                },
+                "properties": {
+                    "description": "Properties is the server.properties of created servers. It can use the\nplaceholders {{port}}, {{name}}, {{motd}}, {{max_players}} and those of\nthe declared variables.",
+                    "type": "string",
+                    "example": "server-port={{port}}\nmotd={{motd}}\nmax-players={{max_players}}"
+                },
+                "variables": {
+                    "type": "array",
+                    "maxItems": 64,
+                    "items": {
+                        "$ref": "#/definitions/model.TemplateVariable"
+                    }
+                }
+            }
+        },
+        "handlers.CreateUploadRequest": {
+            "type": "object",
+            "properties": {
+                "size": {
+                    "description": "Size is the size of the upload request in bytes, if known",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 524288000
+                }
+            }
+        },
+        "handlers.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "Events limits the delivered event types; omit it for all",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "server.crashed",
+                        "backup.failed"
+                    ]
+                },
+                "server_id": {
+                    "description": "ServerID limits the webhook to one server; omit it for all servers",
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/minecraft"
+                }
+            }
+        },
+        "handlers.CreateWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "description": "Events is a comma-separated list of event types; empty means all",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DeleteWorldResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "world",
+                        "world_nether",
+                        "world_the_end"
+                    ]
+                }
+            }
+        },
+        "handlers.GameplayRequest": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "type": "string",
+                    "enum": [
+                        "peaceful",
+                        "easy",
+                        "normal",
+                        "hard"
+                    ],
+                    "example": "hard"
+                },
+                "gamemode": {
+                    "description": "GameMode is the default game mode of players joining for the first time",
+                    "type": "string",
+                    "enum": [
+                        "survival",
+                        "creative",
+                        "adventure",
+                        "spectator"
+                    ],
+                    "example": "survival"
+                },
+                "gamerules": {
+                    "description": "GameRules maps vanilla game rules to booleans or integers depending on the rule",
+                    "type": "object"
+                },
+                "pvp": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.GrantServerAccessRequest": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "description": "Permissions are any of console, files, power and backups",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "console",
+                        "power"
+                    ]
+                },
+                "username": {
+                    "type": "string",
+                    "example": "alex"
+                }
+            }
+        },
+        "handlers.ImportJarFileRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "url",
+                "version"
+            ],
+            "properties": {
+                "checksum": {
+                    "description": "Checksum is the SHA-256 digest of the file in hex, optionally prefixed\nwith \"sha256:\"",
+                    "type": "string",
+                    "maxLength": 71
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "paper"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://api.papermc.io/v2/projects/paper/versions/1.21.1/builds/119/downloads/paper-1.21.1-119.jar"
+                },
+                "version": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "1.21.1"
+                }
+            }
+        },
+        "handlers.ImportModPackRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "checksum": {
+                    "description": "Checksum is the SHA-256 digest of the file in hex, optionally prefixed\nwith \"sha256:\"",
+                    "type": "string",
+                    "maxLength": 71
+                },
+                "name": {
+                    "description": "Name defaults to the name of the downloaded file",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "all-the-mods-9"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "version": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "0.3.2"
+                }
+            }
+        },
+        "handlers.ImportServerRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "path"
+            ],
+            "properties": {
+                "executable_command": {
+                    "type": "string"
+                },
+                "jar": {
+                    "type": "string",
+                    "example": "paper-1.21.1.jar"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "legacy-survival"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/srv/minecraft/survival"
+                }
+            }
+        },
+        "handlers.InstallLoaderRequest": {
+            "type": "object",
+            "properties": {
+                "loader": {
+                    "type": "string",
+                    "example": "fabric"
+                },
+                "loader_version": {
+                    "type": "string",
+                    "example": "0.16.5"
+                },
+                "minecraft_version": {
+                    "type": "string",
+                    "example": "1.21.1"
+                }
+            }
+        },
+        "handlers.InviteTeamMemberRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "description": "Role is owner, manager or member; it defaults to member",
+                    "type": "string",
+                    "example": "member"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "steve"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.MaintenanceWindowRequest": {
+            "type": "object",
+            "required": [
+                "ends_at",
+                "starts_at"
+            ],
+            "properties": {
+                "ends_at": {
+                    "type": "string",
+                    "example": "2024-12-08T05:00:00Z"
+                },
+                "message": {
+                    "description": "Message is the MOTD while the server is down and the reason players\nare kicked or turned away with",
+                    "type": "string",
+                    "maxLength": 256,
+                    "example": "Upgrading to 1.21, back at 05:00 UTC"
+                },
+                "mode": {
+                    "description": "Mode is stop (default), which stops the server and answers pings\nwith the message, or whitelist, which keeps it running for\nwhitelisted players and operators",
+                    "type": "string",
+                    "enum": [
+                        "stop",
+                        "whitelist"
+                    ],
+                    "example": "stop"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-12-08T04:00:00Z"
+                }
+            }
+        },
+        "handlers.OfflineModeRequest": {
+            "type": "object",
+            "properties": {
+                "acknowledged": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.PasswordResetRequest": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "description": "Login is the username or verified email address of the account",
+                    "type": "string",
+                    "example": "steve"
+                }
+            }
+        },
+        "handlers.PlayerActionRequest": {
+            "type": "object",
+            "required": [
+                "player"
+            ],
+            "properties": {
+                "action": {
+                    "description": "Action is kick, ban, pardon, op, deop or whisper",
+                    "type": "string",
+                    "enum": [
+                        "kick",
+                        "ban",
+                        "pardon",
+                        "op",
+                        "deop",
+                        "whisper"
+                    ],
+                    "example": "kick"
+                },
+                "message": {
+                    "description": "Message is the kick or ban reason, or the text to whisper",
+                    "type": "string"
+                },
+                "player": {
+                    "type": "string",
+                    "maxLength": 16,
+                    "example": "Steve"
+                }
+            }
+        },
+        "handlers.PublicStatusResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "3f2b9c0e4a7d1e6f8b5c2a9d0e1f4a7b"
+                },
+                "url": {
+                    "description": "URL is the status endpoint to embed, below the public URL of the manager",
+                    "type": "string",
+                    "example": "https://mc.example.com/api/v2/public/servers/3f2b9c0e4a7d1e6f8b5c2a9d0e1f4a7b/status"
+                }
+            }
+        },
+        "handlers.ReconcileRequest": {
+            "type": "object",
+            "properties": {
+                "adopt_orphans": {
+                    "description": "AdoptOrphans imports orphan directories as servers owned by the caller",
+                    "type": "boolean"
+                },
+                "delete_missing": {
+                    "description": "DeleteMissing soft deletes servers whose directory is missing",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.RegisterDNSRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Name is the label in the configured zone, smp for smp.example.com",
+                    "type": "string",
+                    "example": "smp"
+                }
+            }
+        },
+        "handlers.RestoreBackupAsServerRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "survival-copy"
+                }
+            }
+        },
+        "handlers.ScalingPolicyRequest": {
+            "type": "object",
+            "required": [
+                "template_id"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "idle_minutes": {
+                    "description": "IdleMinutes is how long an instance has to be empty before it is stopped",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 10
+                },
+                "max_instances": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1,
+                    "example": 4
+                },
+                "min_instances": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "scale_up_players": {
+                    "description": "ScaleUpPlayers is the number of players per running instance at which another one is started",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 20
+                },
+                "template_id": {
+                    "description": "TemplateID is the template new instances are created from",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ServerConfigRequest": {
+            "type": "object",
+            "required": [
+                "executable_command",
+                "jar_file_id"
+            ],
+            "properties": {
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "executable_command": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "java -Xmx4096M -jar server.jar nogui"
+                },
+                "jar_file_id": {
+                    "type": "integer"
+                },
+                "max_restarts": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 3
+                },
+                "memory": {
+                    "description": "Memory sizes of zero keep the flags of the executable command",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server_manager.MemorySettings"
+                        }
+                    ]
+                },
+                "mod_pack_id": {
+                    "description": "ModPackID is null for servers without a mod pack",
+                    "type": "integer"
+                },
+                "placeholder": {
+                    "description": "Placeholder answers server list pings while the server is stopped",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server_manager.PlaceholderSettings"
+                        }
+                    ]
+                },
+                "restart_policy": {
+                    "type": "string",
+                    "enum": [
+                        "never",
+                        "on-failure"
+                    ],
+                    "example": "on-failure"
+                }
+            }
+        },
+        "handlers.SetEmailRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "steve@example.com"
+                }
+            }
+        },
+        "handlers.SetProxyTypeRequest": {
+            "type": "object",
+            "properties": {
+                "proxy_type": {
+                    "description": "ProxyType is velocity or bungeecord, empty to turn the proxy back into a game server",
+                    "type": "string",
+                    "enum": [
+                        "velocity",
+                        "bungeecord"
+                    ],
+                    "example": "velocity"
+                }
+            }
+        },
+        "handlers.SetServerTagsRequest": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "smp",
+                        "modded"
+                    ]
+                }
+            }
+        },
+        "handlers.SetServerTeamRequest": {
+            "type": "object",
+            "properties": {
+                "team_id": {
+                    "description": "TeamID is the team to share the server with; null stops sharing",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.SetTeamMemberRoleRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "manager"
+                }
+            }
+        },
+        "handlers.SetUserQuotaRequest": {
+            "type": "object",
+            "properties": {
+                "max_disk_mb": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 20480
+                },
+                "max_memory_mb": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 8192
+                },
+                "max_servers": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 3
+                }
+            }
+        },
+        "handlers.SetUserRoleRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "operator",
+                        "admin"
+                    ],
+                    "example": "operator"
+                }
+            }
+        },
+        "handlers.SignupRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email is optional; a verification link is mailed to it",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password is limited by bcrypt, which ignores bytes past 72",
+                    "type": "string",
+                    "maxLength": 72
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "handlers.StartPregenRequest": {
+            "type": "object",
+            "required": [
+                "radius"
+            ],
+            "properties": {
+                "center_x": {
+                    "type": "integer"
+                },
+                "center_z": {
+                    "type": "integer"
+                },
+                "pause_for_players": {
+                    "description": "PauseForPlayers pauses the task while players are online; true if omitted",
+                    "type": "boolean"
+                },
+                "radius": {
+                    "description": "Radius is the distance from the center in blocks",
+                    "type": "integer",
+                    "maximum": 100000,
+                    "example": 5000
+                },
+                "world": {
+                    "description": "World defaults to the server's level-name",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "world"
+                }
+            }
+        },
+        "handlers.StartServerRequest": {
+            "type": "object"
+        },
+        "handlers.SwapJarRequest": {
+            "type": "object",
+            "required": [
+                "jar_file_id"
+            ],
+            "properties": {
+                "jar_file_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UpdateServerRequest": {
+            "type": "object",
+            "properties": {
+                "auto_start": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "executable_command": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "java -Xmx4G -jar server.jar nogui"
+                },
+                "jar_file_id": {
+                    "type": "integer"
+                },
+                "mod_pack_id": {
+                    "description": "ModPackID 0 removes the mod pack",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "survival"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "timezone": {
+                    "description": "Timezone is the IANA zone the server logs in; \"\" uses the zone of the host",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "handlers.UpgradeModPackRequest": {
+            "type": "object",
+            "properties": {
+                "mod_pack_id": {
+                    "description": "ModPackID is a version of the server's mod pack; the latest version when omitted",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UploadModsResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server_manager.ModUploadResult"
+                    }
+                }
+            }
+        },
+        "handlers.VerifyEmailRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AdditionalFile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Path is the stored file, relative to the additional_files directory of the shared dir unless absolute",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "One of the AdditionalFile type constants",
+                    "type": "string",
+                    "example": "plugin"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Addon": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "geyser"
+                },
+                "path": {
+                    "description": "Path is the plugin jar relative to the server directory",
+                    "type": "string",
+                    "example": "plugins/Geyser-Spigot.jar"
+                },
+                "platform": {
+                    "description": "Platform is the build installed, e.g. spigot or velocity",
+                    "type": "string",
+                    "example": "spigot"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "2.4.4-b688"
+                }
+            }
+        },
+        "model.Alert": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "fired_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "rule_id": {
+                    "type": "integer"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AlertChannel": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AlertRule": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Channels is a comma-separated list of alert channel IDs",
+                    "type": "string"
+                },
+                "condition": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "for_seconds": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Announcement": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "cron": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interval_seconds": {
+                    "type": "integer"
+                },
+                "last_sent_at": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "POST /servers/{id}/start"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "summary": {
+                    "description": "Summary is the request body, truncated and with secrets redacted",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Backup": {
+            "type": "object",
+            "properties": {
+                "base_id": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scheduled": {
+                    "type": "boolean"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.BackupSchedule": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "cron": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "incremental": {
+                    "type": "boolean"
+                },
+                "keep_daily_days": {
+                    "type": "integer"
+                },
+                "keep_last": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Crash": {
+            "type": "object",
+            "properties": {
+                "console_tail": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "exit_error": {
+                    "type": "string"
+                },
+                "findings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crash.Finding"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CrashReport"
+                    }
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "stderr": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CrashReport": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is a stable, machine-readable identifier of the error",
+                    "type": "string",
+                    "example": "validation_failed"
+                },
+                "conflict": {
+                    "description": "Conflict names the operation holding the files of a server when they\nare locked: running, backup, restore, jar_swap, world_delete or\nmod_pack_upgrade",
+                    "type": "string",
+                    "example": "backup"
+                },
+                "diagnosis": {
+                    "description": "Diagnosis lists the likely causes of a server failing to start",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crash.Finding"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Invalid input data"
+                },
+                "fields": {
+                    "description": "Fields lists the invalid request fields of a validation error",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Bad Request"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                }
+            }
+        },
+        "model.Event": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "name"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "is required"
+                }
+            }
+        },
+        "model.JarFile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_common": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.JarSwap": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "jar_file_id": {
+                    "type": "integer"
+                },
+                "previous_jar_file_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "snapshot_path": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MaintenanceWindow": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active is set while the window applies",
+                    "type": "boolean"
+                },
+                "begun_at": {
+                    "description": "BegunAt and EndedAt record when the window was applied and lifted",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "ended_at": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "description": "Message is the MOTD while the server is down and the reason players\nare kicked or turned away with",
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Membership": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invited_by": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "team": {
+                    "$ref": "#/definitions/model.Team"
+                },
+                "team_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MetricPoint": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.ModPack": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_common": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Node": {
+            "type": "object",
+            "properties": {
+                "allocated_memory_mb": {
+                    "description": "AllocatedMemoryMB sums the -Xmx of the servers scheduled onto the node",
+                    "type": "integer"
+                },
+                "cpu_cores": {
+                    "description": "Capacity last reported by the agent",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "free_disk_bytes": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_seen_at": {
+                    "description": "LastSeenAt is when the agent last reported; nodes silent for long are offline",
+                    "type": "string"
+                },
+                "memory_mb": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "online": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PregenTask": {
+            "type": "object",
+            "properties": {
+                "center_x": {
+                    "type": "integer"
+                },
+                "center_z": {
+                    "type": "integer"
+                },
+                "chunks": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pause_for_players": {
+                    "description": "PauseForPlayers pauses the task while players are online",
+                    "type": "boolean"
+                },
+                "percent": {
+                    "type": "number"
+                },
+                "radius": {
+                    "type": "integer"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "world": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ScalingPolicy": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idle_minutes": {
+                    "description": "IdleMinutes stops an instance above the minimum once it has been\nempty this long",
+                    "type": "integer",
+                    "example": 10
+                },
+                "max_instances": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "min_instances": {
+                    "description": "MinInstances are kept running; more than MaxInstances never run",
+                    "type": "integer",
+                    "example": 1
+                },
+                "proxy_id": {
+                    "type": "integer"
+                },
+                "scale_up_players": {
+                    "description": "ScaleUpPlayers adds an instance once the running ones hold this many\nplayers each on average",
+                    "type": "integer",
+                    "example": 16
+                },
+                "template_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "UserID is who set the policy; instances are created on their behalf",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Server": {
+            "type": "object",
+            "properties": {
+                "additional_files": {
+                    "description": "AdditionalFiles are placed into the server directory by their type",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AdditionalFile"
+                    }
+                },
+                "archive_size_bytes": {
+                    "type": "integer"
+                },
+                "archive_target": {
+                    "type": "string"
+                },
+                "archived_at": {
+                    "description": "ArchivedAt is set while the server directory is packed away in cold\nstorage. The archive lives in ArchiveTarget (local or the backup\nstorage) at ArchivePath.",
+                    "type": "string"
+                },
+                "auto_start": {
+                    "description": "AutoStart servers are started when the manager starts",
+                    "type": "boolean"
+                },
+                "autoscaled": {
+                    "description": "Autoscaled backends were created by the scaling policy of their proxy,\nwhich starts and stops them",
+                    "type": "boolean"
+                },
+                "config": {
+                    "description": "Config is only loaded, with its jar file and mod pack, by server lists",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ServerConfig"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "description": "Description is shown on server cards; Notes are free-form operator notes",
+                    "type": "string"
+                },
+                "dns_name": {
+                    "description": "DNSName is the host name registered for the server, e.g. smp.example.com",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "node_id": {
+                    "description": "NodeID is the node agent the server runs on, nil for the control plane",
+                    "type": "integer"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "offline_mode_acknowledged": {
+                    "description": "OfflineModeAcknowledged must be set before a server with online-mode=false may start",
+                    "type": "boolean"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "proxy_address": {
+                    "type": "string"
+                },
+                "proxy_id": {
+                    "description": "ProxyID is the proxy a backend server is attached to; ProxyAddress is\nthe host:port the proxy reaches it at",
+                    "type": "integer"
+                },
+                "proxy_type": {
+                    "description": "ProxyType is set on servers that run a proxy",
+                    "type": "string"
+                },
+                "public_status_token": {
+                    "description": "PublicStatusToken opts the server into the unauthenticated status\nendpoint, which it identifies the server in",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Tag"
+                    }
+                },
+                "team_id": {
+                    "description": "TeamID shares the server with the members of a team",
+                    "type": "integer"
+                },
+                "timezone": {
+                    "description": "Timezone is the IANA zone the game server logs in; console timestamps are normalized to UTC from it",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ServerConfig": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "env": {
+                    "description": "Env holds environment variables set for the server process",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "executable_command": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idle_shutdown_minutes": {
+                    "description": "IdleShutdownMinutes stops the running server after that long without\nplayers; zero never does",
+                    "type": "integer"
+                },
+                "jar_file": {
+                    "$ref": "#/definitions/model.JarFile"
+                },
+                "jar_file_id": {
+                    "type": "integer"
+                },
+                "max_restarts": {
+                    "description": "MaxRestarts caps the restarts after crashes within the restart window",
+                    "type": "integer"
+                },
+                "mod_pack": {
+                    "$ref": "#/definitions/model.ModPack"
+                },
+                "mod_pack_id": {
+                    "type": "integer"
+                },
+                "placeholder": {
+                    "description": "Placeholder answers server list pings on the port of the stopped\nserver with PlaceholderMOTD",
+                    "type": "boolean"
+                },
+                "placeholder_motd": {
+                    "type": "string"
+                },
+                "restart_policy": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "start_on_join": {
+                    "description": "StartOnJoin starts the server when a player tries to join its\nplaceholder",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ServerGrant": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "granted_by": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "permissions": {
+                    "description": "Permissions is a comma-separated list of granted permissions",
+                    "type": "string",
+                    "example": "console,power"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Current marks the session of the token the request was made with",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Tag": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Team": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Template": {
+            "type": "object",
+            "properties": {
+                "additional_files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AdditionalFile"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "executable_command": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "jar_file": {
+                    "$ref": "#/definitions/model.JarFile"
+                },
+                "jar_file_id": {
+                    "type": "integer"
+                },
+                "memory_mb": {
+                    "type": "integer"
+                },
+                "mod_pack": {
+                    "$ref": "#/definitions/model.ModPack"
+                },
+                "mod_pack_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name is unique among templates that are not deleted",
+                    "type": "string"
+                },
+                "properties": {
+                    "description": "Contents of server.properties, with {{variable}} placeholders",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "variables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TemplateVariable"
+                    }
+                }
+            }
+        },
+        "model.TemplateVariable": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default is used when no value is given; a variable without a default is required",
+                    "type": "string",
+                    "example": "10"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "view_distance"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "int"
+                }
+            }
+        },
+        "model.Usage": {
+            "type": "object",
+            "properties": {
+                "api_calls": {
+                    "$ref": "#/definitions/model.UsageMeter"
+                },
+                "backup_bytes": {
+                    "type": "integer"
+                },
+                "backups": {
+                    "$ref": "#/definitions/model.UsageMeter"
+                },
+                "disk_bytes": {
+                    "$ref": "#/definitions/model.UsageMeter"
+                },
+                "memory_mb": {
+                    "$ref": "#/definitions/model.UsageMeter"
+                },
+                "servers": {
+                    "$ref": "#/definitions/model.UsageMeter"
+                }
+            }
+        },
+        "model.UsageMeter": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "description": "Remaining is what is left of the limit; it is omitted when unlimited",
+                    "type": "integer"
+                },
+                "used": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email is used for password resets once it is verified",
+                    "type": "string"
+                },
+                "email_verified": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_disk_mb": {
+                    "type": "integer"
+                },
+                "max_memory_mb": {
+                    "type": "integer"
+                },
+                "max_servers": {
+                    "description": "Quotas override the configured limits when set; 0 means unlimited",
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "servers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Server"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.UserIdentity": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "description": "Events is a comma-separated list of event types; empty means all",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "mods.Dependency": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "versions": {
+                    "description": "Versions holds Fabric version predicates, any of which must match, or\na single Maven version range for Forge and NeoForge",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "mods.Mod": {
+            "type": "object",
+            "properties": {
+                "breaks": {
+                    "description": "Breaks lists mods this mod does not work with",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/mods.Dependency"
+                    }
+                },
+                "dependencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/mods.Dependency"
+                    }
+                },
+                "file": {
+                    "description": "File is the name of the jar in the mods directory",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "loader": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nested": {
+                    "description": "Nested is set for mods bundled inside another mod's jar",
+                    "type": "boolean"
+                },
+                "provides": {
+                    "description": "Provides lists further IDs the mod stands in for",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "mods.Warning": {
+            "type": "object",
+            "properties": {
+                "dependency": {
+                    "description": "Dependency is the missing or conflicting mod",
+                    "type": "string",
+                    "example": "fabric-api"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Sodium requires fabric-api, which is not installed"
+                },
+                "mod": {
+                    "description": "Mod is the ID of the mod the warning is about, empty for jars\nthat could not be read",
+                    "type": "string",
+                    "example": "sodium"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "missing_dependency"
+                }
+            }
+        },
+        "mojang.Profile": {
+            "type": "object",
+            "properties": {
+                "cape_url": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Notch"
+                },
+                "offline": {
+                    "description": "Offline marks profiles of offline mode servers, which have a UUID\nderived from the name and no skin",
+                    "type": "boolean"
+                },
+                "skin_model": {
+                    "description": "SkinModel is classic or slim",
+                    "type": "string",
+                    "example": "classic"
+                },
+                "skin_url": {
+                    "description": "SkinURL is the skin texture, empty for accounts with the default skin",
+                    "type": "string",
+                    "example": "https://textures.minecraft.net/texture/292009a4925b58f02c77dadc3ecef07ea4c7472f64e0fdc32ce5522489362680"
+                },
+                "uuid": {
+                    "description": "UUID is in the dashed form the game uses",
+                    "type": "string",
+                    "example": "069a79f4-44e9-4726-a5be-fca90e38aaf5"
+                }
+            }
+        },
+        "server.Failure": {
+            "type": "object",
+            "properties": {
+                "crash_reports": {
+                    "description": "CrashReports are the files the run wrote to crash-reports/, relative\nto the server directory",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exit_error": {
+                    "type": "string"
+                },
+                "findings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crash.Finding"
+                    }
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ServerDetails": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "$ref": "#/definitions/model.ServerConfig"
+                },
+                "is_running": {
+                    "type": "boolean"
+                },
+                "last_failure": {
+                    "$ref": "#/definitions/server.Failure"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "offline_mode_acknowledged": {
+                    "type": "boolean"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "utc_offset": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "server_manager.Ban": {
+            "type": "object",
+            "properties": {
+                "banned_as": {
+                    "description": "BannedAs is the name the player was banned under, set when the\naccount has been renamed since",
+                    "type": "string",
+                    "example": "Griefer"
+                },
+                "cape_url": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string",
+                    "example": "2024-11-02 18:04:11 +0000"
+                },
+                "expires": {
+                    "type": "string",
+                    "example": "forever"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Notch"
+                },
+                "offline": {
+                    "description": "Offline marks profiles of offline mode servers, which have a UUID\nderived from the name and no skin",
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Banned by an operator."
+                },
+                "skin_model": {
+                    "description": "SkinModel is classic or slim",
+                    "type": "string",
+                    "example": "classic"
+                },
+                "skin_url": {
+                    "description": "SkinURL is the skin texture, empty for accounts with the default skin",
+                    "type": "string",
+                    "example": "https://textures.minecraft.net/texture/292009a4925b58f02c77dadc3ecef07ea4c7472f64e0fdc32ce5522489362680"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "Server"
+                },
+                "uuid": {
+                    "description": "UUID is in the dashed form the game uses",
+                    "type": "string",
+                    "example": "069a79f4-44e9-4726-a5be-fca90e38aaf5"
+                }
+            }
+        },
+        "server_manager.BatchResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "server_manager.DiskStats": {
+            "type": "object",
+            "properties": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server_manager.NodeDiskStats"
+                    }
+                },
+                "reserve_bytes": {
+                    "description": "ReserveBytes is the free space uploads, backups and imports leave",
+                    "type": "integer"
+                },
+                "volumes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server_manager.VolumeStats"
+                    }
+                }
+            }
+        },
+        "server_manager.GCReport": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unreferenced": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "server_manager.GameplayResult": {
+            "type": "object",
+            "properties": {
+                "live": {
+                    "description": "Live is true if the settings were applied to the running server with\ncommands, false if only its files were edited",
+                    "type": "boolean"
+                },
+                "restart_required": {
+                    "description": "RestartRequired is true if a setting takes effect on the next start",
+                    "type": "boolean"
+                }
+            }
+        },
+        "server_manager.GeyserInstall": {
+            "type": "object",
+            "properties": {
+                "addons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Addon"
+                    }
+                },
+                "bedrock_port": {
+                    "description": "BedrockPort is the UDP port Bedrock players connect to",
+                    "type": "integer",
+                    "example": 19132
+                }
+            }
+        },
+        "server_manager.HostStats": {
+            "type": "object",
+            "properties": {
+                "allocated_memory_mb": {
+                    "type": "integer",
+                    "example": 24576
+                },
+                "allocation_percent": {
+                    "description": "AllocationPercent is the allocated memory as a share of the\nphysical memory, over 100 when the machine is oversubscribed",
+                    "type": "number",
+                    "example": 75
+                },
+                "cpu_cores": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "disk": {
+                    "description": "Disk is the volume holding the server directories",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server_manager.VolumeStats"
+                        }
+                    ]
+                },
+                "errors": {
+                    "description": "Errors lists the host figures that could not be read and are zero",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "load": {
+                    "description": "Load is the 1, 5 and 15 minute load average",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    },
+                    "example": [
+                        1.5,
+                        1.2,
+                        0.9
+                    ]
+                },
+                "memory_available_mb": {
+                    "type": "integer",
+                    "example": 20480
+                },
+                "memory_total_mb": {
+                    "type": "integer",
+                    "example": 32768
+                },
+                "oversubscribed": {
+                    "type": "boolean"
+                },
+                "running_memory_mb": {
+                    "type": "integer",
+                    "example": 12288
+                },
+                "running_servers": {
+                    "description": "RunningServers and RunningMemoryMB cover the servers running now",
+                    "type": "integer",
+                    "example": 3
+                },
+                "servers": {
+                    "description": "Servers counts the servers on this machine, archived ones excepted,\nand AllocatedMemoryMB sums their -Xmx",
+                    "type": "integer",
+                    "example": 6
+                }
             }
-        }
-    },
-    "definitions": {
-        "handlers.LoginRequest": {
+        },
+        "server_manager.LoaderInstall": {
             "type": "object",
             "properties": {
-                "email": {
+                "executable_command": {
+                    "description": "ExecutableCommand is the launch command the installer produced, set\nonce it succeeded",
                     "type": "string"
                 },
-                "password": {
+                "finished_at": {
+                    "type": "string"
+                },
+                "loader": {
+                    "type": "string"
+                },
+                "log_path": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "handlers.SignupRequest": {
+        "server_manager.MemorySettings": {
             "type": "object",
             "properties": {
-                "email": {
+                "max_mb": {
+                    "type": "integer",
+                    "example": 4096
+                },
+                "min_mb": {
+                    "type": "integer",
+                    "example": 1024
+                }
+            }
+        },
+        "server_manager.MissingServer": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "boolean"
+                },
+                "error": {
                     "type": "string"
                 },
-                "password": {
+                "name": {
                     "type": "string"
                 },
-                "username": {
+                "path": {
                     "type": "string"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.StartServerRequest": {
+        "server_manager.ModPackApply": {
             "type": "object",
             "properties": {
-                "port": {
+                "finished_at": {
+                    "type": "string"
+                },
+                "mod_pack_id": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server_manager.ModPackApplyResult"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 },
-                "ram": {
+                "strategy": {
                     "type": "string"
                 }
             }
         },
-        "model.ErrorResponse": {
+        "server_manager.ModPackApplyResult": {
             "type": "object",
             "properties": {
+                "action": {
+                    "type": "string"
+                },
                 "error": {
-                    "type": "string",
-                    "example": "Invalid input data"
+                    "type": "string"
                 },
-                "message": {
-                    "type": "string",
-                    "example": "Bad Request"
+                "name": {
+                    "type": "string"
                 },
-                "status": {
-                    "type": "integer",
-                    "example": 400
+                "running": {
+                    "type": "boolean"
+                },
+                "server_id": {
+                    "type": "integer"
                 }
             }
         },
-        "model.JarFile": {
+        "server_manager.ModPackUpgrade": {
             "type": "object",
             "properties": {
+                "added": {
+                    "description": "Added and Removed list the mod jars that differ between the versions",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "from_version": {
                     "type": "string"
                 },
-                "id": {
+                "mod_pack_id": {
                     "type": "integer"
                 },
-                "is_common": {
-                    "type": "boolean"
+                "previous_mod_pack_id": {
+                    "type": "integer"
                 },
-                "name": {
+                "reason": {
                     "type": "string"
                 },
-                "path": {
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "snapshot_path": {
                     "type": "string"
                 },
-                "updated_at": {
+                "status": {
                     "type": "string"
                 },
-                "version": {
+                "to_version": {
                     "type": "string"
                 }
             }
         },
-        "model.ModPack": {
+        "server_manager.ModUploadResult": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "error": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "file": {
+                    "description": "File is the name of the mod in the mods directory",
+                    "type": "string",
+                    "example": "lithium-fabric-0.13.0.jar"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "source": {
+                    "description": "Source is the uploaded zip file the mod was taken from, if any",
+                    "type": "string",
+                    "example": "performance-mods.zip"
+                }
+            }
+        },
+        "server_manager.ModValidation": {
+            "type": "object",
+            "properties": {
+                "loader": {
+                    "description": "Loader and MinecraftVersion are what the mods were checked against,\nempty when they could not be determined",
+                    "type": "string",
+                    "example": "fabric"
+                },
+                "minecraft_version": {
+                    "type": "string",
+                    "example": "1.20.1"
+                },
+                "mods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/mods.Mod"
+                    }
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/mods.Warning"
+                    }
+                }
+            }
+        },
+        "server_manager.NodeDiskStats": {
+            "type": "object",
+            "properties": {
+                "free_disk_bytes": {
+                    "type": "integer"
+                },
+                "last_seen_at": {
                     "type": "string"
                 },
-                "id": {
+                "name": {
+                    "type": "string"
+                },
+                "node_id": {
                     "type": "integer"
                 },
-                "is_common": {
+                "online": {
                     "type": "boolean"
+                }
+            }
+        },
+        "server_manager.OrphanDirectory": {
+            "type": "object",
+            "properties": {
+                "adopted_id": {
+                    "type": "integer"
                 },
-                "name": {
+                "error": {
                     "type": "string"
                 },
                 "path": {
                     "type": "string"
+                }
+            }
+        },
+        "server_manager.PlaceholderSettings": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
                 },
-                "updated_at": {
+                "idle_shutdown_minutes": {
+                    "description": "IdleShutdownMinutes stops the server after that long without\nplayers, so it only runs on demand; zero keeps it running",
+                    "type": "integer",
+                    "example": 15
+                },
+                "motd": {
+                    "description": "MOTD is shown in the server list; empty for the default",
+                    "type": "string",
+                    "example": "§7Server is offline"
+                },
+                "start_on_join": {
+                    "description": "StartOnJoin starts the server when a player tries to join",
+                    "type": "boolean"
+                }
+            }
+        },
+        "server_manager.PreflightCheck": {
+            "type": "object",
+            "properties": {
+                "message": {
                     "type": "string"
                 },
-                "version": {
+                "name": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "model.Server": {
+        "server_manager.PreflightReport": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server_manager.PreflightCheck"
+                    }
+                },
+                "ready": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "server_manager.PublicStatus": {
+            "type": "object",
+            "properties": {
+                "max_players": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "motd": {
+                    "type": "string",
+                    "example": "A Minecraft Server"
+                },
+                "online": {
+                    "type": "boolean"
+                },
+                "players_online": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "uptime_seconds": {
+                    "type": "integer",
+                    "example": 3600
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.20.1"
+                }
+            }
+        },
+        "server_manager.ReconcileReport": {
+            "type": "object",
+            "properties": {
+                "missing_servers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server_manager.MissingServer"
+                    }
+                },
+                "orphan_directories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server_manager.OrphanDirectory"
+                    }
+                },
+                "servers_dir": {
                     "type": "string"
+                }
+            }
+        },
+        "server_manager.ServerConfigDocument": {
+            "type": "object",
+            "properties": {
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "deleted_at": {
+                "executable_command": {
+                    "type": "string",
+                    "example": "java -Xmx4096M -jar server.jar nogui"
+                },
+                "jar_file_id": {
+                    "type": "integer"
+                },
+                "max_restarts": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "memory": {
+                    "$ref": "#/definitions/server_manager.MemorySettings"
+                },
+                "mod_pack_id": {
+                    "description": "ModPackID is nil for servers without a mod pack",
+                    "type": "integer"
+                },
+                "placeholder": {
+                    "description": "Placeholder only applies to servers on this host",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/server_manager.PlaceholderSettings"
+                        }
+                    ]
+                },
+                "restart_policy": {
+                    "type": "string",
+                    "example": "on-failure"
+                }
+            }
+        },
+        "server_manager.ServerStats": {
+            "type": "object",
+            "properties": {
+                "cpu_percent": {
+                    "type": "number"
+                },
+                "disk_bytes": {
+                    "type": "integer"
+                },
+                "pid": {
+                    "type": "integer"
+                },
+                "rss_bytes": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server_manager.UploadProgress": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
+                "eta_seconds": {
+                    "description": "ETASeconds estimates the time left to receive the upload",
+                    "type": "number"
+                },
                 "id": {
+                    "type": "string"
+                },
+                "received": {
                     "type": "integer"
                 },
-                "name": {
+                "started_at": {
                     "type": "string"
                 },
-                "path": {
+                "state": {
+                    "type": "string",
+                    "example": "receiving"
+                },
+                "total": {
+                    "description": "Total is the size of the upload request, or 0 when unknown",
+                    "type": "integer"
+                }
+            }
+        },
+        "server_manager.VolumeStats": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error is set when the volume could not be inspected",
                     "type": "string"
                 },
-                "status": {
+                "free_bytes": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name is servers, shared or backups",
                     "type": "string"
                 },
-                "updated_at": {
+                "path": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
+                "used_percent": {
+                    "type": "number"
                 }
             }
         }
@@ -1035,14 +10884,16 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
+	Version:          "2.0",
 	Host:             "localhost:8080",
-	BasePath:         "/api/v1",
+	BasePath:         "/api/v2",
 	Schemes:          []string{},
 	Title:            "Minecraft Server Manager API",
-	Description:      "This is a Minecraft server management service API",
+	Description:      "This is a Minecraft server management service API.\n\nEvery JSON response below /api/v2 is wrapped in an envelope. A success holds the documented response in data, `{\"data\": ..., \"meta\": {\"request_id\": \"...\", \"api_version\": \"v2\"}}`, and a failure holds a model.ErrorResponse in error, `{\"error\": {\"status\": 404, \"code\": \"not_found\", ...}, \"meta\": {...}}`. Downloads, empty responses and WebSocket upgrades are not wrapped.\n\nThe same routes are served below /api/v1 with the responses unwrapped. v1 is deprecated: its responses carry a Deprecation header and a Link header to the v2 path.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
 }
 
 func init() {
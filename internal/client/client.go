@@ -0,0 +1,133 @@
+// Package client talks to the REST API of a running manager. The
+// command-line interface uses it for its server subcommands.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// apiPrefix is where the API is mounted below the base URL
+const apiPrefix = "/api/v1"
+
+// Client calls the API as one user
+type Client struct {
+	// BaseURL is the address of the manager, e.g. http://localhost:8080
+	BaseURL string
+	// Token is the JWT sent as bearer token, empty for anonymous calls
+	Token string
+	HTTP  *http.Client
+}
+
+// New returns a client for the manager at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is a non-2xx answer of the API
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable code of the error, if the API sent one
+	Code    string
+	Message string
+	Fields  []model.FieldError
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// do sends body as JSON and decodes the response into out, if not nil
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.BaseURL+apiPrefix+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// newAPIError reads the error of a response, which is a JSON
+// model.ErrorResponse unless a proxy in between answered
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	var envelope model.ErrorResponse
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != "" {
+		apiErr.Code = envelope.Code
+		apiErr.Message = envelope.Error
+		apiErr.Fields = envelope.Fields
+	}
+	return apiErr
+}
+
+// Login exchanges a username and password for a token
+func (c *Client) Login(username, password string) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err := c.do(http.MethodPost, "/login", map[string]string{"username": username, "password": password}, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// ListServers returns the servers the user can see
+func (c *Client) ListServers() ([]model.Server, error) {
+	var servers []model.Server
+	if err := c.do(http.MethodGet, "/servers?per_page=100", nil, &servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+// StartServer starts a server
+func (c *Client) StartServer(id uint) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/servers/%d/start", id), nil, nil)
+}
+
+// StopServer stops a server
+func (c *Client) StopServer(id uint) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/servers/%d/stop", id), nil, nil)
+}
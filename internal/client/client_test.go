@@ -0,0 +1,49 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+func TestClient(t *testing.T) {
+	var started string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/servers":
+			json.NewEncoder(w).Encode([]model.Server{{Name: "survival", Status: model.ServerStatusRunning}})
+		case "/api/v1/servers/3/start":
+			started = r.Method + " " + r.URL.Path
+			json.NewEncoder(w).Encode(map[string]string{"message": "Server started successfully"})
+		default:
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "token")
+	servers, err := c.ListServers()
+	if err != nil || len(servers) != 1 || servers[0].Name != "survival" {
+		t.Fatalf("ListServers = %+v, %v", servers, err)
+	}
+	if err := c.StartServer(3); err != nil || started != "POST /api/v1/servers/3/start" {
+		t.Errorf("StartServer: %v, request %q", err, started)
+	}
+
+	var apiErr *APIError
+	if err := c.StopServer(3); !errors.As(err, &apiErr) || apiErr.Code != "not_found" || apiErr.Message != "Server not found" {
+		t.Errorf("StopServer error = %v", err)
+	}
+	if _, err := New(srv.URL, "").ListServers(); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous ListServers error = %v", err)
+	}
+}
@@ -2,12 +2,20 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"reflect"
 
 	"gopkg.in/yaml.v2"
 )
 
 type DatabaseConfig struct {
+	// Driver is postgres (default) or sqlite
+	Driver string `yaml:"driver"`
+	// Path is the database file of the sqlite driver
+	Path     string `yaml:"path"`
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
@@ -18,11 +26,55 @@ type DatabaseConfig struct {
 
 type Storage struct {
 	CommonDir string `yaml:"common_dir"`
+	// BackupTarget is "local" (default) or "s3"
+	BackupTarget string   `yaml:"backup_target"`
+	S3           S3Config `yaml:"s3"`
+	// ImportRoots lists the directories existing servers may be imported
+	// from. Importing is disabled while it is empty.
+	ImportRoots []string `yaml:"import_roots"`
+	// DeletedServerRetentionDays is how long deleted servers stay
+	// restorable before they are purged. Zero keeps them forever.
+	DeletedServerRetentionDays int `yaml:"deleted_server_retention_days"`
+	// DiskReserveMB is the free space uploads, backups and imports must
+	// leave on the volume they write to
+	DiskReserveMB int64 `yaml:"disk_reserve_mb"`
+	// LinkMode is how jar files and mod packs are placed into server
+	// directories: auto (default), symlink, hardlink or copy. auto falls
+	// back to hard links, then copies, where symlinks are unsupported;
+	// with runtime.process.isolate_users it falls back to copies only, and
+	// hardlink is refused, as servers would be handed the shared files.
+	LinkMode string `yaml:"link_mode"`
+}
+
+// S3Config describes an S3-compatible bucket such as AWS S3, MinIO or Backblaze B2
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	Prefix    string `yaml:"prefix"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	UseSSL    bool   `yaml:"use_ssl"`
 }
 
 type Config struct {
 	Server struct {
 		Port string `yaml:"port"`
+		// LogLevel is debug, info (default), warn or error
+		LogLevel string `yaml:"log_level"`
+		// LogFormat is text (default) or json
+		LogFormat string `yaml:"log_format"`
+		// PublicURL is the address users reach the API at, used for links in emails
+		PublicURL string `yaml:"public_url"`
+		// ShutdownTimeout bounds how long shutdown waits for requests and
+		// servers to finish, as a duration such as 90s (default 2m)
+		ShutdownTimeout string `yaml:"shutdown_timeout"`
+		// StopServersOnShutdown stops running servers when the manager shuts
+		// down; otherwise they keep running without it
+		StopServersOnShutdown bool `yaml:"stop_servers_on_shutdown"`
+		// MaxConcurrentStarts limits how many servers may be starting at
+		// once; further starts are queued. Zero is unlimited.
+		MaxConcurrentStarts int `yaml:"max_concurrent_starts"`
 	} `yaml:"server"`
 
 	Database DatabaseConfig `yaml:"database"`
@@ -31,31 +83,281 @@ type Config struct {
 	Storage Storage `yaml:"storage"`
 
 	JWTConfig JWTConfig `yaml:"jwt"`
+
+	Limits Limits `yaml:"limits"`
+
+	SMTP SMTPConfig `yaml:"smtp"`
+
+	Tracing TracingConfig `yaml:"tracing"`
+
+	TLS TLSConfig `yaml:"tls"`
+
+	Runtime RuntimeConfig `yaml:"runtime"`
+
+	DNS DNSConfig `yaml:"dns"`
+
+	// Agent configures the process when it runs as a node agent with --agent
+	Agent AgentConfig `yaml:"agent"`
+
+	// OAuthProviders enables login through Discord, GitHub, Google or any
+	// OpenID Connect provider
+	OAuthProviders []OAuthProvider `yaml:"oauth_providers"`
+
+	// Admins lists usernames that are admins regardless of their role, so
+	// a fresh installation can bootstrap its first admin. The names are
+	// reserved: their accounts are created with the admin create-user
+	// command, never through signup or OAuth.
+	Admins []string `yaml:"admins"`
+	// DefaultRole is the role of new users: viewer (default), operator or admin
+	DefaultRole string `yaml:"default_role"`
+}
+
+// Limits caps what a single user may consume. Zero means unlimited.
+type Limits struct {
+	MaxServers     int64 `yaml:"max_servers"`
+	MaxMemoryMB    int64 `yaml:"max_memory_mb"`
+	MaxDiskMB      int64 `yaml:"max_disk_mb"`
+	MaxBackups     int64 `yaml:"max_backups"`
+	APICallsPerDay int64 `yaml:"api_calls_per_day"`
+}
+
+// SMTPConfig describes the mail server used for password resets and email
+// verification. Both are unavailable while Host is empty.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// TracingConfig describes where OpenTelemetry spans are exported. Tracing
+// is off while Endpoint is empty.
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318
+	Endpoint    string `yaml:"endpoint"`
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio is the fraction of new traces recorded; 0 records all
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// TLSConfig serves the API over HTTPS, either from a certificate on disk or
+// from one obtained from Let's Encrypt. Leaving it empty serves plain HTTP.
+type TLSConfig struct {
+	CertFile string         `yaml:"cert_file"`
+	KeyFile  string         `yaml:"key_file"`
+	Autocert AutocertConfig `yaml:"autocert"`
+}
+
+// AutocertConfig obtains and renews a certificate for Hostname. The server
+// must be reachable on port 443 for hostname, or on HTTPPort for HTTP
+// challenges.
+type AutocertConfig struct {
+	Hostname string `yaml:"hostname"`
+	// Email is passed to Let's Encrypt for expiry notices
+	Email string `yaml:"email"`
+	// CacheDir keeps the account key and certificates across restarts
+	CacheDir string `yaml:"cache_dir"`
+	// HTTPPort, if set, answers HTTP challenges and redirects to HTTPS
+	HTTPPort string `yaml:"http_port"`
+}
+
+// RuntimeConfig selects how server processes are run
+type RuntimeConfig struct {
+	// Type is process (default), running servers on the host, or docker
+	Type    string        `yaml:"type"`
+	Process ProcessConfig `yaml:"process"`
+	Docker  DockerConfig  `yaml:"docker"`
+}
+
+// DNSConfig describes the DNS zone server addresses are registered in
+type DNSConfig struct {
+	// Provider is cloudflare or route53; empty disables DNS records
+	Provider string `yaml:"provider"`
+	// Zone is the domain server names are created below, e.g. example.com
+	Zone string `yaml:"zone"`
+	// Target is the IP address (for an A record) or host name players
+	// reach the servers at
+	Target string `yaml:"target"`
+	TTL    int    `yaml:"ttl"`
+
+	Cloudflare CloudflareConfig `yaml:"cloudflare"`
+	Route53    Route53Config    `yaml:"route53"`
+}
+
+// CloudflareConfig authenticates to the Cloudflare API
+type CloudflareConfig struct {
+	// APIToken needs the DNS edit permission on the zone
+	APIToken string `yaml:"api_token"`
+	ZoneID   string `yaml:"zone_id"`
+}
+
+// Route53Config authenticates to AWS Route 53
+type Route53Config struct {
+	AccessKey    string `yaml:"access_key"`
+	SecretKey    string `yaml:"secret_key"`
+	HostedZoneID string `yaml:"hosted_zone_id"`
+}
+
+// ProcessConfig describes the processes of the process runtime
+type ProcessConfig struct {
+	// IsolateUsers runs every server as its own unprivileged user with the
+	// UID UIDBase plus the server ID, owning its server directory. The
+	// manager must run as root.
+	IsolateUsers bool `yaml:"isolate_users"`
+	UIDBase      int  `yaml:"uid_base"`
+	// GID is the group of all isolated servers, which needs read access
+	// to the shared directory
+	GID int `yaml:"gid"`
+}
+
+// DockerConfig describes the containers of the docker runtime
+type DockerConfig struct {
+	// Binary is the docker CLI, docker by default
+	Binary string `yaml:"binary"`
+	Image  string `yaml:"image"`
+	// MemoryOverheadMB is added to a server's -Xmx for its memory limit
+	MemoryOverheadMB int64 `yaml:"memory_overhead_mb"`
+	// CPUs limits the cores of each container, 0 for no limit
+	CPUs    float64 `yaml:"cpus"`
+	Network string  `yaml:"network"`
+	// User is the uid:gid containers run as, the manager's by default
+	User string `yaml:"user"`
+	// Mounts are extra volumes in docker -v syntax
+	Mounts []string `yaml:"mounts"`
+}
+
+// AgentConfig describes how a node agent reaches the control plane
+type AgentConfig struct {
+	// ControlURL is the node endpoint of the control plane, e.g.
+	// wss://manager.example.com/api/v1/nodes/connect
+	ControlURL string `yaml:"control_url"`
+	// Token is the node token returned when the node was registered
+	Token string `yaml:"token"`
+	// ServersRoot is the directory servers may run in, game_servers in the
+	// working directory by default. Servers keep the paths they have on the
+	// control plane, so both should use the same layout.
+	ServersRoot string `yaml:"servers_root"`
+}
+
+// OAuthProvider configures one OAuth2 login provider. Its callback is
+// <server.public_url>/api/v1/auth/<name>/callback.
+type OAuthProvider struct {
+	Name string `yaml:"name"`
+	// Type is discord, github, google or oidc; it defaults to the name
+	Type         string   `yaml:"type"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+	// AuthURL, TokenURL and UserInfoURL are required for oidc providers
+	AuthURL     string `yaml:"auth_url"`
+	TokenURL    string `yaml:"token_url"`
+	UserInfoURL string `yaml:"userinfo_url"`
 }
 
 type JWTConfig struct {
-	Secret     string `yaml:"secret"`
+	// Algorithm is HS256 (default), RS256 or EdDSA
+	Algorithm      string `yaml:"algorithm"`
+	Secret         string `yaml:"secret"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+	// KeyID is sent as the kid header and selects the key on validation
+	KeyID      string `yaml:"key_id"`
+	Issuer     string `yaml:"issuer"`
+	Audience   string `yaml:"audience"`
 	Expiration string `yaml:"expiration"`
+	// PreviousKeys still validate tokens issued before a key rotation
+	PreviousKeys []JWTKey `yaml:"previous_keys"`
+}
+
+// JWTKey is a verification-only key kept around after rotation
+type JWTKey struct {
+	KeyID         string `yaml:"key_id"`
+	Algorithm     string `yaml:"algorithm"`
+	Secret        string `yaml:"secret"`
+	PublicKeyFile string `yaml:"public_key_file"`
 }
 
-func LoadConfig() (*Config, error) {
+// DefaultPath is the config file read when no path is given
+const DefaultPath = "config.global.yaml"
+
+// Default returns the configuration used for settings missing from both
+// the config file and the environment
+func Default() *Config {
 	cfg := &Config{}
+	cfg.Server.Port = "8080"
+	cfg.Server.LogLevel = "info"
+	cfg.Server.LogFormat = "text"
+	cfg.Server.PublicURL = "http://localhost:8080"
+	cfg.Server.StopServersOnShutdown = true
+	cfg.Database = DatabaseConfig{
+		Driver: "postgres",
+		Path:   "mcgonalds.db",
+		Host:   "localhost",
+		Port:   5432,
+		User:   "postgres",
+		DBName: "mcgonalds_db",
+	}
+	cfg.Storage.CommonDir = "/game_servers/shared"
+	cfg.Storage.BackupTarget = "local"
+	cfg.Storage.DiskReserveMB = 1024
+	cfg.Storage.LinkMode = "auto"
+	cfg.SMTP.Port = 587
+	cfg.Tracing.ServiceName = "mcgonalds"
+	cfg.DefaultRole = "viewer"
+	cfg.Runtime.Type = "process"
+	cfg.Runtime.Process.UIDBase = 200000
+	cfg.Runtime.Process.GID = 200000
+	cfg.Runtime.Docker.Image = "eclipse-temurin:21-jre"
+	cfg.Runtime.Docker.MemoryOverheadMB = 512
+	cfg.DNS.TTL = 300
+	return cfg
+}
 
-	file, err := os.Open("config.global.yaml")
-	if err != nil {
+// LoadConfig reads the config file at path over the defaults, then applies
+// MCGONALDS_* environment overrides. An empty path reads DefaultPath if it
+// exists, so the service can run from the environment alone.
+func LoadConfig(path string) (*Config, error) {
+	cfg := Default()
+
+	optional := path == ""
+	if optional {
+		path = DefaultPath
+	}
+	file, err := os.Open(path)
+	switch {
+	case err == nil:
+		defer file.Close()
+		if err := yaml.NewDecoder(file).Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case optional && errors.Is(err, fs.ErrNotExist):
+	default:
 		return nil, err
 	}
-	defer file.Close()
 
-	decoder := yaml.NewDecoder(file)
-	if err := decoder.Decode(cfg); err != nil {
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix, os.LookupEnv); err != nil {
 		return nil, err
 	}
 
 	// Validate configurations
 	if cfg.Storage.CommonDir == "" {
-		return nil, errors.New("storage.common_dir must be set in config.yml")
+		return nil, errors.New("storage.common_dir must be set")
+	}
+	switch cfg.Storage.LinkMode {
+	case "auto", "symlink", "hardlink", "copy":
+	default:
+		return nil, fmt.Errorf("storage.link_mode must be auto, symlink, hardlink or copy, not %q", cfg.Storage.LinkMode)
+	}
+	if cfg.Storage.LinkMode == "hardlink" && cfg.IsolatesUsers() {
+		return nil, errors.New("storage.link_mode hardlink cannot be used with runtime.process.isolate_users, which would hand the shared artifacts to each server's user")
 	}
 
 	return cfg, nil
 }
+
+// IsolatesUsers reports whether servers run as their own users, owning
+// their directories
+func (c *Config) IsolatesUsers() bool {
+	return (c.Runtime.Type == "" || c.Runtime.Type == "process") && c.Runtime.Process.IsolateUsers
+}
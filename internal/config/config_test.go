@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("database:\n  password: secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MCGONALDS_DATABASE_USER", "mc")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Password != "secret" || cfg.Database.User != "mc" || cfg.Database.Host != "localhost" || cfg.Server.Port != "8080" {
+		t.Fatalf("file, environment and defaults not merged: %+v %+v", cfg.Database, cfg.Server)
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("missing explicit config file accepted")
+	}
+
+	// Hard links would hand the shared artifacts to isolated servers
+	isolated := "runtime:\n  process:\n    isolate_users: true\nstorage:\n  link_mode: hardlink\n"
+	if err := os.WriteFile(path, []byte(isolated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("hardlink accepted with isolate_users")
+	}
+}
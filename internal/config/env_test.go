@@ -0,0 +1,48 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"MCGONALDS_SERVER_PORT":                           "9090",
+		"MCGONALDS_SERVER_STOP_SERVERS_ON_SHUTDOWN":       "false",
+		"MCGONALDS_DATABASE_PORT":                         "6543",
+		"MCGONALDS_STORAGE_S3_ACCESS_KEY":                 "key",
+		"MCGONALDS_STORAGE_IMPORT_ROOTS":                  "/srv/a, /srv/b",
+		"MCGONALDS_TRACING_SAMPLE_RATIO":                  "0.25",
+		"MCGONALDS_STORAGE_DELETED_SERVER_RETENTION_DAYS": "3",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	cfg := Default()
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != "9090" || cfg.Server.StopServersOnShutdown || cfg.Database.Port != 6543 {
+		t.Fatalf("server or database not overridden: %+v %+v", cfg.Server, cfg.Database)
+	}
+	if cfg.Storage.S3.AccessKey != "key" || cfg.Storage.DeletedServerRetentionDays != 3 {
+		t.Fatalf("storage not overridden: %+v", cfg.Storage)
+	}
+	if !reflect.DeepEqual(cfg.Storage.ImportRoots, []string{"/srv/a", "/srv/b"}) {
+		t.Fatalf("unexpected import roots %v", cfg.Storage.ImportRoots)
+	}
+	if cfg.Tracing.SampleRatio != 0.25 || cfg.Database.Host != "localhost" {
+		t.Fatalf("unexpected tracing or defaults: %+v %+v", cfg.Tracing, cfg.Database)
+	}
+
+	env = map[string]string{"MCGONALDS_DATABASE_PORT": "postgres"}
+	if err := applyEnv(reflect.ValueOf(Default()).Elem(), EnvPrefix, lookup); err == nil {
+		t.Fatal("invalid port accepted")
+	}
+	env = map[string]string{"MCGONALDS_OAUTH_PROVIDERS": "discord"}
+	if err := applyEnv(reflect.ValueOf(Default()).Elem(), EnvPrefix, lookup); err == nil {
+		t.Fatal("struct list accepted from the environment")
+	}
+}
@@ -0,0 +1,48 @@
+// Package crash turns crash reports and startup failure output into
+// human-readable causes and suggested fixes.
+package crash
+
+import (
+	"sync"
+)
+
+// Finding is a diagnosed cause of a crash or failed start
+type Finding struct {
+	Analyzer   string `json:"analyzer"`
+	Cause      string `json:"cause"`
+	Suggestion string `json:"suggestion"`
+}
+
+// Analyzer inspects crash report or console text for a known failure signature
+type Analyzer interface {
+	Name() string
+	Analyze(text string) []Finding
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      []Analyzer
+)
+
+// Register adds an analyzer to the pipeline
+func Register(analyzer Analyzer) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry = append(registry, analyzer)
+}
+
+// Analyze runs every registered analyzer over text and collects their findings
+func Analyze(text string) []Finding {
+	registryMutex.RLock()
+	analyzers := append([]Analyzer(nil), registry...)
+	registryMutex.RUnlock()
+
+	var findings []Finding
+	for _, analyzer := range analyzers {
+		for _, finding := range analyzer.Analyze(text) {
+			finding.Analyzer = analyzer.Name()
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,65 @@
+package crash
+
+import "testing"
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		analyzer string
+	}{
+		{
+			name:     "fabric missing dependency",
+			text:     "Mod 'Sodium Extra' (sodium-extra) 0.5.4 requires any version of sodium, which is missing!",
+			analyzer: "missing_dependency",
+		},
+		{
+			name:     "forge missing dependency",
+			text:     "Mod ID: 'create', Requested by: 'createaddition', Expected range: '[0.5.1,)', Actual version: '[MISSING]'",
+			analyzer: "missing_dependency",
+		},
+		{
+			name:     "port bind",
+			text:     "[12:00:00] [Server thread/WARN]: **** FAILED TO BIND TO PORT!",
+			analyzer: "port_bind",
+		},
+		{
+			name:     "java version",
+			text:     "java.lang.UnsupportedClassVersionError: net/minecraft/server/Main has been compiled by a more recent version of the Java Runtime (class file version 65.0), this version of the Java Runtime only recognizes class file versions up to 61.0",
+			analyzer: "java_version",
+		},
+		{
+			name:     "missing fabric api",
+			text:     "Mod 'Sodium' (sodium) 0.5.8 requires any version of fabric-api, which is missing!\nMod 'Lithium' (lithium) 0.12.1 requires any version of fabric-api, which is missing!",
+			analyzer: "missing_fabric_api",
+		},
+		{
+			name:     "eula",
+			text:     "[main/INFO]: You need to agree to the EULA in order to run the server. Go to eula.txt for more info.",
+			analyzer: "eula",
+		},
+		{
+			name:     "missing executable",
+			text:     `exec: "java": executable file not found in $PATH`,
+			analyzer: "missing_executable",
+		},
+		{
+			name:     "corrupted chunk",
+			text:     "[Server thread/ERROR]: Couldn't load chunk [12, -4]",
+			analyzer: "corrupted_chunk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Analyze(tt.text)
+			if len(findings) != 1 || findings[0].Analyzer != tt.analyzer {
+				t.Fatalf("Analyze() = %+v, want one %s finding", findings, tt.analyzer)
+			}
+		})
+	}
+
+	if findings := Analyze("[Server thread/INFO]: Done (3.1s)!"); len(findings) != 0 {
+		t.Errorf("expected no findings for a clean log, got %+v", findings)
+	}
+}
@@ -0,0 +1,170 @@
+package crash
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(missingDependencyAnalyzer{})
+	Register(missingFabricAPIAnalyzer{})
+	Register(eulaAnalyzer{})
+	Register(missingExecutableAnalyzer{})
+	Register(portBindAnalyzer{})
+	Register(javaVersionAnalyzer{})
+	Register(corruptedChunkAnalyzer{})
+}
+
+var (
+	// Fabric: "Mod 'Foo' (foo) 1.0 requires any version of fabric-api, which is missing!"
+	fabricMissingDep = regexp.MustCompile(`Mod '([^']+)' \(([^)]+)\)[^\n]*? requires (?:any version of |version [^ ]+ of )?'?([\w\-.]+)'?[^\n]*?, which is missing`)
+	// Forge: "Mod ID: 'create', Requested by: 'foo', Expected range: '[0.5,)', Actual version: '[MISSING]'"
+	forgeMissingDep   = regexp.MustCompile(`Mod ID: '([^']+)', Requested by: '([^']+)'[^\n]*Actual version: '\[MISSING\]'`)
+	portBind          = regexp.MustCompile(`(?i)\*+ FAILED TO BIND TO PORT!|Perhaps a server is already running on that port\?|java\.net\.BindException: Address already in use`)
+	classVersion      = regexp.MustCompile(`has been compiled by a more recent version of the Java Runtime \(class file version (\d+)\.\d+\), this version of the Java Runtime only recognizes class file versions up to (\d+)\.\d+`)
+	unsupportedJava   = regexp.MustCompile(`Unsupported class file major version (\d+)`)
+	eula              = regexp.MustCompile(`You need to agree to the EULA in order to run the server|Failed to load eula\.txt`)
+	missingExecutable = regexp.MustCompile(`exec: "([^"]+)": executable file not found`)
+	corruptedChunk    = regexp.MustCompile(`(?i)(Couldn't load chunk|Failed to read chunk|Chunk file at \[?(-?\d+), ?(-?\d+)\]? is in the wrong location|Exception reading .*\.mca)`)
+)
+
+// classFileJava maps a class file major version to the Java release that produces it
+func classFileJava(major string) string {
+	version, err := strconv.Atoi(major)
+	if err != nil || version < 45 {
+		return major
+	}
+	return strconv.Itoa(version - 44)
+}
+
+type missingDependencyAnalyzer struct{}
+
+func (missingDependencyAnalyzer) Name() string { return "missing_dependency" }
+
+func (missingDependencyAnalyzer) Analyze(text string) []Finding {
+	var findings []Finding
+	for _, match := range fabricMissingDep.FindAllStringSubmatch(text, -1) {
+		if isFabricAPI(match[3]) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Cause:      fmt.Sprintf("Mod %s requires %s, which is not installed", match[1], match[3]),
+			Suggestion: fmt.Sprintf("Add %s to the mods folder or remove %s", match[3], match[1]),
+		})
+	}
+	for _, match := range forgeMissingDep.FindAllStringSubmatch(text, -1) {
+		findings = append(findings, Finding{
+			Cause:      fmt.Sprintf("Mod %s requires %s, which is not installed", match[2], match[1]),
+			Suggestion: fmt.Sprintf("Add %s to the mods folder or remove %s", match[1], match[2]),
+		})
+	}
+	return findings
+}
+
+// isFabricAPI reports whether a mod ID is Fabric API, which was called
+// fabric before 1.19
+func isFabricAPI(id string) bool {
+	return id == "fabric-api" || id == "fabric"
+}
+
+// missingFabricAPIAnalyzer reports Fabric API once however many mods need it
+type missingFabricAPIAnalyzer struct{}
+
+func (missingFabricAPIAnalyzer) Name() string { return "missing_fabric_api" }
+
+func (missingFabricAPIAnalyzer) Analyze(text string) []Finding {
+	var mods []string
+	seen := make(map[string]bool)
+	for _, match := range fabricMissingDep.FindAllStringSubmatch(text, -1) {
+		if isFabricAPI(match[3]) && !seen[match[1]] {
+			seen[match[1]] = true
+			mods = append(mods, match[1])
+		}
+	}
+	if len(mods) == 0 {
+		return nil
+	}
+	return []Finding{{
+		Cause:      fmt.Sprintf("Fabric API is not installed but is required by %s", strings.Join(mods, ", ")),
+		Suggestion: "Add the Fabric API jar for this Minecraft version to the mods folder",
+	}}
+}
+
+type eulaAnalyzer struct{}
+
+func (eulaAnalyzer) Name() string { return "eula" }
+
+func (eulaAnalyzer) Analyze(text string) []Finding {
+	if !eula.MatchString(text) {
+		return nil
+	}
+	return []Finding{{
+		Cause:      "The server stopped because the Minecraft EULA has not been accepted",
+		Suggestion: "Set eula=true in eula.txt after reading https://aka.ms/MinecraftEULA",
+	}}
+}
+
+type missingExecutableAnalyzer struct{}
+
+func (missingExecutableAnalyzer) Name() string { return "missing_executable" }
+
+func (missingExecutableAnalyzer) Analyze(text string) []Finding {
+	match := missingExecutable.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	return []Finding{{
+		Cause:      fmt.Sprintf("The executable %s of the start command is not installed or not on the PATH", match[1]),
+		Suggestion: "Install Java or change the executable command to the full path of the java binary",
+	}}
+}
+
+type portBindAnalyzer struct{}
+
+func (portBindAnalyzer) Name() string { return "port_bind" }
+
+func (portBindAnalyzer) Analyze(text string) []Finding {
+	if !portBind.MatchString(text) {
+		return nil
+	}
+	return []Finding{{
+		Cause:      "The server could not bind to its port because another process is already using it",
+		Suggestion: "Stop the other server using this port or change server-port in server.properties",
+	}}
+}
+
+type javaVersionAnalyzer struct{}
+
+func (javaVersionAnalyzer) Name() string { return "java_version" }
+
+func (javaVersionAnalyzer) Analyze(text string) []Finding {
+	if match := classVersion.FindStringSubmatch(text); match != nil {
+		return []Finding{{
+			Cause:      fmt.Sprintf("The server jar needs Java %s but is running on Java %s", classFileJava(match[1]), classFileJava(match[2])),
+			Suggestion: fmt.Sprintf("Install Java %s or newer and point the executable command at it", classFileJava(match[1])),
+		}}
+	}
+	if match := unsupportedJava.FindStringSubmatch(text); match != nil {
+		return []Finding{{
+			Cause:      fmt.Sprintf("The runtime is too new for this server or mod loader (class file version %s, Java %s)", match[1], classFileJava(match[1])),
+			Suggestion: "Run the server with the Java version recommended for this Minecraft version",
+		}}
+	}
+	return nil
+}
+
+type corruptedChunkAnalyzer struct{}
+
+func (corruptedChunkAnalyzer) Name() string { return "corrupted_chunk" }
+
+func (corruptedChunkAnalyzer) Analyze(text string) []Finding {
+	if !corruptedChunk.MatchString(text) {
+		return nil
+	}
+	return []Finding{{
+		Cause:      "A world region file is corrupted and a chunk could not be loaded",
+		Suggestion: "Restore the world from a backup or delete the affected region file to regenerate it",
+	}}
+}
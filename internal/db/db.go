@@ -2,11 +2,12 @@ package db
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 
 	"github.com/olindenbaum/mcgonalds/internal/config"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -28,6 +29,17 @@ func InitDatabase(cfg *config.DatabaseConfig) error {
 }
 
 func NewDatabase(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case "", "postgres":
+		return newPostgres(cfg)
+	case "sqlite":
+		return newSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+func newPostgres(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	var dsn string
 	if cfg.SSLMode {
 		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=enable",
@@ -37,12 +49,40 @@ func NewDatabase(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 			cfg.Host, cfg.User, cfg.Password, cfg.DBName, cfg.Port)
 	}
 
-	fmt.Println(dsn)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("Connected to database successfully")
+	slog.Info("Connected to database", "host", cfg.Host, "port", cfg.Port, "dbname", cfg.DBName)
+	return db, nil
+}
+
+// newSQLite opens the database file at cfg.Path, creating it if needed.
+// Writers wait for each other instead of failing while the file is locked.
+func newSQLite(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("database.path must be set for the sqlite driver")
+	}
+	dsn := cfg.Path + "?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL"
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	slog.Info("Opened database", "driver", "sqlite", "path", cfg.Path)
 	return db, nil
 }
+
+// Close closes the connection pool of the shared database
+func Close() error {
+	if instance == nil {
+		return nil
+	}
+	sqlDB, err := instance.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
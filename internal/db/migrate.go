@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/pressly/goose/v3"
+	"gorm.io/gorm"
+)
+
+// newProvider returns a goose provider for the postgres migrations of fsys.
+// Migrations merged with an older version than the latest applied one are
+// still applied, as databases upgraded across branches have such gaps.
+func newProvider(db *gorm.DB, fsys fs.FS) (*goose.Provider, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := goose.NewProvider(goose.DialectPostgres, sqlDB, fsys, goose.WithAllowOutofOrder(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// Migrate applies every migration of fsys that has not been applied yet
+// and returns how many were applied. The migrations are written for
+// postgres, so SQLite databases get their schema from the models instead.
+func Migrate(db *gorm.DB, fsys fs.FS) (int, error) {
+	if db.Dialector.Name() == "sqlite" {
+		if err := db.AutoMigrate(model.All()...); err != nil {
+			return 0, fmt.Errorf("failed to migrate models: %w", err)
+		}
+		return 0, nil
+	}
+
+	provider, err := newProvider(db, fsys)
+	if err != nil {
+		return 0, err
+	}
+	results, err := provider.Up(context.Background())
+	// A failed migration leaves the ones before it applied
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		results = partial.Applied
+	}
+	for _, result := range results {
+		slog.Info("Applied migration", "version", result.Source.Version, "path", result.Source.Path, "duration", result.Duration)
+	}
+	if err != nil {
+		return len(results), fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return len(results), nil
+}
+
+// Rollback reverts the steps most recently applied migrations and returns
+// how many were reverted
+func Rollback(db *gorm.DB, fsys fs.FS, steps int) (int, error) {
+	if db.Dialector.Name() == "sqlite" {
+		return 0, errors.New("rollback is not supported for sqlite databases")
+	}
+
+	provider, err := newProvider(db, fsys)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for count < steps {
+		result, err := provider.Down(context.Background())
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to roll back migration: %w", err)
+		}
+		slog.Info("Rolled back migration", "version", result.Source.Version, "path", result.Source.Path)
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/olindenbaum/mcgonalds/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	database, err := NewDatabase(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := newProvider(database, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := provider.ListSources()
+	if len(sources) == 0 {
+		t.Fatal("no migrations found")
+	}
+	for i, source := range sources {
+		if i > 0 && source.Version <= sources[i-1].Version {
+			t.Errorf("migration %s out of order", source.Path)
+		}
+	}
+
+	duplicate := fstest.MapFS{
+		"1_a.sql":  {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		"01_b.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+	}
+	if _, err := newProvider(database, duplicate); err == nil {
+		t.Fatal("duplicate versions accepted")
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	database, err := NewDatabase(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Migrate(database, migrations.FS); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"users", "servers", "server_tags", "template_additional_files", "sessions"} {
+		if !database.Migrator().HasTable(table) {
+			t.Errorf("table %s not created", table)
+		}
+	}
+	if _, err := Rollback(database, migrations.FS, 1); err == nil {
+		t.Fatal("sqlite rollback accepted")
+	}
+}
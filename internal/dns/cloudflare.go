@@ -0,0 +1,162 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cloudflareAPI is the base URL of the Cloudflare API
+const cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+// Cloudflare manages records through the Cloudflare API
+type Cloudflare struct {
+	Token  string
+	ZoneID string
+	// BaseURL overrides the API address, for tests
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func (c *Cloudflare) Name() string {
+	return "cloudflare"
+}
+
+// cloudflareRecord is a record as the Cloudflare API reads and writes it
+type cloudflareRecord struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Name    string          `json:"name"`
+	Content string          `json:"content,omitempty"`
+	TTL     int             `json:"ttl"`
+	Data    *cloudflareData `json:"data,omitempty"`
+}
+
+// cloudflareData holds the fields of SRV records
+type cloudflareData struct {
+	Priority int    `json:"priority"`
+	Weight   int    `json:"weight"`
+	Port     int    `json:"port"`
+	Target   string `json:"target"`
+}
+
+func (c *Cloudflare) Create(ctx context.Context, record Record) error {
+	body, err := cloudflareBody(record)
+	if err != nil {
+		return err
+	}
+	existing, err := c.find(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return ErrRecordExists
+	}
+	return c.call(ctx, http.MethodPost, "/dns_records", body, nil)
+}
+
+func (c *Cloudflare) Upsert(ctx context.Context, record Record) error {
+	body, err := cloudflareBody(record)
+	if err != nil {
+		return err
+	}
+	existing, err := c.find(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing == "" {
+		return c.call(ctx, http.MethodPost, "/dns_records", body, nil)
+	}
+	return c.call(ctx, http.MethodPut, "/dns_records/"+existing, body, nil)
+}
+
+// cloudflareBody returns record as the API writes it
+func cloudflareBody(record Record) (cloudflareRecord, error) {
+	body := cloudflareRecord{Type: record.Type, Name: record.Name, Content: record.Value, TTL: record.TTL}
+	if record.Type == "SRV" {
+		priority, weight, port, target, err := srvFields(record.Value)
+		if err != nil {
+			return body, err
+		}
+		body.Content = ""
+		body.Data = &cloudflareData{Priority: priority, Weight: weight, Port: port, Target: target}
+	}
+	return body, nil
+}
+
+func (c *Cloudflare) Delete(ctx context.Context, record Record) error {
+	existing, err := c.find(ctx, record)
+	if err != nil || existing == "" {
+		return err
+	}
+	return c.call(ctx, http.MethodDelete, "/dns_records/"+existing, nil, nil)
+}
+
+// find returns the ID of the record with the type and name of record, or
+// an empty string
+func (c *Cloudflare) find(ctx context.Context, record Record) (string, error) {
+	query := url.Values{"type": {record.Type}, "name": {record.Name}}
+	var records []cloudflareRecord
+	if err := c.call(ctx, http.MethodGet, "/dns_records?"+query.Encode(), nil, &records); err != nil {
+		return "", err
+	}
+	if len(records) == 0 {
+		return "", nil
+	}
+	return records[0].ID, nil
+}
+
+// call sends a request to the zone's API and decodes the result into out
+func (c *Cloudflare) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = cloudflareAPI
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(base, "/")+"/zones/"+c.ZoneID+path, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool                       `json:"success"`
+		Errors  []struct{ Message string } `json:"errors"`
+		Result  json.RawMessage            `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("cloudflare returned %s", resp.Status)
+	}
+	if !result.Success {
+		var messages []string
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("cloudflare returned %s: %s", resp.Status, strings.Join(messages, "; "))
+	}
+	if out != nil {
+		return json.Unmarshal(result.Result, out)
+	}
+	return nil
+}
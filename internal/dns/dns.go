@@ -0,0 +1,179 @@
+// Package dns registers the addresses of servers with a DNS provider, so
+// players can join smp.example.com instead of an IP address and port.
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+)
+
+// ErrRecordExists is returned when a record the manager would create already
+// exists. The manager never replaces records it did not create.
+var ErrRecordExists = errors.New("a record of that name already exists in the zone")
+
+// ErrInvalidLabel is returned for names that are not a single DNS label
+var ErrInvalidLabel = errors.New("dns name must be a single label of letters, digits and '-'")
+
+// labelPattern matches a single RFC 1123 label
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// ValidLabel reports whether label is a single DNS label, so that the
+// host name derived from it stays directly below the zone
+func ValidLabel(label string) bool {
+	return labelPattern.MatchString(label)
+}
+
+// Record is a DNS record. Names are fully qualified without trailing dot.
+type Record struct {
+	Type string
+	Name string
+	// Value is the address of A records and "priority weight port target"
+	// for SRV records
+	Value string
+	TTL   int
+}
+
+// Provider creates and deletes records in a zone
+type Provider interface {
+	// Name identifies the provider in logs, e.g. "cloudflare"
+	Name() string
+	// Create creates the record, failing with ErrRecordExists if a record
+	// of the same type and name exists
+	Create(ctx context.Context, record Record) error
+	// Upsert creates the record or replaces the record of the same type
+	// and name. It is only used for names the manager created itself.
+	Upsert(ctx context.Context, record Record) error
+	// Delete removes the record; missing records are not an error
+	Delete(ctx context.Context, record Record) error
+}
+
+// Registrar derives the records of a server from the configuration and
+// writes them through a provider
+type Registrar struct {
+	Provider Provider
+	Zone     string
+	Target   string
+	TTL      int
+}
+
+// New returns the registrar configured by cfg, or nil if DNS records are
+// disabled
+func New(cfg config.DNSConfig) (*Registrar, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+	if cfg.Zone == "" || cfg.Target == "" {
+		return nil, fmt.Errorf("dns.zone and dns.target must be set")
+	}
+
+	var provider Provider
+	switch cfg.Provider {
+	case "cloudflare":
+		if cfg.Cloudflare.APIToken == "" || cfg.Cloudflare.ZoneID == "" {
+			return nil, fmt.Errorf("dns.cloudflare.api_token and dns.cloudflare.zone_id must be set")
+		}
+		provider = &Cloudflare{Token: cfg.Cloudflare.APIToken, ZoneID: cfg.Cloudflare.ZoneID}
+	case "route53":
+		if cfg.Route53.AccessKey == "" || cfg.Route53.SecretKey == "" || cfg.Route53.HostedZoneID == "" {
+			return nil, fmt.Errorf("dns.route53.access_key, secret_key and hosted_zone_id must be set")
+		}
+		provider = &Route53{AccessKey: cfg.Route53.AccessKey, SecretKey: cfg.Route53.SecretKey, HostedZoneID: cfg.Route53.HostedZoneID}
+	default:
+		return nil, fmt.Errorf("unknown dns provider %q", cfg.Provider)
+	}
+	return &Registrar{
+		Provider: provider,
+		Zone:     strings.TrimSuffix(cfg.Zone, "."),
+		Target:   strings.TrimSuffix(cfg.Target, "."),
+		TTL:      cfg.TTL,
+	}, nil
+}
+
+// Hostname returns the fully qualified name of a label in the zone
+func (r *Registrar) Hostname(label string) (string, error) {
+	if !ValidLabel(label) {
+		return "", fmt.Errorf("%q: %w", label, ErrInvalidLabel)
+	}
+	return strings.ToLower(label) + "." + r.Zone, nil
+}
+
+// Records returns the records that make hostname reach a server on port:
+// an A record if the target is an IP address, and the SRV record clients
+// look up to find the port
+func (r *Registrar) Records(hostname string, port int) []Record {
+	var records []Record
+	srvTarget := r.Target
+	if ip := net.ParseIP(r.Target); ip != nil {
+		recordType := "A"
+		if ip.To4() == nil {
+			recordType = "AAAA"
+		}
+		records = append(records, Record{Type: recordType, Name: hostname, Value: r.Target, TTL: r.TTL})
+		srvTarget = hostname
+	}
+	return append(records, Record{
+		Type:  "SRV",
+		Name:  "_minecraft._tcp." + hostname,
+		Value: "0 5 " + strconv.Itoa(port) + " " + srvTarget,
+		TTL:   r.TTL,
+	})
+}
+
+// Register creates the records of hostname, pointing at port. It fails
+// without touching them if any of the records exists already, and removes
+// the records it created if a later one fails.
+func (r *Registrar) Register(ctx context.Context, hostname string, port int) error {
+	var created []Record
+	for _, record := range r.Records(hostname, port) {
+		if err := r.Provider.Create(ctx, record); err != nil {
+			for _, done := range created {
+				r.Provider.Delete(ctx, done)
+			}
+			return fmt.Errorf("failed to create %s record %s: %w", record.Type, record.Name, err)
+		}
+		created = append(created, record)
+	}
+	return nil
+}
+
+// Update points the records of hostname, which Register created, at port
+func (r *Registrar) Update(ctx context.Context, hostname string, port int) error {
+	for _, record := range r.Records(hostname, port) {
+		if err := r.Provider.Upsert(ctx, record); err != nil {
+			return fmt.Errorf("failed to update %s record %s: %w", record.Type, record.Name, err)
+		}
+	}
+	return nil
+}
+
+// Unregister deletes the records of hostname
+func (r *Registrar) Unregister(ctx context.Context, hostname string, port int) error {
+	for _, record := range r.Records(hostname, port) {
+		if err := r.Provider.Delete(ctx, record); err != nil {
+			return fmt.Errorf("failed to delete %s record %s: %w", record.Type, record.Name, err)
+		}
+	}
+	return nil
+}
+
+// srvFields splits the value of an SRV record
+func srvFields(value string) (priority, weight, port int, target string, err error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return 0, 0, 0, "", fmt.Errorf("invalid SRV value %q", value)
+	}
+	numbers := make([]int, 3)
+	for i := range numbers {
+		if numbers[i], err = strconv.Atoi(fields[i]); err != nil {
+			return 0, 0, 0, "", fmt.Errorf("invalid SRV value %q", value)
+		}
+	}
+	return numbers[0], numbers[1], numbers[2], fields[3], nil
+}
@@ -0,0 +1,130 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecords(t *testing.T) {
+	r := &Registrar{Zone: "example.com", Target: "203.0.113.7", TTL: 300}
+	hostname, err := r.Hostname("smp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := r.Records(hostname, 25566)
+	want := []Record{
+		{Type: "A", Name: "smp.example.com", Value: "203.0.113.7", TTL: 300},
+		{Type: "SRV", Name: "_minecraft._tcp.smp.example.com", Value: "0 5 25566 smp.example.com", TTL: 300},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("Records() = %v, want %v", records, want)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %v, want %v", i, records[i], want[i])
+		}
+	}
+
+	for _, label := range []string{"smp.other.org", "", "../x", "smp."} {
+		if _, err := r.Hostname(label); !errors.Is(err, ErrInvalidLabel) {
+			t.Errorf("Hostname(%q) = %v, want ErrInvalidLabel", label, err)
+		}
+	}
+
+	r.Target = "mc.example.net"
+	records = r.Records("smp.example.com", 25566)
+	if len(records) != 1 || records[0].Value != "0 5 25566 mc.example.net" {
+		t.Errorf("Records() with host name target = %v", records)
+	}
+}
+
+func TestCloudflare(t *testing.T) {
+	var stored map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		result := interface{}(nil)
+		switch {
+		case r.Method == http.MethodGet:
+			records := []map[string]interface{}{}
+			if stored != nil {
+				records = append(records, map[string]interface{}{"id": "rec1"})
+			}
+			result = records
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/zone/dns_records":
+			json.NewDecoder(r.Body).Decode(&stored)
+		case r.Method == http.MethodDelete && r.URL.Path == "/zones/zone/dns_records/rec1":
+			stored = nil
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": result})
+	}))
+	defer srv.Close()
+
+	c := &Cloudflare{Token: "token", ZoneID: "zone", BaseURL: srv.URL}
+	record := Record{Type: "SRV", Name: "_minecraft._tcp.smp.example.com", Value: "0 5 25566 mc.example.net", TTL: 300}
+	if err := c.Create(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := stored["data"].(map[string]interface{})
+	if data["port"] != float64(25566) || data["target"] != "mc.example.net" {
+		t.Errorf("stored record = %v", stored)
+	}
+	if err := c.Create(context.Background(), record); !errors.Is(err, ErrRecordExists) {
+		t.Errorf("creating an existing record: got %v, want ErrRecordExists", err)
+	}
+	if err := c.Delete(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	if stored != nil {
+		t.Error("record was not deleted")
+	}
+}
+
+func TestRoute53(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2013-04-01/hostedzone/Z123/rrset/" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		switch {
+		case strings.Contains(body, "<Action>DELETE</Action>"):
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `<ErrorResponse><Error><Message>Tried to delete resource record set but it was not found</Message></Error></ErrorResponse>`)
+		case strings.Contains(body, "<Action>CREATE</Action>"):
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `<ErrorResponse><Error><Message>Tried to create resource record set but it already exists</Message></Error></ErrorResponse>`)
+		}
+	}))
+	defer srv.Close()
+
+	r := &Route53{AccessKey: "AKID", SecretKey: "secret", HostedZoneID: "/hostedzone/Z123", BaseURL: srv.URL}
+	record := Record{Type: "SRV", Name: "_minecraft._tcp.smp.example.com", Value: "0 5 25566 mc.example.net", TTL: 300}
+	if err := r.Upsert(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<Action>UPSERT</Action>", "<Name>_minecraft._tcp.smp.example.com.</Name>", "<Value>0 5 25566 mc.example.net.</Value>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("request lacks %s:\n%s", want, body)
+		}
+	}
+	if err := r.Delete(context.Background(), record); err != nil {
+		t.Errorf("deleting a missing record: %v", err)
+	}
+	if err := r.Create(context.Background(), record); !errors.Is(err, ErrRecordExists) {
+		t.Errorf("creating an existing record: got %v, want ErrRecordExists", err)
+	}
+}
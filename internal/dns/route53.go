@@ -0,0 +1,155 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// route53API is the global endpoint of Route 53, which signs in us-east-1
+const (
+	route53API    = "https://route53.amazonaws.com"
+	route53Region = "us-east-1"
+)
+
+// Route53 manages records in an AWS Route 53 hosted zone
+type Route53 struct {
+	AccessKey    string
+	SecretKey    string
+	HostedZoneID string
+	// BaseURL overrides the API address, for tests
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func (r *Route53) Name() string {
+	return "route53"
+}
+
+// route53Change is the body of ChangeResourceRecordSets with one change
+type route53Change struct {
+	XMLName xml.Name `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Action  string   `xml:"ChangeBatch>Changes>Change>Action"`
+	Name    string   `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>Name"`
+	Type    string   `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>Type"`
+	TTL     int      `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>TTL"`
+	Value   string   `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>ResourceRecords>ResourceRecord>Value"`
+}
+
+func (r *Route53) Create(ctx context.Context, record Record) error {
+	err := r.change(ctx, "CREATE", record)
+	// Route 53 rejects creating a record that exists
+	if err != nil && strings.Contains(err.Error(), "already exists") {
+		return ErrRecordExists
+	}
+	return err
+}
+
+func (r *Route53) Upsert(ctx context.Context, record Record) error {
+	return r.change(ctx, "UPSERT", record)
+}
+
+func (r *Route53) Delete(ctx context.Context, record Record) error {
+	err := r.change(ctx, "DELETE", record)
+	// Route 53 rejects deleting a record that does not exist
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		return nil
+	}
+	return err
+}
+
+func (r *Route53) change(ctx context.Context, action string, record Record) error {
+	value := record.Value
+	if record.Type == "SRV" {
+		// The target of SRV records is fully qualified
+		value = strings.TrimSuffix(value, ".") + "."
+	}
+	body, err := xml.Marshal(route53Change{Action: action, Name: record.Name + ".", Type: record.Type, TTL: record.TTL, Value: value})
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+
+	base := r.BaseURL
+	if base == "" {
+		base = route53API
+	}
+	zone := strings.TrimPrefix(r.HostedZoneID, "/hostedzone/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(base, "/")+"/2013-04-01/hostedzone/"+zone+"/rrset/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	signV4(req, body, r.AccessKey, r.SecretKey, route53Region, "route53", time.Now())
+
+	client := r.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `xml:"Error>Message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if xml.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("route53 returned %s: %s", resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("route53 returned %s", resp.Status)
+	}
+	return nil
+}
+
+// signV4 signs req with AWS Signature Version 4
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders, signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
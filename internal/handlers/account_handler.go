@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Lifetimes of the tokens mailed to users
+const (
+	passwordResetTTL = time.Hour
+	verifyEmailTTL   = 48 * time.Hour
+)
+
+// PasswordResetRequest represents the payload for requesting a password reset
+type PasswordResetRequest struct {
+	// Login is the username or verified email address of the account
+	Login string `json:"login" example:"steve"`
+}
+
+// ConfirmPasswordResetRequest represents the payload for setting a new password
+type ConfirmPasswordResetRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// VerifyEmailRequest represents the payload for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// SetEmailRequest represents the payload for changing the email address
+type SetEmailRequest struct {
+	Email string `json:"email" example:"steve@example.com"`
+}
+
+// RequestPasswordReset godoc
+// @Summary Request a password reset
+// @Description Mail a password reset link to the verified email address of an account. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body PasswordResetRequest true "Account"
+// @Success 202 {object} map[string]string
+// @Failure 503 {object} model.ErrorResponse
+// @Router /password-reset [post]
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	if !h.Mailer.Enabled() {
+		utils.WriteError(w, "Password reset is unavailable: email is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var user model.User
+	err := h.DB.Where("username = ? OR (email = ? AND email_verified = ?)", req.Login, strings.ToLower(req.Login), true).First(&user).Error
+	if err == nil && user.Email != nil && user.EmailVerified {
+		if err := h.sendPasswordReset(&user); err != nil {
+			slog.ErrorContext(r.Context(), "Error sending password reset", "user_id", user.ID, "error", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the account has a verified email address, a reset link is on its way",
+	})
+}
+
+// ConfirmPasswordReset godoc
+// @Summary Set a new password
+// @Description Set a new password with the token from a password reset email. Each token works once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ConfirmPasswordResetRequest true "Token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Router /password-reset/confirm [post]
+func (h *Handler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmPasswordResetRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	claims, err := h.JWT.ValidatePurposeToken(req.Token, utils.PurposePasswordReset)
+	if err != nil {
+		utils.WriteError(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	var user model.User
+	// The fingerprint changes with the password, so a used token is void
+	if err := h.DB.First(&user, claims.UserID).Error; err != nil || fingerprint(user.Password) != claims.Fingerprint {
+		utils.WriteError(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		utils.WriteError(w, "Error processing password", http.StatusInternalServerError)
+		return
+	}
+	if err := h.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+		utils.WriteError(w, "Failed to update password", http.StatusInternalServerError)
+		return
+	}
+	// Whoever knew the old password may still hold a token
+	if _, err := h.ServerManager.RevokeSessions(user.ID, ""); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke sessions", "user_id", user.ID, "error", err)
+	}
+
+	slog.InfoContext(r.Context(), "Password reset", "user_id", user.ID, "username", user.Username)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password updated successfully"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirm an email address with the token from a verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Router /verify-email [post]
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.JWT.ValidatePurposeToken(req.Token, utils.PurposeVerifyEmail)
+	if err != nil {
+		utils.WriteError(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	var user model.User
+	// The fingerprint changes with the address, so only the latest one verifies
+	if err := h.DB.First(&user, claims.UserID).Error; err != nil || user.Email == nil || fingerprint(*user.Email) != claims.Fingerprint {
+		utils.WriteError(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.Model(&user).Update("email_verified", true).Error; err != nil {
+		utils.WriteError(w, "Failed to verify email", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Email verified successfully"})
+}
+
+// SetEmail godoc
+// @Summary Change the email address
+// @Description Set the email address of the current user and mail a verification link to it. The address is used for password resets once verified.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body SetEmailRequest true "Email address"
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.ErrorResponse
+// @Router /me/email [put]
+func (h *Handler) SetEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req SetEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var user model.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		utils.WriteError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err := h.DB.Model(&user).Updates(map[string]interface{}{"email": email, "email_verified": false}).Error; err != nil {
+		utils.WriteError(w, "Failed to update email. It may already be in use.", http.StatusBadRequest)
+		return
+	}
+	user.Email = &email
+	user.EmailVerified = false
+
+	if err := h.sendEmailVerification(&user); err != nil && !errors.Is(err, utils.ErrMailDisabled) {
+		slog.ErrorContext(r.Context(), "Error sending email verification", "user_id", user.ID, "error", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+// sendPasswordReset mails a password reset link to a user's verified address
+func (h *Handler) sendPasswordReset(user *model.User) error {
+	token, err := h.JWT.GeneratePurposeToken(user.ID, user.Username, utils.PurposePasswordReset, fingerprint(user.Password), passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Someone asked to reset the password of %s.\n\n"+
+		"Open %s to choose a new password, or send the token below to POST %s/password-reset/confirm.\n\n%s\n\n"+
+		"The link expires in one hour. If you did not ask for this, ignore this email.\n",
+		user.Username, h.publicLink("/reset-password", token), h.publicURL(APIPrefix), token)
+	return h.Mailer.Send(*user.Email, "Reset your password", body)
+}
+
+// sendEmailVerification mails a verification link to a user's address
+func (h *Handler) sendEmailVerification(user *model.User) error {
+	token, err := h.JWT.GeneratePurposeToken(user.ID, user.Username, utils.PurposeVerifyEmail, fingerprint(*user.Email), verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Confirm that %s belongs to %s by opening %s, or send the token below to POST %s/verify-email.\n\n%s\n",
+		*user.Email, user.Username, h.publicLink("/verify-email", token), h.publicURL(APIPrefix), token)
+	return h.Mailer.Send(*user.Email, "Verify your email address", body)
+}
+
+// publicURL returns path below the configured public URL
+func (h *Handler) publicURL(path string) string {
+	return strings.TrimSuffix(h.Config.Server.PublicURL, "/") + path
+}
+
+// publicLink returns a link below the public URL carrying a token
+func (h *Handler) publicLink(path, token string) string {
+	return h.publicURL(path) + "?token=" + url.QueryEscape(token)
+}
+
+// normalizeEmail checks that s is a bare email address and lower-cases it
+func normalizeEmail(s string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(s))
+	if err != nil || addr.Name != "" || addr.Address != strings.TrimSpace(s) {
+		return "", errors.New("invalid email address")
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// fingerprint returns a short digest binding a token to account state
+func fingerprint(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
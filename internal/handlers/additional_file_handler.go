@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// AttachAdditionalFileRequest names the additional file to attach to a server
+type AttachAdditionalFileRequest struct {
+	AdditionalFileID uint `json:"additional_file_id" validate:"required"`
+}
+
+// ListServerAdditionalFiles godoc
+// @Summary List the additional files of a server
+// @Description Get the additional files attached to a server
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.AdditionalFile
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/additional-files [get]
+func (h *Handler) ListServerAdditionalFiles(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionView)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	files, err := h.ServerManager.ListServerAdditionalFiles(id, userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to fetch additional files")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(files)
+}
+
+// AttachAdditionalFile godoc
+// @Summary Attach an additional file to a server
+// @Description Attach an additional file to a server and place it by its type: config files are copied into the server root, plugins linked into plugins/, mods into mods/ and datapacks into the datapacks directory of the world. A running server picks the file up on its next start. Mods cannot be attached to a server whose mods come from a mod pack.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body AttachAdditionalFileRequest true "Additional file"
+// @Success 201 {object} model.AdditionalFile
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/additional-files [post]
+func (h *Handler) AttachAdditionalFile(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionFiles)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	var req AttachAdditionalFileRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	file, err := h.ServerManager.AttachAdditionalFile(id, userID, req.AdditionalFileID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error attaching additional file", "error", err)
+		switch {
+		case errors.Is(err, server_manager.ErrAdditionalFileNotFound):
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, server_manager.ErrModsProvisioned), errors.Is(err, server_manager.ErrServerArchived), errors.Is(err, server_manager.ErrServerOnNode):
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+		default:
+			serverAccessError(w, err, "Failed to attach additional file")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(file)
+}
+
+// DetachAdditionalFile godoc
+// @Summary Detach an additional file from a server
+// @Description Detach an additional file from a server and remove it from the server directory. Config files are left in place since the server may have changed them.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param file_id path uint true "Additional file ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/additional-files/{file_id} [delete]
+func (h *Handler) DetachAdditionalFile(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionFiles)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+	fileID, err := strconv.ParseUint(mux.Vars(r)["file_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid additional file ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DetachAdditionalFile(id, userID, uint(fileID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Additional file not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error detaching additional file", "error", err)
+		serverAccessError(w, err, "Failed to detach additional file")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Additional file detached"})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// addonError writes the response for an error of an addon operation
+func addonError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, server_manager.ErrServerRunning) {
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	serverAccessError(w, err, message)
+}
+
+// InstallGeyser godoc
+// @Summary Set up Bedrock cross-play
+// @Description Download the latest Geyser and Floodgate into a stopped server and configure Geyser on a free UDP port, with Floodgate authenticating Bedrock players. Calling it again updates both plugins.
+// @Tags addons
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server_manager.GeyserInstall
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/geyser [post]
+func (h *Handler) InstallGeyser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	install, err := h.ServerManager.InstallGeyser(uint(id), userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error installing Geyser", "error", err)
+		addonError(w, err, "Failed to install Geyser")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(install)
+}
+
+// ListAddons godoc
+// @Summary List the addons of a server
+// @Description Get the plugins the manager installed into a server
+// @Tags addons
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.Addon
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/addons [get]
+func (h *Handler) ListAddons(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	addons, err := h.ServerManager.ListAddons(uint(id), userID)
+	if err != nil {
+		addonError(w, err, "Failed to fetch addons")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(addons)
+}
+
+// RemoveAddon godoc
+// @Summary Remove an addon
+// @Description Delete the plugin jar of an addon from a stopped server. Its configuration is kept.
+// @Tags addons
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param addon_id path uint true "Addon ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/addons/{addon_id} [delete]
+func (h *Handler) RemoveAddon(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	addonID, err := strconv.ParseUint(vars["addon_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid addon ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.RemoveAddon(uint(id), uint(addonID), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Addon not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error removing addon", "error", err)
+		addonError(w, err, "Failed to remove addon")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Addon removed"})
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ReconcileRequest selects the repairs of a reconcile run
+type ReconcileRequest struct {
+	// AdoptOrphans imports orphan directories as servers owned by the caller
+	AdoptOrphans bool `json:"adopt_orphans"`
+	// DeleteMissing soft deletes servers whose directory is missing
+	DeleteMissing bool `json:"delete_missing"`
+}
+
+// requireAdmin writes an error and returns false unless the caller is an admin
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+	if !h.isAdmin(userID) {
+		utils.WriteError(w, "Forbidden", http.StatusForbidden)
+		return 0, false
+	}
+	return userID, true
+}
+
+// isAdmin reports whether a user has the admin role
+func (h *Handler) isAdmin(userID uint) bool {
+	var user model.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		return false
+	}
+	return h.roleOf(&user) == model.RoleAdmin
+}
+
+// SetUserRoleRequest represents the payload for changing a user's role
+type SetUserRoleRequest struct {
+	Role string `json:"role" example:"operator" validate:"oneof=viewer operator admin"`
+}
+
+// SetUserQuotaRequest represents the payload for changing a user's quotas.
+// Null fields fall back to the configured limits; 0 means unlimited.
+type SetUserQuotaRequest struct {
+	MaxServers  *int64 `json:"max_servers" example:"3" validate:"omitempty,gte=0"`
+	MaxMemoryMB *int64 `json:"max_memory_mb" example:"8192" validate:"omitempty,gte=0"`
+	MaxDiskMB   *int64 `json:"max_disk_mb" example:"20480" validate:"omitempty,gte=0"`
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Get all users with their roles. Admin only.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} model.User
+// @Failure 403 {object} model.ErrorResponse
+// @Router /admin/users [get]
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	var users []model.User
+	if err := h.DB.Order("id").Find(&users).Error; err != nil {
+		utils.WriteError(w, "Failed to fetch users", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(users)
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Description Make a user a viewer, operator or admin. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body SetUserRoleRequest true "Role"
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /admin/users/{id}/role [put]
+func (h *Handler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SetUserRoleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var user model.User
+	if err := h.DB.First(&user, id).Error; err != nil {
+		utils.WriteError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err := h.DB.Model(&user).Update("role", req.Role).Error; err != nil {
+		utils.WriteError(w, "Failed to update role", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+// SetUserQuota godoc
+// @Summary Change a user's quotas
+// @Description Set the server count, allocated RAM and disk quotas of a user, for example to match a hosting plan. Null fields use the configured limits. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body SetUserQuotaRequest true "Quotas"
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /admin/users/{id}/quota [put]
+func (h *Handler) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SetUserQuotaRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var user model.User
+	if err := h.DB.First(&user, id).Error; err != nil {
+		utils.WriteError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	user.MaxServers = req.MaxServers
+	user.MaxMemoryMB = req.MaxMemoryMB
+	user.MaxDiskMB = req.MaxDiskMB
+	if err := h.DB.Model(&user).Select("MaxServers", "MaxMemoryMB", "MaxDiskMB").Updates(&user).Error; err != nil {
+		utils.WriteError(w, "Failed to update quotas", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+// GetReconcileReport godoc
+// @Summary Compare servers with the disk
+// @Description List directories in the servers directory that belong to no server, and servers whose directory is missing. Admin only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} server_manager.ReconcileReport
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /admin/reconcile [get]
+func (h *Handler) GetReconcileReport(w http.ResponseWriter, r *http.Request) {
+	h.reconcile(w, r, server_manager.ReconcileOptions{})
+}
+
+// Reconcile godoc
+// @Summary Repair differences between servers and the disk
+// @Description Optionally adopt orphan directories as servers owned by the caller and soft delete servers whose directory is missing, then return the report. Orphan directories are never removed. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body ReconcileRequest true "Repairs to perform"
+// @Success 200 {object} server_manager.ReconcileReport
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /admin/reconcile [post]
+func (h *Handler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	var req ReconcileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	h.reconcile(w, r, server_manager.ReconcileOptions{
+		AdoptOrphans:  req.AdoptOrphans,
+		DeleteMissing: req.DeleteMissing,
+	})
+}
+
+func (h *Handler) reconcile(w http.ResponseWriter, r *http.Request, opts server_manager.ReconcileOptions) {
+	userID, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	opts.AdoptUserID = userID
+
+	serversDir, err := server_manager.ServersDir()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting current working directory", "error", err)
+		utils.WriteError(w, "Failed to reconcile servers", http.StatusInternalServerError)
+		return
+	}
+
+	report, err := h.ServerManager.Reconcile(r.Context(), serversDir, opts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reconciling servers", "error", err)
+		utils.WriteError(w, "Failed to reconcile servers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ReloadConfigHandler godoc
+// @Summary Reload the configuration
+// @Description Re-read the configuration file and environment and apply the log level, default limits and JWT expiration without a restart. Other settings need a restart. Sending SIGHUP to the process does the same. Admin only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Failure 501 {object} model.ErrorResponse
+// @Router /admin/config/reload [post]
+func (h *Handler) ReloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	if h.ReloadConfig == nil {
+		utils.WriteError(w, "Config reload is not available", http.StatusNotImplemented)
+		return
+	}
+
+	if err := h.ReloadConfig(); err != nil {
+		slog.ErrorContext(r.Context(), "Error reloading config", "error", err)
+		utils.WriteError(w, "Failed to reload config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Config reloaded successfully"})
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CreateAlertChannelRequest represents the payload for creating an alert channel
+type CreateAlertChannelRequest struct {
+	Name string `json:"name" example:"ops" validate:"required,max=255"`
+	Type string `json:"type" example:"discord" validate:"required,oneof=email discord webhook"`
+	// Target is the email address or URL notifications are sent to
+	Target string `json:"target" example:"https://discord.com/api/webhooks/123/abc" validate:"required,max=2048"`
+}
+
+// CreateAlertRuleRequest represents the payload for creating an alert rule
+type CreateAlertRuleRequest struct {
+	Name string `json:"name" example:"Low TPS" validate:"required,max=255"`
+	// ServerID limits the rule to one server; omit it for all servers
+	ServerID  *uint   `json:"server_id,omitempty"`
+	Condition string  `json:"condition" example:"tps_below" validate:"required,oneof=server_down tps_below cpu_above disk_above"`
+	Threshold float64 `json:"threshold" example:"15"`
+	// For is how long the condition must hold before the rule fires, e.g. 5m
+	For        string `json:"for,omitempty" example:"5m"`
+	ChannelIDs []uint `json:"channel_ids" validate:"required,min=1"`
+}
+
+// CreateAlertChannel godoc
+// @Summary Create an alert channel
+// @Description Register where alert notifications are sent: an email address, a Discord webhook URL, or a URL that receives the rule and alert as a JSON POST
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param request body CreateAlertChannelRequest true "Channel"
+// @Success 201 {object} model.AlertChannel
+// @Failure 400 {object} model.ErrorResponse
+// @Router /alerts/channels [post]
+func (h *Handler) CreateAlertChannel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateAlertChannelRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	channel, err := h.ServerManager.CreateAlertChannel(userID, req.Name, req.Type, req.Target)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating alert channel", "error", err)
+		utils.WriteError(w, "Failed to create alert channel: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(channel)
+}
+
+// ListAlertChannels godoc
+// @Summary List alert channels
+// @Tags alerts
+// @Produce json
+// @Success 200 {array} model.AlertChannel
+// @Failure 500 {object} model.ErrorResponse
+// @Router /alerts/channels [get]
+func (h *Handler) ListAlertChannels(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	channels, err := h.ServerManager.ListAlertChannels(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch alert channels", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(channels)
+}
+
+// DeleteAlertChannel godoc
+// @Summary Delete an alert channel
+// @Description Delete an alert channel that no alert rule uses
+// @Tags alerts
+// @Produce json
+// @Param id path int true "Channel ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /alerts/channels/{id} [delete]
+func (h *Handler) DeleteAlertChannel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteAlertChannel(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Alert channel not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to delete alert channel: "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Alert channel deleted successfully"})
+}
+
+// CreateAlertRule godoc
+// @Summary Create an alert rule
+// @Description Create a rule the metrics sampler evaluates every minute: server_down (crashed, or an auto-start server not running), tps_below, cpu_above (percent) or disk_above (percent of the server's filesystem). It fires once the condition has held for the given duration and resolves when it clears, notifying its channels both times.
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param request body CreateAlertRuleRequest true "Rule"
+// @Success 201 {object} model.AlertRule
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /alerts/rules [post]
+func (h *Handler) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateAlertRuleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	var span time.Duration
+	if req.For != "" {
+		var err error
+		if span, err = parseRange(req.For); err != nil {
+			utils.WriteError(w, "Invalid duration", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rule, err := h.ServerManager.CreateAlertRule(userID, server_manager.AlertRuleOptions{
+		Name:       req.Name,
+		ServerID:   req.ServerID,
+		Condition:  req.Condition,
+		Threshold:  req.Threshold,
+		For:        span,
+		ChannelIDs: req.ChannelIDs,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating alert rule", "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, server_manager.ErrPermissionDenied) {
+			serverAccessError(w, err, "Failed to create alert rule")
+			return
+		}
+		utils.WriteError(w, "Failed to create alert rule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// ListAlertRules godoc
+// @Summary List alert rules
+// @Tags alerts
+// @Produce json
+// @Success 200 {array} model.AlertRule
+// @Failure 500 {object} model.ErrorResponse
+// @Router /alerts/rules [get]
+func (h *Handler) ListAlertRules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rules, err := h.ServerManager.ListAlertRules(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch alert rules", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rules)
+}
+
+// DeleteAlertRule godoc
+// @Summary Delete an alert rule
+// @Description Delete an alert rule and its alert history
+// @Tags alerts
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /alerts/rules/{id} [delete]
+func (h *Handler) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteAlertRule(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Alert rule not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to delete alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Alert rule deleted successfully"})
+}
+
+// ListAlerts godoc
+// @Summary List alerts
+// @Description Get the most recent alerts of the current user's rules, newest first
+// @Tags alerts
+// @Produce json
+// @Param server_id query int false "Only alerts of this server"
+// @Param state query string false "firing to get only the alerts that have not resolved"
+// @Success 200 {array} model.Alert
+// @Failure 400 {object} model.ErrorResponse
+// @Router /alerts [get]
+func (h *Handler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	var serverID *uint
+	if value := query.Get("server_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+			return
+		}
+		sid := uint(id)
+		serverID = &sid
+	}
+
+	alerts, err := h.ServerManager.ListAlerts(userID, serverID, query.Get("state") == "firing")
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch alerts", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(alerts)
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// AnnouncementRequest represents the payload for creating or updating an announcement
+type AnnouncementRequest struct {
+	Message string `json:"message" example:"Join our Discord at discord.gg/example" validate:"required,max=4096"`
+	// Format is say (default) or tellraw, which also takes a JSON text component
+	Format string `json:"format,omitempty" example:"say" validate:"omitempty,oneof=say tellraw"`
+	// Cron schedules the announcement; set either cron or interval
+	Cron string `json:"cron,omitempty" example:"0 * * * *"`
+	// Interval repeats the announcement, e.g. 30m
+	Interval string `json:"interval,omitempty" example:"30m"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+}
+
+// options converts the request into announcement options, writing the
+// error response if the interval is invalid
+func (req *AnnouncementRequest) options(w http.ResponseWriter) (server_manager.AnnouncementOptions, bool) {
+	opts := server_manager.AnnouncementOptions{
+		Message: req.Message,
+		Format:  req.Format,
+		Cron:    req.Cron,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if req.Interval != "" {
+		interval, err := time.ParseDuration(req.Interval)
+		if err != nil {
+			utils.WriteError(w, "Invalid interval", http.StatusBadRequest)
+			return opts, false
+		}
+		opts.Interval = interval
+	}
+	return opts, true
+}
+
+// announcementError writes the response for an error of an announcement operation
+func announcementError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, server_manager.ErrPermissionDenied) {
+		serverAccessError(w, err, message)
+		return
+	}
+	utils.WriteError(w, message+": "+err.Error(), http.StatusBadRequest)
+}
+
+// announcementIDs parses the server and announcement IDs of a route
+func announcementIDs(w http.ResponseWriter, r *http.Request) (uint, uint, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	announcementID, err := strconv.ParseUint(vars["announcement_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid announcement ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uint(id), uint(announcementID), true
+}
+
+// ListAnnouncements godoc
+// @Summary List the announcements of a server
+// @Tags announcements
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.Announcement
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/announcements [get]
+func (h *Handler) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	announcements, err := h.ServerManager.ListAnnouncements(uint(id), userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to fetch announcements")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(announcements)
+}
+
+// CreateAnnouncement godoc
+// @Summary Schedule an announcement
+// @Description Broadcast a message to the players of a server on a cron schedule or at an interval of at least a minute, with say or tellraw. Announcements due while the server is stopped are skipped.
+// @Tags announcements
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body AnnouncementRequest true "Announcement"
+// @Success 201 {object} model.Announcement
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/announcements [post]
+func (h *Handler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req AnnouncementRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	opts, ok := req.options(w)
+	if !ok {
+		return
+	}
+
+	announcement, err := h.ServerManager.CreateAnnouncement(uint(id), userID, opts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating announcement", "error", err)
+		announcementError(w, err, "Failed to create announcement")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(announcement)
+}
+
+// UpdateAnnouncement godoc
+// @Summary Update an announcement
+// @Description Replace the message and schedule of an announcement
+// @Tags announcements
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param announcement_id path uint true "Announcement ID"
+// @Param request body AnnouncementRequest true "Announcement"
+// @Success 200 {object} model.Announcement
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/announcements/{announcement_id} [put]
+func (h *Handler) UpdateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, announcementID, ok := announcementIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req AnnouncementRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	opts, ok := req.options(w)
+	if !ok {
+		return
+	}
+
+	announcement, err := h.ServerManager.UpdateAnnouncement(id, announcementID, userID, opts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating announcement", "error", err)
+		announcementError(w, err, "Failed to update announcement")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(announcement)
+}
+
+// DeleteAnnouncement godoc
+// @Summary Delete an announcement
+// @Tags announcements
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param announcement_id path uint true "Announcement ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/announcements/{announcement_id} [delete]
+func (h *Handler) DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, announcementID, ok := announcementIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ServerManager.DeleteAnnouncement(id, announcementID, userID); err != nil {
+		serverAccessError(w, err, "Failed to delete announcement")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Announcement deleted successfully"})
+}
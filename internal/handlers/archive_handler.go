@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// archiveError writes 409 for servers in the wrong archive state and 507
+// for a failed disk space check, and returns true when it wrote a response
+func archiveError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, server_manager.ErrServerArchived) || errors.Is(err, server_manager.ErrServerNotArchived) || errors.Is(err, server_manager.ErrServerOnNode) {
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+		return true
+	}
+	return diskError(w, err)
+}
+
+// ArchiveServer godoc
+// @Summary Archive a server
+// @Description Stop a server, compress its directory into cold storage (the configured backup storage, or the local backups volume) and delete the directory to free disk. The server cannot be started, backed up or cloned until it is thawed.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} model.Server
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Failure 507 {object} model.ErrorResponse
+// @Router /servers/{id}/archive [post]
+func (h *Handler) ArchiveServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	serverModel, err := h.ServerManager.ArchiveServer(r.Context(), uint(id), userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error archiving server", "error", err)
+		if archiveError(w, err) {
+			return
+		}
+		serverAccessError(w, err, "Failed to archive server")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(serverModel)
+}
+
+// ThawServer godoc
+// @Summary Thaw an archived server
+// @Description Restore the directory of an archived server from cold storage and delete the archive. The server is left stopped.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} model.Server
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Failure 507 {object} model.ErrorResponse
+// @Router /servers/{id}/thaw [post]
+func (h *Handler) ThawServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	serverModel, err := h.ServerManager.ThawServer(r.Context(), uint(id), userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error thawing server", "error", err)
+		if archiveError(w, err) {
+			return
+		}
+		serverAccessError(w, err, "Failed to thaw server")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(serverModel)
+}
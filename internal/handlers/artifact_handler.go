@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ArtifactGCRequest represents the payload for an artifact garbage collection run
+type ArtifactGCRequest struct {
+	Remove bool `json:"remove"`
+}
+
+// DeleteJarFile godoc
+// @Summary Delete a shared JAR file
+// @Description Delete a JAR file and its file on disk. Refused while a server or template still uses it.
+// @Tags jar-files
+// @Produce json
+// @Param id path int true "JAR file ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /jar-files/{id} [delete]
+func (h *Handler) DeleteJarFile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid JAR file ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteJarFile(uint(id)); err != nil {
+		writeArtifactDeleteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "JAR file deleted successfully"})
+}
+
+// DeleteModPack godoc
+// @Summary Delete a shared mod pack
+// @Description Delete a mod pack and its files on disk. Refused while a server or template still uses it.
+// @Tags mod-packs
+// @Produce json
+// @Param id path int true "Mod pack ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /mod-packs/{id} [delete]
+func (h *Handler) DeleteModPack(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid mod pack ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteModPack(uint(id)); err != nil {
+		writeArtifactDeleteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Mod pack deleted successfully"})
+}
+
+func writeArtifactDeleteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, "Artifact not found", http.StatusNotFound)
+	case errors.Is(err, server_manager.ErrArtifactInUse):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	default:
+		utils.WriteError(w, "Failed to delete artifact: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CollectArtifactGarbage godoc
+// @Summary Garbage collect shared artifacts
+// @Description Report files in the shared jar and mod pack directories that no record references, optionally removing them. Files written within the last hour are skipped, as they may belong to uploads in flight.
+// @Tags artifacts
+// @Accept json
+// @Produce json
+// @Param request body ArtifactGCRequest false "Set remove to delete unreferenced files"
+// @Success 200 {object} server_manager.GCReport
+// @Failure 500 {object} model.ErrorResponse
+// @Router /artifacts/gc [post]
+func (h *Handler) CollectArtifactGarbage(w http.ResponseWriter, r *http.Request) {
+	var req ArtifactGCRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.ServerManager.CollectGarbage(req.Remove)
+	if err != nil {
+		utils.WriteError(w, "Failed to collect garbage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ApplyModPackRequest represents the payload for applying a mod pack to running servers
+type ApplyModPackRequest struct {
+	// Strategy is "reload", "restart" or "next-start" (default)
+	Strategy string `json:"strategy" example:"restart"`
+}
+
+// ApplyModPack godoc
+// @Summary Apply a mod pack to the servers using it
+// @Description Start re-provisioning a shared mod pack into every server of the current user that uses it, then reloading or restarting the running ones. Servers are handled in the background; poll GET /mod-packs/{id}/apply for a result per server. Reload is refused when a server runs Forge, NeoForge, Fabric or Quilt, which cannot reload mods.
+// @Tags mod-packs
+// @Accept json
+// @Produce json
+// @Param id path int true "Mod pack ID"
+// @Param request body ApplyModPackRequest false "Apply strategy"
+// @Success 202 {object} server_manager.ModPackApply
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /mod-packs/{id}/apply [post]
+func (h *Handler) ApplyModPack(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid mod pack ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ApplyModPackRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	apply, err := h.ServerManager.ApplyModPack(r.Context(), uint(id), userID, req.Strategy)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.WriteError(w, "Mod pack not found", http.StatusNotFound)
+		case errors.Is(err, server_manager.ErrModPackApplyRunning):
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+		default:
+			utils.WriteError(w, "Failed to apply mod pack: "+err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(apply)
+}
+
+// GetModPackApply godoc
+// @Summary Get the progress of a mod pack apply
+// @Description Get the most recent apply of a mod pack by the current user with a result for each server handled so far
+// @Tags mod-packs
+// @Produce json
+// @Param id path int true "Mod pack ID"
+// @Success 200 {object} server_manager.ModPackApply
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /mod-packs/{id}/apply [get]
+func (h *Handler) GetModPackApply(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid mod pack ID", http.StatusBadRequest)
+		return
+	}
+
+	apply, err := h.ServerManager.GetModPackApply(uint(id), userID)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(apply)
+}
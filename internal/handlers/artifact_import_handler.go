@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ImportJarFileRequest names a jar file to download
+type ImportJarFileRequest struct {
+	URL string `json:"url" example:"https://api.papermc.io/v2/projects/paper/versions/1.21.1/builds/119/downloads/paper-1.21.1-119.jar" validate:"required,url,max=2048"`
+	// Checksum is the SHA-256 digest of the file in hex, optionally prefixed
+	// with "sha256:"
+	Checksum string `json:"checksum,omitempty" validate:"max=71"`
+	Name     string `json:"name" example:"paper" validate:"required,max=255"`
+	Version  string `json:"version" example:"1.21.1" validate:"required,max=64"`
+}
+
+// ImportModPackRequest names a mod pack to download
+type ImportModPackRequest struct {
+	URL string `json:"url" validate:"required,url,max=2048"`
+	// Checksum is the SHA-256 digest of the file in hex, optionally prefixed
+	// with "sha256:"
+	Checksum string `json:"checksum,omitempty" validate:"max=71"`
+	// Name defaults to the name of the downloaded file
+	Name    string `json:"name,omitempty" example:"all-the-mods-9" validate:"max=255"`
+	Version string `json:"version,omitempty" example:"0.3.2" validate:"max=64"`
+}
+
+// importError writes the response for errors of an artifact import and
+// reports whether err was one
+func importError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, server_manager.ErrImportRejected):
+		utils.WriteError(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, server_manager.ErrImportFailed):
+		utils.WriteError(w, err.Error(), http.StatusBadGateway)
+	default:
+		return diskError(w, err)
+	}
+	return true
+}
+
+// ImportJarFile godoc
+// @Summary Import a shared JAR file from a URL
+// @Description Download a jar file from a public http(s) URL and register it as a shared jar file. The download must be a jar of at most 2 GB and match the checksum if one is given. Admin only.
+// @Tags jar-files
+// @Accept json
+// @Produce json
+// @Param request body ImportJarFileRequest true "Download URL and jar details"
+// @Success 201 {object} model.JarFile
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 502 {object} model.ErrorResponse
+// @Failure 507 {object} model.ErrorResponse
+// @Router /jar-files/import [post]
+func (h *Handler) ImportJarFile(w http.ResponseWriter, r *http.Request) {
+	var req ImportJarFileRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	jarFile, err := h.ServerManager.ImportJarFile(r.Context(), req.URL, req.Checksum, req.Name, req.Version)
+	if err != nil {
+		if importError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to import JAR file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(jarFile)
+}
+
+// ImportModPack godoc
+// @Summary Import a shared mod pack from a URL
+// @Description Download a mod pack from a public http(s) URL and register it as a shared mod pack, named after the downloaded file unless a name is given. The download must be a zip of at most 2 GB and match the checksum if one is given. Admin only.
+// @Tags mod-packs
+// @Accept json
+// @Produce json
+// @Param request body ImportModPackRequest true "Download URL"
+// @Success 201 {object} model.ModPack
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 502 {object} model.ErrorResponse
+// @Failure 507 {object} model.ErrorResponse
+// @Router /mod-packs/import [post]
+func (h *Handler) ImportModPack(w http.ResponseWriter, r *http.Request) {
+	var req ImportModPackRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	modPack, err := h.ServerManager.ImportModPack(r.Context(), req.URL, req.Checksum, req.Name, req.Version)
+	if err != nil {
+		if importError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to import mod pack: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(modPack)
+}
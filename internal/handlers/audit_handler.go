@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// defaultAuditPerPage is the page size of audit log listings without per_page
+const defaultAuditPerPage = 50
+
+// ListAuditLogs godoc
+// @Summary List the audit log
+// @Description Get state-changing requests of all users, newest first. The total number of matches is sent in the X-Total-Count header. Admin only.
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Entries per page (default 50, at most 100)"
+// @Param user_id query int false "Filter by user"
+// @Param server_id query int false "Filter by server"
+// @Param action query string false "Filter by action substring, e.g. /start"
+// @Success 200 {array} model.AuditLog
+// @Header 200 {int} X-Total-Count "Total number of matching entries"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Router /admin/audit-logs [get]
+func (h *Handler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	opts, ok := auditLogOptions(w, query)
+	if !ok {
+		return
+	}
+	for param, target := range map[string]*uint{"user_id": &opts.UserID, "server_id": &opts.ServerID} {
+		if value := query.Get(param); value != "" {
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				utils.WriteError(w, "Invalid "+param, http.StatusBadRequest)
+				return
+			}
+			*target = uint(n)
+		}
+	}
+
+	entries, total, err := h.ServerManager.ListAuditLogs(opts)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// ListServerAuditLogs godoc
+// @Summary List the audit log of a server
+// @Description Get state-changing requests made against a server, newest first. The total number of matches is sent in the X-Total-Count header.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Entries per page (default 50, at most 100)"
+// @Param action query string false "Filter by action substring, e.g. /start"
+// @Success 200 {array} model.AuditLog
+// @Header 200 {int} X-Total-Count "Total number of matching entries"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/audit-logs [get]
+func (h *Handler) ListServerAuditLogs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	opts, ok := auditLogOptions(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	entries, total, err := h.ServerManager.ListServerAuditLogs(uint(id), userID, opts)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// auditLogOptions parses the pagination and action filter of an audit log
+// listing, writing an error and returning false when they are invalid
+func auditLogOptions(w http.ResponseWriter, query url.Values) (server_manager.AuditLogOptions, bool) {
+	opts := server_manager.AuditLogOptions{PerPage: defaultAuditPerPage, Action: query.Get("action")}
+	for param, target := range map[string]*int{"page": &opts.Page, "per_page": &opts.PerPage} {
+		if value := query.Get(param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				utils.WriteError(w, "Invalid "+param, http.StatusBadRequest)
+				return opts, false
+			}
+			*target = n
+		}
+	}
+	if opts.PerPage > maxServersPerPage {
+		opts.PerPage = maxServersPerPage
+	}
+	return opts, true
+}
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
 	"github.com/olindenbaum/mcgonalds/internal/model"
 	"github.com/olindenbaum/mcgonalds/internal/utils"
 	"golang.org/x/crypto/bcrypt"
@@ -12,8 +14,11 @@ import (
 
 // SignupRequest represents the expected payload for signup
 type SignupRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required,max=64"`
+	// Password is limited by bcrypt, which ignores bytes past 72
+	Password string `json:"password" validate:"required,max=72"`
+	// Email is optional; a verification link is mailed to it
+	Email string `json:"email,omitempty"`
 }
 
 // LoginRequest represents the expected payload for login
@@ -25,45 +30,73 @@ type LoginRequest struct {
 // Signup handles user registration
 // Signup godoc
 // @Summary Register a new user
-// @Description Create a new user account
+// @Description Create a new user account. Usernames listed as admins in the configuration are reserved and can only be created with the admin create-user command.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body SignupRequest true "User signup information"
 // @Success 201 {object} map[string]string "User created successfully"
-// @Failure 400 {string} string "Invalid request payload or user creation error"
+// @Failure 400 {string} string "Invalid request payload, reserved username or user creation error"
 // @Failure 500 {string} string "Error processing password"
 // @Router /signup [post]
 func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
-	log.Println("Signup request received")
 	var req SignupRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Invalid request payload: %v", err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "Invalid request payload", "error", err)
+		utils.WriteError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if !validRequest(w, req) {
+		return
+	}
+	if h.reservedUsername(req.Username) {
+		utils.WriteError(w, "Username is reserved", http.StatusBadRequest)
 		return
 	}
 
+	var email *string
+	if req.Email != "" {
+		normalized, err := normalizeEmail(req.Email)
+		if err != nil {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		email = &normalized
+	}
+
 	// Hash the password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Error processing password: %v", err)
-		http.Error(w, "Error processing password", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error processing password", "error", err)
+		utils.WriteError(w, "Error processing password", http.StatusInternalServerError)
 		return
 	}
 
+	role := h.Config.DefaultRole
+	if !model.ValidRole(role) {
+		role = model.RoleViewer
+	}
 	user := model.User{
 		Username: req.Username,
 		Password: string(hashedPassword),
+		Role:     role,
+		Email:    email,
 	}
 
 	if err := h.DB.Create(&user).Error; err != nil {
-		log.Printf("Error creating user: %v", err)
-		http.Error(w, "Error creating user. Username may already be in use.", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "Error creating user", "error", err)
+		utils.WriteError(w, "Error creating user. Username may already be in use.", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("User created successfully: %s", user.Username)
+	if email != nil {
+		if err := h.sendEmailVerification(&user); err != nil && !errors.Is(err, utils.ErrMailDisabled) {
+			slog.ErrorContext(r.Context(), "Error sending email verification", "user_id", user.ID, "error", err)
+		}
+	}
+
+	slog.InfoContext(r.Context(), "User created", "user_id", user.ID, "username", user.Username)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "User created successfully",
@@ -81,37 +114,36 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 // @Failure 401 {string} string "Invalid username or password"
 // @Router /login [post]
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
-	log.Println("Login request received")
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Invalid request payload: %v", err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "Invalid request payload", "error", err)
+		utils.WriteError(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
 	var user model.User
 	if err := h.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		log.Printf("Invalid login attempt for username: %s", req.Username)
-		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		slog.WarnContext(r.Context(), "Invalid login attempt", "username", req.Username, "remote_ip", middleware.ClientIP(r))
+		utils.WriteError(w, "Invalid username or password", http.StatusUnauthorized)
 		return
 	}
 
 	// Compare the password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		log.Printf("Invalid password attempt for user: %s", user.Username)
-		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		slog.WarnContext(r.Context(), "Invalid password attempt", "username", user.Username, "remote_ip", middleware.ClientIP(r))
+		utils.WriteError(w, "Invalid username or password", http.StatusUnauthorized)
 		return
 	}
 
 	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Username)
+	token, err := h.issueToken(r, &user)
 	if err != nil {
-		log.Printf("Error generating token for user %s: %v", user.Username, err)
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error generating token", "user_id", user.ID, "error", err)
+		utils.WriteError(w, "Error generating token", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("User %s logged in successfully", user.Username)
+	slog.InfoContext(r.Context(), "User logged in", "user_id", user.ID, "username", user.Username)
 	json.NewEncoder(w).Encode(map[string]string{
 		"token": token,
 	})
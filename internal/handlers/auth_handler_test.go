@@ -0,0 +1,32 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignupRefusesAdminUsernames(t *testing.T) {
+	h, db := newHandler(t)
+	h.Config.Admins = []string{"root"}
+
+	signup := func(username string) int {
+		body, _ := json.Marshal(map[string]string{"username": username, "password": "hunter22"})
+		rr := httptest.NewRecorder()
+		h.Signup(rr, httptest.NewRequest(http.MethodPost, "/api/v1/signup", bytes.NewReader(body)))
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, signup("root"))
+	var count int64
+	require.NoError(t, db.Model(&model.User{}).Where("username = ?", "root").Count(&count).Error)
+	assert.Zero(t, count, "an account with an admin username was created")
+
+	assert.Equal(t, http.StatusCreated, signup("alice"))
+}
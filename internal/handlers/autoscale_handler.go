@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ScalingPolicyRequest represents the payload for setting the scaling policy of a proxy
+type ScalingPolicyRequest struct {
+	// TemplateID is the template new instances are created from
+	TemplateID   uint `json:"template_id" validate:"required"`
+	MinInstances int  `json:"min_instances" example:"1" validate:"gte=1,lte=100"`
+	MaxInstances int  `json:"max_instances" example:"4" validate:"gte=1,lte=100"`
+	// ScaleUpPlayers is the number of players per running instance at which another one is started
+	ScaleUpPlayers int `json:"scale_up_players" example:"20" validate:"gte=1"`
+	// IdleMinutes is how long an instance has to be empty before it is stopped
+	IdleMinutes int   `json:"idle_minutes" example:"10" validate:"gte=1"`
+	Enabled     *bool `json:"enabled,omitempty"`
+}
+
+// scalingPolicyError writes the response for an error reading or deleting
+// a scaling policy, which is not found either with its proxy or by itself
+func scalingPolicyError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	proxyError(w, err, message)
+}
+
+// SetScalingPolicy godoc
+// @Summary Set the scaling policy of a proxy
+// @Description Create or replace the policy the autoscaler applies to the servers behind a proxy. It keeps at least min_instances running, starts another instance from the template once there are scale_up_players players per running instance, up to max_instances, and stops instances that have been empty for idle_minutes. New instances are created next to the proxy, shared with its team and attached to it. Scaling steps appear in the activity feed.
+// @Tags proxies
+// @Accept json
+// @Produce json
+// @Param id path uint true "Proxy server ID"
+// @Param request body ScalingPolicyRequest true "Scaling policy"
+// @Success 200 {object} model.ScalingPolicy
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy/autoscale [put]
+func (h *Handler) SetScalingPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ScalingPolicyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	opts := server_manager.ScalingOptions{
+		TemplateID:     req.TemplateID,
+		MinInstances:   req.MinInstances,
+		MaxInstances:   req.MaxInstances,
+		ScaleUpPlayers: req.ScaleUpPlayers,
+		IdleMinutes:    req.IdleMinutes,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+	}
+	policy, err := h.ServerManager.SetScalingPolicy(uint(id), userID, opts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error setting scaling policy", "error", err)
+		proxyError(w, err, "Failed to set scaling policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// GetScalingPolicy godoc
+// @Summary Get the scaling policy of a proxy
+// @Tags proxies
+// @Produce json
+// @Param id path uint true "Proxy server ID"
+// @Success 200 {object} model.ScalingPolicy
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy/autoscale [get]
+func (h *Handler) GetScalingPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.ServerManager.GetScalingPolicy(uint(id), userID)
+	if err != nil {
+		scalingPolicyError(w, err, "Failed to get scaling policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// DeleteScalingPolicy godoc
+// @Summary Delete the scaling policy of a proxy
+// @Description Stop autoscaling the servers behind a proxy. Instances created by the autoscaler are kept as they are.
+// @Tags proxies
+// @Produce json
+// @Param id path uint true "Proxy server ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy/autoscale [delete]
+func (h *Handler) DeleteScalingPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteScalingPolicy(uint(id), userID); err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting scaling policy", "error", err)
+		scalingPolicyError(w, err, "Failed to delete scaling policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Scaling policy deleted successfully"})
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CreateBackupRequest represents the payload for creating a backup
+type CreateBackupRequest struct {
+	Name string `json:"name,omitempty" example:"before-1.21-upgrade" validate:"max=128"`
+	// Mode is "full" (default) or "incremental"
+	Mode string `json:"mode,omitempty" example:"incremental" validate:"omitempty,oneof=full incremental"`
+}
+
+// CreateBackup godoc
+// @Summary Back up a server
+// @Description Archive the server directory. Running servers have saving paused and the world flushed while the archive is written. The backup runs asynchronously. Incremental backups only store files changed since the previous incremental backup and restore like full ones.
+// @Tags backups
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body CreateBackupRequest false "Backup name"
+// @Param Idempotency-Key header string false "Key to retry the request with without repeating it"
+// @Success 202 {object} model.Backup
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/backups [post]
+func (h *Handler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateBackupRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if !validRequest(w, req) {
+		return
+	}
+
+	backup, err := h.ServerManager.CreateBackup(r.Context(), uint(id), userID, req.Name, req.Mode)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating backup", "error", err)
+		if diskError(w, err) {
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerArchived) || errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if fileLockError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to create backup: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(backup)
+}
+
+// ListBackups godoc
+// @Summary List backups of a server
+// @Description Get all backups of a server, newest first
+// @Tags backups
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.Backup
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/backups [get]
+func (h *Handler) ListBackups(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	backups, err := h.ServerManager.ListBackups(uint(id), userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch backups: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(backups)
+}
+
+// GetBackup godoc
+// @Summary Get a backup
+// @Description Get the status and size of a backup
+// @Tags backups
+// @Produce json
+// @Param id path int true "Backup ID"
+// @Success 200 {object} model.Backup
+// @Failure 404 {object} model.ErrorResponse
+// @Router /backups/{id} [get]
+func (h *Handler) GetBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid backup ID", http.StatusBadRequest)
+		return
+	}
+
+	backup, err := h.ServerManager.GetBackup(uint(id), userID)
+	if err != nil {
+		utils.WriteError(w, "Backup not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(backup)
+}
+
+// RestoreBackup godoc
+// @Summary Restore a backup
+// @Description Stop the server and replace its directory with the contents of the backup. Refused with 409 while a backup of the server is in progress.
+// @Tags backups
+// @Produce json
+// @Param id path int true "Backup ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /backups/{id}/restore [post]
+func (h *Handler) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid backup ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.RestoreBackup(uint(id), userID); err != nil {
+		slog.ErrorContext(r.Context(), "Error restoring backup", "error", err)
+		if diskError(w, err) {
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerArchived) || errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if fileLockError(w, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Backup not found", http.StatusNotFound)
+		} else {
+			utils.WriteError(w, "Failed to restore backup: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Backup restored successfully"})
+}
+
+// DeleteBackup godoc
+// @Summary Delete a backup
+// @Description Delete a backup and its archive
+// @Tags backups
+// @Produce json
+// @Param id path int true "Backup ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /backups/{id} [delete]
+func (h *Handler) DeleteBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid backup ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteBackup(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Backup not found", http.StatusNotFound)
+		} else if errors.Is(err, server_manager.ErrPermissionDenied) {
+			utils.WriteError(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		} else {
+			utils.WriteError(w, "Failed to delete backup: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Backup deleted successfully"})
+}
+
+// RestoreBackupAsServerRequest represents the payload for restoring a backup into a new server
+type RestoreBackupAsServerRequest struct {
+	Name string `json:"name" example:"survival-copy" validate:"required,servername"`
+}
+
+// RestoreBackupAsServer godoc
+// @Summary Restore a backup into a new server
+// @Description Create a new server from a backup, reusing the jar, mod pack and command of the backed up server and allocating a free port. The original server is left untouched.
+// @Tags backups
+// @Accept json
+// @Produce json
+// @Param id path int true "Backup ID"
+// @Param request body RestoreBackupAsServerRequest true "New server name"
+// @Success 201 {object} CreateServerResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /backups/{id}/servers [post]
+func (h *Handler) RestoreBackupAsServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid backup ID", http.StatusBadRequest)
+		return
+	}
+
+	var req RestoreBackupAsServerRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	serverPath, err := serverPathFor(req.Name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting current working directory", "error", err)
+		utils.WriteError(w, "Failed to create server", http.StatusInternalServerError)
+		return
+	}
+
+	serverID, err := h.ServerManager.RestoreBackupAsServer(uint(id), userID, req.Name, serverPath)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error restoring backup into new server", "error", err)
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if diskError(w, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Backup not found", http.StatusNotFound)
+		} else {
+			utils.WriteError(w, "Failed to restore backup: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.respondCreatedServer(w, serverID, userID)
+}
+
+// DownloadBackup godoc
+// @Summary Download a backup archive
+// @Description Stream the tar.gz archive of a backup. Range requests are supported for full backups so large downloads can be resumed.
+// @Tags backups
+// @Produce application/gzip
+// @Param id path int true "Backup ID"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /backups/{id}/download [get]
+func (h *Handler) DownloadBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid backup ID", http.StatusBadRequest)
+		return
+	}
+
+	archive, backup, err := h.ServerManager.OpenBackup(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Backup not found", http.StatusNotFound)
+		} else if errors.Is(err, server_manager.ErrPermissionDenied) {
+			utils.WriteError(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		} else {
+			utils.WriteError(w, "Failed to open backup: "+err.Error(), http.StatusConflict)
+		}
+		return
+	}
+	defer archive.Close()
+
+	fileName := fmt.Sprintf("%s.tar.gz", backup.Name)
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	modTime := backup.CreatedAt
+	if backup.CompletedAt != nil {
+		modTime = *backup.CompletedAt
+	}
+	if seeker, ok := archive.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, fileName, modTime, seeker)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, archive); err != nil {
+		slog.ErrorContext(r.Context(), "Error streaming backup", "backup_id", backup.ID, "error", err)
+	}
+}
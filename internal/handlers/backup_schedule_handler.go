@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// BackupScheduleRequest represents the payload for setting a backup schedule
+type BackupScheduleRequest struct {
+	Cron          string `json:"cron" example:"0 4 * * *" validate:"required"`
+	KeepLast      int    `json:"keep_last" example:"3" validate:"gte=0,lte=1000"`
+	KeepDailyDays int    `json:"keep_daily_days" example:"7" validate:"gte=0,lte=3650"`
+	Incremental   bool   `json:"incremental"`
+	Enabled       *bool  `json:"enabled,omitempty"`
+}
+
+// SetBackupSchedule godoc
+// @Summary Set the backup schedule of a server
+// @Description Create or replace the cron schedule scheduled backups run on. After each run, scheduled backups not kept by keep_last or by keep_daily_days (the newest backup of each day) are pruned. Manual backups are never pruned.
+// @Tags backups
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body BackupScheduleRequest true "Schedule and retention"
+// @Success 200 {object} model.BackupSchedule
+// @Failure 400 {object} model.ErrorResponse
+// @Router /servers/{id}/backup-schedule [put]
+func (h *Handler) SetBackupSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req BackupScheduleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	opts := server_manager.BackupScheduleOptions{
+		Cron:          req.Cron,
+		KeepLast:      req.KeepLast,
+		KeepDailyDays: req.KeepDailyDays,
+		Incremental:   req.Incremental,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+	}
+	schedule, err := h.ServerManager.SetBackupSchedule(uint(id), userID, opts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error setting backup schedule", "error", err)
+		utils.WriteError(w, "Failed to set backup schedule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// GetBackupSchedule godoc
+// @Summary Get the backup schedule of a server
+// @Description Get the schedule, retention rules and outcome of the last scheduled run
+// @Tags backups
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} model.BackupSchedule
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/backup-schedule [get]
+func (h *Handler) GetBackupSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.ServerManager.GetBackupSchedule(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Backup schedule not found", http.StatusNotFound)
+		} else {
+			utils.WriteError(w, "Failed to get backup schedule: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// DeleteBackupSchedule godoc
+// @Summary Delete the backup schedule of a server
+// @Description Stop scheduled backups. Existing backups are kept.
+// @Tags backups
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/backup-schedule [delete]
+func (h *Handler) DeleteBackupSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteBackupSchedule(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Backup schedule not found", http.StatusNotFound)
+		} else {
+			utils.WriteError(w, "Failed to delete backup schedule: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Backup schedule deleted successfully"})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// crashIDs parses the server and crash IDs of a crash route
+func crashIDs(w http.ResponseWriter, r *http.Request) (uint, uint, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	crashID, err := strconv.ParseUint(vars["crash_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid crash ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uint(id), uint(crashID), true
+}
+
+// ListCrashes godoc
+// @Summary List the crashes of a server
+// @Description Get the runs of a server that exited with an error, newest first, with the diagnosis and the crash reports each run wrote. The output of a crash is returned by GET /servers/{id}/crashes/{crash_id}.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.Crash
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/crashes [get]
+func (h *Handler) ListCrashes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	crashes, err := h.ServerManager.ListCrashes(uint(id), userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to fetch crashes")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(crashes)
+}
+
+// GetCrash godoc
+// @Summary Get a crash of a server
+// @Description Get a crash with the stderr and the last console lines of the run
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param crash_id path uint true "Crash ID"
+// @Success 200 {object} model.Crash
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/crashes/{crash_id} [get]
+func (h *Handler) GetCrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, crashID, ok := crashIDs(w, r)
+	if !ok {
+		return
+	}
+
+	record, err := h.ServerManager.GetCrash(id, crashID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Crash not found", http.StatusNotFound)
+			return
+		}
+		serverAccessError(w, err, "Failed to fetch crash")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(record)
+}
+
+// DownloadCrashReport godoc
+// @Summary Download a crash report
+// @Tags servers
+// @Produce plain
+// @Param id path uint true "Server ID"
+// @Param crash_id path uint true "Crash ID"
+// @Param report_id path uint true "Report ID"
+// @Success 200 {file} file
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/crashes/{crash_id}/reports/{report_id} [get]
+func (h *Handler) DownloadCrashReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, crashID, ok := crashIDs(w, r)
+	if !ok {
+		return
+	}
+	reportID, err := strconv.ParseUint(mux.Vars(r)["report_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid report ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.ServerManager.GetCrashReport(id, crashID, uint(reportID), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Crash report not found", http.StatusNotFound)
+			return
+		}
+		serverAccessError(w, err, "Failed to fetch crash report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.Name))
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, report.Content)
+}
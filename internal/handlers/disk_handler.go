@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// diskError writes 507 and returns true when err is a failed disk space check
+func diskError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, server_manager.ErrInsufficientDisk) {
+		return false
+	}
+	utils.WriteError(w, err.Error(), http.StatusInsufficientStorage)
+	return true
+}
+
+// GetDiskStats godoc
+// @Summary Get disk space
+// @Description Get the free space of the server, shared and local backup directories of the control plane, the free space each node last reported, and the reserve uploads, backups and imports must leave free (storage.disk_reserve_mb). Admin only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} server_manager.DiskStats
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /admin/disk [get]
+func (h *Handler) GetDiskStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	stats, err := h.ServerManager.DiskStats()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading disk stats", "error", err)
+		utils.WriteError(w, "Failed to read disk stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
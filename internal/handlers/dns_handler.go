@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/dns"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// RegisterDNSRequest represents the payload for registering a server's address
+type RegisterDNSRequest struct {
+	// Name is the label in the configured zone, smp for smp.example.com
+	Name string `json:"name" example:"smp" validate:"required,dns_label"`
+}
+
+// dnsError writes the response for an error of a DNS operation
+func dnsError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, server_manager.ErrDNSDisabled):
+		utils.WriteError(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, dns.ErrInvalidLabel):
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, server_manager.ErrDNSNameTaken), errors.Is(err, dns.ErrRecordExists):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, server_manager.ErrPermissionDenied):
+		serverAccessError(w, err, message)
+	default:
+		utils.WriteError(w, message+": "+err.Error(), http.StatusBadGateway)
+	}
+}
+
+// RegisterServerDNS godoc
+// @Summary Register a DNS name for a server
+// @Description Create an A record for the host, if it is configured by IP address, and an SRV record for the server's port, so players can join by name. A name the server had before is removed. Records the manager did not create are never replaced; a name that exists in the zone is refused.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body RegisterDNSRequest true "DNS name"
+// @Success 200 {object} model.Server
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 501 {object} model.ErrorResponse
+// @Failure 502 {object} model.ErrorResponse
+// @Router /servers/{id}/dns [put]
+func (h *Handler) RegisterServerDNS(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req RegisterDNSRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	srv, err := h.ServerManager.RegisterServerDNS(r.Context(), uint(id), userID, req.Name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error registering DNS records", "error", err)
+		dnsError(w, err, "Failed to register DNS records")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(srv)
+}
+
+// UnregisterServerDNS godoc
+// @Summary Remove the DNS name of a server
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 501 {object} model.ErrorResponse
+// @Failure 502 {object} model.ErrorResponse
+// @Router /servers/{id}/dns [delete]
+func (h *Handler) UnregisterServerDNS(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.UnregisterServerDNS(r.Context(), uint(id), userID); err != nil {
+		slog.ErrorContext(r.Context(), "Error removing DNS records", "error", err)
+		dnsError(w, err, "Failed to remove DNS records")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "DNS records removed"})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// GetEventsWS godoc
+// @Summary Stream events via WebSocket
+// @Description Establish a WebSocket connection that receives a JSON message for every start, stop, crash, backup and player event of the current user's servers and the servers shared with their teams. Team memberships are read when the connection opens.
+// @Tags events
+// @Success 101 {object} model.Event
+// @Router /events/ws [get]
+func (h *Handler) GetEventsWS(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	teamIDs, err := h.ServerManager.UserTeamIDs(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch teams", http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "WebSocket upgrade error", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	events := h.ServerManager.SubscribeEvents()
+	defer h.ServerManager.UnsubscribeEvents(events)
+
+	// The client sends nothing; reading notices when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.UserID != userID && (event.TeamID == nil || !slices.Contains(teamIDs, *event.TeamID)) {
+				continue
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				slog.ErrorContext(r.Context(), "WebSocket write error", "error", err)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// GameplayRequest represents the payload for changing gameplay settings.
+// Omitted fields are left unchanged.
+type GameplayRequest struct {
+	Difficulty string `json:"difficulty,omitempty" example:"hard" validate:"omitempty,oneof=peaceful easy normal hard"`
+	// GameMode is the default game mode of players joining for the first time
+	GameMode string `json:"gamemode,omitempty" example:"survival" validate:"omitempty,oneof=survival creative adventure spectator"`
+	PVP      *bool  `json:"pvp,omitempty"`
+	// GameRules maps vanilla game rules to booleans or integers depending on the rule
+	GameRules map[string]interface{} `json:"gamerules,omitempty" swaggertype:"object"`
+}
+
+// UpdateGameplay godoc
+// @Summary Change difficulty, game mode, pvp and game rules
+// @Description Apply gameplay settings with the difficulty, defaultgamemode and gamerule commands while the server runs, or by editing server.properties and the level.dat of its world while it is stopped. pvp takes effect on the next start. Game rules are typed: boolean rules take true or false, the others integers.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body GameplayRequest true "Settings to change"
+// @Success 200 {object} server_manager.GameplayResult
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/gameplay [patch]
+func (h *Handler) UpdateGameplay(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req GameplayRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	result, err := h.ServerManager.UpdateGameplay(uint(id), userID, server_manager.GameplayOptions{
+		Difficulty: req.Difficulty,
+		GameMode:   req.GameMode,
+		PVP:        req.PVP,
+		GameRules:  req.GameRules,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating gameplay settings", "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, server_manager.ErrPermissionDenied) || errors.Is(err, server_manager.ErrServerOnNode) {
+			serverAccessError(w, err, "Failed to update gameplay settings")
+			return
+		}
+		utils.WriteError(w, "Failed to update gameplay settings: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// GrantServerAccessRequest represents the payload for granting a user
+// permissions on a server
+type GrantServerAccessRequest struct {
+	Username string `json:"username" example:"alex"`
+	// Permissions are any of console, files, power and backups
+	Permissions []string `json:"permissions" example:"console,power"`
+}
+
+// ListServerGrants godoc
+// @Summary List server grants
+// @Description Get the users granted permissions on a server. Only the owner and the managers and owners of the server's team can see them.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.ServerGrant
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/grants [get]
+func (h *Handler) ListServerGrants(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	grants, err := h.ServerManager.ListServerGrants(uint(id), userID)
+	if err != nil {
+		grantError(w, err, "Failed to fetch grants")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(grants)
+}
+
+// GrantServerAccess godoc
+// @Summary Grant a user permissions on a server
+// @Description Give a user console, files, power and/or backups permissions on one server, replacing any earlier grant. Every grant includes read access to the server's status, stats and metrics.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body GrantServerAccessRequest true "Grant"
+// @Success 200 {object} model.ServerGrant
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/grants [put]
+func (h *Handler) GrantServerAccess(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req GrantServerAccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	grant, err := h.ServerManager.GrantServerAccess(uint(id), userID, req.Username, req.Permissions)
+	if err != nil {
+		grantError(w, err, "Failed to grant access")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(grant)
+}
+
+// RevokeServerAccess godoc
+// @Summary Revoke a user's permissions on a server
+// @Description Remove a user's grant on a server. Users can always remove their own grant.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param user_id path uint true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/grants/{user_id} [delete]
+func (h *Handler) RevokeServerAccess(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	granteeID, err := strconv.ParseUint(vars["user_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.RevokeServerAccess(uint(id), userID, uint(granteeID)); err != nil {
+		grantError(w, err, "Failed to revoke access")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Access revoked successfully"})
+}
+
+// grantError writes the response for an error of a grant operation
+func grantError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, server_manager.ErrPermissionDenied):
+		utils.WriteError(w, "Only the server's owner and team can manage grants", http.StatusForbidden)
+	default:
+		utils.WriteError(w, message+": "+err.Error(), http.StatusBadRequest)
+	}
+}
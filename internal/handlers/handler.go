@@ -1,20 +1,25 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/olindenbaum/mcgonalds/internal/config"
 	"github.com/olindenbaum/mcgonalds/internal/middleware"
 	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/node"
+	"github.com/olindenbaum/mcgonalds/internal/server"
 	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -22,38 +27,245 @@ type Handler struct {
 	DB            *gorm.DB
 	ServerManager *server_manager.ServerManager
 	Config        *config.Config
+	Usage         *middleware.UsageCounter
+	JWT           *utils.JWTIssuer
+	Mailer        *utils.Mailer
+	// OAuth holds the configured login providers by name
+	OAuth map[string]*utils.OAuthProvider
+	// ReloadConfig re-reads the configuration and applies the settings that
+	// can change at runtime
+	ReloadConfig func() error
+	// Nodes accepts node agent connections; nil disables remote nodes
+	Nodes *node.Hub
 }
 
-func NewHandler(db *gorm.DB, sm *server_manager.ServerManager, config *config.Config) *Handler {
+func NewHandler(db *gorm.DB, sm *server_manager.ServerManager, config *config.Config, jwtIssuer *utils.JWTIssuer) *Handler {
 	return &Handler{
 		DB:            db,
 		ServerManager: sm,
 		Config:        config,
+		Usage:         middleware.NewUsageCounter(),
+		JWT:           jwtIssuer,
+		Mailer:        utils.NewMailer(config.SMTP),
 	}
 }
 
 func (h *Handler) RegisterUnauthenticatedRoutes(r *mux.Router) {
 	r.HandleFunc("/signup", h.Signup).Methods("POST")
 	r.HandleFunc("/login", h.Login).Methods("POST")
+	r.HandleFunc("/password-reset", h.RequestPasswordReset).Methods("POST")
+	r.HandleFunc("/password-reset/confirm", h.ConfirmPasswordReset).Methods("POST")
+	r.HandleFunc("/verify-email", h.VerifyEmail).Methods("POST")
+	r.HandleFunc("/auth/providers", h.ListOAuthProviders).Methods("GET")
+	r.HandleFunc("/auth/{provider}/login", h.OAuthLogin).Methods("GET")
+	r.HandleFunc("/auth/{provider}/callback", h.OAuthCallback).Methods("GET")
+	// Servers whose owner published their status, identified by its token
+	r.HandleFunc("/public/servers/{token}/status", h.GetPublicStatus).Methods("GET")
+	// Agents authenticate with their node token instead of a user token
+	if h.Nodes != nil {
+		r.Handle("/nodes/connect", h.Nodes).Methods("GET")
+	}
 }
 
 func (h *Handler) RegisterAuthenticatedRoutes(r *mux.Router) {
-	r.HandleFunc("/servers", h.CreateServer).Methods("POST")
-	r.HandleFunc("/servers", h.ListServers).Methods("GET")
+	// Requests that create servers or artifacts can be retried safely with
+	// an Idempotency-Key
+	idempotent := middleware.Idempotency(h.ServerManager)
+	r.Handle("/servers", idempotent(h.TrackUpload(http.HandlerFunc(h.CreateServer)))).Methods("POST")
+	r.Handle("/servers", middleware.ETag(http.HandlerFunc(h.ListServers))).Methods("GET")
+	r.HandleFunc("/servers/import", h.ImportServer).Methods("POST")
+	r.HandleFunc("/servers/deleted", h.ListDeletedServers).Methods("GET")
+	r.HandleFunc("/servers/import-bundle", h.ImportBundle).Methods("POST")
+	r.HandleFunc("/servers/batch", h.BatchServers).Methods("POST")
 	r.HandleFunc("/servers/{id}", h.GetServer).Methods("GET")
+	r.HandleFunc("/servers/{id}", h.UpdateServer).Methods("PATCH")
 	r.HandleFunc("/servers/{id}", h.DeleteServer).Methods("DELETE")
 	r.HandleFunc("/servers/{id}/start", h.StartServer).Methods("POST")
 	r.HandleFunc("/servers/{id}/stop", h.StopServer).Methods("POST")
 	r.HandleFunc("/servers/{id}/restart", h.RestartServer).Methods("POST")
+	r.HandleFunc("/servers/{id}/preflight", h.GetPreflight).Methods("GET")
+	r.HandleFunc("/servers/{id}/stats", h.GetServerStats).Methods("GET")
+	r.HandleFunc("/servers/{id}/metrics", h.GetServerMetrics).Methods("GET")
+	r.HandleFunc("/servers/{id}/clone", h.CloneServer).Methods("POST")
+	r.HandleFunc("/servers/{id}/archive", h.ArchiveServer).Methods("POST")
+	r.HandleFunc("/servers/{id}/thaw", h.ThawServer).Methods("POST")
+	r.HandleFunc("/servers/{id}/restore", h.RestoreServer).Methods("POST")
+	r.HandleFunc("/servers/{id}/tags", h.SetServerTags).Methods("PUT")
+	r.HandleFunc("/servers/{id}/icon", h.UploadServerIcon).Methods("PUT")
+	r.HandleFunc("/servers/{id}/icon", h.GetServerIcon).Methods("GET")
+	r.HandleFunc("/servers/{id}/icon", h.DeleteServerIcon).Methods("DELETE")
+	r.Handle("/tags", middleware.ETag(http.HandlerFunc(h.ListTags))).Methods("GET")
+	r.HandleFunc("/servers/{id}/export", h.ExportServer).Methods("GET")
 	r.HandleFunc("/servers/{id}/command", h.SendCommand).Methods("POST")
-	r.HandleFunc("/servers/{id}/upload-jar", h.UploadJarFile).Methods("POST")
-	r.HandleFunc("/servers/{id}/upload-modpack", h.UploadModPack).Methods("POST")
-	r.HandleFunc("/jar-files", h.UploadSharedJarFile).Methods("POST")
-	r.HandleFunc("/mod-packs", h.UploadSharedModPack).Methods("POST")
-	r.HandleFunc("/jar-files", h.GetCommonJarFiles).Methods("GET")
-	r.HandleFunc("/mod-packs", h.GetCommonModPacks).Methods("GET")
+	r.HandleFunc("/servers/{id}/players", h.ListPlayers).Methods("GET")
+	r.HandleFunc("/servers/{id}/players", h.PlayerAction).Methods("POST")
+	r.HandleFunc("/servers/{id}/players/profiles", h.ListPlayerProfiles).Methods("GET")
+	r.HandleFunc("/servers/{id}/bans", h.ListBans).Methods("GET")
+	r.HandleFunc("/players/{player}", h.GetPlayerProfile).Methods("GET")
+	r.Handle("/servers/{id}/upload-jar", idempotent(h.TrackUpload(http.HandlerFunc(h.UploadJarFile)))).Methods("POST")
+	r.Handle("/servers/{id}/upload-modpack", idempotent(h.TrackUpload(http.HandlerFunc(h.UploadModPack)))).Methods("POST")
+	r.Handle("/servers/{id}/mods", idempotent(h.TrackUpload(http.HandlerFunc(h.UploadMods)))).Methods("POST")
+	r.HandleFunc("/servers/{id}/mods/validate", h.ValidateMods).Methods("GET")
+	r.Handle("/jar-files", idempotent(h.TrackUpload(http.HandlerFunc(h.UploadSharedJarFile)))).Methods("POST")
+	r.Handle("/mod-packs", idempotent(h.TrackUpload(http.HandlerFunc(h.UploadSharedModPack)))).Methods("POST")
+	r.Handle("/jar-files", middleware.ETag(http.HandlerFunc(h.GetCommonJarFiles))).Methods("GET")
+	r.Handle("/mod-packs", middleware.ETag(http.HandlerFunc(h.GetCommonModPacks))).Methods("GET")
+	r.HandleFunc("/jar-files/import", h.ImportJarFile).Methods("POST")
+	r.HandleFunc("/mod-packs/import", h.ImportModPack).Methods("POST")
+	r.HandleFunc("/jar-files/{id}", h.DeleteJarFile).Methods("DELETE")
+	r.HandleFunc("/mod-packs/{id}", h.DeleteModPack).Methods("DELETE")
+	r.HandleFunc("/mod-packs/{id}/apply", h.ApplyModPack).Methods("POST")
+	r.HandleFunc("/mod-packs/{id}/apply", h.GetModPackApply).Methods("GET")
+	r.HandleFunc("/artifacts/gc", h.CollectArtifactGarbage).Methods("POST")
+	r.HandleFunc("/uploads", h.CreateUpload).Methods("POST")
+	r.HandleFunc("/uploads/{id}", h.GetUpload).Methods("GET")
+	r.HandleFunc("/uploads/{id}/ws", h.GetUploadWS).Methods("GET")
 	r.HandleFunc("/servers/{id}/output", h.GetServerOutput).Methods("GET")
 	r.HandleFunc("/servers/{id}/output/ws", h.GetServerOutputWS).Methods("GET")
+	r.HandleFunc("/servers/{id}/install", h.InstallLoader).Methods("POST")
+	r.HandleFunc("/servers/{id}/install", h.GetLoaderInstall).Methods("GET")
+	r.HandleFunc("/servers/{id}/offline-mode", h.AcknowledgeOfflineMode).Methods("POST")
+	r.HandleFunc("/servers/{id}/config", h.GetServerConfig).Methods("GET")
+	r.HandleFunc("/servers/{id}/public-status", h.EnablePublicStatus).Methods("PUT")
+	r.HandleFunc("/servers/{id}/public-status", h.DisablePublicStatus).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/config", h.UpdateServerConfig).Methods("PUT")
+	r.HandleFunc("/servers/{id}/jar", h.SwapJar).Methods("POST")
+	r.HandleFunc("/servers/{id}/jar", h.GetJarSwap).Methods("GET")
+	r.HandleFunc("/servers/{id}/world", h.DeleteWorld).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/modpack/upgrade", h.UpgradeModPack).Methods("POST")
+	r.HandleFunc("/servers/{id}/modpack/upgrade", h.GetModPackUpgrade).Methods("GET")
+	r.HandleFunc("/servers/{id}/geyser", h.InstallGeyser).Methods("POST")
+	r.Handle("/servers/{id}/addons", middleware.ETag(http.HandlerFunc(h.ListAddons))).Methods("GET")
+	r.HandleFunc("/servers/{id}/addons/{addon_id}", h.RemoveAddon).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/additional-files", h.ListServerAdditionalFiles).Methods("GET")
+	r.HandleFunc("/servers/{id}/additional-files", h.AttachAdditionalFile).Methods("POST")
+	r.HandleFunc("/servers/{id}/additional-files/{file_id}", h.DetachAdditionalFile).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/announcements", h.ListAnnouncements).Methods("GET")
+	r.HandleFunc("/servers/{id}/announcements", h.CreateAnnouncement).Methods("POST")
+	r.HandleFunc("/servers/{id}/announcements/{announcement_id}", h.UpdateAnnouncement).Methods("PUT")
+	r.HandleFunc("/servers/{id}/announcements/{announcement_id}", h.DeleteAnnouncement).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/maintenance", h.ListMaintenanceWindows).Methods("GET")
+	r.HandleFunc("/servers/{id}/maintenance", h.CreateMaintenanceWindow).Methods("POST")
+	r.HandleFunc("/servers/{id}/maintenance/{window_id}", h.UpdateMaintenanceWindow).Methods("PUT")
+	r.HandleFunc("/servers/{id}/maintenance/{window_id}", h.DeleteMaintenanceWindow).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/gameplay", h.UpdateGameplay).Methods("PATCH")
+	r.HandleFunc("/servers/{id}/pregen", h.ListPregenTasks).Methods("GET")
+	r.HandleFunc("/servers/{id}/pregen", h.StartPregen).Methods("POST")
+	r.HandleFunc("/servers/{id}/pregen/{action}", h.ControlPregen).Methods("POST")
+	r.HandleFunc("/servers/{id}/crashes", h.ListCrashes).Methods("GET")
+	r.HandleFunc("/servers/{id}/crashes/{crash_id}", h.GetCrash).Methods("GET")
+	r.HandleFunc("/servers/{id}/crashes/{crash_id}/reports/{report_id}", h.DownloadCrashReport).Methods("GET")
+	r.HandleFunc("/servers/{id}/dns", h.RegisterServerDNS).Methods("PUT")
+	r.HandleFunc("/servers/{id}/dns", h.UnregisterServerDNS).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/proxy", h.SetProxyType).Methods("PUT")
+	r.HandleFunc("/servers/{id}/proxy/backends", h.ListProxyBackends).Methods("GET")
+	r.HandleFunc("/servers/{id}/proxy/backends", h.AttachProxyBackend).Methods("POST")
+	r.HandleFunc("/servers/{id}/proxy/backends/{server_id}", h.DetachProxyBackend).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/proxy/secret", h.RotateForwardingSecret).Methods("POST")
+	r.HandleFunc("/servers/{id}/proxy/autoscale", h.SetScalingPolicy).Methods("PUT")
+	r.HandleFunc("/servers/{id}/proxy/autoscale", h.GetScalingPolicy).Methods("GET")
+	r.HandleFunc("/servers/{id}/proxy/autoscale", h.DeleteScalingPolicy).Methods("DELETE")
+	r.Handle("/servers/{id}/backups", idempotent(http.HandlerFunc(h.CreateBackup))).Methods("POST")
+	r.Handle("/servers/{id}/backups", middleware.ETag(http.HandlerFunc(h.ListBackups))).Methods("GET")
+	r.HandleFunc("/servers/{id}/backup-schedule", h.SetBackupSchedule).Methods("PUT")
+	r.HandleFunc("/servers/{id}/backup-schedule", h.GetBackupSchedule).Methods("GET")
+	r.HandleFunc("/servers/{id}/backup-schedule", h.DeleteBackupSchedule).Methods("DELETE")
+	r.HandleFunc("/backups/{id}", h.GetBackup).Methods("GET")
+	r.HandleFunc("/backups/{id}", h.DeleteBackup).Methods("DELETE")
+	r.HandleFunc("/backups/{id}/download", h.DownloadBackup).Methods("GET")
+	r.HandleFunc("/backups/{id}/restore", h.RestoreBackup).Methods("POST")
+	r.HandleFunc("/backups/{id}/servers", h.RestoreBackupAsServer).Methods("POST")
+	r.HandleFunc("/me/usage", h.GetUsage).Methods("GET")
+	r.HandleFunc("/users/me/limits", h.GetLimits).Methods("GET")
+	r.HandleFunc("/me/email", h.SetEmail).Methods("PUT")
+	r.HandleFunc("/me/identities", h.ListOAuthIdentities).Methods("GET")
+	r.HandleFunc("/me/identities/{provider}", h.LinkOAuthIdentity).Methods("POST")
+	r.HandleFunc("/me/identities/{provider}", h.UnlinkOAuthIdentity).Methods("DELETE")
+	r.HandleFunc("/me/sessions", h.ListSessions).Methods("GET")
+	r.HandleFunc("/me/sessions", h.RevokeOtherSessions).Methods("DELETE")
+	r.HandleFunc("/me/sessions/{id}", h.RevokeSession).Methods("DELETE")
+	r.HandleFunc("/templates", h.CreateTemplate).Methods("POST")
+	r.Handle("/templates", middleware.ETag(http.HandlerFunc(h.ListTemplates))).Methods("GET")
+	r.HandleFunc("/templates/{id}", h.GetTemplate).Methods("GET")
+	r.HandleFunc("/templates/{id}", h.DeleteTemplate).Methods("DELETE")
+	r.HandleFunc("/templates/{id}/servers", h.CreateServerFromTemplate).Methods("POST")
+	r.HandleFunc("/events/ws", h.GetEventsWS).Methods("GET")
+	r.HandleFunc("/webhooks", h.CreateWebhook).Methods("POST")
+	r.HandleFunc("/webhooks", h.ListWebhooks).Methods("GET")
+	r.HandleFunc("/webhooks/{id}", h.DeleteWebhook).Methods("DELETE")
+	r.HandleFunc("/webhooks/{id}/deliveries", h.ListWebhookDeliveries).Methods("GET")
+	r.HandleFunc("/alerts", h.ListAlerts).Methods("GET")
+	r.HandleFunc("/alerts/channels", h.CreateAlertChannel).Methods("POST")
+	r.HandleFunc("/alerts/channels", h.ListAlertChannels).Methods("GET")
+	r.HandleFunc("/alerts/channels/{id}", h.DeleteAlertChannel).Methods("DELETE")
+	r.HandleFunc("/alerts/rules", h.CreateAlertRule).Methods("POST")
+	r.HandleFunc("/alerts/rules", h.ListAlertRules).Methods("GET")
+	r.HandleFunc("/alerts/rules/{id}", h.DeleteAlertRule).Methods("DELETE")
+	r.HandleFunc("/servers/{id}/audit-logs", h.ListServerAuditLogs).Methods("GET")
+	r.HandleFunc("/servers/{id}/team", h.SetServerTeam).Methods("PUT")
+	r.HandleFunc("/servers/{id}/grants", h.ListServerGrants).Methods("GET")
+	r.HandleFunc("/servers/{id}/grants", h.GrantServerAccess).Methods("PUT")
+	r.HandleFunc("/servers/{id}/grants/{user_id}", h.RevokeServerAccess).Methods("DELETE")
+	r.HandleFunc("/teams", h.CreateTeam).Methods("POST")
+	r.HandleFunc("/teams", h.ListTeams).Methods("GET")
+	r.HandleFunc("/teams/{id}/members", h.ListTeamMembers).Methods("GET")
+	r.HandleFunc("/teams/{id}/invitations", h.InviteTeamMember).Methods("POST")
+	r.HandleFunc("/teams/{id}/accept", h.AcceptTeamInvitation).Methods("POST")
+	r.HandleFunc("/teams/{id}/members/{user_id}", h.SetTeamMemberRole).Methods("PUT")
+	r.HandleFunc("/teams/{id}/members/{user_id}", h.RemoveTeamMember).Methods("DELETE")
+	r.HandleFunc("/admin/audit-logs", h.ListAuditLogs).Methods("GET")
+	r.HandleFunc("/admin/users", h.ListUsers).Methods("GET")
+	r.HandleFunc("/admin/users/{id}/role", h.SetUserRole).Methods("PUT")
+	r.HandleFunc("/admin/users/{id}/quota", h.SetUserQuota).Methods("PUT")
+	r.HandleFunc("/admin/reconcile", h.GetReconcileReport).Methods("GET")
+	r.HandleFunc("/admin/reconcile", h.Reconcile).Methods("POST")
+	r.HandleFunc("/admin/config/reload", h.ReloadConfigHandler).Methods("POST")
+	r.HandleFunc("/admin/disk", h.GetDiskStats).Methods("GET")
+	r.HandleFunc("/admin/node", h.GetNodeCapacity).Methods("GET")
+	r.HandleFunc("/admin/nodes", h.CreateNode).Methods("POST")
+	r.HandleFunc("/admin/nodes", h.ListNodes).Methods("GET")
+	r.HandleFunc("/admin/nodes/{id}", h.DeleteNode).Methods("DELETE")
+}
+
+// maxServersPerPage caps the page size of ListServers
+const maxServersPerPage = 100
+
+// CreateServerRequest holds the form fields of CreateServer that are not files
+type CreateServerRequest struct {
+	Name              string `form:"name" validate:"required,servername"`
+	ExecutableCommand string `form:"executable_command" validate:"required,max=1024"`
+	// DNSName is the label registered in the configured DNS zone
+	DNSName string `form:"dns_name" validate:"omitempty,dns_label"`
+	// Timezone is the IANA zone the server logs in; empty for the host's
+	Timezone string `form:"timezone" validate:"iana_timezone"`
+}
+
+// parseIDList parses form values holding comma-separated IDs
+func parseIDList(values []string) ([]uint, error) {
+	var ids []uint
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(field, 10, 64)
+			if err != nil || id == 0 {
+				return nil, fmt.Errorf("invalid ID %q", field)
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, nil
+}
+
+// serverPathFor returns the directory a new server with the given name lives in
+func serverPathFor(name string) (string, error) {
+	dir, err := server_manager.ServersDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
 }
 
 // CreateServer godoc
@@ -68,7 +280,11 @@ func (h *Handler) RegisterAuthenticatedRoutes(r *mux.Router) {
 // @Param jar_file formData file false "JAR File"
 // @Param mod_pack_id formData int false "Mod Pack ID"
 // @Param mod_pack formData file false "Mod Pack File"
-// @Success 201 {object} model.Server
+// @Param dns_name formData string false "Label to register in the DNS zone, e.g. smp for smp.example.com"
+// @Param timezone formData string false "IANA zone the server logs in, e.g. Europe/Berlin; defaults to the zone of the host"
+// @Param additional_file_ids formData string false "Comma-separated IDs of additional files to attach, e.g. 3,7"
+// @Param Idempotency-Key header string false "Key to retry the request with without repeating it"
+// @Success 201 {object} CreateServerResponse
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /servers [post]
@@ -76,13 +292,13 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form data with a maximum memory of 100MB
 	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 	err := r.ParseMultipartForm(100 << 20)
 	if err != nil {
-		log.Printf("Error parsing multipart form: %v", err)
-		http.Error(w, "Failed to parse form data", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "Error parsing multipart form", "error", err)
+		utils.WriteError(w, "Failed to parse form data", http.StatusBadRequest)
 		return
 	}
 
@@ -91,10 +307,16 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	executableCommand := r.FormValue("executable_command")
 	jarFileIDStr := r.FormValue("jar_file_id")
 	modPackIDStr := r.FormValue("mod_pack_id")
+	dnsName := r.FormValue("dns_name")
+	timezone := r.FormValue("timezone")
 
 	// Validate required fields
-	if name == "" || executableCommand == "" {
-		http.Error(w, "Name and executable_command are required", http.StatusBadRequest)
+	if !validRequest(w, CreateServerRequest{Name: name, ExecutableCommand: executableCommand, DNSName: dnsName, Timezone: timezone}) {
+		return
+	}
+	additionalFileIDs, err := parseIDList(r.Form["additional_file_ids"])
+	if err != nil {
+		utils.WriteValidationError(w, model.FieldError{Field: "additional_file_ids", Message: "must be a comma-separated list of IDs"})
 		return
 	}
 
@@ -107,7 +329,7 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	if jarFileIDStr != "" {
 		id, err := strconv.Atoi(jarFileIDStr)
 		if err != nil || id <= 0 {
-			http.Error(w, "Invalid jar_file_id", http.StatusBadRequest)
+			utils.WriteError(w, "Invalid jar_file_id", http.StatusBadRequest)
 			return
 		}
 		jarFileID = uint(id)
@@ -121,21 +343,24 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		defer file.Close()
 		jarFileUploaded = true
-		uploadedJarFile, err = h.ServerManager.UploadJarFile(header.Filename, "default_version", file, header.Filename, header.Size, "TODOSERVERID", false)
+		uploadedJarFile, err = h.ServerManager.UploadJarFile(r.Context(), header.Filename, "default_version", file, header.Filename, header.Size, "TODOSERVERID", false)
 		if err != nil {
-			log.Printf("Error uploading JAR file: %v", err)
-			http.Error(w, "Failed to upload JAR file", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "Error uploading JAR file", "error", err)
+			if diskError(w, err) {
+				return
+			}
+			utils.WriteError(w, "Failed to upload JAR file", http.StatusInternalServerError)
 			return
 		}
 	} else if err != http.ErrMissingFile {
-		log.Printf("Error retrieving jar_file: %v", err)
-		http.Error(w, "Failed to retrieve JAR file", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "Error retrieving jar_file", "error", err)
+		utils.WriteError(w, "Failed to retrieve JAR file", http.StatusBadRequest)
 		return
 	}
 
 	// Ensure either jar_file or jar_file_id is provided, but not both
 	if jarFileUploaded && jarFileIDProvided {
-		http.Error(w, "Provide either jar_file or jar_file_id, not both", http.StatusBadRequest)
+		utils.WriteError(w, "Provide either jar_file or jar_file_id, not both", http.StatusBadRequest)
 		return
 	}
 
@@ -143,14 +368,14 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	if jarFileIDProvided {
 		jarFile, err = h.ServerManager.GetJarFileByID(jarFileID)
 		if err != nil {
-			log.Printf("Error fetching JarFile by ID: %v", err)
-			http.Error(w, "Invalid jar_file_id", http.StatusBadRequest)
+			slog.ErrorContext(r.Context(), "Error fetching JarFile by ID", "error", err)
+			utils.WriteError(w, "Invalid jar_file_id", http.StatusBadRequest)
 			return
 		}
 	} else if jarFileUploaded {
 		jarFile = uploadedJarFile
 	} else {
-		http.Error(w, "Either jar_file or jar_file_id must be provided", http.StatusBadRequest)
+		utils.WriteError(w, "Either jar_file or jar_file_id must be provided", http.StatusBadRequest)
 		return
 	}
 
@@ -163,7 +388,7 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	if modPackIDStr != "" {
 		id, err := strconv.Atoi(modPackIDStr)
 		if err != nil || id <= 0 {
-			http.Error(w, "Invalid mod_pack_id", http.StatusBadRequest)
+			utils.WriteError(w, "Invalid mod_pack_id", http.StatusBadRequest)
 			return
 		}
 		modPackID = uint(id)
@@ -177,21 +402,24 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		defer file.Close()
 		modPackUploaded = true
-		uploadedModPack, err = h.ServerManager.UploadModPack(header.Filename, file, header.Size, "TODOSERVERID", false)
+		uploadedModPack, err = h.ServerManager.UploadModPack(r.Context(), "", "", header.Filename, file, header.Size, "TODOSERVERID", false)
 		if err != nil {
-			log.Printf("Error uploading mod pack: %v", err)
-			http.Error(w, "Failed to upload mod pack", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "Error uploading mod pack", "error", err)
+			if diskError(w, err) {
+				return
+			}
+			utils.WriteError(w, "Failed to upload mod pack", http.StatusInternalServerError)
 			return
 		}
 	} else if err != http.ErrMissingFile {
-		log.Printf("Error retrieving mod_pack: %v", err)
-		http.Error(w, "Failed to retrieve mod pack", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "Error retrieving mod_pack", "error", err)
+		utils.WriteError(w, "Failed to retrieve mod pack", http.StatusBadRequest)
 		return
 	}
 
 	// Ensure either mod_pack or mod_pack_id is provided, but not both
 	if modPackUploaded && modPackIDProvided {
-		http.Error(w, "Provide either mod_pack or mod_pack_id, not both", http.StatusBadRequest)
+		utils.WriteError(w, "Provide either mod_pack or mod_pack_id, not both", http.StatusBadRequest)
 		return
 	}
 
@@ -199,8 +427,8 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	if modPackIDProvided {
 		modPack, err = h.ServerManager.GetModPackByID(modPackID)
 		if err != nil {
-			log.Printf("Error fetching ModPack by ID: %v", err)
-			http.Error(w, "Invalid mod_pack_id", http.StatusBadRequest)
+			slog.ErrorContext(r.Context(), "Error fetching ModPack by ID", "error", err)
+			utils.WriteError(w, "Invalid mod_pack_id", http.StatusBadRequest)
 			return
 		}
 	} else if modPackUploaded {
@@ -210,95 +438,154 @@ func (h *Handler) CreateServer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create the server
-	dir, err := os.Getwd()
+	serverPath, err := serverPathFor(name)
 	if err != nil {
-		log.Printf("Error getting current working directory: %v", err)
-		http.Error(w, "Failed to create server", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error getting current working directory", "error", err)
+		utils.WriteError(w, "Failed to create server", http.StatusInternalServerError)
 		return
 	}
-	serverPath := filepath.Join(dir, "game_servers", name)
-	log.Printf("Creating server with path: %s", serverPath)
-	id, err := h.ServerManager.CreateServer(name, serverPath, executableCommand, jarFile, modPack, nil, userID)
+	id, err := h.ServerManager.CreateServer(name, serverPath, executableCommand, jarFile, modPack, additionalFileIDs, userID)
 	if err != nil {
-		log.Printf("Error creating server: %v", err)
-		http.Error(w, "Failed to create server", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error creating server", "error", err)
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, server_manager.ErrAdditionalFileNotFound) {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.WriteError(w, "Failed to create server", http.StatusInternalServerError)
 		return
 	}
-	log.Printf("Server created successfully with ID: %d", id)
-	if err != nil {
-		log.Printf("Error creating server: %v", err)
-		http.Error(w, "Failed to create server", http.StatusInternalServerError)
-		return
+
+	// The server exists either way, so these failures are only warnings
+	var warnings []string
+	if timezone != "" {
+		if _, err := h.ServerManager.UpdateServer(id, userID, server_manager.ServerUpdate{Timezone: &timezone}); err != nil {
+			slog.ErrorContext(r.Context(), "Error setting server timezone", "server_id", id, "error", err)
+			warnings = append(warnings, "The timezone was not set: "+err.Error())
+		}
+	}
+	if dnsName != "" {
+		if _, err := h.ServerManager.RegisterServerDNS(r.Context(), id, userID, dnsName); err != nil {
+			slog.ErrorContext(r.Context(), "Error registering DNS records", "server_id", id, "error", err)
+			warnings = append(warnings, "DNS records were not created: "+err.Error())
+		}
 	}
 
-	// Respond with the created server details
-	server, err := h.ServerManager.GetServer(uint8(id), userID)
+	h.respondCreatedServer(w, id, userID, warnings...)
+}
+
+// CreateServerResponse is returned after provisioning a server
+type CreateServerResponse struct {
+	Server   *server.ServerDetails `json:"server"`
+	Warnings []string              `json:"warnings"`
+}
+
+// respondCreatedServer writes the created server together with any
+// provisioning warnings gathered for it and the extra warnings given
+func (h *Handler) respondCreatedServer(w http.ResponseWriter, id uint, userID uint, extra ...string) {
+	srv, err := h.ServerManager.GetServer(id, userID)
 	if err != nil {
-		log.Printf("Error fetching created server: %v", err)
-		http.Error(w, "Server created but failed to fetch details", http.StatusInternalServerError)
+		slog.Error("Error fetching created server", "server_id", id, "error", err)
+		utils.WriteError(w, "Server created but failed to fetch details", http.StatusInternalServerError)
 		return
 	}
 
+	warnings := append(h.ServerManager.ProvisioningWarnings(id), extra...)
+	if warnings == nil {
+		warnings = []string{}
+	}
+
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(server)
+	json.NewEncoder(w).Encode(CreateServerResponse{
+		Server:   srv.GetServerDetails(),
+		Warnings: warnings,
+	})
 }
 
 // ListServers godoc
 // @Summary List all Minecraft servers
-// @Description Get a list of all Minecraft servers
+// @Description Get the servers of the current user with their config, jar file and mod pack. Without per_page all matching servers are returned. The total number of matches is sent in the X-Total-Count header.
 // @Tags servers
 // @Produce json
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Servers per page"
+// @Param status query string false "Filter by status (stopped, queued, starting, running, crashed)"
+// @Param tag query string false "Filter by tag"
+// @Param q query string false "Search server and tag names"
+// @Param sort query string false "Sort by id, name, status, created_at or updated_at; prefix with - for descending"
 // @Success 200 {array} model.Server
-// @Failure 500 {object{ model.ErrorResponse
+// @Header 200 {int} X-Total-Count "Total number of matching servers"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /servers [get]
 func (h *Handler) ListServers(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	servers, err := h.ServerManager.ListServers(userID)
+	query := r.URL.Query()
+	opts := server_manager.ServerListOptions{
+		Status: query.Get("status"),
+		Tag:    query.Get("tag"),
+		Query:  query.Get("q"),
+		Sort:   query.Get("sort"),
+	}
+	for param, target := range map[string]*int{"page": &opts.Page, "per_page": &opts.PerPage} {
+		if value := query.Get(param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				utils.WriteError(w, "Invalid "+param, http.StatusBadRequest)
+				return
+			}
+			*target = n
+		}
+	}
+	if opts.PerPage > maxServersPerPage {
+		opts.PerPage = maxServersPerPage
+	}
+
+	servers, total, err := h.ServerManager.ListServers(userID, opts)
 	if err != nil {
-		http.Error(w, "Failed to fetch servers", http.StatusInternalServerError)
+		utils.WriteError(w, "Failed to fetch servers", http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(servers)
 }
 
 // GetServer godoc
 // @Summary Get a specific Minecraft server
-// @Description Get details of a specific Minecraft server by name
+// @Description Get details of a specific Minecraft server, including its config with the jar file and mod pack
 // @Tags servers
 // @Produce json
-// @Param id path uint8 true "Server ID"
-// @Success 200 {object} model.Server
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server.ServerDetails
 // @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /servers/{id} [get]
 func (h *Handler) GetServer(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 	vars := mux.Vars(r)
 	serverId := vars["id"]
-	fmt.Printf("Getting server with ID: %s", serverId)
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
-	server, err := h.ServerManager.GetServer(uint8(id), userID)
+	server, err := h.ServerManager.GetServer(uint(id), userID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Server not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to fetch server", http.StatusInternalServerError)
-		}
+		serverAccessError(w, err, "Failed to fetch server")
 		return
 	}
 	serverDetails := server.GetServerDetails()
@@ -308,10 +595,11 @@ func (h *Handler) GetServer(w http.ResponseWriter, r *http.Request) {
 
 // DeleteServer godoc
 // @Summary Delete a Minecraft server
-// @Description Delete a specific Minecraft server by name
+// @Description Stop a server and mark it deleted. It can be restored until it is purged after storage.deleted_server_retention_days. With purge_files=true its directory, backups and config are removed immediately instead.
 // @Tags servers
 // @Produce json
-// @Param id path uint8 true "Server ID"
+// @Param id path uint true "Server ID"
+// @Param purge_files query bool false "Remove files and records immediately"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
@@ -319,20 +607,21 @@ func (h *Handler) GetServer(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteServer(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 	vars := mux.Vars(r)
 	serverId := vars["id"]
 
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.ServerManager.DeleteServer(uint8(id), userID); err != nil {
-		http.Error(w, "Failed to delete server: "+err.Error(), http.StatusInternalServerError)
+	purgeFiles, _ := strconv.ParseBool(r.URL.Query().Get("purge_files"))
+	if err := h.ServerManager.DeleteServer(r.Context(), uint(id), userID, purgeFiles); err != nil {
+		utils.WriteError(w, "Failed to delete server: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -340,45 +629,207 @@ func (h *Handler) DeleteServer(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Server deleted successfully"})
 }
 
+// SetServerTagsRequest represents the payload for replacing the tags of a server
+type SetServerTagsRequest struct {
+	Tags []string `json:"tags" example:"smp,modded" validate:"max=20,dive,max=32"`
+}
+
+// SetServerTags godoc
+// @Summary Set the tags of a server
+// @Description Replace the tags of a server. Tags are created on first use and normalized to lower case.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body SetServerTagsRequest true "Tags"
+// @Success 200 {array} model.Tag
+// @Failure 400 {object} model.ErrorResponse
+// @Router /servers/{id}/tags [put]
+func (h *Handler) SetServerTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SetServerTagsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	tags, err := h.ServerManager.SetServerTags(uint(id), userID, req.Tags)
+	if err != nil {
+		utils.WriteError(w, "Failed to set tags: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tags)
+}
+
+// ListTags godoc
+// @Summary List tags
+// @Description Get the tags in use on the current user's servers
+// @Tags servers
+// @Produce json
+// @Success 200 {array} model.Tag
+// @Failure 500 {object} model.ErrorResponse
+// @Router /tags [get]
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tags, err := h.ServerManager.ListTags(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tags)
+}
+
+// ListDeletedServers godoc
+// @Summary List deleted servers
+// @Description Get the deleted servers of the current user that can still be restored
+// @Tags servers
+// @Produce json
+// @Success 200 {array} model.Server
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/deleted [get]
+func (h *Handler) ListDeletedServers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	servers, err := h.ServerManager.ListDeletedServers(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch deleted servers", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(servers)
+}
+
+// RestoreServer godoc
+// @Summary Restore a deleted server
+// @Description Bring back a deleted server before the purge job removes it
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} model.Server
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/restore [post]
+func (h *Handler) RestoreServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	serverModel, err := h.ServerManager.RestoreServer(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Deleted server not found", http.StatusNotFound)
+		} else {
+			utils.WriteError(w, "Failed to restore server: "+err.Error(), http.StatusConflict)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(serverModel)
+}
+
 // StartServerRequest represents the payload for starting a server
 type StartServerRequest struct {
 }
 
 // StartServer godoc
 // @Summary Start a Minecraft server
-// @Description Start a specific Minecraft server by name with customizable RAM and port
+// @Description Start a specific Minecraft server by name with customizable RAM and port. When the process cannot be started the error has the code start_failed and a diagnosis of the likely causes; failures after the process started are diagnosed in the last_failure of the server details and in its crash records.
 // @Tags servers
 // @Accept json
 // @Produce json
-// @Param id path uint8 true "Server ID"
+// @Param id path uint true "Server ID"
 // @Param StartServerRequest body StartServerRequest true "RAM and Port"
 // @Success 200 {object} map[string]string
+// @Success 202 {object} map[string]interface{} "Queued behind other starts (server.max_concurrent_starts)"
 // @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /servers/{id}/start [post]
 func (h *Handler) StartServer(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 	vars := mux.Vars(r)
 	serverId := vars["id"]
 
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
 	// Start the server
-	err = h.ServerManager.StartServer(uint8(id), userID)
+	err = h.ServerManager.StartServer(h.powerContext(r), uint(id), userID)
 	if err != nil {
-		log.Printf("Error starting server: %v", err)
-		http.Error(w, "Failed to start server: "+err.Error(), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error starting server", "error", err)
+		if maintenanceBlocked(w, err) {
+			return
+		}
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerArchived) || errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if fileLockError(w, err) {
+			return
+		}
+		var startErr *server.StartError
+		if errors.As(err, &startErr) {
+			utils.WriteErrorResponse(w, model.ErrorResponse{
+				Status:    http.StatusInternalServerError,
+				Code:      utils.CodeStartFailed,
+				Error:     "Failed to start server: " + err.Error(),
+				Diagnosis: startErr.Findings,
+			})
+			return
+		}
+		serverAccessError(w, err, "Failed to start server")
 		return
 	}
 
+	if position := h.ServerManager.StartQueuePosition(uint(id)); position > 0 {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Server queued to start", "position": position})
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Server started successfully"})
 }
@@ -388,7 +839,7 @@ func (h *Handler) StartServer(w http.ResponseWriter, r *http.Request) {
 // @Description Stop a specific Minecraft server by name
 // @Tags servers
 // @Produce json
-// @Param id path uint8 true "Server ID"
+// @Param id path uint true "Server ID"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object{ model.ErrorResponse
 // @Failure 500 {object{ model.ErrorResponse
@@ -396,20 +847,23 @@ func (h *Handler) StartServer(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) StopServer(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 	vars := mux.Vars(r)
 	serverId := vars["id"]
 
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.ServerManager.StopServer(uint8(id), userID); err != nil {
-		http.Error(w, "Failed to stop server: "+err.Error(), http.StatusInternalServerError)
+	if err := h.ServerManager.StopServer(h.powerContext(r), uint(id), userID); err != nil {
+		if maintenanceBlocked(w, err) {
+			return
+		}
+		serverAccessError(w, err, "Failed to stop server")
 		return
 	}
 
@@ -422,23 +876,31 @@ func (h *Handler) StopServer(w http.ResponseWriter, r *http.Request) {
 // @Description Restart a specific Minecraft server by name
 // @Tags servers
 // @Produce json
-// @Param id path uint8 true "Server ID"
+// @Param id path uint true "Server ID"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object{ model.ErrorResponse
 // @Failure 500 {object{ model.ErrorResponse
 // @Router /servers/{id}/restart [post]
 func (h *Handler) RestartServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	vars := mux.Vars(r)
 	serverId := vars["id"]
 
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.ServerManager.RestartServer(uint8(id)); err != nil {
-		http.Error(w, "Failed to restart server: "+err.Error(), http.StatusInternalServerError)
+	if err := h.ServerManager.RestartServer(h.powerContext(r), uint(id), userID); err != nil {
+		if maintenanceBlocked(w, err) {
+			return
+		}
+		serverAccessError(w, err, "Failed to restart server")
 		return
 	}
 
@@ -446,13 +908,485 @@ func (h *Handler) RestartServer(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Server restarted successfully"})
 }
 
+// maxBatchServers caps the number of servers in one batch request
+const maxBatchServers = 100
+
+// BatchRequest represents the payload for a batch server operation
+type BatchRequest struct {
+	ServerIDs []uint `json:"server_ids" validate:"required,min=1,max=100"`
+	Action    string `json:"action" example:"restart" validate:"oneof=start stop restart"`
+}
+
+// BatchServers godoc
+// @Summary Start, stop or restart several servers
+// @Description Apply an action (start, stop or restart) to a list of servers concurrently. The response reports the outcome per server; a failure on one server does not affect the others.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param request body BatchRequest true "Servers and action"
+// @Success 200 {array} server_manager.BatchResult
+// @Failure 400 {object} model.ErrorResponse
+// @Router /servers/batch [post]
+func (h *Handler) BatchServers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req BatchRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	results, err := h.ServerManager.RunBatch(h.powerContext(r), req.ServerIDs, userID, req.Action)
+	if err != nil {
+		utils.WriteError(w, "Failed to run batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
+
+// GetPreflight godoc
+// @Summary Check whether a server can start
+// @Description Run the checks performed before a server starts: jar, EULA, port, Java version and free disk space. Failed checks prevent starting; warnings do not.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server_manager.PreflightReport
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/preflight [get]
+func (h *Handler) GetPreflight(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.ServerManager.Preflight(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		utils.WriteError(w, "Failed to run preflight checks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetServerStats godoc
+// @Summary Get live resource usage of a server
+// @Description Get CPU, resident memory and uptime of the server process, and the disk space used by the server directory. CPU is averaged since the previous request; 100 percent is one full core.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server_manager.ServerStats
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/stats [get]
+func (h *Handler) GetServerStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.ServerManager.ServerStats(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to get server stats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// OfflineModeRequest represents the payload for acknowledging offline mode
+type OfflineModeRequest struct {
+	Acknowledged bool `json:"acknowledged"`
+}
+
+// AcknowledgeOfflineMode godoc
+// @Summary Acknowledge offline mode for a server
+// @Description Servers with online-mode=false refuse to start until the owner explicitly acknowledges that unauthenticated players can join
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body OfflineModeRequest true "Acknowledgement"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/offline-mode [post]
+func (h *Handler) AcknowledgeOfflineMode(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req OfflineModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.AcknowledgeOfflineMode(uint(id), userID, req.Acknowledged); err != nil {
+		utils.WriteError(w, "Failed to update offline mode acknowledgement: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Offline mode acknowledgement updated"})
+}
+
+// UpdateServerRequest represents the payload for updating a server; omitted fields are unchanged
+type UpdateServerRequest struct {
+	Name              *string `json:"name,omitempty" example:"survival" validate:"omitempty,servername"`
+	ExecutableCommand *string `json:"executable_command,omitempty" example:"java -Xmx4G -jar server.jar nogui" validate:"omitempty,max=1024"`
+	JarFileID         *uint   `json:"jar_file_id,omitempty"`
+	// ModPackID 0 removes the mod pack
+	ModPackID   *uint   `json:"mod_pack_id,omitempty"`
+	AutoStart   *bool   `json:"auto_start,omitempty"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Notes       *string `json:"notes,omitempty" validate:"omitempty,max=10000"`
+	// Timezone is the IANA zone the server logs in; "" uses the zone of the host
+	Timezone *string `json:"timezone,omitempty" example:"Europe/Berlin" validate:"omitempty,iana_timezone"`
+}
+
+// UpdateServer godoc
+// @Summary Update a server
+// @Description Rename a server (moving its directory), change its command, reassign its jar or mod pack, toggle auto start, edit its description and notes, or set the timezone its console timestamps are read in. Renaming stops the server and moves its directory, copying it across volumes; jar or mod pack changes require the server to be stopped.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body UpdateServerRequest true "Fields to change"
+// @Success 200 {object} model.Server
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id} [patch]
+func (h *Handler) UpdateServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateServerRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	update := server_manager.ServerUpdate{
+		Name:              req.Name,
+		ExecutableCommand: req.ExecutableCommand,
+		JarFileID:         req.JarFileID,
+		ModPackID:         req.ModPackID,
+		AutoStart:         req.AutoStart,
+		Description:       req.Description,
+		Notes:             req.Notes,
+		Timezone:          req.Timezone,
+	}
+	serverModel, err := h.ServerManager.UpdateServer(uint(id), userID, update)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating server", "error", err)
+		if errors.Is(err, server_manager.ErrServerRunning) || errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+		} else {
+			utils.WriteError(w, "Failed to update server: "+err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(serverModel)
+}
+
+// CloneServerRequest represents the payload for cloning a server
+type CloneServerRequest struct {
+	Name          string `json:"name" example:"survival-staging" validate:"required,servername"`
+	ExcludeWorlds bool   `json:"exclude_worlds"`
+}
+
+// CloneServer godoc
+// @Summary Clone a server
+// @Description Duplicate a server's directory and config into a new server with its own name and port, optionally without its worlds
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body CloneServerRequest true "Clone options"
+// @Success 201 {object} CreateServerResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/clone [post]
+func (h *Handler) CloneServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CloneServerRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	serverPath, err := serverPathFor(req.Name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting current working directory", "error", err)
+		utils.WriteError(w, "Failed to clone server", http.StatusInternalServerError)
+		return
+	}
+
+	cloneID, err := h.ServerManager.CloneServer(uint(id), userID, req.Name, serverPath, req.ExcludeWorlds)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error cloning server", "error", err)
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerArchived) || errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if diskError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to clone server: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.respondCreatedServer(w, cloneID, userID)
+}
+
+// ImportServerRequest represents the payload for importing an existing server directory
+type ImportServerRequest struct {
+	Name              string `json:"name" example:"legacy-survival" validate:"required,servername"`
+	Path              string `json:"path" example:"/srv/minecraft/survival" validate:"required"`
+	Jar               string `json:"jar,omitempty" example:"paper-1.21.1.jar"`
+	ExecutableCommand string `json:"executable_command,omitempty"`
+}
+
+// ImportServer godoc
+// @Summary Import an existing server directory
+// @Description Register a server directory that already exists on disk as a managed server without copying data. The jar and start command are detected unless given. The path must be below one of the configured storage.import_roots.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param request body ImportServerRequest true "Directory to import"
+// @Success 201 {object} CreateServerResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Router /servers/import [post]
+func (h *Handler) ImportServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ImportServerRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	opts := server_manager.ImportOptions{
+		Name:              req.Name,
+		Path:              req.Path,
+		Jar:               req.Jar,
+		ExecutableCommand: req.ExecutableCommand,
+	}
+	id, err := h.ServerManager.ImportServer(opts, h.Config.Storage.ImportRoots, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error importing server", "error", err)
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, server_manager.ErrImportPathNotAllowed) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+		} else {
+			utils.WriteError(w, "Failed to import server: "+err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	h.respondCreatedServer(w, id, userID)
+}
+
+// ExportServer godoc
+// @Summary Export a server as a portable bundle
+// @Description Stream a tar.gz bundle with the server directory (world, configs, jar and mods resolved from shared storage) and an mcgonalds.json with metadata and a mod manifest. The bundle can be imported on another instance.
+// @Tags servers
+// @Produce application/gzip
+// @Param id path uint true "Server ID"
+// @Success 200 {file} file
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/export [get]
+func (h *Handler) ExportServer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv, err := h.ServerManager.GetServer(uint(id), userID)
+	if err != nil {
+		utils.WriteError(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	bundle := &downloadWriter{w: w, fileName: srv.GetName() + ".mcgonalds.tar.gz"}
+	if err := h.ServerManager.ExportServer(uint(id), userID, bundle); err != nil {
+		slog.ErrorContext(r.Context(), "Error exporting server", "server_id", id, "error", err)
+		// Once headers are sent the client sees a truncated archive
+		if !bundle.started {
+			serverAccessError(w, err, "Failed to export server")
+		}
+	}
+}
+
+// downloadWriter sends the headers of a gzip download with the first write,
+// so that errors before it can still be reported with a status
+type downloadWriter struct {
+	w        http.ResponseWriter
+	fileName string
+	started  bool
+}
+
+func (d *downloadWriter) Write(p []byte) (int, error) {
+	if !d.started {
+		d.started = true
+		d.w.Header().Set("Content-Type", "application/gzip")
+		d.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", d.fileName))
+		d.w.WriteHeader(http.StatusOK)
+	}
+	return d.w.Write(p)
+}
+
+// ImportBundleRequest holds the form fields of ImportBundle besides the bundle
+type ImportBundleRequest struct {
+	Name string `form:"name" validate:"required,servername"`
+}
+
+// ImportBundle godoc
+// @Summary Import a server from an export bundle
+// @Description Create a new server from a bundle produced by the export endpoint of this or another instance
+// @Tags servers
+// @Accept multipart/form-data
+// @Produce json
+// @Param name formData string true "Server Name"
+// @Param bundle formData file true "Export bundle"
+// @Success 201 {object} CreateServerResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Router /servers/import-bundle [post]
+func (h *Handler) ImportBundle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseMultipartForm(100 << 20); err != nil {
+		utils.WriteError(w, "Failed to parse form data", http.StatusBadRequest)
+		return
+	}
+
+	name := r.FormValue("name")
+	file, header, fileErr := r.FormFile("bundle")
+	if fileErr == nil {
+		defer file.Close()
+	}
+	var invalid fieldErrors
+	invalid.check(ImportBundleRequest{Name: name})
+	invalid.require("bundle", fileErr == nil)
+	if invalid.write(w) {
+		return
+	}
+
+	serverPath, err := serverPathFor(name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting current working directory", "error", err)
+		utils.WriteError(w, "Failed to import bundle", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := h.ServerManager.ImportBundle(file, header.Size, name, serverPath, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error importing bundle", "error", err)
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if diskError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to import bundle: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.respondCreatedServer(w, id, userID)
+}
+
 // SendCommand godoc
 // @Summary Send a command to a Minecraft server
 // @Description Send a command to a specific Minecraft server by name
 // @Tags servers
 // @Accept json
 // @Produce json
-// @Param id path uint8 true "Server ID"
+// @Param id path uint true "Server ID"
 // @Param command body map[string]string true "Command to send"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} model.ErrorResponse
@@ -460,24 +1394,29 @@ func (h *Handler) RestartServer(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} model.ErrorResponse
 // @Router /servers/{id}/command [post]
 func (h *Handler) SendCommand(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	vars := mux.Vars(r)
 	serverId := vars["id"]
 
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 	var commandReq struct {
 		Command string `json:"command"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&commandReq); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if _, err := h.ServerManager.SendCommand(uint8(id), commandReq.Command); err != nil {
-		http.Error(w, "Failed to send command: "+err.Error(), http.StatusInternalServerError)
+	if _, err := h.ServerManager.SendCommand(r.Context(), uint(id), userID, commandReq.Command); err != nil {
+		serverAccessError(w, err, "Failed to send command")
 		return
 	}
 
@@ -493,33 +1432,45 @@ func (h *Handler) SendCommand(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param name formData string true "Server Name"
 // @Param version formData string true "Version of the JAR file"
-// @Param serverID path string true "Server ID"
+// @Param id path uint true "Server ID"
 // @Param file formData file true "JAR file to upload"
+// @Param Idempotency-Key header string false "Key to retry the request with without repeating it"
 // @Success 200 {object} map[string]string "JAR file uploaded successfully"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
-// @Router /servers/{serverId}/upload-jar [post]
+// @Router /servers/{id}/upload-jar [post]
 func (h *Handler) UploadJarFile(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	serverID := vars["serverId"]
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionFiles)
+	if !ok {
+		return
+	}
+	serverID := strconv.FormatUint(uint64(id), 10)
 	nickname := r.FormValue("name")
 	version := r.FormValue("version")
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		http.Error(w, "Failed to parse file", http.StatusBadRequest)
+		utils.WriteError(w, "Failed to parse file", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
+	if !h.checkUploadQuota(w, r, header.Size) {
+		return
+	}
 	// Extract the filename and extension
 	filename := header.Filename
 	extension := filepath.Ext(filename)                 // Get the file extension
 	baseName := filename[:len(filename)-len(extension)] // Get the file name without extension
 
-	log.Printf("Uploaded file: %s, with extension: %s", baseName, extension)
+	slog.DebugContext(r.Context(), "Uploaded file", "name", baseName, "extension", extension)
 
-	jarFile, err := h.ServerManager.UploadJarFile(nickname, version, file, baseName, header.Size, serverID, false)
+	jarFile, err := h.ServerManager.UploadJarFile(r.Context(), nickname, version, file, baseName, header.Size, serverID, false)
 	if err != nil {
-		http.Error(w, "Failed to upload JAR file: "+err.Error(), http.StatusInternalServerError)
+		if diskError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to upload JAR file: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -530,40 +1481,73 @@ func (h *Handler) UploadJarFile(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// checkUploadQuota writes an error and returns false when the owner of the
+// server in the route has no disk quota left for an upload of size bytes
+func (h *Handler) checkUploadQuota(w http.ResponseWriter, r *http.Request, size int64) bool {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return false
+	}
+	if err := h.ServerManager.CheckServerUpload(uint(id), size); err != nil {
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+		} else {
+			utils.WriteError(w, "Failed to check quota: "+err.Error(), http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
 // UploadModPack godoc
 // @Summary Upload mod pack for a server
 // @Description Upload a mod pack to a specific server, either selecting a common mod pack or uploading a new one
 // @Tags servers
 // @Accept multipart/form-data
 // @Produce json
-// @Param name path string true "Server Name"
+// @Param id path uint true "Server ID"
 // @Param file formData file true "Mod pack file to upload"
+// @Param name formData string false "Name of the mod pack, shared by its versions; defaults to the file name"
+// @Param version formData string false "Version of the mod pack"
+// @Param Idempotency-Key header string false "Key to retry the request with without repeating it"
 // @Success 200 {object} map[string]string "Mod pack uploaded successfully"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
-// @Router /servers/{name}/upload-modpack [post]
+// @Router /servers/{id}/upload-modpack [post]
 func (h *Handler) UploadModPack(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	serverName := vars["name"]
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionFiles)
+	if !ok {
+		return
+	}
+	serverName := strconv.FormatUint(uint64(id), 10)
 
 	// Parse the multipart form
 	err := r.ParseMultipartForm(100 << 20) // 100 MB max size
 	if err != nil {
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		utils.WriteError(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		http.Error(w, "Failed to get file from form", http.StatusBadRequest)
+		utils.WriteError(w, "Failed to get file from form", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
+	if !h.checkUploadQuota(w, r, header.Size) {
+		return
+	}
 
 	// Call ServerManager's UploadModPack
-	modPack, err := h.ServerManager.UploadModPack(header.Filename, file, header.Size, serverName, false)
+	modPack, err := h.ServerManager.UploadModPack(r.Context(), r.FormValue("name"), r.FormValue("version"), header.Filename, file, header.Size, serverName, false)
 	if err != nil {
-		http.Error(w, "Failed to upload mod pack: "+err.Error(), http.StatusInternalServerError)
+		if diskError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to upload mod pack: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -584,6 +1568,7 @@ func (h *Handler) UploadModPack(w http.ResponseWriter, r *http.Request) {
 // @Param name formData string true "Nickname of the JAR file"
 // @Param version formData string true "Version of the JAR file"
 // @Param file formData file true "The JAR file to upload"
+// @Param Idempotency-Key header string false "Key to retry the request with without repeating it"
 // @Success 201 {object} model.JarFile
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
@@ -591,21 +1576,23 @@ func (h *Handler) UploadModPack(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UploadSharedJarFile(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseMultipartForm(10 << 20) // 10 MB max
 	if err != nil {
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		utils.WriteError(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
 	nickname := r.FormValue("name")
 	version := r.FormValue("version")
 
-	if nickname == "" || version == "" {
-		http.Error(w, "Name and version are required", http.StatusBadRequest)
+	var invalid fieldErrors
+	invalid.require("name", nickname != "")
+	invalid.require("version", version != "")
+	if invalid.write(w) {
 		return
 	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		http.Error(w, "Failed to get file from form", http.StatusBadRequest)
+		utils.WriteError(w, "Failed to get file from form", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
@@ -615,11 +1602,14 @@ func (h *Handler) UploadSharedJarFile(w http.ResponseWriter, r *http.Request) {
 	extension := filepath.Ext(filename)                 // Get the file extension
 	baseName := filename[:len(filename)-len(extension)] // Get the file name without extension
 
-	log.Printf("Uploaded file: %s, with extension: %s", baseName, extension)
+	slog.DebugContext(r.Context(), "Uploaded file", "name", baseName, "extension", extension)
 
-	jarFile, err := h.ServerManager.UploadJarFile(nickname, version, file, baseName, header.Size, "", true)
+	jarFile, err := h.ServerManager.UploadJarFile(r.Context(), nickname, version, file, baseName, header.Size, "", true)
 	if err != nil {
-		http.Error(w, "Failed to upload JAR file: "+err.Error(), http.StatusInternalServerError)
+		if diskError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to upload JAR file: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -629,14 +1619,15 @@ func (h *Handler) UploadSharedJarFile(w http.ResponseWriter, r *http.Request) {
 
 // UploadSharedModPack godoc
 // @Summary Upload a shared mod pack
-// @Description Upload a shared mod pack to be used by multiple servers
+// @Description Upload a shared mod pack to be used by multiple servers. Mod packs with the same name are versions of one pack that servers can upgrade between.
 // @Tags mod-packs
 // @Accept multipart/form-data
 // @Produce json
-// @Param name formData string true "Name of the mod pack"
+// @Param name formData string true "Name of the mod pack, shared by its versions"
 // @Param version formData string true "Version of the mod pack"
 // @Param type formData string true "Type of the mod pack (e.g., zip, folder)"
 // @Param file formData file true "The mod pack file to upload"
+// @Param Idempotency-Key header string false "Key to retry the request with without repeating it"
 // @Success 201 {object} model.ModPack
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
@@ -644,7 +1635,7 @@ func (h *Handler) UploadSharedJarFile(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UploadSharedModPack(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseMultipartForm(100 << 20) // 100 MB max
 	if err != nil {
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		utils.WriteError(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
@@ -652,14 +1643,17 @@ func (h *Handler) UploadSharedModPack(w http.ResponseWriter, r *http.Request) {
 	version := r.FormValue("version")
 	fileType := r.FormValue("type")
 
-	if name == "" || version == "" || fileType == "" {
-		http.Error(w, "Name, version, and type are required", http.StatusBadRequest)
+	var invalid fieldErrors
+	invalid.require("name", name != "")
+	invalid.require("version", version != "")
+	invalid.require("type", fileType != "")
+	if invalid.write(w) {
 		return
 	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		http.Error(w, "Failed to get file from form", http.StatusBadRequest)
+		utils.WriteError(w, "Failed to get file from form", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
@@ -669,11 +1663,14 @@ func (h *Handler) UploadSharedModPack(w http.ResponseWriter, r *http.Request) {
 	extension := filepath.Ext(filename)                 // Get the file extension
 	baseName := filename[:len(filename)-len(extension)] // Get the file name without extension
 
-	log.Printf("Uploaded file: %s, with extension: %s", baseName, extension)
+	slog.DebugContext(r.Context(), "Uploaded file", "name", baseName, "extension", extension)
 
-	modPack, err := h.ServerManager.UploadModPack(header.Filename, file, header.Size, "", true)
+	modPack, err := h.ServerManager.UploadModPack(r.Context(), name, version, header.Filename, file, header.Size, "", true)
 	if err != nil {
-		http.Error(w, "Failed to upload mod pack: "+err.Error(), http.StatusInternalServerError)
+		if diskError(w, err) {
+			return
+		}
+		utils.WriteError(w, "Failed to upload mod pack: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -694,13 +1691,13 @@ func (h *Handler) GetCommonJarFiles(w http.ResponseWriter, r *http.Request) {
 	commonParam := r.URL.Query().Get("common")
 	common, err := strconv.ParseBool(commonParam)
 	if commonParam != "" && err != nil {
-		http.Error(w, "Invalid 'common' query parameter", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid 'common' query parameter", http.StatusBadRequest)
 		return
 	}
 
 	jarFiles, err := h.ServerManager.GetJarFiles(common)
 	if err != nil {
-		http.Error(w, "Failed to fetch JAR files", http.StatusInternalServerError)
+		utils.WriteError(w, "Failed to fetch JAR files", http.StatusInternalServerError)
 		return
 	}
 
@@ -721,13 +1718,13 @@ func (h *Handler) GetCommonModPacks(w http.ResponseWriter, r *http.Request) {
 	commonParam := r.URL.Query().Get("common")
 	common, err := strconv.ParseBool(commonParam)
 	if commonParam != "" && err != nil {
-		http.Error(w, "Invalid 'common' query parameter", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid 'common' query parameter", http.StatusBadRequest)
 		return
 	}
 
 	modPacks, err := h.ServerManager.GetModPacks(common)
 	if err != nil {
-		http.Error(w, "Failed to fetch mod packs", http.StatusInternalServerError)
+		utils.WriteError(w, "Failed to fetch mod packs", http.StatusInternalServerError)
 		return
 	}
 
@@ -737,28 +1734,33 @@ func (h *Handler) GetCommonModPacks(w http.ResponseWriter, r *http.Request) {
 
 // GetServerOutput godoc
 // @Summary Get server output
-// @Description Retrieve the output stream of a specific Minecraft server
+// @Description Retrieve the most recent console lines of a Minecraft server, up to 1000, as one string
 // @Tags servers
 // @Produce json
-// @Param id path uint8 true "Server ID"
+// @Param id path uint true "Server ID"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /servers/{id}/output [get]
 func (h *Handler) GetServerOutput(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	vars := mux.Vars(r)
-	serverId := vars["name"]
+	serverId := vars["id"]
 
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
-	output, err := h.ServerManager.GetServerOutput(uint8(id))
+	output, err := h.ServerManager.GetServerOutput(uint(id), userID)
 	if err != nil {
-		log.Printf("Error fetching server output: %v", err)
-		http.Error(w, "Failed to fetch server output", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error fetching server output", "error", err)
+		serverAccessError(w, err, "Failed to fetch server output")
 		return
 	}
 
@@ -768,6 +1770,8 @@ func (h *Handler) GetServerOutput(w http.ResponseWriter, r *http.Request) {
 
 // Upgrader specifies parameters for upgrading an HTTP connection to a WebSocket connection.
 var upgrader = websocket.Upgrader{
+	// Echo the token subprotocol of browsers that authenticate with it
+	Subprotocols: []string{middleware.WebSocketTokenProtocol},
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Adjust this as needed for security
 	},
@@ -775,58 +1779,81 @@ var upgrader = websocket.Upgrader{
 
 // GetServerOutputWS godoc
 // @Summary Get server output via WebSocket
-// @Description Establish a WebSocket connection to receive real-time server output
+// @Description Establish a WebSocket connection to receive real-time server output. Each message holds one or more lines. The most recent lines are replayed first; a client too slow to keep up receives a note of how many lines it skipped. Browsers, which cannot set the Authorization header, pass the token as the subprotocols ["bearer", token] or as token query parameter.
 // @Tags servers
-// @Param id path uint8 true "Server ID"
+// @Param id path uint true "Server ID"
+// @Param backlog query int false "Number of recent lines to replay (default 100)"
 // @Router /servers/{id}/output/ws [get]
 func (h *Handler) GetServerOutputWS(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	serverId := vars["id"]
 
-	id, err := strconv.ParseUint(serverId, 10, 8)
+	id, err := strconv.ParseUint(serverId, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
 	// Get user ID from context
 	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Check server ownership
-	var server model.Server
-	if err := h.DB.First(&server, id).Error; err != nil {
-		http.Error(w, "Server not found", http.StatusNotFound)
+	if err := h.ServerManager.Authorize(uint(id), userID, model.PermissionConsole); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Forbidden", http.StatusForbidden)
 		return
 	}
 
-	if server.UserID != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
+	backlog := 100
+	if value := r.URL.Query().Get("backlog"); value != "" {
+		backlog, err = strconv.Atoi(value)
+		if err != nil || backlog < 0 {
+			utils.WriteError(w, "Invalid backlog", http.StatusBadRequest)
+			return
+		}
 	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		slog.ErrorContext(r.Context(), "WebSocket upgrade error", "error", err)
 		return
 	}
 	defer conn.Close()
 
-	// Subscribe to server output
-	outputChan, err := h.ServerManager.SubscribeOutput(uint8(id))
-	if err != nil {
-		log.Printf("Error subscribing to server output: %v", err)
-		return
-	}
-	defer h.ServerManager.UnsubscribeOutput(uint8(id), outputChan)
+	sub := h.ServerManager.SubscribeConsole(uint(id), backlog)
+	defer h.ServerManager.UnsubscribeConsole(sub)
+
+	// The client sends nothing; reading notices when it goes away
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
 
-	for msg := range outputChan {
-		err := conn.WriteMessage(websocket.TextMessage, []byte(msg))
+	for {
+		// Everything written since the last message is sent at once, so a
+		// slow client falls behind in batches rather than losing lines
+		lines, skipped, err := sub.Next(ctx)
 		if err != nil {
-			log.Printf("WebSocket write error: %v", err)
-			break
+			return
+		}
+		if skipped > 0 {
+			lines = append([]string{fmt.Sprintf("[... %d lines skipped ...]", skipped)}, lines...)
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(strings.Join(lines, "\n"))); err != nil {
+			slog.ErrorContext(r.Context(), "WebSocket write error", "error", err)
+			return
 		}
 	}
 }
@@ -849,7 +1876,7 @@ func (h *Handler) GetServerOutputWS(w http.ResponseWriter, r *http.Request) {
 
 // 	file, header, err := r.FormFile("file")
 // 	if err != nil {
-// 		http.Error(w, "Failed to get file from form", http.StatusBadRequest)
+// 		utils.WriteError(w, "Failed to get file from form", http.StatusBadRequest)
 // 		return
 // 	}
 // 	defer file.Close()
@@ -857,7 +1884,7 @@ func (h *Handler) GetServerOutputWS(w http.ResponseWriter, r *http.Request) {
 // 	// Call ServerManager's UploadAdditionalFile
 // 	err = h.ServerManager.UploadAdditionalFile(serverName, file, header.Size)
 // 	if err != nil {
-// 		http.Error(w, "Failed to upload additional file: "+err.Error(), http.StatusInternalServerError)
+// 		utils.WriteError(w, "Failed to upload additional file: "+err.Error(), http.StatusInternalServerError)
 // 		return
 // 	}
 
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// UploadServerIcon godoc
+// @Summary Upload a server icon
+// @Description Upload a 64x64 PNG as the server's server-icon.png. Players see it in the server list after the next start.
+// @Tags servers
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param icon formData file true "64x64 PNG"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/icon [put]
+func (h *Handler) UploadServerIcon(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("icon")
+	if err != nil {
+		utils.WriteError(w, "Failed to parse icon", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if err := h.ServerManager.SetServerIcon(uint(id), userID, file); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		utils.WriteError(w, "Failed to set icon: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Icon uploaded successfully"})
+}
+
+// GetServerIcon godoc
+// @Summary Get a server icon
+// @Description Download the server's server-icon.png
+// @Tags servers
+// @Produce png
+// @Param id path uint true "Server ID"
+// @Success 200 {file} binary
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/icon [get]
+func (h *Handler) GetServerIcon(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	path, err := h.ServerManager.ServerIconPath(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, server_manager.ErrNoServerIcon) {
+			utils.WriteError(w, "Icon not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to get icon", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeFile(w, r, path)
+}
+
+// DeleteServerIcon godoc
+// @Summary Delete a server icon
+// @Description Remove the server's server-icon.png
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/icon [delete]
+func (h *Handler) DeleteServerIcon(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteServerIcon(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, server_manager.ErrNoServerIcon) {
+			utils.WriteError(w, "Icon not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to delete icon: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Icon deleted successfully"})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// InstallLoaderRequest represents the payload for installing a mod loader
+type InstallLoaderRequest struct {
+	Loader           string `json:"loader" example:"fabric"`
+	MinecraftVersion string `json:"minecraft_version,omitempty" example:"1.21.1"`
+	LoaderVersion    string `json:"loader_version,omitempty" example:"0.16.5"`
+}
+
+// InstallLoader godoc
+// @Summary Install a mod loader into a server
+// @Description Start the Forge/NeoForge installer attached as the server jar, or download and start the Fabric installer for the given Minecraft version, as the server's user. The server must be stopped and stays locked until the installer is done, after which the executable command is updated to the produced launch arguments. Poll GET /servers/{id}/install for the outcome.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body InstallLoaderRequest false "Loader to install (detected from the jar when omitted)"
+// @Success 202 {object} server_manager.LoaderInstall
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/install [post]
+func (h *Handler) InstallLoader(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req InstallLoaderRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	install, err := h.ServerManager.InstallLoader(uint(id), userID, server_manager.InstallOptions{
+		Loader:           req.Loader,
+		MinecraftVersion: req.MinecraftVersion,
+		LoaderVersion:    req.LoaderVersion,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error installing loader", "error", err)
+		if errors.Is(err, server_manager.ErrInvalidInstall) {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fileLockError(w, err) {
+			return
+		}
+		serverAccessError(w, err, "Failed to install loader")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(install)
+}
+
+// GetLoaderInstall godoc
+// @Summary Get the latest loader install of a server
+// @Description Report whether the most recent loader install is running, succeeded or failed, with the launch command it produced
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server_manager.LoaderInstall
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/install [get]
+func (h *Handler) GetLoaderInstall(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionView)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	install, err := h.ServerManager.GetLoaderInstall(id, userID)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(install)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// SwapJarRequest represents the payload for replacing a server's jar
+type SwapJarRequest struct {
+	JarFileID uint `json:"jar_file_id" validate:"required"`
+}
+
+// SwapJar godoc
+// @Summary Replace the jar of a server
+// @Description Snapshot the directory of a stopped server and switch to another jar. The change is rolled back if the next start does not reach "Done". Refused with 409 while the server is running or a backup is in progress.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body SwapJarRequest true "New jar file"
+// @Success 200 {object} model.JarSwap
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/jar [post]
+func (h *Handler) SwapJar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SwapJarRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	swap, err := h.ServerManager.SwapJar(uint(id), userID, req.JarFileID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error swapping jar", "error", err)
+		if fileLockError(w, err) {
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		utils.WriteError(w, "Failed to swap jar: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(swap)
+}
+
+// GetJarSwap godoc
+// @Summary Get the latest jar swap of a server
+// @Description Report whether the most recent jar swap is pending, confirmed or rolled back
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} model.JarSwap
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/jar [get]
+func (h *Handler) GetJarSwap(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	swap, err := h.ServerManager.GetJarSwap(uint(id), userID)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(swap)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// MaintenanceWindowRequest represents the payload for scheduling a maintenance window
+type MaintenanceWindowRequest struct {
+	StartsAt time.Time `json:"starts_at" example:"2024-12-08T04:00:00Z" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" example:"2024-12-08T05:00:00Z" validate:"required"`
+	// Mode is stop (default), which stops the server and answers pings
+	// with the message, or whitelist, which keeps it running for
+	// whitelisted players and operators
+	Mode string `json:"mode,omitempty" example:"stop" validate:"omitempty,oneof=stop whitelist"`
+	// Message is the MOTD while the server is down and the reason players
+	// are kicked or turned away with
+	Message string `json:"message,omitempty" example:"Upgrading to 1.21, back at 05:00 UTC" validate:"max=256"`
+}
+
+func (req *MaintenanceWindowRequest) options() server_manager.MaintenanceOptions {
+	return server_manager.MaintenanceOptions{
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Mode:     req.Mode,
+		Message:  req.Message,
+	}
+}
+
+// maintenanceError writes the response for an error of a maintenance window operation
+func maintenanceError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, server_manager.ErrPermissionDenied) || errors.Is(err, server_manager.ErrServerOnNode) {
+		serverAccessError(w, err, message)
+		return
+	}
+	utils.WriteError(w, message+": "+err.Error(), http.StatusBadRequest)
+}
+
+// powerContext returns the context for a power action of the caller, which
+// maintenance windows block unless the caller is an admin
+func (h *Handler) powerContext(r *http.Request) context.Context {
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+	if h.isAdmin(userID) {
+		return r.Context()
+	}
+	return server_manager.WithMaintenanceCheck(r.Context())
+}
+
+// maintenanceBlocked writes 409 and returns true when err is a power action
+// refused during a maintenance window
+func maintenanceBlocked(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, server_manager.ErrMaintenance) {
+		return false
+	}
+	utils.WriteError(w, err.Error()+"; only admins can start, stop or restart it", http.StatusConflict)
+	return true
+}
+
+// maintenanceWindowID parses the window ID of a route
+func maintenanceWindowID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	windowID, err := strconv.ParseUint(mux.Vars(r)["window_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(windowID), true
+}
+
+// ListMaintenanceWindows godoc
+// @Summary List the maintenance windows of a server
+// @Description Get the past, current and upcoming maintenance windows of a server. active is set on the window in effect.
+// @Tags maintenance
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.MaintenanceWindow
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/maintenance [get]
+func (h *Handler) ListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionView)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	windows, err := h.ServerManager.ListMaintenanceWindows(id, userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to fetch maintenance windows")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(windows)
+}
+
+// CreateMaintenanceWindow godoc
+// @Summary Schedule a maintenance window
+// @Description Schedule a period during which the server is stopped, with its port answering pings with the message and turning players away, or limited to whitelisted players and operators. A stopped server that was running is started again when the window ends. Only admins can start, stop or restart the server during the window. Windows of a server cannot overlap. Admin only.
+// @Tags maintenance
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body MaintenanceWindowRequest true "Maintenance window"
+// @Success 201 {object} model.MaintenanceWindow
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/maintenance [post]
+func (h *Handler) CreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionManage)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	var req MaintenanceWindowRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	window, err := h.ServerManager.CreateMaintenanceWindow(id, userID, req.options())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating maintenance window", "error", err)
+		maintenanceError(w, err, "Failed to create maintenance window")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(window)
+}
+
+// UpdateMaintenanceWindow godoc
+// @Summary Reschedule a maintenance window
+// @Description Replace the times, mode and message of a maintenance window. Once the window has begun only its end and message can change. Admin only.
+// @Tags maintenance
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param window_id path uint true "Maintenance window ID"
+// @Param request body MaintenanceWindowRequest true "Maintenance window"
+// @Success 200 {object} model.MaintenanceWindow
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/maintenance/{window_id} [put]
+func (h *Handler) UpdateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionManage)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+	windowID, ok := maintenanceWindowID(w, r)
+	if !ok {
+		return
+	}
+
+	var req MaintenanceWindowRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	window, err := h.ServerManager.UpdateMaintenanceWindow(id, windowID, userID, req.options())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating maintenance window", "error", err)
+		maintenanceError(w, err, "Failed to update maintenance window")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(window)
+}
+
+// DeleteMaintenanceWindow godoc
+// @Summary Delete a maintenance window
+// @Description Remove a maintenance window. A window in effect is lifted first, as if it had ended. Admin only.
+// @Tags maintenance
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param window_id path uint true "Maintenance window ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/maintenance/{window_id} [delete]
+func (h *Handler) DeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionManage)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+	windowID, ok := maintenanceWindowID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ServerManager.DeleteMaintenanceWindow(id, windowID, userID); err != nil {
+		serverAccessError(w, err, "Failed to delete maintenance window")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Maintenance window deleted successfully"})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// GetServerMetrics godoc
+// @Summary Get metric history of a server
+// @Description Get a time series of cpu, memory, players or tps. Samples are taken every minute while the server runs and averaged hourly after a day. TPS is only recorded for servers that report it.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param metric query string true "cpu, memory, players or tps"
+// @Param range query string false "How far back to go, e.g. 6h or 7d (default 24h, at most 30d)"
+// @Success 200 {array} model.MetricPoint
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/metrics [get]
+func (h *Handler) GetServerMetrics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	span := 24 * time.Hour
+	if value := r.URL.Query().Get("range"); value != "" {
+		span, err = parseRange(value)
+		if err != nil {
+			utils.WriteError(w, "Invalid range", http.StatusBadRequest)
+			return
+		}
+	}
+
+	points, err := h.ServerManager.MetricSeries(uint(id), userID, r.URL.Query().Get("metric"), span)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to fetch metrics: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(points)
+}
+
+// parseRange parses a Go duration, additionally accepting whole days such as "7d"
+func parseRange(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// UpgradeModPackRequest names the mod pack version to upgrade to
+type UpgradeModPackRequest struct {
+	// ModPackID is a version of the server's mod pack; the latest version when omitted
+	ModPackID uint `json:"mod_pack_id,omitempty"`
+}
+
+// UpgradeModPack godoc
+// @Summary Upgrade the mod pack of a server
+// @Description Move a server to another version of its mod pack, the most recently uploaded one unless mod_pack_id is given. Versions of a pack share its name. The config directory is snapshotted, mods are relinked to the new version and a running server is restarted. The response lists the mods added and removed. If the next start does not reach "Done" the previous version and configs are restored.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body UpgradeModPackRequest false "Mod pack version"
+// @Success 200 {object} server_manager.ModPackUpgrade
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/modpack/upgrade [post]
+func (h *Handler) UpgradeModPack(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionFiles)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	var req UpgradeModPackRequest
+	if r.ContentLength > 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	upgrade, err := h.ServerManager.UpgradeModPack(r.Context(), id, userID, req.ModPackID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error upgrading mod pack", "error", err)
+		if fileLockError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, server_manager.ErrInvalidUpgrade):
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, server_manager.ErrServerArchived), errors.Is(err, server_manager.ErrServerOnNode):
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+		default:
+			serverAccessError(w, err, "Failed to upgrade mod pack")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(upgrade)
+}
+
+// GetModPackUpgrade godoc
+// @Summary Get the latest mod pack upgrade of a server
+// @Description Report whether the most recent mod pack upgrade is pending, confirmed or rolled back, with the mods it added and removed
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server_manager.ModPackUpgrade
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/modpack/upgrade [get]
+func (h *Handler) GetModPackUpgrade(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionView)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	upgrade, err := h.ServerManager.GetModPackUpgrade(id, userID)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(upgrade)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// UploadModsResponse lists the outcome of every mod of an upload
+type UploadModsResponse struct {
+	Results []server_manager.ModUploadResult `json:"results"`
+}
+
+// UploadMods godoc
+// @Summary Upload mods to a server
+// @Description Upload any number of mod jars, or zip files whose jars are extracted, into the mods directory of a server. Mods with the name of an existing mod replace it. Each mod succeeds or fails on its own. Servers whose mods come from a mod pack cannot take uploads.
+// @Tags servers
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param files formData file true "Mod jars or zip files of mod jars; repeat the field for every file"
+// @Param Idempotency-Key header string false "Key to retry the request with without repeating it"
+// @Success 200 {object} UploadModsResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 507 {object} model.ErrorResponse
+// @Router /servers/{id}/mods [post]
+func (h *Handler) UploadMods(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionFiles)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		utils.WriteError(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		utils.WriteValidationError(w, model.FieldError{Field: "files", Message: "is required"})
+		return
+	}
+	var total int64
+	for _, header := range files {
+		total += header.Size
+	}
+	if !h.checkUploadQuota(w, r, total) {
+		return
+	}
+
+	results, err := h.ServerManager.UploadMods(r.Context(), id, userID, files)
+	if err != nil {
+		if diskError(w, err) {
+			return
+		}
+		if errors.Is(err, server_manager.ErrModsProvisioned) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		serverAccessError(w, err, "Failed to upload mods")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(UploadModsResponse{Results: results})
+}
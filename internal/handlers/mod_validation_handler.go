@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ValidateMods godoc
+// @Summary Check the mods of a server
+// @Description Read the metadata of every mod in the mods directory of a server and list what is likely to keep it from starting: missing dependencies such as Fabric API, mods that declare each other incompatible, mods installed twice, and mods for another loader or Minecraft version. The same checks run as a warning before every start.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server_manager.ModValidation
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/mods/validate [get]
+func (h *Handler) ValidateMods(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionView)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	validation, err := h.ServerManager.ValidateMods(id, userID)
+	if err != nil {
+		if errors.Is(err, server_manager.ErrServerArchived) || errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		serverAccessError(w, err, "Failed to validate mods")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(validation)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CreateNodeRequest represents the payload for registering a node
+type CreateNodeRequest struct {
+	Name string `json:"name" example:"node-1" validate:"required,max=64"`
+}
+
+// CreateNodeResponse is a node together with the token its agent connects
+// with, which is only ever returned here
+type CreateNodeResponse struct {
+	model.Node
+	Token string `json:"token"`
+}
+
+// CreateNode godoc
+// @Summary Register a node
+// @Description Register a machine that runs servers through a node agent. Start the agent with the returned token; new servers are scheduled onto the online node with the most free memory. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body CreateNodeRequest true "Node"
+// @Success 201 {object} CreateNodeResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Router /admin/nodes [post]
+func (h *Handler) CreateNode(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	var req CreateNodeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	n, token, err := h.ServerManager.CreateNode(req.Name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating node", "error", err)
+		utils.WriteError(w, "Failed to create node: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateNodeResponse{Node: *n, Token: token})
+}
+
+// ListNodes godoc
+// @Summary List nodes
+// @Description Get the registered nodes with their last reported capacity, online state and the memory allocated to their servers. Admin only.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} model.Node
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /admin/nodes [get]
+func (h *Handler) ListNodes(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	nodes, err := h.ServerManager.ListNodes()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing nodes", "error", err)
+		utils.WriteError(w, "Failed to fetch nodes", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(nodes)
+}
+
+// DeleteNode godoc
+// @Summary Delete a node
+// @Description Remove a node and revoke its token. Nodes with servers cannot be removed. Admin only.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Node ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /admin/nodes/{id} [delete]
+func (h *Handler) DeleteNode(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid node ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteNode(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Node not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to delete node: "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Node deleted successfully"})
+}
+
+// GetNodeCapacity godoc
+// @Summary Get the capacity of this machine
+// @Description Get the CPU cores, memory, load average and disk of the machine running the control plane, with the memory (-Xmx) configured for and used by its servers. oversubscribed is set when the servers together may use more memory than the machine has. Servers on node agents are not counted. Admin only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} server_manager.HostStats
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /admin/node [get]
+func (h *Handler) GetNodeCapacity(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	stats, err := h.ServerManager.HostStats()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading host stats", "error", err)
+		utils.WriteError(w, "Failed to read node capacity", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
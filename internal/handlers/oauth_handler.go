@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// oauthStateTTL bounds how long a user may spend on the provider's consent page
+	oauthStateTTL = 10 * time.Minute
+	// oauthNonceCookie ties the callback to the browser that started the flow
+	oauthNonceCookie = "mcgonalds_oauth_nonce"
+)
+
+// OAuthCallbackPath is the path below the API prefix that provider
+// callbacks are served at, followed by /<provider>/callback
+const OAuthCallbackPath = "/auth"
+
+// ListOAuthProviders godoc
+// @Summary List OAuth providers
+// @Description Get the names of the configured OAuth login providers
+// @Tags auth
+// @Produce json
+// @Success 200 {array} string
+// @Router /auth/providers [get]
+func (h *Handler) ListOAuthProviders(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range h.OAuth {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(names)
+}
+
+// OAuthLogin godoc
+// @Summary Log in with an OAuth provider
+// @Description Redirect to the provider's consent page. The provider returns to the callback, which responds with a token like /login. A first login creates an account; to add a provider to an existing account use POST /me/identities/{provider} instead.
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302
+// @Failure 404 {object} model.ErrorResponse
+// @Router /auth/{provider}/login [get]
+func (h *Handler) OAuthLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oauthProvider(w, r)
+	if !ok {
+		return
+	}
+	url, err := h.startOAuth(w, provider, 0)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error starting OAuth login", "provider", provider.Name, "error", err)
+		utils.WriteError(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// OAuthCallback godoc
+// @Summary Complete an OAuth login
+// @Description Called by the provider after consent. Logs in the user linked to the provider account, links it to the account that started a linking flow, or creates a new account.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /auth/{provider}/callback [get]
+func (h *Handler) OAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oauthProvider(w, r)
+	if !ok {
+		return
+	}
+
+	nonce, err := r.Cookie(oauthNonceCookie)
+	if err != nil {
+		utils.WriteError(w, "Login session expired, start again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthNonceCookie, Value: "", Path: APIPrefix + OAuthCallbackPath, MaxAge: -1, HttpOnly: true})
+	state, err := h.JWT.ValidatePurposeToken(r.URL.Query().Get("state"), utils.PurposeOAuthState)
+	if err != nil || state.Username != provider.Name || state.Fingerprint != fingerprint(nonce.Value) {
+		utils.WriteError(w, "Invalid login state", http.StatusBadRequest)
+		return
+	}
+	if errText := r.URL.Query().Get("error"); errText != "" {
+		utils.WriteError(w, "Login was denied: "+errText, http.StatusBadRequest)
+		return
+	}
+
+	profile, err := provider.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		slog.WarnContext(r.Context(), "OAuth login failed", "provider", provider.Name, "error", err)
+		utils.WriteError(w, "Login with "+provider.Name+" failed", http.StatusBadGateway)
+		return
+	}
+
+	user, err := h.oauthUser(provider.Name, profile, state.UserID)
+	if err != nil {
+		if errors.Is(err, errIdentityTaken) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error completing OAuth login", "provider", provider.Name, "error", err)
+		utils.WriteError(w, "Failed to complete login", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := h.issueToken(r, user)
+	if err != nil {
+		utils.WriteError(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "User logged in", "user_id", user.ID, "username", user.Username, "provider", provider.Name)
+	json.NewEncoder(w).Encode(map[string]string{
+		"token": token,
+	})
+}
+
+// LinkOAuthIdentity godoc
+// @Summary Link an OAuth provider to the current account
+// @Description Start an OAuth flow that links the provider account to the current user, so it can be used to log in. Open the returned URL in the browser.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /me/identities/{provider} [post]
+func (h *Handler) LinkOAuthIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	provider, ok := h.oauthProvider(w, r)
+	if !ok {
+		return
+	}
+
+	url, err := h.startOAuth(w, provider, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error starting OAuth link", "provider", provider.Name, "error", err)
+		utils.WriteError(w, "Failed to start linking", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"url": url})
+}
+
+// ListOAuthIdentities godoc
+// @Summary List linked OAuth providers
+// @Description Get the provider accounts linked to the current user
+// @Tags auth
+// @Produce json
+// @Success 200 {array} model.UserIdentity
+// @Router /me/identities [get]
+func (h *Handler) ListOAuthIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identities := []model.UserIdentity{}
+	if err := h.DB.Where("user_id = ?", userID).Order("provider").Find(&identities).Error; err != nil {
+		utils.WriteError(w, "Failed to fetch identities", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(identities)
+}
+
+// UnlinkOAuthIdentity godoc
+// @Summary Unlink an OAuth provider
+// @Description Remove a provider account from the current user. The last way to log in cannot be removed.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /me/identities/{provider} [delete]
+func (h *Handler) UnlinkOAuthIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	providerName := mux.Vars(r)["provider"]
+
+	var user model.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		utils.WriteError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	var identities int64
+	if err := h.DB.Model(&model.UserIdentity{}).Where("user_id = ?", userID).Count(&identities).Error; err != nil {
+		utils.WriteError(w, "Failed to fetch identities", http.StatusInternalServerError)
+		return
+	}
+	if user.Password == "" && identities <= 1 {
+		utils.WriteError(w, "Set a password or link another provider before removing the last one", http.StatusBadRequest)
+		return
+	}
+
+	result := h.DB.Unscoped().Where("user_id = ? AND provider = ?", userID, providerName).Delete(&model.UserIdentity{})
+	if result.Error != nil {
+		utils.WriteError(w, "Failed to unlink provider", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.WriteError(w, "Provider is not linked", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Provider unlinked successfully"})
+}
+
+// errIdentityTaken is returned when a provider account belongs to another user
+var errIdentityTaken = errors.New("this provider account is linked to another user")
+
+// oauthProvider returns the provider named in the route or writes a 404
+func (h *Handler) oauthProvider(w http.ResponseWriter, r *http.Request) (*utils.OAuthProvider, bool) {
+	provider, ok := h.OAuth[mux.Vars(r)["provider"]]
+	if !ok {
+		utils.WriteError(w, "Unknown login provider", http.StatusNotFound)
+		return nil, false
+	}
+	return provider, true
+}
+
+// startOAuth sets the nonce cookie and returns the consent page URL. The
+// state carries linkUserID when an existing account is being linked.
+func (h *Handler) startOAuth(w http.ResponseWriter, provider *utils.OAuthProvider, linkUserID uint) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(buf)
+	state, err := h.JWT.GeneratePurposeToken(linkUserID, provider.Name, utils.PurposeOAuthState, fingerprint(nonce), oauthStateTTL)
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthNonceCookie,
+		Value:    nonce,
+		Path:     APIPrefix + OAuthCallbackPath,
+		MaxAge:   int(oauthStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.Config.Server.PublicURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return provider.AuthCodeURL(state), nil
+}
+
+// oauthUser resolves the user a provider profile logs in as: the linked
+// user, linkUserID when linking, or a newly created user
+func (h *Handler) oauthUser(providerName string, profile *utils.OAuthProfile, linkUserID uint) (*model.User, error) {
+	var identity model.UserIdentity
+	err := h.DB.Where("provider = ? AND subject = ?", providerName, profile.Subject).First(&identity).Error
+	if err == nil {
+		if linkUserID != 0 && identity.UserID != linkUserID {
+			return nil, errIdentityTaken
+		}
+		var user model.User
+		if err := h.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch linked user: %w", err)
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch identity: %w", err)
+	}
+
+	var user model.User
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if linkUserID != 0 {
+			if err := tx.First(&user, linkUserID).Error; err != nil {
+				return fmt.Errorf("failed to fetch user: %w", err)
+			}
+		} else {
+			if err := h.createOAuthUser(tx, providerName, profile, &user); err != nil {
+				return err
+			}
+		}
+		identity = model.UserIdentity{
+			UserID:   user.ID,
+			Provider: providerName,
+			Subject:  profile.Subject,
+			Username: profile.Username,
+			Email:    profile.Email,
+		}
+		return tx.Create(&identity).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Linked OAuth account", "provider", providerName, "subject", profile.Subject, "user_id", user.ID)
+	return &user, nil
+}
+
+// createOAuthUser creates an account without a password for a first OAuth
+// login, picking a free username based on the provider's. Usernames listed
+// as admins count as taken.
+func (h *Handler) createOAuthUser(tx *gorm.DB, providerName string, profile *utils.OAuthProfile, user *model.User) error {
+	base := strings.TrimSpace(profile.Username)
+	if base == "" {
+		base = providerName + "-" + profile.Subject
+	}
+	role := h.Config.DefaultRole
+	if !model.ValidRole(role) {
+		role = model.RoleViewer
+	}
+
+	*user = model.User{Role: role}
+	for i := 0; ; i++ {
+		user.Username = base
+		if i == 1 {
+			user.Username = base + "-" + providerName
+		} else if i > 1 {
+			user.Username = fmt.Sprintf("%s-%s-%d", base, providerName, i)
+		}
+		var taken int64
+		if err := tx.Model(&model.User{}).Where("username = ?", user.Username).Count(&taken).Error; err != nil {
+			return fmt.Errorf("error checking username: %w", err)
+		}
+		if taken == 0 && !h.reservedUsername(user.Username) {
+			break
+		}
+	}
+
+	// A verified provider address is taken over unless another account has it
+	if profile.Email != "" && profile.EmailVerified {
+		var taken int64
+		if err := tx.Model(&model.User{}).Where("email = ?", profile.Email).Count(&taken).Error; err != nil {
+			return fmt.Errorf("error checking email: %w", err)
+		}
+		if taken == 0 {
+			user.Email = &profile.Email
+			user.EmailVerified = true
+		}
+	}
+
+	if err := tx.Create(user).Error; err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	slog.Info("Created user for OAuth login", "user_id", user.ID, "username", user.Username, "provider", providerName)
+	return nil
+}
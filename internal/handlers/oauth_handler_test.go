@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCreateOAuthUserSkipsAdminUsernames(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(model.All()...); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{DB: db, Config: &config.Config{Admins: []string{"root"}}}
+
+	var user model.User
+	profile := &utils.OAuthProfile{Subject: "42", Username: "root"}
+	if err := h.createOAuthUser(db, "github", profile, &user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "root-github" {
+		t.Errorf("created %q, want root-github", user.Username)
+	}
+	if user.Role == model.RoleAdmin || h.roleOf(&user) == model.RoleAdmin {
+		t.Error("OAuth login with an admin username became an admin")
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// serverPermissions maps the routes of a single server to the permission a
+// grant must include. Server routes missing here need full access, which
+// only the owner and the managers and owners of the server's team have.
+var serverPermissions = map[string]string{
+	"GET /servers/{id}":                                        model.PermissionView,
+	"GET /servers/{id}/preflight":                              model.PermissionView,
+	"GET /servers/{id}/config":                                 model.PermissionView,
+	"GET /servers/{id}/stats":                                  model.PermissionView,
+	"GET /servers/{id}/metrics":                                model.PermissionView,
+	"GET /servers/{id}/icon":                                   model.PermissionView,
+	"GET /servers/{id}/proxy/backends":                         model.PermissionView,
+	"GET /servers/{id}/proxy/autoscale":                        model.PermissionView,
+	"POST /servers/{id}/start":                                 model.PermissionPower,
+	"POST /servers/{id}/stop":                                  model.PermissionPower,
+	"POST /servers/{id}/restart":                               model.PermissionPower,
+	"GET /servers/{id}/output":                                 model.PermissionConsole,
+	"GET /servers/{id}/output/ws":                              model.PermissionConsole,
+	"GET /servers/{id}/announcements":                          model.PermissionConsole,
+	"GET /servers/{id}/maintenance":                            model.PermissionView,
+	"POST /servers/{id}/announcements":                         model.PermissionConsole,
+	"PUT /servers/{id}/announcements/{announcement_id}":        model.PermissionConsole,
+	"DELETE /servers/{id}/announcements/{announcement_id}":     model.PermissionConsole,
+	"PATCH /servers/{id}/gameplay":                             model.PermissionConsole,
+	"GET /servers/{id}/pregen":                                 model.PermissionConsole,
+	"POST /servers/{id}/pregen":                                model.PermissionConsole,
+	"POST /servers/{id}/pregen/{action}":                       model.PermissionConsole,
+	"GET /servers/{id}/crashes":                                model.PermissionConsole,
+	"GET /servers/{id}/crashes/{crash_id}":                     model.PermissionConsole,
+	"GET /servers/{id}/crashes/{crash_id}/reports/{report_id}": model.PermissionConsole,
+	"POST /servers/{id}/command":                               model.PermissionConsole,
+	"GET /servers/{id}/players":                                model.PermissionConsole,
+	"POST /servers/{id}/players":                               model.PermissionConsole,
+	"GET /servers/{id}/players/profiles":                       model.PermissionConsole,
+	"GET /servers/{id}/bans":                                   model.PermissionConsole,
+	"PUT /servers/{id}/icon":                                   model.PermissionFiles,
+	"DELETE /servers/{id}/icon":                                model.PermissionFiles,
+	"GET /servers/{id}/export":                                 model.PermissionFiles,
+	"POST /servers/{id}/upload-jar":                            model.PermissionFiles,
+	"POST /servers/{id}/upload-modpack":                        model.PermissionFiles,
+	"POST /servers/{id}/mods":                                  model.PermissionFiles,
+	"GET /servers/{id}/mods/validate":                          model.PermissionView,
+	"GET /servers/{id}/install":                                model.PermissionView,
+	"POST /servers/{id}/geyser":                                model.PermissionFiles,
+	"GET /servers/{id}/addons":                                 model.PermissionView,
+	"DELETE /servers/{id}/addons/{addon_id}":                   model.PermissionFiles,
+	"GET /servers/{id}/additional-files":                       model.PermissionView,
+	"POST /servers/{id}/additional-files":                      model.PermissionFiles,
+	"DELETE /servers/{id}/additional-files/{file_id}":          model.PermissionFiles,
+	"GET /servers/{id}/jar":                                    model.PermissionFiles,
+	"POST /servers/{id}/jar":                                   model.PermissionFiles,
+	"DELETE /servers/{id}/world":                               model.PermissionFiles,
+	"POST /servers/{id}/modpack/upgrade":                       model.PermissionFiles,
+	"GET /servers/{id}/modpack/upgrade":                        model.PermissionView,
+	"GET /servers/{id}/backups":                                model.PermissionBackups,
+	"POST /servers/{id}/backups":                               model.PermissionBackups,
+	"GET /servers/{id}/backup-schedule":                        model.PermissionBackups,
+	"PUT /servers/{id}/backup-schedule":                        model.PermissionBackups,
+	"DELETE /servers/{id}/backup-schedule":                     model.PermissionBackups,
+	"DELETE /servers/{id}/grants/{user_id}":                    model.PermissionView,
+}
+
+// ServerPermissions checks the caller's permission on the server a route
+// acts on, resolving backup routes to the backup's server. Routes outside
+// /servers/{id} and /backups/{id} pass through. It must be installed after
+// AuthMiddleware.
+func (h *Handler) ServerPermissions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path := routePath(template)
+		isServer := path == "/servers/{id}" || strings.HasPrefix(path, "/servers/{id}/")
+		isBackup := path == "/backups/{id}" || strings.HasPrefix(path, "/backups/{id}/")
+		if !isServer && !isBackup {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+		if !ok {
+			utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			// The handler reports the malformed ID
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		serverID := uint(id)
+		permission := model.PermissionBackups
+		if isServer {
+			var mapped bool
+			if permission, mapped = serverPermissions[r.Method+" "+path]; !mapped {
+				permission = model.PermissionManage
+			}
+		} else {
+			backup, err := h.ServerManager.GetBackup(uint(id), userID)
+			if err != nil {
+				// The handler reports the missing backup
+				next.ServeHTTP(w, r)
+				return
+			}
+			serverID = backup.ServerID
+		}
+
+		if err := h.ServerManager.Authorize(serverID, userID, permission); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				utils.WriteError(w, "Server not found", http.StatusNotFound)
+			case errors.Is(err, server_manager.ErrPermissionDenied):
+				utils.WriteError(w, "Forbidden: requires the "+permission+" permission on this server", http.StatusForbidden)
+			default:
+				utils.WriteError(w, "Failed to check server permissions", http.StatusInternalServerError)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorizeServerAccess checks the caller's permission on the server in the
+// route, writing the error response and returning false when it is missing
+func (h *Handler) authorizeServerAccess(w http.ResponseWriter, r *http.Request, permission string) (uint, bool) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return 0, false
+	}
+	if err := h.ServerManager.Authorize(uint(id), userID, permission); err != nil {
+		serverAccessError(w, err, "Failed to check server permissions")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// serverAccessError writes the response for an error of a server operation,
+// mapping a missing server to 404 and a missing permission to 403
+func serverAccessError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, "Server not found", http.StatusNotFound)
+	case errors.Is(err, server_manager.ErrPermissionDenied):
+		utils.WriteError(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+	case errors.Is(err, server_manager.ErrServerOnNode):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	default:
+		utils.WriteError(w, message+": "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// fileLockError writes 409 naming the conflicting operation if err is
+// because another operation holds the files of a server, and reports
+// whether it was
+func fileLockError(w http.ResponseWriter, err error) bool {
+	var lockErr *server_manager.FileLockError
+	if !errors.As(err, &lockErr) {
+		return false
+	}
+	utils.WriteErrorResponse(w, model.ErrorResponse{
+		Status:   http.StatusConflict,
+		Code:     utils.CodeFilesLocked,
+		Error:    lockErr.Error(),
+		Conflict: lockErr.Operation,
+	})
+	return true
+}
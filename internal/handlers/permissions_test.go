@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/handlers"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorizationRoutes are the routes TestServerAuthorization calls, with the
+// permission or role that guards them
+var authorizationRoutes = []struct {
+	method, path string
+}{
+	{"GET", "/servers/{id}"},          // view
+	{"GET", "/servers/{id}/output"},   // console
+	{"POST", "/servers/{id}/start"},   // power
+	{"POST", "/servers/{id}/mods"},    // files
+	{"POST", "/servers/{id}/backups"}, // backups
+	{"GET", "/backups/{id}"},          // backups, on the backup's server
+	{"PUT", "/servers/{id}/dns"},      // unmapped, so manage
+	{"POST", "/servers/{id}/clone"},   // admin role
+}
+
+func TestServerAuthorization(t *testing.T) {
+	h, db := newHandler(t)
+	sm := h.ServerManager
+
+	// The whole authenticated middleware chain in front of handlers that
+	// only answer 200, except for the backup route whose handler reports
+	// backups the caller cannot see
+	r := mux.NewRouter()
+	api := r.PathPrefix(handlers.APIPrefix).Subrouter()
+	api.Use(middleware.AuthMiddleware(h.JWT, sm.CheckSession))
+	api.Use(h.RBAC)
+	api.Use(h.ServerPermissions)
+	for _, route := range authorizationRoutes {
+		handler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+		if route.path == "/backups/{id}" {
+			handler = h.GetBackup
+		}
+		api.HandleFunc(route.path, handler).Methods(route.method)
+	}
+
+	users := map[string]*model.User{}
+	tokens := map[string]string{}
+	sessions := map[string]*model.Session{}
+	for _, name := range []string{"owner", "manager", "teammate", "invitee", "stranger", "viewer", "console", "power", "files", "backups", "reader"} {
+		role := model.RoleOperator
+		if name == "reader" {
+			role = model.RoleViewer
+		}
+		user := &model.User{Username: name, Password: "x", Role: role}
+		require.NoError(t, db.Create(user).Error)
+		session, err := sm.CreateSession(user.ID, "127.0.0.1", "test", time.Hour)
+		require.NoError(t, err)
+		token, err := h.JWT.GenerateJWT(user.ID, name, session.TokenID)
+		require.NoError(t, err)
+		users[name], sessions[name], tokens[name] = user, session, token
+	}
+
+	team := &model.Team{Name: "crew"}
+	require.NoError(t, db.Create(team).Error)
+	require.NoError(t, db.Create(&model.Membership{TeamID: team.ID, UserID: users["manager"].ID, Role: model.TeamRoleManager, Accepted: true}).Error)
+	require.NoError(t, db.Create(&model.Membership{TeamID: team.ID, UserID: users["teammate"].ID, Role: model.TeamRoleMember, Accepted: true}).Error)
+	require.NoError(t, db.Create(&model.Membership{TeamID: team.ID, UserID: users["invitee"].ID, Role: model.TeamRoleMember}).Error)
+	srv := &model.Server{Name: "survival", Path: t.TempDir(), UserID: users["owner"].ID, TeamID: &team.ID}
+	require.NoError(t, db.Create(srv).Error)
+	backup := &model.Backup{ServerID: srv.ID, UserID: users["owner"].ID, Name: "nightly", Path: "nightly.tar.gz", Status: model.BackupStatusCompleted}
+	require.NoError(t, db.Create(backup).Error)
+
+	// A grant with no permissions listed still includes view
+	grants := map[string]string{"viewer": "", "console": "console", "power": "power", "files": "files", "backups": "backups", "reader": "power"}
+	for name, permissions := range grants {
+		require.NoError(t, db.Create(&model.ServerGrant{ServerID: srv.ID, UserID: users[name].ID, Permissions: permissions, GrantedBy: users["owner"].ID}).Error)
+	}
+
+	call := func(user, method, path string) int {
+		target := fmt.Sprintf("%s/backups/%d", handlers.APIPrefix, backup.ID)
+		if rest, found := strings.CutPrefix(path, "/servers/{id}"); found {
+			target = fmt.Sprintf("%s/servers/%d%s", handlers.APIPrefix, srv.ID, rest)
+		}
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+tokens[user])
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	const ok, forbidden, missing = http.StatusOK, http.StatusForbidden, http.StatusNotFound
+	// Codes in the order of authorizationRoutes
+	want := map[string][]int{
+		"owner":   {ok, ok, ok, ok, ok, ok, ok, forbidden},
+		"manager": {ok, ok, ok, ok, ok, ok, ok, forbidden},
+		// Plain members hold everything but manage
+		"teammate": {ok, ok, ok, ok, ok, ok, forbidden, forbidden},
+		"invitee":  {missing, missing, missing, missing, missing, missing, missing, forbidden},
+		"stranger": {missing, missing, missing, missing, missing, missing, missing, forbidden},
+		"viewer":   {ok, forbidden, forbidden, forbidden, forbidden, forbidden, forbidden, forbidden},
+		"console":  {ok, ok, forbidden, forbidden, forbidden, forbidden, forbidden, forbidden},
+		"power":    {ok, forbidden, ok, forbidden, forbidden, forbidden, forbidden, forbidden},
+		"files":    {ok, forbidden, forbidden, ok, forbidden, forbidden, forbidden, forbidden},
+		"backups":  {ok, forbidden, forbidden, forbidden, ok, ok, forbidden, forbidden},
+		// The viewer role stops changes before the grant is looked at
+		"reader": {ok, forbidden, forbidden, forbidden, forbidden, forbidden, forbidden, forbidden},
+	}
+	for user, codes := range want {
+		for i, route := range authorizationRoutes {
+			assert.Equal(t, codes[i], call(user, route.method, route.path), "%s %s %s", user, route.method, route.path)
+		}
+	}
+
+	// A revoked session stops working right away
+	require.NoError(t, sm.RevokeSession(users["owner"].ID, sessions["owner"].ID))
+	assert.Equal(t, http.StatusUnauthorized, call("owner", "GET", "/servers/{id}"))
+	assert.Equal(t, ok, call("teammate", "GET", "/servers/{id}"))
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/mojang"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// PlayerActionRequest represents the payload for managing a player
+type PlayerActionRequest struct {
+	// Action is kick, ban, pardon, op, deop or whisper
+	Action string `json:"action" example:"kick" validate:"oneof=kick ban pardon op deop whisper"`
+	Player string `json:"player" example:"Steve" validate:"required,max=16"`
+	// Message is the kick or ban reason, or the text to whisper
+	Message string `json:"message,omitempty"`
+}
+
+// ListPlayers godoc
+// @Summary List online players
+// @Description Get the players online on a server, tracked from join and leave messages since it started
+// @Tags players
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/players [get]
+func (h *Handler) ListPlayers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	players, err := h.ServerManager.ListPlayers(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to list players", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(players)
+}
+
+// PlayerAction godoc
+// @Summary Manage a player
+// @Description Kick, ban, pardon, op, deop or whisper to a player through the server console. The server must be running.
+// @Tags players
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body PlayerActionRequest true "Action"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/players [post]
+func (h *Handler) PlayerAction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req PlayerActionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.ServerManager.PlayerAction(uint(id), userID, req.Action, req.Player, req.Message); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to "+req.Action+" player: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Command sent"})
+}
+
+// ListPlayerProfiles godoc
+// @Summary List online players with their profiles
+// @Description Get the players online on a server with their UUIDs and skins from Mojang, cached for an hour. Offline mode servers get the UUIDs the server derives from the names and no skins. Players Mojang cannot resolve are listed by name only.
+// @Tags players
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} mojang.Profile
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/players/profiles [get]
+func (h *Handler) ListPlayerProfiles(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionConsole)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	profiles, err := h.ServerManager.ListPlayerProfiles(r.Context(), id, userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to list players")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(profiles)
+}
+
+// ListBans godoc
+// @Summary List banned players
+// @Description Get the entries of the server's banned-players.json with each player's current name and skin. banned_as holds the name a player was banned under when they have been renamed since; pardon them by their current name.
+// @Tags players
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} server_manager.Ban
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/bans [get]
+func (h *Handler) ListBans(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionConsole)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	bans, err := h.ServerManager.ListBans(r.Context(), id, userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to list bans")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bans)
+}
+
+// GetPlayerProfile godoc
+// @Summary Look up a player
+// @Description Get the UUID, current name and skin of a Minecraft account by name or UUID. Lookups are cached for an hour; a name that has changed hands resolves to its current holder.
+// @Tags players
+// @Produce json
+// @Param player path string true "Player name or UUID"
+// @Success 200 {object} mojang.Profile
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 502 {object} model.ErrorResponse
+// @Failure 503 {object} model.ErrorResponse
+// @Router /players/{player} [get]
+func (h *Handler) GetPlayerProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.ServerManager.PlayerProfile(r.Context(), mux.Vars(r)["player"])
+	if err != nil {
+		switch {
+		case errors.Is(err, server_manager.ErrInvalidPlayer):
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, mojang.ErrNotFound):
+			utils.WriteError(w, "Player not found", http.StatusNotFound)
+		case errors.Is(err, mojang.ErrRateLimited):
+			w.Header().Set("Retry-After", "60")
+			utils.WriteError(w, "Player lookups are rate limited by Mojang, try again shortly", http.StatusServiceUnavailable)
+		default:
+			utils.WriteError(w, "Failed to look up player: "+err.Error(), http.StatusBadGateway)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(profile)
+}
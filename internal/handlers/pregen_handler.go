@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// StartPregenRequest represents the payload for starting a pre-generation task
+type StartPregenRequest struct {
+	// World defaults to the server's level-name
+	World   string `json:"world,omitempty" example:"world" validate:"omitempty,max=255,excludesall= "`
+	CenterX int    `json:"center_x"`
+	CenterZ int    `json:"center_z"`
+	// Radius is the distance from the center in blocks
+	Radius int `json:"radius" example:"5000" validate:"required,gt=0,lte=100000"`
+	// PauseForPlayers pauses the task while players are online; true if omitted
+	PauseForPlayers *bool `json:"pause_for_players,omitempty"`
+}
+
+// pregenError writes the response for an error of a pre-generation operation
+func pregenError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, server_manager.ErrServerNotRunning), errors.Is(err, server_manager.ErrNoActivePregen), errors.Is(err, server_manager.ErrServerOnNode):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, server_manager.ErrPermissionDenied):
+		serverAccessError(w, err, message)
+	default:
+		utils.WriteError(w, message+": "+err.Error(), http.StatusBadRequest)
+	}
+}
+
+// ListPregenTasks godoc
+// @Summary List the pre-generation tasks of a server
+// @Description Get the most recent pre-generation tasks of a server with their state and progress, newest first
+// @Tags pregen
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {array} model.PregenTask
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/pregen [get]
+func (h *Handler) ListPregenTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := h.ServerManager.ListPregenTasks(uint(id), userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to fetch pre-generation tasks")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tasks)
+}
+
+// StartPregen godoc
+// @Summary Start pre-generating a world
+// @Description Run a Chunky task generating the chunks within radius blocks of a center on a running server with the Chunky plugin or mod. Progress is read from the console. Unless pause_for_players is false, the task pauses while players are online and continues once the server is empty.
+// @Tags pregen
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body StartPregenRequest true "Task"
+// @Success 201 {object} model.PregenTask
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/pregen [post]
+func (h *Handler) StartPregen(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req StartPregenRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	task, err := h.ServerManager.StartPregen(uint(id), userID, server_manager.PregenOptions{
+		World:           req.World,
+		CenterX:         req.CenterX,
+		CenterZ:         req.CenterZ,
+		Radius:          req.Radius,
+		PauseForPlayers: req.PauseForPlayers == nil || *req.PauseForPlayers,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error starting pre-generation", "error", err)
+		pregenError(w, err, "Failed to start pre-generation")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(task)
+}
+
+// ControlPregen godoc
+// @Summary Pause, resume or cancel pre-generation
+// @Description Pause, resume or cancel the active pre-generation task of a server. Tasks of a server that stopped are paused and can be resumed after it starts again.
+// @Tags pregen
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param action path string true "pause, resume or cancel"
+// @Success 200 {object} model.PregenTask
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/pregen/{action} [post]
+func (h *Handler) ControlPregen(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var task *model.PregenTask
+	switch vars["action"] {
+	case "pause":
+		task, err = h.ServerManager.PausePregen(uint(id), userID)
+	case "resume":
+		task, err = h.ServerManager.ResumePregen(uint(id), userID)
+	case "cancel":
+		task, err = h.ServerManager.CancelPregen(uint(id), userID)
+	default:
+		utils.WriteError(w, "Unknown action", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error controlling pre-generation", "action", vars["action"], "error", err)
+		pregenError(w, err, "Failed to "+vars["action"]+" pre-generation")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(task)
+}
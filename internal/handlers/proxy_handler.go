@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// SetProxyTypeRequest represents the payload for turning a server into a proxy
+type SetProxyTypeRequest struct {
+	// ProxyType is velocity or bungeecord, empty to turn the proxy back into a game server
+	ProxyType string `json:"proxy_type" example:"velocity" validate:"omitempty,oneof=velocity bungeecord"`
+}
+
+// AttachProxyBackendRequest represents the payload for attaching a server to a proxy
+type AttachProxyBackendRequest struct {
+	ServerID uint `json:"server_id" validate:"required"`
+	// Address is where the proxy reaches the server, its port on localhost by default
+	Address string `json:"address,omitempty" example:"127.0.0.1:25566" validate:"omitempty,hostname_port"`
+}
+
+// proxyError writes the response for an error of a proxy operation
+func proxyError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, server_manager.ErrNotProxy), errors.Is(err, server_manager.ErrServerOnNode):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, server_manager.ErrPermissionDenied):
+		serverAccessError(w, err, message)
+	default:
+		utils.WriteError(w, message+": "+err.Error(), http.StatusBadRequest)
+	}
+}
+
+// SetProxyType godoc
+// @Summary Turn a server into a proxy
+// @Description Mark a server that runs Velocity or BungeeCord as proxy, so other servers can be attached to it, and generate its forwarding secret. An empty type turns it back into a game server once no servers are attached.
+// @Tags proxies
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body SetProxyTypeRequest true "Proxy type"
+// @Success 200 {object} model.Server
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy [put]
+func (h *Handler) SetProxyType(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SetProxyTypeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	srv, err := h.ServerManager.SetProxyType(uint(id), userID, req.ProxyType)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error setting proxy type", "error", err)
+		proxyError(w, err, "Failed to set proxy type")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(srv)
+}
+
+// ListProxyBackends godoc
+// @Summary List the servers attached to a proxy
+// @Tags proxies
+// @Produce json
+// @Param id path uint true "Proxy server ID"
+// @Success 200 {array} model.Server
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy/backends [get]
+func (h *Handler) ListProxyBackends(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	backends, err := h.ServerManager.ProxyBackends(uint(id), userID)
+	if err != nil {
+		proxyError(w, err, "Failed to fetch backends")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(backends)
+}
+
+// AttachProxyBackend godoc
+// @Summary Attach a server to a proxy
+// @Description Add a server to the proxy's server list and make it accept the players the proxy forwards: Velocity modern forwarding in config/paper-global.yml, or bungeecord in spigot.yml, with online-mode=false. Both servers pick up the change on their next start.
+// @Tags proxies
+// @Accept json
+// @Produce json
+// @Param id path uint true "Proxy server ID"
+// @Param request body AttachProxyBackendRequest true "Backend"
+// @Success 200 {object} model.Server
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy/backends [post]
+func (h *Handler) AttachProxyBackend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req AttachProxyBackendRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	backend, err := h.ServerManager.AttachToProxy(uint(id), req.ServerID, userID, req.Address)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error attaching server to proxy", "error", err)
+		proxyError(w, err, "Failed to attach server")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(backend)
+}
+
+// DetachProxyBackend godoc
+// @Summary Detach a server from a proxy
+// @Description Remove a server from the proxy's server list and turn its proxy forwarding off and online-mode back on
+// @Tags proxies
+// @Produce json
+// @Param id path uint true "Proxy server ID"
+// @Param server_id path uint true "Backend server ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy/backends/{server_id} [delete]
+func (h *Handler) DetachProxyBackend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	backendID, err := strconv.ParseUint(vars["server_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid backend server ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DetachFromProxy(uint(id), uint(backendID), userID); err != nil {
+		slog.ErrorContext(r.Context(), "Error detaching server from proxy", "error", err)
+		proxyError(w, err, "Failed to detach server")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Server detached from proxy"})
+}
+
+// RotateForwardingSecret godoc
+// @Summary Rotate the forwarding secret of a proxy
+// @Description Generate a new forwarding secret and write it to the proxy and all attached servers. Restart them to use it.
+// @Tags proxies
+// @Produce json
+// @Param id path uint true "Proxy server ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/proxy/secret [post]
+func (h *Handler) RotateForwardingSecret(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.RotateForwardingSecret(uint(id), userID); err != nil {
+		slog.ErrorContext(r.Context(), "Error rotating forwarding secret", "error", err)
+		proxyError(w, err, "Failed to rotate forwarding secret")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Forwarding secret rotated; restart the proxy and its servers"})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// PublicStatusResponse tells where the public status of a server is served
+type PublicStatusResponse struct {
+	Token string `json:"token" example:"3f2b9c0e4a7d1e6f8b5c2a9d0e1f4a7b"`
+	// URL is the status endpoint to embed, below the public URL of the manager
+	URL string `json:"url" example:"https://mc.example.com/api/v2/public/servers/3f2b9c0e4a7d1e6f8b5c2a9d0e1f4a7b/status"`
+}
+
+// EnablePublicStatus godoc
+// @Summary Publish the status of a server
+// @Description Opt a server into the unauthenticated status endpoint, which reports its MOTD, player count, version and uptime to anyone with the returned token, e.g. for a community website. Enabling it again returns the same token.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} PublicStatusResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/public-status [put]
+func (h *Handler) EnablePublicStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionManage)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	token, err := h.ServerManager.EnablePublicStatus(id, userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to enable public status")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(PublicStatusResponse{
+		Token: token,
+		URL:   h.publicURL(APIPrefixV2 + "/public/servers/" + token + "/status"),
+	})
+}
+
+// DisablePublicStatus godoc
+// @Summary Stop publishing the status of a server
+// @Description Take a server off the unauthenticated status endpoint. Its token stops working; publishing it again issues a new one.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/public-status [delete]
+func (h *Handler) DisablePublicStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionManage)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	if err := h.ServerManager.DisablePublicStatus(id, userID); err != nil {
+		serverAccessError(w, err, "Failed to disable public status")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Public status disabled"})
+}
+
+// GetPublicStatus godoc
+// @Summary Get the public status of a server
+// @Description Report whether a server is online with its MOTD, player count, version and uptime. Needs no authentication, only the token of a server whose owner published its status, and may be fetched from any website.
+// @Tags public
+// @Produce json
+// @Param token path string true "Public status token"
+// @Success 200 {object} server_manager.PublicStatus
+// @Failure 404 {object} model.ErrorResponse
+// @Router /public/servers/{token}/status [get]
+func (h *Handler) GetPublicStatus(w http.ResponseWriter, r *http.Request) {
+	// Community websites fetch the status from their own origin
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	status, err := h.ServerManager.GetPublicStatus(mux.Vars(r)["token"])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Status not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to get status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=15")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// APIPrefix is the path prefix of the first version of the API, which is
+// deprecated in favour of APIPrefixV2
+const APIPrefix = "/api/v1"
+
+// APIPrefixV2 is the path prefix of the API whose responses are enveloped
+const APIPrefixV2 = "/api/v2"
+
+// routePath returns a route template without its API version prefix
+func routePath(template string) string {
+	if path, found := strings.CutPrefix(template, APIPrefixV2); found {
+		return path
+	}
+	return strings.TrimPrefix(template, APIPrefix)
+}
+
+// adminRoutes are the routes that create or delete servers, manage shared
+// artifacts or schedule maintenance. Everything below /admin is admin only
+// as well.
+var adminRoutes = map[string]bool{
+	"POST /servers":                true,
+	"POST /servers/import":         true,
+	"POST /servers/import-bundle":  true,
+	"DELETE /servers/{id}":         true,
+	"POST /servers/{id}/clone":     true,
+	"POST /servers/{id}/restore":   true,
+	"POST /backups/{id}/servers":   true,
+	"POST /jar-files":              true,
+	"POST /jar-files/import":       true,
+	"DELETE /jar-files/{id}":       true,
+	"POST /mod-packs":              true,
+	"POST /mod-packs/import":       true,
+	"DELETE /mod-packs/{id}":       true,
+	"POST /mod-packs/{id}/apply":   true,
+	"POST /artifacts/gc":           true,
+	"POST /templates":              true,
+	"DELETE /templates/{id}":       true,
+	"POST /templates/{id}/servers": true,
+
+	// The autoscaler creates servers on behalf of whoever set the policy
+	"PUT /servers/{id}/proxy/autoscale": true,
+
+	// Operators could lift maintenance otherwise
+	"POST /servers/{id}/maintenance":               true,
+	"PUT /servers/{id}/maintenance/{window_id}":    true,
+	"DELETE /servers/{id}/maintenance/{window_id}": true,
+}
+
+// viewerRoutes are changes that only concern the caller's own account
+var viewerRoutes = map[string]bool{
+	"POST /teams/{id}/accept":               true,
+	"DELETE /teams/{id}/members/{user_id}":  true,
+	"DELETE /servers/{id}/grants/{user_id}": true,
+	"PUT /me/email":                         true,
+	"DELETE /me/sessions":                   true,
+	"DELETE /me/sessions/{id}":              true,
+}
+
+// requiredRole returns the least role allowed to call a route. Reads are
+// open to viewers and other changes to operators.
+func requiredRole(method, path string) string {
+	if adminRoutes[method+" "+path] || strings.HasPrefix(path, "/admin/") {
+		return model.RoleAdmin
+	}
+	if method == http.MethodGet || method == http.MethodHead || viewerRoutes[method+" "+path] {
+		return model.RoleViewer
+	}
+	return model.RoleOperator
+}
+
+// roleOf returns the effective role of a user; usernames listed as admins
+// in the configuration are always admins
+func (h *Handler) roleOf(user *model.User) string {
+	if slices.Contains(h.Config.Admins, user.Username) {
+		return model.RoleAdmin
+	}
+	return user.Role
+}
+
+// reservedUsername reports whether username is listed as an admin in the
+// configuration. Signup and OAuth never create such accounts, as whoever
+// claimed the name first would become an admin; they are created with the
+// admin create-user command instead.
+func (h *Handler) reservedUsername(username string) bool {
+	return slices.Contains(h.Config.Admins, username)
+}
+
+// RBAC rejects requests whose user's role is below the one the matched route
+// requires. It must be installed after AuthMiddleware.
+func (h *Handler) RBAC(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+		if !ok {
+			utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var user model.User
+		if err := h.DB.First(&user, userID).Error; err != nil {
+			utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		path := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				path = template
+			}
+		}
+		required := requiredRole(r.Method, routePath(path))
+		if !model.RoleAtLeast(h.roleOf(&user), required) {
+			utils.WriteError(w, "Forbidden: requires the "+required+" role", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
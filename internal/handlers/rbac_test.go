@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		method, path, role string
+	}{
+		{"GET", "/servers", model.RoleViewer},
+		{"GET", "/servers/{id}/output", model.RoleViewer},
+		{"HEAD", "/servers/{id}/icon", model.RoleViewer},
+		{"DELETE", "/me/sessions/{id}", model.RoleViewer},
+		{"DELETE", "/servers/{id}/grants/{user_id}", model.RoleViewer},
+		{"POST", "/servers/{id}/start", model.RoleOperator},
+		{"PUT", "/servers/{id}/dns", model.RoleOperator},
+		{"POST", "/servers", model.RoleAdmin},
+		{"DELETE", "/servers/{id}", model.RoleAdmin},
+		{"POST", "/servers/{id}/maintenance", model.RoleAdmin},
+		{"GET", "/admin/users", model.RoleAdmin},
+	}
+	for _, tt := range tests {
+		if got := requiredRole(tt.method, tt.path); got != tt.role {
+			t.Errorf("requiredRole(%s %s) = %s, want %s", tt.method, tt.path, got, tt.role)
+		}
+	}
+	if got := routePath(APIPrefixV2 + "/servers/{id}"); got != "/servers/{id}" {
+		t.Errorf("routePath of a v2 route = %s", got)
+	}
+}
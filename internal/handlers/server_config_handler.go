@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ServerConfigRequest is the full config of a server; omitted fields are
+// reset to their defaults
+type ServerConfigRequest struct {
+	ExecutableCommand string `json:"executable_command" example:"java -Xmx4096M -jar server.jar nogui" validate:"required,max=1024"`
+	JarFileID         uint   `json:"jar_file_id" validate:"required"`
+	// ModPackID is null for servers without a mod pack
+	ModPackID *uint             `json:"mod_pack_id"`
+	Env       map[string]string `json:"env" validate:"max=64,dive,max=4096"`
+	// Memory sizes of zero keep the flags of the executable command
+	Memory        server_manager.MemorySettings `json:"memory"`
+	RestartPolicy string                        `json:"restart_policy" example:"on-failure" validate:"omitempty,oneof=never on-failure"`
+	MaxRestarts   int                           `json:"max_restarts" example:"3" validate:"min=0,max=100"`
+	// Placeholder answers server list pings while the server is stopped
+	Placeholder server_manager.PlaceholderSettings `json:"placeholder"`
+}
+
+// GetServerConfig godoc
+// @Summary Get the config of a server
+// @Description Get everything that decides how a server starts: its executable command, jar, mod pack, environment variables, memory settings read from the command, restart policy and placeholder settings
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} server_manager.ServerConfigDocument
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/config [get]
+func (h *Handler) GetServerConfig(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionView)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	doc, err := h.ServerManager.GetServerConfigDocument(id, userID)
+	if err != nil {
+		serverAccessError(w, err, "Failed to get server config")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(doc)
+}
+
+// UpdateServerConfig godoc
+// @Summary Replace the config of a server
+// @Description Replace the config of a server in one document. Memory sizes are written into the -Xms and -Xmx flags of the executable command. Servers with the on-failure restart policy are started again after a crash, at most max_restarts times in ten minutes. With the placeholder enabled, a lightweight listener answers server list pings on the port of the stopped server with the placeholder MOTD, and with start_on_join a player trying to join starts the server and is told when to try again. Such on-demand servers are stopped again after idle_shutdown_minutes without players. Jar or mod pack changes require the server to be stopped; placeholder settings apply right away and everything else on the next start.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body ServerConfigRequest true "Server config"
+// @Success 200 {object} server_manager.ServerConfigDocument
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /servers/{id}/config [put]
+func (h *Handler) UpdateServerConfig(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionManage)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	var req ServerConfigRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	doc, err := h.ServerManager.UpdateServerConfig(id, userID, server_manager.ServerConfigDocument{
+		ExecutableCommand: req.ExecutableCommand,
+		JarFileID:         req.JarFileID,
+		ModPackID:         req.ModPackID,
+		Env:               req.Env,
+		Memory:            req.Memory,
+		RestartPolicy:     req.RestartPolicy,
+		MaxRestarts:       req.MaxRestarts,
+		Placeholder:       req.Placeholder,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating server config", "error", err)
+		switch {
+		case errors.Is(err, server_manager.ErrInvalidServerConfig):
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, server_manager.ErrServerRunning):
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+		default:
+			serverAccessError(w, err, "Failed to update server config")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(doc)
+}
@@ -4,190 +4,224 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/olindenbaum/mcgonalds/internal/config"
 	"github.com/olindenbaum/mcgonalds/internal/handlers"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
 	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
 	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-func setupTestEnvironment(t *testing.T) (*handlers.Handler, *gorm.DB, func()) {
-	// Create a temporary directory for the test
-	tempDir, err := os.MkdirTemp("", "mcgonalds_test")
-	assert.NoError(t, err)
+// helloRuntime starts processes that greet, finish loading and run until
+// interrupted, standing in for java
+type helloRuntime struct{}
 
-	// Set up a test database
-	db, err := gorm.Open(sqlite.Open(filepath.Join(tempDir, "test.db")), &gorm.Config{})
-	assert.NoError(t, err)
+func (helloRuntime) Start(spec server.ProcessSpec) (server.Process, error) {
+	stdout, out := io.Pipe()
+	p := &helloProcess{stdout: stdout, out: out, exited: make(chan struct{})}
+	go fmt.Fprint(out, "Hello from test JAR!\n[12:00:00] [Server thread/INFO]: Done (1.0s)! For help, type \"help\"\n")
+	return p, nil
+}
+
+type helloProcess struct {
+	stdout   io.Reader
+	out      *io.PipeWriter
+	exited   chan struct{}
+	exitOnce sync.Once
+}
+
+func (p *helloProcess) exit() error {
+	p.exitOnce.Do(func() {
+		p.out.Close()
+		close(p.exited)
+	})
+	return nil
+}
 
-	// Run migrations
-	err = db.AutoMigrate(&model.Server{}, &model.JarFile{}, &model.AdditionalFile{})
-	assert.NoError(t, err)
+func (p *helloProcess) Stdin() io.WriteCloser { return nopWriteCloser{io.Discard} }
+func (p *helloProcess) Stdout() io.Reader     { return p.stdout }
+func (p *helloProcess) Wait() error           { <-p.exited; return nil }
+func (p *helloProcess) Interrupt() error      { return p.exit() }
+func (p *helloProcess) Kill() error           { return p.exit() }
+func (p *helloProcess) PID() int              { return 0 }
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// setupTestEnvironment returns a handler working in a temporary directory,
+// the authenticated routes behind the middleware main installs, and an
+// admin with a token for them. Servers run on helloRuntime and pass the
+// preflight checks once their EULA is accepted.
+func setupTestEnvironment(t *testing.T) (*handlers.Handler, *gorm.DB, http.Handler, *model.User, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	// New servers are created below the working directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tempDir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "java"), []byte("#!/bin/sh\necho 'openjdk version \"21.0.2\"' >&2\n"), 0755))
+	t.Setenv("PATH", bin)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tempDir, "test.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(model.All()...))
 
-	// Create a test configuration
 	cfg := &config.Config{
 		Storage: config.Storage{
 			CommonDir: tempDir,
 		},
 	}
-
-	// Create a server manager
 	sm, err := server_manager.NewServerManager(db, cfg.Storage.CommonDir)
-	assert.NoError(t, err)
-
-	// Create a handler
-	h := handlers.NewHandler(db, sm, cfg)
+	require.NoError(t, err)
+	sm.SetRuntime(helloRuntime{})
+	jwtIssuer, err := utils.NewJWTIssuer(&config.JWTConfig{Secret: "test-secret"})
+	require.NoError(t, err)
+	h := handlers.NewHandler(db, sm, cfg, jwtIssuer)
+
+	r := mux.NewRouter()
+	api := r.PathPrefix(handlers.APIPrefix).Subrouter()
+	api.Use(middleware.AuthMiddleware(jwtIssuer, sm.CheckSession))
+	api.Use(h.RBAC)
+	api.Use(h.ServerPermissions)
+	h.RegisterAuthenticatedRoutes(api)
+
+	admin := &model.User{Username: "admin", Password: "x", Role: model.RoleAdmin}
+	require.NoError(t, db.Create(admin).Error)
+	session, err := sm.CreateSession(admin.ID, "127.0.0.1", "test", time.Hour)
+	require.NoError(t, err)
+	token, err := jwtIssuer.GenerateJWT(admin.ID, admin.Username, session.TokenID)
+	require.NoError(t, err)
+
+	return h, db, r, admin, token
+}
 
-	// Return a cleanup function
-	cleanup := func() {
-		os.RemoveAll(tempDir)
+// serve sends a request with token to router and returns the response
+func serve(router http.Handler, token, method, path string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, handlers.APIPrefix+path, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
 	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// createTestJar records a jar file that passes the jar check
+func createTestJar(t *testing.T, h *handlers.Handler, db *gorm.DB, name string) *model.JarFile {
+	t.Helper()
+	path := filepath.Join(h.Config.Storage.CommonDir, name)
+	require.NoError(t, os.WriteFile(path, []byte("PK\x03\x04"), 0644))
+	jarFile := &model.JarFile{Name: name, Version: "1.0", Path: path}
+	require.NoError(t, db.Create(jarFile).Error)
+	return jarFile
+}
 
-	return h, db, cleanup
+// acceptEULA accepts the EULA of the server at path
+func acceptEULA(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(path, "eula.txt"), []byte("eula=true\n"), 0644))
 }
 
 func TestCreateAndStopServer(t *testing.T) {
-	h, db, cleanup := setupTestEnvironment(t)
-	defer cleanup()
-
-	// Create a test JAR file
-	jarFile := &model.JarFile{
-		Name:    "test.jar",
-		Version: "1.0",
-		Path:    filepath.Join(h.Config.Storage.CommonDir, "test.jar"),
-	}
-	db.Create(jarFile)
+	h, db, router, _, token := setupTestEnvironment(t)
+	jarFile := createTestJar(t, h, db, "test.jar")
 
 	// Test creating a server
-	createServerRequest := model.CreateServerRequest{
-		Name:              "test_server",
-		JarFileID:         jarFile.ID,
-		ExecutableCommand: "java -jar test.jar",
-	}
-	reqBody, _ := json.Marshal(createServerRequest)
-	req, _ := http.NewRequest("POST", "/api/v1/servers", bytes.NewBuffer(reqBody))
-	rr := httptest.NewRecorder()
-	h.CreateServer(rr, req)
-
-	assert.Equal(t, http.StatusCreated, rr.Code)
-
-	var createdServer model.Server
-	json.Unmarshal(rr.Body.Bytes(), &createdServer)
-	assert.Equal(t, "test_server", createdServer.Name)
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	writer.WriteField("name", "test_server")
+	writer.WriteField("jar_file_id", fmt.Sprint(jarFile.ID))
+	writer.WriteField("executable_command", "java -jar test.jar")
+	writer.Close()
+	rr := serve(router, token, "POST", "/servers", &form, writer.FormDataContentType())
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	var created handlers.CreateServerResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "test_server", created.Server.Name)
+	acceptEULA(t, created.Server.Path)
 
 	// Test starting the server
-	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/servers/%s/start", createdServer.Name), nil)
-	rr = httptest.NewRecorder()
-	router := mux.NewRouter()
-	router.HandleFunc("/api/v1/servers/{name}/start", h.StartServer)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-
-	// Wait for the server to start
-	time.Sleep(2 * time.Second)
+	rr = serve(router, token, "POST", fmt.Sprintf("/servers/%d/start", created.Server.ServerId), nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 
 	// Test stopping the server
-	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/servers/%s/stop", createdServer.Name), nil)
-	rr = httptest.NewRecorder()
-	router = mux.NewRouter()
-	router.HandleFunc("/api/v1/servers/{name}/stop", h.StopServer)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(router, token, "POST", fmt.Sprintf("/servers/%d/stop", created.Server.ServerId), nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 }
 
 func TestDeleteServer(t *testing.T) {
-	h, db, cleanup := setupTestEnvironment(t)
-	defer cleanup()
+	h, db, router, admin, token := setupTestEnvironment(t)
 
 	// Create a test server
 	server := &model.Server{
-		Name: "test_server",
-		Path: filepath.Join(h.Config.Storage.CommonDir, "test_server"),
-		// JarFile: model.JarFile{Name: "test.jar", Version: "1.0"},
+		Name:   "test_server",
+		Path:   filepath.Join(h.Config.Storage.CommonDir, "test_server"),
+		UserID: admin.ID,
 	}
-	db.Create(server)
+	require.NoError(t, db.Create(server).Error)
 
 	// Test deleting the server
-	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/servers/%s", server.Name), nil)
-	rr := httptest.NewRecorder()
-	router := mux.NewRouter()
-	router.HandleFunc("/api/v1/servers/{name}", h.DeleteServer)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
+	rr := serve(router, token, "DELETE", fmt.Sprintf("/servers/%d", server.ID), nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 
 	// Verify the server was deleted from the database
 	var deletedServer model.Server
 	result := db.First(&deletedServer, "name = ?", server.Name)
 	assert.Error(t, result.Error)
-	assert.Nil(t, result.Error)
 }
 
 func TestVerifyJarOutput(t *testing.T) {
-	h, _, cleanup := setupTestEnvironment(t)
-	defer cleanup()
-
-	// Create a test JAR file
-	jarContent := []byte{
-		0xCA, 0xFE, 0xBA, 0xBE, // Magic number
-		0x00, 0x00, 0x00, 0x34, // Java class file version
-		// ... (rest of the JAR file content)
-	}
-	jarPath := filepath.Join(h.Config.Storage.CommonDir, "test_output.jar")
-	err := os.WriteFile(jarPath, jarContent, 0644)
-	assert.NoError(t, err)
+	h, db, router, admin, token := setupTestEnvironment(t)
+	jarFile := createTestJar(t, h, db, "test_output.jar")
 
 	// Create a server with the test JAR
-	server := &model.Server{
-		Name: "test_output_server",
-		Path: filepath.Join(h.Config.Storage.CommonDir, "test_output_server"),
-		// JarFile: model.JarFile{Name: "test_output.jar", Version: "1.0", Path: jarPath},
-	}
-	h.DB.Create(server)
+	path := filepath.Join(h.Config.Storage.CommonDir, "test_output_server")
+	id, err := h.ServerManager.CreateServer("test_output_server", path, "java -jar test_output.jar", jarFile, nil, nil, admin.ID)
+	require.NoError(t, err)
+	acceptEULA(t, path)
 
 	// Start the server
-	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/servers/%s/start", server.Name), nil)
-	rr := httptest.NewRecorder()
-	router := mux.NewRouter()
-	router.HandleFunc("/api/v1/servers/{name}/start", h.StartServer)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-
-	// Wait for the server to start and produce output
-	time.Sleep(2 * time.Second)
-
-	// Get the server output
-	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/servers/%s/output", server.Name), nil)
-	rr = httptest.NewRecorder()
-	router = mux.NewRouter()
-	router.HandleFunc("/api/v1/servers/{name}/output", h.GetServerOutput)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
-
-	var output string
-	json.Unmarshal(rr.Body.Bytes(), &output)
-	assert.Contains(t, output, "Hello from test JAR!")
+	rr := serve(router, token, "POST", fmt.Sprintf("/servers/%d/start", id), nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// Wait for the server to produce output
+	var output map[string]string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		rr = serve(router, token, "GET", fmt.Sprintf("/servers/%d/output", id), nil, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &output))
+		if output["output"] != "" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Contains(t, output["output"], "Hello from test JAR!")
 
 	// Stop the server
-	req, _ = http.NewRequest("POST", fmt.Sprintf("/api/v1/servers/%s/stop", server.Name), nil)
-	rr = httptest.NewRecorder()
-	router = mux.NewRouter()
-	router.HandleFunc("/api/v1/servers/{name}/stop", h.StopServer)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(router, token, "POST", fmt.Sprintf("/servers/%d/stop", id), nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 }
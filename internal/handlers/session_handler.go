@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// issueToken starts a session for user and returns its token, remembering
+// the client's address and user agent so the user can tell sessions apart
+func (h *Handler) issueToken(r *http.Request, user *model.User) (string, error) {
+	session, err := h.ServerManager.CreateSession(user.ID, middleware.ClientIP(r), r.UserAgent(), h.JWT.Expiration())
+	if err != nil {
+		return "", err
+	}
+	return h.JWT.GenerateJWT(user.ID, user.Username, session.TokenID)
+}
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description List the current user's active sessions with the address and user agent they were created from. The session of the calling token is marked as current.
+// @Tags auth
+// @Produce json
+// @Success 200 {array} model.Session
+// @Failure 500 {object} model.ErrorResponse
+// @Router /me/sessions [get]
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	current, _ := r.Context().Value(middleware.ContextSessionID).(string)
+
+	sessions, err := h.ServerManager.ListSessions(userID, current)
+	if err != nil {
+		utils.WriteError(w, "Failed to list sessions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Log out one of the current user's sessions; its token stops working immediately
+// @Tags auth
+// @Produce json
+// @Param id path uint true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /me/sessions/{id} [delete]
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.RevokeSession(userID, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Session revoked successfully"})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Log out every session of the current user except the one making the request
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]int64
+// @Failure 500 {object} model.ErrorResponse
+// @Router /me/sessions [delete]
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	current, _ := r.Context().Value(middleware.ContextSessionID).(string)
+
+	revoked, err := h.ServerManager.RevokeSessions(userID, current)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int64{"revoked": revoked})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CreateTeamRequest represents the payload for creating a team
+type CreateTeamRequest struct {
+	Name string `json:"name" example:"survival-crew" validate:"required,max=64"`
+}
+
+// InviteTeamMemberRequest represents the payload for inviting a user to a team
+type InviteTeamMemberRequest struct {
+	Username string `json:"username" example:"steve"`
+	// Role is owner, manager or member; it defaults to member
+	Role string `json:"role,omitempty" example:"member"`
+}
+
+// SetTeamMemberRoleRequest represents the payload for changing a team role
+type SetTeamMemberRoleRequest struct {
+	Role string `json:"role" example:"manager"`
+}
+
+// SetServerTeamRequest represents the payload for sharing a server with a team
+type SetServerTeamRequest struct {
+	// TeamID is the team to share the server with; null stops sharing
+	TeamID *uint `json:"team_id"`
+}
+
+// CreateTeam godoc
+// @Summary Create a team
+// @Description Create a team with the current user as its owner
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param request body CreateTeamRequest true "Team"
+// @Success 201 {object} model.Team
+// @Failure 400 {object} model.ErrorResponse
+// @Router /teams [post]
+func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateTeamRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	team, err := h.ServerManager.CreateTeam(req.Name, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating team", "error", err)
+		utils.WriteError(w, "Failed to create team: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(team)
+}
+
+// ListTeams godoc
+// @Summary List teams
+// @Description Get the teams of the current user with their role, including pending invitations
+// @Tags teams
+// @Produce json
+// @Success 200 {array} model.Membership
+// @Failure 500 {object} model.ErrorResponse
+// @Router /teams [get]
+func (h *Handler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	memberships, err := h.ServerManager.ListTeams(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch teams", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(memberships)
+}
+
+// ListTeamMembers godoc
+// @Summary List team members
+// @Description Get the members and pending invitations of a team the current user belongs to
+// @Tags teams
+// @Produce json
+// @Param id path uint true "Team ID"
+// @Success 200 {array} model.Membership
+// @Failure 404 {object} model.ErrorResponse
+// @Router /teams/{id}/members [get]
+func (h *Handler) ListTeamMembers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	teamID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	members, err := h.ServerManager.ListTeamMembers(uint(teamID), userID)
+	if err != nil {
+		teamError(w, err, "Failed to fetch team members")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(members)
+}
+
+// InviteTeamMember godoc
+// @Summary Invite a user to a team
+// @Description Invite a user by name. The invitation takes effect once the user accepts it. Owners and managers can invite; only owners can invite owners.
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path uint true "Team ID"
+// @Param request body InviteTeamMemberRequest true "Invitation"
+// @Success 201 {object} model.Membership
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /teams/{id}/invitations [post]
+func (h *Handler) InviteTeamMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	teamID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	var req InviteTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = "member"
+	}
+
+	membership, err := h.ServerManager.InviteTeamMember(uint(teamID), userID, req.Username, req.Role)
+	if err != nil {
+		teamError(w, err, "Failed to invite user")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(membership)
+}
+
+// AcceptTeamInvitation godoc
+// @Summary Accept a team invitation
+// @Description Join a team the current user was invited to
+// @Tags teams
+// @Produce json
+// @Param id path uint true "Team ID"
+// @Success 200 {object} model.Membership
+// @Failure 404 {object} model.ErrorResponse
+// @Router /teams/{id}/accept [post]
+func (h *Handler) AcceptTeamInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	teamID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+
+	membership, err := h.ServerManager.AcceptTeamInvitation(uint(teamID), userID)
+	if err != nil {
+		teamError(w, err, "Failed to accept invitation")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(membership)
+}
+
+// SetTeamMemberRole godoc
+// @Summary Change a team member's role
+// @Description Make a member an owner, manager or member. Managers can only assign member and manager; a team always keeps one owner.
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path uint true "Team ID"
+// @Param user_id path uint true "User ID"
+// @Param request body SetTeamMemberRoleRequest true "Role"
+// @Success 200 {object} model.Membership
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /teams/{id}/members/{user_id} [put]
+func (h *Handler) SetTeamMemberRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	teamID, memberID, ok := teamMemberIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetTeamMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	membership, err := h.ServerManager.SetTeamMemberRole(teamID, userID, memberID, req.Role)
+	if err != nil {
+		teamError(w, err, "Failed to change team role")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(membership)
+}
+
+// RemoveTeamMember godoc
+// @Summary Remove a team member
+// @Description Remove a member or withdraw an invitation. Members can always remove themselves to leave the team.
+// @Tags teams
+// @Produce json
+// @Param id path uint true "Team ID"
+// @Param user_id path uint true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /teams/{id}/members/{user_id} [delete]
+func (h *Handler) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	teamID, memberID, ok := teamMemberIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ServerManager.RemoveTeamMember(teamID, userID, memberID); err != nil {
+		teamError(w, err, "Failed to remove team member")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Team member removed successfully"})
+}
+
+// SetServerTeam godoc
+// @Summary Share a server with a team
+// @Description Give the members of a team access to a server, or stop sharing it with a null team_id. Team managers and owners hold every permission on it, plain members every one but manage. Only the server's owner can change this, and only to a team they belong to.
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Param request body SetServerTeamRequest true "Team"
+// @Success 200 {object} model.Server
+// @Failure 404 {object} model.ErrorResponse
+// @Router /servers/{id}/team [put]
+func (h *Handler) SetServerTeam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SetServerTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	serverModel, err := h.ServerManager.SetServerTeam(uint(id), userID, req.TeamID)
+	if err != nil {
+		teamError(w, err, "Failed to share server")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(serverModel)
+}
+
+// teamMemberIDs parses the team and user IDs of a team member route
+func teamMemberIDs(w http.ResponseWriter, r *http.Request) (uint, uint, bool) {
+	vars := mux.Vars(r)
+	teamID, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid team ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	memberID, err := strconv.ParseUint(vars["user_id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uint(teamID), uint(memberID), true
+}
+
+// teamError writes the response for an error of a team operation
+func teamError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, server_manager.ErrTeamPermission):
+		utils.WriteError(w, "Your team role does not allow this", http.StatusForbidden)
+	default:
+		utils.WriteError(w, message+": "+err.Error(), http.StatusBadRequest)
+	}
+}
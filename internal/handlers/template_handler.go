@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CreateTemplateRequest represents the payload for creating a server template
+type CreateTemplateRequest struct {
+	Name              string `json:"name" example:"paper-1.21" validate:"required,max=64"`
+	Description       string `json:"description" validate:"max=1000"`
+	JarFileID         uint   `json:"jar_file_id" validate:"required"`
+	ModPackID         *uint  `json:"mod_pack_id,omitempty"`
+	ExecutableCommand string `json:"executable_command,omitempty"`
+	MemoryMB          int    `json:"memory_mb" example:"4096" validate:"gte=0,lte=1048576"`
+	// Properties is the server.properties of created servers. It can use the
+	// placeholders {{port}}, {{name}}, {{motd}}, {{max_players}} and those of
+	// the declared variables.
+	Properties        string                   `json:"properties,omitempty" example:"server-port={{port}}\nmotd={{motd}}\nmax-players={{max_players}}"`
+	Variables         []model.TemplateVariable `json:"variables,omitempty" validate:"max=64"`
+	AdditionalFileIDs []uint                   `json:"additional_file_ids,omitempty"`
+}
+
+// CreateServerFromTemplateRequest represents the payload for creating a server from a template
+type CreateServerFromTemplateRequest struct {
+	Name string `json:"name" validate:"required,servername"`
+	// Variables sets the template's variables and motd or max_players, typed as declared
+	Variables map[string]interface{} `json:"variables,omitempty" swaggertype:"object"`
+}
+
+// CreateTemplate godoc
+// @Summary Create a server template
+// @Description Define a reusable blueprint of jar, mod pack, server.properties, memory and additional files
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param request body CreateTemplateRequest true "Template definition"
+// @Success 201 {object} model.Template
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /templates [post]
+func (h *Handler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateTemplateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	template := &model.Template{
+		Name:              req.Name,
+		Description:       req.Description,
+		JarFileID:         req.JarFileID,
+		ModPackID:         req.ModPackID,
+		ExecutableCommand: req.ExecutableCommand,
+		MemoryMB:          req.MemoryMB,
+		Properties:        req.Properties,
+		Variables:         req.Variables,
+		UserID:            userID,
+	}
+	if err := h.ServerManager.CreateTemplate(template, req.AdditionalFileIDs); err != nil {
+		slog.ErrorContext(r.Context(), "Error creating template", "error", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, server_manager.ErrTemplateExists) {
+			status = http.StatusConflict
+		}
+		utils.WriteError(w, "Failed to create template: "+err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// ListTemplates godoc
+// @Summary List server templates
+// @Description Get all server templates
+// @Tags templates
+// @Produce json
+// @Success 200 {array} model.Template
+// @Failure 500 {object} model.ErrorResponse
+// @Router /templates [get]
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.ServerManager.ListTemplates()
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch templates", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplate godoc
+// @Summary Get a server template
+// @Description Get a server template by ID
+// @Tags templates
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} model.Template
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /templates/{id} [get]
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.ServerManager.GetTemplate(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.WriteError(w, "Template not found", http.StatusNotFound)
+		} else {
+			utils.WriteError(w, "Failed to fetch template", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete a server template
+// @Description Delete a server template by ID. Servers created from it are unaffected.
+// @Tags templates
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /templates/{id} [delete]
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteTemplate(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.WriteError(w, "Template not found", http.StatusNotFound)
+		} else {
+			utils.WriteError(w, "Failed to delete template: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Template deleted successfully"})
+}
+
+// CreateServerFromTemplate godoc
+// @Summary Create a server from a template
+// @Description Create a new Minecraft server using the jar, mod pack, command and server.properties of a template. The placeholders of the properties are rendered with the given variables and their defaults; {{port}} gets a free port.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param request body CreateServerFromTemplateRequest true "Server name"
+// @Success 201 {object} CreateServerResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /templates/{id}/servers [post]
+func (h *Handler) CreateServerFromTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	templateID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateServerFromTemplateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	serverPath, err := serverPathFor(req.Name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting current working directory", "error", err)
+		utils.WriteError(w, "Failed to create server", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := h.ServerManager.CreateServerFromTemplate(uint(templateID), req.Name, serverPath, userID, req.Variables)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating server from template", "error", err)
+		if errors.Is(err, server_manager.ErrQuotaExceeded) {
+			utils.WriteError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, server_manager.ErrInvalidTemplateValues) {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.WriteError(w, "Failed to create server: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.respondCreatedServer(w, id, userID)
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/olindenbaum/mcgonalds/internal/handlers"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newHandler returns a handler on a fresh database with every table
+func newHandler(t *testing.T) (*handlers.Handler, *gorm.DB) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(model.All()...))
+
+	cfg := &config.Config{Storage: config.Storage{CommonDir: dir}}
+	sm, err := server_manager.NewServerManager(db, dir)
+	require.NoError(t, err)
+	jwtIssuer, err := utils.NewJWTIssuer(&config.JWTConfig{Secret: "test-secret"})
+	require.NoError(t, err)
+	return handlers.NewHandler(db, sm, cfg, jwtIssuer), db
+}
+
+// asUser returns r as sent by an authenticated user
+func asUser(r *http.Request, userID uint) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), middleware.ContextUserID, userID))
+}
+
+func TestCreateTemplate(t *testing.T) {
+	h, db := newHandler(t)
+	jarPath := filepath.Join(h.Config.Storage.CommonDir, "paper.jar")
+	require.NoError(t, os.WriteFile(jarPath, nil, 0644))
+	jar := &model.JarFile{Name: "paper.jar", Version: "1.21", Path: jarPath}
+	require.NoError(t, db.Create(jar).Error)
+
+	create := func(body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/templates", bytes.NewReader(data))
+		rr := httptest.NewRecorder()
+		h.CreateTemplate(rr, asUser(req, 1))
+		return rr
+	}
+
+	rr := create(handlers.CreateTemplateRequest{Name: "paper", JarFileID: jar.ID, MemoryMB: 2048})
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var template model.Template
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &template))
+	assert.Equal(t, "paper", template.Name)
+
+	rr = create(handlers.CreateTemplateRequest{Name: "paper", JarFileID: jar.ID})
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+
+	rr = create(handlers.CreateTemplateRequest{Name: "no-jar"})
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	rr = create(handlers.CreateTemplateRequest{
+		Name:       "bad-variable",
+		JarFileID:  jar.ID,
+		Properties: "view-distance={{view_distance}}",
+		Variables:  []model.TemplateVariable{{Name: "view_distance", Type: "float"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+}
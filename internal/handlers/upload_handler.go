@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// uploadProgressInterval is the least time between two progress messages
+const uploadProgressInterval = 250 * time.Millisecond
+
+// CreateUploadRequest announces an upload
+type CreateUploadRequest struct {
+	// Size is the size of the upload request in bytes, if known
+	Size int64 `json:"size,omitempty" example:"524288000" validate:"min=0"`
+}
+
+// CreateUpload godoc
+// @Summary Start an upload session
+// @Description Create a session to follow an upload with. Pass its ID as the upload_id query parameter of a jar or mod pack upload and watch /uploads/{id}/ws for the bytes received, an ETA and whether the upload is being validated.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param request body CreateUploadRequest false "Upload size"
+// @Success 201 {object} server_manager.UploadProgress
+// @Failure 400 {object} model.ErrorResponse
+// @Router /uploads [post]
+func (h *Handler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateUploadRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if !validRequest(w, req) {
+		return
+	}
+
+	progress, _ := h.ServerManager.CreateUploadSession(userID, req.Size).Progress()
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(progress)
+}
+
+// GetUpload godoc
+// @Summary Get the progress of an upload
+// @Tags uploads
+// @Produce json
+// @Param id path string true "Upload session ID"
+// @Success 200 {object} server_manager.UploadProgress
+// @Failure 404 {object} model.ErrorResponse
+// @Router /uploads/{id} [get]
+func (h *Handler) GetUpload(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.uploadSession(w, r)
+	if !ok {
+		return
+	}
+	progress, _ := session.Progress()
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(progress)
+}
+
+// GetUploadWS godoc
+// @Summary Watch the progress of an upload via WebSocket
+// @Description Establish a WebSocket connection that receives the progress of an upload as JSON whenever it changes, at most four times a second. The connection is closed once the upload is complete or failed.
+// @Tags uploads
+// @Param id path string true "Upload session ID"
+// @Success 101 {object} server_manager.UploadProgress
+// @Failure 404 {object} model.ErrorResponse
+// @Router /uploads/{id}/ws [get]
+func (h *Handler) GetUploadWS(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.uploadSession(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "WebSocket upgrade error", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// The client sends nothing; reading notices when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		progress, changed := session.Progress()
+		if err := conn.WriteJSON(progress); err != nil {
+			slog.ErrorContext(r.Context(), "WebSocket write error", "error", err)
+			return
+		}
+		if progress.Done() {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, progress.State))
+			return
+		}
+		select {
+		case <-changed:
+		case <-closed:
+			return
+		}
+		select {
+		case <-time.After(uploadProgressInterval):
+		case <-closed:
+			return
+		}
+	}
+}
+
+// uploadSession looks up the upload session in the route, writing an error
+// and returning false when the caller has none by that ID
+func (h *Handler) uploadSession(w http.ResponseWriter, r *http.Request) (*server_manager.UploadSession, bool) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	session, err := h.ServerManager.GetUploadSession(mux.Vars(r)["id"], userID)
+	if err != nil {
+		utils.WriteError(w, "Upload session not found", http.StatusNotFound)
+		return nil, false
+	}
+	return session, true
+}
+
+// TrackUpload reports the progress of an upload request to the session
+// named by its upload_id query parameter. Requests without one are handled
+// as usual.
+func (h *Handler) TrackUpload(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("upload_id")
+		if id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+		if !ok {
+			utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		session, err := h.ServerManager.GetUploadSession(id, userID)
+		if err != nil {
+			utils.WriteError(w, "Upload session not found", http.StatusNotFound)
+			return
+		}
+
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{session.Reader(r.Body, r.ContentLength), r.Body}
+		recorder := &uploadResponseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		session.Finish(recorder.err())
+	})
+}
+
+// uploadResponseRecorder keeps the status and error of a response
+type uploadResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (u *uploadResponseRecorder) WriteHeader(status int) {
+	u.status = status
+	u.ResponseWriter.WriteHeader(status)
+}
+
+func (u *uploadResponseRecorder) Write(data []byte) (int, error) {
+	if u.status >= http.StatusBadRequest {
+		u.body.Write(data)
+	}
+	return u.ResponseWriter.Write(data)
+}
+
+// err returns the error message of a failed response
+func (u *uploadResponseRecorder) err() error {
+	if u.status < http.StatusBadRequest {
+		return nil
+	}
+	var resp model.ErrorResponse
+	if json.Unmarshal(u.body.Bytes(), &resp) == nil && resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return errors.New(http.StatusText(u.status))
+}
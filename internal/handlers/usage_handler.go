@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// GetUsage godoc
+// @Summary Get resource usage of the current user
+// @Description Summarize servers, allocated RAM, disk, backups and API calls against the user's limits. A limit of 0 means unlimited.
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.Usage
+// @Failure 500 {object} model.ErrorResponse
+// @Router /me/usage [get]
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := h.ServerManager.Quota(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to compute usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	usage.APICalls.Used = h.Usage.Count(userID)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(usage)
+}
+
+// GetLimits godoc
+// @Summary Get remaining quota of the current user
+// @Description Report the user's quotas for servers, allocated RAM, disk, backups and API calls with what is used and what remains. Quotas set on the user override the configured defaults; remaining is omitted for unlimited resources.
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.Usage
+// @Failure 500 {object} model.ErrorResponse
+// @Router /users/me/limits [get]
+func (h *Handler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := h.ServerManager.Quota(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to compute quota: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	usage.APICalls.Used = h.Usage.Count(userID)
+	for _, meter := range []*model.UsageMeter{&usage.Servers, &usage.MemoryMB, &usage.DiskBytes, &usage.Backups, &usage.APICalls} {
+		meter.SetRemaining()
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(usage)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/olindenbaum/mcgonalds/internal/dns"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// maxServerNameLength bounds server names, which become directory names
+const maxServerNameLength = 64
+
+// serverNamePattern allows names that are safe as a single path element on
+// every platform
+var serverNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// validate checks request structs against their validate tags. Field errors
+// are reported under the JSON (or form) name of the field.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	v.RegisterValidation("servername", func(fl validator.FieldLevel) bool {
+		name := fl.Field().String()
+		return len(name) <= maxServerNameLength && serverNamePattern.MatchString(name) && !strings.Contains(name, "..")
+	})
+	// Unlike hostname_rfc1123 this allows no dots, so a name stays directly
+	// below the configured zone
+	v.RegisterValidation("dns_label", func(fl validator.FieldLevel) bool {
+		return dns.ValidLabel(fl.Field().String())
+	})
+	// Unlike the built-in timezone tag this allows "", the zone of the host
+	v.RegisterValidation("iana_timezone", func(fl validator.FieldLevel) bool {
+		name := fl.Field().String()
+		if name == "" {
+			return true
+		}
+		_, err := time.LoadLocation(name)
+		return err == nil && name != "Local"
+	})
+	return v
+}
+
+// fieldErrors collects the invalid fields of a request, so a client learns
+// about all of them at once
+type fieldErrors []model.FieldError
+
+// add records that field is invalid for the reason in message
+func (f *fieldErrors) add(field, message string) {
+	*f = append(*f, model.FieldError{Field: field, Message: message})
+}
+
+// require records field as missing unless set
+func (f *fieldErrors) require(field string, set bool) {
+	if !set {
+		f.add(field, "is required")
+	}
+}
+
+// check adds the failed validate tags of req
+func (f *fieldErrors) check(req interface{}) {
+	var errs validator.ValidationErrors
+	if err := validate.Struct(req); errors.As(err, &errs) {
+		for _, fe := range errs {
+			f.add(fieldPath(fe), fieldMessage(fe))
+		}
+	}
+}
+
+// write replies with a validation error if any field was invalid and
+// reports whether it did
+func (f fieldErrors) write(w http.ResponseWriter) bool {
+	if len(f) == 0 {
+		return false
+	}
+	utils.WriteValidationError(w, f...)
+	return true
+}
+
+// validRequest replies with a validation error unless req passes its
+// validate tags
+func validRequest(w http.ResponseWriter, req interface{}) bool {
+	var invalid fieldErrors
+	invalid.check(req)
+	return !invalid.write(w)
+}
+
+// decodeRequest decodes the JSON body into req and validates it, replying
+// with an error and returning false if either fails
+func decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return validRequest(w, req)
+}
+
+// fieldPath is the name of a field below the request, e.g. tags[2]
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// fieldMessage explains a failed validate tag
+func fieldMessage(fe validator.FieldError) string {
+	kind := fe.Kind()
+	sized := kind == reflect.String || kind == reflect.Slice || kind == reflect.Map
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "servername":
+		return fmt.Sprintf("must be at most %d letters, digits, '.', '_' or '-' and start with a letter or digit", maxServerNameLength)
+	case "iana_timezone":
+		return "must be an IANA timezone such as Europe/Berlin"
+	case "dns_label":
+		return "must be a single DNS label of letters, digits and '-' such as smp"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be an email address"
+	case "url", "http_url":
+		return "must be a URL"
+	case "hostname_port":
+		return "must be a host:port address"
+	case "min", "gte":
+		if sized {
+			return "must have at least " + fe.Param() + " " + unit(kind)
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if sized {
+			return "must have at most " + fe.Param() + " " + unit(kind)
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	default:
+		return "is invalid (" + fe.Tag() + ")"
+	}
+}
+
+func unit(kind reflect.Kind) string {
+	if kind == reflect.String {
+		return "characters"
+	}
+	return "entries"
+}
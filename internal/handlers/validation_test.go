@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestDecodeRequestValidation(t *testing.T) {
+	tests := []struct {
+		body   string
+		fields []model.FieldError
+	}{
+		{`{"server_ids":[1,2],"action":"restart"}`, nil},
+		{`{"server_ids":[],"action":"explode"}`, []model.FieldError{
+			{Field: "server_ids", Message: "must have at least 1 entries"},
+			{Field: "action", Message: "must be one of start, stop, restart"},
+		}},
+		{`{"action":"stop"}`, []model.FieldError{{Field: "server_ids", Message: "is required"}}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		var req BatchRequest
+		ok := decodeRequest(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)), &req)
+		if ok != (tt.fields == nil) {
+			t.Errorf("%s: decodeRequest = %v", tt.body, ok)
+			continue
+		}
+		if ok {
+			continue
+		}
+		var resp model.ErrorResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusBadRequest || len(resp.Fields) != len(tt.fields) {
+			t.Errorf("%s: %d %+v", tt.body, rec.Code, resp)
+			continue
+		}
+		for i, field := range tt.fields {
+			if resp.Fields[i] != field {
+				t.Errorf("%s: field %d = %+v, want %+v", tt.body, i, resp.Fields[i], field)
+			}
+		}
+	}
+}
+
+func TestServerNameValidation(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"survival":              true,
+		"paper-1.21_staging":    true,
+		"":                      false,
+		"../etc":                false,
+		".hidden":               false,
+		"a..b":                  false,
+		"with space":            false,
+		"nested/dir":            false,
+		strings.Repeat("a", 65): false,
+	} {
+		err := validate.Struct(CloneServerRequest{Name: name})
+		if (err == nil) != valid {
+			t.Errorf("name %q: %v, want valid %v", name, err, valid)
+		}
+	}
+}
+
+func TestDNSLabelValidation(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"smp":                   true,
+		"survival-2":            true,
+		"":                      false,
+		"smp.example.com":       false,
+		"-smp":                  false,
+		"smp-":                  false,
+		"smp_1":                 false,
+		strings.Repeat("a", 64): false,
+	} {
+		err := validate.Struct(RegisterDNSRequest{Name: name})
+		if (err == nil) != valid {
+			t.Errorf("name %q: %v, want valid %v", name, err, valid)
+		}
+	}
+}
+
+func TestTimezoneValidation(t *testing.T) {
+	for timezone, valid := range map[string]bool{
+		"Europe/Berlin": true,
+		"UTC":           true,
+		"":              true,
+		"Local":         false,
+		"Mars/Olympus":  false,
+	} {
+		err := validate.Struct(UpdateServerRequest{Timezone: &timezone})
+		if (err == nil) != valid {
+			t.Errorf("timezone %q: %v, want valid %v", timezone, err, valid)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CreateWebhookRequest represents the payload for registering a webhook
+type CreateWebhookRequest struct {
+	URL string `json:"url" example:"https://example.com/hooks/minecraft"`
+	// ServerID limits the webhook to one server; omit it for all servers
+	ServerID *uint `json:"server_id,omitempty"`
+	// Events limits the delivered event types; omit it for all
+	Events []string `json:"events,omitempty" example:"server.crashed,backup.failed"`
+}
+
+// CreateWebhookResponse is a webhook together with its signing secret,
+// which is only ever returned here
+type CreateWebhookResponse struct {
+	model.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register a URL that receives a JSON POST for server lifecycle, backup and player events. Each request carries an X-Mcgonalds-Signature header of the form sha256=<hex HMAC-SHA256 of the body keyed with the secret>. Failed deliveries are retried with backoff.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body CreateWebhookRequest true "Webhook"
+// @Success 201 {object} CreateWebhookResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, secret, err := h.ServerManager.CreateWebhook(userID, server_manager.WebhookOptions{
+		URL:      req.URL,
+		ServerID: req.ServerID,
+		Events:   req.Events,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating webhook", "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, server_manager.ErrPermissionDenied) {
+			serverAccessError(w, err, "Failed to create webhook")
+			return
+		}
+		utils.WriteError(w, "Failed to create webhook: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateWebhookResponse{Webhook: *webhook, Secret: secret})
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description Get the webhooks of the current user
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} model.Webhook
+// @Failure 500 {object} model.ErrorResponse
+// @Router /webhooks [get]
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	webhooks, err := h.ServerManager.ListWebhooks(userID)
+	if err != nil {
+		utils.WriteError(w, "Failed to fetch webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook and its delivery history
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} model.ErrorResponse
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ServerManager.DeleteWebhook(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to delete webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Get the most recent deliveries of a webhook with their attempts and outcome
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {array} model.WebhookDelivery
+// @Failure 404 {object} model.ErrorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.ContextUserID).(uint)
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.ServerManager.ListWebhookDeliveries(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "Failed to fetch deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deliveries)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// DeleteWorldResponse lists the world directories that were deleted
+type DeleteWorldResponse struct {
+	Deleted []string `json:"deleted" example:"world,world_nether,world_the_end"`
+}
+
+// DeleteWorld godoc
+// @Summary Delete the world of a server
+// @Description Delete the world directories of a stopped server so a new world is generated on the next start. Refused with 409, naming the conflicting operation, while the server is running or a backup, restore or jar swap is in progress.
+// @Tags servers
+// @Produce json
+// @Param id path uint true "Server ID"
+// @Success 200 {object} DeleteWorldResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /servers/{id}/world [delete]
+func (h *Handler) DeleteWorld(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.authorizeServerAccess(w, r, model.PermissionFiles)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value(middleware.ContextUserID).(uint)
+
+	deleted, err := h.ServerManager.DeleteWorld(id, userID)
+	if err != nil {
+		if fileLockError(w, err) {
+			return
+		}
+		if errors.Is(err, server_manager.ErrServerArchived) || errors.Is(err, server_manager.ErrServerOnNode) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		serverAccessError(w, err, "Failed to delete world")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(DeleteWorldResponse{Deleted: deleted})
+}
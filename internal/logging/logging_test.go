@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRequestIDIsLogged(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var out bytes.Buffer
+	if err := Setup(&out, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+	slog.DebugContext(context.Background(), "hidden")
+	slog.InfoContext(WithRequestID(context.Background(), "abc123"), "started server", "server_id", 7)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", out.String(), err)
+	}
+	if record["request_id"] != "abc123" || record["msg"] != "started server" || record["server_id"] != float64(7) {
+		t.Fatalf("unexpected record %v", record)
+	}
+}
+
+func TestSetupRejectsUnknownSettings(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var out bytes.Buffer
+	if err := Setup(&out, "loud", "text"); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+	if err := Setup(&out, "info", "xml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
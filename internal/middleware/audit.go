@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+const (
+	// auditBodyPeek is how much of a request body is read for the summary
+	auditBodyPeek = 4 << 10
+	// maxAuditSummary bounds the stored summary
+	maxAuditSummary = 1024
+)
+
+// auditRedacted lists substrings of JSON keys whose values are never stored
+var auditRedacted = []string{"password", "secret", "token", "key"}
+
+// Audit records every state-changing request of an authenticated user with
+// record. Reads are not recorded. It must be installed after AuthMiddleware
+// on a router whose routes use {id} for the server ID below /servers.
+func Audit(record func(entry *model.AuditLog)) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := r.Context().Value(ContextUserID).(uint)
+			if !ok || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			entry := &model.AuditLog{
+				UserID: userID,
+				Action: r.Method + " " + r.URL.Path,
+				Path:   r.URL.Path,
+				IP:     ClientIP(r),
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					entry.Action = r.Method + " " + template
+					if strings.Contains(template, "/servers/{id}") {
+						if id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64); err == nil {
+							serverID := uint(id)
+							entry.ServerID = &serverID
+						}
+					}
+				}
+			}
+
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") && r.Body != nil {
+				peek, _ := io.ReadAll(io.LimitReader(r.Body, auditBodyPeek))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(peek), r.Body), r.Body}
+				entry.Summary = SummarizeBody(peek)
+			} else if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+				entry.Summary = "multipart upload"
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			entry.StatusCode = recorder.status
+			record(entry)
+		})
+	}
+}
+
+// SummarizeBody returns a JSON body with the values of secret-looking keys
+// redacted, truncated to fit the audit log
+func SummarizeBody(body []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redacted, err := json.Marshal(redact(value)); err == nil {
+			body = redacted
+		}
+	} else {
+		// Truncated or invalid JSON may hold secrets we cannot find
+		return "unparsed body"
+	}
+	if len(body) > maxAuditSummary {
+		return string(body[:maxAuditSummary]) + "..."
+	}
+	return string(body)
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			lower := strings.ToLower(key)
+			secret := false
+			for _, word := range auditRedacted {
+				if strings.Contains(lower, word) {
+					secret = true
+					break
+				}
+			}
+			if secret {
+				v[key] = "[redacted]"
+			} else {
+				v[key] = redact(inner)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+	return value
+}
+
+// ClientIP returns the host part of the request's remote address
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
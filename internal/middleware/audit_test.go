@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeBodyRedactsSecrets(t *testing.T) {
+	summary := SummarizeBody([]byte(`{"username":"steve","password":"hunter2","nested":{"api_key":"abc"},"command":"say hi"}`))
+	for _, secret := range []string{"hunter2", "abc"} {
+		if strings.Contains(summary, secret) {
+			t.Errorf("summary %s leaks %q", summary, secret)
+		}
+	}
+	for _, kept := range []string{"steve", "say hi"} {
+		if !strings.Contains(summary, kept) {
+			t.Errorf("summary %s lost %q", summary, kept)
+		}
+	}
+}
+
+func TestSummarizeBodyTruncates(t *testing.T) {
+	summary := SummarizeBody([]byte(`{"command":"` + strings.Repeat("a", 2*maxAuditSummary) + `"}`))
+	if len(summary) > maxAuditSummary+3 {
+		t.Errorf("summary is %d bytes long", len(summary))
+	}
+	if SummarizeBody([]byte(`{"password":"hun`)) != "unparsed body" {
+		t.Error("expected invalid JSON to be withheld")
+	}
+}
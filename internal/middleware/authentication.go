@@ -2,11 +2,12 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
-	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/gorilla/websocket"
 	"github.com/olindenbaum/mcgonalds/internal/utils"
 )
 
@@ -15,36 +16,68 @@ type contextKey string
 const (
 	ContextUserID   contextKey = "userID"
 	ContextUsername contextKey = "username"
+	// ContextSessionID holds the session ID from the token's jti claim
+	ContextSessionID contextKey = "sessionID"
 )
 
-// AuthMiddleware validates JWT tokens and adds user info to the request context
-func AuthMiddleware(jwtConfig *config.JWTConfig) mux.MiddlewareFunc {
+// WebSocketTokenProtocol is the subprotocol that marks the next offered
+// subprotocol as the token, for browsers that cannot set headers on a
+// WebSocket upgrade: new WebSocket(url, ["bearer", token]). Upgraders must
+// accept it, or the browser drops the connection.
+const WebSocketTokenProtocol = "bearer"
+
+// requestToken returns the bearer token of r. WebSocket upgrades may pass it
+// as subprotocol or as token query parameter instead of the header.
+func requestToken(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" && websocket.IsWebSocketUpgrade(r) {
+		protocols := websocket.Subprotocols(r)
+		for i, protocol := range protocols {
+			if protocol == WebSocketTokenProtocol && i+1 < len(protocols) {
+				return protocols[i+1], nil
+			}
+		}
+		if token := r.URL.Query().Get("token"); token != "" {
+			return token, nil
+		}
+	}
+	if authHeader == "" {
+		return "", errors.New("Missing Authorization header")
+	}
+
+	// Expect the header to be in the format "Bearer <token>"
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return "", errors.New("Invalid Authorization header format")
+	}
+	return parts[1], nil
+}
+
+// AuthMiddleware validates JWT tokens and adds user info to the request
+// context. checkSession rejects tokens whose session was revoked.
+func AuthMiddleware(issuer *utils.JWTIssuer, checkSession func(userID uint, sessionID string) error) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get the Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+			tokenStr, err := requestToken(r)
+			if err != nil {
+				utils.WriteError(w, err.Error(), http.StatusUnauthorized)
 				return
 			}
 
-			// Expect the header to be in the format "Bearer <token>"
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+			claims, err := issuer.ValidateJWT(tokenStr)
+			if err != nil {
+				utils.WriteError(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
 				return
 			}
-
-			tokenStr := parts[1]
-			claims, err := utils.ValidateJWT(tokenStr)
-			if err != nil {
-				http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+			if err := checkSession(claims.UserID, claims.ID); err != nil {
+				utils.WriteError(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
 				return
 			}
 
 			// Add user info to context
 			ctx := context.WithValue(r.Context(), ContextUserID, claims.UserID)
 			ctx = context.WithValue(ctx, ContextUsername, claims.Username)
+			ctx = context.WithValue(ctx, ContextSessionID, claims.ID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+func TestAuthMiddlewareTokenSources(t *testing.T) {
+	issuer, err := utils.NewJWTIssuer(&config.JWTConfig{Secret: "secret", Audience: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.GenerateJWT(7, "steve", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := AuthMiddleware(issuer, func(uint, string) error { return nil })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ContextUserID) != uint(7) {
+			t.Error("user ID missing from context")
+		}
+	}))
+
+	upgrade := func(target string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Header.Set("Connection", "Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		return r
+	}
+	header := httptest.NewRequest(http.MethodGet, "/servers", nil)
+	header.Header.Set("Authorization", "Bearer "+token)
+	protocol := upgrade("/servers/1/output/ws")
+	protocol.Header.Set("Sec-WebSocket-Protocol", WebSocketTokenProtocol+", "+token)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"header", header, http.StatusOK},
+		{"subprotocol", protocol, http.StatusOK},
+		{"query", upgrade("/servers/1/output/ws?token=" + token), http.StatusOK},
+		{"query without upgrade", httptest.NewRequest(http.MethodGet, "/servers?token="+token, nil), http.StatusUnauthorized},
+		{"invalid query token", upgrade("/servers/1/output/ws?token=garbage"), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, tt.req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func listing(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"files": strings.Repeat("paper.jar ", 200)})
+}
+
+func TestGzipCompressesJSON(t *testing.T) {
+	handler := Gzip(http.HandlerFunc(listing))
+
+	req := httptest.NewRequest(http.MethodGet, "/jar-files", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("response was not compressed: %v", rec.Header())
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(reader)
+	if !strings.Contains(string(body), "paper.jar") {
+		t.Fatalf("decompressed body is %q", body)
+	}
+
+	// Clients without gzip get the plain body
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jar-files", nil))
+	if rec.Header().Get("Content-Encoding") != "" || !strings.Contains(rec.Body.String(), "paper.jar") {
+		t.Fatalf("uncompressed response has encoding %q", rec.Header().Get("Content-Encoding"))
+	}
+
+	// Binary downloads are left alone
+	download := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(make([]byte, 4096))
+	}))
+	rec = httptest.NewRecorder()
+	download.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 4096 {
+		t.Fatalf("download was compressed again")
+	}
+}
+
+func TestETagNotModified(t *testing.T) {
+	handler := ETag(http.HandlerFunc(listing))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jar-files", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first request returned %d with ETag %q", rec.Code, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/jar-files", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("matching request returned %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+
+	req.Header.Set("If-None-Match", `W/"stale"`)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Fatalf("stale request returned %d", rec.Code)
+	}
+}
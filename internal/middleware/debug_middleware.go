@@ -1,32 +1,28 @@
 package middleware
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
 
-	"github.com/fatih/color"
 	"github.com/gorilla/mux"
 )
 
+// DebugMiddleware logs every request with the route it matched at debug
+// level. It must be installed after RequestID so records carry the ID.
 func DebugMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Use colors for different parts of the log
-		methodColor := color.New(color.FgCyan).SprintFunc()
-		pathColor := color.New(color.FgGreen).SprintFunc()
-		routeColor := color.New(color.FgYellow).SprintFunc()
+		var routePath string
+		if route := mux.CurrentRoute(r); route != nil {
+			routePath, _ = route.GetPathTemplate()
+		}
 
-		// Get the route being processed
-		route := mux.CurrentRoute(r)
-		routePath, _ := route.GetPathTemplate()
-
-		// Print the request method, path, and route
-		fmt.Printf("Processing %s request for %s (Route: %s)\n",
-			methodColor(r.Method),
-			pathColor(r.URL.Path),
-			routeColor(routePath),
+		slog.DebugContext(r.Context(), "processing request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routePath,
+			"remote_ip", ClientIP(r),
 		)
 
-		// Call the next handler
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a request without repeating
+	// its effect
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader marks a response that was replayed
+	IdempotentReplayHeader = "Idempotent-Replayed"
+	// maxIdempotencyKey bounds keys accepted from clients
+	maxIdempotencyKey = 255
+)
+
+// IdempotencyStore keeps the responses to requests made with an
+// Idempotency-Key
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(userID uint, key, request string) (*model.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(record *model.IdempotencyKey, status int, contentType string, response []byte)
+	ReleaseIdempotencyKey(record *model.IdempotencyKey)
+}
+
+// Idempotency replays the stored response when a request is repeated with
+// the same Idempotency-Key header, instead of handling it again. Requests
+// without the header are handled as usual. Responses with a server error
+// are not kept, so the request can be retried. It must be installed after
+// AuthMiddleware, as keys are scoped to the user.
+func Idempotency(store IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			userID, ok := r.Context().Value(ContextUserID).(uint)
+			if key == "" || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				utils.WriteError(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			request := r.Method + " " + r.URL.Path
+			record, created, err := store.ReserveIdempotencyKey(userID, key, request)
+			if err != nil {
+				utils.WriteError(w, "Failed to check Idempotency-Key", http.StatusInternalServerError)
+				return
+			}
+			if !created {
+				switch {
+				case record.Request != request:
+					utils.WriteError(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+				case !record.Completed:
+					utils.WriteError(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+				default:
+					if record.ContentType != "" {
+						w.Header().Set("Content-Type", record.ContentType)
+					}
+					w.Header().Set(IdempotentReplayHeader, "true")
+					w.WriteHeader(record.StatusCode)
+					w.Write(record.Response)
+				}
+				return
+			}
+
+			// A handler that panics leaves the key released as well
+			recorder := &teeResponseWriter{ResponseWriter: w, status: http.StatusInternalServerError}
+			defer func() {
+				if recorder.status >= http.StatusInternalServerError {
+					store.ReleaseIdempotencyKey(record)
+					return
+				}
+				store.CompleteIdempotencyKey(record, recorder.status, w.Header().Get("Content-Type"), recorder.body.Bytes())
+			}()
+			next.ServeHTTP(recorder, r)
+			if !recorder.wroteHeader {
+				recorder.status = http.StatusOK
+			}
+		})
+	}
+}
+
+// teeResponseWriter passes a response through while keeping a copy
+type teeResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (t *teeResponseWriter) WriteHeader(status int) {
+	if !t.wroteHeader {
+		t.status = status
+		t.wroteHeader = true
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *teeResponseWriter) Write(data []byte) (int, error) {
+	if !t.wroteHeader {
+		t.status = http.StatusOK
+		t.wroteHeader = true
+	}
+	t.body.Write(data)
+	return t.ResponseWriter.Write(data)
+}
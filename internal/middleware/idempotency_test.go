@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// memoryIdempotencyStore keeps keys in a map
+type memoryIdempotencyStore map[string]*model.IdempotencyKey
+
+func (m memoryIdempotencyStore) ReserveIdempotencyKey(userID uint, key, request string) (*model.IdempotencyKey, bool, error) {
+	if record, exists := m[key]; exists {
+		return record, false, nil
+	}
+	m[key] = &model.IdempotencyKey{UserID: userID, Key: key, Request: request}
+	return m[key], true, nil
+}
+
+func (m memoryIdempotencyStore) CompleteIdempotencyKey(record *model.IdempotencyKey, status int, contentType string, response []byte) {
+	record.Completed, record.StatusCode, record.ContentType, record.Response = true, status, contentType, response
+}
+
+func (m memoryIdempotencyStore) ReleaseIdempotencyKey(record *model.IdempotencyKey) {
+	delete(m, record.Key)
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	handler := Idempotency(memoryIdempotencyStore{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":1}`))
+	}))
+	send := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(IdempotencyKeyHeader, key)
+		req = req.WithContext(context.WithValue(req.Context(), ContextUserID, uint(1)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	send(http.MethodPost, "/servers", "a")
+	rec := send(http.MethodPost, "/servers", "a")
+	if calls != 1 || rec.Code != http.StatusCreated || rec.Body.String() != `{"id":1}` || rec.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Fatalf("retry was handled %d times and returned %d %q", calls, rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPost, "/jar-files", "a"); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reusing a key for another request returned %d", rec.Code)
+	}
+
+	// Server errors are not kept, so the retry runs again
+	status = http.StatusInternalServerError
+	send(http.MethodPost, "/servers", "b")
+	status = http.StatusCreated
+	if rec := send(http.MethodPost, "/servers", "b"); calls != 3 || rec.Code != http.StatusCreated {
+		t.Fatalf("retry after a server error returned %d after %d calls", rec.Code, calls)
+	}
+}
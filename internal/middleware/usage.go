@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UsageCounter counts authenticated API calls per user per UTC day
+type UsageCounter struct {
+	mutex  sync.Mutex
+	day    string
+	counts map[uint]int64
+}
+
+// NewUsageCounter creates an empty API call counter
+func NewUsageCounter() *UsageCounter {
+	return &UsageCounter{counts: make(map[uint]int64)}
+}
+
+// Middleware counts every request made by an authenticated user. It must be
+// installed after AuthMiddleware so the user ID is in the context.
+func (c *UsageCounter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID, ok := r.Context().Value(ContextUserID).(uint); ok {
+			c.increment(userID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Count returns the number of calls userID made today
+func (c *UsageCounter) Count(userID uint) int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rollover()
+	return c.counts[userID]
+}
+
+func (c *UsageCounter) increment(userID uint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rollover()
+	c.counts[userID]++
+}
+
+// rollover resets the counters when the UTC day changes. The caller must hold the mutex.
+func (c *UsageCounter) rollover() {
+	today := time.Now().UTC().Format("2006-01-02")
+	if c.day != today {
+		c.day = today
+		c.counts = make(map[uint]int64)
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/logging"
+)
+
+// ResponseMeta describes an enveloped response
+type ResponseMeta struct {
+	RequestID  string `json:"request_id,omitempty"`
+	APIVersion string `json:"api_version"`
+}
+
+// Envelope is the shape of every JSON response of the v2 API: the handler's
+// response in data, or its error in error, along with meta
+type Envelope struct {
+	Data  json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+	Error json.RawMessage `json:"error,omitempty" swaggertype:"object"`
+	Meta  ResponseMeta    `json:"meta"`
+}
+
+// Enveloped wraps JSON responses in an Envelope tagged with apiVersion.
+// Downloads, empty responses and WebSocket upgrades are passed through. It
+// must be installed after RequestID so meta carries the ID.
+func Enveloped(apiVersion string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &envelopeWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(ew, r)
+			if !ew.buffering {
+				if ew.wroteHeader && !ew.passthrough {
+					w.WriteHeader(ew.status)
+				}
+				return
+			}
+
+			body := bytes.TrimSpace(ew.body.Bytes())
+			if !json.Valid(body) {
+				w.WriteHeader(ew.status)
+				w.Write(ew.body.Bytes())
+				return
+			}
+			envelope := Envelope{Meta: ResponseMeta{
+				RequestID:  logging.RequestID(r.Context()),
+				APIVersion: apiVersion,
+			}}
+			if ew.status >= http.StatusBadRequest {
+				envelope.Error = body
+			} else {
+				envelope.Data = body
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Del("Content-Length")
+			w.WriteHeader(ew.status)
+			json.NewEncoder(w).Encode(envelope)
+		})
+	}
+}
+
+// envelopeWriter buffers responses that may be JSON and passes the rest
+// through untouched
+type envelopeWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	// buffering is set once the response is known to be a JSON candidate
+	buffering bool
+	// passthrough is set once the response is known not to be
+	passthrough bool
+	body        bytes.Buffer
+}
+
+func (e *envelopeWriter) WriteHeader(status int) {
+	if !e.wroteHeader {
+		e.status = status
+		e.wroteHeader = true
+	}
+}
+
+func (e *envelopeWriter) Write(data []byte) (int, error) {
+	if !e.buffering && !e.passthrough {
+		// Most handlers leave the content type to be sniffed, which makes
+		// JSON text/plain
+		contentType := e.Header().Get("Content-Type")
+		if contentType == "" || strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "text/plain") {
+			e.buffering = true
+		} else {
+			e.passthrough = true
+			e.ResponseWriter.WriteHeader(e.status)
+		}
+	}
+	if e.passthrough {
+		return e.ResponseWriter.Write(data)
+	}
+	return e.body.Write(data)
+}
+
+// Deprecated marks responses of a deprecated API version, pointing clients
+// at the same path below successor
+func Deprecated(prefix, successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			if path, found := strings.CutPrefix(r.URL.Path, prefix); found {
+				w.Header().Set("Link", "<"+successor+path+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/logging"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+func TestEnvelopedWrapsJSON(t *testing.T) {
+	handler := Enveloped("v2")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/servers":
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode([]map[string]int{{"id": 1}})
+		case "/missing":
+			utils.WriteError(w, "Server not found", http.StatusNotFound)
+		case "/icon":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG"))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	send := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(logging.WithRequestID(req.Context(), "abc"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var envelope struct {
+		Data  []map[string]int `json:"data"`
+		Error map[string]any   `json:"error"`
+		Meta  ResponseMeta     `json:"meta"`
+	}
+	rec := send("/servers")
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if len(envelope.Data) != 1 || envelope.Data[0]["id"] != 1 || envelope.Meta.RequestID != "abc" || envelope.Meta.APIVersion != "v2" {
+		t.Fatalf("unexpected envelope %s", rec.Body.String())
+	}
+
+	rec = send("/missing")
+	envelope.Error = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotFound || envelope.Error["error"] != "Server not found" {
+		t.Fatalf("unexpected error envelope %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := send("/icon"); rec.Body.String() != "\x89PNG" {
+		t.Fatalf("download was enveloped: %q", rec.Body.String())
+	}
+	if rec := send("/start"); rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Fatalf("empty response became %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDeprecatedPointsAtSuccessor(t *testing.T) {
+	handler := Deprecated("/api/v1", "/api/v2")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/servers/3", nil))
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Link") != `</api/v2/servers/3>; rel="successor-version"` {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+}
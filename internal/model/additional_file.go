@@ -1,8 +1,22 @@
 package model
 
+// Types of additional files, which decide where in a server directory a file
+// is placed
+const (
+	// AdditionalFileConfig files are copied into the server root
+	AdditionalFileConfig = "config"
+	// AdditionalFilePlugin files are linked into plugins/
+	AdditionalFilePlugin = "plugin"
+	// AdditionalFileMod files are linked into mods/
+	AdditionalFileMod = "mod"
+	// AdditionalFileDatapack files are linked into the datapacks directory of the world
+	AdditionalFileDatapack = "datapack"
+)
+
 type AdditionalFile struct {
 	SwaggerGormModel
-	Name string `gorm:"not null"`
-	Type string `gorm:"not null"` // e.g., "modpack", "config", etc.
-	Path string `gorm:"not null"`
+	Name string `gorm:"not null" json:"name"`
+	Type string `gorm:"not null" json:"type" example:"plugin"` // One of the AdditionalFile type constants
+	// Path is the stored file, relative to the additional_files directory of the shared dir unless absolute
+	Path string `gorm:"not null" json:"path"`
 }
@@ -0,0 +1,20 @@
+package model
+
+// Addons the manager installs and keeps track of
+const (
+	AddonGeyser    = "geyser"
+	AddonFloodgate = "floodgate"
+)
+
+// Addon is a plugin the manager installed into a server. The manager knows
+// its file, so it can be updated or removed again.
+type Addon struct {
+	SwaggerGormModel
+	ServerID uint   `gorm:"not null;uniqueIndex:idx_addons_server_name" json:"server_id"`
+	Name     string `gorm:"not null;uniqueIndex:idx_addons_server_name" json:"name" example:"geyser"`
+	// Platform is the build installed, e.g. spigot or velocity
+	Platform string `gorm:"not null" json:"platform" example:"spigot"`
+	Version  string `json:"version" example:"2.4.4-b688"`
+	// Path is the plugin jar relative to the server directory
+	Path string `gorm:"not null" json:"path" example:"plugins/Geyser-Spigot.jar"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// Alert conditions. Thresholds are TPS, CPU percent and disk percent; a
+// server is down when it crashed or an auto-start server is not running.
+const (
+	AlertServerDown = "server_down"
+	AlertTPSBelow   = "tps_below"
+	AlertCPUAbove   = "cpu_above"
+	AlertDiskAbove  = "disk_above"
+)
+
+// AlertConditions lists every alert condition
+var AlertConditions = []string{AlertServerDown, AlertTPSBelow, AlertCPUAbove, AlertDiskAbove}
+
+// Alert channel types
+const (
+	AlertChannelEmail   = "email"
+	AlertChannelDiscord = "discord"
+	AlertChannelWebhook = "webhook"
+)
+
+// Alert states
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// AlertChannel is where the notifications of alert rules are sent: an
+// email address, a Discord webhook URL or any URL receiving JSON
+type AlertChannel struct {
+	SwaggerGormModel
+	UserID uint   `gorm:"not null;index" json:"-"`
+	Name   string `gorm:"not null" json:"name"`
+	Type   string `gorm:"not null" json:"type"`
+	Target string `gorm:"not null" json:"target"`
+}
+
+// AlertRule fires when its condition holds on a server for ForSeconds. It
+// watches one server, or all servers of its owner when ServerID is nil.
+type AlertRule struct {
+	SwaggerGormModel
+	UserID     uint    `gorm:"not null;index" json:"-"`
+	ServerID   *uint   `json:"server_id,omitempty"`
+	Name       string  `gorm:"not null" json:"name"`
+	Condition  string  `gorm:"not null" json:"condition"`
+	Threshold  float64 `json:"threshold"`
+	ForSeconds int     `gorm:"not null;default:0" json:"for_seconds"`
+	// Channels is a comma-separated list of alert channel IDs
+	Channels string `json:"channels"`
+	Enabled  bool   `gorm:"not null;default:true" json:"enabled"`
+}
+
+// Alert is one time a rule fired on a server. It stays firing until the
+// condition clears.
+type Alert struct {
+	SwaggerGormModel
+	RuleID     uint       `gorm:"not null;index" json:"rule_id"`
+	ServerID   uint       `gorm:"not null;index" json:"server_id"`
+	UserID     uint       `gorm:"not null;index" json:"-"`
+	State      string     `gorm:"not null" json:"state"`
+	Value      float64    `json:"value"`
+	Message    string     `json:"message"`
+	FiredAt    time.Time  `json:"fired_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Announcement formats: say prefixes the message with the server name,
+// tellraw shows plain text or a JSON text component as is
+const (
+	AnnouncementSay     = "say"
+	AnnouncementTellraw = "tellraw"
+)
+
+// Announcement is a message broadcast to the players of a running server
+// on a cron schedule or every IntervalSeconds
+type Announcement struct {
+	SwaggerGormModel
+	ServerID        uint       `gorm:"not null;index" json:"server_id"`
+	Message         string     `gorm:"type:text;not null" json:"message"`
+	Format          string     `gorm:"not null;default:say" json:"format"`
+	Cron            string     `json:"cron,omitempty"`
+	IntervalSeconds int        `json:"interval_seconds,omitempty"`
+	Enabled         bool       `gorm:"not null" json:"enabled"`
+	LastSentAt      *time.Time `json:"last_sent_at,omitempty"`
+}
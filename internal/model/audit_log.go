@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// AuditLog records one management action: who made which request, from
+// where, and what came of it
+type AuditLog struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	ServerID   *uint     `gorm:"index" json:"server_id,omitempty"`
+	Action     string    `gorm:"not null" json:"action" example:"POST /servers/{id}/start"`
+	Path       string    `gorm:"not null" json:"path"`
+	IP         string    `json:"ip"`
+	StatusCode int       `json:"status_code"`
+	// Summary is the request body, truncated and with secrets redacted
+	Summary string `gorm:"type:text" json:"summary,omitempty"`
+}
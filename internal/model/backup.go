@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Backup states
+const (
+	BackupStatusRunning   = "running"
+	BackupStatusCompleted = "completed"
+	BackupStatusFailed    = "failed"
+	BackupStatusRestoring = "restoring"
+)
+
+// Backup modes. Full backups are tar.gz archives; incremental backups are
+// directory snapshots that hard-link files unchanged since the previous one.
+const (
+	BackupModeFull        = "full"
+	BackupModeIncremental = "incremental"
+)
+
+// BackupTargetLocal marks backups stored on the local disk. Remote backups
+// carry the name of their storage backend instead.
+const BackupTargetLocal = "local"
+
+type Backup struct {
+	SwaggerGormModel
+	ServerID    uint       `gorm:"not null;index" json:"server_id"`
+	UserID      uint       `gorm:"not null" json:"user_id"`
+	Name        string     `gorm:"not null" json:"name"`
+	Path        string     `gorm:"not null" json:"-"`
+	Target      string     `gorm:"not null;default:local" json:"target"`
+	Mode        string     `gorm:"not null;default:full" json:"mode"`
+	BaseID      *uint      `json:"base_id,omitempty"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Status      string     `gorm:"not null" json:"status"`
+	Scheduled   bool       `gorm:"not null;default:false" json:"scheduled"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// BackupSchedule runs backups of a server on a cron schedule and prunes the
+// scheduled backups that fall outside its retention rules
+type BackupSchedule struct {
+	SwaggerGormModel
+	ServerID      uint       `gorm:"not null;uniqueIndex" json:"server_id"`
+	UserID        uint       `gorm:"not null" json:"user_id"`
+	Cron          string     `gorm:"not null" json:"cron"`
+	KeepLast      int        `json:"keep_last"`
+	KeepDailyDays int        `json:"keep_daily_days"`
+	Incremental   bool       `gorm:"not null;default:false" json:"incremental"`
+	Enabled       bool       `gorm:"not null" json:"enabled"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
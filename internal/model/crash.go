@@ -0,0 +1,25 @@
+package model
+
+import "github.com/olindenbaum/mcgonalds/internal/crash"
+
+// Crash records a run of a server that exited with an error, with its
+// output and the crash reports it wrote
+type Crash struct {
+	SwaggerGormModel
+	ServerID    uint            `gorm:"not null;index" json:"server_id"`
+	ExitError   string          `json:"exit_error"`
+	Findings    []crash.Finding `gorm:"type:text;serializer:json" json:"findings"`
+	Stderr      string          `gorm:"type:text" json:"stderr,omitempty"`
+	ConsoleTail string          `gorm:"type:text" json:"console_tail,omitempty"`
+	Reports     []CrashReport   `json:"reports"`
+}
+
+// CrashReport is a file the server wrote to crash-reports/ during a
+// crashed run
+type CrashReport struct {
+	ID        uint   `gorm:"primarykey" json:"id"`
+	CrashID   uint   `gorm:"not null;index" json:"-"`
+	Name      string `gorm:"not null" json:"name"`
+	SizeBytes int64  `json:"size_bytes"`
+	Content   string `gorm:"type:text" json:"-"`
+}
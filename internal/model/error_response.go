@@ -1,8 +1,27 @@
 package model
 
-// ErrorResponse represents a standardized error response for the API
+import "github.com/olindenbaum/mcgonalds/internal/crash"
+
+// ErrorResponse represents a standardized error response for the API. The
+// v2 API returns it in the error field of the response envelope.
 type ErrorResponse struct {
-	Status  int    `json:"status" example:"400"`
+	Status int `json:"status" example:"400"`
+	// Code is a stable, machine-readable identifier of the error
+	Code    string `json:"code" example:"validation_failed"`
 	Message string `json:"message" example:"Bad Request"`
 	Error   string `json:"error,omitempty" example:"Invalid input data"`
+	// Fields lists the invalid request fields of a validation error
+	Fields []FieldError `json:"fields,omitempty"`
+	// Conflict names the operation holding the files of a server when they
+	// are locked: running, backup, restore, jar_swap, world_delete or
+	// mod_pack_upgrade
+	Conflict string `json:"conflict,omitempty" example:"backup"`
+	// Diagnosis lists the likely causes of a server failing to start
+	Diagnosis []crash.Finding `json:"diagnosis,omitempty"`
+}
+
+// FieldError describes why one request field was rejected
+type FieldError struct {
+	Field   string `json:"field" example:"name"`
+	Message string `json:"message" example:"is required"`
 }
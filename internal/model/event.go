@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Event types published by the server manager
+const (
+	EventServerStarted   = "server.started"
+	EventServerStopped   = "server.stopped"
+	EventServerCrashed   = "server.crashed"
+	EventBackupCompleted = "backup.completed"
+	EventBackupFailed    = "backup.failed"
+	EventPlayerJoined    = "player.joined"
+	EventPlayerLeft      = "player.left"
+	// Scaling events are published for the instance that was started or
+	// stopped by the scaling policy of its proxy
+	EventScaledUp   = "autoscale.scaled_up"
+	EventScaledDown = "autoscale.scaled_down"
+)
+
+// EventTypes lists every event type
+var EventTypes = []string{
+	EventServerStarted,
+	EventServerStopped,
+	EventServerCrashed,
+	EventBackupCompleted,
+	EventBackupFailed,
+	EventPlayerJoined,
+	EventPlayerLeft,
+	EventScaledUp,
+	EventScaledDown,
+}
+
+// Event is something that happened to a server
+type Event struct {
+	Type     string            `json:"type"`
+	ServerID uint              `json:"server_id"`
+	UserID   uint              `json:"-"`
+	TeamID   *uint             `json:"-"`
+	Time     time.Time         `json:"time"`
+	Data     map[string]string `json:"data,omitempty"`
+}
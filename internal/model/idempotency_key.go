@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header, so a client retrying it gets the same response
+// instead of a second server or upload
+type IdempotencyKey struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key       string    `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	// Request is the method and path the key was first used for
+	Request string `gorm:"not null"`
+	// Completed is false while the first request is still being handled
+	Completed   bool `gorm:"not null;default:false"`
+	StatusCode  int
+	ContentType string
+	Response    []byte
+}
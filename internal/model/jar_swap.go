@@ -0,0 +1,21 @@
+package model
+
+// Jar swap states
+const (
+	JarSwapPending    = "pending"
+	JarSwapConfirmed  = "confirmed"
+	JarSwapRolledBack = "rolled_back"
+)
+
+// JarSwap tracks a jar replacement until the next start proves it works.
+// Pending swaps outlive manager restarts, so the first start after one is
+// still watched and its snapshot is not left behind.
+type JarSwap struct {
+	SwaggerGormModel
+	ServerID          uint   `gorm:"not null;index" json:"server_id"`
+	PreviousJarFileID uint   `gorm:"not null" json:"previous_jar_file_id"`
+	JarFileID         uint   `gorm:"not null" json:"jar_file_id"`
+	SnapshotPath      string `gorm:"not null" json:"snapshot_path"`
+	Status            string `gorm:"not null" json:"status"`
+	Reason            string `json:"reason,omitempty"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Maintenance modes: stop shuts the server down and answers pings with the
+// message, whitelist keeps it running for whitelisted players and operators
+const (
+	MaintenanceStop      = "stop"
+	MaintenanceWhitelist = "whitelist"
+)
+
+// MaintenanceWindow is a period during which a server is taken down or
+// limited to its whitelist and only admins may start, stop or restart it
+type MaintenanceWindow struct {
+	SwaggerGormModel
+	ServerID uint      `gorm:"not null;index" json:"server_id"`
+	StartsAt time.Time `gorm:"not null" json:"starts_at"`
+	EndsAt   time.Time `gorm:"not null" json:"ends_at"`
+	Mode     string    `gorm:"not null;default:stop" json:"mode"`
+	// Message is the MOTD while the server is down and the reason players
+	// are kicked or turned away with
+	Message string `gorm:"type:text;not null" json:"message"`
+	// BegunAt and EndedAt record when the window was applied and lifted
+	BegunAt *time.Time `json:"begun_at,omitempty"`
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+	// WasRunning, PreviousWhitelist and PreviousEnforceWhitelist hold what
+	// is restored when the window ends
+	WasRunning               bool   `gorm:"not null;default:false" json:"-"`
+	PreviousWhitelist        string `gorm:"not null;default:''" json:"-"`
+	PreviousEnforceWhitelist string `gorm:"not null;default:''" json:"-"`
+	// Active is set while the window applies
+	Active bool `gorm:"-" json:"active"`
+}
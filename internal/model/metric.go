@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Metrics that can be charted from MetricSample rows
+const (
+	MetricCPU     = "cpu"
+	MetricMemory  = "memory"
+	MetricPlayers = "players"
+	MetricTPS     = "tps"
+)
+
+// MetricSample is a periodic measurement of a running server. Recent samples
+// are kept at full resolution; older ones are averaged into hourly samples.
+type MetricSample struct {
+	ID          uint      `gorm:"primarykey" json:"-"`
+	ServerID    uint      `gorm:"not null;index:idx_metric_samples_server_time" json:"server_id"`
+	Time        time.Time `gorm:"not null;index:idx_metric_samples_server_time" json:"time"`
+	Resolution  int       `gorm:"not null" json:"resolution_seconds"`
+	CPUPercent  float64   `gorm:"column:cpu_percent" json:"cpu_percent"`
+	MemoryBytes int64     `json:"memory_bytes"`
+	Players     float64   `json:"players"`
+	TPS         *float64  `gorm:"column:tps" json:"tps,omitempty"`
+}
+
+// MetricPoint is one value of a metric time series
+type MetricPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
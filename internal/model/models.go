@@ -0,0 +1,40 @@
+package model
+
+// All returns one value of every persisted model, for databases whose
+// schema is created from the models rather than the SQL migrations
+func All() []interface{} {
+	return []interface{}{
+		&User{},
+		&UserIdentity{},
+		&Session{},
+		&Team{},
+		&Membership{},
+		&Node{},
+		&JarFile{},
+		&ModPack{},
+		&AdditionalFile{},
+		&Server{},
+		&ServerConfig{},
+		&ServerGrant{},
+		&Addon{},
+		&Template{},
+		&Tag{},
+		&Backup{},
+		&Crash{},
+		&CrashReport{},
+		&BackupSchedule{},
+		&Announcement{},
+		&MaintenanceWindow{},
+		&PregenTask{},
+		&JarSwap{},
+		&ScalingPolicy{},
+		&MetricSample{},
+		&Webhook{},
+		&WebhookDelivery{},
+		&AlertChannel{},
+		&AlertRule{},
+		&Alert{},
+		&AuditLog{},
+		&IdempotencyKey{},
+	}
+}
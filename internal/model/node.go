@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Node is a machine running a node agent. Servers with a NodeID run on
+// that node instead of the control plane.
+type Node struct {
+	SwaggerGormModel
+	Name      string `gorm:"not null;uniqueIndex" json:"name"`
+	TokenHash string `gorm:"not null;uniqueIndex" json:"-"`
+	// Capacity last reported by the agent
+	CPUCores      int   `json:"cpu_cores"`
+	MemoryMB      int64 `json:"memory_mb"`
+	FreeDiskBytes int64 `json:"free_disk_bytes"`
+	// LastSeenAt is when the agent last reported; nodes silent for long are offline
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Online     bool       `gorm:"-" json:"online"`
+	// AllocatedMemoryMB sums the -Xmx of the servers scheduled onto the node
+	AllocatedMemoryMB int64 `gorm:"-" json:"allocated_memory_mb"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Pre-generation task states. Throttled tasks are paused while players are
+// online and continue once the server is empty.
+const (
+	PregenRunning   = "running"
+	PregenPaused    = "paused"
+	PregenThrottled = "throttled"
+	PregenCompleted = "completed"
+	PregenCancelled = "cancelled"
+)
+
+// PregenTask is a Chunky job generating the chunks of a world within
+// Radius blocks of a center ahead of time
+type PregenTask struct {
+	SwaggerGormModel
+	ServerID uint   `gorm:"not null;index" json:"server_id"`
+	World    string `gorm:"not null" json:"world"`
+	CenterX  int    `json:"center_x"`
+	CenterZ  int    `json:"center_z"`
+	Radius   int    `gorm:"not null" json:"radius"`
+	// PauseForPlayers pauses the task while players are online
+	PauseForPlayers bool       `gorm:"not null;default:true" json:"pause_for_players"`
+	State           string     `gorm:"not null" json:"state"`
+	Chunks          int64      `json:"chunks"`
+	Percent         float64    `json:"percent"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
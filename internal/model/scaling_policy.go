@@ -0,0 +1,23 @@
+package model
+
+// ScalingPolicy scales the game servers behind a proxy with their player
+// count. Instances are created from a template next to the proxy and
+// attached to it; stopped instances are started again before new ones are
+// created.
+type ScalingPolicy struct {
+	SwaggerGormModel
+	ProxyID    uint `gorm:"not null;uniqueIndex:idx_scaling_policies_proxy_id,where:deleted_at IS NULL" json:"proxy_id"`
+	TemplateID uint `gorm:"not null" json:"template_id"`
+	// MinInstances are kept running; more than MaxInstances never run
+	MinInstances int `gorm:"not null" json:"min_instances" example:"1"`
+	MaxInstances int `gorm:"not null" json:"max_instances" example:"4"`
+	// ScaleUpPlayers adds an instance once the running ones hold this many
+	// players each on average
+	ScaleUpPlayers int `gorm:"not null" json:"scale_up_players" example:"16"`
+	// IdleMinutes stops an instance above the minimum once it has been
+	// empty this long
+	IdleMinutes int  `gorm:"not null" json:"idle_minutes" example:"10"`
+	Enabled     bool `gorm:"not null" json:"enabled"`
+	// UserID is who set the policy; instances are created on their behalf
+	UserID uint `json:"user_id"`
+}
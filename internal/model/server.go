@@ -1,10 +1,81 @@
 package model
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Server states, kept in sync with the server process
+const (
+	ServerStatusStopped = "stopped"
+	// ServerStatusQueued waits for a free start slot
+	ServerStatusQueued = "queued"
+	// ServerStatusStarting has a process that has not finished loading
+	ServerStatusStarting = "starting"
+	ServerStatusRunning  = "running"
+	ServerStatusCrashed  = "crashed"
+)
+
+// Proxy types; a server with a proxy type runs a proxy in front of the
+// servers attached to it
+const (
+	ProxyTypeVelocity   = "velocity"
+	ProxyTypeBungeeCord = "bungeecord"
+)
+
+// Server spells out the gorm.Model fields instead of embedding
+// SwaggerGormModel so that DeletedAt is a gorm.DeletedAt: deleting a server
+// only marks it deleted and it stays restorable until it is purged.
 type Server struct {
-	SwaggerGormModel
-	Name   string `gorm:"not null" json:"name"`
-	Path   string `gorm:"not null" json:"path"`
-	Status string `json:"status"`
-	UserID uint   `json:"user_id"`
-	User   User   `json:"-"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string" format:"date-time"`
+	Name      string         `gorm:"not null" json:"name"`
+	Path      string         `gorm:"not null" json:"path"`
+	Status    string         `json:"status"`
+	UserID    uint           `json:"user_id"`
+	User      User           `json:"-"`
+	// TeamID shares the server with the members of a team
+	TeamID *uint `gorm:"index" json:"team_id,omitempty"`
+	// NodeID is the node agent the server runs on, nil for the control plane
+	NodeID *uint `gorm:"index" json:"node_id,omitempty"`
+	Tags   []Tag `gorm:"many2many:server_tags;" json:"tags"`
+	// AdditionalFiles are placed into the server directory by their type
+	AdditionalFiles []AdditionalFile `gorm:"many2many:server_additional_files" json:"additional_files,omitempty"`
+	// Config is only loaded, with its jar file and mod pack, by server lists
+	Config *ServerConfig `gorm:"foreignKey:ServerID" json:"config,omitempty"`
+	// Description is shown on server cards; Notes are free-form operator notes
+	Description string `gorm:"type:text" json:"description"`
+	Notes       string `gorm:"type:text" json:"notes"`
+	// Timezone is the IANA zone the game server logs in; console timestamps are normalized to UTC from it
+	Timezone string `json:"timezone"`
+	// OfflineModeAcknowledged must be set before a server with online-mode=false may start
+	OfflineModeAcknowledged bool `gorm:"not null;default:false" json:"offline_mode_acknowledged"`
+	// AutoStart servers are started when the manager starts
+	AutoStart bool `gorm:"not null;default:false" json:"auto_start"`
+	// ProxyType is set on servers that run a proxy
+	ProxyType string `gorm:"not null;default:''" json:"proxy_type,omitempty"`
+	// ForwardingSecret authenticates the proxy to its backends
+	ForwardingSecret string `json:"-"`
+	// ProxyID is the proxy a backend server is attached to; ProxyAddress is
+	// the host:port the proxy reaches it at
+	ProxyID      *uint  `gorm:"index" json:"proxy_id,omitempty"`
+	ProxyAddress string `json:"proxy_address,omitempty"`
+	// Autoscaled backends were created by the scaling policy of their proxy,
+	// which starts and stops them
+	Autoscaled bool `gorm:"not null;default:false" json:"autoscaled,omitempty"`
+	// DNSName is the host name registered for the server, e.g. smp.example.com
+	DNSName string `json:"dns_name,omitempty"`
+	// PublicStatusToken opts the server into the unauthenticated status
+	// endpoint, which it identifies the server in
+	PublicStatusToken *string `gorm:"uniqueIndex" json:"public_status_token,omitempty"`
+	// ArchivedAt is set while the server directory is packed away in cold
+	// storage. The archive lives in ArchiveTarget (local or the backup
+	// storage) at ArchivePath.
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	ArchiveTarget    string     `json:"archive_target,omitempty"`
+	ArchivePath      string     `json:"-"`
+	ArchiveSizeBytes int64      `json:"archive_size_bytes,omitempty"`
 }
@@ -1,5 +1,12 @@
 package model
 
+// Restart policies of a server. On-failure servers are started again after
+// a crash, up to their restart limit.
+const (
+	RestartPolicyNever     = "never"
+	RestartPolicyOnFailure = "on-failure"
+)
+
 type ServerConfig struct {
 	SwaggerGormModel
 	ServerID          uint     `gorm:"uniqueIndex;not null" json:"server_id"`
@@ -8,4 +15,19 @@ type ServerConfig struct {
 	ModPackID         *uint    `json:"mod_pack_id"`
 	ModPack           *ModPack `gorm:"foreignKey:ModPackID" json:"mod_pack,omitempty"`
 	ExecutableCommand string   `gorm:"not null" json:"executable_command"`
+	// Env holds environment variables set for the server process
+	Env           map[string]string `gorm:"type:text;serializer:json" json:"env"`
+	RestartPolicy string            `gorm:"not null;default:never" json:"restart_policy"`
+	// MaxRestarts caps the restarts after crashes within the restart window
+	MaxRestarts int `gorm:"not null;default:3" json:"max_restarts"`
+	// Placeholder answers server list pings on the port of the stopped
+	// server with PlaceholderMOTD
+	Placeholder     bool   `gorm:"not null;default:false" json:"placeholder"`
+	PlaceholderMOTD string `json:"placeholder_motd"`
+	// StartOnJoin starts the server when a player tries to join its
+	// placeholder
+	StartOnJoin bool `gorm:"not null;default:false" json:"start_on_join"`
+	// IdleShutdownMinutes stops the running server after that long without
+	// players; zero never does
+	IdleShutdownMinutes int `gorm:"not null;default:0" json:"idle_shutdown_minutes"`
 }
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"slices"
+	"strings"
+)
+
+// Server permissions. A grant holds any of console, files, power and
+// backups; view comes with every grant, and manage is reserved for the
+// server's owner and the members of its team.
+const (
+	PermissionView    = "view"
+	PermissionConsole = "console"
+	PermissionFiles   = "files"
+	PermissionPower   = "power"
+	PermissionBackups = "backups"
+	PermissionManage  = "manage"
+)
+
+// GrantablePermissions are the permissions a grant can hold
+var GrantablePermissions = []string{PermissionConsole, PermissionFiles, PermissionPower, PermissionBackups}
+
+// ServerGrant gives a user, who neither owns the server nor shares it
+// through a team, a limited set of permissions on one server
+type ServerGrant struct {
+	SwaggerGormModel
+	ServerID uint   `gorm:"not null;uniqueIndex:idx_server_grants_server_user" json:"server_id"`
+	UserID   uint   `gorm:"not null;uniqueIndex:idx_server_grants_server_user" json:"user_id"`
+	Username string `gorm:"-" json:"username,omitempty"`
+	// Permissions is a comma-separated list of granted permissions
+	Permissions string `gorm:"not null" json:"permissions" example:"console,power"`
+	GrantedBy   uint   `json:"granted_by"`
+}
+
+// Has reports whether the grant includes a permission
+func (g *ServerGrant) Has(permission string) bool {
+	return permission == PermissionView || slices.Contains(strings.Split(g.Permissions, ","), permission)
+}
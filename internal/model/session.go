@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Session is one login of a user. Its token carries TokenID, and deleting
+// the session revokes the token.
+type Session struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UserID     uint      `gorm:"not null;index" json:"-"`
+	TokenID    string    `gorm:"not null;uniqueIndex" json:"-"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `gorm:"index" json:"expires_at"`
+	// Current marks the session of the token the request was made with
+	Current bool `gorm:"-" json:"current"`
+}
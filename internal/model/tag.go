@@ -0,0 +1,9 @@
+package model
+
+// Tag labels servers so users with many servers can group and filter them.
+// Tag names are unique per user.
+type Tag struct {
+	SwaggerGormModel
+	UserID uint   `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"-"`
+	Name   string `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"name"`
+}
@@ -0,0 +1,42 @@
+package model
+
+// Team roles. Owners and managers invite members and assign roles; only
+// owners can make someone an owner.
+const (
+	TeamRoleOwner   = "owner"
+	TeamRoleManager = "manager"
+	TeamRoleMember  = "member"
+)
+
+// teamRoleRanks orders the team roles by privilege
+var teamRoleRanks = map[string]int{TeamRoleMember: 1, TeamRoleManager: 2, TeamRoleOwner: 3}
+
+// ValidTeamRole reports whether role is a known team role
+func ValidTeamRole(role string) bool {
+	return teamRoleRanks[role] > 0
+}
+
+// TeamRoleAtLeast reports whether a team role grants everything min does
+func TeamRoleAtLeast(role, min string) bool {
+	return teamRoleRanks[role] >= teamRoleRanks[min]
+}
+
+// Team owns servers jointly: every accepted member can use the servers
+// assigned to it.
+type Team struct {
+	SwaggerGormModel
+	Name string `gorm:"not null;unique" json:"name"`
+}
+
+// Membership links a user to a team. Invitations are memberships that have
+// not been accepted yet.
+type Membership struct {
+	SwaggerGormModel
+	TeamID    uint   `gorm:"not null;uniqueIndex:idx_memberships_team_user" json:"team_id"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_memberships_team_user" json:"user_id"`
+	Username  string `gorm:"-" json:"username,omitempty"`
+	Role      string `gorm:"not null;default:member" json:"role"`
+	Accepted  bool   `gorm:"not null;default:false" json:"accepted"`
+	InvitedBy uint   `json:"invited_by"`
+	Team      *Team  `json:"team,omitempty"`
+}
@@ -0,0 +1,36 @@
+package model
+
+// Template is a reusable blueprint for creating servers
+type Template struct {
+	SwaggerGormModel
+	// Name is unique among templates that are not deleted
+	Name              string             `gorm:"not null;uniqueIndex:idx_templates_name,where:deleted_at IS NULL" json:"name"`
+	Description       string             `json:"description"`
+	JarFileID         uint               `gorm:"not null" json:"jar_file_id"`
+	JarFile           JarFile            `gorm:"foreignKey:JarFileID" json:"jar_file"`
+	ModPackID         *uint              `json:"mod_pack_id"`
+	ModPack           *ModPack           `gorm:"foreignKey:ModPackID" json:"mod_pack,omitempty"`
+	ExecutableCommand string             `json:"executable_command"`
+	MemoryMB          int                `json:"memory_mb"`
+	Properties        string             `gorm:"type:text" json:"properties"` // Contents of server.properties, with {{variable}} placeholders
+	Variables         []TemplateVariable `gorm:"type:text;serializer:json" json:"variables"`
+	AdditionalFiles   []AdditionalFile   `gorm:"many2many:template_additional_files" json:"additional_files,omitempty"`
+	UserID            uint               `json:"user_id"`
+}
+
+// Types of template variables
+const (
+	VariableString = "string"
+	VariableInt    = "int"
+	VariableBool   = "bool"
+)
+
+// TemplateVariable is a placeholder a template's server.properties can use
+// besides the built-in ones, filled in when a server is created from it
+type TemplateVariable struct {
+	Name        string `json:"name" example:"view_distance"`
+	Type        string `json:"type" example:"int"`
+	Description string `json:"description,omitempty"`
+	// Default is used when no value is given; a variable without a default is required
+	Default *string `json:"default,omitempty" example:"10"`
+}
@@ -0,0 +1,30 @@
+package model
+
+// UsageMeter reports consumption of a resource against its limit. A limit of
+// zero means the resource is unlimited.
+type UsageMeter struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+	// Remaining is what is left of the limit; it is omitted when unlimited
+	Remaining *int64 `json:"remaining,omitempty"`
+}
+
+// SetRemaining fills in the remaining amount of a limited meter
+func (m *UsageMeter) SetRemaining() {
+	if m.Limit <= 0 {
+		m.Remaining = nil
+		return
+	}
+	remaining := max(m.Limit-m.Used, 0)
+	m.Remaining = &remaining
+}
+
+// Usage summarizes what a user consumes, for rendering dashboard meters
+type Usage struct {
+	Servers     UsageMeter `json:"servers"`
+	MemoryMB    UsageMeter `json:"memory_mb"`
+	DiskBytes   UsageMeter `json:"disk_bytes"`
+	Backups     UsageMeter `json:"backups"`
+	BackupBytes int64      `json:"backup_bytes"`
+	APICalls    UsageMeter `json:"api_calls"`
+}
@@ -4,11 +4,41 @@ import (
 	"time"
 )
 
+// User roles, from least to most privileged. Viewers can read server status
+// and console output, operators can also start, stop and send commands, and
+// admins can create and delete servers and manage shared artifacts.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// roleRanks orders the roles by privilege
+var roleRanks = map[string]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAtLeast reports whether role grants everything min does
+func RoleAtLeast(role, min string) bool {
+	return roleRanks[role] >= roleRanks[min]
+}
+
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Username  string    `gorm:"unique;not null" json:"username"`
-	Password  string    `gorm:"not null" json:"-"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Servers   []Server  `json:"servers"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Username string `gorm:"unique;not null" json:"username"`
+	Password string `gorm:"not null" json:"-"`
+	Role     string `gorm:"not null;default:viewer" json:"role"`
+	// Email is used for password resets once it is verified
+	Email         *string `gorm:"uniqueIndex" json:"email,omitempty"`
+	EmailVerified bool    `gorm:"not null;default:false" json:"email_verified"`
+	// Quotas override the configured limits when set; 0 means unlimited
+	MaxServers  *int64    `json:"max_servers,omitempty"`
+	MaxMemoryMB *int64    `json:"max_memory_mb,omitempty"`
+	MaxDiskMB   *int64    `json:"max_disk_mb,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Servers     []Server  `json:"servers"`
 }
@@ -0,0 +1,12 @@
+package model
+
+// UserIdentity links a user to an account at an OAuth provider
+type UserIdentity struct {
+	SwaggerGormModel
+	UserID   uint   `gorm:"not null;index" json:"-"`
+	Provider string `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	// Subject is the provider's stable ID of the account
+	Subject  string `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"-"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+}
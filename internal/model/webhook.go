@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Webhook receives signed JSON payloads of the events of one server, or of
+// all servers of its owner when ServerID is nil
+type Webhook struct {
+	SwaggerGormModel
+	UserID   uint   `gorm:"not null;index" json:"-"`
+	ServerID *uint  `json:"server_id,omitempty"`
+	URL      string `gorm:"not null" json:"url"`
+	// Secret signs payloads; it is only returned when the webhook is created
+	Secret string `gorm:"not null" json:"-"`
+	// Events is a comma-separated list of event types; empty means all
+	Events  string `json:"events"`
+	Enabled bool   `gorm:"not null;default:true" json:"enabled"`
+}
+
+// WebhookDelivery records the attempts to deliver one event to a webhook
+type WebhookDelivery struct {
+	SwaggerGormModel
+	WebhookID   uint       `gorm:"not null;index" json:"webhook_id"`
+	Event       string     `gorm:"not null" json:"event"`
+	Payload     string     `gorm:"type:text" json:"payload"`
+	Attempts    int        `json:"attempts"`
+	StatusCode  int        `json:"status_code,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
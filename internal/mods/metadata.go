@@ -0,0 +1,405 @@
+// Package mods reads the metadata of mod jars and checks a set of mods for
+// missing dependencies, conflicts and mismatched loaders or Minecraft
+// versions.
+package mods
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Mod loaders a mod can be written for
+const (
+	LoaderFabric   = "fabric"
+	LoaderQuilt    = "quilt"
+	LoaderForge    = "forge"
+	LoaderNeoForge = "neoforge"
+)
+
+// maxMetadataSize caps the size of a metadata file or nested jar read from a mod
+const maxMetadataSize = 16 << 20
+
+// Mod is one mod declared by a jar. Jars may declare several, and Fabric
+// jars may bundle further mods as nested jars.
+type Mod struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Loader  string `json:"loader"`
+	// File is the name of the jar in the mods directory
+	File string `json:"file"`
+	// Nested is set for mods bundled inside another mod's jar
+	Nested       bool         `json:"nested,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	// Breaks lists mods this mod does not work with
+	Breaks []Dependency `json:"breaks,omitempty"`
+	// Provides lists further IDs the mod stands in for
+	Provides []string `json:"provides,omitempty"`
+}
+
+// Dependency is a required mod and the versions of it that satisfy the
+// requirement, in the syntax of the loader
+type Dependency struct {
+	ID string `json:"id"`
+	// Versions holds Fabric version predicates, any of which must match, or
+	// a single Maven version range for Forge and NeoForge
+	Versions []string `json:"versions,omitempty"`
+}
+
+// ReadJar returns the mods declared by a mod jar, nil for jars without mod
+// metadata
+func ReadJar(path string) ([]Mod, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return readMods(&reader.Reader, filepath.Base(path), false)
+}
+
+func readMods(reader *zip.Reader, file string, nested bool) ([]Mod, error) {
+	entries := make(map[string]*zip.File, len(reader.File))
+	for _, entry := range reader.File {
+		entries[entry.Name] = entry
+	}
+
+	var mods []Mod
+	if entry, ok := entries["fabric.mod.json"]; ok {
+		data, err := readEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		mod, jars, err := parseFabric(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fabric.mod.json: %w", err)
+		}
+		mod.File, mod.Nested = file, nested
+		mods = append(mods, *mod)
+		for _, jar := range jars {
+			if entry, ok := entries[jar]; ok {
+				mods = append(mods, readNested(entry, file)...)
+			}
+		}
+		return mods, nil
+	}
+	if entry, ok := entries["quilt.mod.json"]; ok {
+		data, err := readEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		mod, err := parseQuilt(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quilt.mod.json: %w", err)
+		}
+		mod.File, mod.Nested = file, nested
+		return append(mods, *mod), nil
+	}
+
+	for _, name := range []string{"META-INF/neoforge.mods.toml", "META-INF/mods.toml"} {
+		entry, ok := entries[name]
+		if !ok {
+			continue
+		}
+		data, err := readEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		loader := LoaderForge
+		if strings.HasPrefix(filepath.Base(name), "neoforge") {
+			loader = LoaderNeoForge
+		}
+		var manifestVersion string
+		if manifest, ok := entries["META-INF/MANIFEST.MF"]; ok {
+			if data, err := readEntry(manifest); err == nil {
+				manifestVersion = manifestAttribute(data, "Implementation-Version")
+			}
+		}
+		for _, mod := range parseModsToml(data, loader) {
+			// Forge fills the version in from the manifest at build time
+			if mod.Version == "${file.jarVersion}" {
+				mod.Version = manifestVersion
+			}
+			mod.File, mod.Nested = file, nested
+			mods = append(mods, mod)
+		}
+		return mods, nil
+	}
+	return nil, nil
+}
+
+// readNested reads the mods of a jar bundled inside a Fabric mod. Nested
+// jars that cannot be read are skipped; the loader reports them itself.
+func readNested(entry *zip.File, file string) []Mod {
+	data, err := readEntry(entry)
+	if err != nil {
+		return nil
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	mods, _ := readMods(reader, file, true)
+	return mods
+}
+
+func readEntry(entry *zip.File) ([]byte, error) {
+	if entry.UncompressedSize64 > maxMetadataSize {
+		return nil, fmt.Errorf("%s is too large", entry.Name)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxMetadataSize))
+}
+
+// fabricMetadata is the part of fabric.mod.json the checks use
+type fabricMetadata struct {
+	ID       string                     `json:"id"`
+	Name     string                     `json:"name"`
+	Version  string                     `json:"version"`
+	Depends  map[string]json.RawMessage `json:"depends"`
+	Breaks   map[string]json.RawMessage `json:"breaks"`
+	Provides []string                   `json:"provides"`
+	Jars     []struct {
+		File string `json:"file"`
+	} `json:"jars"`
+}
+
+// parseFabric reads fabric.mod.json and returns the mod and its nested jars
+func parseFabric(data []byte) (*Mod, []string, error) {
+	var metadata fabricMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, nil, err
+	}
+	if metadata.ID == "" {
+		return nil, nil, fmt.Errorf("no mod id")
+	}
+	mod := &Mod{
+		ID:           metadata.ID,
+		Name:         metadata.Name,
+		Version:      metadata.Version,
+		Loader:       LoaderFabric,
+		Dependencies: fabricDependencies(metadata.Depends),
+		Breaks:       fabricDependencies(metadata.Breaks),
+		Provides:     metadata.Provides,
+	}
+	var jars []string
+	for _, jar := range metadata.Jars {
+		jars = append(jars, jar.File)
+	}
+	return mod, jars, nil
+}
+
+// fabricDependencies reads a depends or breaks object, whose values are a
+// version predicate or a list of them
+func fabricDependencies(raw map[string]json.RawMessage) []Dependency {
+	var dependencies []Dependency
+	for id, value := range raw {
+		dependency := Dependency{ID: id}
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			dependency.Versions = []string{single}
+		} else {
+			json.Unmarshal(value, &dependency.Versions)
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	sortDependencies(dependencies)
+	return dependencies
+}
+
+// quiltMetadata is the part of quilt.mod.json the checks use
+type quiltMetadata struct {
+	Loader struct {
+		ID       string `json:"id"`
+		Version  string `json:"version"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Depends  []json.RawMessage `json:"depends"`
+		Breaks   []json.RawMessage `json:"breaks"`
+		Provides []json.RawMessage `json:"provides"`
+	} `json:"quilt_loader"`
+}
+
+func parseQuilt(data []byte) (*Mod, error) {
+	var metadata quiltMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	if metadata.Loader.ID == "" {
+		return nil, fmt.Errorf("no mod id")
+	}
+	mod := &Mod{
+		ID:           metadata.Loader.ID,
+		Name:         metadata.Loader.Metadata.Name,
+		Version:      metadata.Loader.Version,
+		Loader:       LoaderQuilt,
+		Dependencies: quiltDependencies(metadata.Loader.Depends),
+		Breaks:       quiltDependencies(metadata.Loader.Breaks),
+	}
+	for _, provided := range quiltDependencies(metadata.Loader.Provides) {
+		mod.Provides = append(mod.Provides, provided.ID)
+	}
+	return mod, nil
+}
+
+// quiltDependencies reads a list of mod IDs or dependency objects, leaving
+// out optional ones
+func quiltDependencies(raw []json.RawMessage) []Dependency {
+	var dependencies []Dependency
+	for _, value := range raw {
+		var id string
+		if err := json.Unmarshal(value, &id); err == nil {
+			dependencies = append(dependencies, Dependency{ID: id})
+			continue
+		}
+		var object struct {
+			ID       string          `json:"id"`
+			Versions json.RawMessage `json:"versions"`
+			Optional bool            `json:"optional"`
+		}
+		if err := json.Unmarshal(value, &object); err != nil || object.ID == "" || object.Optional {
+			continue
+		}
+		dependency := Dependency{ID: object.ID}
+		var single string
+		if err := json.Unmarshal(object.Versions, &single); err == nil {
+			dependency.Versions = []string{single}
+		} else {
+			json.Unmarshal(object.Versions, &dependency.Versions)
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies
+}
+
+// parseModsToml reads the mods and required server-side dependencies of a
+// Forge or NeoForge mods.toml. It understands the subset of TOML these
+// files use: tables, arrays of tables and string or boolean keys.
+func parseModsToml(data []byte, loader string) []Mod {
+	var mods []Mod
+	dependencies := make(map[string][]Dependency)
+	breaks := make(map[string][]Dependency)
+
+	var table string
+	var section map[string]string
+	flush := func() {
+		switch {
+		case section == nil:
+		case table == "mods":
+			if section["modId"] != "" {
+				mods = append(mods, Mod{
+					ID:      section["modId"],
+					Name:    section["displayName"],
+					Version: section["version"],
+					Loader:  loader,
+				})
+			}
+		case strings.HasPrefix(table, "dependencies."):
+			owner := strings.Trim(strings.TrimPrefix(table, "dependencies."), `"'`)
+			id := section["modId"]
+			if id == "" || strings.EqualFold(section["side"], "CLIENT") {
+				break
+			}
+			dependency := Dependency{ID: id}
+			if section["versionRange"] != "" {
+				dependency.Versions = []string{section["versionRange"]}
+			}
+			// Forge marks dependencies mandatory; NeoForge gives them a type
+			switch strings.ToLower(section["type"]) {
+			case "required":
+				dependencies[owner] = append(dependencies[owner], dependency)
+			case "incompatible":
+				breaks[owner] = append(breaks[owner], dependency)
+			case "":
+				if section["mandatory"] == "true" {
+					dependencies[owner] = append(dependencies[owner], dependency)
+				}
+			}
+		}
+		section = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64<<10), maxMetadataSize)
+	var multiline string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if multiline != "" {
+			if strings.Contains(line, multiline) {
+				multiline = ""
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			flush()
+			table = strings.TrimSpace(strings.Trim(stripComment(line), "[]"))
+			section = make(map[string]string)
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section == nil {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		for _, quote := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, quote) && !strings.Contains(value[len(quote):], quote) {
+				multiline = quote
+			}
+		}
+		section[strings.TrimSpace(key)] = tomlValue(value)
+	}
+	flush()
+
+	for i := range mods {
+		mods[i].Dependencies = dependencies[mods[i].ID]
+		mods[i].Breaks = breaks[mods[i].ID]
+	}
+	return mods
+}
+
+// tomlValue returns a string or other scalar TOML value without its quotes
+// and trailing comment
+func tomlValue(value string) string {
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+		return strings.Trim(value, `"'`)
+	}
+	return strings.TrimSpace(stripComment(value))
+}
+
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// manifestAttribute returns an attribute of the main section of a jar manifest
+func manifestAttribute(data []byte, name string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok && key == name {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
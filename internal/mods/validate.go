@@ -0,0 +1,171 @@
+package mods
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of warnings about a set of mods
+const (
+	WarningMissingDependency = "missing_dependency"
+	WarningConflict          = "conflict"
+	WarningDuplicateMod      = "duplicate_mod"
+	WarningLoaderMismatch    = "loader_mismatch"
+	WarningMinecraftMismatch = "minecraft_mismatch"
+	WarningUnreadable        = "unreadable"
+)
+
+// Warning is a problem likely to keep a set of mods from loading
+type Warning struct {
+	Type string `json:"type" example:"missing_dependency"`
+	// Mod is the ID of the mod the warning is about, empty for jars
+	// that could not be read
+	Mod   string   `json:"mod,omitempty" example:"sodium"`
+	Files []string `json:"files"`
+	// Dependency is the missing or conflicting mod
+	Dependency string `json:"dependency,omitempty" example:"fabric-api"`
+	Message    string `json:"message" example:"Sodium requires fabric-api, which is not installed"`
+}
+
+// builtinIDs are provided by the game or the loader itself
+var builtinIDs = map[string]bool{
+	"minecraft":    true,
+	"java":         true,
+	"fabricloader": true,
+	"quilt_loader": true,
+	"forge":        true,
+	"neoforge":     true,
+	"javafml":      true,
+}
+
+// Validate checks mods installed together on a server running loader and
+// Minecraft mcVersion. Either may be empty when unknown, which skips the
+// checks that need it.
+func Validate(mods []Mod, loader, mcVersion string) []Warning {
+	warnings := []Warning{}
+	installed := make(map[string]Mod)
+	for _, mod := range mods {
+		if _, exists := installed[mod.ID]; !exists || !mod.Nested {
+			installed[mod.ID] = mod
+		}
+		for _, id := range mod.Provides {
+			if _, exists := installed[id]; !exists {
+				installed[id] = mod
+			}
+		}
+	}
+
+	// The loader picks one of several nested copies, but two jars
+	// declaring the same mod fail the start
+	files := make(map[string][]string)
+	for _, mod := range mods {
+		if !mod.Nested {
+			files[mod.ID] = append(files[mod.ID], mod.File)
+		}
+	}
+	for _, mod := range topLevel(mods) {
+		if len(files[mod.ID]) > 1 {
+			warnings = append(warnings, Warning{
+				Type:    WarningDuplicateMod,
+				Mod:     mod.ID,
+				Files:   files[mod.ID],
+				Message: fmt.Sprintf("%s is installed %d times: %s", displayName(mod), len(files[mod.ID]), strings.Join(files[mod.ID], ", ")),
+			})
+			delete(files, mod.ID)
+		}
+	}
+
+	for _, mod := range topLevel(mods) {
+		if loader != "" && !loaderCompatible(mod.Loader, loader) {
+			warnings = append(warnings, Warning{
+				Type:    WarningLoaderMismatch,
+				Mod:     mod.ID,
+				Files:   []string{mod.File},
+				Message: fmt.Sprintf("%s is a %s mod but the server runs %s", displayName(mod), mod.Loader, loader),
+			})
+		}
+
+		for _, dependency := range mod.Dependencies {
+			if dependency.ID == "minecraft" {
+				if mcVersion != "" && !versionsMatch(mod.Loader, dependency.Versions, mcVersion) {
+					warnings = append(warnings, Warning{
+						Type:    WarningMinecraftMismatch,
+						Mod:     mod.ID,
+						Files:   []string{mod.File},
+						Message: fmt.Sprintf("%s needs Minecraft %s but the server runs %s", displayName(mod), strings.Join(dependency.Versions, " or "), mcVersion),
+					})
+				}
+				continue
+			}
+			if builtinIDs[dependency.ID] {
+				continue
+			}
+			if _, ok := installed[dependency.ID]; !ok {
+				warnings = append(warnings, Warning{
+					Type:       WarningMissingDependency,
+					Mod:        mod.ID,
+					Files:      []string{mod.File},
+					Dependency: dependency.ID,
+					Message:    fmt.Sprintf("%s requires %s, which is not installed", displayName(mod), dependencyName(dependency.ID)),
+				})
+			}
+		}
+
+		for _, broken := range mod.Breaks {
+			other, ok := installed[broken.ID]
+			if !ok || other.File == mod.File || !versionsMatch(mod.Loader, broken.Versions, other.Version) {
+				continue
+			}
+			warnings = append(warnings, Warning{
+				Type:       WarningConflict,
+				Mod:        mod.ID,
+				Files:      []string{mod.File, other.File},
+				Dependency: broken.ID,
+				Message:    fmt.Sprintf("%s does not work with %s", displayName(mod), displayName(other)),
+			})
+		}
+	}
+	return warnings
+}
+
+// topLevel returns the mods that are jars of their own, in file order
+func topLevel(mods []Mod) []Mod {
+	var result []Mod
+	for _, mod := range mods {
+		if !mod.Nested {
+			result = append(result, mod)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].File < result[j].File })
+	return result
+}
+
+// loaderCompatible reports whether a mod for one loader runs on another.
+// Quilt runs Fabric mods, and NeoForge still reads Forge metadata.
+func loaderCompatible(mod, server string) bool {
+	switch {
+	case mod == server:
+		return true
+	case mod == LoaderFabric && server == LoaderQuilt:
+		return true
+	case mod == LoaderForge && server == LoaderNeoForge:
+		return true
+	}
+	return false
+}
+
+func displayName(mod Mod) string {
+	if mod.Name != "" {
+		return mod.Name
+	}
+	return mod.ID
+}
+
+// dependencyName spells out dependencies users commonly miss
+func dependencyName(id string) string {
+	if id == "fabric-api" || id == "fabric" {
+		return "Fabric API (fabric-api)"
+	}
+	return id
+}
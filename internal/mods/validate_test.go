@@ -0,0 +1,158 @@
+package mods
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeJar writes a jar holding the given files into dir
+func writeJar(t *testing.T, dir, name string, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	for file, data := range files {
+		w, err := zw.Create(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readAll(t *testing.T, paths ...string) []Mod {
+	t.Helper()
+	var mods []Mod
+	for _, path := range paths {
+		found, err := ReadJar(path)
+		if err != nil {
+			t.Fatalf("ReadJar(%s): %v", path, err)
+		}
+		mods = append(mods, found...)
+	}
+	return mods
+}
+
+func warningTypes(warnings []Warning) map[string]int {
+	types := make(map[string]int)
+	for _, warning := range warnings {
+		types[warning.Type]++
+	}
+	return types
+}
+
+func TestValidateFabric(t *testing.T) {
+	dir := t.TempDir()
+	sodium := writeJar(t, dir, "sodium.jar", map[string]string{
+		"fabric.mod.json": `{"id": "sodium", "name": "Sodium", "version": "0.5.8",
+			"depends": {"fabricloader": ">=0.12", "minecraft": ["~1.20.1"], "fabric-api": "*"},
+			"breaks": {"optifabric": "*"}}`,
+	})
+	copied := writeJar(t, dir, "sodium-copy.jar", map[string]string{
+		"fabric.mod.json": `{"id": "sodium", "name": "Sodium", "version": "0.5.7", "depends": {"minecraft": "1.20.x"}}`,
+	})
+	optifabric := writeJar(t, dir, "optifabric.jar", map[string]string{
+		"fabric.mod.json": `{"id": "optifabric", "version": "1.14.3", "depends": {"minecraft": ">=1.20 <1.21"}}`,
+	})
+	create := writeJar(t, dir, "create.jar", map[string]string{
+		"META-INF/mods.toml":   "modLoader=\"javafml\"\n[[mods]]\nmodId=\"create\"\nversion=\"${file.jarVersion}\"\n",
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\nImplementation-Version: 0.5.1\n",
+	})
+
+	mods := readAll(t, sodium, copied, optifabric, create)
+	warnings := Validate(mods, LoaderFabric, "1.20.1")
+	got := warningTypes(warnings)
+	want := map[string]int{
+		WarningMissingDependency: 1,
+		WarningDuplicateMod:      1,
+		WarningConflict:          1,
+		WarningLoaderMismatch:    1,
+	}
+	for kind, count := range want {
+		if got[kind] != count {
+			t.Errorf("got %d %s warnings, want %d: %+v", got[kind], kind, count, warnings)
+		}
+	}
+	if len(warnings) != 4 {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+	for _, mod := range mods {
+		if mod.ID == "create" && mod.Version != "0.5.1" {
+			t.Errorf("create has version %q, want the manifest version", mod.Version)
+		}
+	}
+
+	if got := warningTypes(Validate(mods, LoaderFabric, "1.21")); got[WarningMinecraftMismatch] != 3 {
+		t.Errorf("expected every Fabric mod to mismatch Minecraft 1.21, got %v", got)
+	}
+}
+
+func TestValidateForgeDependencies(t *testing.T) {
+	dir := t.TempDir()
+	addition := writeJar(t, dir, "createaddition.jar", map[string]string{
+		"META-INF/mods.toml": `modLoader="javafml" # the language loader
+loaderVersion="[47,)"
+description='''
+Electricity for Create
+'''
+[[mods]]
+modId="createaddition"
+version="1.2.3"
+displayName="Create Crafts & Additions"
+[[dependencies.createaddition]]
+    modId="forge"
+    mandatory=true
+    versionRange="[47,)"
+[[dependencies.createaddition]]
+    modId="minecraft"
+    mandatory=true
+    versionRange="[1.20.1,1.21)"
+[[dependencies.createaddition]]
+    modId="create"
+    mandatory=true
+    versionRange="[0.5.1,)"
+[[dependencies.createaddition]]
+    modId="jei"
+    mandatory=false
+`,
+	})
+
+	warnings := Validate(readAll(t, addition), LoaderForge, "1.20.1")
+	if len(warnings) != 1 || warnings[0].Type != WarningMissingDependency || warnings[0].Dependency != "create" {
+		t.Fatalf("expected create to be missing, got %+v", warnings)
+	}
+}
+
+func TestVersionRanges(t *testing.T) {
+	tests := []struct {
+		loader   string
+		versions []string
+		version  string
+		want     bool
+	}{
+		{LoaderFabric, []string{">=1.20 <1.21"}, "1.20.4", true},
+		{LoaderFabric, []string{"~1.20.1"}, "1.20.6", true},
+		{LoaderFabric, []string{"~1.20.1"}, "1.21", false},
+		{LoaderFabric, []string{"1.19.x", "1.20.x"}, "1.20.2", true},
+		{LoaderFabric, []string{"1.20.1"}, "1.20.2", false},
+		{LoaderForge, []string{"[1.20.1,1.21)"}, "1.20.4", true},
+		{LoaderForge, []string{"[1.20.1,1.21)"}, "1.21", false},
+		{LoaderForge, []string{"[1.19.2],[1.20.1]"}, "1.20.1", true},
+		{LoaderForge, []string{"1.20.1"}, "1.18.2", true},
+	}
+	for _, tt := range tests {
+		if got := versionsMatch(tt.loader, tt.versions, tt.version); got != tt.want {
+			t.Errorf("versionsMatch(%s, %v, %s) = %v, want %v", tt.loader, tt.versions, tt.version, got, tt.want)
+		}
+	}
+}
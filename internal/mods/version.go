@@ -0,0 +1,188 @@
+package mods
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// compareVersions orders two dotted versions numerically, a pre-release
+// such as 1.20.5-pre1 before its release. Build metadata after + is
+// ignored, as are components that are not numbers.
+func compareVersions(a, b string) int {
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+	aMain, aPre, _ := strings.Cut(a, "-")
+	bMain, bPre, _ := strings.Cut(b, "-")
+
+	aParts := strings.Split(aMain, ".")
+	bParts := strings.Split(bMain, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+// versionsMatch reports whether version satisfies the versions of a
+// dependency in the syntax of loader. Versions it cannot interpret match.
+func versionsMatch(loader string, versions []string, version string) bool {
+	if len(versions) == 0 || version == "" {
+		return true
+	}
+	if loader == LoaderForge || loader == LoaderNeoForge {
+		return mavenRangeMatches(versions[0], version)
+	}
+	for _, predicate := range versions {
+		if fabricPredicateMatches(predicate, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// fabricPredicateMatches checks a Fabric version predicate: space separated
+// terms that must all match, each an optional operator (=, >, >=, <, <=, ~
+// or ^) and a version that may end in x wildcards
+func fabricPredicateMatches(predicate, version string) bool {
+	for _, term := range strings.Fields(predicate) {
+		if !fabricTermMatches(term, version) {
+			return false
+		}
+	}
+	return true
+}
+
+func fabricTermMatches(term, version string) bool {
+	if term == "*" {
+		return true
+	}
+	operator := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, candidate) {
+			operator = candidate
+			term = term[len(candidate):]
+			break
+		}
+	}
+
+	// 1.20.x matches every 1.20 release
+	parts := strings.Split(term, ".")
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			prefix := strings.Join(parts[:i], ".")
+			if prefix == "" {
+				return true
+			}
+			return version == prefix || strings.HasPrefix(version, prefix+".")
+		}
+	}
+
+	cmp := compareVersions(version, term)
+	switch operator {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "~":
+		// Same minor version, at least term
+		return cmp >= 0 && sameComponents(version, term, 2)
+	case "^":
+		// Same major version, at least term
+		return cmp >= 0 && sameComponents(version, term, 1)
+	default:
+		return cmp == 0
+	}
+}
+
+// sameComponents reports whether the first n components of two versions are equal
+func sameComponents(a, b string, n int) bool {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < n; i++ {
+		var x, y string
+		if i < len(aParts) {
+			x = aParts[i]
+		}
+		if i < len(bParts) {
+			y = bParts[i]
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// mavenRangeMatches checks a Maven version range such as [1.20.1,1.21) or
+// a union of them. A bare version is a recommendation and matches anything.
+func mavenRangeMatches(spec, version string) bool {
+	spec = strings.ReplaceAll(spec, " ", "")
+	if spec == "" || (spec[0] != '[' && spec[0] != '(') {
+		return true
+	}
+	for len(spec) > 0 {
+		end := strings.IndexAny(spec, "])")
+		if end < 0 {
+			return true
+		}
+		if mavenIntervalMatches(spec[:end+1], version) {
+			return true
+		}
+		spec = strings.TrimPrefix(spec[end+1:], ",")
+	}
+	return false
+}
+
+func mavenIntervalMatches(interval, version string) bool {
+	if len(interval) < 2 {
+		return true
+	}
+	lowInclusive := interval[0] == '['
+	highInclusive := interval[len(interval)-1] == ']'
+	body := interval[1 : len(interval)-1]
+	low, high, isRange := strings.Cut(body, ",")
+	if !isRange {
+		return compareVersions(version, body) == 0
+	}
+	if low != "" {
+		cmp := compareVersions(version, low)
+		if cmp < 0 || cmp == 0 && !lowInclusive {
+			return false
+		}
+	}
+	if high != "" {
+		cmp := compareVersions(version, high)
+		if cmp > 0 || cmp == 0 && !highInclusive {
+			return false
+		}
+	}
+	return true
+}
+
+func sortDependencies(dependencies []Dependency) {
+	sort.Slice(dependencies, func(i, j int) bool { return dependencies[i].ID < dependencies[j].ID })
+}
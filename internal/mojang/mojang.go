@@ -0,0 +1,353 @@
+// Package mojang looks up Minecraft player profiles through the Mojang API
+// and caches them, since the API allows only a few hundred requests every
+// ten minutes
+package mojang
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// apiURL resolves names to UUIDs
+	apiURL = "https://api.mojang.com"
+	// sessionURL serves profiles with their textures by UUID
+	sessionURL = "https://sessionserver.mojang.com"
+
+	// profileTTL is how long a profile or name is trusted. Names can be
+	// changed every 30 days, so an hour old name is rarely wrong.
+	profileTTL = time.Hour
+	// notFoundTTL is how long an unknown name or UUID is remembered,
+	// shorter since names get registered
+	notFoundTTL = 5 * time.Minute
+	// maxEntries bounds each cache
+	maxEntries = 10000
+)
+
+var (
+	// ErrNotFound is returned for names and UUIDs of no Minecraft account
+	ErrNotFound = errors.New("player not found")
+	// ErrRateLimited is returned when Mojang refuses more requests for now
+	// and nothing is cached
+	ErrRateLimited = errors.New("mojang rate limit reached")
+)
+
+// Profile is a Minecraft account
+type Profile struct {
+	// UUID is in the dashed form the game uses
+	UUID string `json:"uuid" example:"069a79f4-44e9-4726-a5be-fca90e38aaf5"`
+	Name string `json:"name" example:"Notch"`
+	// SkinURL is the skin texture, empty for accounts with the default skin
+	SkinURL string `json:"skin_url,omitempty" example:"https://textures.minecraft.net/texture/292009a4925b58f02c77dadc3ecef07ea4c7472f64e0fdc32ce5522489362680"`
+	// SkinModel is classic or slim
+	SkinModel string `json:"skin_model,omitempty" example:"classic"`
+	CapeURL   string `json:"cape_url,omitempty"`
+	// Offline marks profiles of offline mode servers, which have a UUID
+	// derived from the name and no skin
+	Offline bool `json:"offline,omitempty"`
+}
+
+type cachedProfile struct {
+	// profile is nil for UUIDs of no account
+	profile *Profile
+	at      time.Time
+}
+
+type cachedName struct {
+	// uuid is empty for names of no account
+	uuid string
+	at   time.Time
+}
+
+// Client looks up profiles, caching them in memory. When Mojang is down or
+// rate limits, expired entries are served rather than failing.
+type Client struct {
+	// APIURL and SessionURL override the Mojang addresses, for tests
+	APIURL     string
+	SessionURL string
+	HTTP       *http.Client
+
+	mutex    sync.Mutex
+	profiles map[string]cachedProfile
+	names    map[string]cachedName
+}
+
+// NewClient returns a client with empty caches
+func NewClient() *Client {
+	return &Client{
+		HTTP:     &http.Client{Timeout: 10 * time.Second},
+		profiles: make(map[string]cachedProfile),
+		names:    make(map[string]cachedName),
+	}
+}
+
+// Lookup returns the profile of a player given by UUID, with or without
+// dashes, or by name
+func (c *Client) Lookup(ctx context.Context, player string) (*Profile, error) {
+	if id, ok := normalizeUUID(player); ok {
+		return c.ByUUID(ctx, id)
+	}
+	return c.ByName(ctx, player)
+}
+
+// ByName returns the profile of the account currently named name. A cached
+// name whose account has since been renamed is looked up again, so the
+// result always carries the account's current name.
+func (c *Client) ByName(ctx context.Context, name string) (*Profile, error) {
+	key := strings.ToLower(name)
+	c.mutex.Lock()
+	entry, cached := c.names[key]
+	c.mutex.Unlock()
+
+	if cached && fresh(entry.at, entry.uuid != "") {
+		if entry.uuid == "" {
+			return nil, ErrNotFound
+		}
+		profile, err := c.ByUUID(ctx, entry.uuid)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if err == nil && strings.EqualFold(profile.Name, name) {
+			return profile, nil
+		}
+	}
+
+	var found struct {
+		ID string `json:"id"`
+	}
+	err := c.get(ctx, c.baseURL(c.APIURL, apiURL)+"/users/profiles/minecraft/"+url.PathEscape(name), &found)
+	if errors.Is(err, ErrNotFound) {
+		c.mutex.Lock()
+		c.storeName(key, "")
+		c.mutex.Unlock()
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		if cached && entry.uuid != "" {
+			return c.ByUUID(ctx, entry.uuid)
+		}
+		return nil, err
+	}
+	id, ok := normalizeUUID(found.ID)
+	if !ok {
+		return nil, fmt.Errorf("mojang returned invalid UUID %q", found.ID)
+	}
+	c.mutex.Lock()
+	c.storeName(key, id)
+	c.mutex.Unlock()
+	return c.ByUUID(ctx, id)
+}
+
+// ByUUID returns the profile of the account with the given UUID
+func (c *Client) ByUUID(ctx context.Context, player string) (*Profile, error) {
+	id, ok := normalizeUUID(player)
+	if !ok {
+		return nil, fmt.Errorf("invalid UUID %q", player)
+	}
+	c.mutex.Lock()
+	entry, cached := c.profiles[id]
+	c.mutex.Unlock()
+	if cached && fresh(entry.at, entry.profile != nil) {
+		return copyProfile(entry.profile), nil
+	}
+
+	var found struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		Properties []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"properties"`
+	}
+	err := c.get(ctx, c.baseURL(c.SessionURL, sessionURL)+"/session/minecraft/profile/"+strings.ReplaceAll(id, "-", ""), &found)
+	if errors.Is(err, ErrNotFound) {
+		c.mutex.Lock()
+		c.storeProfile(id, nil)
+		c.mutex.Unlock()
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		if cached && entry.profile != nil {
+			return copyProfile(entry.profile), nil
+		}
+		return nil, err
+	}
+
+	profile := &Profile{UUID: id, Name: found.Name}
+	for _, property := range found.Properties {
+		if property.Name == "textures" {
+			readTextures(profile, property.Value)
+		}
+	}
+	c.mutex.Lock()
+	c.storeProfile(id, profile)
+	c.storeName(strings.ToLower(profile.Name), id)
+	c.mutex.Unlock()
+	return copyProfile(profile), nil
+}
+
+// readTextures fills in the skin and cape of profile from the base64 JSON
+// textures property
+func readTextures(profile *Profile, value string) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return
+	}
+	var textures struct {
+		Textures struct {
+			Skin struct {
+				URL      string `json:"url"`
+				Metadata struct {
+					Model string `json:"model"`
+				} `json:"metadata"`
+			} `json:"SKIN"`
+			Cape struct {
+				URL string `json:"url"`
+			} `json:"CAPE"`
+		} `json:"textures"`
+	}
+	if json.Unmarshal(data, &textures) != nil {
+		return
+	}
+	// The texture server answers on https too, which browsers require on
+	// pages served over https
+	profile.SkinURL = strings.Replace(textures.Textures.Skin.URL, "http://", "https://", 1)
+	profile.CapeURL = strings.Replace(textures.Textures.Cape.URL, "http://", "https://", 1)
+	if profile.SkinURL != "" {
+		profile.SkinModel = "classic"
+		if textures.Textures.Skin.Metadata.Model == "slim" {
+			profile.SkinModel = "slim"
+		}
+	}
+}
+
+// get fetches a Mojang endpoint into out. Mojang answers unknown names
+// with 404 or, on older endpoints, 204.
+func (c *Client) get(ctx context.Context, address string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach mojang: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	default:
+		return fmt.Errorf("mojang returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode mojang response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) baseURL(override, fallback string) string {
+	if override != "" {
+		return strings.TrimRight(override, "/")
+	}
+	return fallback
+}
+
+// storeProfile caches profile under id. The caller holds the mutex.
+func (c *Client) storeProfile(id string, profile *Profile) {
+	if c.profiles == nil {
+		c.profiles = make(map[string]cachedProfile)
+	}
+	if len(c.profiles) >= maxEntries {
+		for key, entry := range c.profiles {
+			if !fresh(entry.at, entry.profile != nil) {
+				delete(c.profiles, key)
+			}
+		}
+		if len(c.profiles) >= maxEntries {
+			c.profiles = make(map[string]cachedProfile)
+		}
+	}
+	c.profiles[id] = cachedProfile{profile: profile, at: time.Now()}
+}
+
+// storeName caches the UUID of a lower case name. The caller holds the
+// mutex.
+func (c *Client) storeName(name, id string) {
+	if c.names == nil {
+		c.names = make(map[string]cachedName)
+	}
+	if len(c.names) >= maxEntries {
+		for key, entry := range c.names {
+			if !fresh(entry.at, entry.uuid != "") {
+				delete(c.names, key)
+			}
+		}
+		if len(c.names) >= maxEntries {
+			c.names = make(map[string]cachedName)
+		}
+	}
+	c.names[name] = cachedName{uuid: id, at: time.Now()}
+}
+
+// fresh reports whether an entry cached at is still trusted
+func fresh(at time.Time, found bool) bool {
+	if found {
+		return time.Since(at) < profileTTL
+	}
+	return time.Since(at) < notFoundTTL
+}
+
+// copyProfile keeps callers from changing cached profiles
+func copyProfile(profile *Profile) *Profile {
+	copied := *profile
+	return &copied
+}
+
+// normalizeUUID returns id in dashed lower case form, accepting it with or
+// without dashes
+func normalizeUUID(id string) (string, bool) {
+	raw := strings.ToLower(strings.ReplaceAll(id, "-", ""))
+	if len(raw) != 32 || (len(id) != 32 && len(id) != 36) {
+		return "", false
+	}
+	if _, err := hex.DecodeString(raw); err != nil {
+		return "", false
+	}
+	return raw[0:8] + "-" + raw[8:12] + "-" + raw[12:16] + "-" + raw[16:20] + "-" + raw[20:], true
+}
+
+// OfflineProfile returns the profile an offline mode server gives name:
+// a version 3 UUID of "OfflinePlayer:" and the name, as Java's
+// UUID.nameUUIDFromBytes computes it
+func OfflineProfile(name string) *Profile {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	id, _ := normalizeUUID(hex.EncodeToString(sum[:]))
+	return &Profile{UUID: id, Name: name, Offline: true}
+}
+
+// IsUUID reports whether s is a UUID, with or without dashes
+func IsUUID(s string) bool {
+	_, ok := normalizeUUID(s)
+	return ok
+}
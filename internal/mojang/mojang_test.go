@@ -0,0 +1,80 @@
+package mojang
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	const id = "069a79f444e94726a5befca90e38aaf5"
+	name := "Notch"
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case strings.EqualFold(r.URL.Path, "/users/profiles/minecraft/"+name):
+			json.NewEncoder(w).Encode(map[string]string{"id": id, "name": name})
+		case strings.HasPrefix(r.URL.Path, "/users/profiles/minecraft/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/session/minecraft/profile/"+id:
+			textures := `{"textures": {"SKIN": {"url": "http://textures.minecraft.net/texture/abc", "metadata": {"model": "slim"}}}}`
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":   id,
+				"name": name,
+				"properties": []map[string]string{
+					{"name": "textures", "value": base64.StdEncoding.EncodeToString([]byte(textures))},
+				},
+			})
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.APIURL = srv.URL
+	client.SessionURL = srv.URL
+	ctx := context.Background()
+
+	profile, err := client.Lookup(ctx, "notch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.UUID != "069a79f4-44e9-4726-a5be-fca90e38aaf5" || profile.Name != "Notch" ||
+		profile.SkinURL != "https://textures.minecraft.net/texture/abc" || profile.SkinModel != "slim" {
+		t.Errorf("got %+v", profile)
+	}
+	if _, err := client.Lookup(ctx, "069a79f4-44e9-4726-a5be-fca90e38aaf5"); err != nil || requests != 2 {
+		t.Errorf("expected a cached lookup by UUID, got %v after %d requests", err, requests)
+	}
+
+	// The account is renamed: its old name resolves again and misses
+	name = "Jeb"
+	client.mutex.Lock()
+	delete(client.profiles, profile.UUID)
+	client.mutex.Unlock()
+	if _, err := client.ByName(ctx, "Notch"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v for the old name, want ErrNotFound", err)
+	}
+	if profile, err := client.ByName(ctx, "Jeb"); err != nil || profile.UUID != "069a79f4-44e9-4726-a5be-fca90e38aaf5" {
+		t.Errorf("got %+v, %v for the new name", profile, err)
+	}
+
+	before := requests
+	if _, err := client.ByName(ctx, "Notch"); !errors.Is(err, ErrNotFound) || requests != before {
+		t.Errorf("expected the unknown name to be cached, got %v after %d requests", err, requests-before)
+	}
+}
+
+func TestOfflineProfile(t *testing.T) {
+	if profile := OfflineProfile("Notch"); profile.UUID != "b50ad385-829d-3141-a216-7e7d7539ba7f" || !profile.Offline {
+		t.Errorf("got %+v", profile)
+	}
+}
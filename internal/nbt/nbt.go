@@ -0,0 +1,401 @@
+// Package nbt reads and writes Minecraft's Named Binary Tag format, enough
+// to edit files such as level.dat in place.
+package nbt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// Tag types
+const (
+	TagEnd byte = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+// maxDepth bounds the nesting of compounds and lists read from a file
+const maxDepth = 512
+
+// Tag is a named value of a compound. Value holds an int8, int16, int32,
+// int64, float32, float64, []int8, string, *List, *Compound, []int32 or
+// []int64 depending on Type.
+type Tag struct {
+	Type  byte
+	Name  string
+	Value interface{}
+}
+
+// Compound is an ordered set of named tags
+type Compound struct {
+	Tags []Tag
+}
+
+// List is a sequence of unnamed values of a single type
+type List struct {
+	Type   byte
+	Values []interface{}
+}
+
+// Get returns the value of the tag named name
+func (c *Compound) Get(name string) (interface{}, bool) {
+	for _, tag := range c.Tags {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Compound returns the compound named name
+func (c *Compound) Compound(name string) (*Compound, bool) {
+	value, _ := c.Get(name)
+	compound, ok := value.(*Compound)
+	return compound, ok
+}
+
+// Set replaces the tag named name or appends it if missing
+func (c *Compound) Set(name string, tagType byte, value interface{}) {
+	for i := range c.Tags {
+		if c.Tags[i].Name == name {
+			c.Tags[i] = Tag{Type: tagType, Name: name, Value: value}
+			return
+		}
+	}
+	c.Tags = append(c.Tags, Tag{Type: tagType, Name: name, Value: value})
+}
+
+// Read decodes an uncompressed NBT document, returning the name and value
+// of its root compound
+func Read(r io.Reader) (string, *Compound, error) {
+	d := decoder{r: bufio.NewReader(r)}
+	tagType := d.byte()
+	if d.err == nil && tagType != TagCompound {
+		return "", nil, fmt.Errorf("nbt: root tag has type %d, want a compound", tagType)
+	}
+	name := d.string()
+	root, _ := d.payload(TagCompound, 0).(*Compound)
+	if d.err != nil {
+		return "", nil, fmt.Errorf("nbt: %w", d.err)
+	}
+	return name, root, nil
+}
+
+// Write encodes root as an uncompressed NBT document
+func Write(w io.Writer, name string, root *Compound) error {
+	e := encoder{w: bufio.NewWriter(w)}
+	e.byte(TagCompound)
+	e.string(name)
+	e.payload(TagCompound, root)
+	if e.err != nil {
+		return fmt.Errorf("nbt: %w", e.err)
+	}
+	return e.w.Flush()
+}
+
+// EditFile applies edit to the root compound of the gzip-compressed NBT file
+// name below dir, such as level.dat. The previous contents are kept in
+// name_old, the way Minecraft backs up level.dat, and the file is replaced
+// atomically. Symlinks in name are never followed.
+func EditFile(dir, name string, edit func(root *Compound) error) error {
+	path := filepath.Join(dir, name)
+	data, err := utils.ReadFileIn(dir, name)
+	if err != nil {
+		return err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("nbt: %s is not gzip-compressed: %w", filepath.Base(path), err)
+	}
+	rootName, root, err := Read(zr)
+	if err != nil {
+		return err
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := Write(zw, rootName, root); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := utils.WriteFileIn(dir, name+"_old", data, 0644); err != nil {
+		return err
+	}
+	if err := utils.WriteFileIn(dir, name+".tmp", buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// decoder reads NBT values, keeping the first error
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		d.err = err
+		return nil
+	}
+	return buf
+}
+
+func (d *decoder) byte() byte {
+	if b := d.read(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.read(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.read(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.read(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	return string(d.read(int(d.uint16())))
+}
+
+// length reads an array or list length
+func (d *decoder) length() int {
+	n := int32(d.uint32())
+	if n < 0 && d.err == nil {
+		d.err = fmt.Errorf("negative length %d", n)
+	}
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) payload(tagType byte, depth int) interface{} {
+	if depth > maxDepth {
+		d.err = fmt.Errorf("nesting deeper than %d", maxDepth)
+	}
+	if d.err != nil {
+		return nil
+	}
+	switch tagType {
+	case TagByte:
+		return int8(d.byte())
+	case TagShort:
+		return int16(d.uint16())
+	case TagInt:
+		return int32(d.uint32())
+	case TagLong:
+		return int64(d.uint64())
+	case TagFloat:
+		return math.Float32frombits(d.uint32())
+	case TagDouble:
+		return math.Float64frombits(d.uint64())
+	case TagByteArray:
+		raw := d.read(d.length())
+		values := make([]int8, len(raw))
+		for i, b := range raw {
+			values[i] = int8(b)
+		}
+		return values
+	case TagString:
+		return d.string()
+	case TagList:
+		list := &List{Type: d.byte()}
+		n := d.length()
+		for i := 0; i < n && d.err == nil; i++ {
+			list.Values = append(list.Values, d.payload(list.Type, depth+1))
+		}
+		return list
+	case TagCompound:
+		compound := &Compound{}
+		for d.err == nil {
+			childType := d.byte()
+			if childType == TagEnd {
+				break
+			}
+			name := d.string()
+			compound.Tags = append(compound.Tags, Tag{Type: childType, Name: name, Value: d.payload(childType, depth+1)})
+		}
+		return compound
+	case TagIntArray:
+		n := d.length()
+		values := make([]int32, 0, min(n, 1024))
+		for i := 0; i < n && d.err == nil; i++ {
+			values = append(values, int32(d.uint32()))
+		}
+		return values
+	case TagLongArray:
+		n := d.length()
+		values := make([]int64, 0, min(n, 1024))
+		for i := 0; i < n && d.err == nil; i++ {
+			values = append(values, int64(d.uint64()))
+		}
+		return values
+	default:
+		d.err = fmt.Errorf("unknown tag type %d", tagType)
+		return nil
+	}
+}
+
+// encoder writes NBT values, keeping the first error
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) byte(b byte) {
+	e.write([]byte{b})
+}
+
+func (e *encoder) uint16(v uint16) {
+	e.write(binary.BigEndian.AppendUint16(nil, v))
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.write(binary.BigEndian.AppendUint64(nil, v))
+}
+
+func (e *encoder) string(s string) {
+	if len(s) > math.MaxUint16 {
+		e.err = fmt.Errorf("string of %d bytes is too long", len(s))
+		return
+	}
+	e.uint16(uint16(len(s)))
+	e.write([]byte(s))
+}
+
+func (e *encoder) payload(tagType byte, value interface{}) {
+	if e.err != nil {
+		return
+	}
+	ok := true
+	switch tagType {
+	case TagByte:
+		var v int8
+		v, ok = value.(int8)
+		e.byte(byte(v))
+	case TagShort:
+		var v int16
+		v, ok = value.(int16)
+		e.uint16(uint16(v))
+	case TagInt:
+		var v int32
+		v, ok = value.(int32)
+		e.uint32(uint32(v))
+	case TagLong:
+		var v int64
+		v, ok = value.(int64)
+		e.uint64(uint64(v))
+	case TagFloat:
+		var v float32
+		v, ok = value.(float32)
+		e.uint32(math.Float32bits(v))
+	case TagDouble:
+		var v float64
+		v, ok = value.(float64)
+		e.uint64(math.Float64bits(v))
+	case TagByteArray:
+		var values []int8
+		values, ok = value.([]int8)
+		e.uint32(uint32(len(values)))
+		for _, v := range values {
+			e.byte(byte(v))
+		}
+	case TagString:
+		var v string
+		v, ok = value.(string)
+		e.string(v)
+	case TagList:
+		var list *List
+		if list, ok = value.(*List); ok {
+			e.byte(list.Type)
+			e.uint32(uint32(len(list.Values)))
+			for _, v := range list.Values {
+				e.payload(list.Type, v)
+			}
+		}
+	case TagCompound:
+		var compound *Compound
+		if compound, ok = value.(*Compound); ok {
+			for _, tag := range compound.Tags {
+				e.byte(tag.Type)
+				e.string(tag.Name)
+				e.payload(tag.Type, tag.Value)
+			}
+			e.byte(TagEnd)
+		}
+	case TagIntArray:
+		var values []int32
+		values, ok = value.([]int32)
+		e.uint32(uint32(len(values)))
+		for _, v := range values {
+			e.uint32(uint32(v))
+		}
+	case TagLongArray:
+		var values []int64
+		values, ok = value.([]int64)
+		e.uint32(uint32(len(values)))
+		for _, v := range values {
+			e.uint64(uint64(v))
+		}
+	default:
+		e.err = fmt.Errorf("unknown tag type %d", tagType)
+		return
+	}
+	if !ok && e.err == nil {
+		e.err = fmt.Errorf("value %T does not match tag type %d", value, tagType)
+	}
+}
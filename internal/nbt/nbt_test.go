@@ -0,0 +1,93 @@
+package nbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	root := &Compound{Tags: []Tag{
+		{Type: TagByte, Name: "Difficulty", Value: int8(2)},
+		{Type: TagShort, Name: "Short", Value: int16(-3)},
+		{Type: TagInt, Name: "GameType", Value: int32(1)},
+		{Type: TagLong, Name: "Time", Value: int64(1 << 40)},
+		{Type: TagFloat, Name: "Float", Value: float32(0.5)},
+		{Type: TagDouble, Name: "BorderSize", Value: 5.9999968e7},
+		{Type: TagByteArray, Name: "Bytes", Value: []int8{1, -1}},
+		{Type: TagString, Name: "LevelName", Value: "world"},
+		{Type: TagList, Name: "Players", Value: &List{Type: TagString, Values: []interface{}{"Steve", "Alex"}}},
+		{Type: TagCompound, Name: "GameRules", Value: &Compound{Tags: []Tag{{Type: TagString, Name: "keepInventory", Value: "false"}}}},
+		{Type: TagIntArray, Name: "Ints", Value: []int32{7, 8}},
+		{Type: TagLongArray, Name: "Longs", Value: []int64{-9}},
+	}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "", root); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	name, got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if name != "" || !reflect.DeepEqual(got, root) {
+		t.Errorf("got %q %+v, want %+v", name, got, root)
+	}
+}
+
+func TestEditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "level.dat")
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	data := &Compound{Tags: []Tag{{Type: TagByte, Name: "Difficulty", Value: int8(1)}}}
+	if err := Write(zw, "", &Compound{Tags: []Tag{{Type: TagCompound, Name: "Data", Value: data}}}); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := EditFile(filepath.Dir(path), filepath.Base(path), func(root *Compound) error {
+		data, _ := root.Compound("Data")
+		data.Set("Difficulty", TagByte, int8(3))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EditFile: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, root, err := Read(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := root.Compound("Data")
+	if difficulty, _ := got.Get("Difficulty"); difficulty != int8(3) {
+		t.Errorf("got difficulty %v, want 3", difficulty)
+	}
+	if _, err := os.Stat(path + "_old"); err != nil {
+		t.Errorf("previous level.dat not kept: %v", err)
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "", &Compound{Tags: []Tag{{Type: TagString, Name: "LevelName", Value: "world"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Read(bytes.NewReader(buf.Bytes()[:buf.Len()-3])); err == nil {
+		t.Error("truncated document read without error")
+	}
+}
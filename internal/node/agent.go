@@ -0,0 +1,301 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+const (
+	// capacityInterval is how often an agent reports its capacity
+	capacityInterval = 30 * time.Second
+	// maxReconnectDelay caps the backoff between connection attempts
+	maxReconnectDelay = time.Minute
+)
+
+// Agent runs the servers the control plane schedules onto its node
+type Agent struct {
+	// URL is the WebSocket endpoint of the control plane, e.g.
+	// wss://manager.example.com/nodes/connect
+	URL   string
+	Token string
+	// Runtime starts the server processes
+	Runtime server.Runtime
+	// Root is the directory servers and their files must be in
+	Root string
+}
+
+// Run connects to the control plane and serves it, reconnecting with
+// backoff, until ctx is done
+func (a *Agent) Run(ctx context.Context) error {
+	delay := time.Second
+	for {
+		connected, err := a.session(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			delay = time.Second
+		}
+		slog.Warn("Control plane connection lost", "error", err, "retry_in", delay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// session serves one connection. It reports whether the connection was
+// established.
+func (a *Agent) session(ctx context.Context) (bool, error) {
+	header := http.Header{"Authorization": []string{"Bearer " + a.Token}}
+	ws, _, err := websocket.DefaultDialer.DialContext(ctx, a.URL, header)
+	if err != nil {
+		return false, err
+	}
+	slog.Info("Connected to control plane", "url", a.URL)
+
+	s := &agentSession{agent: a, ws: ws, processes: make(map[uint]server.Process)}
+	defer s.close()
+
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	defer stop()
+	go s.reportCapacity()
+
+	for {
+		var msg Message
+		if err := ws.ReadJSON(&msg); err != nil {
+			return true, err
+		}
+		s.handle(msg)
+	}
+}
+
+// agentSession is the state of one connection to the control plane
+type agentSession struct {
+	agent      *Agent
+	ws         *websocket.Conn
+	writeMutex sync.Mutex
+
+	mutex     sync.Mutex
+	processes map[uint]server.Process
+	closed    bool
+}
+
+func (s *agentSession) send(msg Message) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	return s.ws.WriteJSON(msg)
+}
+
+func (s *agentSession) reply(request Message, err error) {
+	result := Message{Type: TypeResult, ID: request.ID}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	s.send(result)
+}
+
+// close stops the servers of the session, which the control plane can no
+// longer see
+func (s *agentSession) close() {
+	s.mutex.Lock()
+	s.closed = true
+	processes := s.processes
+	s.processes = make(map[uint]server.Process)
+	s.mutex.Unlock()
+
+	s.ws.Close()
+	for serverID, process := range processes {
+		slog.Info("Stopping server after losing the control plane", "server_id", serverID)
+		process.Interrupt()
+	}
+}
+
+func (s *agentSession) reportCapacity() {
+	ticker := time.NewTicker(capacityInterval)
+	defer ticker.Stop()
+	for {
+		capacity := s.agent.capacity()
+		if err := s.send(Message{Type: TypeCapacity, Capacity: &capacity}); err != nil {
+			return
+		}
+		<-ticker.C
+	}
+}
+
+func (s *agentSession) process(serverID uint) (server.Process, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	process, ok := s.processes[serverID]
+	if !ok {
+		return nil, fmt.Errorf("server %d is not running", serverID)
+	}
+	return process, nil
+}
+
+func (s *agentSession) handle(msg Message) {
+	switch msg.Type {
+	case TypeStart:
+		s.reply(msg, s.start(msg))
+	case TypeStdin:
+		if process, err := s.process(msg.ServerID); err == nil {
+			process.Stdin().Write(msg.Data)
+		}
+	case TypeInterrupt:
+		process, err := s.process(msg.ServerID)
+		if err == nil {
+			err = process.Interrupt()
+		}
+		s.reply(msg, err)
+	case TypeKill:
+		process, err := s.process(msg.ServerID)
+		if err == nil {
+			err = process.Kill()
+		}
+		s.reply(msg, err)
+	case TypeWriteFile:
+		s.reply(msg, s.agent.writeFile(msg.Path, msg.Offset, msg.Data))
+	default:
+		s.reply(msg, fmt.Errorf("unknown message type %q", msg.Type))
+	}
+}
+
+func (s *agentSession) start(msg Message) error {
+	if msg.Start == nil || len(msg.Start.Command) == 0 {
+		return errors.New("start request without a command")
+	}
+	dir, err := s.agent.path(msg.Start.Dir)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return errors.New("session closed")
+	}
+	if _, ok := s.processes[msg.ServerID]; ok {
+		return fmt.Errorf("server %d is already running", msg.ServerID)
+	}
+	process, err := s.agent.Runtime.Start(server.ProcessSpec{
+		ServerID: msg.ServerID,
+		Dir:      dir,
+		Command:  msg.Start.Command,
+		Env:      msg.Start.Env,
+		MemoryMB: msg.Start.MemoryMB,
+		Port:     msg.Start.Port,
+		Stderr:   outputWriter{session: s, serverID: msg.ServerID, typ: TypeStderr},
+	})
+	if err != nil {
+		return err
+	}
+	s.processes[msg.ServerID] = process
+	go s.monitor(msg.ServerID, process)
+	return nil
+}
+
+// monitor forwards the output of a process and reports its exit once the
+// output is drained
+func (s *agentSession) monitor(serverID uint, process server.Process) {
+	io.Copy(outputWriter{session: s, serverID: serverID, typ: TypeStdout}, process.Stdout())
+	exit := Message{Type: TypeExit, ServerID: serverID}
+	if err := process.Wait(); err != nil {
+		exit.Error = err.Error()
+	}
+
+	s.mutex.Lock()
+	if s.processes[serverID] == process {
+		delete(s.processes, serverID)
+	}
+	s.mutex.Unlock()
+	s.send(exit)
+}
+
+// outputWriter sends process output to the control plane
+type outputWriter struct {
+	session  *agentSession
+	serverID uint
+	typ      string
+}
+
+func (w outputWriter) Write(data []byte) (int, error) {
+	msg := Message{Type: w.typ, ServerID: w.serverID, Data: data}
+	// Errors are dropped to keep draining the process without a control plane
+	w.session.send(msg)
+	return len(data), nil
+}
+
+// path resolves p and makes sure it is inside the agent's root
+func (a *Agent) path(p string) (string, error) {
+	root, err := filepath.Abs(a.Root)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of %s", p, root)
+	}
+	return abs, nil
+}
+
+// writeFile writes a chunk of a file; the chunk at offset zero truncates it
+func (a *Agent) writeFile(p string, offset int64, data []byte) error {
+	path, err := a.path(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, offset); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (a *Agent) capacity() Capacity {
+	capacity := Capacity{CPUCores: runtime.NumCPU(), MemoryMB: totalMemoryMB()}
+	if free, err := utils.FreeDiskSpace(a.Root); err == nil {
+		capacity.FreeDiskBytes = int64(free)
+	}
+	return capacity
+}
+
+// totalMemoryMB reads the memory of the machine from /proc/meminfo, zero
+// where it is unavailable
+func totalMemoryMB() int64 {
+	memory, err := utils.ReadHostMemory()
+	if err != nil {
+		return 0
+	}
+	return int64(memory.TotalBytes >> 20)
+}
@@ -0,0 +1,404 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+const (
+	// requestTimeout bounds how long the control plane waits for an agent
+	// to answer a request
+	requestTimeout = 30 * time.Second
+	// fileChunkSize is how much of a file one write_file message carries
+	fileChunkSize = 1 << 20
+)
+
+// ErrNodeOffline is returned for requests to a node without a connected agent
+var ErrNodeOffline = errors.New("node is not connected")
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// Hub keeps the connections of node agents on the control plane and runs
+// server processes through them
+type Hub struct {
+	authenticate func(token string) (uint, error)
+	report       func(nodeID uint, capacity Capacity)
+
+	mutex sync.Mutex
+	conns map[uint]*conn
+}
+
+// NewHub returns a hub accepting agents whose token authenticate maps to a
+// node. Reported capacity is passed to report.
+func NewHub(authenticate func(token string) (uint, error), report func(nodeID uint, capacity Capacity)) *Hub {
+	return &Hub{
+		authenticate: authenticate,
+		report:       report,
+		conns:        make(map[uint]*conn),
+	}
+}
+
+// ServeHTTP accepts the WebSocket of an agent presenting its node token as
+// a bearer token, and serves it until it disconnects
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	nodeID, err := h.authenticate(token)
+	if err != nil {
+		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "WebSocket upgrade error", "error", err)
+		return
+	}
+	c := &conn{
+		nodeID:    nodeID,
+		ws:        ws,
+		pending:   make(map[uint64]chan Message),
+		processes: make(map[uint]*remoteProcess),
+	}
+
+	h.mutex.Lock()
+	previous := h.conns[nodeID]
+	h.conns[nodeID] = c
+	h.mutex.Unlock()
+	if previous != nil {
+		previous.close(errors.New("node reconnected"))
+	}
+
+	slog.Info("Node connected", "node_id", nodeID, "remote_addr", r.RemoteAddr)
+	err = c.readLoop(h.report)
+
+	h.mutex.Lock()
+	if h.conns[nodeID] == c {
+		delete(h.conns, nodeID)
+	}
+	h.mutex.Unlock()
+	c.close(ErrNodeOffline)
+	slog.Info("Node disconnected", "node_id", nodeID, "error", err)
+}
+
+// Online reports whether the agent of a node is connected
+func (h *Hub) Online(nodeID uint) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, ok := h.conns[nodeID]
+	return ok
+}
+
+func (h *Hub) conn(nodeID uint) (*conn, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	c, ok := h.conns[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("node %d: %w", nodeID, ErrNodeOffline)
+	}
+	return c, nil
+}
+
+// WriteFile writes content to path on a node, replacing the file
+func (h *Hub) WriteFile(nodeID uint, path string, content io.Reader) error {
+	c, err := h.conn(nodeID)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, fileChunkSize)
+	var offset int64
+	for {
+		n, readErr := io.ReadFull(content, buf)
+		// The first chunk is sent even for empty files so they are created
+		if n > 0 || offset == 0 {
+			if _, err := c.request(Message{Type: TypeWriteFile, Path: path, Offset: offset, Data: buf[:n]}); err != nil {
+				return fmt.Errorf("failed to write %s on node %d: %w", path, nodeID, err)
+			}
+			offset += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// Runtime returns a runtime that starts servers with a NodeID through
+// their node's agent and all others with local
+func (h *Hub) Runtime(local server.Runtime) server.Runtime {
+	return routingRuntime{hub: h, local: local}
+}
+
+type routingRuntime struct {
+	hub   *Hub
+	local server.Runtime
+}
+
+func (r routingRuntime) Start(spec server.ProcessSpec) (server.Process, error) {
+	if spec.NodeID == nil {
+		return r.local.Start(spec)
+	}
+	c, err := r.hub.conn(*spec.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	return c.start(spec)
+}
+
+// PrepareCommand readies helper commands the way the local runtime does;
+// they only run in the directories of local servers
+func (r routingRuntime) PrepareCommand(cmd *exec.Cmd, serverID uint) error {
+	if preparer, ok := r.local.(server.CommandPreparer); ok {
+		return preparer.PrepareCommand(cmd, serverID)
+	}
+	return nil
+}
+
+// conn is the connection of one agent
+type conn struct {
+	nodeID     uint
+	ws         *websocket.Conn
+	writeMutex sync.Mutex
+
+	mutex     sync.Mutex
+	nextID    uint64
+	pending   map[uint64]chan Message
+	processes map[uint]*remoteProcess
+	closed    bool
+}
+
+func (c *conn) send(msg Message) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.ws.WriteJSON(msg)
+}
+
+// request sends msg and waits for the agent's result
+func (c *conn) request(msg Message) (Message, error) {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return Message{}, ErrNodeOffline
+	}
+	c.nextID++
+	msg.ID = c.nextID
+	result := make(chan Message, 1)
+	c.pending[msg.ID] = result
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, msg.ID)
+		c.mutex.Unlock()
+	}()
+
+	if err := c.send(msg); err != nil {
+		return Message{}, err
+	}
+	select {
+	case reply := <-result:
+		if reply.Error != "" {
+			return reply, errors.New(reply.Error)
+		}
+		return reply, nil
+	case <-time.After(requestTimeout):
+		return Message{}, fmt.Errorf("node %d did not answer within %s", c.nodeID, requestTimeout)
+	}
+}
+
+// readLoop dispatches agent messages until the connection fails
+func (c *conn) readLoop(report func(nodeID uint, capacity Capacity)) error {
+	for {
+		var msg Message
+		if err := c.ws.ReadJSON(&msg); err != nil {
+			return err
+		}
+		switch msg.Type {
+		case TypeCapacity:
+			if msg.Capacity != nil && report != nil {
+				report(c.nodeID, *msg.Capacity)
+			}
+		case TypeResult:
+			c.mutex.Lock()
+			result, ok := c.pending[msg.ID]
+			c.mutex.Unlock()
+			if ok {
+				deliver(result, msg)
+			}
+		case TypeStdout, TypeStderr, TypeExit:
+			c.mutex.Lock()
+			process := c.processes[msg.ServerID]
+			if msg.Type == TypeExit {
+				delete(c.processes, msg.ServerID)
+			}
+			c.mutex.Unlock()
+			if process != nil {
+				process.handle(msg)
+			}
+		default:
+			slog.Warn("Unknown message from node", "node_id", c.nodeID, "type", msg.Type)
+		}
+	}
+}
+
+// close fails pending requests and ends the processes of the connection
+func (c *conn) close(err error) {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return
+	}
+	c.closed = true
+	pending := c.pending
+	processes := c.processes
+	c.pending = make(map[uint64]chan Message)
+	c.processes = make(map[uint]*remoteProcess)
+	c.mutex.Unlock()
+
+	c.ws.Close()
+	for _, result := range pending {
+		deliver(result, Message{Type: TypeResult, Error: err.Error()})
+	}
+	for _, process := range processes {
+		process.exit(err.Error())
+	}
+}
+
+// deliver passes a result to its waiting request. Results hold one
+// message, so a duplicate is dropped rather than blocking.
+func deliver(result chan Message, msg Message) {
+	select {
+	case result <- msg:
+	default:
+	}
+}
+
+// start runs a server process on the agent
+func (c *conn) start(spec server.ProcessSpec) (server.Process, error) {
+	stdout, stdoutWriter := io.Pipe()
+	process := &remoteProcess{
+		conn:         c,
+		serverID:     spec.ServerID,
+		stdout:       stdout,
+		stdoutWriter: stdoutWriter,
+		stderr:       spec.Stderr,
+		done:         make(chan struct{}),
+	}
+
+	// Output may arrive before the result of the start request
+	c.mutex.Lock()
+	c.processes[spec.ServerID] = process
+	c.mutex.Unlock()
+
+	_, err := c.request(Message{
+		Type:     TypeStart,
+		ServerID: spec.ServerID,
+		Start: &StartRequest{
+			Dir:      spec.Dir,
+			Command:  spec.Command,
+			Env:      spec.Env,
+			MemoryMB: spec.MemoryMB,
+			Port:     spec.Port,
+		},
+	})
+	if err != nil {
+		c.mutex.Lock()
+		if c.processes[spec.ServerID] == process {
+			delete(c.processes, spec.ServerID)
+		}
+		c.mutex.Unlock()
+		return nil, fmt.Errorf("failed to start server on node %d: %w", c.nodeID, err)
+	}
+	return process, nil
+}
+
+// remoteProcess is a server process run by an agent
+type remoteProcess struct {
+	conn         *conn
+	serverID     uint
+	stdout       *io.PipeReader
+	stdoutWriter *io.PipeWriter
+	stderr       io.Writer
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+func (p *remoteProcess) handle(msg Message) {
+	switch msg.Type {
+	case TypeStdout:
+		p.stdoutWriter.Write(msg.Data)
+	case TypeStderr:
+		if p.stderr != nil {
+			p.stderr.Write(msg.Data)
+		}
+	case TypeExit:
+		p.exit(msg.Error)
+	}
+}
+
+func (p *remoteProcess) exit(errText string) {
+	p.once.Do(func() {
+		if errText != "" {
+			p.err = errors.New(errText)
+		}
+		p.stdoutWriter.Close()
+		close(p.done)
+	})
+}
+
+func (p *remoteProcess) Stdin() io.WriteCloser { return remoteStdin{p} }
+
+func (p *remoteProcess) Stdout() io.Reader { return p.stdout }
+
+func (p *remoteProcess) Wait() error {
+	<-p.done
+	return p.err
+}
+
+func (p *remoteProcess) Interrupt() error {
+	_, err := p.conn.request(Message{Type: TypeInterrupt, ServerID: p.serverID})
+	return err
+}
+
+func (p *remoteProcess) Kill() error {
+	_, err := p.conn.request(Message{Type: TypeKill, ServerID: p.serverID})
+	return err
+}
+
+// PID is unknown for remote processes, so their stats are unavailable
+func (p *remoteProcess) PID() int { return 0 }
+
+// remoteStdin forwards console input to the agent
+type remoteStdin struct {
+	process *remoteProcess
+}
+
+func (s remoteStdin) Write(data []byte) (int, error) {
+	if err := s.process.conn.send(Message{Type: TypeStdin, ServerID: s.process.serverID, Data: data}); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (s remoteStdin) Close() error { return nil }
@@ -0,0 +1,96 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// fakeRuntime starts processes that print their command and exit
+type fakeRuntime struct{}
+
+func (fakeRuntime) Start(spec server.ProcessSpec) (server.Process, error) {
+	return &fakeProcess{stdout: strings.NewReader(strings.Join(spec.Command, " ") + "\n")}, nil
+}
+
+type fakeProcess struct {
+	stdout io.Reader
+}
+
+func (p *fakeProcess) Stdin() io.WriteCloser { return nopWriteCloser{io.Discard} }
+func (p *fakeProcess) Stdout() io.Reader     { return p.stdout }
+func (p *fakeProcess) Wait() error           { return nil }
+func (p *fakeProcess) Interrupt() error      { return nil }
+func (p *fakeProcess) Kill() error           { return nil }
+func (p *fakeProcess) PID() int              { return 1 }
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestHubAgentRoundTrip(t *testing.T) {
+	hub := NewHub(func(token string) (uint, error) {
+		if token != "secret" {
+			return 0, errors.New("unknown token")
+		}
+		return 7, nil
+	}, nil)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	root := t.TempDir()
+	agent := &Agent{URL: "ws" + strings.TrimPrefix(srv.URL, "http"), Token: "secret", Runtime: fakeRuntime{}, Root: root}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agent.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !hub.Online(7) {
+		if time.Now().After(deadline) {
+			t.Fatal("agent did not connect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	jar := filepath.Join(root, "survival", "server.jar")
+	if err := hub.WriteFile(7, jar, strings.NewReader("jar")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if content, err := os.ReadFile(jar); err != nil || string(content) != "jar" {
+		t.Errorf("written file = %q, %v", content, err)
+	}
+	if err := hub.WriteFile(7, filepath.Join(root, "..", "escape"), strings.NewReader("x")); err == nil {
+		t.Error("WriteFile outside of the root succeeded")
+	}
+
+	nodeID := uint(7)
+	process, err := hub.Runtime(nil).Start(server.ProcessSpec{
+		ServerID: 1,
+		NodeID:   &nodeID,
+		Dir:      filepath.Join(root, "survival"),
+		Command:  []string{"java", "-jar", "server.jar"},
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	output, _ := io.ReadAll(process.Stdout())
+	if string(output) != "java -jar server.jar\n" {
+		t.Errorf("output = %q", output)
+	}
+	if err := process.Wait(); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+
+	otherNode := uint(8)
+	if _, err := hub.Runtime(nil).Start(server.ProcessSpec{NodeID: &otherNode}); !errors.Is(err, ErrNodeOffline) {
+		t.Errorf("Start on a disconnected node: %v, want ErrNodeOffline", err)
+	}
+}
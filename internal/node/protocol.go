@@ -0,0 +1,55 @@
+// Package node connects node agents, which run game servers on other
+// machines, to the control plane. Agents dial the control plane over a
+// WebSocket and exchange JSON messages on it.
+package node
+
+// Message types. Requests from the control plane carry an ID that the
+// agent's result echoes.
+const (
+	// Agent to control plane
+	TypeCapacity = "capacity"
+	TypeResult   = "result"
+	TypeStdout   = "stdout"
+	TypeStderr   = "stderr"
+	TypeExit     = "exit"
+
+	// Control plane to agent
+	TypeStart     = "start"
+	TypeStdin     = "stdin"
+	TypeInterrupt = "interrupt"
+	TypeKill      = "kill"
+	TypeWriteFile = "write_file"
+)
+
+// Message is one frame on the agent connection
+type Message struct {
+	Type string `json:"type"`
+	// ID pairs a request with its result
+	ID       uint64        `json:"id,omitempty"`
+	ServerID uint          `json:"server_id,omitempty"`
+	Start    *StartRequest `json:"start,omitempty"`
+	Capacity *Capacity     `json:"capacity,omitempty"`
+	// Path, Offset and Data describe a chunk of a written file; Data also
+	// carries console input and output
+	Path   string `json:"path,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+	Data   []byte `json:"data,omitempty"`
+	// Error is the failure of a request, or the exit error of a process
+	Error string `json:"error,omitempty"`
+}
+
+// StartRequest describes a server process for the agent to start
+type StartRequest struct {
+	Dir      string   `json:"dir"`
+	Command  []string `json:"command"`
+	Env      []string `json:"env,omitempty"`
+	MemoryMB int64    `json:"memory_mb"`
+	Port     int      `json:"port"`
+}
+
+// Capacity is what an agent's machine offers
+type Capacity struct {
+	CPUCores      int   `json:"cpu_cores"`
+	MemoryMB      int64 `json:"memory_mb"`
+	FreeDiskBytes int64 `json:"free_disk_bytes"`
+}
@@ -0,0 +1,278 @@
+// Package placeholder answers Minecraft clients on the port of a server that
+// is not running. Server list pings get a custom MOTD and players trying to
+// join are disconnected with a message, so the server shows up as down for
+// a reason rather than unreachable.
+package placeholder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// connTimeout bounds a whole exchange with one client
+	connTimeout = 5 * time.Second
+	// maxPacket is the largest packet accepted; handshakes, status
+	// requests and login starts are far smaller
+	maxPacket = 4096
+
+	stateStatus = 1
+	stateLogin  = 2
+	// stateTransfer is a login redirected from another server (1.20.5+)
+	stateTransfer = 3
+)
+
+// Options is what a placeholder shows
+type Options struct {
+	// MOTD is the description in the server list. It may use § codes.
+	MOTD string
+	// Version replaces the ping bars in the server list when set, in red
+	// since clients treat it as an incompatible version
+	Version string
+	// MaxPlayers is the player limit shown in the server list
+	MaxPlayers int
+	// Kick is the message players trying to join are disconnected with
+	Kick string
+	// Join, when set, is called with the name of each player trying to
+	// join. A non-empty result replaces Kick as their message.
+	Join func(player string) string
+}
+
+// Listener is a running placeholder
+type Listener struct {
+	listener net.Listener
+	options  Options
+	wg       sync.WaitGroup
+}
+
+// Listen starts a placeholder on addr, a host:port the server would bind
+func Listen(addr string, options Options) (*Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	l := &Listener{listener: listener, options: options}
+	l.wg.Add(1)
+	go l.serve()
+	return l, nil
+}
+
+// Addr returns the address the placeholder listens on
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Close stops the placeholder and waits for open connections to finish, so
+// the port is free once it returns
+func (l *Listener) Close() error {
+	err := l.listener.Close()
+	l.wg.Wait()
+	return err
+}
+
+func (l *Listener) serve() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Warn("Placeholder stopped accepting connections", "addr", l.listener.Addr(), "error", err)
+			}
+			return
+		}
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(connTimeout))
+			if err := l.handle(conn); err != nil && !errors.Is(err, io.EOF) {
+				slog.Debug("Placeholder connection failed", "remote", conn.RemoteAddr(), "error", err)
+			}
+		}()
+	}
+}
+
+// handle answers one client: a status request with the MOTD and its ping
+// with a pong, or a login with a disconnect
+func (l *Listener) handle(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	// Clients before 1.7 open with 0xFE and speak another protocol
+	if first, err := r.Peek(1); err != nil || first[0] == 0xFE {
+		return err
+	}
+
+	id, handshake, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if id != 0x00 {
+		return fmt.Errorf("expected a handshake, got packet %#x", id)
+	}
+	protocol, err := readVarInt(handshake)
+	if err != nil {
+		return err
+	}
+	if _, err := readString(handshake, 255); err != nil {
+		return err
+	}
+	var port uint16
+	if err := binary.Read(handshake, binary.BigEndian, &port); err != nil {
+		return err
+	}
+	next, err := readVarInt(handshake)
+	if err != nil {
+		return err
+	}
+
+	switch next {
+	case stateStatus:
+		return l.status(conn, r, protocol)
+	case stateLogin, stateTransfer:
+		return l.login(conn, r)
+	}
+	return fmt.Errorf("unknown next state %d", next)
+}
+
+func (l *Listener) status(w io.Writer, r *bufio.Reader, protocol int32) error {
+	if id, _, err := readPacket(r); err != nil || id != 0x00 {
+		return err
+	}
+
+	version := map[string]interface{}{"name": "Offline", "protocol": protocol}
+	if l.options.Version != "" {
+		version = map[string]interface{}{"name": l.options.Version, "protocol": -1}
+	}
+	response, err := json.Marshal(map[string]interface{}{
+		"version":     version,
+		"players":     map[string]int{"max": l.options.MaxPlayers, "online": 0},
+		"description": map[string]string{"text": l.options.MOTD},
+	})
+	if err != nil {
+		return err
+	}
+	if err := writePacket(w, 0x00, appendString(nil, string(response))); err != nil {
+		return err
+	}
+
+	// The ping carries a number to echo back
+	id, ping, err := readPacket(r)
+	if err != nil || id != 0x01 {
+		return err
+	}
+	payload, err := io.ReadAll(ping)
+	if err != nil {
+		return err
+	}
+	return writePacket(w, 0x01, payload)
+}
+
+func (l *Listener) login(w io.Writer, r *bufio.Reader) error {
+	id, start, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if id != 0x00 {
+		return fmt.Errorf("expected a login start, got packet %#x", id)
+	}
+	player, err := readString(start, 16)
+	if err != nil {
+		return err
+	}
+	slog.Debug("Placeholder turned away a player", "player", player)
+
+	message := l.options.Kick
+	if l.options.Join != nil {
+		if joined := l.options.Join(player); joined != "" {
+			message = joined
+		}
+	}
+	reason, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return err
+	}
+	return writePacket(w, 0x00, appendString(nil, string(reason)))
+}
+
+// readPacket reads a length prefixed packet and returns its ID and payload
+func readPacket(r *bufio.Reader) (int32, *bytes.Reader, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length <= 0 || length > maxPacket {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	payload := bytes.NewReader(data)
+	id, err := readVarInt(payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, payload, nil
+}
+
+func writePacket(w io.Writer, id int32, payload []byte) error {
+	body := append(appendVarInt(nil, id), payload...)
+	_, err := w.Write(append(appendVarInt(nil, int32(len(body))), body...))
+	return err
+}
+
+// readVarInt reads a protocol VarInt: seven bits a byte, least significant
+// first, with the high bit marking that more follow
+func readVarInt(r io.ByteReader) (int32, error) {
+	var value uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(value), nil
+		}
+	}
+	return 0, errors.New("VarInt is too long")
+}
+
+func appendVarInt(buf []byte, value int32) []byte {
+	v := uint32(value)
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+// readString reads a VarInt length prefixed UTF-8 string of at most max
+// characters
+func readString(r *bytes.Reader, max int) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	// Characters take up to four bytes
+	if length < 0 || int(length) > max*4 || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	return append(appendVarInt(buf, int32(len(s))), s...)
+}
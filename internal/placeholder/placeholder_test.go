@@ -0,0 +1,133 @@
+package placeholder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+// handshake returns a handshake packet for the given next state
+func handshake(next int32) []byte {
+	var payload []byte
+	payload = appendVarInt(payload, 767)
+	payload = appendString(payload, "localhost")
+	payload = binary.BigEndian.AppendUint16(payload, 25565)
+	payload = appendVarInt(payload, next)
+	var packet bytes.Buffer
+	writePacket(&packet, 0x00, payload)
+	return packet.Bytes()
+}
+
+func dial(t *testing.T, l *Listener) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, bufio.NewReader(conn)
+}
+
+func TestStatusAndPing(t *testing.T) {
+	l, err := Listen("127.0.0.1:0", Options{MOTD: "Down for maintenance", Version: "Maintenance", MaxPlayers: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	conn, r := dial(t, l)
+	conn.Write(handshake(stateStatus))
+	writePacket(conn, 0x00, nil)
+
+	id, payload, err := readPacket(r)
+	if err != nil || id != 0x00 {
+		t.Fatalf("status response: packet %#x, %v", id, err)
+	}
+	data, err := readString(payload, maxPacket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status struct {
+		Version struct {
+			Name     string `json:"name"`
+			Protocol int    `json:"protocol"`
+		} `json:"version"`
+		Players struct {
+			Max int `json:"max"`
+		} `json:"players"`
+		Description struct {
+			Text string `json:"text"`
+		} `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Description.Text != "Down for maintenance" || status.Version.Name != "Maintenance" ||
+		status.Version.Protocol != -1 || status.Players.Max != 20 {
+		t.Errorf("unexpected status %s", data)
+	}
+
+	writePacket(conn, 0x01, binary.BigEndian.AppendUint64(nil, 42))
+	id, payload, err = readPacket(r)
+	if err != nil || id != 0x01 {
+		t.Fatalf("pong: packet %#x, %v", id, err)
+	}
+	var pong uint64
+	if binary.Read(payload, binary.BigEndian, &pong); pong != 42 {
+		t.Errorf("pong carried %d, want 42", pong)
+	}
+}
+
+func TestLoginIsDisconnected(t *testing.T) {
+	l, err := Listen("127.0.0.1:0", Options{Kick: "Back at 18:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	conn, r := dial(t, l)
+	conn.Write(handshake(stateLogin))
+	writePacket(conn, 0x00, appendString(nil, "Steve"))
+
+	id, payload, err := readPacket(r)
+	if err != nil || id != 0x00 {
+		t.Fatalf("disconnect: packet %#x, %v", id, err)
+	}
+	reason, err := readString(payload, maxPacket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason != `{"text":"Back at 18:00"}` {
+		t.Errorf("got reason %s", reason)
+	}
+}
+
+func TestJoinReplacesKick(t *testing.T) {
+	joined := make(chan string, 1)
+	l, err := Listen("127.0.0.1:0", Options{Kick: "Server is offline", Join: func(player string) string {
+		joined <- player
+		return "Server is starting"
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	conn, r := dial(t, l)
+	conn.Write(handshake(stateLogin))
+	writePacket(conn, 0x00, appendString(nil, "Alex"))
+
+	_, payload, err := readPacket(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason, _ := readString(payload, maxPacket); reason != `{"text":"Server is starting"}` {
+		t.Errorf("got reason %s", reason)
+	}
+	if player := <-joined; player != "Alex" {
+		t.Errorf("Join got %q, want Alex", player)
+	}
+}
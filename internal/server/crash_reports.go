@@ -0,0 +1,51 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// CrashReportsDir is where the game writes crash reports, relative to the
+// server directory
+const CrashReportsDir = "crash-reports"
+
+// NewCrashReports returns the crash reports in the server directory dir
+// written since, relative to dir and oldest first. A crash-reports link
+// placed by the server is not followed.
+func NewCrashReports(dir string, since time.Time) []string {
+	reportsDir, err := utils.OpenIn(dir, CrashReportsDir, os.O_RDONLY, 0)
+	if err != nil {
+		return nil
+	}
+	defer reportsDir.Close()
+	entries, err := reportsDir.ReadDir(-1)
+	if err != nil {
+		return nil
+	}
+	type report struct {
+		path    string
+		modTime time.Time
+	}
+	var reports []report
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		reports = append(reports, report{filepath.Join(CrashReportsDir, entry.Name()), info.ModTime()})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].modTime.Before(reports[j].modTime) })
+
+	paths := make([]string, len(reports))
+	for i, r := range reports {
+		paths[i] = r.path
+	}
+	return paths
+}
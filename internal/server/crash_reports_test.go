@@ -0,0 +1,51 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewCrashReports(t *testing.T) {
+	dir := t.TempDir()
+	reports := filepath.Join(dir, CrashReportsDir)
+	if err := os.MkdirAll(reports, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now().Add(-time.Minute)
+	for name, modTime := range map[string]time.Time{
+		"crash-old-server.txt":    started.Add(-time.Hour),
+		"crash-second-server.txt": started.Add(20 * time.Second),
+		"crash-first-server.txt":  started.Add(10 * time.Second),
+	} {
+		path := filepath.Join(reports, name)
+		if err := os.WriteFile(path, []byte("---- Minecraft Crash Report ----"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		filepath.Join(CrashReportsDir, "crash-first-server.txt"),
+		filepath.Join(CrashReportsDir, "crash-second-server.txt"),
+	}
+	if got := NewCrashReports(dir, started); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewCrashReports = %v, want %v", got, want)
+	}
+	if got := NewCrashReports(t.TempDir(), started); got != nil {
+		t.Errorf("NewCrashReports without crash-reports = %v", got)
+	}
+
+	// A crash-reports link to another directory is not followed
+	linked := t.TempDir()
+	if err := os.Symlink(reports, filepath.Join(linked, CrashReportsDir)); err != nil {
+		t.Fatal(err)
+	}
+	if got := NewCrashReports(linked, started); got != nil {
+		t.Errorf("NewCrashReports through a link = %v", got)
+	}
+}
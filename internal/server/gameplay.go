@@ -0,0 +1,149 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/olindenbaum/mcgonalds/internal/nbt"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// Difficulties in the order of their level.dat values
+var Difficulties = []string{"peaceful", "easy", "normal", "hard"}
+
+// GameModes are the values of the gamemode property
+var GameModes = []string{"survival", "creative", "adventure", "spectator"}
+
+// Types of game rule values
+const (
+	GameRuleBool = "bool"
+	GameRuleInt  = "int"
+)
+
+// GameRules are the vanilla game rules and their value types
+var GameRules = map[string]string{
+	"announceAdvancements":             GameRuleBool,
+	"blockExplosionDropDecay":          GameRuleBool,
+	"commandBlockOutput":               GameRuleBool,
+	"commandModificationBlockLimit":    GameRuleInt,
+	"disableElytraMovementCheck":       GameRuleBool,
+	"disableRaids":                     GameRuleBool,
+	"doDaylightCycle":                  GameRuleBool,
+	"doEntityDrops":                    GameRuleBool,
+	"doFireTick":                       GameRuleBool,
+	"doImmediateRespawn":               GameRuleBool,
+	"doInsomnia":                       GameRuleBool,
+	"doLimitedCrafting":                GameRuleBool,
+	"doMobLoot":                        GameRuleBool,
+	"doMobSpawning":                    GameRuleBool,
+	"doPatrolSpawning":                 GameRuleBool,
+	"doTileDrops":                      GameRuleBool,
+	"doTraderSpawning":                 GameRuleBool,
+	"doVinesSpread":                    GameRuleBool,
+	"doWardenSpawning":                 GameRuleBool,
+	"doWeatherCycle":                   GameRuleBool,
+	"drowningDamage":                   GameRuleBool,
+	"fallDamage":                       GameRuleBool,
+	"fireDamage":                       GameRuleBool,
+	"forgiveDeadPlayers":               GameRuleBool,
+	"freezeDamage":                     GameRuleBool,
+	"globalSoundEvents":                GameRuleBool,
+	"keepInventory":                    GameRuleBool,
+	"lavaSourceConversion":             GameRuleBool,
+	"logAdminCommands":                 GameRuleBool,
+	"maxCommandChainLength":            GameRuleInt,
+	"maxCommandForkCount":              GameRuleInt,
+	"maxEntityCramming":                GameRuleInt,
+	"mobExplosionDropDecay":            GameRuleBool,
+	"mobGriefing":                      GameRuleBool,
+	"naturalRegeneration":              GameRuleBool,
+	"playersNetherPortalCreativeDelay": GameRuleInt,
+	"playersNetherPortalDefaultDelay":  GameRuleInt,
+	"playersSleepingPercentage":        GameRuleInt,
+	"randomTickSpeed":                  GameRuleInt,
+	"reducedDebugInfo":                 GameRuleBool,
+	"sendCommandFeedback":              GameRuleBool,
+	"showDeathMessages":                GameRuleBool,
+	"snowAccumulationHeight":           GameRuleInt,
+	"spawnChunkRadius":                 GameRuleInt,
+	"spawnRadius":                      GameRuleInt,
+	"spectatorsGenerateChunks":         GameRuleBool,
+	"tntExplosionDropDecay":            GameRuleBool,
+	"universalAnger":                   GameRuleBool,
+	"waterSourceConversion":            GameRuleBool,
+}
+
+// GameRuleValue checks value against the type of a game rule and returns it
+// the way commands and level.dat spell it
+func GameRuleValue(rule string, value interface{}) (string, error) {
+	ruleType, ok := GameRules[rule]
+	if !ok {
+		return "", fmt.Errorf("unknown game rule %q", rule)
+	}
+	switch ruleType {
+	case GameRuleBool:
+		if v, ok := value.(bool); ok {
+			return strconv.FormatBool(v), nil
+		}
+		return "", fmt.Errorf("game rule %s takes true or false", rule)
+	default:
+		// JSON numbers decode as float64
+		if v, ok := value.(float64); ok && v == float64(int32(v)) {
+			return strconv.Itoa(int(v)), nil
+		}
+		return "", fmt.Errorf("game rule %s takes an integer", rule)
+	}
+}
+
+// SetGameplayProperties writes difficulty, gamemode and pvp to
+// server.properties. Empty strings and a nil pvp are left unchanged.
+func SetGameplayProperties(serverPath, difficulty, gameMode string, pvp *bool) error {
+	path := filepath.Join(serverPath, "server.properties")
+	properties := map[string]string{"difficulty": difficulty, "gamemode": gameMode}
+	if pvp != nil {
+		properties["pvp"] = strconv.FormatBool(*pvp)
+	}
+	for _, key := range []string{"difficulty", "gamemode", "pvp"} {
+		if properties[key] == "" {
+			continue
+		}
+		if err := utils.SetProperty(path, key, properties[key]); err != nil {
+			return fmt.Errorf("failed to update server.properties: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetLevelGameplay writes the difficulty and game rules of the world level
+// of the server in serverPath to its level.dat. Rule values must come from
+// GameRuleValue.
+func SetLevelGameplay(serverPath, level, difficulty string, rules map[string]string) error {
+	err := nbt.EditFile(serverPath, filepath.Join(level, "level.dat"), func(root *nbt.Compound) error {
+		data, ok := root.Compound("Data")
+		if !ok {
+			return fmt.Errorf("level.dat has no Data compound")
+		}
+		for i, name := range Difficulties {
+			if name == difficulty {
+				data.Set("Difficulty", nbt.TagByte, int8(i))
+			}
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		gameRules, ok := data.Compound("GameRules")
+		if !ok {
+			gameRules = &nbt.Compound{}
+			data.Set("GameRules", nbt.TagCompound, gameRules)
+		}
+		for rule, value := range rules {
+			gameRules.Set(rule, nbt.TagString, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update level.dat: %w", err)
+	}
+	return nil
+}
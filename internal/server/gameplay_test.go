@@ -0,0 +1,53 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+func TestGameRuleValue(t *testing.T) {
+	tests := []struct {
+		rule    string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{rule: "keepInventory", value: true, want: "true"},
+		{rule: "randomTickSpeed", value: float64(3), want: "3"},
+		{rule: "keepInventory", value: float64(1), wantErr: true},
+		{rule: "randomTickSpeed", value: 2.5, wantErr: true},
+		{rule: "randomTickSpeed", value: "3", wantErr: true},
+		{rule: "noSuchRule", value: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := GameRuleValue(tt.rule, tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("GameRuleValue(%s, %v) = %q, %v", tt.rule, tt.value, got, err)
+		}
+	}
+}
+
+func TestSetGameplayProperties(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.properties")
+	if err := os.WriteFile(path, []byte("difficulty=easy\npvp=true\nmotd=hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pvp := false
+	if err := SetGameplayProperties(dir, "hard", "", &pvp); err != nil {
+		t.Fatal(err)
+	}
+	properties, err := utils.ReadProperties(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if properties["difficulty"] != "hard" || properties["pvp"] != "false" || properties["motd"] != "hi" {
+		t.Errorf("got properties %v", properties)
+	}
+	if _, ok := properties["gamemode"]; ok {
+		t.Error("unchanged gamemode was written")
+	}
+}
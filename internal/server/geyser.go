@@ -0,0 +1,76 @@
+package server
+
+import (
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBedrockPort is the UDP port Bedrock clients connect to by default
+const DefaultBedrockPort = 19132
+
+// Plugin platforms of Geyser and Floodgate builds
+const (
+	PlatformSpigot     = "spigot"
+	PlatformVelocity   = "velocity"
+	PlatformBungeeCord = "bungeecord"
+)
+
+// geyserDataDirs are the plugin data directories of Geyser per platform
+var geyserDataDirs = map[string]string{
+	PlatformSpigot:     "Geyser-Spigot",
+	PlatformVelocity:   "geyser",
+	PlatformBungeeCord: "Geyser-BungeeCord",
+}
+
+// PluginPlatform returns the plugin platform of a server: its proxy type
+// for proxies, spigot (which Paper runs as well) otherwise
+func PluginPlatform(serverModel *model.Server) string {
+	switch serverModel.ProxyType {
+	case model.ProxyTypeVelocity:
+		return PlatformVelocity
+	case model.ProxyTypeBungeeCord:
+		return PlatformBungeeCord
+	default:
+		return PlatformSpigot
+	}
+}
+
+// ConfigureGeyser writes the Geyser settings the manager owns: Bedrock
+// players connect on bedrockPort and are authenticated through Floodgate,
+// so they need no Java account. Geyser fills in the rest on first start.
+func ConfigureGeyser(serverPath, platform string, bedrockPort int) error {
+	name := filepath.Join("plugins", geyserDataDirs[platform], "config.yml")
+	return editYAML(serverPath, name, func(root *yaml.Node) error {
+		if err := setYAMLKey(root, bedrockPort, "bedrock", "port"); err != nil {
+			return err
+		}
+		// The Java port is TCP and taken by the server itself
+		if err := setYAMLKey(root, false, "bedrock", "clone-remote-port"); err != nil {
+			return err
+		}
+		return setYAMLKey(root, "floodgate", "remote", "auth-type")
+	})
+}
+
+// BedrockPort returns the UDP port Geyser listens on in the server, zero
+// if Geyser is not configured
+func BedrockPort(serverPath string) int {
+	for _, dir := range geyserDataDirs {
+		data, err := utils.ReadFileIn(serverPath, filepath.Join("plugins", dir, "config.yml"))
+		if err != nil {
+			continue
+		}
+		var config struct {
+			Bedrock struct {
+				Port int `yaml:"port"`
+			} `yaml:"bedrock"`
+		}
+		if yaml.Unmarshal(data, &config) == nil && config.Bedrock.Port > 0 {
+			return config.Bedrock.Port
+		}
+	}
+	return 0
+}
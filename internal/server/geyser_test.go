@@ -0,0 +1,41 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigureGeyser(t *testing.T) {
+	dir := t.TempDir()
+	if BedrockPort(dir) != 0 {
+		t.Fatal("bedrock port reported without geyser")
+	}
+	config := filepath.Join(dir, "plugins", "Geyser-Spigot", "config.yml")
+	if err := os.MkdirAll(filepath.Dir(config), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	original := "bedrock:\n  # The port Bedrock players connect to\n  port: 19132\n  motd1: Geyser\nremote:\n  auth-type: online\n"
+	if err := os.WriteFile(config, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConfigureGeyser(dir, PlatformSpigot, 19133); err != nil {
+		t.Fatal(err)
+	}
+	if port := BedrockPort(dir); port != 19133 {
+		t.Errorf("BedrockPort = %d, want 19133", port)
+	}
+	data, _ := os.ReadFile(config)
+	for _, want := range []string{"# The port Bedrock players connect to", "motd1: Geyser", "clone-remote-port: false", "auth-type: floodgate"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config.yml lacks %q:\n%s", want, data)
+		}
+	}
+
+	args := strings.Join((&DockerRuntime{Image: "java"}).runArgs("mc", dir, ProcessSpec{Port: 25565, BedrockPort: 19133}), " ")
+	if !strings.Contains(args, "--publish 19133:19133/udp") {
+		t.Errorf("docker run args %q do not publish the bedrock port", args)
+	}
+}
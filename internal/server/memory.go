@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	xmxFlag = regexp.MustCompile(`-Xmx(\d+)([kKmMgG]?)`)
+	xmsFlag = regexp.MustCompile(`-Xms(\d+)([kKmMgG]?)`)
+)
+
+// CommandMemoryMB returns the maximum heap in megabytes requested by the
+// -Xmx flag of an executable command, or zero when none is set
+func CommandMemoryMB(command string) int64 {
+	return flagMemoryMB(xmxFlag, command)
+}
+
+// CommandMinMemoryMB returns the initial heap in megabytes requested by the
+// -Xms flag of an executable command, or zero when none is set
+func CommandMinMemoryMB(command string) int64 {
+	return flagMemoryMB(xmsFlag, command)
+}
+
+// SetCommandMemory rewrites the -Xms and -Xmx flags of an executable
+// command. Flags missing from the command are added after the executable;
+// a zero size leaves its flag as it is.
+func SetCommandMemory(command string, minMB, maxMB int64) string {
+	command = setMemoryFlag(command, xmxFlag, "-Xmx", maxMB)
+	return setMemoryFlag(command, xmsFlag, "-Xms", minMB)
+}
+
+func setMemoryFlag(command string, flag *regexp.Regexp, name string, mb int64) string {
+	if mb <= 0 {
+		return command
+	}
+	value := fmt.Sprintf("%s%dM", name, mb)
+	if flag.MatchString(command) {
+		return flag.ReplaceAllLiteralString(command, value)
+	}
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return command
+	}
+	return strings.Join(append([]string{parts[0], value}, parts[1:]...), " ")
+}
+
+func flagMemoryMB(flag *regexp.Regexp, command string) int64 {
+	match := flag.FindStringSubmatch(command)
+	if match == nil {
+		return 0
+	}
+	value, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToLower(match[2]) {
+	case "g":
+		return value * 1024
+	case "m":
+		return value
+	case "k":
+		return value / 1024
+	default:
+		return value / (1024 * 1024)
+	}
+}
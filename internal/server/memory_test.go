@@ -0,0 +1,44 @@
+package server
+
+import "testing"
+
+func TestSetCommandMemory(t *testing.T) {
+	tests := []struct {
+		name         string
+		command      string
+		minMB, maxMB int64
+		want         string
+	}{
+		{
+			name:    "replace",
+			command: "java -Xms1G -Xmx4G -jar server.jar nogui",
+			minMB:   2048,
+			maxMB:   6144,
+			want:    "java -Xms2048M -Xmx6144M -jar server.jar nogui",
+		},
+		{
+			name:    "add",
+			command: "java -jar server.jar nogui",
+			minMB:   1024,
+			maxMB:   2048,
+			want:    "java -Xms1024M -Xmx2048M -jar server.jar nogui",
+		},
+		{
+			name:    "keep unset",
+			command: "java -Xms512M -Xmx4G -jar server.jar nogui",
+			maxMB:   8192,
+			want:    "java -Xms512M -Xmx8192M -jar server.jar nogui",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SetCommandMemory(tt.command, tt.minMB, tt.maxMB)
+			if got != tt.want {
+				t.Fatalf("SetCommandMemory() = %q, want %q", got, tt.want)
+			}
+			if CommandMemoryMB(got) != CommandMemoryMB(tt.want) || CommandMinMemoryMB(got) != CommandMinMemoryMB(tt.want) {
+				t.Fatalf("memory of %q does not round trip", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// IsOfflineMode reports whether server.properties disables Mojang authentication
+func IsOfflineMode(serverPath string) bool {
+	properties, err := utils.ReadProperties(filepath.Join(serverPath, "server.properties"))
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(properties["online-mode"], "false")
+}
+
+// OfflineModeWarning returns a warning when the server runs with online-mode=false
+func OfflineModeWarning(serverPath string) string {
+	if !IsOfflineMode(serverPath) {
+		return ""
+	}
+	return "online-mode is disabled: anyone can join with any username unless the server sits behind an authenticating proxy"
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+var (
+	// [..] [Server thread/INFO]: Steve joined the game
+	joinedLine = regexp.MustCompile(`\]: ([A-Za-z0-9_]{1,16}) joined the game$`)
+	// [..] [Server thread/INFO]: Steve left the game
+	leftLine = regexp.MustCompile(`\]: ([A-Za-z0-9_]{1,16}) left the game$`)
+	// Paper and Spigot: TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0. The
+	// values may carry colour codes and a leading * when capped.
+	tpsLine = regexp.MustCompile(`TPS from last 1m, 5m, 15m: \D*(\d+(?:\.\d+)?)`)
+	// Game servers and Velocity log Done (3.2s)! once loaded, BungeeCord
+	// logs Listening on /0.0.0.0:25577
+	readyLine = regexp.MustCompile(`\]: (Done \(\d|Listening on /)`)
+)
+
+// trackConsole updates the online players, last reported TPS and
+// pre-generation progress from a console line
+func (s *Server) trackConsole(line string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.starting && readyLine.MatchString(line) {
+		s.markReady()
+		return
+	}
+	if match := joinedLine.FindStringSubmatch(line); match != nil {
+		s.online[match[1]] = struct{}{}
+		s.emit(model.EventPlayerJoined, map[string]string{"player": match[1]})
+	} else if match := leftLine.FindStringSubmatch(line); match != nil {
+		delete(s.online, match[1])
+		s.emit(model.EventPlayerLeft, map[string]string{"player": match[1]})
+	} else if match := tpsLine.FindStringSubmatch(line); match != nil {
+		if tps, err := strconv.ParseFloat(match[1], 64); err == nil {
+			s.tps = tps
+			s.tpsAt = time.Now()
+		}
+	} else {
+		s.trackPregen(line)
+	}
+}
+
+// PlayerCount returns the number of players online according to the join
+// and leave messages seen since the server started
+func (s *Server) PlayerCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.online)
+}
+
+// OnlinePlayers returns the names of the players online, sorted
+func (s *Server) OnlinePlayers() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	players := make([]string, 0, len(s.online))
+	for name := range s.online {
+		players = append(players, name)
+	}
+	sort.Strings(players)
+	return players
+}
+
+// LastTPS returns the ticks per second last reported on the console and when
+// it was reported. Only Paper-based servers print TPS, in reply to the tps
+// command; the time is zero when none has been seen since the server started.
+func (s *Server) LastTPS() (float64, time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.tps, s.tpsAt
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestTrackConsole(t *testing.T) {
+	s := NewServer(nil)
+	for _, line := range []string{
+		"[2024-11-10T12:00:00Z] [Server thread/INFO]: Steve joined the game",
+		"[2024-11-10T12:00:01Z] [Server thread/INFO]: Alex joined the game",
+		"[2024-11-10T12:00:02Z] [Server thread/INFO]: <Steve> Alex joined the game",
+		"[2024-11-10T12:00:03Z] [Server thread/INFO]: Steve left the game",
+		"[2024-11-10T12:00:04Z INFO]: TPS from last 1m, 5m, 15m: *19.5, 20.0, 20.0",
+	} {
+		s.trackConsole(line)
+	}
+
+	if got := s.PlayerCount(); got != 1 {
+		t.Errorf("got %d players online, want 1", got)
+	}
+	if tps, at := s.LastTPS(); tps != 19.5 || at.IsZero() {
+		t.Errorf("got TPS %v at %v, want 19.5", tps, at)
+	}
+}
+
+func TestTrackConsoleReady(t *testing.T) {
+	s := NewServer(&model.Server{})
+	s.isRunning, s.starting, s.ready = true, true, make(chan struct{})
+
+	s.trackConsole("[12:00:00] [Server thread/INFO]: Preparing spawn area: 83%")
+	select {
+	case <-s.Ready():
+		t.Fatal("ready before the server finished starting")
+	default:
+	}
+
+	s.trackConsole(`[12:00:05] [Server thread/INFO]: Done (5.012s)! For help, type "help"`)
+	select {
+	case <-s.Ready():
+	default:
+		t.Fatal("not ready after Done")
+	}
+	if s.model.Status != model.ServerStatusRunning {
+		t.Errorf("got status %q, want running", s.model.Status)
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// DefaultPort is the game port of servers that do not set server-port
+const DefaultPort = 25565
+
+// Port reads server-port from server.properties, falling back to the default
+func Port(serverPath string) int {
+	properties, err := utils.ReadProperties(filepath.Join(serverPath, "server.properties"))
+	if err != nil {
+		return DefaultPort
+	}
+	if port, err := strconv.Atoi(properties["server-port"]); err == nil {
+		return port
+	}
+	return DefaultPort
+}
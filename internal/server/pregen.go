@@ -0,0 +1,71 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chunkyLine matches the progress Chunky reports on the console:
+// [Chunky] Task running for world. Processed: 1234 chunks (5.67%), ETA: ...
+// [Chunky] Task finished for world. Processed: 40401 chunks (100.00%), ...
+var chunkyLine = regexp.MustCompile(`\[Chunky\] Task (running|finished) for (\S+?)\. Processed: (\d+) chunks \((\d+(?:\.\d+)?)%\)`)
+
+// PregenProgress is the progress of a Chunky pre-generation task last
+// reported on the console
+type PregenProgress struct {
+	Chunks   int64
+	Percent  float64
+	Finished bool
+	At       time.Time
+}
+
+// trackPregen records Chunky progress from a console line. The caller must
+// hold the mutex.
+func (s *Server) trackPregen(line string) {
+	match := chunkyLine.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	chunks, _ := strconv.ParseInt(match[3], 10, 64)
+	percent, _ := strconv.ParseFloat(match[4], 64)
+	if s.pregen == nil {
+		s.pregen = make(map[string]PregenProgress)
+	}
+	s.pregen[match[2]] = PregenProgress{
+		Chunks:   chunks,
+		Percent:  percent,
+		Finished: match[1] == "finished",
+		At:       time.Now(),
+	}
+}
+
+// PregenProgress returns the progress of the Chunky task of a world
+// reported since the server started
+func (s *Server) PregenProgress(world string) (PregenProgress, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	progress, ok := s.pregen[world]
+	return progress, ok
+}
+
+// ChunkyInstalled reports whether the server in dir has the Chunky plugin
+// or mod
+func ChunkyInstalled(dir string) bool {
+	for _, sub := range []string{"plugins", "mods"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.ToLower(entry.Name())
+			if strings.HasPrefix(name, "chunky") && !strings.HasPrefix(name, "chunkyborder") && strings.HasSuffix(name, ".jar") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrackPregen(t *testing.T) {
+	s := NewServer(nil)
+	for _, line := range []string{
+		"[12:00:00 INFO]: [Chunky] Task running for world. Processed: 1234 chunks (5.67%), ETA: 0:12:34, Rate: 80.2 cps, Current: 12, -4",
+		"[12:00:05 INFO]: [Chunky] Task running for world_nether. Processed: 10 chunks (0.10%), ETA: 1:00:00, Rate: 2.0 cps, Current: 0, 0",
+		"[12:00:10 INFO]: [Chunky] Task finished for world_nether. Processed: 9801 chunks (100.00%), Total time: 0:45:10",
+	} {
+		s.trackConsole(line)
+	}
+
+	if progress, ok := s.PregenProgress("world"); !ok || progress.Chunks != 1234 || progress.Percent != 5.67 || progress.Finished {
+		t.Errorf("got world progress %+v, want 1234 chunks at 5.67%%", progress)
+	}
+	if progress, ok := s.PregenProgress("world_nether"); !ok || progress.Chunks != 9801 || !progress.Finished {
+		t.Errorf("got world_nether progress %+v, want 9801 chunks finished", progress)
+	}
+	if _, ok := s.PregenProgress("world_the_end"); ok {
+		t.Error("got progress for a world without a task")
+	}
+}
+
+func TestChunkyInstalled(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plugins", "ChunkyBorder-1.2.jar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ChunkyInstalled(dir) {
+		t.Error("ChunkyBorder alone reported as Chunky")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plugins", "Chunky-Bukkit-1.4.10.jar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !ChunkyInstalled(dir) {
+		t.Error("Chunky plugin not detected")
+	}
+}
@@ -0,0 +1,104 @@
+//go:build linux || darwin || freebsd
+
+package server
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// procAttr runs the server in its own process group, so signals sent to
+// the manager from a terminal do not reach it and the manager decides
+// whether it is stopped on shutdown
+func procAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processGroup needs no handles here, the process is signalled directly
+type processGroup struct{}
+
+func newProcessGroup(process *os.Process) (processGroup, error) {
+	return processGroup{}, nil
+}
+
+// interrupt sends SIGINT, which servers handle like the stop command
+func (processGroup) interrupt(p *commandProcess) error {
+	return p.cmd.Process.Signal(os.Interrupt)
+}
+
+func (processGroup) kill(process *os.Process) error {
+	return process.Kill()
+}
+
+func (processGroup) close() {}
+
+// checkIsolation fails unless the manager may switch users
+func checkIsolation() error {
+	if os.Geteuid() != 0 {
+		return errors.New("runtime.process.isolate_users requires running as root")
+	}
+	return nil
+}
+
+// runAs makes cmd run as uid and gid without supplementary groups
+func runAs(cmd *exec.Cmd, uid, gid int) {
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: []uint32{}}
+}
+
+// isolateDir gives dir and everything in it to uid and closes the directory
+// to everyone else. Links into the shared directory are changed, not their
+// targets. The tree is walked through directory handles that refuse
+// symlinks, so a server replacing a directory with a link while it is walked
+// cannot redirect the chown outside dir.
+func isolateDir(dir string, uid, gid int) error {
+	fd, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: dir, Err: err}
+	}
+	root := os.NewFile(uintptr(fd), dir)
+	defer root.Close()
+	if err := chownTree(root, uid, gid); err != nil {
+		return err
+	}
+	if err := unix.Fchown(fd, uid, gid); err != nil {
+		return &os.PathError{Op: "chown", Path: dir, Err: err}
+	}
+	if err := unix.Fchmod(fd, 0o700); err != nil {
+		return &os.PathError{Op: "chmod", Path: dir, Err: err}
+	}
+	return nil
+}
+
+// chownTree gives everything below the open directory dir to uid
+func chownTree(dir *os.File, uid, gid int) error {
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return err
+	}
+	dirfd := int(dir.Fd())
+	for _, entry := range entries {
+		path := filepath.Join(dir.Name(), entry.Name())
+		if err := unix.Fchownat(dirfd, entry.Name(), uid, gid, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+			return &os.PathError{Op: "chown", Path: path, Err: err}
+		}
+		if !entry.IsDir() {
+			continue
+		}
+		fd, err := unix.Openat(dirfd, entry.Name(), unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return &os.PathError{Op: "open", Path: path, Err: err}
+		}
+		child := os.NewFile(uintptr(fd), path)
+		err = chownTree(child, uid, gid)
+		child.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
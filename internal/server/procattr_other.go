@@ -0,0 +1,43 @@
+//go:build !(linux || darwin || freebsd || windows)
+
+package server
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// procAttr uses the default process attributes on this platform
+func procAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+type processGroup struct{}
+
+func newProcessGroup(process *os.Process) (processGroup, error) {
+	return processGroup{}, nil
+}
+
+func (processGroup) interrupt(p *commandProcess) error {
+	return p.cmd.Process.Signal(os.Interrupt)
+}
+
+func (processGroup) kill(process *os.Process) error {
+	return process.Kill()
+}
+
+func (processGroup) close() {}
+
+// checkIsolation fails, as servers cannot run as other users on this
+// platform
+func checkIsolation() error {
+	return errors.New("runtime.process.isolate_users is not supported on this platform")
+}
+
+func runAs(cmd *exec.Cmd, uid, gid int) {}
+
+func isolateDir(dir string, uid, gid int) error {
+	return errors.New("user isolation is not supported on this platform")
+}
@@ -0,0 +1,100 @@
+//go:build windows
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// procAttr starts the server in its own process group without a console
+// window, so Ctrl+C in the manager's console does not reach it and the
+// manager decides whether it is stopped on shutdown
+func procAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.CREATE_NO_WINDOW,
+	}
+}
+
+// processGroup holds the job object the server runs in. Processes the
+// server starts, such as java under a start.bat, join the job, and closing
+// it terminates all of them, including when the manager itself exits.
+type processGroup struct {
+	job windows.Handle
+}
+
+func newProcessGroup(process *os.Process) (processGroup, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return processGroup{}, err
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{}
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	_, err = windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		windows.CloseHandle(job)
+		return processGroup{}, err
+	}
+
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return processGroup{}, err
+	}
+	defer windows.CloseHandle(handle)
+	if err := windows.AssignProcessToJobObject(job, handle); err != nil {
+		windows.CloseHandle(job)
+		return processGroup{}, err
+	}
+	return processGroup{job: job}, nil
+}
+
+// interrupt sends the stop command. Signals cannot be delivered to child
+// consoles here, and the JVM answers CTRL_BREAK with a thread dump instead
+// of shutting down, so the console is the only graceful way.
+func (processGroup) interrupt(p *commandProcess) error {
+	if _, err := io.WriteString(p.stdin, "stop\n"); err != nil {
+		return fmt.Errorf("failed to send stop command: %w", err)
+	}
+	return nil
+}
+
+// kill terminates every process in the job, or the process tree through
+// taskkill when the server could not be assigned a job
+func (g processGroup) kill(process *os.Process) error {
+	if g.job != 0 {
+		return windows.TerminateJobObject(g.job, 1)
+	}
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid)).Run(); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
+
+// close releases the job once the server has exited
+func (g processGroup) close() {
+	if g.job != 0 {
+		windows.CloseHandle(g.job)
+	}
+}
+
+// checkIsolation fails, as servers cannot run as other users on this
+// platform
+func checkIsolation() error {
+	return errors.New("runtime.process.isolate_users is not supported on this platform")
+}
+
+func runAs(cmd *exec.Cmd, uid, gid int) {}
+
+func isolateDir(dir string, uid, gid int) error {
+	return errors.New("user isolation is not supported on this platform")
+}
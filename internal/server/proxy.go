@@ -0,0 +1,271 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ProxyBackend is a server a proxy forwards players to
+type ProxyBackend struct {
+	Name string
+	// Address is the host:port the proxy connects to
+	Address string
+}
+
+// velocitySecretFile holds the modern forwarding secret next to velocity.toml
+const velocitySecretFile = "forwarding.secret"
+
+// ConfigureProxy writes the backends and the forwarding settings into the
+// configuration of the proxy in proxyPath. Players join the backends in
+// order. Settings the manager does not own are kept.
+func ConfigureProxy(proxyPath, proxyType string, backends []ProxyBackend, secret string) error {
+	switch proxyType {
+	case model.ProxyTypeVelocity:
+		return configureVelocity(proxyPath, backends, secret)
+	case model.ProxyTypeBungeeCord:
+		return configureBungeeCord(proxyPath, backends)
+	default:
+		return fmt.Errorf("unknown proxy type %q", proxyType)
+	}
+}
+
+func configureVelocity(proxyPath string, backends []ProxyBackend, secret string) error {
+	if err := utils.WriteFileIn(proxyPath, velocitySecretFile, []byte(secret), 0o600); err != nil {
+		return fmt.Errorf("failed to write forwarding secret: %w", err)
+	}
+
+	data, err := utils.ReadFileIn(proxyPath, "velocity.toml")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	lines := splitLines(string(data))
+	lines = setTOMLKey(lines, "player-info-forwarding-mode", strconv.Quote("modern"))
+	lines = setTOMLKey(lines, "forwarding-secret-file", strconv.Quote(velocitySecretFile))
+
+	var servers, try []string
+	for _, backend := range backends {
+		servers = append(servers, strconv.Quote(backend.Name)+" = "+strconv.Quote(backend.Address))
+		try = append(try, strconv.Quote(backend.Name))
+	}
+	servers = append(servers, "try = ["+strings.Join(try, ", ")+"]")
+	lines = replaceTOMLTable(lines, "servers", servers)
+	// Forced hosts name servers, which may no longer exist
+	lines = replaceTOMLTable(lines, "forced-hosts", nil)
+
+	return utils.WriteFileIn(proxyPath, "velocity.toml", []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
+
+func configureBungeeCord(proxyPath string, backends []ProxyBackend) error {
+	return editYAML(proxyPath, "config.yml", func(root *yaml.Node) error {
+		servers := map[string]interface{}{}
+		priorities := []string{}
+		for _, backend := range backends {
+			servers[backend.Name] = map[string]interface{}{
+				"motd":       backend.Name,
+				"address":    backend.Address,
+				"restricted": false,
+			}
+			priorities = append(priorities, backend.Name)
+		}
+		if err := setYAMLKey(root, servers, "servers"); err != nil {
+			return err
+		}
+		if err := setYAMLKey(root, true, "ip_forward"); err != nil {
+			return err
+		}
+		// The first listener decides where players join
+		if listeners := yamlChild(root, "listeners"); listeners != nil && listeners.Kind == yaml.SequenceNode &&
+			len(listeners.Content) > 0 && listeners.Content[0].Kind == yaml.MappingNode {
+			return setYAMLKey(listeners.Content[0], priorities, "priorities")
+		}
+		return setYAMLKey(root, []map[string]interface{}{{"host": "0.0.0.0:25577", "priorities": priorities}}, "listeners")
+	})
+}
+
+// EnableProxyForwarding makes the server in serverPath accept the players
+// a proxy of proxyType forwards. The proxy authenticates players, so the
+// server runs with online-mode=false.
+func EnableProxyForwarding(serverPath, proxyType, secret string) error {
+	switch proxyType {
+	case model.ProxyTypeVelocity:
+		err := editYAML(serverPath, filepath.Join("config", "paper-global.yml"), func(root *yaml.Node) error {
+			if err := setYAMLKey(root, true, "proxies", "velocity", "enabled"); err != nil {
+				return err
+			}
+			if err := setYAMLKey(root, true, "proxies", "velocity", "online-mode"); err != nil {
+				return err
+			}
+			return setYAMLKey(root, secret, "proxies", "velocity", "secret")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to configure velocity forwarding: %w", err)
+		}
+	case model.ProxyTypeBungeeCord:
+		err := editYAML(serverPath, "spigot.yml", func(root *yaml.Node) error {
+			return setYAMLKey(root, true, "settings", "bungeecord")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to configure bungeecord forwarding: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown proxy type %q", proxyType)
+	}
+	return utils.SetProperty(filepath.Join(serverPath, "server.properties"), "online-mode", "false")
+}
+
+// DisableProxyForwarding undoes EnableProxyForwarding, so the server
+// authenticates players itself again
+func DisableProxyForwarding(serverPath string) error {
+	edits := map[string][]string{
+		filepath.Join("config", "paper-global.yml"): {"proxies", "velocity", "enabled"},
+		"spigot.yml": {"settings", "bungeecord"},
+	}
+	for name, keys := range edits {
+		if _, err := os.Lstat(filepath.Join(serverPath, name)); os.IsNotExist(err) {
+			continue
+		}
+		if err := editYAML(serverPath, name, func(root *yaml.Node) error { return setYAMLKey(root, false, keys...) }); err != nil {
+			return err
+		}
+	}
+	return utils.SetProperty(filepath.Join(serverPath, "server.properties"), "online-mode", "true")
+}
+
+// editYAML applies edit to the root mapping of the YAML file name below
+// dir, creating the file if needed. Comments and unrelated keys are kept.
+func editYAML(dir, name string, edit func(root *yaml.Node) error) error {
+	path := filepath.Join(dir, name)
+	data, err := utils.ReadFileIn(dir, name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", path)
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileIn(dir, name, out, 0o644)
+}
+
+// yamlChild returns the value of key in a mapping node, or nil
+func yamlChild(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setYAMLKey sets the value below the path of keys, creating missing
+// mappings on the way
+func setYAMLKey(mapping *yaml.Node, value interface{}, keys ...string) error {
+	for i, key := range keys {
+		child := yamlChild(mapping, key)
+		last := i == len(keys)-1
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+		}
+		if last {
+			var encoded yaml.Node
+			if err := encoded.Encode(value); err != nil {
+				return err
+			}
+			encoded.HeadComment, encoded.LineComment = child.HeadComment, child.LineComment
+			*child = encoded
+			return nil
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a mapping", strings.Join(keys[:i+1], "."))
+		}
+		mapping = child
+	}
+	return nil
+}
+
+func splitLines(content string) []string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
+
+// tomlTable returns the table name of a header line such as [servers]
+func tomlTable(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") || strings.HasPrefix(line, "[[") {
+		return "", false
+	}
+	name, _, found := strings.Cut(line[1:], "]")
+	return strings.TrimSpace(name), found
+}
+
+// setTOMLKey sets a key of the top-level table to an encoded TOML value
+func setTOMLKey(lines []string, key, value string) []string {
+	end := len(lines)
+	for i, line := range lines {
+		if _, ok := tomlTable(line); ok {
+			end = i
+			break
+		}
+		if k, _, found := strings.Cut(line, "="); found && strings.TrimSpace(k) == key {
+			lines[i] = key + " = " + value
+			return lines
+		}
+	}
+	// Append after the last key, not before the blank line above a table
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return append(lines[:end], append([]string{key + " = " + value}, lines[end:]...)...)
+}
+
+// replaceTOMLTable replaces the body of a table, appending the table if it
+// is missing
+func replaceTOMLTable(lines []string, table string, body []string) []string {
+	start := -1
+	for i, line := range lines {
+		if name, ok := tomlTable(line); ok && name == table {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return append(append(lines, "", "["+table+"]"), body...)
+	}
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if _, ok := tomlTable(lines[i]); ok {
+			end = i
+			break
+		}
+	}
+	replaced := append(append([]string{}, lines[:start+1]...), body...)
+	if end < len(lines) {
+		replaced = append(replaced, "")
+	}
+	return append(replaced, lines[end:]...)
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+func TestConfigureVelocity(t *testing.T) {
+	dir := t.TempDir()
+	original := `# Config version. Do not change this
+config-version = "2.7"
+bind = "0.0.0.0:25577"
+player-info-forwarding-mode = "NONE"
+
+[servers]
+lobby = "127.0.0.1:30066"
+try = ["lobby"]
+
+[forced-hosts]
+"lobby.example.com" = ["lobby"]
+
+[advanced]
+compression-threshold = 256
+`
+	if err := os.WriteFile(filepath.Join(dir, "velocity.toml"), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	backends := []ProxyBackend{{Name: "survival", Address: "127.0.0.1:25566"}, {Name: "creative", Address: "127.0.0.1:25567"}}
+	if err := ConfigureProxy(dir, model.ProxyTypeVelocity, backends, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "velocity.toml"))
+	want := `# Config version. Do not change this
+config-version = "2.7"
+bind = "0.0.0.0:25577"
+player-info-forwarding-mode = "modern"
+forwarding-secret-file = "forwarding.secret"
+
+[servers]
+"survival" = "127.0.0.1:25566"
+"creative" = "127.0.0.1:25567"
+try = ["survival", "creative"]
+
+[forced-hosts]
+
+[advanced]
+compression-threshold = 256
+`
+	if string(data) != want {
+		t.Errorf("velocity.toml =\n%s\nwant\n%s", data, want)
+	}
+	if secret, _ := os.ReadFile(filepath.Join(dir, "forwarding.secret")); string(secret) != "s3cret" {
+		t.Errorf("forwarding.secret = %q", secret)
+	}
+}
+
+func TestConfigureBungeeCord(t *testing.T) {
+	dir := t.TempDir()
+	original := `# BungeeCord
+ip_forward: false
+listeners:
+- host: 0.0.0.0:25577
+  priorities:
+  - lobby
+servers:
+  lobby:
+    address: localhost:25565
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureProxy(dir, model.ProxyTypeBungeeCord, []ProxyBackend{{Name: "survival", Address: "127.0.0.1:25566"}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config.yml"))
+	config := string(data)
+	for _, want := range []string{"# BungeeCord", "ip_forward: true", "host: 0.0.0.0:25577", "- survival", "address: 127.0.0.1:25566"} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config.yml lacks %q:\n%s", want, config)
+		}
+	}
+	if strings.Contains(config, "lobby") {
+		t.Errorf("config.yml still lists lobby:\n%s", config)
+	}
+}
+
+func TestProxyForwarding(t *testing.T) {
+	dir := t.TempDir()
+	if err := EnableProxyForwarding(dir, model.ProxyTypeVelocity, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config", "paper-global.yml"))
+	if !strings.Contains(string(data), "enabled: true") || !strings.Contains(string(data), "secret: s3cret") {
+		t.Errorf("paper-global.yml =\n%s", data)
+	}
+	if !IsOfflineMode(dir) {
+		t.Error("backend still runs in online mode")
+	}
+
+	if err := DisableProxyForwarding(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "config", "paper-global.yml"))
+	if !strings.Contains(string(data), "enabled: false") {
+		t.Errorf("paper-global.yml =\n%s", data)
+	}
+	if properties, _ := utils.ReadProperties(filepath.Join(dir, "server.properties")); properties["online-mode"] != "true" {
+		t.Errorf("online-mode = %q", properties["online-mode"])
+	}
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+)
+
+// Runtime starts server processes. ProcessRuntime runs the executable
+// command on the host; DockerRuntime runs it in a container.
+type Runtime interface {
+	Start(spec ProcessSpec) (Process, error)
+}
+
+// ProcessSpec describes the process of a server run
+type ProcessSpec struct {
+	ServerID uint
+	// NodeID is the node agent to run on, nil for this machine
+	NodeID *uint
+	// Dir is the server directory the command runs in
+	Dir     string
+	Command []string
+	// Env holds KEY=value pairs added to the environment of the process
+	Env []string
+	// MemoryMB is the heap requested with -Xmx, zero when unset
+	MemoryMB int64
+	// Port is the game port from server.properties
+	Port int
+	// BedrockPort is the UDP port of Geyser, zero without Geyser
+	BedrockPort int
+	Stderr      io.Writer
+}
+
+// Process is a started server process
+type Process interface {
+	Stdin() io.WriteCloser
+	Stdout() io.Reader
+	// Wait blocks until the process exits
+	Wait() error
+	// Interrupt asks the process to shut down gracefully
+	Interrupt() error
+	Kill() error
+	// PID is the host process ID of the game process, or zero if unknown
+	PID() int
+}
+
+// ProcessRuntime runs servers as child processes of the manager
+type ProcessRuntime struct {
+	// Isolation runs each server as its own user, nil to run servers as
+	// the manager's user
+	Isolation *UserIsolation
+}
+
+// UserIsolation assigns each server the UID Base plus its ID, so servers
+// cannot read each other's directories
+type UserIsolation struct {
+	Base int
+	// GID is the group shared by all servers
+	GID int
+}
+
+// UID is the user a server runs as
+func (u *UserIsolation) UID(serverID uint) int {
+	return u.Base + int(serverID)
+}
+
+// CommandPreparer is implemented by runtimes that run helper commands, such
+// as loader installers, in a server directory the way they run the server
+type CommandPreparer interface {
+	// PrepareCommand readies cmd, whose Dir is the directory of the server,
+	// before it is started
+	PrepareCommand(cmd *exec.Cmd, serverID uint) error
+}
+
+// PrepareCommand makes cmd run as the server's user, handing the directory
+// to it first. Without isolation cmd runs as the manager's user.
+func (r ProcessRuntime) PrepareCommand(cmd *exec.Cmd, serverID uint) error {
+	if r.Isolation == nil {
+		return nil
+	}
+	if err := isolateDir(cmd.Dir, r.Isolation.UID(serverID), r.Isolation.GID); err != nil {
+		return fmt.Errorf("failed to hand server directory to its user: %w", err)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = procAttr()
+	}
+	runAs(cmd, r.Isolation.UID(serverID), r.Isolation.GID)
+	return nil
+}
+
+func (r ProcessRuntime) Start(spec ProcessSpec) (Process, error) {
+	cmd := exec.Command(spec.Command[0], spec.Command[1:]...)
+	cmd.Dir = spec.Dir
+	if len(spec.Env) > 0 {
+		cmd.Env = append(os.Environ(), spec.Env...)
+	}
+	cmd.SysProcAttr = procAttr()
+	if r.Isolation != nil {
+		// Files the manager wrote since the last start belong to it
+		if err := isolateDir(spec.Dir, r.Isolation.UID(spec.ServerID), r.Isolation.GID); err != nil {
+			return nil, fmt.Errorf("failed to hand server directory to its user: %w", err)
+		}
+		runAs(cmd, r.Isolation.UID(spec.ServerID), r.Isolation.GID)
+	}
+	return startCommand(cmd, spec.Stderr)
+}
+
+// processEnv turns the environment variables of a server config into
+// KEY=value pairs, sorted so processes start the same way every time
+func processEnv(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for key, value := range env {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// commandProcess is a process run through an exec.Cmd
+type commandProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.Reader
+	// group holds the platform handles used to stop the process and its
+	// children
+	group processGroup
+}
+
+// startCommand starts cmd with pipes to its stdin and stdout
+func startCommand(cmd *exec.Cmd, stderr io.Writer) (*commandProcess, error) {
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start server: %w", err)
+	}
+	group, err := newProcessGroup(cmd.Process)
+	if err != nil {
+		// The process still runs; only stopping its children is less reliable
+		slog.Warn("Failed to track server process group", "pid", cmd.Process.Pid, "error", err)
+	}
+	return &commandProcess{cmd: cmd, stdin: stdin, stdout: stdout, group: group}, nil
+}
+
+func (p *commandProcess) Stdin() io.WriteCloser { return p.stdin }
+
+func (p *commandProcess) Stdout() io.Reader { return p.stdout }
+
+func (p *commandProcess) Wait() error {
+	err := p.cmd.Wait()
+	p.group.close()
+	return err
+}
+
+func (p *commandProcess) Interrupt() error { return p.group.interrupt(p) }
+
+func (p *commandProcess) Kill() error { return p.group.kill(p.cmd.Process) }
+
+func (p *commandProcess) PID() int { return p.cmd.Process.Pid }
+
+// NewRuntime returns the runtime selected by the configuration. Servers
+// link to files in sharedDir, which containers need to see.
+func NewRuntime(cfg config.RuntimeConfig, sharedDir string) (Runtime, error) {
+	switch cfg.Type {
+	case "", "process":
+		if !cfg.Process.IsolateUsers {
+			return ProcessRuntime{}, nil
+		}
+		if err := checkIsolation(); err != nil {
+			return nil, err
+		}
+		if cfg.Process.UIDBase <= 0 || cfg.Process.GID <= 0 {
+			return nil, fmt.Errorf("runtime.process.uid_base and runtime.process.gid must be positive")
+		}
+		return ProcessRuntime{Isolation: &UserIsolation{Base: cfg.Process.UIDBase, GID: cfg.Process.GID}}, nil
+	case "docker":
+		if cfg.Docker.Image == "" {
+			return nil, fmt.Errorf("runtime.docker.image must be set")
+		}
+		return &DockerRuntime{
+			Binary:           cfg.Docker.Binary,
+			Image:            cfg.Docker.Image,
+			SharedDir:        sharedDir,
+			MemoryOverheadMB: cfg.Docker.MemoryOverheadMB,
+			CPUs:             cfg.Docker.CPUs,
+			Network:          cfg.Docker.Network,
+			User:             cfg.Docker.User,
+			Mounts:           cfg.Docker.Mounts,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown runtime %q", cfg.Type)
+	}
+}
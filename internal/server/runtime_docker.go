@@ -0,0 +1,140 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DockerRuntime runs each server in a Docker container through the docker
+// CLI. The server directory and the shared directory are mounted at their
+// host paths, so executable commands work unchanged.
+type DockerRuntime struct {
+	// Binary is the docker CLI, docker by default
+	Binary string
+	Image  string
+	// SharedDir holds the jars and mod packs servers link to; it is
+	// mounted read-only
+	SharedDir string
+	// MemoryOverheadMB is added to the -Xmx heap for the container memory
+	// limit. Containers of commands without -Xmx are not limited.
+	MemoryOverheadMB int64
+	// CPUs limits the cores a container may use, zero for no limit
+	CPUs float64
+	// Network is the container network; the game port is published unless
+	// it is host
+	Network string
+	// User runs the container as uid:gid, the manager's own by default so
+	// files written by the server stay accessible
+	User string
+	// Mounts are extra volumes in docker -v syntax
+	Mounts []string
+}
+
+// containerName names the container of a server, so at most one exists per
+// server and leftovers can be removed
+func containerName(serverID uint) string {
+	return fmt.Sprintf("mcgonalds-server-%d", serverID)
+}
+
+func (r *DockerRuntime) binary() string {
+	if r.Binary == "" {
+		return "docker"
+	}
+	return r.Binary
+}
+
+func (r *DockerRuntime) Start(spec ProcessSpec) (Process, error) {
+	dir, err := filepath.Abs(spec.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve server directory: %w", err)
+	}
+	name := containerName(spec.ServerID)
+
+	// A container left behind by a manager that died holds the name
+	exec.Command(r.binary(), "rm", "--force", name).Run()
+
+	cmd := exec.Command(r.binary(), r.runArgs(name, dir, spec)...)
+	process, err := startCommand(cmd, spec.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	return &dockerProcess{commandProcess: process, runtime: r, name: name}, nil
+}
+
+// runArgs builds the docker run arguments of a server container
+func (r *DockerRuntime) runArgs(name, dir string, spec ProcessSpec) []string {
+	user := r.User
+	if user == "" {
+		user = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	}
+	args := []string{"run", "--rm", "--interactive", "--name", name,
+		"--volume", dir + ":" + dir, "--workdir", dir, "--user", user}
+	if r.SharedDir != "" {
+		if shared, err := filepath.Abs(r.SharedDir); err == nil {
+			args = append(args, "--volume", shared+":"+shared+":ro")
+		}
+	}
+	for _, mount := range r.Mounts {
+		args = append(args, "--volume", mount)
+	}
+	for _, env := range spec.Env {
+		args = append(args, "--env", env)
+	}
+	if spec.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", spec.MemoryMB+r.MemoryOverheadMB))
+	}
+	if r.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(r.CPUs, 'f', -1, 64))
+	}
+	if r.Network != "" {
+		args = append(args, "--network", r.Network)
+	}
+	if r.Network != "host" && spec.Port > 0 {
+		args = append(args, "--publish", fmt.Sprintf("%d:%d", spec.Port, spec.Port))
+	}
+	if r.Network != "host" && spec.BedrockPort > 0 {
+		args = append(args, "--publish", fmt.Sprintf("%d:%d/udp", spec.BedrockPort, spec.BedrockPort))
+	}
+	args = append(args, r.Image)
+	return append(args, spec.Command...)
+}
+
+// dockerProcess is a server container attached to a docker run client.
+// Signals go to the container, as the client does not forward them.
+type dockerProcess struct {
+	*commandProcess
+	runtime *DockerRuntime
+	name    string
+
+	pidMutex sync.Mutex
+	pid      int
+}
+
+func (p *dockerProcess) Interrupt() error {
+	return exec.Command(p.runtime.binary(), "kill", "--signal", "SIGINT", p.name).Run()
+}
+
+func (p *dockerProcess) Kill() error {
+	if err := exec.Command(p.runtime.binary(), "kill", p.name).Run(); err != nil {
+		return p.commandProcess.Kill()
+	}
+	return nil
+}
+
+// PID looks up the host PID of the container's main process once it runs
+func (p *dockerProcess) PID() int {
+	p.pidMutex.Lock()
+	defer p.pidMutex.Unlock()
+	if p.pid == 0 {
+		out, err := exec.Command(p.runtime.binary(), "inspect", "--format", "{{.State.Pid}}", p.name).Output()
+		if err == nil {
+			p.pid, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+		}
+	}
+	return p.pid
+}
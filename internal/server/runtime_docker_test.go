@@ -0,0 +1,45 @@
+package server
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	runtime := &DockerRuntime{
+		Image:            "eclipse-temurin:21-jre",
+		SharedDir:        "/game_servers/shared",
+		MemoryOverheadMB: 512,
+		CPUs:             1.5,
+		User:             "1000:1000",
+	}
+	args := runtime.runArgs("mcgonalds-server-7", "/game_servers/survival", ProcessSpec{
+		ServerID: 7,
+		Command:  []string{"java", "-Xmx2G", "-jar", "server.jar", "nogui"},
+		MemoryMB: 2048,
+		Port:     25566,
+	})
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"--name mcgonalds-server-7",
+		"--volume /game_servers/survival:/game_servers/survival",
+		"--workdir /game_servers/survival",
+		"--volume /game_servers/shared:/game_servers/shared:ro",
+		"--memory 2560m",
+		"--cpus 1.5",
+		"--user 1000:1000",
+		"--publish 25566:25566",
+		"eclipse-temurin:21-jre java -Xmx2G -jar server.jar nogui",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("docker run args %q lack %q", joined, want)
+		}
+	}
+
+	runtime.Network = "host"
+	args = runtime.runArgs("mcgonalds-server-7", "/srv", ProcessSpec{Command: []string{"./start.sh"}, Port: 25565})
+	if slices.Contains(args, "--publish") || slices.Contains(args, "--memory") {
+		t.Errorf("unexpected docker run args %q", args)
+	}
+}
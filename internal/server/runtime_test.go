@@ -0,0 +1,49 @@
+package server
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestProcessRuntimeIsolation(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Skip("switching users needs root on linux")
+	}
+	dir := t.TempDir()
+	// The server user needs to traverse the parents of its directory
+	if err := os.Chmod(filepath.Dir(dir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/server.properties", []byte("motd=hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := ProcessRuntime{Isolation: &UserIsolation{Base: 200000, GID: 200000}}
+	process, err := r.Start(ProcessSpec{ServerID: 7, Dir: dir, Command: []string{"id", "-u"}})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	output, _ := io.ReadAll(process.Stdout())
+	if err := process.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "200007" {
+		t.Errorf("server ran as %q, want 200007", output)
+	}
+
+	info, err := os.Stat(dir + "/server.properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat := info.Sys().(*syscall.Stat_t); stat.Uid != 200007 || stat.Gid != 200000 {
+		t.Errorf("server.properties owned by %d:%d", stat.Uid, stat.Gid)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0o700 {
+		t.Errorf("server directory mode %v", info.Mode().Perm())
+	}
+}
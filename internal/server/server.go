@@ -2,32 +2,132 @@ package server
 
 import (
 	"bufio"
-	"bytes"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/olindenbaum/mcgonalds/internal/crash"
 	"github.com/olindenbaum/mcgonalds/internal/db"
 	"github.com/olindenbaum/mcgonalds/internal/model"
 )
 
+// stopTimeout is how long Restart waits for the process to exit
+const stopTimeout = 60 * time.Second
+
 // Server represents a Minecraft server instance.
 type Server struct {
-	model     *model.Server
-	cmd       *exec.Cmd
-	stdin     io.WriteCloser
-	stdout    io.ReadCloser
-	console   chan string
-	mutex     sync.Mutex
-	isRunning bool
-	stopOnce  sync.Once
-	doneOnce  sync.Once
-	done      chan struct{}
+	model       *model.Server
+	runtime     Runtime
+	process     Process
+	console     chan string
+	mutex       sync.Mutex
+	isRunning   bool
+	stopOnce    sync.Once
+	consoleOnce sync.Once
+	done        chan struct{}
+	// ready is closed once the current run has finished starting, until
+	// then starting is set
+	ready    chan struct{}
+	starting bool
+	// stopping is set once the current run was asked to stop or killed
+	stopping    bool
+	stderr      *tailWriter
+	tail        []string
+	lastFailure *Failure
+	startedAt   time.Time
+	lastCPU     cpuSample
+	online      map[string]struct{}
+	tps         float64
+	tpsAt       time.Time
+	pregen      map[string]PregenProgress
+	listener    Listener
+
+	// config caches the result of configLoader until InvalidateConfig.
+	// configMutex is separate so Start can load it while holding mutex.
+	config       *model.ServerConfig
+	configLoader ConfigLoader
+	configMutex  sync.Mutex
+}
+
+// ConfigLoader loads the config of a server from where it is stored
+type ConfigLoader func(serverID uint) (*model.ServerConfig, error)
+
+// Listener receives the lifecycle and player events of a server. It is
+// called with the server locked and must not block.
+type Listener func(event model.Event)
+
+// consoleTailLines is how many recent console lines are kept for failure analysis
+const consoleTailLines = 200
+
+// maxFailureStderr is how much of the end of stderr a failure keeps
+const maxFailureStderr = 64 << 10
+
+// tailWriter keeps the last limit bytes written to it. A server that keeps
+// logging to stderr would otherwise grow the buffer for as long as it runs.
+type tailWriter struct {
+	mutex sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buf = append(w.buf, p...)
+	// Trimming only past twice the limit keeps the copying amortized
+	if len(w.buf) > 2*w.limit {
+		w.buf = append(w.buf[:0], w.buf[len(w.buf)-w.limit:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the last limit bytes written
+func (w *tailWriter) String() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.buf) > w.limit {
+		return string(w.buf[len(w.buf)-w.limit:])
+	}
+	return string(w.buf)
+}
+
+// errExitedDuringStartup is the failure of a run that exited cleanly before
+// finishing startup without being asked to stop, as servers do when the
+// EULA has not been accepted
+var errExitedDuringStartup = errors.New("server exited before finishing startup")
+
+// StartError is returned when the process of a server cannot be started,
+// with the diagnosis of the error
+type StartError struct {
+	Err      error
+	Findings []crash.Finding
+}
+
+func (e *StartError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
+// Failure describes the last time the server process exited with an error
+type Failure struct {
+	ExitError string          `json:"exit_error"`
+	Findings  []crash.Finding `json:"findings"`
+	Time      time.Time       `json:"time"`
+	// Stderr and ConsoleTail are the output of the failed run
+	Stderr      string   `json:"-"`
+	ConsoleTail []string `json:"-"`
+	// CrashReports are the files the run wrote to crash-reports/, relative
+	// to the server directory
+	CrashReports []string `json:"crash_reports,omitempty"`
 }
 
 // NewServer initializes a new Server instance.
@@ -36,11 +136,14 @@ func NewServer(model *model.Server) *Server {
 		model:   model,
 		console: make(chan string, 100),
 		done:    make(chan struct{}),
+		online:  make(map[string]struct{}),
 	}
 }
 
 // GetConsole returns a read-only channel for server console output.
 func (s *Server) GetConsole() <-chan string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	return s.console
 }
 
@@ -53,12 +156,16 @@ func (s *Server) Start() error {
 		return fmt.Errorf("server is already running")
 	}
 
+	if IsOfflineMode(s.model.Path) && !s.model.OfflineModeAcknowledged {
+		return fmt.Errorf("server.properties sets online-mode=false; acknowledge offline mode before starting")
+	}
+
 	config, err := s.GetConfig()
 	if err != nil {
 		return fmt.Errorf("failed to get server config: %w", err)
 	}
 
-	log.Printf("ExecutableCommand: %s", config.ExecutableCommand)
+	s.logger().Debug("Starting server process", "command", config.ExecutableCommand)
 
 	// Split the command into the executable and its arguments
 	parts := strings.Fields(config.ExecutableCommand)
@@ -66,80 +173,154 @@ func (s *Server) Start() error {
 		return fmt.Errorf("invalid executable command")
 	}
 
-	executable := parts[0]
-	args := parts[1:]
-
-	s.cmd = exec.Command(executable, args...)
-	s.cmd.Dir = s.model.Path
+	s.stderr = &tailWriter{limit: maxFailureStderr}
+	s.tail = nil
 
-	var errBuffer bytes.Buffer
-	s.cmd.Stderr = &errBuffer
-
-	s.stdin, err = s.cmd.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	runtime := s.runtime
+	if runtime == nil {
+		runtime = ProcessRuntime{}
 	}
-
-	s.stdout, err = s.cmd.StdoutPipe()
+	s.process, err = runtime.Start(ProcessSpec{
+		ServerID: s.model.ID,
+		NodeID:   s.model.NodeID,
+		Dir:      s.model.Path,
+		Command:  parts,
+		Env:      processEnv(config.Env),
+		MemoryMB: CommandMemoryMB(config.ExecutableCommand),
+		Port:     Port(s.model.Path),
+		// Geyser takes Bedrock players on a UDP port of its own
+		BedrockPort: BedrockPort(s.model.Path),
+		Stderr:      s.stderr,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	if err := s.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start server: %w", err)
+		s.lastFailure = &Failure{ExitError: err.Error(), Findings: crash.Analyze(err.Error()), Time: time.Now()}
+		return &StartError{Err: err, Findings: s.lastFailure.Findings}
 	}
 
 	s.isRunning = true
-
-	go s.readConsole()
-	go s.monitorProcess()
+	s.startedAt = time.Now()
+	s.lastCPU = cpuSample{}
+	s.online = make(map[string]struct{})
+	s.tps, s.tpsAt = 0, time.Time{}
+	s.pregen = nil
+	s.ready = make(chan struct{})
+	s.starting = true
+	s.stopping = false
+	s.setStatus(model.ServerStatusStarting)
+	s.emit(model.EventServerStarted, nil)
+
+	// Each run gets fresh channels so a restart never writes to channels
+	// closed by the previous process
+	s.console = make(chan string, 100)
+	s.done = make(chan struct{})
+	s.stopOnce = sync.Once{}
+	s.consoleOnce = sync.Once{}
+
+	go s.readConsole(s.process.Stdout(), s.console, s.done, &s.consoleOnce)
+	go s.monitorProcess(s.process, s.done)
 
 	return nil
 }
 
 // readConsole reads the server's stdout and sends it to the console channel.
-func (s *Server) readConsole() {
-	scanner := bufio.NewScanner(s.stdout)
+func (s *Server) readConsole(stdout io.Reader, console chan string, done chan struct{}, once *sync.Once) {
+	loc := LoadLocation(s.model.Timezone)
+	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
-		line := scanner.Text()
+		line := NormalizeTimestamp(scanner.Text(), loc, time.Now())
+		s.recordTail(line)
+		s.trackConsole(line)
 		select {
-		case s.console <- line:
-		case <-s.done:
+		case console <- line:
+		case <-done:
 			return
 		}
-		log.Printf("[%s] %s", s.model.Name, line)
+		s.logger().Debug("Console output", "line", line)
 	}
 	if err := scanner.Err(); err != nil {
-		log.Printf("Error reading server output: %v", err)
+		s.logger().Error("Failed to read server output", "error", err)
 	}
 	// Close console channel only once
-	s.doneOnce.Do(func() {
-		close(s.console)
+	once.Do(func() {
+		close(console)
 	})
 }
 
 // monitorProcess waits for the server process to exit and handles cleanup.
-func (s *Server) monitorProcess() {
-	err := s.cmd.Wait()
+func (s *Server) monitorProcess(process Process, done chan struct{}) {
+	err := process.Wait()
 
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	if err == nil && s.starting && !s.stopping {
+		err = errExitedDuringStartup
+	}
 	if err != nil {
-		log.Printf("Server %s exited with error: %v", s.model.Name, err)
+		s.logger().Warn("Server exited with error", "error", err)
+		s.lastFailure = s.analyzeFailure(err)
+		s.setStatus(model.ServerStatusCrashed)
+		data := map[string]string{"exit_error": err.Error()}
+		if len(s.lastFailure.Findings) > 0 {
+			data["cause"] = s.lastFailure.Findings[0].Cause
+		}
+		s.emit(model.EventServerCrashed, data)
 	} else {
-		log.Printf("Server %s stopped gracefully", s.model.Name)
+		s.logger().Info("Server stopped gracefully")
+		s.setStatus(model.ServerStatusStopped)
+		s.emit(model.EventServerStopped, nil)
 	}
 
 	s.isRunning = false
+	s.starting = false
+	s.online = make(map[string]struct{})
+	close(done)
+}
 
-	// Signal done once
-	s.doneOnce.Do(func() {
-		close(s.done)
-	})
+// recordTail keeps the most recent console lines for failure analysis
+func (s *Server) recordTail(line string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tail = append(s.tail, line)
+	if len(s.tail) > consoleTailLines {
+		s.tail = s.tail[len(s.tail)-consoleTailLines:]
+	}
+}
+
+// analyzeFailure runs the crash analyzers over stderr and the console tail.
+// The caller must hold the mutex.
+func (s *Server) analyzeFailure(exitErr error) *Failure {
+	var stderr string
+	if s.stderr != nil {
+		stderr = s.stderr.String()
+	}
+	text := strings.Join(s.tail, "\n")
+	if stderr != "" {
+		text = stderr + "\n" + text
+	}
+	failure := &Failure{
+		ExitError:    exitErr.Error(),
+		Findings:     crash.Analyze(text),
+		Time:         time.Now(),
+		Stderr:       stderr,
+		ConsoleTail:  append([]string(nil), s.tail...),
+		CrashReports: NewCrashReports(s.model.Path, s.startedAt),
+	}
+	for _, finding := range failure.Findings {
+		s.logger().Warn("Server failure diagnosis", "analyzer", finding.Analyzer, "cause", finding.Cause)
+	}
+	return failure
+}
+
+// LastFailure returns the diagnosis of the last failed run, if any
+func (s *Server) LastFailure() *Failure {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastFailure
 }
 
-// Stop gracefully stops the server process.
+// Stop asks the server process to shut down gracefully. The server keeps
+// reporting as running until the process has actually exited.
 func (s *Server) Stop() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -149,14 +330,104 @@ func (s *Server) Stop() error {
 	}
 
 	// Ensure stop is called only once
+	s.stopping = true
 	s.stopOnce.Do(func() {
-		if err := s.cmd.Process.Signal(os.Interrupt); err != nil {
-			log.Printf("Failed to send interrupt signal: %v", err)
+		if err := s.process.Interrupt(); err != nil {
+			s.logger().Error("Failed to send interrupt signal", "error", err)
 		}
 	})
 
-	s.isRunning = false
+	return nil
+}
+
+// Ready returns a channel closed once the current run has logged that it
+// finished starting, or nil when the server has not been started
+func (s *Server) Ready() <-chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ready
+}
+
+// MarkReady reports a server that is still starting as running, for
+// servers that never log that they finished starting
+func (s *Server) MarkReady() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.isRunning && s.starting {
+		s.markReady()
+	}
+}
+
+// markReady records that the current run finished starting. The caller
+// must hold the mutex.
+func (s *Server) markReady() {
+	s.starting = false
+	s.setStatus(model.ServerStatusRunning)
+	close(s.ready)
+}
 
+// SetQueued reports a stopped server as waiting to start, or as stopped
+// again when queued is false
+func (s *Server) SetQueued(queued bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.isRunning {
+		return
+	}
+	if queued {
+		s.setStatus(model.ServerStatusQueued)
+	} else if s.model.Status == model.ServerStatusQueued {
+		s.setStatus(model.ServerStatusStopped)
+	}
+}
+
+// Wait blocks until the current server process exits or the timeout passes.
+func (s *Server) Wait(timeout time.Duration) error {
+	s.mutex.Lock()
+	done := s.done
+	running := s.isRunning
+	s.mutex.Unlock()
+
+	if !running {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("server did not exit within %s", timeout)
+	}
+}
+
+// Kill forcibly terminates the server process.
+func (s *Server) Kill() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRunning {
+		return nil
+	}
+	s.stopping = true
+	return s.process.Kill()
+}
+
+// StopAndWait stops the server and waits for it to exit, killing the process
+// if it does not shut down in time. It is a no-op for stopped servers.
+func (s *Server) StopAndWait(timeout time.Duration) error {
+	if !s.IsRunning() {
+		return nil
+	}
+	if err := s.Stop(); err != nil && s.IsRunning() {
+		return err
+	}
+	if err := s.Wait(timeout); err != nil {
+		s.logger().Warn("Server did not stop in time, killing it", "error", err)
+		if err := s.Kill(); err != nil {
+			return fmt.Errorf("failed to kill server: %w", err)
+		}
+		return s.Wait(timeout)
+	}
 	return nil
 }
 
@@ -165,6 +436,9 @@ func (s *Server) Restart() error {
 	if err := s.Stop(); err != nil {
 		return err
 	}
+	if err := s.Wait(stopTimeout); err != nil {
+		return err
+	}
 	return s.Start()
 }
 
@@ -177,7 +451,7 @@ func (s *Server) SendCommand(command string) error {
 		return fmt.Errorf("server is not running")
 	}
 
-	_, err := io.WriteString(s.stdin, command+"\n")
+	_, err := io.WriteString(s.process.Stdin(), command+"\n")
 	if err != nil {
 		return fmt.Errorf("failed to send command: %w", err)
 	}
@@ -234,6 +508,16 @@ func (s *Server) IsRunning() bool {
 	return s.isRunning
 }
 
+// Uptime returns how long the current run has lasted, zero while stopped
+func (s *Server) Uptime() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.isRunning {
+		return 0
+	}
+	return time.Since(s.startedAt)
+}
+
 // GetName returns the server's name.
 func (s *Server) GetName() string {
 	return s.model.Name
@@ -244,18 +528,99 @@ func (s *Server) GetPath() string {
 	return s.model.Path
 }
 
-// GetConfig retrieves the server's configuration from the database.
+// GetConfig returns the server's configuration, loading it on first use.
+// The result is a copy the caller may modify.
 func (s *Server) GetConfig() (*model.ServerConfig, error) {
-	var config model.ServerConfig
-	if err := db.GetDB().Where("server_id = ?", s.GetServerId()).First(&config).Error; err != nil {
-		return nil, fmt.Errorf("failed to get server config: %w", err)
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	if s.config == nil {
+		if s.configLoader == nil {
+			return nil, fmt.Errorf("server %d has no config loader", s.GetServerId())
+		}
+		config, err := s.configLoader(s.GetServerId())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server config: %w", err)
+		}
+		s.config = config
 	}
+	config := *s.config
 	return &config, nil
 }
 
-// GetServerId returns the server's ID as uint8.
-func (s *Server) GetServerId() uint8 {
-	return uint8(s.model.ID)
+// SetConfigLoader sets how the server's configuration is loaded and drops
+// the cached one
+func (s *Server) SetConfigLoader(loader ConfigLoader) {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+	s.configLoader = loader
+	s.config = nil
+}
+
+// InvalidateConfig drops the cached configuration so the next GetConfig
+// loads it again. It must be called whenever the stored config changes.
+func (s *Server) InvalidateConfig() {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+	s.config = nil
+}
+
+// setStatus records the process state on the model and in the database so
+// server lists can be filtered by it. The caller must hold the mutex.
+func (s *Server) setStatus(status string) {
+	s.model.Status = status
+	if database := db.GetDB(); database != nil {
+		if err := database.Model(&model.Server{}).Where("id = ?", s.model.ID).Update("status", status).Error; err != nil {
+			s.logger().Error("Failed to record server status", "status", status, "error", err)
+		}
+	}
+}
+
+// SetRuntime sets how the server process is run from the next start on.
+// Servers without a runtime run as child processes.
+func (s *Server) SetRuntime(runtime Runtime) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runtime = runtime
+}
+
+// SetListener registers the function that receives the server's events
+func (s *Server) SetListener(listener Listener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listener = listener
+}
+
+// logger returns the logger for records about the server
+func (s *Server) logger() *slog.Logger {
+	return slog.With("server_id", s.model.ID, "server", s.model.Name)
+}
+
+// emit passes an event to the listener. The caller must hold the mutex.
+func (s *Server) emit(eventType string, data map[string]string) {
+	if s.listener == nil {
+		return
+	}
+	s.listener(model.Event{
+		Type:     eventType,
+		ServerID: s.model.ID,
+		UserID:   s.model.UserID,
+		TeamID:   s.model.TeamID,
+		Time:     time.Now(),
+		Data:     data,
+	})
+}
+
+// SetOfflineModeAcknowledged records whether offline mode was acknowledged
+func (s *Server) SetOfflineModeAcknowledged(acknowledged bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.model.OfflineModeAcknowledged = acknowledged
+}
+
+// GetServerId returns the server's ID.
+func (s *Server) GetServerId() uint {
+	return s.model.ID
 }
 
 // String returns a string representation of the server.
@@ -267,13 +632,23 @@ func (s *Server) String() string {
 func (s *Server) GetServerDetails() *ServerDetails {
 	config, err := s.GetConfig()
 	if err != nil {
-		log.Printf("Failed to get server config: %v", err)
+		s.logger().Error("Failed to get server config", "error", err)
+		config = &model.ServerConfig{}
+	}
+	var warnings []string
+	if warning := OfflineModeWarning(s.model.Path); warning != "" {
+		warnings = append(warnings, warning)
 	}
 	return &ServerDetails{
-		Name:      s.model.Name,
-		Path:      s.model.Path,
-		IsRunning: s.isRunning,
-		ServerId:  uint8(s.model.ID),
-		Config:    *config,
+		Name:                    s.model.Name,
+		Path:                    s.model.Path,
+		IsRunning:               s.isRunning,
+		ServerId:                s.model.ID,
+		Config:                  *config,
+		OfflineModeAcknowledged: s.model.OfflineModeAcknowledged,
+		Timezone:                LoadLocation(s.model.Timezone).String(),
+		UTCOffset:               UTCOffset(LoadLocation(s.model.Timezone), time.Now()),
+		Warnings:                warnings,
+		LastFailure:             s.lastFailure,
 	}
 }
@@ -3,9 +3,15 @@ package server
 import "github.com/olindenbaum/mcgonalds/internal/model"
 
 type ServerDetails struct {
-	ServerId  uint8              `json:"server_id"`
+	ServerId  uint               `json:"server_id"`
 	Name      string             `json:"name"`
 	Path      string             `json:"path"`
 	IsRunning bool               `json:"is_running"`
 	Config    model.ServerConfig `json:"config"`
+
+	OfflineModeAcknowledged bool     `json:"offline_mode_acknowledged"`
+	Timezone                string   `json:"timezone"`
+	UTCOffset               string   `json:"utc_offset"`
+	Warnings                []string `json:"warnings,omitempty"`
+	LastFailure             *Failure `json:"last_failure,omitempty"`
 }
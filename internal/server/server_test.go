@@ -0,0 +1,61 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestGetConfigCaches(t *testing.T) {
+	s := NewServer(&model.Server{ID: 7})
+	if _, err := s.GetConfig(); err == nil {
+		t.Fatal("GetConfig without a loader succeeded")
+	}
+
+	loads := 0
+	s.SetConfigLoader(func(id uint) (*model.ServerConfig, error) {
+		loads++
+		return &model.ServerConfig{ServerID: id, ExecutableCommand: "java -jar server.jar"}, nil
+	})
+	for i := 0; i < 3; i++ {
+		config, err := s.GetConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Callers get a copy they may change
+		config.ExecutableCommand = "changed"
+	}
+	if loads != 1 {
+		t.Errorf("config was loaded %d times, want once", loads)
+	}
+	if config, _ := s.GetConfig(); config.ExecutableCommand != "java -jar server.jar" || config.ServerID != 7 {
+		t.Errorf("cached config was modified: %+v", config)
+	}
+
+	s.InvalidateConfig()
+	s.GetConfig()
+	if loads != 2 {
+		t.Errorf("config was loaded %d times after invalidating, want twice", loads)
+	}
+}
+
+func TestTailWriterKeepsTheEnd(t *testing.T) {
+	w := &tailWriter{limit: 8}
+	if w.String() != "" {
+		t.Fatalf("empty writer holds %q", w.String())
+	}
+	w.Write([]byte("abc"))
+	if got := w.String(); got != "abc" {
+		t.Errorf("got %q, want %q", got, "abc")
+	}
+	for i := 0; i < 100; i++ {
+		w.Write([]byte("0123456789"))
+	}
+	w.Write([]byte("end"))
+	if got := w.String(); got != "56789end" {
+		t.Errorf("got %q, want %q", got, "56789end")
+	}
+	if len(w.buf) > 2*w.limit {
+		t.Errorf("buffer grew to %d bytes", len(w.buf))
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the USER_HZ unit /proc reports CPU times in. It is 100 on
+// every mainstream Linux architecture.
+const clockTicks = 100
+
+// ProcessStats is a sample of the resources used by a server process
+type ProcessStats struct {
+	PID           int     `json:"pid"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	RSSBytes      int64   `json:"rss_bytes"`
+	UptimeSeconds int64   `json:"uptime_seconds"`
+}
+
+// cpuSample is the CPU time of the process at a point in time, kept so the
+// next sample can report usage over the interval in between
+type cpuSample struct {
+	ticks uint64
+	at    time.Time
+}
+
+// Stats samples CPU, memory and uptime of the running server process from
+// /proc. CPU usage is averaged since the previous call, or since the process
+// started on the first call. One hundred percent is one fully used core.
+func (s *Server) Stats() (*ProcessStats, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRunning || s.process == nil {
+		return nil, fmt.Errorf("server is not running")
+	}
+	pid := s.process.PID()
+	if pid == 0 {
+		return nil, fmt.Errorf("process ID of the server is not known yet")
+	}
+
+	statData, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process stats: %w", err)
+	}
+	ticks, err := parseCPUTicks(statData)
+	if err != nil {
+		return nil, err
+	}
+	statusData, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process status: %w", err)
+	}
+
+	now := time.Now()
+	previous := s.lastCPU
+	if previous.at.IsZero() {
+		previous = cpuSample{at: s.startedAt}
+	}
+	s.lastCPU = cpuSample{ticks: ticks, at: now}
+
+	stats := &ProcessStats{
+		PID:           pid,
+		RSSBytes:      parseRSS(statusData),
+		UptimeSeconds: int64(now.Sub(s.startedAt).Seconds()),
+	}
+	if elapsed := now.Sub(previous.at).Seconds(); elapsed > 0 && ticks >= previous.ticks {
+		stats.CPUPercent = float64(ticks-previous.ticks) / clockTicks / elapsed * 100
+	}
+	return stats, nil
+}
+
+// parseCPUTicks returns utime + stime from the contents of /proc/<pid>/stat.
+// The command name may contain spaces and parentheses, so fields are counted
+// from the last closing parenthesis.
+func parseCPUTicks(data []byte) (uint64, error) {
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed process stat")
+	}
+	// Fields after the command name start at field 3 (state); utime and
+	// stime are fields 14 and 15
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("malformed process stat")
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed process stat: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed process stat: %w", err)
+	}
+	return utime + stime, nil
+}
+
+// parseRSS returns the resident set size in bytes from the contents of
+// /proc/<pid>/status, or zero when it is not reported
+func parseRSS(data []byte) int64 {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "VmRSS:"))
+		if len(fields) == 0 {
+			return 0
+		}
+		kb, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
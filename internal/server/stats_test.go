@@ -0,0 +1,28 @@
+package server
+
+import "testing"
+
+func TestParseCPUTicks(t *testing.T) {
+	stat := []byte("4242 (java (main)) S 1 4242 4242 0 -1 4194560 51203 0 12 0 1500 250 0 0 20 0 42 0 123456 4000000000 250000 18446744073709551615\n")
+	ticks, err := parseCPUTicks(stat)
+	if err != nil {
+		t.Fatalf("parseCPUTicks: %v", err)
+	}
+	if ticks != 1750 {
+		t.Errorf("got %d ticks, want 1750", ticks)
+	}
+
+	if _, err := parseCPUTicks([]byte("4242 java S 1")); err == nil {
+		t.Error("expected an error for malformed stat")
+	}
+}
+
+func TestParseRSS(t *testing.T) {
+	status := []byte("Name:\tjava\nVmPeak:\t 9000000 kB\nVmRSS:\t 1048576 kB\nThreads:\t42\n")
+	if got := parseRSS(status); got != 1<<30 {
+		t.Errorf("got %d bytes, want %d", got, 1<<30)
+	}
+	if got := parseRSS([]byte("Name:\tjava\n")); got != 0 {
+		t.Errorf("got %d bytes for missing VmRSS, want 0", got)
+	}
+}
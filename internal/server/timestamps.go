@@ -0,0 +1,63 @@
+package server
+
+import (
+	"regexp"
+	"time"
+)
+
+// utcLayout is the timestamp format normalized console lines carry
+const utcLayout = "2006-01-02T15:04:05Z"
+
+var (
+	// [12:34:56] [Server thread/INFO]: ... (vanilla, Fabric) and [12:34:56 INFO]: ... (Paper, Spigot)
+	clockPrefix = regexp.MustCompile(`^\[(\d{2}:\d{2}:\d{2})(?:\.\d+)?([\] ])`)
+	// [18Oct2024 12:34:56.789] [main/INFO] ... (Forge debug.log style)
+	datedPrefix = regexp.MustCompile(`^\[(\d{2}[A-Za-z]{3}\d{4} \d{2}:\d{2}:\d{2})(?:\.\d+)?\]`)
+)
+
+// LoadLocation resolves a server's configured timezone, defaulting to the host zone
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// NormalizeTimestamp rewrites the leading timestamp of a console line, which
+// the game prints in the server's local time, to UTC. Lines without a
+// recognised timestamp are returned unchanged.
+func NormalizeTimestamp(line string, loc *time.Location, now time.Time) string {
+	if match := datedPrefix.FindStringSubmatchIndex(line); match != nil {
+		ts, err := time.ParseInLocation("02Jan2006 15:04:05", line[match[2]:match[3]], loc)
+		if err != nil {
+			return line
+		}
+		return "[" + ts.UTC().Format(utcLayout) + line[match[1]-1:]
+	}
+
+	match := clockPrefix.FindStringSubmatchIndex(line)
+	if match == nil {
+		return line
+	}
+	clock, err := time.Parse("15:04:05", line[match[2]:match[3]])
+	if err != nil {
+		return line
+	}
+
+	local := now.In(loc)
+	ts := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+	// A line printed just before local midnight can be read just after it
+	if ts.Sub(local) > time.Hour {
+		ts = ts.AddDate(0, 0, -1)
+	}
+	return "[" + ts.UTC().Format(utcLayout) + line[match[4]:]
+}
+
+// UTCOffset formats the current offset of loc, e.g. "+02:00"
+func UTCOffset(loc *time.Location, now time.Time) string {
+	return now.In(loc).Format("-07:00")
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeTimestamp(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	now := time.Date(2024, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "vanilla",
+			line: "[13:30:00] [Server thread/INFO]: Done (3.2s)!",
+			want: "[2024-10-18T11:30:00Z] [Server thread/INFO]: Done (3.2s)!",
+		},
+		{
+			name: "paper",
+			line: "[13:30:00 INFO]: Done (3.2s)!",
+			want: "[2024-10-18T11:30:00Z INFO]: Done (3.2s)!",
+		},
+		{
+			name: "forge dated",
+			line: "[18Oct2024 13:30:00.123] [main/INFO] [cpw.mods.modlauncher.Launcher/MODLAUNCHER]: loading",
+			want: "[2024-10-18T11:30:00Z] [main/INFO] [cpw.mods.modlauncher.Launcher/MODLAUNCHER]: loading",
+		},
+		{
+			name: "before midnight",
+			line: "[23:59:59] [Server thread/INFO]: late",
+			want: "[2024-10-17T21:59:59Z] [Server thread/INFO]: late",
+		},
+		{
+			name: "no timestamp",
+			line: "Starting minecraft server version 1.21.1",
+			want: "Starting minecraft server version 1.21.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeTimestamp(tt.line, berlin, now); got != tt.want {
+				t.Errorf("NormalizeTimestamp() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ErrAdditionalFileNotFound is returned for additional file IDs that do not exist
+var ErrAdditionalFileNotFound = errors.New("additional file not found")
+
+// getAdditionalFiles fetches additional files by ID, failing unless all exist
+func (sm *ServerManager) getAdditionalFiles(ids []uint) ([]model.AdditionalFile, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var files []model.AdditionalFile
+	if err := sm.db.Where("id IN ?", ids).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch additional files: %w", err)
+	}
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(files) != len(unique) {
+		return nil, fmt.Errorf("%w: one or more of %v", ErrAdditionalFileNotFound, ids)
+	}
+	return files, nil
+}
+
+// ListServerAdditionalFiles returns the additional files attached to a server
+func (sm *ServerManager) ListServerAdditionalFiles(id uint, userID uint) ([]model.AdditionalFile, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+	files := []model.AdditionalFile{}
+	if err := sm.db.Model(serverModel).Order("id").Association("AdditionalFiles").Find(&files); err != nil {
+		return nil, fmt.Errorf("failed to fetch additional files: %w", err)
+	}
+	return files, nil
+}
+
+// AttachAdditionalFile attaches an additional file to a server and places it
+// into the server directory right away. A running server picks it up on its
+// next start.
+func (sm *ServerManager) AttachAdditionalFile(id uint, userID uint, fileID uint) (*model.AdditionalFile, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	if serverModel.ArchivedAt != nil {
+		return nil, ErrServerArchived
+	}
+	files, err := sm.getAdditionalFiles([]uint{fileID})
+	if err != nil {
+		return nil, err
+	}
+	file := &files[0]
+
+	if err := sm.placeAdditionalFile(serverModel.Path, file); err != nil {
+		return nil, err
+	}
+	if err := sm.db.Model(serverModel).Association("AdditionalFiles").Append(file); err != nil {
+		return nil, fmt.Errorf("failed to attach additional file: %w", err)
+	}
+	slog.Info("Attached additional file", "server_id", id, "additional_file_id", fileID, "type", file.Type)
+	return file, nil
+}
+
+// DetachAdditionalFile detaches an additional file from a server and removes
+// it from the server directory. Config files are kept since the server may
+// have changed them.
+func (sm *ServerManager) DetachAdditionalFile(id uint, userID uint, fileID uint) error {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return err
+	}
+	var attached []model.AdditionalFile
+	if err := sm.db.Model(serverModel).Association("AdditionalFiles").Find(&attached, "additional_files.id = ?", fileID); err != nil {
+		return fmt.Errorf("failed to fetch additional file: %w", err)
+	}
+	if len(attached) == 0 {
+		return fmt.Errorf("%w: additional file %d is not attached to server %d", gorm.ErrRecordNotFound, fileID, id)
+	}
+	file := attached[0]
+
+	if err := sm.db.Model(serverModel).Association("AdditionalFiles").Delete(&file); err != nil {
+		return fmt.Errorf("failed to detach additional file: %w", err)
+	}
+	if file.Type != model.AdditionalFileConfig && serverModel.ArchivedAt == nil {
+		if destination, err := additionalFileDestination(serverModel.Path, &file); err == nil {
+			if err := os.Remove(destination); err != nil && !os.IsNotExist(err) {
+				slog.Error("Failed to remove additional file", "path", destination, "error", err)
+			}
+		}
+	}
+	slog.Info("Detached additional file", "server_id", id, "additional_file_id", fileID)
+	return nil
+}
+
+// placeAdditionalFiles places each additional file into a server directory
+func (sm *ServerManager) placeAdditionalFiles(serverPath string, files []model.AdditionalFile) error {
+	for i := range files {
+		if err := sm.placeAdditionalFile(serverPath, &files[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeAdditionalFile places an additional file where its type belongs.
+// Config files are copied because the server rewrites them; everything else
+// is linked like jar files and mod packs.
+func (sm *ServerManager) placeAdditionalFile(serverPath string, file *model.AdditionalFile) error {
+	destination, err := additionalFileDestination(serverPath, file)
+	if err != nil {
+		return err
+	}
+	if file.Type == model.AdditionalFileMod && utils.IsLinkedArtifact(filepath.Join(serverPath, "mods")) {
+		return ErrModsProvisioned
+	}
+	source, err := sm.additionalFileSource(file)
+	if err != nil {
+		return err
+	}
+
+	if file.Type == model.AdditionalFileConfig {
+		if err := copyIntoServer(source, serverPath, destination); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", file.Name, err)
+		}
+		return nil
+	}
+	if err := sm.linkArtifact(source, destination); err != nil {
+		return fmt.Errorf("failed to link %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// copyIntoServer copies source to destination inside serverPath. The
+// destination and its parents are created without following links the
+// server may have put in their place.
+func copyIntoServer(source, serverPath, destination string) error {
+	rel, err := filepath.Rel(serverPath, destination)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(rel); dir != "." {
+		if err := utils.MkdirAllIn(serverPath, dir, 0755); err != nil {
+			return err
+		}
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := utils.OpenIn(serverPath, rel, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// additionalFileSource returns where an additional file is stored
+func (sm *ServerManager) additionalFileSource(file *model.AdditionalFile) (string, error) {
+	if filepath.IsAbs(file.Path) {
+		return file.Path, nil
+	}
+	sharedDir, err := sm.sharedDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(sharedDir, "additional_files", file.Path), nil
+}
+
+// additionalFileDestination returns where in a server directory an
+// additional file is placed
+func additionalFileDestination(serverPath string, file *model.AdditionalFile) (string, error) {
+	var dir string
+	switch file.Type {
+	case model.AdditionalFileConfig:
+		dir = "."
+	case model.AdditionalFilePlugin:
+		dir = "plugins"
+	case model.AdditionalFileMod:
+		dir = "mods"
+	case model.AdditionalFileDatapack:
+		dir = filepath.Join(levelName(serverPath), "datapacks")
+	default:
+		return "", fmt.Errorf("additional file %d has unknown type %q", file.ID, file.Type)
+	}
+	// level-name comes from server.properties, which users edit
+	return utils.SafeJoin(serverPath, filepath.Join(dir, filepath.Base(file.Path)))
+}
@@ -0,0 +1,64 @@
+package server_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestPlaceAdditionalFiles(t *testing.T) {
+	shared := t.TempDir()
+	serverPath := t.TempDir()
+	os.WriteFile(filepath.Join(serverPath, "server.properties"), []byte("level-name=survival\n"), 0644)
+
+	files := []model.AdditionalFile{
+		{Name: "ops", Type: model.AdditionalFileConfig, Path: filepath.Join(shared, "ops.json")},
+		{Name: "essentials", Type: model.AdditionalFilePlugin, Path: filepath.Join(shared, "essentials.jar")},
+		{Name: "lithium", Type: model.AdditionalFileMod, Path: filepath.Join(shared, "lithium.jar")},
+		{Name: "terralith", Type: model.AdditionalFileDatapack, Path: filepath.Join(shared, "terralith.zip")},
+	}
+	for _, file := range files {
+		os.WriteFile(file.Path, []byte(file.Name), 0644)
+	}
+
+	sm := &ServerManager{}
+	if err := sm.placeAdditionalFiles(serverPath, files); err != nil {
+		t.Fatal(err)
+	}
+	for rel, name := range map[string]string{
+		"ops.json":                         "ops",
+		"plugins/essentials.jar":           "essentials",
+		"mods/lithium.jar":                 "lithium",
+		"survival/datapacks/terralith.zip": "terralith",
+	} {
+		if data, err := os.ReadFile(filepath.Join(serverPath, rel)); err != nil || string(data) != name {
+			t.Errorf("%s holds %q: %v", rel, data, err)
+		}
+	}
+
+	// Configs are copies the server may rewrite without touching the shared file
+	if info, _ := os.Lstat(filepath.Join(serverPath, "ops.json")); info.Mode()&os.ModeSymlink != 0 {
+		t.Error("config file was linked instead of copied")
+	}
+
+	// A server replacing its config with a link cannot make the copy write
+	// outside its directory
+	outside := filepath.Join(t.TempDir(), "passwd")
+	os.WriteFile(outside, []byte("root"), 0644)
+	os.Remove(filepath.Join(serverPath, "ops.json"))
+	if err := os.Symlink(outside, filepath.Join(serverPath, "ops.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.placeAdditionalFile(serverPath, &files[0]); err == nil {
+		t.Error("config copied through a link")
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "root" {
+		t.Errorf("file outside the server directory was overwritten with %q", data)
+	}
+
+	if err := sm.placeAdditionalFile(serverPath, &model.AdditionalFile{Type: "script", Path: files[0].Path}); err == nil {
+		t.Error("file of unknown type was placed")
+	}
+}
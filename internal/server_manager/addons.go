@@ -0,0 +1,247 @@
+package server_manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// geyserBuildURL is the GeyserMC download API entry of the latest build of
+// a project, geyser or floodgate
+const geyserBuildURL = "https://download.geysermc.org/v2/projects/%s/versions/latest/builds/latest"
+
+// geyserBuild is the part of a GeyserMC build the manager uses
+type geyserBuild struct {
+	Version   string `json:"version"`
+	Build     int    `json:"build"`
+	Downloads map[string]struct {
+		Name   string `json:"name"`
+		Sha256 string `json:"sha256"`
+	} `json:"downloads"`
+}
+
+// latestGeyserBuild asks the GeyserMC API for the newest build of project
+func latestGeyserBuild(project string) (*geyserBuild, error) {
+	client := &http.Client{Timeout: fabricMetaTimeout}
+	resp, err := client.Get(fmt.Sprintf(geyserBuildURL, project))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the geysermc api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geysermc api returned %s for %s", resp.Status, project)
+	}
+	var build geyserBuild
+	if err := json.NewDecoder(resp.Body).Decode(&build); err != nil {
+		return nil, fmt.Errorf("failed to decode geysermc api response: %w", err)
+	}
+	return &build, nil
+}
+
+// GeyserInstall is the result of installing Geyser and Floodgate
+type GeyserInstall struct {
+	Addons []model.Addon `json:"addons"`
+	// BedrockPort is the UDP port Bedrock players connect to
+	BedrockPort int `json:"bedrock_port" example:"19132"`
+}
+
+// InstallGeyser downloads the latest Geyser and Floodgate builds into a
+// stopped server and configures Geyser to take Bedrock players on a UDP
+// port of its own, authenticated by Floodgate. Installing again updates
+// both plugins and keeps the port.
+func (sm *ServerManager) InstallGeyser(id uint, userID uint) (*GeyserInstall, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return nil, err
+	}
+	if srv.IsRunning() {
+		return nil, ErrServerRunning
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+
+	port := server.BedrockPort(serverModel.Path)
+	if port == 0 {
+		if port, err = sm.allocateBedrockPort(); err != nil {
+			return nil, err
+		}
+	}
+
+	platform := server.PluginPlatform(serverModel)
+	install := &GeyserInstall{BedrockPort: port}
+	for _, name := range []string{model.AddonGeyser, model.AddonFloodgate} {
+		addon, err := sm.installGeyserProject(serverModel, name, platform)
+		if err != nil {
+			return nil, err
+		}
+		install.Addons = append(install.Addons, *addon)
+	}
+
+	if err := server.ConfigureGeyser(serverModel.Path, platform, port); err != nil {
+		return nil, fmt.Errorf("failed to configure geyser: %w", err)
+	}
+	slog.Info("Installed Geyser", "server_id", id, "platform", platform, "bedrock_port", port)
+	return install, nil
+}
+
+// installGeyserProject downloads the latest build of a GeyserMC project
+// into the plugins directory and records it as addon
+func (sm *ServerManager) installGeyserProject(serverModel *model.Server, project, platform string) (*model.Addon, error) {
+	build, err := latestGeyserBuild(project)
+	if err != nil {
+		return nil, err
+	}
+	download, ok := build.Downloads[platform]
+	if !ok || download.Name == "" {
+		return nil, fmt.Errorf("%s has no %s build", project, platform)
+	}
+
+	relPath := filepath.Join("plugins", filepath.Base(download.Name))
+	url := fmt.Sprintf(geyserBuildURL+"/downloads/%s", project, platform)
+	slog.Info("Downloading addon", "addon", project, "version", build.Version, "build", build.Build, "url", url)
+	if err := downloadVerified(url, serverModel.Path, relPath, download.Sha256); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", project, err)
+	}
+
+	addon := model.Addon{ServerID: serverModel.ID, Name: project}
+	err = sm.db.Where("server_id = ? AND name = ?", serverModel.ID, project).First(&addon).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch addon: %w", err)
+	}
+	// A jar of an older version has another name
+	if addon.Path != "" && addon.Path != relPath {
+		utils.RemoveIn(serverModel.Path, addon.Path)
+	}
+	addon.Platform = platform
+	addon.Version = build.Version + "-b" + strconv.Itoa(build.Build)
+	addon.Path = relPath
+	if err := sm.db.Save(&addon).Error; err != nil {
+		return nil, fmt.Errorf("failed to record addon: %w", err)
+	}
+	return &addon, nil
+}
+
+// downloadVerified downloads url to name below serverPath and checks its
+// SHA-256 digest. The download is verified outside the server directory and
+// copied in without following links the server may have placed.
+func downloadVerified(url, serverPath, name, sha string) error {
+	tmpDir, err := os.MkdirTemp("", "mcgonalds-addon-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	tmp := filepath.Join(tmpDir, "addon.jar")
+	if err := downloadFile(url, tmp); err != nil {
+		return err
+	}
+	file, err := os.Open(tmp)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	if sha != "" && hex.EncodeToString(hash.Sum(nil)) != sha {
+		return fmt.Errorf("checksum mismatch")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	dest, err := utils.OpenIn(serverPath, name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dest, file)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// allocateBedrockPort returns the lowest UDP port from the Bedrock default
+// upwards that no other server's Geyser is configured for and nothing is
+// bound to
+func (sm *ServerManager) allocateBedrockPort() (int, error) {
+	var servers []model.Server
+	if err := sm.db.Find(&servers).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch servers: %w", err)
+	}
+	used := make(map[int]bool)
+	for _, srv := range servers {
+		used[server.BedrockPort(srv.Path)] = true
+	}
+
+	for port := server.DefaultBedrockPort; port <= 65535; port++ {
+		if !used[port] && udpPortAvailable(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free UDP port available")
+}
+
+func udpPortAvailable(port int) bool {
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// ListAddons returns the addons installed into a server
+func (sm *ServerManager) ListAddons(id uint, userID uint) ([]model.Addon, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionView); err != nil {
+		return nil, err
+	}
+	var addons []model.Addon
+	if err := sm.db.Where("server_id = ?", id).Order("name").Find(&addons).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch addons: %w", err)
+	}
+	return addons, nil
+}
+
+// RemoveAddon deletes the jar of an addon from a stopped server. Its
+// configuration stays, so installing it again picks it up.
+func (sm *ServerManager) RemoveAddon(id, addonID uint, userID uint) error {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return err
+	}
+	if srv.IsRunning() {
+		return ErrServerRunning
+	}
+	var addon model.Addon
+	if err := sm.db.Where("id = ? AND server_id = ?", addonID, id).First(&addon).Error; err != nil {
+		return err
+	}
+	if err := utils.RemoveIn(serverModel.Path, addon.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", addon.Path, err)
+	}
+	if err := sm.db.Delete(&addon).Error; err != nil {
+		return fmt.Errorf("failed to delete addon: %w", err)
+	}
+	slog.Info("Removed addon", "server_id", id, "addon", addon.Name)
+	return nil
+}
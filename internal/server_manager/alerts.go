@@ -0,0 +1,418 @@
+package server_manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+// alertHistory is how many alerts ListAlerts returns
+const alertHistory = 100
+
+// alertClient posts to alert channels without reaching the manager's network
+var alertClient = publicClient(webhookTimeout)
+
+// alertKey identifies the state of one rule on one server
+type alertKey struct {
+	rule   uint
+	server uint
+}
+
+// SetMailer makes the manager send alert emails through mailer
+func (sm *ServerManager) SetMailer(mailer *utils.Mailer) {
+	sm.mailer = mailer
+}
+
+// CreateAlertChannel registers where alert notifications go: an email
+// address, a Discord webhook URL or a URL receiving the alert as JSON
+func (sm *ServerManager) CreateAlertChannel(userID uint, name, channelType, target string) (*model.AlertChannel, error) {
+	switch channelType {
+	case model.AlertChannelEmail:
+		if _, err := mail.ParseAddress(target); err != nil {
+			return nil, fmt.Errorf("invalid email address %q", target)
+		}
+	case model.AlertChannelDiscord, model.AlertChannelWebhook:
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid URL %q", target)
+		}
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", channelType)
+	}
+
+	channel := &model.AlertChannel{UserID: userID, Name: name, Type: channelType, Target: target}
+	if err := sm.db.Create(channel).Error; err != nil {
+		return nil, fmt.Errorf("failed to create alert channel: %w", err)
+	}
+	return channel, nil
+}
+
+// ListAlertChannels returns the alert channels of a user
+func (sm *ServerManager) ListAlertChannels(userID uint) ([]model.AlertChannel, error) {
+	var channels []model.AlertChannel
+	if err := sm.db.Where("user_id = ?", userID).Order("id").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch alert channels: %w", err)
+	}
+	return channels, nil
+}
+
+// DeleteAlertChannel removes an alert channel no rule uses
+func (sm *ServerManager) DeleteAlertChannel(id uint, userID uint) error {
+	var channel model.AlertChannel
+	if err := sm.db.Where("id = ? AND user_id = ?", id, userID).First(&channel).Error; err != nil {
+		return err
+	}
+	var rules []model.AlertRule
+	if err := sm.db.Where("user_id = ?", userID).Find(&rules).Error; err != nil {
+		return fmt.Errorf("failed to fetch alert rules: %w", err)
+	}
+	for _, rule := range rules {
+		if slices.Contains(alertChannelIDs(rule.Channels), id) {
+			return fmt.Errorf("channel is used by alert rule %q", rule.Name)
+		}
+	}
+	return sm.db.Delete(&channel).Error
+}
+
+// AlertRuleOptions describes an alert rule to create
+type AlertRuleOptions struct {
+	Name string
+	// ServerID limits the rule to one server; nil watches all servers of
+	// the user
+	ServerID  *uint
+	Condition string
+	Threshold float64
+	// For is how long the condition must hold before the rule fires
+	For        time.Duration
+	ChannelIDs []uint
+}
+
+// CreateAlertRule creates an alert rule that notifies the given channels
+func (sm *ServerManager) CreateAlertRule(userID uint, opts AlertRuleOptions) (*model.AlertRule, error) {
+	if !slices.Contains(model.AlertConditions, opts.Condition) {
+		return nil, fmt.Errorf("unknown condition %q", opts.Condition)
+	}
+	if opts.For < 0 {
+		return nil, fmt.Errorf("duration must not be negative")
+	}
+	if opts.ServerID != nil {
+		if _, _, err := sm.ownedServer(*opts.ServerID, userID, model.PermissionManage); err != nil {
+			return nil, err
+		}
+	}
+	ids := make([]string, 0, len(opts.ChannelIDs))
+	for _, channelID := range opts.ChannelIDs {
+		var count int64
+		if err := sm.db.Model(&model.AlertChannel{}).Where("id = ? AND user_id = ?", channelID, userID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check alert channel: %w", err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("unknown alert channel %d", channelID)
+		}
+		ids = append(ids, strconv.FormatUint(uint64(channelID), 10))
+	}
+
+	rule := &model.AlertRule{
+		UserID:     userID,
+		ServerID:   opts.ServerID,
+		Name:       opts.Name,
+		Condition:  opts.Condition,
+		Threshold:  opts.Threshold,
+		ForSeconds: int(opts.For.Seconds()),
+		Channels:   strings.Join(ids, ","),
+		Enabled:    true,
+	}
+	if err := sm.db.Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListAlertRules returns the alert rules of a user
+func (sm *ServerManager) ListAlertRules(userID uint) ([]model.AlertRule, error) {
+	var rules []model.AlertRule
+	if err := sm.db.Where("user_id = ?", userID).Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// DeleteAlertRule removes an alert rule and its alerts
+func (sm *ServerManager) DeleteAlertRule(id uint, userID uint) error {
+	var rule model.AlertRule
+	if err := sm.db.Where("id = ? AND user_id = ?", id, userID).First(&rule).Error; err != nil {
+		return err
+	}
+	return sm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_id = ?", rule.ID).Delete(&model.Alert{}).Error; err != nil {
+			return fmt.Errorf("failed to delete alerts: %w", err)
+		}
+		return tx.Delete(&rule).Error
+	})
+}
+
+// ListAlerts returns the most recent alerts of a user, optionally of one
+// server or only those still firing
+func (sm *ServerManager) ListAlerts(userID uint, serverID *uint, firingOnly bool) ([]model.Alert, error) {
+	query := sm.db.Where("user_id = ?", userID)
+	if serverID != nil {
+		query = query.Where("server_id = ?", *serverID)
+	}
+	if firingOnly {
+		query = query.Where("state = ?", model.AlertFiring)
+	}
+	alerts := []model.Alert{}
+	if err := query.Order("id DESC").Limit(alertHistory).Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// evaluateAlerts checks every enabled rule against the servers it watches,
+// using the samples just taken. A rule fires once its condition has held
+// for its duration and resolves when the condition clears.
+func (sm *ServerManager) evaluateAlerts(now time.Time, samples map[uint]model.MetricSample) {
+	var rules []model.AlertRule
+	if err := sm.db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		slog.Error("Failed to fetch alert rules", "error", err)
+		return
+	}
+	var open []model.Alert
+	if err := sm.db.Where("state = ?", model.AlertFiring).Find(&open).Error; err != nil {
+		slog.Error("Failed to fetch firing alerts", "error", err)
+		return
+	}
+	firing := make(map[alertKey]*model.Alert, len(open))
+	for i := range open {
+		firing[alertKey{open[i].RuleID, open[i].ServerID}] = &open[i]
+	}
+
+	seen := make(map[alertKey]bool)
+	for i := range rules {
+		rule := &rules[i]
+		servers, err := sm.alertServers(rule)
+		if err != nil {
+			slog.Error("Failed to fetch servers of alert rule", "rule_id", rule.ID, "error", err)
+			continue
+		}
+		for j := range servers {
+			serverModel := &servers[j]
+			key := alertKey{rule.ID, serverModel.ID}
+			seen[key] = true
+
+			value, breached := sm.measureAlert(rule, serverModel, samples)
+			if !breached {
+				delete(sm.alertPending, key)
+				if alert := firing[key]; alert != nil {
+					sm.resolveAlert(rule, alert, now)
+				}
+				continue
+			}
+			since, pending := sm.alertPending[key]
+			if !pending {
+				since = now
+				sm.alertPending[key] = now
+			}
+			if firing[key] == nil && now.Sub(since) >= time.Duration(rule.ForSeconds)*time.Second {
+				sm.fireAlert(rule, serverModel, value, now)
+			}
+		}
+	}
+
+	// Alerts of deleted or disabled rules and of deleted servers resolve
+	// silently
+	for key, alert := range firing {
+		if !seen[key] {
+			sm.resolveAlert(nil, alert, now)
+		}
+	}
+	for key := range sm.alertPending {
+		if !seen[key] {
+			delete(sm.alertPending, key)
+		}
+	}
+}
+
+// alertServers returns the servers a rule watches
+func (sm *ServerManager) alertServers(rule *model.AlertRule) ([]model.Server, error) {
+	var servers []model.Server
+	query := sm.db.Where("user_id = ?", rule.UserID)
+	if rule.ServerID != nil {
+		query = sm.db.Where("id = ?", *rule.ServerID)
+	}
+	if err := query.Find(&servers).Error; err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+// measureAlert returns the value the condition of rule looks at on a server
+// and whether it breaches the rule. Servers without a value, such as
+// stopped servers for TPS, do not breach.
+func (sm *ServerManager) measureAlert(rule *model.AlertRule, serverModel *model.Server, samples map[uint]model.MetricSample) (float64, bool) {
+	sample, sampled := samples[serverModel.ID]
+	switch rule.Condition {
+	case model.AlertServerDown:
+		sm.mutex.RLock()
+		srv := sm.servers[serverModel.ID]
+		sm.mutex.RUnlock()
+		running := srv != nil && srv.IsRunning()
+		if serverModel.Status == model.ServerStatusCrashed || (serverModel.AutoStart && !running) {
+			return 1, true
+		}
+		return 0, false
+	case model.AlertTPSBelow:
+		if !sampled || sample.TPS == nil {
+			return 0, false
+		}
+		return *sample.TPS, *sample.TPS < rule.Threshold
+	case model.AlertCPUAbove:
+		if !sampled {
+			return 0, false
+		}
+		return sample.CPUPercent, sample.CPUPercent > rule.Threshold
+	case model.AlertDiskAbove:
+		if serverModel.NodeID != nil {
+			return 0, false
+		}
+		used, err := utils.DiskUsage(serverModel.Path)
+		if err != nil {
+			return 0, false
+		}
+		return used, used > rule.Threshold
+	}
+	return 0, false
+}
+
+// alertMessage describes a breach of rule on the server named name
+func alertMessage(rule *model.AlertRule, name string, value float64) string {
+	switch rule.Condition {
+	case model.AlertServerDown:
+		return fmt.Sprintf("%s is down", name)
+	case model.AlertTPSBelow:
+		return fmt.Sprintf("%s runs at %.1f TPS, below %g", name, value, rule.Threshold)
+	case model.AlertCPUAbove:
+		return fmt.Sprintf("%s uses %.0f%% CPU, above %g%%", name, value, rule.Threshold)
+	case model.AlertDiskAbove:
+		return fmt.Sprintf("The disk of %s is %.0f%% full, above %g%%", name, value, rule.Threshold)
+	}
+	return fmt.Sprintf("%s breaches %s", name, rule.Condition)
+}
+
+// fireAlert records a new alert and notifies the rule's channels
+func (sm *ServerManager) fireAlert(rule *model.AlertRule, serverModel *model.Server, value float64, now time.Time) {
+	alert := &model.Alert{
+		RuleID:   rule.ID,
+		ServerID: serverModel.ID,
+		UserID:   rule.UserID,
+		State:    model.AlertFiring,
+		Value:    value,
+		Message:  alertMessage(rule, serverModel.Name, value),
+		FiredAt:  now,
+	}
+	if err := sm.db.Create(alert).Error; err != nil {
+		slog.Error("Failed to record alert", "rule_id", rule.ID, "server_id", serverModel.ID, "error", err)
+		return
+	}
+	slog.Warn("Alert firing", "rule_id", rule.ID, "server_id", serverModel.ID, "message", alert.Message)
+	go sm.notifyAlert(*rule, *alert)
+}
+
+// resolveAlert marks an alert resolved and, if rule is set, notifies the
+// rule's channels
+func (sm *ServerManager) resolveAlert(rule *model.AlertRule, alert *model.Alert, now time.Time) {
+	alert.State = model.AlertResolved
+	alert.ResolvedAt = &now
+	if err := sm.db.Model(alert).Updates(map[string]interface{}{"state": alert.State, "resolved_at": now}).Error; err != nil {
+		slog.Error("Failed to resolve alert", "alert_id", alert.ID, "error", err)
+		return
+	}
+	slog.Info("Alert resolved", "rule_id", alert.RuleID, "server_id", alert.ServerID)
+	if rule != nil {
+		go sm.notifyAlert(*rule, *alert)
+	}
+}
+
+// notifyAlert sends an alert to every channel of its rule
+func (sm *ServerManager) notifyAlert(rule model.AlertRule, alert model.Alert) {
+	ids := alertChannelIDs(rule.Channels)
+	if len(ids) == 0 {
+		return
+	}
+	var channels []model.AlertChannel
+	if err := sm.db.Where("id IN ? AND user_id = ?", ids, rule.UserID).Find(&channels).Error; err != nil {
+		slog.Error("Failed to fetch alert channels", "rule_id", rule.ID, "error", err)
+		return
+	}
+	for i := range channels {
+		if err := sm.sendAlert(&channels[i], &rule, &alert); err != nil {
+			slog.Warn("Failed to send alert notification", "channel_id", channels[i].ID, "alert_id", alert.ID, "error", err)
+		}
+	}
+}
+
+// alertPayload is the JSON body webhook channels receive
+type alertPayload struct {
+	Rule  *model.AlertRule `json:"rule"`
+	Alert *model.Alert     `json:"alert"`
+}
+
+// sendAlert delivers an alert to one channel
+func (sm *ServerManager) sendAlert(channel *model.AlertChannel, rule *model.AlertRule, alert *model.Alert) error {
+	subject := fmt.Sprintf("[%s] %s", alert.State, rule.Name)
+	text := alert.Message
+	if alert.State == model.AlertResolved {
+		text = "Resolved: " + text
+	}
+	switch channel.Type {
+	case model.AlertChannelEmail:
+		if sm.mailer == nil {
+			return utils.ErrMailDisabled
+		}
+		return sm.mailer.Send(channel.Target, subject, text+"\n")
+	case model.AlertChannelDiscord:
+		return postAlert(channel.Target, map[string]string{"content": "**" + subject + "**\n" + text})
+	case model.AlertChannelWebhook:
+		return postAlert(channel.Target, alertPayload{Rule: rule, Alert: alert})
+	}
+	return fmt.Errorf("unknown channel type %q", channel.Type)
+}
+
+// postAlert posts payload as JSON to target
+func postAlert(target string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := alertClient.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// alertChannelIDs parses the channel list of a rule
+func alertChannelIDs(channels string) []uint {
+	var ids []uint
+	for _, field := range strings.Split(channels, ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
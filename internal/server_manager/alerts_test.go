@@ -0,0 +1,61 @@
+package server_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestAlertChannelIDs(t *testing.T) {
+	if got := alertChannelIDs("3, 5,x,"); !reflect.DeepEqual(got, []uint{3, 5}) {
+		t.Errorf("alertChannelIDs = %v, want [3 5]", got)
+	}
+	if got := alertChannelIDs(""); got != nil {
+		t.Errorf("alertChannelIDs of empty list = %v", got)
+	}
+}
+
+func TestAlertMessage(t *testing.T) {
+	rule := &model.AlertRule{Condition: model.AlertTPSBelow, Threshold: 15}
+	if got, want := alertMessage(rule, "survival", 12.34), "survival runs at 12.3 TPS, below 15"; got != want {
+		t.Errorf("alertMessage = %q, want %q", got, want)
+	}
+}
+
+func TestSendAlertToDiscord(t *testing.T) {
+	var payload map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sm := &ServerManager{}
+	channel := &model.AlertChannel{Type: model.AlertChannelDiscord, Target: srv.URL}
+	rule := &model.AlertRule{Name: "Low TPS"}
+	alert := &model.Alert{State: model.AlertResolved, Message: "survival runs at 12.3 TPS, below 15"}
+
+	// The default client never reaches a loopback address
+	if err := sm.sendAlert(channel, rule, alert); !errors.Is(err, ErrAddressNotPublic) {
+		t.Fatalf("loopback alert returned %v", err)
+	}
+
+	defer func(client *http.Client) { alertClient = client }(alertClient)
+	alertClient = srv.Client()
+	if err := sm.sendAlert(channel, rule, alert); err != nil {
+		t.Fatal(err)
+	}
+	if want := "**[resolved] Low TPS**\nResolved: survival runs at 12.3 TPS, below 15"; payload["content"] != want {
+		t.Errorf("content = %q, want %q", payload["content"], want)
+	}
+
+	channel.Type = model.AlertChannelEmail
+	if err := sm.sendAlert(channel, rule, alert); err == nil {
+		t.Error("email without a mailer succeeded")
+	}
+}
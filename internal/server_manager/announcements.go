@@ -0,0 +1,221 @@
+package server_manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// announcementSchedulerInterval is how often the scheduler looks for
+	// due announcements. Cron expressions have minute resolution as well.
+	announcementSchedulerInterval = time.Minute
+	// minAnnouncementInterval is the shortest interval an announcement can repeat at
+	minAnnouncementInterval = time.Minute
+)
+
+// AnnouncementOptions holds the user-editable fields of an announcement.
+// Exactly one of Cron and Interval is set.
+type AnnouncementOptions struct {
+	Message  string
+	Format   string
+	Cron     string
+	Interval time.Duration
+	Enabled  bool
+}
+
+// validate checks the options and returns them normalized
+func (opts AnnouncementOptions) validate() (AnnouncementOptions, error) {
+	if opts.Format == "" {
+		opts.Format = model.AnnouncementSay
+	}
+	opts.Message = strings.TrimSpace(opts.Message)
+	if opts.Message == "" {
+		return opts, fmt.Errorf("message must not be empty")
+	}
+	switch opts.Format {
+	case model.AnnouncementSay:
+		if strings.ContainsAny(opts.Message, "\r\n") {
+			return opts, fmt.Errorf("say messages must be a single line; use tellraw for several lines")
+		}
+	case model.AnnouncementTellraw:
+	default:
+		return opts, fmt.Errorf("unknown format %q", opts.Format)
+	}
+	if (opts.Cron == "") == (opts.Interval == 0) {
+		return opts, fmt.Errorf("set either a cron expression or an interval")
+	}
+	if opts.Cron != "" {
+		if _, err := cron.ParseStandard(opts.Cron); err != nil {
+			return opts, fmt.Errorf("invalid cron expression %q: %w", opts.Cron, err)
+		}
+	} else if opts.Interval < minAnnouncementInterval {
+		return opts, fmt.Errorf("interval must be at least %s", minAnnouncementInterval)
+	}
+	return opts, nil
+}
+
+// CreateAnnouncement schedules a message to be broadcast on a server
+func (sm *ServerManager) CreateAnnouncement(id uint, userID uint, opts AnnouncementOptions) (*model.Announcement, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionConsole); err != nil {
+		return nil, err
+	}
+	opts, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	announcement := &model.Announcement{ServerID: id}
+	applyAnnouncementOptions(announcement, opts)
+	if err := sm.db.Create(announcement).Error; err != nil {
+		return nil, fmt.Errorf("failed to create announcement: %w", err)
+	}
+	return announcement, nil
+}
+
+// UpdateAnnouncement replaces the message and schedule of an announcement
+func (sm *ServerManager) UpdateAnnouncement(id, announcementID uint, userID uint, opts AnnouncementOptions) (*model.Announcement, error) {
+	announcement, err := sm.serverAnnouncement(id, announcementID, userID)
+	if err != nil {
+		return nil, err
+	}
+	opts, err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	applyAnnouncementOptions(announcement, opts)
+	if err := sm.db.Save(announcement).Error; err != nil {
+		return nil, fmt.Errorf("failed to update announcement: %w", err)
+	}
+	return announcement, nil
+}
+
+func applyAnnouncementOptions(announcement *model.Announcement, opts AnnouncementOptions) {
+	announcement.Message = opts.Message
+	announcement.Format = opts.Format
+	announcement.Cron = opts.Cron
+	announcement.IntervalSeconds = int(opts.Interval.Seconds())
+	announcement.Enabled = opts.Enabled
+}
+
+// ListAnnouncements returns the announcements of a server
+func (sm *ServerManager) ListAnnouncements(id uint, userID uint) ([]model.Announcement, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionConsole); err != nil {
+		return nil, err
+	}
+	announcements := []model.Announcement{}
+	if err := sm.db.Where("server_id = ?", id).Order("id").Find(&announcements).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch announcements: %w", err)
+	}
+	return announcements, nil
+}
+
+// DeleteAnnouncement removes an announcement of a server
+func (sm *ServerManager) DeleteAnnouncement(id, announcementID uint, userID uint) error {
+	announcement, err := sm.serverAnnouncement(id, announcementID, userID)
+	if err != nil {
+		return err
+	}
+	return sm.db.Unscoped().Delete(announcement).Error
+}
+
+func (sm *ServerManager) serverAnnouncement(id, announcementID uint, userID uint) (*model.Announcement, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionConsole); err != nil {
+		return nil, err
+	}
+	var announcement model.Announcement
+	if err := sm.db.Where("id = ? AND server_id = ?", announcementID, id).First(&announcement).Error; err != nil {
+		return nil, err
+	}
+	return &announcement, nil
+}
+
+// StartAnnouncementScheduler broadcasts due announcements on running servers
+// in the background until stop is closed
+func (sm *ServerManager) StartAnnouncementScheduler(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(announcementSchedulerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				sm.sendDueAnnouncements(now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// sendDueAnnouncements broadcasts every enabled announcement whose next run
+// is not in the future. Announcements due while their server is stopped are
+// skipped rather than sent on the next start.
+func (sm *ServerManager) sendDueAnnouncements(now time.Time) {
+	var announcements []model.Announcement
+	if err := sm.db.Where("enabled = ?", true).Find(&announcements).Error; err != nil {
+		slog.Error("Failed to fetch announcements", "error", err)
+		return
+	}
+
+	for i := range announcements {
+		announcement := &announcements[i]
+		next, err := nextAnnouncement(announcement)
+		if err != nil {
+			slog.Error("Announcement has an invalid schedule", "announcement_id", announcement.ID, "error", err)
+			continue
+		}
+		if next.After(now) {
+			continue
+		}
+		sm.db.Model(announcement).Update("last_sent_at", now)
+
+		sm.mutex.RLock()
+		srv, ok := sm.servers[announcement.ServerID]
+		sm.mutex.RUnlock()
+		if !ok || !srv.IsRunning() {
+			continue
+		}
+		if err := srv.SendCommand(announcementCommand(announcement)); err != nil {
+			slog.Warn("Failed to send announcement", "announcement_id", announcement.ID, "server_id", announcement.ServerID, "error", err)
+		}
+	}
+}
+
+// nextAnnouncement returns when an announcement is due next
+func nextAnnouncement(announcement *model.Announcement) (time.Time, error) {
+	last := announcement.CreatedAt
+	if announcement.LastSentAt != nil {
+		last = *announcement.LastSentAt
+	}
+	if announcement.Cron == "" {
+		return last.Add(time.Duration(announcement.IntervalSeconds) * time.Second), nil
+	}
+	spec, err := cron.ParseStandard(announcement.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return spec.Next(last), nil
+}
+
+// announcementCommand returns the console command broadcasting an
+// announcement. tellraw messages that are not a JSON text component are
+// sent as plain text.
+func announcementCommand(announcement *model.Announcement) string {
+	if announcement.Format != model.AnnouncementTellraw {
+		return "say " + announcement.Message
+	}
+	// Commands are a single line, so components are compacted
+	var component bytes.Buffer
+	message := strings.TrimSpace(announcement.Message)
+	if !strings.ContainsAny(message[:1], "{[") || json.Compact(&component, []byte(message)) != nil {
+		component.Reset()
+		encoded, _ := json.Marshal(map[string]string{"text": announcement.Message})
+		component.Write(encoded)
+	}
+	return "tellraw @a " + component.String()
+}
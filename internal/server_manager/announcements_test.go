@@ -0,0 +1,84 @@
+package server_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestAnnouncementCommand(t *testing.T) {
+	tests := []struct {
+		format, message, want string
+	}{
+		{model.AnnouncementSay, "Vote for us!", "say Vote for us!"},
+		{model.AnnouncementTellraw, "Line one\nLine two", `tellraw @a {"text":"Line one\nLine two"}`},
+		{model.AnnouncementTellraw, "{\n  \"text\": \"Hi\",\n  \"color\": \"gold\"\n}", `tellraw @a {"text":"Hi","color":"gold"}`},
+		{model.AnnouncementTellraw, "{not json", `tellraw @a {"text":"{not json"}`},
+	}
+	for _, test := range tests {
+		got := announcementCommand(&model.Announcement{Format: test.format, Message: test.message})
+		if got != test.want {
+			t.Errorf("announcementCommand(%q, %q) = %q, want %q", test.format, test.message, got, test.want)
+		}
+	}
+}
+
+func TestAnnouncementOptionsValidate(t *testing.T) {
+	valid := []AnnouncementOptions{
+		{Message: "Hi", Interval: 10 * time.Minute},
+		{Message: "Hi", Cron: "0 * * * *", Format: model.AnnouncementTellraw},
+	}
+	for _, opts := range valid {
+		if _, err := opts.validate(); err != nil {
+			t.Errorf("validate(%+v) = %v", opts, err)
+		}
+	}
+	invalid := []AnnouncementOptions{
+		{Message: "", Interval: time.Hour},
+		{Message: "Hi"},
+		{Message: "Hi", Interval: time.Hour, Cron: "0 * * * *"},
+		{Message: "Hi", Interval: time.Second},
+		{Message: "Hi\nthere", Interval: time.Hour},
+		{Message: "Hi", Cron: "every hour"},
+	}
+	for _, opts := range invalid {
+		if _, err := opts.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestNextAnnouncement(t *testing.T) {
+	created := time.Date(2024, 11, 10, 12, 5, 0, 0, time.UTC)
+	announcement := &model.Announcement{Cron: "0 * * * *"}
+	announcement.CreatedAt = created
+	if next, _ := nextAnnouncement(announcement); !next.Equal(time.Date(2024, 11, 10, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("next cron run = %v", next)
+	}
+	announcement.Cron = ""
+	announcement.IntervalSeconds = 600
+	sent := created.Add(time.Hour)
+	announcement.LastSentAt = &sent
+	if next, _ := nextAnnouncement(announcement); !next.Equal(sent.Add(10 * time.Minute)) {
+		t.Errorf("next interval run = %v", next)
+	}
+}
+
+func TestCreateDisabledAnnouncement(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "survival")
+
+	created, err := sm.CreateAnnouncement(id, owner.ID, AnnouncementOptions{Message: "Hi", Interval: 10 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored model.Announcement
+	if err := sm.db.First(&stored, created.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Enabled {
+		t.Error("announcement created disabled was stored enabled")
+	}
+}
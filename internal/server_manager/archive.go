@@ -0,0 +1,191 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/storage"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+var (
+	// ErrServerArchived is returned for operations that need the files of
+	// an archived server
+	ErrServerArchived = errors.New("server is archived; thaw it first")
+	// ErrServerNotArchived is returned when thawing a server that is not archived
+	ErrServerNotArchived = errors.New("server is not archived")
+)
+
+// ArchiveServer stops a server, packs its directory into cold storage and
+// removes the directory to free the disk. Archives go to the backup
+// storage when one is configured, next to the local backups otherwise.
+// The server cannot start until it is thawed.
+func (sm *ServerManager) ArchiveServer(ctx context.Context, id uint, userID uint) (*model.Server, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if serverModel.ArchivedAt != nil {
+		return nil, ErrServerArchived
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	fileName := fmt.Sprintf("%d-%d.tar.gz", id, now.UnixNano())
+	updates := map[string]interface{}{"archived_at": now}
+	if sm.backupStorage != nil {
+		updates["archive_target"] = sm.backupStorage.Name()
+		updates["archive_path"] = "archives/" + fileName
+	} else {
+		sharedDir, err := sm.sharedDir()
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Join(sharedDir, "archives")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create archive directory: %w", err)
+		}
+		if err := sm.checkDiskSpace(dir, DirSize(serverModel.Path)); err != nil {
+			return nil, err
+		}
+		updates["archive_target"] = model.BackupTargetLocal
+		updates["archive_path"] = filepath.Join(dir, fileName)
+	}
+
+	if err := srv.StopAndWait(stopTimeout); err != nil {
+		return nil, fmt.Errorf("failed to stop server: %w", err)
+	}
+	// Recording the archive first keeps the server from being started
+	// while its directory is packed
+	if err := sm.db.Model(serverModel).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update server: %w", err)
+	}
+	sm.closePlaceholder(id)
+
+	size, err := sm.writeArchive(serverModel)
+	if err != nil {
+		sm.removeArchive(serverModel)
+		sm.db.Model(serverModel).Updates(map[string]interface{}{"archived_at": nil, "archive_target": "", "archive_path": ""})
+		return nil, fmt.Errorf("failed to archive server: %w", err)
+	}
+	if err := sm.db.Model(serverModel).Update("archive_size_bytes", size).Error; err != nil {
+		return nil, fmt.Errorf("failed to update server: %w", err)
+	}
+	if err := os.RemoveAll(serverModel.Path); err != nil {
+		return nil, fmt.Errorf("failed to remove server directory: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Archived server", "server_id", id, "target", serverModel.ArchiveTarget, "bytes", size)
+	return serverModel, nil
+}
+
+// ThawServer restores the directory of an archived server from cold
+// storage and deletes the archive. The server is left stopped.
+func (sm *ServerManager) ThawServer(ctx context.Context, id uint, userID uint) (*model.Server, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if serverModel.ArchivedAt == nil {
+		return nil, ErrServerNotArchived
+	}
+	// The archive is compressed, so this is a lower bound of the thawed size
+	if err := sm.checkDiskSpace(serverModel.Path, serverModel.ArchiveSizeBytes); err != nil {
+		return nil, err
+	}
+
+	err = replaceDirectory(serverModel.Path, func(staging string) error {
+		if err := sm.readArchive(serverModel, staging); err != nil {
+			return fmt.Errorf("failed to extract archive: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sm.removeArchive(serverModel)
+	err = sm.db.Model(serverModel).Updates(map[string]interface{}{
+		"archived_at":        nil,
+		"archive_target":     "",
+		"archive_path":       "",
+		"archive_size_bytes": 0,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update server: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Thawed server", "server_id", id)
+	return serverModel, nil
+}
+
+// writeArchive packs the directory of a server into its archive
+func (sm *ServerManager) writeArchive(serverModel *model.Server) (int64, error) {
+	if serverModel.ArchiveTarget == model.BackupTargetLocal {
+		return utils.CreateTarGz(serverModel.Path, serverModel.ArchivePath, skipLockFiles)
+	}
+	backend, err := sm.archiveBackend(serverModel)
+	if err != nil {
+		return 0, err
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(utils.WriteTarGz(serverModel.Path, writer, skipLockFiles))
+	}()
+	tags := map[string]string{"server": fmt.Sprint(serverModel.ID), "kind": "archive"}
+	size, err := backend.Put(context.Background(), serverModel.ArchivePath, reader, tags)
+	reader.CloseWithError(err)
+	return size, err
+}
+
+// readArchive unpacks the archive of a server into destDir
+func (sm *ServerManager) readArchive(serverModel *model.Server, destDir string) error {
+	if serverModel.ArchiveTarget == model.BackupTargetLocal {
+		return utils.ExtractTarGz(serverModel.ArchivePath, destDir)
+	}
+	backend, err := sm.archiveBackend(serverModel)
+	if err != nil {
+		return err
+	}
+	object, err := backend.Get(context.Background(), serverModel.ArchivePath)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+	return utils.RestoreTarGz(object, destDir)
+}
+
+// removeArchive deletes the archive of a server, logging failures
+func (sm *ServerManager) removeArchive(serverModel *model.Server) {
+	var err error
+	if serverModel.ArchiveTarget == model.BackupTargetLocal {
+		if err = os.Remove(serverModel.ArchivePath); os.IsNotExist(err) {
+			err = nil
+		}
+	} else {
+		var backend storage.Backend
+		if backend, err = sm.archiveBackend(serverModel); err == nil {
+			err = backend.Delete(context.Background(), serverModel.ArchivePath)
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to remove server archive", "server_id", serverModel.ID, "path", serverModel.ArchivePath, "error", err)
+	}
+}
+
+// archiveBackend returns the storage backend a remote archive lives in
+func (sm *ServerManager) archiveBackend(serverModel *model.Server) (storage.Backend, error) {
+	if sm.backupStorage == nil || sm.backupStorage.Name() != serverModel.ArchiveTarget {
+		return nil, fmt.Errorf("archive of server %d is stored in %s, which is not configured", serverModel.ID, serverModel.ArchiveTarget)
+	}
+	return sm.backupStorage, nil
+}
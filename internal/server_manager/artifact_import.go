@@ -0,0 +1,172 @@
+package server_manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+const (
+	// maxImportSize bounds artifacts imported from a URL
+	maxImportSize = 2 << 30
+	// importTimeout bounds the whole download of an import
+	importTimeout = 30 * time.Minute
+)
+
+// ErrImportRejected is returned when the URL or the file it serves is not
+// acceptable as an artifact
+var ErrImportRejected = errors.New("import rejected")
+
+// ErrImportFailed is returned when the artifact could not be downloaded
+var ErrImportFailed = errors.New("import failed")
+
+// importContentTypes are the content types artifact downloads may declare.
+// Hosts often serve jars and zips as octet streams.
+var importContentTypes = []string{
+	"application/java-archive",
+	"application/x-java-archive",
+	"application/zip",
+	"application/x-zip-compressed",
+	"application/octet-stream",
+	"binary/octet-stream",
+}
+
+// zipMagic starts every jar and zip file
+var zipMagic = []byte("PK\x03\x04")
+
+// importClient downloads imports without reaching the manager's network
+var importClient = publicClient(importTimeout)
+
+// ImportJarFile downloads a common jar file from rawURL and registers it.
+// checksum is an optional SHA-256 digest, with or without a "sha256:"
+// prefix.
+func (sm *ServerManager) ImportJarFile(ctx context.Context, rawURL, checksum, name, version string) (*model.JarFile, error) {
+	tmp, filename, size, err := fetchArtifact(ctx, rawURL, checksum)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	baseName := strings.TrimSuffix(filename, path.Ext(filename))
+	return sm.UploadJarFile(ctx, name, version, tmp, baseName, size, "", true)
+}
+
+// ImportModPack downloads a common mod pack from rawURL and registers it
+// under name, or the name of the downloaded file if empty
+func (sm *ServerManager) ImportModPack(ctx context.Context, rawURL, checksum, name, version string) (*model.ModPack, error) {
+	tmp, filename, size, err := fetchArtifact(ctx, rawURL, checksum)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	return sm.UploadModPack(ctx, name, version, filename, tmp, size, "", true)
+}
+
+// fetchArtifact downloads rawURL into a temporary file, rewound for reading,
+// checking its size, content type and checksum. It returns the file with
+// the name the server gave it.
+func fetchArtifact(ctx context.Context, rawURL, checksum string) (*os.File, string, int64, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "", 0, fmt.Errorf("%w: only http and https URLs can be imported", ErrImportRejected)
+	}
+	checksum = strings.ToLower(strings.TrimPrefix(checksum, "sha256:"))
+	if checksum != "" {
+		if digest, err := hex.DecodeString(checksum); err != nil || len(digest) != sha256.Size {
+			return nil, "", 0, fmt.Errorf("%w: checksum must be a SHA-256 digest in hex", ErrImportRejected)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("%w: %v", ErrImportRejected, err)
+	}
+	resp, err := importClient.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrAddressNotPublic) {
+			return nil, "", 0, fmt.Errorf("%w: %v", ErrImportRejected, err)
+		}
+		return nil, "", 0, fmt.Errorf("%w: %v", ErrImportFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", 0, fmt.Errorf("%w: %s answered %s", ErrImportFailed, parsed.Host, resp.Status)
+	}
+	if resp.ContentLength > maxImportSize {
+		return nil, "", 0, fmt.Errorf("%w: file is larger than %d MB", ErrImportRejected, maxImportSize>>20)
+	}
+	if contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); contentType != "" && !slices.Contains(importContentTypes, contentType) {
+		return nil, "", 0, fmt.Errorf("%w: URL serves %s, not a jar or zip file", ErrImportRejected, contentType)
+	}
+
+	tmp, err := os.CreateTemp("", "mcgonalds-import-*")
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	fail := func(err error) (*os.File, string, int64, error) {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, "", 0, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return fail(fmt.Errorf("%w: %v", ErrImportFailed, err))
+	}
+	if size > maxImportSize {
+		return fail(fmt.Errorf("%w: file is larger than %d MB", ErrImportRejected, maxImportSize>>20))
+	}
+	if checksum != "" && hex.EncodeToString(hash.Sum(nil)) != checksum {
+		return fail(fmt.Errorf("%w: checksum mismatch", ErrImportRejected))
+	}
+
+	magic := make([]byte, len(zipMagic))
+	if _, err := tmp.ReadAt(magic, 0); err != nil || !bytes.Equal(magic, zipMagic) {
+		return fail(fmt.Errorf("%w: file is not a jar or zip file", ErrImportRejected))
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+
+	filename := importFilename(resp)
+	slog.InfoContext(ctx, "Downloaded artifact", "url", parsed.Redacted(), "file", filename, "bytes", size)
+	return tmp, filename, size, nil
+}
+
+// importFilename returns the name the server gave a download, falling back
+// to the last element of the final URL
+func importFilename(resp *http.Response) string {
+	name := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		name = path.Base(resp.Request.URL.Path)
+	}
+	// Never let the name escape the artifact directory
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		name = "artifact.jar"
+	}
+	return name
+}
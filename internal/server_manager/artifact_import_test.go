@@ -0,0 +1,62 @@
+package server_manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestFetchArtifact(t *testing.T) {
+	jar := []byte("PK\x03\x04 a jar")
+	sum := sha256.Sum256(jar)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/text.jar":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("not a jar"))
+		default:
+			w.Header().Set("Content-Type", "application/java-archive")
+			w.Write(jar)
+		}
+	}))
+	defer srv.Close()
+
+	// The default client never reaches a loopback address
+	if _, _, _, err := fetchArtifact(context.Background(), srv.URL+"/paper.jar", ""); !errors.Is(err, ErrImportRejected) {
+		t.Fatalf("loopback import returned %v", err)
+	}
+
+	defer func(client *http.Client) { importClient = client }(importClient)
+	importClient = srv.Client()
+
+	tmp, name, size, err := fetchArtifact(context.Background(), srv.URL+"/dl/paper.jar", "sha256:"+hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	if name != "paper.jar" || size != int64(len(jar)) {
+		t.Fatalf("fetched %q of %d bytes", name, size)
+	}
+
+	for path, checksum := range map[string]string{
+		"/paper.jar": hex.EncodeToString(make([]byte, sha256.Size)),
+		"/page":      "",
+		"/text.jar":  "",
+	} {
+		if _, _, _, err := fetchArtifact(context.Background(), srv.URL+path, checksum); !errors.Is(err, ErrImportRejected) {
+			t.Errorf("%s was not rejected: %v", path, err)
+		}
+	}
+	if _, _, _, err := fetchArtifact(context.Background(), "file:///etc/passwd", ""); !errors.Is(err, ErrImportRejected) {
+		t.Errorf("file URL was not rejected: %v", err)
+	}
+}
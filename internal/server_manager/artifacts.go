@@ -0,0 +1,181 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ErrArtifactInUse is returned when deleting a jar file or mod pack that is
+// still referenced by a server config or template
+var ErrArtifactInUse = errors.New("artifact is still in use")
+
+// artifactGCGrace is how long a file in the shared directories is left alone
+// after its last write. Uploads are written before their record is created,
+// so a younger file may belong to an upload still in flight.
+const artifactGCGrace = time.Hour
+
+// GCReport lists shared artifact files that no database record points at
+type GCReport struct {
+	Unreferenced []string `json:"unreferenced"`
+	Removed      []string `json:"removed"`
+	Bytes        int64    `json:"bytes"`
+}
+
+// sharedDir returns the directory shared artifacts are uploaded to. Like the
+// upload paths, the configured common dir is resolved against the working directory.
+func (sm *ServerManager) sharedDir() (string, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	return filepath.Join(currentDir, sm.commonDir), nil
+}
+
+// SetLinkMode sets how jar files and mod packs are placed into server
+// directories, one of the utils.Link constants
+func (sm *ServerManager) SetLinkMode(mode string) {
+	sm.limitsMutex.Lock()
+	defer sm.limitsMutex.Unlock()
+	sm.linkMode = mode
+}
+
+// linkArtifact places a shared jar file or mod pack into a server directory
+func (sm *ServerManager) linkArtifact(source, destination string) error {
+	sm.limitsMutex.RLock()
+	mode := sm.linkMode
+	sm.limitsMutex.RUnlock()
+	return utils.LinkArtifact(source, destination, mode)
+}
+
+// DeleteJarFile removes a jar file record and its file on disk, refusing
+// while any server config or template still references it
+func (sm *ServerManager) DeleteJarFile(id uint) error {
+	jarFile, err := sm.GetJarFileByID(id)
+	if err != nil {
+		return err
+	}
+
+	var references int64
+	if err := sm.db.Model(&model.ServerConfig{}).Where("jar_file_id = ?", id).Count(&references).Error; err != nil {
+		return fmt.Errorf("failed to check jar file references: %w", err)
+	}
+	if references > 0 {
+		return fmt.Errorf("%w: referenced by %d server(s)", ErrArtifactInUse, references)
+	}
+	if err := sm.db.Model(&model.Template{}).Where("jar_file_id = ?", id).Count(&references).Error; err != nil {
+		return fmt.Errorf("failed to check jar file references: %w", err)
+	}
+	if references > 0 {
+		return fmt.Errorf("%w: referenced by %d template(s)", ErrArtifactInUse, references)
+	}
+
+	if err := sm.db.Delete(jarFile).Error; err != nil {
+		return fmt.Errorf("failed to delete jar file record: %w", err)
+	}
+	if err := os.Remove(jarFile.Path); err != nil && !os.IsNotExist(err) {
+		slog.Error("Failed to remove jar file", "path", jarFile.Path, "error", err)
+	}
+	return nil
+}
+
+// DeleteModPack removes a mod pack record and its file on disk, refusing
+// while any server config or template still references it
+func (sm *ServerManager) DeleteModPack(id uint) error {
+	modPack, err := sm.GetModPackByID(id)
+	if err != nil {
+		return err
+	}
+
+	var references int64
+	if err := sm.db.Model(&model.ServerConfig{}).Where("mod_pack_id = ?", id).Count(&references).Error; err != nil {
+		return fmt.Errorf("failed to check mod pack references: %w", err)
+	}
+	if references > 0 {
+		return fmt.Errorf("%w: referenced by %d server(s)", ErrArtifactInUse, references)
+	}
+	if err := sm.db.Model(&model.Template{}).Where("mod_pack_id = ?", id).Count(&references).Error; err != nil {
+		return fmt.Errorf("failed to check mod pack references: %w", err)
+	}
+	if references > 0 {
+		return fmt.Errorf("%w: referenced by %d template(s)", ErrArtifactInUse, references)
+	}
+
+	if err := sm.db.Delete(modPack).Error; err != nil {
+		return fmt.Errorf("failed to delete mod pack record: %w", err)
+	}
+	if err := os.RemoveAll(modPack.Path); err != nil {
+		slog.Error("Failed to remove mod pack", "path", modPack.Path, "error", err)
+	}
+	return nil
+}
+
+// CollectGarbage scans the shared jar and mod pack directories for files no
+// record references. When remove is true the unreferenced files are deleted.
+// Files written within artifactGCGrace are skipped.
+func (sm *ServerManager) CollectGarbage(remove bool) (*GCReport, error) {
+	sharedDir, err := sm.sharedDir()
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+	var jarFiles []model.JarFile
+	if err := sm.db.Find(&jarFiles).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch jar files: %w", err)
+	}
+	for _, jarFile := range jarFiles {
+		referenced[filepath.Clean(jarFile.Path)] = true
+	}
+	var modPacks []model.ModPack
+	if err := sm.db.Find(&modPacks).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch mod packs: %w", err)
+	}
+	for _, modPack := range modPacks {
+		referenced[filepath.Clean(modPack.Path)] = true
+	}
+
+	report := &GCReport{Unreferenced: []string{}, Removed: []string{}}
+	for _, dir := range []string{"jar_files", "mod_packs"} {
+		entries, err := os.ReadDir(filepath.Join(sharedDir, dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(sharedDir, dir, entry.Name())
+			if referenced[path] {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if time.Since(info.ModTime()) < artifactGCGrace {
+				continue
+			}
+			report.Unreferenced = append(report.Unreferenced, path)
+			report.Bytes += info.Size()
+
+			if remove {
+				if err := os.RemoveAll(path); err != nil {
+					slog.Error("Failed to remove unreferenced artifact", "path", path, "error", err)
+					continue
+				}
+				report.Removed = append(report.Removed, path)
+			}
+		}
+	}
+
+	slog.Info("Artifact GC finished", "unreferenced", len(report.Unreferenced), "removed", len(report.Removed))
+	return report, nil
+}
@@ -0,0 +1,92 @@
+package server_manager
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// chdirTemp runs the test from a fresh directory and points the manager's
+// common dir at a relative path in it, as uploads resolve it against the
+// working directory
+func chdirTemp(t *testing.T, sm *ServerManager) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	sm.commonDir = "shared"
+}
+
+func TestCollectGarbageSkipsInFlightUploads(t *testing.T) {
+	sm := newTestManager(t)
+	chdirTemp(t, sm)
+	sharedDir, err := sm.sharedDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The upload is half written when the collector runs
+	reader, writer := io.Pipe()
+	type result struct {
+		path string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		jar, err := sm.UploadJarFile(context.Background(), "paper", "1.21", reader, "paper.jar", 0, "", true)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		done <- result{path: jar.Path}
+	}()
+	if _, err := writer.Write([]byte("half")); err != nil {
+		t.Fatal(err)
+	}
+
+	orphan := filepath.Join(sharedDir, "mod_packs", "orphan.zip")
+	if err := os.MkdirAll(filepath.Dir(orphan), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(orphan, []byte("orphan"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * artifactGCGrace)
+	if err := os.Chtimes(orphan, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := sm.CollectGarbage(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(report.Removed, []string{orphan}) {
+		t.Errorf("removed %v, want only %s", report.Removed, orphan)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("orphan was kept: %v", err)
+	}
+
+	writer.Write([]byte(" and the rest"))
+	writer.Close()
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	data, err := os.ReadFile(res.path)
+	if err != nil {
+		t.Fatalf("uploaded jar: %v", err)
+	}
+	if string(data) != "half and the rest" {
+		t.Errorf("uploaded jar holds %q", data)
+	}
+}
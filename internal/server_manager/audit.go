@@ -0,0 +1,71 @@
+package server_manager
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// AuditLogOptions filters and paginates audit log entries
+type AuditLogOptions struct {
+	Page    int
+	PerPage int
+	// UserID and ServerID restrict entries when non-zero
+	UserID   uint
+	ServerID uint
+	// Action matches entries whose action contains it
+	Action string
+}
+
+// RecordAudit stores an audit log entry. Failures are logged rather than
+// failing the request that was audited.
+func (sm *ServerManager) RecordAudit(entry *model.AuditLog) {
+	if err := sm.db.Create(entry).Error; err != nil {
+		slog.Error("Failed to record audit log entry", "action", entry.Action, "user_id", entry.UserID, "error", err)
+	}
+}
+
+// ListAuditLogs returns matching audit log entries, newest first, together
+// with the total number of matches
+func (sm *ServerManager) ListAuditLogs(opts AuditLogOptions) ([]model.AuditLog, int64, error) {
+	query := sm.db.Model(&model.AuditLog{})
+	if opts.UserID != 0 {
+		query = query.Where("user_id = ?", opts.UserID)
+	}
+	if opts.ServerID != 0 {
+		query = query.Where("server_id = ?", opts.ServerID)
+	}
+	if opts.Action != "" {
+		query = query.Where("action LIKE ?", "%"+opts.Action+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit log entries: %w", err)
+	}
+
+	page := opts.Page
+	if page < 1 {
+		page = 1
+	}
+	if opts.PerPage > 0 {
+		query = query.Offset((page - 1) * opts.PerPage).Limit(opts.PerPage)
+	}
+
+	var entries []model.AuditLog
+	if err := query.Order("id DESC").Find(&entries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+	return entries, total, nil
+}
+
+// ListServerAuditLogs returns the audit log of a server owned by userID
+func (sm *ServerManager) ListServerAuditLogs(id uint, userID uint, opts AuditLogOptions) ([]model.AuditLog, int64, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionManage); err != nil {
+		return nil, 0, err
+	}
+	opts.ServerID = id
+	opts.UserID = 0
+	return sm.ListAuditLogs(opts)
+}
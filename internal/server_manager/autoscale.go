@@ -0,0 +1,325 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidScalingPolicy is returned for scaling policies that cannot be
+// applied, such as a minimum above the maximum
+var ErrInvalidScalingPolicy = errors.New("invalid scaling policy")
+
+// autoscaleInterval is how often scaling policies are evaluated
+const autoscaleInterval = 30 * time.Second
+
+// maxInstanceNames bounds the search for a free instance name
+const maxInstanceNames = 1000
+
+// ScalingOptions configures the scaling policy of a proxy
+type ScalingOptions struct {
+	TemplateID     uint
+	MinInstances   int
+	MaxInstances   int
+	ScaleUpPlayers int
+	IdleMinutes    int
+	Enabled        bool
+}
+
+// SetScalingPolicy creates or replaces the scaling policy of a proxy. The
+// autoscaler creates instances on behalf of userID, so their quota applies.
+func (sm *ServerManager) SetScalingPolicy(proxyID uint, userID uint, opts ScalingOptions) (*model.ScalingPolicy, error) {
+	proxy, _, err := sm.ownedServer(proxyID, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if proxy.ProxyType == "" {
+		return nil, ErrNotProxy
+	}
+	if err := checkLocal(proxy); err != nil {
+		return nil, err
+	}
+	// An empty family has no server for players to join, so there is
+	// always at least one instance
+	switch {
+	case opts.MinInstances < 1:
+		return nil, fmt.Errorf("%w: min_instances must be at least 1", ErrInvalidScalingPolicy)
+	case opts.MaxInstances < opts.MinInstances:
+		return nil, fmt.Errorf("%w: max_instances must not be below min_instances", ErrInvalidScalingPolicy)
+	case opts.ScaleUpPlayers < 1:
+		return nil, fmt.Errorf("%w: scale_up_players must be at least 1", ErrInvalidScalingPolicy)
+	case opts.IdleMinutes < 1:
+		return nil, fmt.Errorf("%w: idle_minutes must be at least 1", ErrInvalidScalingPolicy)
+	}
+	if _, err := sm.GetTemplate(opts.TemplateID); err != nil {
+		return nil, fmt.Errorf("%w: template %d not found", ErrInvalidScalingPolicy, opts.TemplateID)
+	}
+
+	var policy model.ScalingPolicy
+	err = sm.db.Where("proxy_id = ?", proxyID).First(&policy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch scaling policy: %w", err)
+	}
+	policy.ProxyID = proxyID
+	policy.TemplateID = opts.TemplateID
+	policy.MinInstances = opts.MinInstances
+	policy.MaxInstances = opts.MaxInstances
+	policy.ScaleUpPlayers = opts.ScaleUpPlayers
+	policy.IdleMinutes = opts.IdleMinutes
+	policy.Enabled = opts.Enabled
+	policy.UserID = userID
+	if err := sm.db.Save(&policy).Error; err != nil {
+		return nil, fmt.Errorf("failed to save scaling policy: %w", err)
+	}
+
+	slog.Info("Set scaling policy", "proxy_id", proxyID, "template_id", opts.TemplateID,
+		"min", opts.MinInstances, "max", opts.MaxInstances, "enabled", opts.Enabled)
+	return &policy, nil
+}
+
+// GetScalingPolicy returns the scaling policy of a proxy
+func (sm *ServerManager) GetScalingPolicy(proxyID uint, userID uint) (*model.ScalingPolicy, error) {
+	if err := sm.Authorize(proxyID, userID, model.PermissionView); err != nil {
+		return nil, err
+	}
+	var policy model.ScalingPolicy
+	if err := sm.db.Where("proxy_id = ?", proxyID).First(&policy).Error; err != nil {
+		return nil, fmt.Errorf("scaling policy not found: %w", err)
+	}
+	return &policy, nil
+}
+
+// DeleteScalingPolicy stops autoscaling a proxy. Its instances are kept
+// and stay attached.
+func (sm *ServerManager) DeleteScalingPolicy(proxyID uint, userID uint) error {
+	if err := sm.Authorize(proxyID, userID, model.PermissionManage); err != nil {
+		return err
+	}
+	result := sm.db.Where("proxy_id = ?", proxyID).Delete(&model.ScalingPolicy{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete scaling policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("scaling policy not found: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// StartAutoscaler applies the enabled scaling policies until stop is closed
+func (sm *ServerManager) StartAutoscaler(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(autoscaleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				sm.autoscale(now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// scaledInstance is an autoscaled backend with its in-memory server
+type scaledInstance struct {
+	model model.Server
+	srv   *server.Server
+}
+
+// autoscale takes at most one scaling step for every enabled policy
+func (sm *ServerManager) autoscale(now time.Time) {
+	var policies []model.ScalingPolicy
+	if err := sm.db.Where("enabled = ?", true).Find(&policies).Error; err != nil {
+		slog.Error("Failed to fetch scaling policies", "error", err)
+		return
+	}
+
+	idle := make(map[uint]time.Time)
+	for i := range policies {
+		sm.scaleProxy(&policies[i], now, idle)
+	}
+	sm.scaleIdleSince = idle
+}
+
+// scaleProxy starts an instance of a proxy when fewer than the minimum run
+// or the running ones are full, and otherwise stops the newest instance
+// that has been empty for the idle time. Nothing changes while an instance
+// is still starting, as its players are not known yet. An instance counts
+// as empty from the first check that finds it started and without players.
+func (sm *ServerManager) scaleProxy(policy *model.ScalingPolicy, now time.Time, idle map[uint]time.Time) {
+	var instances []model.Server
+	if err := sm.db.Where("proxy_id = ? AND autoscaled = ?", policy.ProxyID, true).Order("id").Find(&instances).Error; err != nil {
+		slog.Error("Failed to fetch autoscaled instances", "proxy_id", policy.ProxyID, "error", err)
+		return
+	}
+
+	var running []scaledInstance
+	var stopped []model.Server
+	players, starting := 0, false
+	for _, instance := range instances {
+		sm.mutex.RLock()
+		srv, ok := sm.servers[instance.ID]
+		sm.mutex.RUnlock()
+		if !ok || !srv.IsRunning() {
+			if sm.StartQueuePosition(instance.ID) > 0 {
+				starting = true
+			}
+			stopped = append(stopped, instance)
+			continue
+		}
+		running = append(running, scaledInstance{model: instance, srv: srv})
+		select {
+		case <-srv.Ready():
+		default:
+			starting = true
+			continue
+		}
+		count := len(srv.OnlinePlayers())
+		players += count
+		if count == 0 {
+			since, seen := sm.scaleIdleSince[instance.ID]
+			if !seen {
+				since = now
+			}
+			idle[instance.ID] = since
+		}
+	}
+
+	switch {
+	case starting:
+		return
+	case len(running) < policy.MinInstances:
+		sm.scaleUp(policy, stopped, fmt.Sprintf("%d of at least %d instances running", len(running), policy.MinInstances))
+	case len(running) < policy.MaxInstances && players >= policy.ScaleUpPlayers*len(running):
+		sm.scaleUp(policy, stopped, fmt.Sprintf("%d players on %d instances", players, len(running)))
+	// Stopping must not leave the others at the scale up threshold, or the
+	// next check would start an instance again
+	case len(running) > policy.MinInstances && players < policy.ScaleUpPlayers*(len(running)-1):
+		delay := time.Duration(policy.IdleMinutes) * time.Minute
+		for i := len(running) - 1; i >= 0; i-- {
+			instance := running[i]
+			since, empty := idle[instance.model.ID]
+			if !empty || now.Sub(since) < delay {
+				continue
+			}
+			reason := fmt.Sprintf("empty for %s", now.Sub(since).Round(time.Second))
+			if err := instance.srv.Stop(); err != nil {
+				slog.Error("Failed to stop autoscaled instance", "proxy_id", policy.ProxyID, "server_id", instance.model.ID, "error", err)
+				return
+			}
+			// A stopping instance still runs for a while
+			delete(idle, instance.model.ID)
+			slog.Info("Scaled down", "proxy_id", policy.ProxyID, "server_id", instance.model.ID, "reason", reason)
+			sm.publish(scalingEvent(model.EventScaledDown, &instance.model, reason))
+			return
+		}
+	}
+}
+
+// scaleUp starts the oldest stopped instance, or creates a new one when
+// all of them run
+func (sm *ServerManager) scaleUp(policy *model.ScalingPolicy, stopped []model.Server, reason string) {
+	var instance *model.Server
+	if len(stopped) > 0 {
+		instance = &stopped[0]
+	} else {
+		created, err := sm.createInstance(policy)
+		if err != nil {
+			slog.Error("Failed to create autoscaled instance", "proxy_id", policy.ProxyID, "error", err)
+			return
+		}
+		instance = created
+	}
+
+	if err := sm.StartServer(context.Background(), instance.ID, policy.UserID); err != nil {
+		slog.Error("Failed to start autoscaled instance", "proxy_id", policy.ProxyID, "server_id", instance.ID, "error", err)
+		return
+	}
+	slog.Info("Scaled up", "proxy_id", policy.ProxyID, "server_id", instance.ID, "reason", reason)
+	sm.publish(scalingEvent(model.EventScaledUp, instance, reason))
+}
+
+// createInstance creates the next instance of a proxy from the policy's
+// template in a directory next to the proxy's, shares it with the proxy's
+// team and attaches it
+func (sm *ServerManager) createInstance(policy *model.ScalingPolicy) (*model.Server, error) {
+	var proxy model.Server
+	if err := sm.db.First(&proxy, policy.ProxyID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch proxy: %w", err)
+	}
+	name, path, err := sm.instanceName(&proxy)
+	if err != nil {
+		return nil, err
+	}
+	id, err := sm.CreateServerFromTemplate(policy.TemplateID, name, path, policy.UserID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var instance model.Server
+	err = sm.db.Model(&model.Server{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"autoscaled": true, "team_id": proxy.TeamID}).Error
+	if err == nil {
+		err = sm.db.First(&instance, id).Error
+	}
+	if err != nil {
+		sm.discardServer(id)
+		return nil, fmt.Errorf("failed to update instance: %w", err)
+	}
+	// Events carry the team of the cached model
+	sm.mutex.Lock()
+	sm.servers[id] = sm.newServer(&instance)
+	sm.mutex.Unlock()
+
+	attached, err := sm.AttachToProxy(proxy.ID, id, policy.UserID, "")
+	if err != nil {
+		sm.discardServer(id)
+		return nil, fmt.Errorf("failed to attach instance: %w", err)
+	}
+	return attached, nil
+}
+
+// instanceName returns the first free name of the form <proxy>-<n> with its
+// directory next to the proxy's
+func (sm *ServerManager) instanceName(proxy *model.Server) (string, string, error) {
+	for n := 1; n <= maxInstanceNames; n++ {
+		name := fmt.Sprintf("%s-%d", proxy.Name, n)
+		path := filepath.Join(filepath.Dir(proxy.Path), name)
+		var taken int64
+		if err := sm.db.Unscoped().Model(&model.Server{}).Where("name = ? OR path = ?", name, path).Count(&taken).Error; err != nil {
+			return "", "", fmt.Errorf("error checking for existing server: %w", err)
+		}
+		if taken > 0 {
+			continue
+		}
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return name, path, nil
+		}
+	}
+	return "", "", fmt.Errorf("no free instance name for proxy %s", proxy.Name)
+}
+
+// scalingEvent describes a scaling step on instance for the activity feed
+func scalingEvent(eventType string, instance *model.Server, reason string) model.Event {
+	return model.Event{
+		Type:     eventType,
+		ServerID: instance.ID,
+		UserID:   instance.UserID,
+		TeamID:   instance.TeamID,
+		Data: map[string]string{
+			"proxy_id": strconv.FormatUint(uint64(*instance.ProxyID), 10),
+			"name":     instance.Name,
+			"reason":   reason,
+		},
+	}
+}
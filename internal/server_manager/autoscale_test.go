@@ -0,0 +1,247 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// gameRuntime starts processes that finish loading at once and run until
+// interrupted. Tests write console lines to a server through log.
+type gameRuntime struct {
+	mutex     sync.Mutex
+	processes map[uint]*gameProcess
+}
+
+func (r *gameRuntime) Start(spec server.ProcessSpec) (server.Process, error) {
+	stdout, out := io.Pipe()
+	p := &gameProcess{stdout: stdout, out: out, exited: make(chan struct{})}
+	r.mutex.Lock()
+	r.processes[spec.ServerID] = p
+	r.mutex.Unlock()
+	go p.log("Done (1.0s)! For help, type \"help\"")
+	return p, nil
+}
+
+// log writes a console line to the running process of server id
+func (r *gameRuntime) log(t *testing.T, id uint, line string) {
+	t.Helper()
+	r.mutex.Lock()
+	p := r.processes[id]
+	r.mutex.Unlock()
+	if p == nil {
+		t.Fatalf("server %d was never started", id)
+	}
+	p.log(line)
+}
+
+type gameProcess struct {
+	stdout   io.Reader
+	out      *io.PipeWriter
+	exited   chan struct{}
+	exitOnce sync.Once
+}
+
+func (p *gameProcess) log(line string) {
+	fmt.Fprintf(p.out, "[12:00:00] [Server thread/INFO]: %s\n", line)
+}
+
+func (p *gameProcess) exit() error {
+	p.exitOnce.Do(func() {
+		p.out.Close()
+		close(p.exited)
+	})
+	return nil
+}
+
+func (p *gameProcess) Stdin() io.WriteCloser { return nopWriteCloser{io.Discard} }
+func (p *gameProcess) Stdout() io.Reader     { return p.stdout }
+func (p *gameProcess) Wait() error           { <-p.exited; return nil }
+func (p *gameProcess) Interrupt() error      { return p.exit() }
+func (p *gameProcess) Kill() error           { return p.exit() }
+func (p *gameProcess) PID() int              { return 0 }
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// waitFor polls cond until it holds or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAutoscaler(t *testing.T) {
+	sm := newTestManager(t)
+	runtime := &gameRuntime{processes: make(map[uint]*gameProcess)}
+	sm.SetRuntime(runtime)
+	owner := createTestUser(t, sm, "alice", model.RoleAdmin)
+	proxyID := createTestServer(t, sm, owner, "lobby")
+	gameID := createTestServer(t, sm, owner, "game")
+	if _, err := sm.SetProxyType(proxyID, owner.ID, "velocity"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Instances pass the preflight checks: a jar that looks like one, the
+	// EULA placed from the template and a stand-in java
+	jar := createTestJar(t, sm)
+	if err := os.WriteFile(jar.Path, []byte("PK\x03\x04"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	eula := &model.AdditionalFile{Name: "eula", Type: model.AdditionalFileConfig, Path: filepath.Join(sm.commonDir, "eula.txt")}
+	if err := os.WriteFile(eula.Path, []byte("eula=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.db.Create(eula).Error; err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "java"), []byte("#!/bin/sh\necho 'openjdk version \"21.0.2\"' >&2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	template := &model.Template{Name: "minigame", JarFileID: jar.ID, ExecutableCommand: "java -jar server.jar nogui"}
+	if err := sm.CreateTemplate(template, []uint{eula.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ScalingOptions{TemplateID: template.ID, MinInstances: 1, MaxInstances: 2, ScaleUpPlayers: 2, IdleMinutes: 1, Enabled: true}
+	invalid := opts
+	invalid.MaxInstances = 0
+	if _, err := sm.SetScalingPolicy(proxyID, owner.ID, invalid); !errors.Is(err, ErrInvalidScalingPolicy) {
+		t.Errorf("max below min: got %v, want ErrInvalidScalingPolicy", err)
+	}
+	invalid = opts
+	invalid.TemplateID = template.ID + 1
+	if _, err := sm.SetScalingPolicy(proxyID, owner.ID, invalid); !errors.Is(err, ErrInvalidScalingPolicy) {
+		t.Errorf("unknown template: got %v, want ErrInvalidScalingPolicy", err)
+	}
+	if _, err := sm.SetScalingPolicy(gameID, owner.ID, opts); !errors.Is(err, ErrNotProxy) {
+		t.Errorf("game server: got %v, want ErrNotProxy", err)
+	}
+	if _, err := sm.SetScalingPolicy(proxyID, owner.ID, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	events := sm.SubscribeEvents()
+	defer sm.UnsubscribeEvents(events)
+	nextScaling := func(want string) model.Event {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case event := <-events:
+				if event.Type == model.EventScaledUp || event.Type == model.EventScaledDown {
+					if event.Type != want {
+						t.Fatalf("got %s event, want %s", event.Type, want)
+					}
+					return event
+				}
+			case <-timeout:
+				t.Fatalf("no %s event", want)
+			}
+		}
+	}
+	ready := func(id uint) {
+		t.Helper()
+		_, srv, err := sm.ownedServer(id, owner.ID, model.PermissionView)
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, "the instance to start", func() bool {
+			select {
+			case <-srv.Ready():
+				return true
+			default:
+				return false
+			}
+		})
+	}
+	players := func(id uint, want int) {
+		t.Helper()
+		_, srv, err := sm.ownedServer(id, owner.ID, model.PermissionView)
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, fmt.Sprintf("%d players", want), func() bool { return len(srv.OnlinePlayers()) == want })
+	}
+
+	// Below the minimum, the first instance is created and attached
+	now := time.Now()
+	sm.autoscale(now)
+	first := nextScaling(model.EventScaledUp).ServerID
+	instance, _, err := sm.ownedServer(first, owner.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Name != "lobby-1" || !instance.Autoscaled || instance.ProxyID == nil || *instance.ProxyID != proxyID {
+		t.Fatalf("got instance %q attached to %v, want an autoscaled lobby-1 behind the proxy", instance.Name, instance.ProxyID)
+	}
+	ready(first)
+
+	// Two players fill the only instance
+	runtime.log(t, first, "Steve joined the game")
+	runtime.log(t, first, "Alex joined the game")
+	players(first, 2)
+	sm.autoscale(now)
+	second := nextScaling(model.EventScaledUp).ServerID
+	ready(second)
+
+	// At the maximum, more players start nothing
+	runtime.log(t, first, "Herobrine joined the game")
+	players(first, 3)
+	sm.autoscale(now)
+
+	// Once the players fit on one instance, the empty one is stopped after
+	// the idle time
+	runtime.log(t, first, "Herobrine left the game")
+	runtime.log(t, first, "Alex left the game")
+	players(first, 1)
+	sm.autoscale(now)
+	sm.autoscale(now.Add(30 * time.Second))
+	sm.autoscale(now.Add(2 * time.Minute))
+	if event := nextScaling(model.EventScaledDown); event.ServerID != second {
+		t.Fatalf("stopped server %d, want %d", event.ServerID, second)
+	}
+	_, srv, err := sm.ownedServer(second, owner.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the instance to stop", func() bool { return !srv.IsRunning() })
+
+	// The stopped instance is started again rather than a third created
+	runtime.log(t, first, "Alex joined the game")
+	players(first, 2)
+	sm.autoscale(now.Add(3 * time.Minute))
+	if event := nextScaling(model.EventScaledUp); event.ServerID != second {
+		t.Fatalf("started server %d, want %d", event.ServerID, second)
+	}
+	var count int64
+	if err := sm.db.Model(&model.Server{}).Where("autoscaled = ?", true).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("%d instances, want 2", count)
+	}
+
+	if err := sm.DeleteScalingPolicy(proxyID, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.GetScalingPolicy(proxyID, owner.ID); err == nil {
+		t.Error("policy still there after deleting it")
+	}
+}
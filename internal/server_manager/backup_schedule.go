@@ -0,0 +1,208 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// backupSchedulerInterval is how often the scheduler looks for due backups.
+// Cron expressions have minute resolution.
+const backupSchedulerInterval = time.Minute
+
+// BackupScheduleOptions holds the user-editable fields of a backup schedule
+type BackupScheduleOptions struct {
+	Cron          string
+	KeepLast      int
+	KeepDailyDays int
+	Incremental   bool
+	Enabled       bool
+}
+
+// SetBackupSchedule creates or replaces the backup schedule of a server
+func (sm *ServerManager) SetBackupSchedule(id uint, userID uint, opts BackupScheduleOptions) (*model.BackupSchedule, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionBackups)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cron.ParseStandard(opts.Cron); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", opts.Cron, err)
+	}
+	if opts.KeepLast < 0 || opts.KeepDailyDays < 0 {
+		return nil, fmt.Errorf("retention values must not be negative")
+	}
+
+	var schedule model.BackupSchedule
+	err = sm.db.Where("server_id = ?", serverModel.ID).First(&schedule).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch backup schedule: %w", err)
+	}
+
+	schedule.ServerID = serverModel.ID
+	schedule.UserID = userID
+	schedule.Cron = opts.Cron
+	schedule.KeepLast = opts.KeepLast
+	schedule.KeepDailyDays = opts.KeepDailyDays
+	schedule.Incremental = opts.Incremental
+	schedule.Enabled = opts.Enabled
+	if err := sm.db.Save(&schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to save backup schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// GetBackupSchedule returns the backup schedule of a server userID can access
+func (sm *ServerManager) GetBackupSchedule(id uint, userID uint) (*model.BackupSchedule, error) {
+	var schedule model.BackupSchedule
+	shared := sm.db.Model(&model.Server{}).Select("servers.id").Scopes(sm.serverAccess(userID))
+	if err := sm.db.Where("server_id = ? AND server_id IN (?)", id, shared).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// DeleteBackupSchedule stops scheduled backups of a server. Existing backups are kept.
+func (sm *ServerManager) DeleteBackupSchedule(id uint, userID uint) error {
+	schedule, err := sm.GetBackupSchedule(id, userID)
+	if err != nil {
+		return err
+	}
+	return sm.db.Unscoped().Delete(schedule).Error
+}
+
+// StartBackupScheduler runs due scheduled backups in the background until stop is closed
+func (sm *ServerManager) StartBackupScheduler(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(backupSchedulerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				sm.runDueBackups(now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// runDueBackups starts every enabled schedule whose next run is not in the future
+func (sm *ServerManager) runDueBackups(now time.Time) {
+	var schedules []model.BackupSchedule
+	if err := sm.db.Where("enabled = ?", true).Find(&schedules).Error; err != nil {
+		slog.Error("Failed to fetch backup schedules", "error", err)
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+		spec, err := cron.ParseStandard(schedule.Cron)
+		if err != nil {
+			slog.Error("Backup schedule has an invalid cron expression", "schedule_id", schedule.ID, "error", err)
+			continue
+		}
+		last := schedule.CreatedAt
+		if schedule.LastRunAt != nil {
+			last = *schedule.LastRunAt
+		}
+		if spec.Next(last).After(now) {
+			continue
+		}
+
+		sm.db.Model(schedule).Update("last_run_at", now)
+		go sm.runScheduledBackup(schedule, now)
+	}
+}
+
+// runScheduledBackup takes a backup for a schedule and then applies its retention rules
+func (sm *ServerManager) runScheduledBackup(schedule *model.BackupSchedule, now time.Time) {
+	err := sm.scheduledBackup(schedule, now)
+	lastError := ""
+	if err != nil {
+		slog.Error("Scheduled backup failed", "server_id", schedule.ServerID, "error", err)
+		lastError = err.Error()
+	}
+	sm.db.Model(schedule).Update("last_error", lastError)
+}
+
+func (sm *ServerManager) scheduledBackup(schedule *model.BackupSchedule, now time.Time) error {
+	serverModel, srv, err := sm.ownedServer(schedule.ServerID, schedule.UserID, model.PermissionBackups)
+	if err != nil {
+		return err
+	}
+
+	mode := model.BackupModeFull
+	if schedule.Incremental {
+		mode = model.BackupModeIncremental
+	}
+	backup, err := sm.newBackup(serverModel, "scheduled-"+now.UTC().Format("2006-01-02T15-04-05Z"), mode, true)
+	if err != nil {
+		return err
+	}
+	if err := sm.runBackup(context.Background(), schedule.ServerID, srv, backup); err != nil {
+		return err
+	}
+
+	return sm.pruneBackups(schedule, now)
+}
+
+// pruneBackups deletes the completed scheduled backups of a server that its
+// retention rules no longer keep. Manual backups are never pruned.
+func (sm *ServerManager) pruneBackups(schedule *model.BackupSchedule, now time.Time) error {
+	var backups []model.Backup
+	err := sm.db.Where("server_id = ? AND scheduled = ? AND status = ?", schedule.ServerID, true, model.BackupStatusCompleted).
+		Find(&backups).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch backups for pruning: %w", err)
+	}
+
+	for _, backup := range expiredBackups(backups, schedule.KeepLast, schedule.KeepDailyDays, now) {
+		if err := sm.removeBackupArchive(&backup); err != nil {
+			slog.Error("Failed to remove backup archive", "path", backup.Path, "error", err)
+			continue
+		}
+		if err := sm.db.Delete(&backup).Error; err != nil {
+			slog.Error("Failed to delete backup", "backup_id", backup.ID, "error", err)
+			continue
+		}
+		slog.Info("Pruned backup", "backup_id", backup.ID, "server_id", backup.ServerID)
+	}
+	return nil
+}
+
+// expiredBackups returns the backups that neither the keepLast newest nor the
+// newest backup of each of the last keepDailyDays days retain. With both
+// rules at zero nothing expires.
+func expiredBackups(backups []model.Backup, keepLast, keepDailyDays int, now time.Time) []model.Backup {
+	if keepLast <= 0 && keepDailyDays <= 0 {
+		return nil
+	}
+
+	sorted := make([]model.Backup, len(backups))
+	copy(sorted, backups)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	cutoff := now.UTC().AddDate(0, 0, -keepDailyDays)
+	seenDays := make(map[string]bool)
+	var expired []model.Backup
+	for i, backup := range sorted {
+		created := backup.CreatedAt.UTC()
+		day := created.Format("2006-01-02")
+		keepDaily := keepDailyDays > 0 && created.After(cutoff) && !seenDays[day]
+		seenDays[day] = true
+		if i < keepLast || keepDaily {
+			continue
+		}
+		expired = append(expired, backup)
+	}
+	return expired
+}
@@ -0,0 +1,73 @@
+package server_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestExpiredBackups(t *testing.T) {
+	now := time.Date(2024, 11, 10, 12, 0, 0, 0, time.UTC)
+	backup := func(id uint, age time.Duration) model.Backup {
+		b := model.Backup{}
+		b.ID = id
+		b.CreatedAt = now.Add(-age)
+		return b
+	}
+	backups := []model.Backup{
+		backup(1, 1*time.Hour),
+		backup(2, 2*time.Hour),
+		backup(3, 3*time.Hour),
+		backup(4, 25*time.Hour),
+		backup(5, 26*time.Hour),
+		backup(6, 10*24*time.Hour),
+	}
+
+	tests := []struct {
+		name          string
+		keepLast      int
+		keepDailyDays int
+		want          []uint
+	}{
+		{"no rules", 0, 0, nil},
+		{"keep last", 2, 0, []uint{3, 4, 5, 6}},
+		{"dailies", 0, 7, []uint{2, 3, 5, 6}},
+		{"both", 2, 7, []uint{3, 5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint
+			for _, b := range expiredBackups(backups, tt.keepLast, tt.keepDailyDays, now) {
+				got = append(got, b.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expired = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expired = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetBackupScheduleDisabled(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "survival")
+
+	for _, enabled := range []bool{false, true, false} {
+		if _, err := sm.SetBackupSchedule(id, owner.ID, BackupScheduleOptions{Cron: "0 4 * * *", Enabled: enabled}); err != nil {
+			t.Fatal(err)
+		}
+		schedule, err := sm.GetBackupSchedule(id, owner.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if schedule.Enabled != enabled {
+			t.Errorf("stored enabled %v, want %v", schedule.Enabled, enabled)
+		}
+	}
+}
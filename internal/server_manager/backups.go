@@ -0,0 +1,579 @@
+package server_manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/storage"
+	"github.com/olindenbaum/mcgonalds/internal/tracing"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// saveFlushTimeout bounds how long we wait for "save-all flush" to complete
+const saveFlushTimeout = 60 * time.Second
+
+// CreateBackup starts an asynchronous backup of a server directory. The
+// returned record is in the running state; poll it to see the outcome.
+// mode is model.BackupModeFull (the default when empty) or model.BackupModeIncremental.
+func (sm *ServerManager) CreateBackup(ctx context.Context, id uint, userID uint, name, mode string) (*model.Backup, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := sm.newBackup(serverModel, name, mode, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The backup outlives the request but stays part of its trace
+	go sm.runBackup(context.WithoutCancel(ctx), id, srv, backup)
+
+	return backup, nil
+}
+
+// newBackup records a running backup of a server. It holds the files of the
+// server until runBackup is done with them.
+func (sm *ServerManager) newBackup(serverModel *model.Server, name, mode string, scheduled bool) (*model.Backup, error) {
+	if mode == "" {
+		mode = model.BackupModeFull
+	}
+	if mode != model.BackupModeFull && mode != model.BackupModeIncremental {
+		return nil, fmt.Errorf("unknown backup mode %q", mode)
+	}
+	if mode == model.BackupModeIncremental && sm.backupStorage != nil {
+		return nil, fmt.Errorf("incremental backups need local backup storage")
+	}
+	if serverModel.ArchivedAt != nil {
+		return nil, ErrServerArchived
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	release, err := sm.shareFiles(serverModel.ID, FileLockBackup)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if name == "" {
+		name = now.UTC().Format("2006-01-02T15-04-05Z")
+	}
+
+	backup := &model.Backup{
+		ServerID:  serverModel.ID,
+		UserID:    serverModel.UserID,
+		Name:      name,
+		Target:    model.BackupTargetLocal,
+		Mode:      mode,
+		Status:    model.BackupStatusRunning,
+		Scheduled: scheduled,
+	}
+
+	fileName := fmt.Sprintf("%d.tar.gz", now.UnixNano())
+	if mode == model.BackupModeIncremental {
+		fileName = fmt.Sprint(now.UnixNano())
+		if base, err := sm.latestSnapshot(serverModel.ID); err == nil {
+			backup.BaseID = &base.ID
+		}
+	}
+	if sm.backupStorage != nil {
+		backup.Target = sm.backupStorage.Name()
+		backup.Path = fmt.Sprintf("%d/%s", serverModel.ID, fileName)
+	} else {
+		backupDir, err := sm.backupDir(serverModel.ID)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		// The uncompressed size bounds both archives and snapshots
+		if err := sm.checkDiskSpace(backupDir, DirSize(serverModel.Path)); err != nil {
+			release()
+			return nil, err
+		}
+		backup.Path = filepath.Join(backupDir, fileName)
+	}
+	if err := sm.db.Create(backup).Error; err != nil {
+		release()
+		return nil, fmt.Errorf("failed to create backup record: %w", err)
+	}
+	return backup, nil
+}
+
+// runBackup archives the server directory, pausing world saves while a
+// running server is being copied
+func (sm *ServerManager) runBackup(ctx context.Context, id uint, srv *server.Server, backup *model.Backup) (err error) {
+	ctx, span := tracing.Start(ctx, "backup.run",
+		attribute.Int64("server.id", int64(id)),
+		attribute.Int64("backup.id", int64(backup.ID)),
+		attribute.String("backup.mode", backup.Mode),
+		attribute.String("backup.target", backup.Target),
+	)
+	defer func() { tracing.End(span, err) }()
+	defer sm.unlockFiles(id)
+	slog.InfoContext(ctx, "Starting backup", "backup_id", backup.ID, "server_id", id)
+
+	var size int64
+	err = sm.withSavesPaused(id, srv, func() error {
+		var err error
+		switch {
+		case backup.Mode == model.BackupModeIncremental:
+			size, err = sm.snapshotBackup(srv.GetPath(), backup)
+		case backup.Target == model.BackupTargetLocal:
+			size, err = utils.CreateTarGz(srv.GetPath(), backup.Path, skipLockFiles)
+		default:
+			size, err = sm.uploadBackup(srv.GetPath(), backup)
+		}
+		return err
+	})
+
+	span.SetAttributes(attribute.Int64("backup.size_bytes", size))
+	sm.finishBackup(ctx, backup, size, err)
+	return err
+}
+
+// finishBackup records the outcome of a backup run
+func (sm *ServerManager) finishBackup(ctx context.Context, backup *model.Backup, size int64, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"completed_at": &now,
+		"size_bytes":   size,
+		"status":       model.BackupStatusCompleted,
+		"error":        "",
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Backup failed", "backup_id", backup.ID, "server_id", backup.ServerID, "error", err)
+		updates["status"] = model.BackupStatusFailed
+		updates["error"] = err.Error()
+	} else {
+		slog.InfoContext(ctx, "Backup completed", "backup_id", backup.ID, "server_id", backup.ServerID, "bytes", size)
+	}
+
+	if err := sm.db.WithContext(ctx).Model(backup).Updates(updates).Error; err != nil {
+		slog.Error("Failed to update backup", "backup_id", backup.ID, "error", err)
+	}
+
+	event := model.Event{
+		Type:     model.EventBackupCompleted,
+		ServerID: backup.ServerID,
+		UserID:   backup.UserID,
+		Data:     map[string]string{"backup_id": strconv.FormatUint(uint64(backup.ID), 10), "name": backup.Name},
+	}
+	if err != nil {
+		event.Type = model.EventBackupFailed
+		event.Data["error"] = err.Error()
+	}
+	sm.publish(event)
+}
+
+// snapshotBackup copies the server directory into a snapshot directory,
+// hard-linking files unchanged since the base snapshot. The returned size
+// only counts newly stored bytes.
+func (sm *ServerManager) snapshotBackup(serverPath string, backup *model.Backup) (int64, error) {
+	baseDir := ""
+	if backup.BaseID != nil {
+		var base model.Backup
+		if err := sm.db.First(&base, *backup.BaseID).Error; err == nil {
+			baseDir = base.Path
+		}
+	}
+	return utils.LinkSnapshot(serverPath, backup.Path, baseDir, skipLockFiles)
+}
+
+// latestSnapshot returns the newest completed incremental backup of a server
+func (sm *ServerManager) latestSnapshot(serverID uint) (*model.Backup, error) {
+	var backup model.Backup
+	err := sm.db.Where("server_id = ? AND mode = ? AND status = ?", serverID, model.BackupModeIncremental, model.BackupStatusCompleted).
+		Order("created_at DESC").First(&backup).Error
+	if err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// uploadBackup streams an archive of serverPath to the remote backup
+// storage without staging it on local disk. Objects are tagged with the
+// server and whether the backup was scheduled, so bucket lifecycle rules
+// can expire them.
+func (sm *ServerManager) uploadBackup(serverPath string, backup *model.Backup) (int64, error) {
+	backend, err := sm.backupBackend(backup)
+	if err != nil {
+		return 0, err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(utils.WriteTarGz(serverPath, writer, skipLockFiles))
+	}()
+
+	tags := map[string]string{
+		"server": fmt.Sprint(backup.ServerID),
+		"kind":   "manual",
+	}
+	if backup.Scheduled {
+		tags["kind"] = "scheduled"
+	}
+	size, err := backend.Put(context.Background(), backup.Path, reader, tags)
+	reader.CloseWithError(err)
+	return size, err
+}
+
+// backupBackend returns the storage backend a remote backup lives in
+func (sm *ServerManager) backupBackend(backup *model.Backup) (storage.Backend, error) {
+	if sm.backupStorage == nil || sm.backupStorage.Name() != backup.Target {
+		return nil, fmt.Errorf("backup %d is stored in %s, which is not configured", backup.ID, backup.Target)
+	}
+	return sm.backupStorage, nil
+}
+
+// withSavesPaused runs fn with autosaving disabled and the world flushed to
+// disk when the server is running, re-enabling saves afterwards
+func (sm *ServerManager) withSavesPaused(id uint, srv *server.Server, fn func() error) error {
+	if !srv.IsRunning() {
+		return fn()
+	}
+
+	if err := srv.SendCommand("save-off"); err != nil {
+		return fmt.Errorf("failed to disable saving: %w", err)
+	}
+	defer func() {
+		if err := srv.SendCommand("save-on"); err != nil {
+			slog.Error("Failed to re-enable saving", "server_id", id, "error", err)
+		}
+	}()
+
+	if err := sm.flushWorld(id, srv); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// flushWorld issues save-all flush and waits for the server to confirm it
+func (sm *ServerManager) flushWorld(id uint, srv *server.Server) error {
+	output, err := sm.SubscribeOutput(id)
+	if err != nil {
+		return err
+	}
+	defer sm.UnsubscribeOutput(id, output)
+
+	if err := srv.SendCommand("save-all flush"); err != nil {
+		return fmt.Errorf("failed to flush world: %w", err)
+	}
+
+	timeout := time.After(saveFlushTimeout)
+	for {
+		select {
+		case line := <-output:
+			if strings.Contains(line, "Saved the game") {
+				return nil
+			}
+		case <-timeout:
+			return fmt.Errorf("server did not confirm save-all within %s", saveFlushTimeout)
+		}
+	}
+}
+
+// skipLockFiles leaves out files the game keeps locked while running
+func skipLockFiles(rel string, info os.FileInfo) bool {
+	return !info.IsDir() && filepath.Base(rel) == "session.lock"
+}
+
+// backupDir returns the directory backups of a server are stored in
+func (sm *ServerManager) backupDir(serverID uint) (string, error) {
+	sharedDir, err := sm.sharedDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(sharedDir, "backups", fmt.Sprint(serverID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return dir, nil
+}
+
+// ListBackups returns the backups of a server owned by userID, newest first
+func (sm *ServerManager) ListBackups(id uint, userID uint) ([]model.Backup, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionBackups); err != nil {
+		return nil, err
+	}
+
+	var backups []model.Backup
+	if err := sm.db.Where("server_id = ?", id).Order("created_at DESC").Find(&backups).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch backups: %w", err)
+	}
+	return backups, nil
+}
+
+// GetBackup retrieves a backup owned by userID or of a server shared with them
+func (sm *ServerManager) GetBackup(backupID uint, userID uint) (*model.Backup, error) {
+	var backup model.Backup
+	shared := sm.db.Model(&model.Server{}).Select("servers.id").Scopes(sm.serverAccess(userID))
+	if err := sm.db.Where("id = ? AND (user_id = ? OR server_id IN (?))", backupID, userID, shared).First(&backup).Error; err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// RestoreBackup replaces a server's directory with the contents of a backup.
+// A running server is stopped first; a backup in progress is not interrupted.
+func (sm *ServerManager) RestoreBackup(backupID uint, userID uint) error {
+	backup, err := sm.GetBackup(backupID, userID)
+	if err != nil {
+		return fmt.Errorf("backup not found: %w", err)
+	}
+	if backup.Status != model.BackupStatusCompleted {
+		return fmt.Errorf("backup %d is %s and cannot be restored", backup.ID, backup.Status)
+	}
+
+	serverModel, srv, err := sm.ownedServer(backup.ServerID, userID, model.PermissionBackups)
+	if err != nil {
+		return err
+	}
+	if serverModel.ArchivedAt != nil {
+		return ErrServerArchived
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return err
+	}
+	// The backup is extracted next to the server before the old directory goes
+	if err := sm.checkDiskSpace(serverModel.Path, backup.SizeBytes); err != nil {
+		return err
+	}
+
+	// The server is stopped rather than refused, but not during a backup
+	release, err := sm.lockFiles(serverModel.ID, FileLockRestore, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := srv.StopAndWait(stopTimeout); err != nil {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+
+	sm.db.Model(backup).Update("status", model.BackupStatusRestoring)
+	defer sm.db.Model(backup).Update("status", model.BackupStatusCompleted)
+
+	if err := sm.restoreArchive(backup, serverModel.Path); err != nil {
+		return err
+	}
+
+	slog.Info("Restored backup", "backup_id", backup.ID, "server_id", backup.ServerID)
+	return nil
+}
+
+// restoreArchive extracts a backup next to serverPath and swaps it into
+// place, keeping the old directory until the swap has succeeded
+func (sm *ServerManager) restoreArchive(backup *model.Backup, serverPath string) error {
+	return replaceDirectory(serverPath, func(staging string) error {
+		if err := sm.extractBackup(backup, staging); err != nil {
+			return fmt.Errorf("failed to extract backup: %w", err)
+		}
+		return nil
+	})
+}
+
+// replaceDirectory fills a staging directory next to serverPath and swaps
+// it into place, keeping the old directory, if any, until the swap has
+// succeeded
+func replaceDirectory(serverPath string, fill func(staging string) error) error {
+	stamp := time.Now().UnixNano()
+	staging := fmt.Sprintf("%s.restore-%d", serverPath, stamp)
+	if err := fill(staging); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	previous := fmt.Sprintf("%s.pre-restore-%d", serverPath, stamp)
+	if err := os.Rename(serverPath, previous); err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(staging)
+		return fmt.Errorf("failed to move current server directory aside: %w", err)
+	}
+	if err := os.Rename(staging, serverPath); err != nil {
+		os.Rename(previous, serverPath)
+		os.RemoveAll(staging)
+		return fmt.Errorf("failed to move restored directory into place: %w", err)
+	}
+	if err := os.RemoveAll(previous); err != nil {
+		slog.Error("Failed to remove previous server directory", "path", previous, "error", err)
+	}
+	return nil
+}
+
+// extractBackup unpacks a backup into destDir, streaming remote backups
+// straight from their storage backend
+func (sm *ServerManager) extractBackup(backup *model.Backup, destDir string) error {
+	if backup.Mode == model.BackupModeIncremental {
+		// Copy rather than link so the restored server cannot modify the snapshot
+		return utils.CopyDir(backup.Path, destDir)
+	}
+	if backup.Target == model.BackupTargetLocal {
+		return utils.ExtractTarGz(backup.Path, destDir)
+	}
+
+	backend, err := sm.backupBackend(backup)
+	if err != nil {
+		return err
+	}
+	object, err := backend.Get(context.Background(), backup.Path)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+	return utils.RestoreTarGz(object, destDir)
+}
+
+// removeBackupArchive deletes the archive of a backup from wherever it is stored
+func (sm *ServerManager) removeBackupArchive(backup *model.Backup) error {
+	if backup.Mode == model.BackupModeIncremental {
+		// Files shared with other snapshots survive through their other links
+		return os.RemoveAll(backup.Path)
+	}
+	if backup.Target == model.BackupTargetLocal {
+		if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	backend, err := sm.backupBackend(backup)
+	if err != nil {
+		return err
+	}
+	return backend.Delete(context.Background(), backup.Path)
+}
+
+// DeleteBackup removes a backup record and its archive
+func (sm *ServerManager) DeleteBackup(backupID uint, userID uint) error {
+	backup, err := sm.GetBackup(backupID, userID)
+	if err != nil {
+		return err
+	}
+	if err := sm.Authorize(backup.ServerID, userID, model.PermissionBackups); err != nil {
+		return err
+	}
+	if backup.Status == model.BackupStatusRunning || backup.Status == model.BackupStatusRestoring {
+		return fmt.Errorf("backup %d is %s and cannot be deleted", backup.ID, backup.Status)
+	}
+
+	if err := sm.removeBackupArchive(backup); err != nil {
+		return fmt.Errorf("failed to remove backup archive: %w", err)
+	}
+	return sm.db.Delete(backup).Error
+}
+
+// SetBackupStorage makes new backups go to a remote storage backend instead
+// of the local disk. Existing local backups stay where they are.
+func (sm *ServerManager) SetBackupStorage(backend storage.Backend) {
+	sm.backupStorage = backend
+}
+
+// RestoreBackupAsServer creates a new server owned by userID from a backup,
+// using the jar, mod pack and command of the backed up server and a newly
+// allocated port. The original server is left untouched. If the restore
+// fails the new server is purged again.
+func (sm *ServerManager) RestoreBackupAsServer(backupID uint, userID uint, name, path string) (uint, error) {
+	backup, err := sm.GetBackup(backupID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("backup not found: %w", err)
+	}
+	if backup.Status != model.BackupStatusCompleted {
+		return 0, fmt.Errorf("backup %d is %s and cannot be restored", backup.ID, backup.Status)
+	}
+
+	if err := sm.Authorize(backup.ServerID, userID, model.PermissionBackups); err != nil {
+		return 0, err
+	}
+	var source model.Server
+	if err := sm.db.Where("id = ?", backup.ServerID).First(&source).Error; err != nil {
+		return 0, fmt.Errorf("server not found: %w", err)
+	}
+	var config model.ServerConfig
+	if err := sm.db.Preload("JarFile").Preload("ModPack").Where("server_id = ?", source.ID).First(&config).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch server config: %w", err)
+	}
+	// The archive is compressed, so this is a lower bound of the restored size
+	if err := sm.CheckQuota(userID, QuotaRequest{DiskBytes: backup.SizeBytes}); err != nil {
+		return 0, err
+	}
+	if err := sm.checkDiskSpace(path, backup.SizeBytes); err != nil {
+		return 0, err
+	}
+
+	port, err := sm.allocatePort()
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := sm.createServer(name, path, config.ExecutableCommand, &config.JarFile, config.ModPack, nil, userID, func(id uint) error {
+		if err := sm.restoreArchive(backup, path); err != nil {
+			return err
+		}
+		if err := utils.SetProperty(filepath.Join(path, "server.properties"), "server-port", fmt.Sprint(port)); err != nil {
+			return fmt.Errorf("failed to set server port: %w", err)
+		}
+		if source.Timezone != "" {
+			if err := sm.db.Model(&model.Server{}).Where("id = ?", id).Update("timezone", source.Timezone).Error; err != nil {
+				return fmt.Errorf("failed to set server timezone: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Info("Restored backup as new server", "backup_id", backup.ID, "source_server_id", source.ID, "server_id", id, "port", port)
+	return id, nil
+}
+
+// OpenBackup opens the archive of a completed backup for download. Local and
+// object storage archives are seekable; incremental snapshots are packed
+// into a tar.gz on the fly and can only be read sequentially.
+func (sm *ServerManager) OpenBackup(backupID uint, userID uint) (io.ReadCloser, *model.Backup, error) {
+	backup, err := sm.GetBackup(backupID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The archive holds the whole server directory
+	if err := sm.Authorize(backup.ServerID, userID, model.PermissionBackups); err != nil {
+		return nil, nil, err
+	}
+	if backup.Status != model.BackupStatusCompleted {
+		return nil, nil, fmt.Errorf("backup %d is %s and cannot be downloaded", backup.ID, backup.Status)
+	}
+
+	switch {
+	case backup.Mode == model.BackupModeIncremental:
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(utils.WriteTarGz(backup.Path, writer, nil))
+		}()
+		return reader, backup, nil
+	case backup.Target == model.BackupTargetLocal:
+		file, err := os.Open(backup.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open backup archive: %w", err)
+		}
+		return file, backup, nil
+	}
+
+	backend, err := sm.backupBackend(backup)
+	if err != nil {
+		return nil, nil, err
+	}
+	object, err := backend.Get(context.Background(), backup.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return object, backup, nil
+}
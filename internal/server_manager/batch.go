@@ -0,0 +1,97 @@
+package server_manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// Actions supported by RunBatch
+const (
+	BatchStart   = "start"
+	BatchStop    = "stop"
+	BatchRestart = "restart"
+)
+
+// batchWorkers caps how many servers a batch operates on at once
+const batchWorkers = 4
+
+// BatchResult is the outcome of a batch action on one server
+type BatchResult struct {
+	ServerID uint   `json:"server_id"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RunBatch applies action to every server in ids that userID has the power
+// permission on, using a small worker pool. Results are returned in the order of ids with
+// duplicates dropped; a failure on one server does not affect the others.
+func (sm *ServerManager) RunBatch(ctx context.Context, ids []uint, userID uint, action string) ([]BatchResult, error) {
+	seen := make(map[uint]bool, len(ids))
+	unique := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	ids = unique
+
+	var run func(id uint) error
+	switch action {
+	case BatchStart:
+		run = func(id uint) error { return sm.StartServer(ctx, id, userID) }
+	case BatchStop:
+		run = func(id uint) error {
+			_, srv, err := sm.ownedServer(id, userID, model.PermissionPower)
+			if err != nil {
+				return err
+			}
+			return srv.Stop()
+		}
+	case BatchRestart:
+		run = func(id uint) error {
+			_, srv, err := sm.ownedServer(id, userID, model.PermissionPower)
+			if err != nil {
+				return err
+			}
+			return srv.Restart()
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q", action)
+	}
+
+	results := make([]BatchResult, len(ids))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < batchWorkers && w < len(ids); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := BatchResult{ServerID: ids[i], Success: true}
+				err := sm.Authorize(ids[i], userID, model.PermissionPower)
+				if err == nil {
+					err = sm.checkMaintenance(ctx, ids[i])
+				}
+				if err == nil {
+					err = run(ids[i])
+				}
+				if err != nil {
+					result.Success = false
+					result.Error = err.Error()
+				}
+				results[i] = result
+			}
+		}()
+	}
+	for i := range ids {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
@@ -0,0 +1,117 @@
+package server_manager
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// CloneServer duplicates a server into a new one with its own name, path and
+// port. The clone shares the source's jar, mod pack and command. With
+// excludeWorlds the world directories are left out so the clone generates a
+// fresh world. If copying fails the clone is purged again.
+func (sm *ServerManager) CloneServer(id uint, userID uint, name, path string, excludeWorlds bool) (uint, error) {
+	source, srv, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkLocal(source); err != nil {
+		return 0, err
+	}
+	if source.ArchivedAt != nil {
+		return 0, ErrServerArchived
+	}
+	var config model.ServerConfig
+	if err := sm.db.Preload("JarFile").Preload("ModPack").Where("server_id = ?", source.ID).First(&config).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch server config: %w", err)
+	}
+
+	size := DirSize(source.Path)
+	if err := sm.CheckQuota(userID, QuotaRequest{DiskBytes: size}); err != nil {
+		return 0, err
+	}
+	if err := sm.checkDiskSpace(path, size); err != nil {
+		return 0, err
+	}
+
+	port, err := sm.allocatePort()
+	if err != nil {
+		return 0, err
+	}
+
+	worlds := worldDirs(source.Path)
+	skip := func(rel string, info os.FileInfo) bool {
+		if skipLockFiles(rel, info) {
+			return true
+		}
+		if excludeWorlds && worlds[rel] {
+			return true
+		}
+		// server.jar and mods were already provisioned by CreateServer; never
+		// copy through their symlinks into the shared artifacts
+		existing, err := os.Lstat(filepath.Join(path, filepath.FromSlash(rel)))
+		return err == nil && (!info.IsDir() || existing.Mode()&os.ModeSymlink != 0)
+	}
+	cloneID, err := sm.createServer(name, path, config.ExecutableCommand, &config.JarFile, config.ModPack, nil, userID, func(cloneID uint) error {
+		err := sm.withSavesPaused(id, srv, func() error {
+			return utils.CopyDirSkip(source.Path, path, skip)
+		})
+		if err != nil {
+			return err
+		}
+		return sm.finishClone(cloneID, source, &config, path, port)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Info("Cloned server", "server_id", id, "clone_id", cloneID, "port", port)
+	return cloneID, nil
+}
+
+// finishClone gives a copied clone its own port and the settings of its
+// source
+func (sm *ServerManager) finishClone(cloneID uint, source *model.Server, config *model.ServerConfig, path string, port int) error {
+	if err := utils.SetProperty(filepath.Join(path, "server.properties"), "server-port", fmt.Sprint(port)); err != nil {
+		return fmt.Errorf("failed to set server port: %w", err)
+	}
+	if source.Timezone != "" {
+		if err := sm.db.Model(&model.Server{}).Where("id = ?", cloneID).Update("timezone", source.Timezone).Error; err != nil {
+			return fmt.Errorf("failed to set server timezone: %w", err)
+		}
+	}
+	cloneConfig, err := sm.getServerConfig(cloneID)
+	if err != nil {
+		return fmt.Errorf("failed to get server config: %w", err)
+	}
+	cloneConfig.Env = config.Env
+	cloneConfig.RestartPolicy = config.RestartPolicy
+	cloneConfig.MaxRestarts = config.MaxRestarts
+	if err := sm.db.Model(cloneConfig).Select("Env", "RestartPolicy", "MaxRestarts").Updates(cloneConfig).Error; err != nil {
+		return fmt.Errorf("failed to update server config: %w", err)
+	}
+	return nil
+}
+
+// levelName returns the main world of the server in serverPath
+func levelName(serverPath string) string {
+	if properties, err := utils.ReadProperties(filepath.Join(serverPath, "server.properties")); err == nil && properties["level-name"] != "" {
+		return properties["level-name"]
+	}
+	return "world"
+}
+
+// worldDirs returns the world directories of a server: the configured
+// level-name and its nether and end dimensions
+func worldDirs(serverPath string) map[string]bool {
+	level := levelName(serverPath)
+	return map[string]bool{
+		level:              true,
+		level + "_nether":  true,
+		level + "_the_end": true,
+	}
+}
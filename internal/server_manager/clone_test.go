@@ -0,0 +1,36 @@
+package server_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestFailedCloneIsDiscarded(t *testing.T) {
+	sm := newTestManager(t)
+	user := createTestUser(t, sm, "alice", model.RoleAdmin)
+	id := createTestServer(t, sm, user, "survival")
+	source, _, err := sm.ownedServer(id, user.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The port of the clone cannot be written into a directory
+	if err := os.MkdirAll(filepath.Join(source.Path, "server.properties"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(sm.commonDir, "servers", "survival-copy")
+	if _, err := sm.CloneServer(id, user.ID, "survival-copy", path, false); err == nil {
+		t.Fatal("clone succeeded")
+	}
+	var count int64
+	sm.db.Unscoped().Model(&model.Server{}).Where("name = ?", "survival-copy").Count(&count)
+	if count != 0 {
+		t.Error("the failed clone was kept")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the directory of the failed clone was kept: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// consoleBacklog is how many recent console lines of each server are kept.
+// New subscribers can replay them, and subscribers further behind than
+// this skip ahead.
+const consoleBacklog = 1000
+
+// ErrSubscriptionClosed is returned by ConsoleSubscription.Next once the
+// subscription is closed
+var ErrSubscriptionClosed = errors.New("console subscription closed")
+
+// consoleBuffer is a ring of the recent console lines of a server, numbered
+// by a sequence that keeps counting across restarts
+type consoleBuffer struct {
+	mutex sync.Mutex
+	lines [consoleBacklog]string
+	// next is the sequence number the next line gets
+	next uint64
+	// wake is closed and replaced whenever a line is added
+	wake chan struct{}
+}
+
+func newConsoleBuffer() *consoleBuffer {
+	return &consoleBuffer{wake: make(chan struct{})}
+}
+
+func (b *consoleBuffer) append(line string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.lines[b.next%consoleBacklog] = line
+	b.next++
+	close(b.wake)
+	b.wake = make(chan struct{})
+}
+
+// since returns the lines from sequence number cursor on, the cursor after
+// them, how many lines were overwritten before they could be read, and a
+// channel closed when more lines arrive
+func (b *consoleBuffer) since(cursor uint64) ([]string, uint64, uint64, <-chan struct{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var skipped uint64
+	if b.next-cursor > consoleBacklog {
+		skipped = b.next - consoleBacklog - cursor
+		cursor = b.next - consoleBacklog
+	}
+	lines := make([]string, 0, b.next-cursor)
+	for seq := cursor; seq < b.next; seq++ {
+		lines = append(lines, b.lines[seq%consoleBacklog])
+	}
+	return lines, b.next, skipped, b.wake
+}
+
+// ConsoleSubscription reads the console of a server at its own pace. It
+// never blocks the server; a subscriber that falls more than the backlog
+// behind is told how many lines it skipped.
+type ConsoleSubscription struct {
+	buffer *consoleBuffer
+	cursor uint64
+	closed chan struct{}
+	once   sync.Once
+}
+
+// Next blocks until there are unread lines and returns all of them, with the
+// number of lines lost since the previous call
+func (s *ConsoleSubscription) Next(ctx context.Context) ([]string, uint64, error) {
+	for {
+		select {
+		case <-s.closed:
+			return nil, 0, ErrSubscriptionClosed
+		default:
+		}
+		lines, next, skipped, wake := s.buffer.since(s.cursor)
+		s.cursor = next
+		if len(lines) > 0 || skipped > 0 {
+			return lines, skipped, nil
+		}
+		select {
+		case <-wake:
+		case <-s.closed:
+			return nil, 0, ErrSubscriptionClosed
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+	}
+}
+
+// Close ends the subscription; a blocked Next returns ErrSubscriptionClosed
+func (s *ConsoleSubscription) Close() {
+	s.once.Do(func() { close(s.closed) })
+}
+
+// consoleBufferFor returns the console buffer of a server, creating it on
+// first use. The caller must hold streamMutex.
+func (sm *ServerManager) consoleBufferFor(id uint) *consoleBuffer {
+	buffer, exists := sm.consoles[id]
+	if !exists {
+		buffer = newConsoleBuffer()
+		sm.consoles[id] = buffer
+	}
+	return buffer
+}
+
+// SubscribeConsole follows the console of a server, starting with up to
+// backlog of the most recent lines. It must be closed when done.
+func (sm *ServerManager) SubscribeConsole(id uint, backlog int) *ConsoleSubscription {
+	sm.streamMutex.Lock()
+	defer sm.streamMutex.Unlock()
+
+	buffer := sm.consoleBufferFor(id)
+	buffer.mutex.Lock()
+	cursor := buffer.next
+	buffer.mutex.Unlock()
+	if backlog > consoleBacklog {
+		backlog = consoleBacklog
+	}
+	if backlog > 0 && cursor > uint64(backlog) {
+		cursor -= uint64(backlog)
+	} else if backlog > 0 {
+		cursor = 0
+	}
+
+	sub := &ConsoleSubscription{buffer: buffer, cursor: cursor, closed: make(chan struct{})}
+	sm.consoleSubs[sub] = struct{}{}
+	return sub
+}
+
+// UnsubscribeConsole closes a subscription from SubscribeConsole
+func (sm *ServerManager) UnsubscribeConsole(sub *ConsoleSubscription) {
+	sm.streamMutex.Lock()
+	delete(sm.consoleSubs, sub)
+	sm.streamMutex.Unlock()
+	sub.Close()
+}
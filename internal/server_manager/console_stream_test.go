@@ -0,0 +1,62 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConsoleSubscription(t *testing.T) {
+	sm := &ServerManager{
+		consoles:    make(map[uint]*consoleBuffer),
+		consoleSubs: make(map[*ConsoleSubscription]struct{}),
+	}
+	buffer := sm.consoleBufferFor(1)
+	for i := 0; i < 10; i++ {
+		buffer.append(fmt.Sprint(i))
+	}
+
+	// A new subscriber replays the most recent lines
+	replay := sm.SubscribeConsole(1, 3)
+	defer sm.UnsubscribeConsole(replay)
+	lines, skipped, err := replay.Next(context.Background())
+	if err != nil || skipped != 0 || fmt.Sprint(lines) != "[7 8 9]" {
+		t.Fatalf("replay returned %v, %d skipped, %v", lines, skipped, err)
+	}
+
+	// A slow subscriber gets everything still buffered in one batch and
+	// learns how much it missed
+	slow := sm.SubscribeConsole(1, 0)
+	defer sm.UnsubscribeConsole(slow)
+	for i := 0; i < consoleBacklog+5; i++ {
+		buffer.append(fmt.Sprint(10 + i))
+	}
+	lines, skipped, err = slow.Next(context.Background())
+	if err != nil || skipped != 5 || len(lines) != consoleBacklog || lines[0] != "15" {
+		t.Fatalf("slow subscriber got %d lines from %q, %d skipped, %v", len(lines), lines[0], skipped, err)
+	}
+
+	// Next waits for new lines
+	done := make(chan []string)
+	go func() {
+		lines, _, _ := slow.Next(context.Background())
+		done <- lines
+	}()
+	time.Sleep(10 * time.Millisecond)
+	buffer.append("new")
+	select {
+	case lines := <-done:
+		if fmt.Sprint(lines) != "[new]" {
+			t.Fatalf("waiting subscriber got %v", lines)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting subscriber was not woken")
+	}
+
+	sm.UnsubscribeConsole(slow)
+	if _, _, err := slow.Next(context.Background()); !errors.Is(err, ErrSubscriptionClosed) {
+		t.Fatalf("closed subscription returned %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package server_manager
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// crashHistory is how many crashes are kept per server
+	crashHistory = 50
+	// maxCrashReportSize caps the stored size of one crash report
+	maxCrashReportSize = 1 << 20
+)
+
+// StartCrashRecorder stores a crash record every time a server exits with
+// an error and restarts servers whose restart policy asks for it, until
+// stop is closed
+func (sm *ServerManager) StartCrashRecorder(stop <-chan struct{}) {
+	events := sm.SubscribeEvents()
+	go func() {
+		defer sm.UnsubscribeEvents(events)
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if event.Type == model.EventServerCrashed {
+					if err := sm.recordCrash(event.ServerID); err != nil {
+						slog.Error("Failed to record crash", "server_id", event.ServerID, "error", err)
+					}
+					sm.restartAfterCrash(event.ServerID)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// recordCrash stores the last failure of a server with the crash reports
+// it wrote and prunes crashes beyond the history
+func (sm *ServerManager) recordCrash(id uint) error {
+	sm.mutex.RLock()
+	srv, ok := sm.servers[id]
+	sm.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	failure := srv.LastFailure()
+	if failure == nil {
+		return nil
+	}
+
+	record := &model.Crash{
+		ServerID:    id,
+		ExitError:   failure.ExitError,
+		Findings:    failure.Findings,
+		Stderr:      failure.Stderr,
+		ConsoleTail: strings.Join(failure.ConsoleTail, "\n"),
+	}
+	for _, path := range failure.CrashReports {
+		report, err := readCrashReport(srv.GetPath(), path)
+		if err != nil {
+			slog.Warn("Failed to read crash report", "server_id", id, "path", path, "error", err)
+			continue
+		}
+		record.Reports = append(record.Reports, *report)
+	}
+	if err := sm.db.Create(record).Error; err != nil {
+		return err
+	}
+	slog.Info("Recorded crash", "server_id", id, "crash_id", record.ID, "reports", len(record.Reports))
+	return sm.pruneCrashes(id)
+}
+
+// readCrashReport reads the crash report at path below the server directory
+// dir, truncated to maxCrashReportSize. Links and other files that are not
+// regular are refused.
+func readCrashReport(dir, path string) (*model.CrashReport, error) {
+	file, err := utils.OpenRegularIn(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(io.LimitReader(file, maxCrashReportSize))
+	if err != nil {
+		return nil, err
+	}
+	return &model.CrashReport{Name: filepath.Base(path), SizeBytes: info.Size(), Content: string(content)}, nil
+}
+
+// pruneCrashes removes the crashes of a server beyond the history
+func (sm *ServerManager) pruneCrashes(id uint) error {
+	var old []uint
+	err := sm.db.Model(&model.Crash{}).Where("server_id = ?", id).Order("id DESC").
+		Offset(crashHistory).Pluck("id", &old).Error
+	if err != nil || len(old) == 0 {
+		return err
+	}
+	return sm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("crash_id IN ?", old).Delete(&model.CrashReport{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", old).Delete(&model.Crash{}).Error
+	})
+}
+
+// ListCrashes returns the crashes of a server, newest first, without their
+// output
+func (sm *ServerManager) ListCrashes(id uint, userID uint) ([]model.Crash, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionConsole); err != nil {
+		return nil, err
+	}
+	crashes := []model.Crash{}
+	err := sm.db.Omit("stderr", "console_tail").Where("server_id = ?", id).
+		Preload("Reports", func(db *gorm.DB) *gorm.DB { return db.Omit("content").Order("id") }).
+		Order("id DESC").Find(&crashes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crashes: %w", err)
+	}
+	return crashes, nil
+}
+
+// GetCrash returns a crash of a server with its output
+func (sm *ServerManager) GetCrash(id, crashID uint, userID uint) (*model.Crash, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionConsole); err != nil {
+		return nil, err
+	}
+	var record model.Crash
+	err := sm.db.Where("id = ? AND server_id = ?", crashID, id).
+		Preload("Reports", func(db *gorm.DB) *gorm.DB { return db.Omit("content").Order("id") }).
+		First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GetCrashReport returns a crash report of a server with its content
+func (sm *ServerManager) GetCrashReport(id, crashID, reportID uint, userID uint) (*model.CrashReport, error) {
+	if _, err := sm.GetCrash(id, crashID, userID); err != nil {
+		return nil, err
+	}
+	var report model.CrashReport
+	if err := sm.db.Where("id = ? AND crash_id = ?", reportID, crashID).First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,30 @@
+package server_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCrashReportRefusesLinks(t *testing.T) {
+	dir := t.TempDir()
+	reports := filepath.Join(dir, "crash-reports")
+	os.MkdirAll(reports, 0755)
+	os.WriteFile(filepath.Join(reports, "crash-server.txt"), []byte("---- Minecraft Crash Report ----"), 0644)
+	outside := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(outside, []byte("secret"), 0644)
+	if err := os.Symlink(outside, filepath.Join(reports, "crash-link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := readCrashReport(dir, filepath.Join("crash-reports", "crash-server.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Name != "crash-server.txt" || report.Content != "---- Minecraft Crash Report ----" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report, err := readCrashReport(dir, filepath.Join("crash-reports", "crash-link.txt")); err == nil {
+		t.Errorf("link was read: %q", report.Content)
+	}
+}
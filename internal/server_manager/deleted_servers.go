@@ -0,0 +1,199 @@
+package server_manager
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/gorm"
+)
+
+// purgeInterval is how often the purge job looks for expired deleted servers
+const purgeInterval = time.Hour
+
+// checkPathNotDeleted fails when a deleted server still owns path, so a new
+// server cannot take over the files of one that may be restored
+func (sm *ServerManager) checkPathNotDeleted(path string) error {
+	var deleted int64
+	err := sm.db.Unscoped().Model(&model.Server{}).
+		Where("path = ? AND deleted_at IS NOT NULL", path).
+		Count(&deleted).Error
+	if err != nil {
+		return fmt.Errorf("error checking deleted servers: %w", err)
+	}
+	if deleted > 0 {
+		return fmt.Errorf("a deleted server still uses %s; restore or purge it first", path)
+	}
+	return nil
+}
+
+// ListDeletedServers returns the deleted servers of a user or their teams that have not been purged yet
+func (sm *ServerManager) ListDeletedServers(userID uint) ([]model.Server, error) {
+	var servers []model.Server
+	err := sm.db.Unscoped().Scopes(sm.serverAccess(userID)).Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").Find(&servers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted servers: %w", err)
+	}
+	return servers, nil
+}
+
+// RestoreServer brings back a deleted server that has not been purged yet
+func (sm *ServerManager) RestoreServer(id uint, userID uint) (*model.Server, error) {
+	var serverModel model.Server
+	err := sm.db.Unscoped().Scopes(sm.serverControl(userID)).Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&serverModel).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := sm.db.Model(&model.Server{}).Where("name = ?", serverModel.Name).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("error checking for existing server: %w", err)
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("another server is now named %s; rename it first", serverModel.Name)
+	}
+
+	if err := sm.db.Unscoped().Model(&serverModel).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore server: %w", err)
+	}
+	serverModel.DeletedAt = gorm.DeletedAt{}
+
+	sm.mutex.Lock()
+	sm.servers[id] = sm.newServer(&serverModel)
+	sm.mutex.Unlock()
+
+	slog.Info("Restored deleted server", "server_id", id, "name", serverModel.Name)
+	return &serverModel, nil
+}
+
+// StartPurgeJob permanently removes servers deleted more than retention ago,
+// checking every hour until stop is closed
+func (sm *ServerManager) StartPurgeJob(retention time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+		for {
+			sm.PurgeDeletedServers(time.Now().Add(-retention))
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// PurgeDeletedServers permanently removes servers deleted before cutoff
+func (sm *ServerManager) PurgeDeletedServers(cutoff time.Time) {
+	var servers []model.Server
+	err := sm.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&servers).Error
+	if err != nil {
+		slog.Error("Failed to fetch deleted servers to purge", "error", err)
+		return
+	}
+	for i := range servers {
+		if err := sm.purgeServer(&servers[i]); err != nil {
+			slog.Error("Failed to purge server", "server_id", servers[i].ID, "error", err)
+		}
+	}
+}
+
+// purgeServer permanently deletes a server: its backups, schedule, config
+// and record in one transaction, then its directory
+func (sm *ServerManager) purgeServer(serverModel *model.Server) error {
+	var backups []model.Backup
+	if err := sm.db.Where("server_id = ?", serverModel.ID).Find(&backups).Error; err != nil {
+		return fmt.Errorf("failed to fetch backups: %w", err)
+	}
+
+	err := sm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.Backup{}).Error; err != nil {
+			return fmt.Errorf("failed to delete backups: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.BackupSchedule{}).Error; err != nil {
+			return fmt.Errorf("failed to delete backup schedule: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.Announcement{}).Error; err != nil {
+			return fmt.Errorf("failed to delete announcements: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.MaintenanceWindow{}).Error; err != nil {
+			return fmt.Errorf("failed to delete maintenance windows: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.PregenTask{}).Error; err != nil {
+			return fmt.Errorf("failed to delete pre-generation tasks: %w", err)
+		}
+		if err := tx.Where("server_id = ?", serverModel.ID).Delete(&model.MetricSample{}).Error; err != nil {
+			return fmt.Errorf("failed to delete metrics: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.ServerGrant{}).Error; err != nil {
+			return fmt.Errorf("failed to delete grants: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.Addon{}).Error; err != nil {
+			return fmt.Errorf("failed to delete addons: %w", err)
+		}
+		crashes := tx.Unscoped().Model(&model.Crash{}).Select("id").Where("server_id = ?", serverModel.ID)
+		if err := tx.Where("crash_id IN (?)", crashes).Delete(&model.CrashReport{}).Error; err != nil {
+			return fmt.Errorf("failed to delete crash reports: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.Crash{}).Error; err != nil {
+			return fmt.Errorf("failed to delete crashes: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.Alert{}).Error; err != nil {
+			return fmt.Errorf("failed to delete alerts: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.AlertRule{}).Error; err != nil {
+			return fmt.Errorf("failed to delete alert rules: %w", err)
+		}
+		if err := tx.Unscoped().Where("server_id = ?", serverModel.ID).Delete(&model.ServerConfig{}).Error; err != nil {
+			return fmt.Errorf("failed to delete server config: %w", err)
+		}
+		// Backends of a purged proxy keep their forwarding settings
+		if err := tx.Unscoped().Model(&model.Server{}).Where("proxy_id = ?", serverModel.ID).
+			Updates(map[string]interface{}{"proxy_id": nil, "proxy_address": ""}).Error; err != nil {
+			return fmt.Errorf("failed to detach backends: %w", err)
+		}
+		if err := tx.Unscoped().Delete(serverModel).Error; err != nil {
+			return fmt.Errorf("failed to delete server: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range backups {
+		if err := sm.removeBackupArchive(&backups[i]); err != nil {
+			slog.Error("Failed to remove backup archive", "backup_id", backups[i].ID, "error", err)
+		}
+	}
+	if serverModel.ArchivedAt != nil {
+		sm.removeArchive(serverModel)
+	}
+	if err := os.RemoveAll(serverModel.Path); err != nil {
+		return fmt.Errorf("failed to remove server directory: %w", err)
+	}
+
+	slog.Info("Purged server", "server_id", serverModel.ID, "name", serverModel.Name)
+	return nil
+}
+
+// discardServer purges a server whose provisioning failed after it was
+// created, so it does not hold on to its name, path and quota
+func (sm *ServerManager) discardServer(id uint) {
+	sm.mutex.Lock()
+	delete(sm.servers, id)
+	sm.mutex.Unlock()
+
+	var serverModel model.Server
+	if err := sm.db.Unscoped().First(&serverModel, id).Error; err != nil {
+		slog.Error("Failed to fetch server to discard", "server_id", id, "error", err)
+		return
+	}
+	if err := sm.purgeServer(&serverModel); err != nil {
+		slog.Error("Failed to discard server", "server_id", id, "error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ErrInsufficientDisk is returned when an operation would leave less free
+// space on its volume than the configured reserve
+var ErrInsufficientDisk = errors.New("insufficient disk space")
+
+// SetDiskReserve sets the free space in MB that uploads, backups and
+// imports must leave on the volume they write to
+func (sm *ServerManager) SetDiskReserve(mb int64) {
+	sm.limitsMutex.Lock()
+	defer sm.limitsMutex.Unlock()
+	sm.diskReserve = mb << 20
+}
+
+func (sm *ServerManager) diskReserveBytes() int64 {
+	sm.limitsMutex.RLock()
+	defer sm.limitsMutex.RUnlock()
+	return sm.diskReserve
+}
+
+// checkDiskSpace fails with ErrInsufficientDisk when writing need bytes
+// below path would leave less than the reserve free. path need not exist
+// yet. Platforms that cannot report free space are not checked.
+func (sm *ServerManager) checkDiskSpace(path string, need int64) error {
+	dir := path
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	free, err := utils.FreeDiskSpace(dir)
+	if err != nil {
+		slog.Debug("Skipping disk space check", "path", dir, "error", err)
+		return nil
+	}
+	reserve := sm.diskReserveBytes()
+	if int64(free)-need < reserve {
+		return fmt.Errorf("%w: %d MB free on the volume of %s, %d MB needed with %d MB kept in reserve",
+			ErrInsufficientDisk, free>>20, dir, need>>20, reserve>>20)
+	}
+	return nil
+}
+
+// VolumeStats is the disk space of a volume the manager writes to
+type VolumeStats struct {
+	// Name is servers, shared or backups
+	Name        string  `json:"name"`
+	Path        string  `json:"path"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+	// Error is set when the volume could not be inspected
+	Error string `json:"error,omitempty"`
+}
+
+// NodeDiskStats is the free space a node agent last reported
+type NodeDiskStats struct {
+	NodeID        uint       `json:"node_id"`
+	Name          string     `json:"name"`
+	Online        bool       `json:"online"`
+	FreeDiskBytes int64      `json:"free_disk_bytes"`
+	LastSeenAt    *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// DiskStats reports the free space of the control plane and its nodes
+type DiskStats struct {
+	// ReserveBytes is the free space uploads, backups and imports leave
+	ReserveBytes int64           `json:"reserve_bytes"`
+	Volumes      []VolumeStats   `json:"volumes"`
+	Nodes        []NodeDiskStats `json:"nodes"`
+}
+
+// DiskStats returns the free space of the server, shared and local backup
+// directories and of every node
+func (sm *ServerManager) DiskStats() (*DiskStats, error) {
+	serversDir, err := ServersDir()
+	if err != nil {
+		return nil, err
+	}
+	sharedDir, err := sm.sharedDir()
+	if err != nil {
+		return nil, err
+	}
+	paths := [][2]string{{"servers", serversDir}, {"shared", sharedDir}}
+	if sm.backupStorage == nil {
+		paths = append(paths, [2]string{"backups", filepath.Join(sharedDir, "backups")})
+	}
+
+	stats := &DiskStats{ReserveBytes: sm.diskReserveBytes(), Volumes: []VolumeStats{}, Nodes: []NodeDiskStats{}}
+	for _, entry := range paths {
+		volume := VolumeStats{Name: entry[0], Path: entry[1]}
+		free, err := utils.FreeDiskSpace(entry[1])
+		if err == nil {
+			volume.FreeBytes = free
+			volume.UsedPercent, err = utils.DiskUsage(entry[1])
+		}
+		if err != nil {
+			volume.Error = err.Error()
+		}
+		stats.Volumes = append(stats.Volumes, volume)
+	}
+
+	nodes, err := sm.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nodes {
+		stats.Nodes = append(stats.Nodes, NodeDiskStats{
+			NodeID:        n.ID,
+			Name:          n.Name,
+			Online:        n.Online,
+			FreeDiskBytes: n.FreeDiskBytes,
+			LastSeenAt:    n.LastSeenAt,
+		})
+	}
+	return stats, nil
+}
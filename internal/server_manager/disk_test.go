@@ -0,0 +1,21 @@
+package server_manager
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	sm := &ServerManager{}
+	// Paths that do not exist yet are checked on their nearest parent
+	path := filepath.Join(t.TempDir(), "game_servers", "new")
+
+	if err := sm.checkDiskSpace(path, 1<<20); err != nil {
+		t.Fatalf("checkDiskSpace without a reserve: %v", err)
+	}
+	sm.SetDiskReserve(1 << 40)
+	if err := sm.checkDiskSpace(path, 0); !errors.Is(err, ErrInsufficientDisk) {
+		t.Errorf("got %v with a reserve larger than the disk, want ErrInsufficientDisk", err)
+	}
+}
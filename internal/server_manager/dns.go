@@ -0,0 +1,102 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/olindenbaum/mcgonalds/internal/dns"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// ErrDNSDisabled is returned for DNS changes when no provider is configured
+var ErrDNSDisabled = errors.New("dns records are not configured")
+
+// ErrDNSNameTaken is returned when a name is registered for another server
+var ErrDNSNameTaken = errors.New("dns name is registered for another server")
+
+// SetDNS makes the manager register server addresses through registrar
+func (sm *ServerManager) SetDNS(registrar *dns.Registrar) {
+	sm.dns = registrar
+}
+
+// RegisterServerDNS points label in the configured zone at a server and
+// its port, replacing the name the server had. It returns the server with
+// its new host name.
+func (sm *ServerManager) RegisterServerDNS(ctx context.Context, id uint, userID uint, label string) (*model.Server, error) {
+	if sm.dns == nil {
+		return nil, ErrDNSDisabled
+	}
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := sm.dns.Hostname(label)
+	if err != nil {
+		return nil, err
+	}
+	var taken int64
+	if err := sm.db.Model(&model.Server{}).Where("dns_name = ? AND id <> ?", hostname, id).Count(&taken).Error; err != nil {
+		return nil, fmt.Errorf("failed to check dns name: %w", err)
+	}
+	if taken > 0 {
+		return nil, fmt.Errorf("%s: %w", hostname, ErrDNSNameTaken)
+	}
+
+	// Only records the manager created for this server are replaced; a
+	// new name must not exist in the zone yet
+	port := server.Port(serverModel.Path)
+	if serverModel.DNSName == hostname {
+		err = sm.dns.Update(ctx, hostname, port)
+	} else {
+		err = sm.dns.Register(ctx, hostname, port)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if serverModel.DNSName != "" && serverModel.DNSName != hostname {
+		if err := sm.dns.Unregister(ctx, serverModel.DNSName, port); err != nil {
+			slog.WarnContext(ctx, "Failed to remove previous DNS records", "server_id", id, "dns_name", serverModel.DNSName, "error", err)
+		}
+	}
+	serverModel.DNSName = hostname
+	if err := sm.db.Model(serverModel).Update("dns_name", hostname).Error; err != nil {
+		return nil, fmt.Errorf("failed to update server: %w", err)
+	}
+	slog.InfoContext(ctx, "Registered DNS records", "server_id", id, "dns_name", hostname, "provider", sm.dns.Provider.Name())
+	return serverModel, nil
+}
+
+// UnregisterServerDNS removes the DNS records of a server
+func (sm *ServerManager) UnregisterServerDNS(ctx context.Context, id uint, userID uint) error {
+	if sm.dns == nil {
+		return ErrDNSDisabled
+	}
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return err
+	}
+	if serverModel.DNSName == "" {
+		return nil
+	}
+	return sm.unregisterDNS(ctx, serverModel)
+}
+
+// unregisterDNS deletes the records of a server and forgets its host name
+func (sm *ServerManager) unregisterDNS(ctx context.Context, serverModel *model.Server) error {
+	if sm.dns == nil {
+		return ErrDNSDisabled
+	}
+	if err := sm.dns.Unregister(ctx, serverModel.DNSName, server.Port(serverModel.Path)); err != nil {
+		return err
+	}
+	if err := sm.db.Model(serverModel).Update("dns_name", "").Error; err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+	slog.InfoContext(ctx, "Removed DNS records", "server_id", serverModel.ID, "dns_name", serverModel.DNSName)
+	serverModel.DNSName = ""
+	return nil
+}
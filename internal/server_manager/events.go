@@ -0,0 +1,76 @@
+package server_manager
+
+import (
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// eventBuffer is how many events a subscriber may fall behind before
+// further events are dropped for it
+const eventBuffer = 100
+
+// newServer creates the in-memory instance of a server wired to publish
+// its events
+func (sm *ServerManager) newServer(serverModel *model.Server) *server.Server {
+	srv := server.NewServer(serverModel)
+	srv.SetListener(sm.publish)
+	srv.SetConfigLoader(sm.GetServerConfig)
+	if sm.runtime != nil {
+		srv.SetRuntime(sm.runtime)
+	}
+	return srv
+}
+
+// SetRuntime sets how server processes are run. Running servers keep their
+// runtime until they are restarted.
+func (sm *ServerManager) SetRuntime(runtime server.Runtime) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.runtime = runtime
+	for _, srv := range sm.servers {
+		srv.SetRuntime(runtime)
+	}
+}
+
+// SubscribeEvents returns a channel receiving the events of all servers.
+// Slow subscribers miss events rather than blocking the servers.
+func (sm *ServerManager) SubscribeEvents() chan model.Event {
+	sm.eventMutex.Lock()
+	defer sm.eventMutex.Unlock()
+
+	ch := make(chan model.Event, eventBuffer)
+	sm.eventSubscribers = append(sm.eventSubscribers, ch)
+	return ch
+}
+
+// UnsubscribeEvents stops and closes a channel returned by SubscribeEvents
+func (sm *ServerManager) UnsubscribeEvents(ch chan model.Event) {
+	sm.eventMutex.Lock()
+	defer sm.eventMutex.Unlock()
+
+	for i, subscriber := range sm.eventSubscribers {
+		if subscriber == ch {
+			sm.eventSubscribers = append(sm.eventSubscribers[:i], sm.eventSubscribers[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// publish passes an event to every subscriber without blocking
+func (sm *ServerManager) publish(event model.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	sm.eventMutex.RLock()
+	defer sm.eventMutex.RUnlock()
+	for _, ch := range sm.eventSubscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package server_manager
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+const (
+	bundleFormatVersion = 1
+	bundleMetadataFile  = "mcgonalds.json"
+	bundleServerDir     = "server"
+)
+
+// BundleMetadata describes the server packed into an export bundle
+type BundleMetadata struct {
+	FormatVersion     int             `json:"format_version"`
+	Name              string          `json:"name"`
+	ExecutableCommand string          `json:"executable_command"`
+	Timezone          string          `json:"timezone,omitempty"`
+	Jar               BundleArtifact  `json:"jar"`
+	ModPack           *BundleArtifact `json:"mod_pack,omitempty"`
+	Mods              []BundleFile    `json:"mods"`
+	ExportedAt        time.Time       `json:"exported_at"`
+}
+
+// BundleArtifact is a jar or mod pack the server used, with its location
+// relative to the bundled server directory
+type BundleArtifact struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	File    string `json:"file"`
+}
+
+// BundleFile is an entry of the mod manifest
+type BundleFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ExportServer writes a self-contained bundle of a server to w: a tar.gz
+// holding mcgonalds.json and the server directory under server/, with the
+// provisioned links to the shared jar and mod pack replaced by the files
+// they point to. Other symlinks are kept as links. The bundle is written to
+// a temporary file first so saves are only paused while it is packed, not
+// while a client downloads it.
+func (sm *ServerManager) ExportServer(id uint, userID uint, w io.Writer) error {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return err
+	}
+	var config model.ServerConfig
+	if err := sm.db.Preload("JarFile").Preload("ModPack").Where("server_id = ?", serverModel.ID).First(&config).Error; err != nil {
+		return fmt.Errorf("failed to fetch server config: %w", err)
+	}
+
+	metadata := BundleMetadata{
+		FormatVersion:     bundleFormatVersion,
+		Name:              serverModel.Name,
+		ExecutableCommand: config.ExecutableCommand,
+		Timezone:          serverModel.Timezone,
+		Jar: BundleArtifact{
+			Name:    config.JarFile.Name,
+			Version: config.JarFile.Version,
+			File:    bundledJarPath(serverModel.Path, config.JarFile.Path),
+		},
+		ExportedAt: time.Now().UTC(),
+	}
+	if config.ModPack != nil {
+		metadata.ModPack = &BundleArtifact{Name: config.ModPack.Name, Version: config.ModPack.Version, File: "mods"}
+	}
+	artifacts := bundleArtifacts(&config)
+
+	bundle, err := os.CreateTemp(filepath.Dir(serverModel.Path), filepath.Base(serverModel.Path)+".export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	defer os.Remove(bundle.Name())
+	defer bundle.Close()
+
+	err = sm.withSavesPaused(id, srv, func() error {
+		metadata.Mods, err = modManifest(serverModel.Path, artifacts)
+		if err != nil {
+			return fmt.Errorf("failed to build mod manifest: %w", err)
+		}
+		return writeBundle(bundle, serverModel.Path, artifacts, &metadata)
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := bundle.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, bundle)
+	return err
+}
+
+// bundleArtifacts maps the links CreateServer provisions in a server
+// directory to the shared artifacts they point to
+func bundleArtifacts(config *model.ServerConfig) map[string]string {
+	artifacts := map[string]string{"server.jar": config.JarFile.Path}
+	if config.ModPack != nil {
+		artifacts["mods"] = config.ModPack.Path
+	}
+	return artifacts
+}
+
+// provisionedArtifact returns the shared artifact path, at rel below the
+// server directory, is the provisioned link to, or "" for any other path.
+// Links the server placed itself are never resolved: the export runs as
+// root and would pack whatever they point to.
+func provisionedArtifact(path, rel string, artifacts map[string]string) string {
+	source, ok := artifacts[filepath.ToSlash(rel)]
+	if !ok {
+		return ""
+	}
+	target, err := os.Readlink(path)
+	if err != nil || filepath.Clean(target) != filepath.Clean(source) {
+		return ""
+	}
+	return source
+}
+
+// bundledJarPath returns where the jar ends up in the bundled server
+// directory: its own path for jars inside the directory, otherwise the
+// server.jar symlink CreateServer provisions
+func bundledJarPath(serverPath, jarPath string) string {
+	if rel, err := filepath.Rel(serverPath, jarPath); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return "server.jar"
+}
+
+// modManifest lists the files of the mods directory of a server with their
+// checksums. Only the provisioned link to the mod pack is followed.
+func modManifest(serverPath string, artifacts map[string]string) ([]BundleFile, error) {
+	mods := []BundleFile{}
+	modsDir := filepath.Join(serverPath, "mods")
+	root, open := modsDir, func(path string) (*os.File, error) {
+		rel, err := filepath.Rel(serverPath, path)
+		if err != nil {
+			return nil, err
+		}
+		return utils.OpenRegularIn(serverPath, rel)
+	}
+	if source := provisionedArtifact(modsDir, "mods", artifacts); source != "" {
+		root, open = source, os.Open
+	}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == root {
+			return nil
+		}
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		file, err := open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		mods = append(mods, BundleFile{Path: filepath.ToSlash(rel), Size: info.Size(), SHA256: hex.EncodeToString(hash.Sum(nil))})
+		return nil
+	})
+	return mods, err
+}
+
+func writeBundle(w io.Writer, serverPath string, artifacts map[string]string, metadata *BundleMetadata) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    bundleMetadataFile,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: metadata.ExportedAt,
+	})
+	if err == nil {
+		_, err = tw.Write(data)
+	}
+	if err == nil {
+		err = addBundleTree(tw, serverPath, bundleServerDir, artifacts)
+	}
+
+	if closeErr := tw.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if closeErr := gz.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// addBundleTree adds dir to the archive under prefix. The provisioned
+// artifact links are replaced by the artifacts, so the bundle does not
+// depend on shared files; any other symlink is stored as a link. Files are
+// opened without following links, as the server may swap them meanwhile.
+func addBundleTree(tw *tar.Writer, dir, prefix string, artifacts map[string]string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := prefix
+		if rel != "." {
+			name = prefix + "/" + filepath.ToSlash(rel)
+		}
+		if skipLockFiles(rel, info) {
+			return nil
+		}
+
+		var file *os.File
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			source := provisionedArtifact(path, rel, artifacts)
+			if source == "" {
+				if link, err = os.Readlink(path); err != nil {
+					return err
+				}
+			} else {
+				// Shared artifacts are the manager's own files
+				sourceInfo, err := os.Stat(source)
+				if err != nil {
+					return err
+				}
+				if sourceInfo.IsDir() {
+					return addBundleTree(tw, source, name, nil)
+				}
+				if file, err = os.Open(source); err != nil {
+					return err
+				}
+				defer file.Close()
+				info = sourceInfo
+			}
+		} else if info.Mode().IsRegular() {
+			if file, err = utils.OpenRegularIn(dir, rel); err != nil {
+				return err
+			}
+			defer file.Close()
+		} else if !info.IsDir() {
+			// FIFOs, sockets and devices are left out
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if file == nil {
+			return nil
+		}
+		// The header carries the size seen by the walk; a file the server
+		// grew or shrank meanwhile must still match it
+		_, err = io.Copy(tw, io.LimitReader(file, header.Size))
+		return err
+	})
+}
+
+// ImportBundle creates a server named name, owned by userID, from an export
+// bundle of size bytes read from r
+func (sm *ServerManager) ImportBundle(r io.Reader, size int64, name, path string, userID uint) (uint, error) {
+	// The bundle is compressed, so this is a lower bound of the extracted size
+	if err := sm.checkDiskSpace(path, size); err != nil {
+		return 0, err
+	}
+	staging := fmt.Sprintf("%s.import-%d", path, time.Now().UnixNano())
+	defer os.RemoveAll(staging)
+	if err := utils.ReadTarGz(r, staging); err != nil {
+		return 0, fmt.Errorf("failed to extract bundle: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(staging, bundleMetadataFile))
+	if err != nil {
+		return 0, fmt.Errorf("bundle has no %s: %w", bundleMetadataFile, err)
+	}
+	var metadata BundleMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", bundleMetadataFile, err)
+	}
+	if metadata.FormatVersion != bundleFormatVersion {
+		return 0, fmt.Errorf("unsupported bundle format version %d", metadata.FormatVersion)
+	}
+	if err := sm.checkServerUnique(name, path); err != nil {
+		return 0, err
+	}
+
+	jarPath, err := utils.SafeJoin(path, metadata.Jar.File)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := os.Stat(filepath.Join(staging, bundleServerDir, filepath.FromSlash(metadata.Jar.File))); err != nil {
+		return 0, fmt.Errorf("bundle does not contain its jar %s", metadata.Jar.File)
+	}
+
+	if err := os.Rename(filepath.Join(staging, bundleServerDir), path); err != nil {
+		return 0, fmt.Errorf("failed to move server directory into place: %w", err)
+	}
+
+	id, err := sm.registerServer(name, path, jarPath, metadata.Jar.Version, metadata.ExecutableCommand, userID)
+	if err != nil {
+		os.RemoveAll(path)
+		return 0, err
+	}
+	if metadata.Timezone != "" {
+		sm.db.Model(&model.Server{}).Where("id = ?", id).Update("timezone", metadata.Timezone)
+	}
+
+	slog.Info("Imported server bundle", "source", metadata.Name, "name", name, "mods", len(metadata.Mods))
+	return id, nil
+}
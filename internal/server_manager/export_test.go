@@ -0,0 +1,91 @@
+package server_manager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestExportStoresPlantedLinksAsLinks(t *testing.T) {
+	sm := newTestManager(t)
+	user := createTestUser(t, sm, "alice", model.RoleOperator)
+	id := createTestServer(t, sm, user, "survival")
+	serverModel, _, err := sm.ownedServer(id, user.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sm.commonDir, "server.jar"), []byte("jar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Links the server placed must not pull outside files into the bundle
+	outside := filepath.Join(sm.commonDir, "outside")
+	os.MkdirAll(outside, 0755)
+	secret := filepath.Join(outside, "secret.txt")
+	os.WriteFile(secret, []byte("secret"), 0600)
+	os.Symlink(secret, filepath.Join(serverModel.Path, "secret.txt"))
+	os.Symlink(outside, filepath.Join(serverModel.Path, "outside"))
+
+	var buf bytes.Buffer
+	if err := sm.ExportServer(id, user.ID, &buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]*tar.Header{}
+	contents := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		entries[header.Name], contents[header.Name] = header, string(data)
+	}
+
+	if header := entries["server/server.jar"]; header == nil || header.Typeflag != tar.TypeReg || contents["server/server.jar"] != "jar" {
+		t.Errorf("the provisioned jar was not replaced by the jar: %+v", header)
+	}
+	for _, name := range []string{"server/secret.txt", "server/outside"} {
+		if header := entries[name]; header == nil || header.Typeflag != tar.TypeSymlink {
+			t.Errorf("%s was not stored as a link: %+v", name, header)
+		}
+	}
+	for name, data := range contents {
+		if strings.HasPrefix(name, "server/outside/") || data == "secret" {
+			t.Errorf("the bundle holds %s from outside the server", name)
+		}
+	}
+
+	// A bundle that cannot be registered leaves no directory behind. The
+	// import refuses links leaving the bundle, so they go first.
+	os.Remove(filepath.Join(serverModel.Path, "secret.txt"))
+	os.Remove(filepath.Join(serverModel.Path, "outside"))
+	limit := int64(1)
+	sm.db.Model(user).Update("max_servers", &limit)
+	path := filepath.Join(sm.commonDir, "servers", "imported")
+	var bundle bytes.Buffer
+	if err := sm.ExportServer(id, user.ID, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.ImportBundle(&bundle, int64(bundle.Len()), "imported", path, user.ID); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("importing beyond the server quota: got %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("the directory of the refused import was kept: %v", err)
+	}
+}
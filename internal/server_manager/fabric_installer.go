@@ -0,0 +1,132 @@
+package server_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	fabricMetaURL     = "https://meta.fabricmc.net/v2/versions/installer"
+	fabricMavenURL    = "https://maven.fabricmc.net/net/fabricmc/fabric-installer/%s/fabric-installer-%s.jar"
+	fabricLaunchJar   = "fabric-server-launch.jar"
+	downloadTimeout   = 5 * time.Minute
+	fabricMetaTimeout = 30 * time.Second
+)
+
+// runFabricInstaller downloads the Fabric installer into the shared directory
+// and runs it in server mode, letting it fetch the vanilla server jar.
+func (sm *ServerManager) runFabricInstaller(serverID uint, serverPath, mcVersion, loaderVersion string) (string, error) {
+	installerPath, err := sm.fabricInstallerJar()
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"-jar", installerPath, "server", "-mcversion", mcVersion, "-downloadMinecraft"}
+	if loaderVersion != "" {
+		args = append(args, "-loader", loaderVersion)
+	}
+	if err := sm.runInstaller(serverID, serverPath, "java", args...); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Lstat(filepath.Join(serverPath, fabricLaunchJar)); err != nil {
+		return "", fmt.Errorf("fabric installer did not produce %s (see %s)", fabricLaunchJar, filepath.Join(serverPath, installerLog))
+	}
+
+	return "java -jar " + fabricLaunchJar + " nogui", nil
+}
+
+// fabricInstallerJar returns the path of the latest stable Fabric installer,
+// downloading it into the shared installers directory when missing.
+func (sm *ServerManager) fabricInstallerJar() (string, error) {
+	version, err := latestFabricInstallerVersion()
+	if err != nil {
+		return "", err
+	}
+
+	sharedDir, err := sm.sharedDir()
+	if err != nil {
+		return "", err
+	}
+	installerDir := filepath.Join(sharedDir, "installers")
+	if err := os.MkdirAll(installerDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create installers directory: %w", err)
+	}
+
+	installerPath := filepath.Join(installerDir, fmt.Sprintf("fabric-installer-%s.jar", version))
+	if _, err := os.Stat(installerPath); err == nil {
+		return installerPath, nil
+	}
+
+	url := fmt.Sprintf(fabricMavenURL, version, version)
+	slog.Info("Downloading Fabric installer", "version", version, "url", url)
+	if err := downloadFile(url, installerPath); err != nil {
+		return "", fmt.Errorf("failed to download fabric installer: %w", err)
+	}
+	return installerPath, nil
+}
+
+// latestFabricInstallerVersion asks the Fabric meta API for the newest stable installer
+func latestFabricInstallerVersion() (string, error) {
+	client := &http.Client{Timeout: fabricMetaTimeout}
+	resp, err := client.Get(fabricMetaURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to query fabric meta: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fabric meta returned %s", resp.Status)
+	}
+
+	var versions []struct {
+		Version string `json:"version"`
+		Stable  bool   `json:"stable"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return "", fmt.Errorf("failed to decode fabric meta response: %w", err)
+	}
+	for _, v := range versions {
+		if v.Stable {
+			return v.Version, nil
+		}
+	}
+	return "", fmt.Errorf("no stable fabric installer version found")
+}
+
+// downloadFile fetches url into dest, writing to a temporary file first so a
+// failed download never leaves a truncated artifact behind.
+func downloadFile(url, dest string) error {
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	tmp := dest + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,105 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// Operations that hold the files of a server
+const (
+	FileLockRunning        = "running"
+	FileLockBackup         = "backup"
+	FileLockRestore        = "restore"
+	FileLockJarSwap        = "jar_swap"
+	FileLockWorldDelete    = "world_delete"
+	FileLockModPackUpgrade = "mod_pack_upgrade"
+	FileLockInstall        = "loader_install"
+)
+
+// ErrFilesLocked is returned when an operation needs the files of a server
+// while another operation holds them
+var ErrFilesLocked = errors.New("server files are locked")
+
+// FileLockError names the operation holding the files of a server
+type FileLockError struct {
+	ServerID uint
+	// Operation is the operation holding the files, one of the FileLock constants
+	Operation string
+}
+
+func (e *FileLockError) Error() string {
+	if e.Operation == FileLockRunning {
+		return fmt.Sprintf("server %d is running; stop it first", e.ServerID)
+	}
+	return fmt.Sprintf("files of server %d are locked by a %s in progress", e.ServerID, e.Operation)
+}
+
+func (e *FileLockError) Unwrap() error {
+	return ErrFilesLocked
+}
+
+// fileLock is held on the files of a server. Exclusive locks are taken by
+// operations that replace or delete files; shared ones by operations that
+// only read them, such as backups, which may overlap.
+type fileLock struct {
+	operation string
+	exclusive bool
+	holders   int
+}
+
+// lockFiles takes the files of a server for a destructive operation. It
+// fails while any other operation holds them or, if srv is given, while the
+// server is running. The returned function releases the lock.
+func (sm *ServerManager) lockFiles(id uint, operation string, srv *server.Server) (func(), error) {
+	sm.fileLockMutex.Lock()
+	defer sm.fileLockMutex.Unlock()
+	if lock, held := sm.fileLocks[id]; held {
+		return nil, &FileLockError{ServerID: id, Operation: lock.operation}
+	}
+	if srv != nil && srv.IsRunning() {
+		return nil, &FileLockError{ServerID: id, Operation: FileLockRunning}
+	}
+	sm.fileLocks[id] = &fileLock{operation: operation, exclusive: true, holders: 1}
+	return func() { sm.unlockFiles(id) }, nil
+}
+
+// shareFiles takes the files of a server for an operation that reads them.
+// It fails only while a destructive operation holds them.
+func (sm *ServerManager) shareFiles(id uint, operation string) (func(), error) {
+	sm.fileLockMutex.Lock()
+	defer sm.fileLockMutex.Unlock()
+	lock, held := sm.fileLocks[id]
+	switch {
+	case !held:
+		sm.fileLocks[id] = &fileLock{operation: operation, holders: 1}
+	case lock.exclusive:
+		return nil, &FileLockError{ServerID: id, Operation: lock.operation}
+	default:
+		lock.holders++
+	}
+	return func() { sm.unlockFiles(id) }, nil
+}
+
+// unlockFiles drops one holder of the lock on the files of a server
+func (sm *ServerManager) unlockFiles(id uint) {
+	sm.fileLockMutex.Lock()
+	defer sm.fileLockMutex.Unlock()
+	if lock, held := sm.fileLocks[id]; held {
+		if lock.holders--; lock.holders <= 0 {
+			delete(sm.fileLocks, id)
+		}
+	}
+}
+
+// exclusiveFileLock returns a FileLockError while a destructive operation
+// holds the files of a server; the server must not start until it is done
+func (sm *ServerManager) exclusiveFileLock(id uint) error {
+	sm.fileLockMutex.Lock()
+	defer sm.fileLockMutex.Unlock()
+	if lock, held := sm.fileLocks[id]; held && lock.exclusive {
+		return &FileLockError{ServerID: id, Operation: lock.operation}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package server_manager
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFileLocks(t *testing.T) {
+	sm := &ServerManager{fileLocks: make(map[uint]*fileLock)}
+
+	releaseFirst, err := sm.shareFiles(1, FileLockBackup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseSecond, err := sm.shareFiles(1, FileLockBackup)
+	if err != nil {
+		t.Fatalf("overlapping backups were refused: %v", err)
+	}
+
+	_, err = sm.lockFiles(1, FileLockJarSwap, nil)
+	var lockErr *FileLockError
+	if !errors.As(err, &lockErr) || lockErr.Operation != FileLockBackup || !errors.Is(err, ErrFilesLocked) {
+		t.Fatalf("jar swap during a backup returned %v", err)
+	}
+	if _, err := sm.lockFiles(2, FileLockJarSwap, nil); err != nil {
+		t.Fatalf("other servers are not locked: %v", err)
+	}
+
+	releaseFirst()
+	if _, err := sm.lockFiles(1, FileLockWorldDelete, nil); err == nil {
+		t.Fatal("lock taken while a backup still held the files")
+	}
+	releaseSecond()
+
+	release, err := sm.lockFiles(1, FileLockWorldDelete, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.exclusiveFileLock(1); !errors.Is(err, ErrFilesLocked) {
+		t.Fatalf("start during a world delete returned %v", err)
+	}
+	if _, err := sm.shareFiles(1, FileLockBackup); !errors.As(err, &lockErr) || lockErr.Operation != FileLockWorldDelete {
+		t.Fatalf("backup during a world delete returned %v", err)
+	}
+	release()
+	if err := sm.exclusiveFileLock(1); err != nil {
+		t.Fatalf("files stayed locked after release: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package server_manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// GameplayOptions are the gameplay settings to change. Empty strings, a nil
+// PVP and missing game rules are left unchanged.
+type GameplayOptions struct {
+	Difficulty string
+	GameMode   string
+	PVP        *bool
+	// GameRules maps game rules to JSON booleans or numbers
+	GameRules map[string]interface{}
+}
+
+// GameplayResult tells how gameplay settings were applied
+type GameplayResult struct {
+	// Live is true if the settings were applied to the running server with
+	// commands, false if only its files were edited
+	Live bool `json:"live"`
+	// RestartRequired is true if a setting takes effect on the next start
+	RestartRequired bool `json:"restart_required"`
+}
+
+// UpdateGameplay changes the difficulty, default game mode, pvp and game
+// rules of a server. A running server gets the difficulty, defaultgamemode
+// and gamerule commands; pvp has no command and applies on the next start.
+// For a stopped server server.properties and the level.dat of its world are
+// edited. server.properties is updated either way, so the settings survive
+// restarts.
+func (sm *ServerManager) UpdateGameplay(id uint, userID uint, opts GameplayOptions) (*GameplayResult, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionConsole)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Difficulty != "" && !slices.Contains(server.Difficulties, opts.Difficulty) {
+		return nil, fmt.Errorf("unknown difficulty %q", opts.Difficulty)
+	}
+	if opts.GameMode != "" && !slices.Contains(server.GameModes, opts.GameMode) {
+		return nil, fmt.Errorf("unknown game mode %q", opts.GameMode)
+	}
+	rules := make(map[string]string, len(opts.GameRules))
+	for rule, value := range opts.GameRules {
+		if rules[rule], err = server.GameRuleValue(rule, value); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Difficulty == "" && opts.GameMode == "" && opts.PVP == nil && len(rules) == 0 {
+		return nil, fmt.Errorf("no gameplay settings to change")
+	}
+
+	local := serverModel.NodeID == nil
+	result := &GameplayResult{Live: srv.IsRunning()}
+	if !result.Live && !local {
+		return nil, fmt.Errorf("the server runs on a node; start it to change gameplay settings")
+	}
+	if opts.PVP != nil {
+		if !local {
+			return nil, fmt.Errorf("pvp of servers on nodes cannot be changed")
+		}
+		result.RestartRequired = result.Live
+	}
+
+	if result.Live {
+		if err := sendGameplayCommands(srv, opts, rules); err != nil {
+			return nil, err
+		}
+	} else if opts.Difficulty != "" || len(rules) > 0 {
+		level := levelName(serverModel.Path)
+		if _, err := os.Stat(filepath.Join(serverModel.Path, level, "level.dat")); err == nil {
+			if err := server.SetLevelGameplay(serverModel.Path, level, opts.Difficulty, rules); err != nil {
+				return nil, err
+			}
+		} else if len(rules) > 0 {
+			// New worlds take their difficulty from server.properties, but
+			// game rules only live in level.dat
+			return nil, fmt.Errorf("the world has not been generated yet; start the server once before changing game rules")
+		}
+	}
+	if local {
+		if err := server.SetGameplayProperties(serverModel.Path, opts.Difficulty, opts.GameMode, opts.PVP); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// sendGameplayCommands applies gameplay settings to a running server.
+// Game rules are sent in name order.
+func sendGameplayCommands(srv *server.Server, opts GameplayOptions, rules map[string]string) error {
+	var commands []string
+	if opts.Difficulty != "" {
+		commands = append(commands, "difficulty "+opts.Difficulty)
+	}
+	if opts.GameMode != "" {
+		commands = append(commands, "defaultgamemode "+opts.GameMode)
+	}
+	names := make([]string, 0, len(rules))
+	for rule := range rules {
+		names = append(names, rule)
+	}
+	sort.Strings(names)
+	for _, rule := range names {
+		commands = append(commands, "gamerule "+rule+" "+rules[rule])
+	}
+	for _, command := range commands {
+		if err := srv.SendCommand(command); err != nil {
+			return fmt.Errorf("failed to send %q: %w", command, err)
+		}
+	}
+	return nil
+}
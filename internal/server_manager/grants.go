@@ -0,0 +1,157 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrPermissionDenied is returned when a user's grant on a server lacks the
+// permission an action needs
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Authorize checks that userID may perform an action needing permission on
+// a server. Owners and the managers and owners of its team hold every
+// permission, plain team members every one but manage; other users need a
+// grant that includes it. Deleted servers are covered so they can be
+// restored.
+func (sm *ServerManager) Authorize(id uint, userID uint, permission string) error {
+	var controlled int64
+	err := sm.db.Unscoped().Model(&model.Server{}).Scopes(sm.serverControl(userID)).Where("servers.id = ?", id).Count(&controlled).Error
+	if err != nil {
+		return fmt.Errorf("failed to check server access: %w", err)
+	}
+	if controlled > 0 {
+		return nil
+	}
+	var shared int64
+	err = sm.db.Unscoped().Model(&model.Server{}).Scopes(sm.serverShared(userID)).Where("servers.id = ?", id).Count(&shared).Error
+	if err != nil {
+		return fmt.Errorf("failed to check server access: %w", err)
+	}
+	if shared > 0 {
+		if permission == model.PermissionManage {
+			return fmt.Errorf("%w: requires the %s permission", ErrPermissionDenied, permission)
+		}
+		return nil
+	}
+
+	var grant model.ServerGrant
+	if err := sm.db.Where("server_id = ? AND user_id = ?", id, userID).First(&grant).Error; err != nil {
+		return fmt.Errorf("server not found: %w", err)
+	}
+	if permission == model.PermissionManage || !grant.Has(permission) {
+		return fmt.Errorf("%w: requires the %s permission", ErrPermissionDenied, permission)
+	}
+	return nil
+}
+
+// grantedServers returns a subquery of the IDs of servers userID holds a grant on
+func (sm *ServerManager) grantedServers(userID uint) *gorm.DB {
+	return sm.db.Model(&model.ServerGrant{}).Select("server_id").Where("user_id = ?", userID)
+}
+
+// GrantServerAccess gives a user a set of permissions on a server, replacing
+// any permissions granted before. Only owners and team managers can grant.
+func (sm *ServerManager) GrantServerAccess(id uint, userID uint, username string, permissions []string) (*model.ServerGrant, error) {
+	if err := sm.Authorize(id, userID, model.PermissionManage); err != nil {
+		return nil, err
+	}
+	if len(permissions) == 0 {
+		return nil, fmt.Errorf("grant at least one of %s", strings.Join(model.GrantablePermissions, ", "))
+	}
+	for _, p := range permissions {
+		if !slices.Contains(model.GrantablePermissions, p) {
+			return nil, fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	// Stored in a fixed order without duplicates
+	var granted []string
+	for _, p := range model.GrantablePermissions {
+		if slices.Contains(permissions, p) {
+			granted = append(granted, p)
+		}
+	}
+
+	var user model.User
+	if err := sm.db.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user %s not found: %w", username, err)
+	}
+	// Team members already hold every permission a grant can give
+	var shared int64
+	if err := sm.db.Model(&model.Server{}).Scopes(sm.serverShared(user.ID)).Where("servers.id = ?", id).Count(&shared).Error; err != nil {
+		return nil, fmt.Errorf("failed to check server access: %w", err)
+	}
+	if shared > 0 {
+		return nil, fmt.Errorf("%s already has access to the server", username)
+	}
+
+	var grant model.ServerGrant
+	err := sm.db.Where("server_id = ? AND user_id = ?", id, user.ID).First(&grant).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch grant: %w", err)
+	}
+	grant.ServerID = id
+	grant.UserID = user.ID
+	grant.Permissions = strings.Join(granted, ",")
+	grant.GrantedBy = userID
+	if err := sm.db.Save(&grant).Error; err != nil {
+		return nil, fmt.Errorf("failed to save grant: %w", err)
+	}
+	grant.Username = user.Username
+
+	slog.Info("Granted server access", "user_id", userID, "grantee", username, "permissions", grant.Permissions, "server_id", id)
+	return &grant, nil
+}
+
+// ListServerGrants returns the grants of a server owned by or shared with userID
+func (sm *ServerManager) ListServerGrants(id uint, userID uint) ([]model.ServerGrant, error) {
+	if err := sm.Authorize(id, userID, model.PermissionManage); err != nil {
+		return nil, err
+	}
+	var grants []model.ServerGrant
+	if err := sm.db.Where("server_id = ?", id).Order("id").Find(&grants).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch grants: %w", err)
+	}
+
+	ids := make([]uint, len(grants))
+	for i, g := range grants {
+		ids[i] = g.UserID
+	}
+	var users []model.User
+	if err := sm.db.Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %w", err)
+	}
+	for i := range grants {
+		for _, u := range users {
+			if u.ID == grants[i].UserID {
+				grants[i].Username = u.Username
+			}
+		}
+	}
+	return grants, nil
+}
+
+// RevokeServerAccess removes the grant of granteeID on a server. Grantees
+// can give up their own grant.
+func (sm *ServerManager) RevokeServerAccess(id uint, userID uint, granteeID uint) error {
+	if granteeID != userID {
+		if err := sm.Authorize(id, userID, model.PermissionManage); err != nil {
+			return err
+		}
+	}
+	result := sm.db.Unscoped().Where("server_id = ? AND user_id = ?", id, granteeID).Delete(&model.ServerGrant{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke grant: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("grant not found: %w", gorm.ErrRecordNotFound)
+	}
+	slog.Info("Revoked server access", "user_id", userID, "grantee_id", granteeID, "server_id", id)
+	return nil
+}
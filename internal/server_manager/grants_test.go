@@ -0,0 +1,144 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestGrantedActionsNeedTheirPermission(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	grantee := createTestUser(t, sm, "bob", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "survival")
+	if _, err := sm.GrantServerAccess(id, owner.ID, grantee.Username, []string{model.PermissionPower}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sm.ServerStats(id, grantee.ID); err != nil {
+		t.Errorf("viewing stats: %v", err)
+	}
+	if _, _, err := sm.CreateWebhook(grantee.ID, WebhookOptions{URL: "https://example.com/hook", ServerID: &id}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("CreateWebhook: got %v, want ErrPermissionDenied", err)
+	}
+	if _, err := sm.CreateAlertRule(grantee.ID, AlertRuleOptions{Name: "down", ServerID: &id, Condition: model.AlertServerDown}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("CreateAlertRule: got %v, want ErrPermissionDenied", err)
+	}
+	if _, err := sm.ListPlayers(id, grantee.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("ListPlayers without console: got %v, want ErrPermissionDenied", err)
+	}
+	if _, err := sm.DeleteWorld(id, grantee.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("DeleteWorld without files: got %v, want ErrPermissionDenied", err)
+	}
+
+	// Each granted permission unlocks its own actions and no others
+	if _, err := sm.GrantServerAccess(id, owner.ID, grantee.Username, []string{model.PermissionConsole}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.ListPlayers(id, grantee.ID); err != nil {
+		t.Errorf("ListPlayers with console: %v", err)
+	}
+	if _, err := sm.ListBackups(id, grantee.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("ListBackups without backups: got %v, want ErrPermissionDenied", err)
+	}
+	backup := &model.Backup{ServerID: id, UserID: owner.ID, Name: "nightly", Path: filepath.Join(sm.commonDir, "nightly.tar.gz"), Status: model.BackupStatusCompleted}
+	if err := sm.db.Create(backup).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := sm.OpenBackup(backup.ID, grantee.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("OpenBackup without backups: got %v, want ErrPermissionDenied", err)
+	}
+	if err := sm.DeleteBackup(backup.ID, grantee.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("DeleteBackup without backups: got %v, want ErrPermissionDenied", err)
+	}
+
+	// Owners hold every permission
+	if _, _, err := sm.CreateWebhook(owner.ID, WebhookOptions{URL: "https://example.com/hook", ServerID: &id}); err != nil {
+		t.Errorf("CreateWebhook as owner: %v", err)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	member := createTestUser(t, sm, "bob", model.RoleOperator)
+	invitee := createTestUser(t, sm, "carol", model.RoleOperator)
+	grantee := createTestUser(t, sm, "dave", model.RoleOperator)
+	manager := createTestUser(t, sm, "erin", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "survival")
+
+	team, err := sm.CreateTeam("crew", owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []*model.User{member, invitee} {
+		if _, err := sm.InviteTeamMember(team.ID, owner.ID, user.Username, model.TeamRoleMember); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sm.InviteTeamMember(team.ID, owner.ID, manager.Username, model.TeamRoleManager); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []*model.User{member, manager} {
+		if _, err := sm.AcceptTeamInvitation(team.ID, user.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sm.SetServerTeam(id, owner.ID, &team.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.GrantServerAccess(id, owner.ID, grantee.Username, []string{model.PermissionFiles}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user       *model.User
+		permission string
+		want       error
+	}{
+		{owner, model.PermissionManage, nil},
+		{manager, model.PermissionManage, nil},
+		{member, model.PermissionFiles, nil},
+		{member, model.PermissionManage, ErrPermissionDenied},
+		{invitee, model.PermissionView, gorm.ErrRecordNotFound},
+		{grantee, model.PermissionView, nil},
+		{grantee, model.PermissionFiles, nil},
+		{grantee, model.PermissionConsole, ErrPermissionDenied},
+		{grantee, model.PermissionManage, ErrPermissionDenied},
+	}
+	for _, tt := range tests {
+		if err := sm.Authorize(id, tt.user.ID, tt.permission); !errors.Is(err, tt.want) {
+			t.Errorf("%s with %s: got %v, want %v", tt.user.Username, tt.permission, err, tt.want)
+		}
+	}
+
+	// Plain members cannot hand out access
+	if _, err := sm.GrantServerAccess(id, member.ID, invitee.Username, []string{model.PermissionView}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("GrantServerAccess as member: got %v, want ErrPermissionDenied", err)
+	}
+
+	// Only owners and team managers can restore a deleted server
+	if err := sm.DeleteServer(context.Background(), id, owner.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Authorize(id, manager.ID, model.PermissionManage); err != nil {
+		t.Errorf("team manager on a deleted server: %v", err)
+	}
+	for _, user := range []*model.User{member, grantee} {
+		if _, err := sm.RestoreServer(id, user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("RestoreServer as %s: got %v, want not found", user.Username, err)
+		}
+	}
+
+	// Leaving the team ends the access
+	if err := sm.RemoveTeamMember(team.ID, member.ID, member.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Authorize(id, member.ID, model.PermissionView); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("former team member: got %v, want not found", err)
+	}
+}
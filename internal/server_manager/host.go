@@ -0,0 +1,99 @@
+package server_manager
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// HostStats is the capacity of the machine running the control plane and
+// the memory its servers are configured to use
+type HostStats struct {
+	CPUCores int `json:"cpu_cores" example:"8"`
+	// Load is the 1, 5 and 15 minute load average
+	Load              []float64 `json:"load" example:"1.5,1.2,0.9"`
+	MemoryTotalMB     int64     `json:"memory_total_mb" example:"32768"`
+	MemoryAvailableMB int64     `json:"memory_available_mb" example:"20480"`
+	// Disk is the volume holding the server directories
+	Disk VolumeStats `json:"disk"`
+	// Servers counts the servers on this machine, archived ones excepted,
+	// and AllocatedMemoryMB sums their -Xmx
+	Servers           int   `json:"servers" example:"6"`
+	AllocatedMemoryMB int64 `json:"allocated_memory_mb" example:"24576"`
+	// RunningServers and RunningMemoryMB cover the servers running now
+	RunningServers  int   `json:"running_servers" example:"3"`
+	RunningMemoryMB int64 `json:"running_memory_mb" example:"12288"`
+	// AllocationPercent is the allocated memory as a share of the
+	// physical memory, over 100 when the machine is oversubscribed
+	AllocationPercent float64 `json:"allocation_percent" example:"75"`
+	Oversubscribed    bool    `json:"oversubscribed"`
+	// Errors lists the host figures that could not be read and are zero
+	Errors []string `json:"errors,omitempty"`
+}
+
+// HostStats returns the CPU, memory, load and disk of the control plane
+// machine and compares the memory of its servers with the physical memory.
+// Servers on node agents are left out, their nodes report capacity instead.
+func (sm *ServerManager) HostStats() (*HostStats, error) {
+	stats := &HostStats{CPUCores: runtime.NumCPU(), Load: []float64{0, 0, 0}}
+
+	if memory, err := utils.ReadHostMemory(); err == nil {
+		stats.MemoryTotalMB = int64(memory.TotalBytes >> 20)
+		stats.MemoryAvailableMB = int64(memory.AvailableBytes >> 20)
+	} else {
+		stats.Errors = append(stats.Errors, fmt.Sprintf("memory: %v", err))
+	}
+	if load, err := utils.LoadAverage(); err == nil {
+		stats.Load = load[:]
+	} else {
+		stats.Errors = append(stats.Errors, fmt.Sprintf("load: %v", err))
+	}
+
+	serversDir, err := ServersDir()
+	if err != nil {
+		return nil, err
+	}
+	stats.Disk = VolumeStats{Name: "servers", Path: serversDir}
+	free, err := utils.FreeDiskSpace(serversDir)
+	if err == nil {
+		stats.Disk.FreeBytes = free
+		stats.Disk.UsedPercent, err = utils.DiskUsage(serversDir)
+	}
+	if err != nil {
+		stats.Disk.Error = err.Error()
+	}
+
+	var rows []struct {
+		ID                uint
+		ExecutableCommand string
+	}
+	err = sm.db.Model(&model.Server{}).
+		Select("servers.id, server_configs.executable_command").
+		Joins("JOIN server_configs ON server_configs.server_id = servers.id").
+		Where("servers.node_id IS NULL AND servers.archived_at IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch servers: %w", err)
+	}
+
+	sm.mutex.RLock()
+	for _, row := range rows {
+		memoryMB := server.CommandMemoryMB(row.ExecutableCommand)
+		stats.Servers++
+		stats.AllocatedMemoryMB += memoryMB
+		if srv, ok := sm.servers[row.ID]; ok && srv.IsRunning() {
+			stats.RunningServers++
+			stats.RunningMemoryMB += memoryMB
+		}
+	}
+	sm.mutex.RUnlock()
+
+	if stats.MemoryTotalMB > 0 {
+		stats.AllocationPercent = float64(stats.AllocatedMemoryMB) / float64(stats.MemoryTotalMB) * 100
+		stats.Oversubscribed = stats.AllocatedMemoryMB > stats.MemoryTotalMB
+	}
+	return stats, nil
+}
@@ -0,0 +1,92 @@
+package server_manager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+const (
+	// serverIconFile is where the game server looks for its server list icon
+	serverIconFile = "server-icon.png"
+	// serverIconSize is the width and height the game requires
+	serverIconSize = 64
+	// maxIconBytes bounds icon uploads; a 64x64 PNG is far smaller
+	maxIconBytes = 256 << 10
+)
+
+// ErrNoServerIcon is returned when a server has no icon
+var ErrNoServerIcon = errors.New("server has no icon")
+
+// SetServerIcon validates a 64x64 PNG and installs it as the server's
+// server-icon.png. The game server picks it up on its next start.
+func (sm *ServerManager) SetServerIcon(id uint, userID uint, r io.Reader) error {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxIconBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read icon: %w", err)
+	}
+	if len(data) > maxIconBytes {
+		return fmt.Errorf("icon must be at most %d KB", maxIconBytes>>10)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("icon is not a valid PNG: %w", err)
+	}
+	if size := img.Bounds().Size(); size.X != serverIconSize || size.Y != serverIconSize {
+		return fmt.Errorf("icon must be %dx%d pixels, got %dx%d", serverIconSize, serverIconSize, size.X, size.Y)
+	}
+
+	path := filepath.Join(serverModel.Path, serverIconFile)
+	tmp := path + ".tmp"
+	if err := utils.WriteFileIn(serverModel.Path, serverIconFile+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write icon: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to install icon: %w", err)
+	}
+	return nil
+}
+
+// ServerIconPath returns the path of a server's icon
+func (sm *ServerManager) ServerIconPath(id uint, userID uint) (string, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionView)
+	if err != nil {
+		return "", err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return "", err
+	}
+	path := filepath.Join(serverModel.Path, serverIconFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", ErrNoServerIcon
+	}
+	return path, nil
+}
+
+// DeleteServerIcon removes a server's icon
+func (sm *ServerManager) DeleteServerIcon(id uint, userID uint) error {
+	path, err := sm.ServerIconPath(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove icon: %w", err)
+	}
+	return nil
+}
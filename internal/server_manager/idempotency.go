@@ -0,0 +1,56 @@
+package server_manager
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// idempotencyKeyTTL is how long the response to an idempotent request is
+// kept for retries
+const idempotencyKeyTTL = 24 * time.Hour
+
+// ReserveIdempotencyKey claims key for a request of userID. It returns the
+// stored record and whether it was created by this call; otherwise the key
+// was used before and the record tells how that request went.
+func (sm *ServerManager) ReserveIdempotencyKey(userID uint, key, request string) (*model.IdempotencyKey, bool, error) {
+	if err := sm.db.Where("created_at < ?", time.Now().Add(-idempotencyKeyTTL)).Delete(&model.IdempotencyKey{}).Error; err != nil {
+		slog.Error("Failed to remove expired idempotency keys", "error", err)
+	}
+
+	record := &model.IdempotencyKey{UserID: userID, Key: key, Request: request}
+	if err := sm.db.Create(record).Error; err == nil {
+		return record, true, nil
+	}
+
+	// The unique index rejected the key, so look up its first use
+	existing := &model.IdempotencyKey{}
+	if err := sm.db.Where("user_id = ? AND key = ?", userID, key).First(existing).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	return existing, false, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request that reserved
+// record
+func (sm *ServerManager) CompleteIdempotencyKey(record *model.IdempotencyKey, status int, contentType string, response []byte) {
+	err := sm.db.Model(record).Updates(map[string]interface{}{
+		"completed":    true,
+		"status_code":  status,
+		"content_type": contentType,
+		"response":     response,
+	}).Error
+	if err != nil {
+		slog.Error("Failed to store idempotent response", "key", record.Key, "user_id", record.UserID, "error", err)
+	}
+}
+
+// ReleaseIdempotencyKey forgets a key whose request failed, so it can be
+// retried
+func (sm *ServerManager) ReleaseIdempotencyKey(record *model.IdempotencyKey) {
+	if err := sm.db.Delete(record).Error; err != nil {
+		slog.Error("Failed to release idempotency key", "key", record.Key, "user_id", record.UserID, "error", err)
+	}
+}
@@ -0,0 +1,71 @@
+package server_manager
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// idleCheckInterval is how often servers are checked for players
+const idleCheckInterval = 30 * time.Second
+
+// StartIdleShutdown stops servers that have had no players for their idle
+// shutdown delay, until stop is closed. Their placeholder starts them again
+// when a player tries to join.
+func (sm *ServerManager) StartIdleShutdown(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(idleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				sm.stopIdleServers(now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopIdleServers stops the servers idle for longer than their delay. A
+// server counts as idle from the first check that finds it finished
+// starting and empty, which gives the player whose join started it the
+// whole delay to come back.
+func (sm *ServerManager) stopIdleServers(now time.Time) {
+	var configs []model.ServerConfig
+	if err := sm.db.Where("idle_shutdown_minutes > 0").Find(&configs).Error; err != nil {
+		slog.Error("Failed to fetch server configs", "error", err)
+		return
+	}
+
+	idle := make(map[uint]time.Time)
+	for _, config := range configs {
+		sm.mutex.RLock()
+		srv, ok := sm.servers[config.ServerID]
+		sm.mutex.RUnlock()
+		if !ok || !srv.IsRunning() || len(srv.OnlinePlayers()) > 0 {
+			continue
+		}
+		select {
+		case <-srv.Ready():
+		default:
+			continue
+		}
+
+		since, seen := sm.idleSince[config.ServerID]
+		if !seen {
+			since = now
+		}
+		delay := time.Duration(config.IdleShutdownMinutes) * time.Minute
+		if now.Sub(since) < delay {
+			idle[config.ServerID] = since
+			continue
+		}
+		slog.Info("Stopping idle server", "server_id", config.ServerID, "idle", now.Sub(since).Round(time.Second))
+		if err := srv.Stop(); err != nil {
+			slog.Error("Failed to stop idle server", "server_id", config.ServerID, "error", err)
+		}
+	}
+	sm.idleSince = idle
+}
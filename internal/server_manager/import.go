@@ -0,0 +1,253 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// ErrImportPathNotAllowed is returned when an import path lies outside the
+// configured import roots
+var ErrImportPathNotAllowed = errors.New("import path is not below an allowed import root")
+
+// ImportOptions describes an existing server directory to take over
+type ImportOptions struct {
+	Name string
+	Path string
+	// Jar selects the server jar when the directory holds several
+	Jar string
+	// ExecutableCommand overrides the detected start command
+	ExecutableCommand string
+}
+
+// ImportServer registers an existing server directory as a managed server
+// without copying or moving any data. The jar and start command are
+// detected from the directory unless given.
+func (sm *ServerManager) ImportServer(opts ImportOptions, allowedRoots []string, userID uint) (uint, error) {
+	if !pathBelowAny(opts.Path, allowedRoots) {
+		return 0, ErrImportPathNotAllowed
+	}
+	// The server is registered at the real directory, so a link swapped
+	// later cannot point it elsewhere
+	path, err := filepath.Abs(opts.Path)
+	if err != nil {
+		return 0, err
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", opts.Path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return 0, fmt.Errorf("%s is not a directory", path)
+	}
+
+	if err := sm.checkServerUnique(opts.Name, path); err != nil {
+		return 0, err
+	}
+
+	jarPath, err := detectServerJar(path, opts.Jar)
+	if err != nil {
+		return 0, err
+	}
+	command := opts.ExecutableCommand
+	if command == "" {
+		command = detectStartCommand(path, jarPath)
+	}
+
+	id, err := sm.registerServer(opts.Name, path, jarPath, "imported", command, userID)
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("Imported server", "name", opts.Name, "path", path, "jar", filepath.Base(jarPath), "command", command)
+	return id, nil
+}
+
+// checkServerUnique fails when a server with the name or path already
+// exists, or when path contains or lies inside the directory of another
+// server, deleted ones included
+func (sm *ServerManager) checkServerUnique(name, path string) error {
+	var existing int64
+	if err := sm.db.Model(&model.Server{}).Where("name = ? OR path = ?", name, path).Count(&existing).Error; err != nil {
+		return fmt.Errorf("error checking for existing server: %w", err)
+	}
+	if existing > 0 {
+		return fmt.Errorf("a server named %s or at %s already exists", name, path)
+	}
+	if err := sm.checkPathNotDeleted(path); err != nil {
+		return err
+	}
+
+	var paths []string
+	if err := sm.db.Unscoped().Model(&model.Server{}).Pluck("path", &paths).Error; err != nil {
+		return fmt.Errorf("error checking for existing server: %w", err)
+	}
+	path = resolvePath(path)
+	for _, other := range paths {
+		resolved := resolvePath(other)
+		if pathBelow(path, resolved) || pathBelow(resolved, path) {
+			return fmt.Errorf("%s overlaps the directory of the server at %s", path, other)
+		}
+	}
+	return nil
+}
+
+// registerServer records a server whose directory and jar already exist on
+// disk. Unlike CreateServer it provisions nothing; the jar is registered as
+// a server-specific jar file at its current location.
+func (sm *ServerManager) registerServer(name, path, jarPath, jarVersion, command string, userID uint) (uint, error) {
+	quota := QuotaRequest{Servers: 1, MemoryMB: server.CommandMemoryMB(command), DiskBytes: DirSize(path)}
+	if err := sm.CheckQuota(userID, quota); err != nil {
+		return 0, err
+	}
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	tx := sm.db.Begin()
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	}
+
+	serverModel := &model.Server{
+		Name:   name,
+		UserID: userID,
+		Status: model.ServerStatusStopped,
+		Path:   path,
+	}
+	if err := tx.Create(serverModel).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to create server in database: %w", err)
+	}
+
+	jarFile := &model.JarFile{
+		Name:    filepath.Base(jarPath),
+		Version: jarVersion,
+		Path:    jarPath,
+	}
+	if err := tx.Create(jarFile).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to create jar file record: %w", err)
+	}
+
+	serverConfig := &model.ServerConfig{
+		ServerID:          serverModel.ID,
+		ExecutableCommand: command,
+		JarFileID:         jarFile.ID,
+	}
+	if err := tx.Create(serverConfig).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to create server config in database: %w", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	sm.servers[serverModel.ID] = sm.newServer(serverModel)
+	return serverModel.ID, nil
+}
+
+// detectServerJar picks the server jar in the top level of a server
+// directory: the requested one, server.jar, or the only jar present
+func detectServerJar(serverPath, requested string) (string, error) {
+	if requested != "" {
+		jarPath := filepath.Join(serverPath, filepath.Base(requested))
+		if _, err := os.Stat(jarPath); err != nil {
+			return "", fmt.Errorf("jar %s not found in %s", requested, serverPath)
+		}
+		return jarPath, nil
+	}
+
+	jars, err := filepath.Glob(filepath.Join(serverPath, "*.jar"))
+	if err != nil {
+		return "", err
+	}
+	for _, jar := range jars {
+		if filepath.Base(jar) == "server.jar" {
+			return jar, nil
+		}
+	}
+
+	// Forge and NeoForge installers leave their own jar next to the server
+	var candidates []string
+	for _, jar := range jars {
+		if !strings.HasSuffix(jar, "-installer.jar") {
+			candidates = append(candidates, jar)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("no server jar found in %s", serverPath)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, jar := range candidates {
+		names[i] = filepath.Base(jar)
+	}
+	return "", fmt.Errorf("several jars found in %s, choose one of: %s", serverPath, strings.Join(names, ", "))
+}
+
+// detectStartCommand reuses the java line of a run.sh when the directory
+// has one (Forge, NeoForge and most hand-written setups), otherwise runs the jar
+func detectStartCommand(serverPath, jarPath string) string {
+	if command, err := commandFromRunScript(filepath.Join(serverPath, "run.sh")); err == nil {
+		return command
+	}
+	if command, err := commandFromRunScript(filepath.Join(serverPath, "start.sh")); err == nil {
+		return command
+	}
+	return fmt.Sprintf("java -jar %s nogui", filepath.Base(jarPath))
+}
+
+// pathBelowAny reports whether path is one of roots or lies below one once
+// symlinks are resolved. Paths that do not exist are below no root.
+func pathBelowAny(path string, roots []string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return false
+	}
+	for _, root := range roots {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if root, err = filepath.EvalSymlinks(root); err != nil {
+			continue
+		}
+		if pathBelow(path, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathBelow reports whether the absolute path is root or lies below it
+func pathBelow(path, root string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// resolvePath returns the absolute path with symlinks resolved, as far as
+// it exists. Archived and not yet created servers have no directory.
+func resolvePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
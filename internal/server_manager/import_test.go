@@ -0,0 +1,127 @@
+package server_manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestDetectServerJar(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		requested string
+		want      string
+	}{
+		{name: "server.jar wins", files: []string{"paper.jar", "server.jar"}, want: "server.jar"},
+		{name: "only jar", files: []string{"paper-1.21.jar", "eula.txt"}, want: "paper-1.21.jar"},
+		{name: "installer left out", files: []string{"forge-1.20.1-installer.jar", "forge-1.20.1.jar"}, want: "forge-1.20.1.jar"},
+		{name: "requested", files: []string{"paper.jar", "server.jar"}, requested: "paper.jar", want: "paper.jar"},
+		{name: "requested outside the directory", files: []string{"paper.jar"}, requested: "../paper.jar", want: "paper.jar"},
+		{name: "requested missing", files: []string{"server.jar"}, requested: "paper.jar"},
+		{name: "several jars", files: []string{"paper.jar", "purpur.jar"}},
+		{name: "only the installer", files: []string{"forge-1.20.1-installer.jar"}},
+		{name: "no jar", files: []string{"eula.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := detectServerJar(dir, tt.requested)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(dir, tt.want); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPathBelowAny(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "servers")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "survival"), filepath.Join(base, "servers-old"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Symlink(outside, filepath.Join(root, "escape"))
+	os.Symlink(root, filepath.Join(base, "linked-root"))
+
+	tests := []struct {
+		name  string
+		path  string
+		roots []string
+		want  bool
+	}{
+		{"root itself", root, []string{root}, true},
+		{"below the root", filepath.Join(root, "survival"), []string{root}, true},
+		{"unclean path", filepath.Join(root, "survival", "..", "survival"), []string{root}, true},
+		{"sibling sharing the prefix", filepath.Join(base, "servers-old"), []string{root}, false},
+		{"link out of the root", filepath.Join(root, "escape"), []string{root}, false},
+		{"dot dot out of the root", filepath.Join(root, "..", "outside"), []string{root}, false},
+		{"linked root", filepath.Join(root, "survival"), []string{filepath.Join(base, "linked-root")}, true},
+		{"second root", outside, []string{root, outside}, true},
+		{"missing path", filepath.Join(root, "missing"), []string{root}, false},
+		{"no roots", root, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathBelowAny(tt.path, tt.roots); got != tt.want {
+				t.Errorf("pathBelowAny(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportServerRejectsOverlappingPaths(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "survival")
+	serverModel, _, err := sm.ownedServer(id, owner.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := []string{sm.commonDir}
+
+	nested := filepath.Join(serverModel.Path, "world")
+	parent := filepath.Join(sm.commonDir, "servers")
+	standalone := filepath.Join(sm.commonDir, "creative")
+	for _, dir := range []string{nested, parent, standalone} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "server.jar"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	linked := filepath.Join(sm.commonDir, "linked")
+	os.Symlink(serverModel.Path, linked)
+
+	for name, path := range map[string]string{"inside": nested, "containing": parent, "linked": linked} {
+		if _, err := sm.ImportServer(ImportOptions{Name: name, Path: path}, roots, owner.ID); err == nil {
+			t.Errorf("importing %s: got no error", path)
+		}
+	}
+	if _, err := sm.ImportServer(ImportOptions{Name: "outside", Path: t.TempDir()}, roots, owner.ID); !errors.Is(err, ErrImportPathNotAllowed) {
+		t.Errorf("importing outside the roots: got %v, want ErrImportPathNotAllowed", err)
+	}
+	if _, err := sm.ImportServer(ImportOptions{Name: "creative", Path: standalone}, roots, owner.ID); err != nil {
+		t.Errorf("importing a separate directory: %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package server_manager
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// Supported loaders for the install pipeline
+const (
+	LoaderForge    = "forge"
+	LoaderNeoForge = "neoforge"
+	LoaderFabric   = "fabric"
+)
+
+// installTimeout bounds how long a headless installer may run
+const installTimeout = 15 * time.Minute
+
+// installerLog is the file in the server directory installers write their
+// output to
+const installerLog = "installer.log"
+
+// Loader install states
+const (
+	InstallRunning   = "running"
+	InstallSucceeded = "succeeded"
+	InstallFailed    = "failed"
+)
+
+// ErrInvalidInstall is returned for loader installs that cannot run on a
+// server, such as Forge without an installer jar attached
+var ErrInvalidInstall = errors.New("invalid loader install")
+
+// InstallOptions selects the loader and versions to install
+type InstallOptions struct {
+	Loader           string
+	MinecraftVersion string
+	LoaderVersion    string
+}
+
+// LoaderInstall tracks a loader installer running in a server directory
+type LoaderInstall struct {
+	ServerID uint   `json:"server_id"`
+	Loader   string `json:"loader"`
+	Status   string `json:"status"`
+	// ExecutableCommand is the launch command the installer produced, set
+	// once it succeeded
+	ExecutableCommand string     `json:"executable_command,omitempty"`
+	Reason            string     `json:"reason,omitempty"`
+	LogPath           string     `json:"log_path"`
+	StartedAt         time.Time  `json:"started_at"`
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+}
+
+// InstallLoader starts the loader installer headless inside the directory
+// of a stopped server. The files of the server stay locked until it is
+// done; the executable command is then updated to the launch arguments it
+// produced. GetLoaderInstall reports the progress.
+func (sm *ServerManager) InstallLoader(id uint, userID uint, opts InstallOptions) (*LoaderInstall, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+
+	var config model.ServerConfig
+	if err := sm.db.Preload("JarFile").Where("server_id = ?", id).First(&config).Error; err != nil {
+		return nil, fmt.Errorf("failed to get server config: %w", err)
+	}
+
+	loader := opts.Loader
+	if loader == "" {
+		loader = detectLoader(config.JarFile)
+	}
+	switch loader {
+	case LoaderForge, LoaderNeoForge:
+		if config.JarFile.Path == "" {
+			return nil, fmt.Errorf("%w: server has no installer jar attached", ErrInvalidInstall)
+		}
+	case LoaderFabric:
+		if opts.MinecraftVersion == "" {
+			return nil, fmt.Errorf("%w: minecraft_version is required for fabric", ErrInvalidInstall)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported loader %q", ErrInvalidInstall, loader)
+	}
+
+	release, err := sm.lockFiles(id, FileLockInstall, srv)
+	if err != nil {
+		return nil, err
+	}
+	install := &LoaderInstall{
+		ServerID:  id,
+		Loader:    loader,
+		Status:    InstallRunning,
+		LogPath:   filepath.Join(serverModel.Path, installerLog),
+		StartedAt: time.Now(),
+	}
+	sm.changeMutex.Lock()
+	sm.installs[id] = install
+	copied := *install
+	sm.changeMutex.Unlock()
+
+	go func() {
+		defer release()
+		command, err := sm.runLoaderInstall(serverModel, config.JarFile, loader, opts)
+		if err == nil {
+			_, err = sm.UpdateServer(id, userID, ServerUpdate{ExecutableCommand: &command})
+		}
+		sm.finishInstall(install, command, err)
+	}()
+
+	slog.Info("Started loader install", "loader", loader, "server_id", id)
+	return &copied, nil
+}
+
+// GetLoaderInstall returns the most recent loader install of a server
+func (sm *ServerManager) GetLoaderInstall(id uint, userID uint) (*LoaderInstall, error) {
+	if err := sm.Authorize(id, userID, model.PermissionView); err != nil {
+		return nil, err
+	}
+
+	sm.changeMutex.Lock()
+	defer sm.changeMutex.Unlock()
+	install, exists := sm.installs[id]
+	if !exists {
+		return nil, fmt.Errorf("no loader install recorded for server %d", id)
+	}
+	copied := *install
+	return &copied, nil
+}
+
+// runLoaderInstall runs the installer of loader and returns the command
+// launching the installed server. server.jar is removed first: it is linked
+// to the shared jar, which installers writing it would change for every
+// server. A failed install links it again.
+func (sm *ServerManager) runLoaderInstall(serverModel *model.Server, jarFile model.JarFile, loader string, opts InstallOptions) (string, error) {
+	if err := utils.RemoveIn(serverModel.Path, "server.jar"); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove server.jar: %w", err)
+	}
+
+	var command string
+	var err error
+	switch loader {
+	case LoaderForge, LoaderNeoForge:
+		command, err = sm.runForgeInstaller(serverModel.ID, serverModel.Path, jarFile.Path, loader)
+	case LoaderFabric:
+		command, err = sm.runFabricInstaller(serverModel.ID, serverModel.Path, opts.MinecraftVersion, opts.LoaderVersion)
+	}
+	if err != nil && jarFile.Path != "" {
+		if linkErr := sm.linkArtifact(jarFile.Path, filepath.Join(serverModel.Path, "server.jar")); linkErr != nil {
+			slog.Error("Failed to relink server jar after failed install", "server_id", serverModel.ID, "error", linkErr)
+		}
+	}
+	return command, err
+}
+
+// finishInstall records the outcome of a loader install
+func (sm *ServerManager) finishInstall(install *LoaderInstall, command string, err error) {
+	sm.changeMutex.Lock()
+	defer sm.changeMutex.Unlock()
+	now := time.Now()
+	install.FinishedAt = &now
+	if err != nil {
+		install.Status = InstallFailed
+		install.Reason = err.Error()
+		slog.Error("Loader install failed", "loader", install.Loader, "server_id", install.ServerID, "error", err)
+		return
+	}
+	install.Status = InstallSucceeded
+	install.ExecutableCommand = command
+	slog.Info("Installed loader", "loader", install.Loader, "server_id", install.ServerID, "command", command)
+}
+
+// detectLoader guesses the loader from the attached installer jar
+func detectLoader(jarFile model.JarFile) string {
+	name := strings.ToLower(filepath.Base(jarFile.Path) + " " + jarFile.Name)
+	switch {
+	case strings.Contains(name, "fabric"):
+		return LoaderFabric
+	case strings.Contains(name, "neoforge"):
+		return LoaderNeoForge
+	case strings.Contains(name, "forge"):
+		return LoaderForge
+	}
+	return ""
+}
+
+// runForgeInstaller executes a Forge/NeoForge installer with --installServer
+// and returns the command needed to launch the installed server.
+func (sm *ServerManager) runForgeInstaller(serverID uint, serverPath, installerPath, loader string) (string, error) {
+	if err := sm.runInstaller(serverID, serverPath, "java", "-jar", installerPath, "--installServer"); err != nil {
+		return "", err
+	}
+
+	return detectForgeCommand(serverPath, loader)
+}
+
+// runInstaller runs an installer process in the directory of a server, as
+// the server's user when servers are isolated, writing its output to
+// installer.log there
+func (sm *ServerManager) runInstaller(serverID uint, dir, name string, args ...string) error {
+	logFile, err := utils.OpenIn(dir, installerLog, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create installer log: %w", err)
+	}
+	defer logFile.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), installTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	sm.mutex.RLock()
+	preparer, ok := sm.runtime.(server.CommandPreparer)
+	sm.mutex.RUnlock()
+	if ok {
+		if err := preparer.PrepareCommand(cmd, serverID); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Running installer", "dir", dir, "command", name+" "+strings.Join(args, " "))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("installer failed (see %s): %w", logFile.Name(), err)
+	}
+	return nil
+}
+
+// detectForgeCommand inspects the files produced by the installer. Modern
+// installers (1.17+) write run.sh and an args file under libraries/, older
+// ones produce a runnable forge-*.jar in the server root.
+func detectForgeCommand(serverPath, loader string) (string, error) {
+	if command, err := commandFromRunScript(filepath.Join(serverPath, "run.sh")); err == nil {
+		return command, nil
+	}
+
+	libDir := filepath.Join(serverPath, "libraries", "net", "minecraftforge", "forge")
+	if loader == LoaderNeoForge {
+		libDir = filepath.Join(serverPath, "libraries", "net", "neoforged", "neoforge")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(libDir, "*", "unix_args.txt")); len(matches) > 0 {
+		rel, err := filepath.Rel(serverPath, matches[len(matches)-1])
+		if err == nil {
+			args := "@" + filepath.ToSlash(rel)
+			if _, err := os.Stat(filepath.Join(serverPath, "user_jvm_args.txt")); err == nil {
+				args = "@user_jvm_args.txt " + args
+			}
+			return "java " + args + " nogui", nil
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(serverPath, "forge-*.jar"))
+	for _, match := range matches {
+		if strings.HasSuffix(match, "-installer.jar") {
+			continue
+		}
+		return "java -jar " + filepath.Base(match) + " nogui", nil
+	}
+
+	return "", fmt.Errorf("could not detect launch arguments produced by the %s installer", loader)
+}
+
+// commandFromRunScript extracts the java invocation from an installer run.sh
+func commandFromRunScript(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "java ") {
+			continue
+		}
+		line = strings.ReplaceAll(line, `"$@"`, "nogui")
+		line = strings.ReplaceAll(line, "$@", "nogui")
+		return strings.Join(strings.Fields(line), " "), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no java invocation found in %s", path)
+}
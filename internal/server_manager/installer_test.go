@@ -0,0 +1,216 @@
+package server_manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestCommandFromRunScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{
+			name:   "quoted arguments",
+			script: "#!/usr/bin/env sh\n# Forge requires a configured set of both JVM and program arguments.\njava @user_jvm_args.txt @libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt \"$@\"\n",
+			want:   "java @user_jvm_args.txt @libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt nogui",
+		},
+		{
+			name:   "bare arguments and extra spaces",
+			script: "  java  -Xmx4G   -jar forge.jar $@  \n",
+			want:   "java -Xmx4G -jar forge.jar nogui",
+		},
+		{
+			name:   "first invocation wins",
+			script: "echo starting\njava -jar first.jar \"$@\"\njava -jar second.jar \"$@\"\n",
+			want:   "java -jar first.jar nogui",
+		},
+		{
+			name:   "no java",
+			script: "#!/bin/sh\necho nothing to run\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run.sh")
+			if err := os.WriteFile(path, []byte(tt.script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			got, err := commandFromRunScript(path)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectForgeCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		loader string
+		files  map[string]string
+		want   string
+	}{
+		{
+			name:   "run script",
+			loader: LoaderForge,
+			files: map[string]string{
+				"run.sh": "java @user_jvm_args.txt @libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt \"$@\"\n",
+			},
+			want: "java @user_jvm_args.txt @libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt nogui",
+		},
+		{
+			name:   "forge args file",
+			loader: LoaderForge,
+			files: map[string]string{
+				"libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt": "",
+			},
+			want: "java @libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt nogui",
+		},
+		{
+			name:   "neoforge args file with user arguments",
+			loader: LoaderNeoForge,
+			files: map[string]string{
+				"libraries/net/neoforged/neoforge/21.1.65/unix_args.txt": "",
+				"user_jvm_args.txt": "",
+			},
+			want: "java @user_jvm_args.txt @libraries/net/neoforged/neoforge/21.1.65/unix_args.txt nogui",
+		},
+		{
+			name:   "neoforge ignores forge args",
+			loader: LoaderNeoForge,
+			files: map[string]string{
+				"libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt": "",
+			},
+		},
+		{
+			name:   "legacy jar",
+			loader: LoaderForge,
+			files: map[string]string{
+				"forge-1.12.2-14.23.5.2860-installer.jar": "",
+				"forge-1.12.2-14.23.5.2860.jar":           "",
+			},
+			want: "java -jar forge-1.12.2-14.23.5.2860.jar nogui",
+		},
+		{
+			name:   "only the installer",
+			loader: LoaderForge,
+			files: map[string]string{
+				"forge-1.12.2-14.23.5.2860-installer.jar": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := detectForgeCommand(dir, tt.loader)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallLoader(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	grantee := createTestUser(t, sm, "bob", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "modded")
+	if _, err := sm.GrantServerAccess(id, owner.ID, grantee.Username, []string{model.PermissionFiles}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Installing changes the launch command, so files alone is not enough
+	if _, err := sm.InstallLoader(id, grantee.ID, InstallOptions{Loader: LoaderForge}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("InstallLoader with files: got %v, want ErrPermissionDenied", err)
+	}
+	if _, err := sm.InstallLoader(id, owner.ID, InstallOptions{Loader: "quilt"}); !errors.Is(err, ErrInvalidInstall) {
+		t.Errorf("unsupported loader: got %v, want ErrInvalidInstall", err)
+	}
+	if _, err := sm.InstallLoader(id, owner.ID, InstallOptions{Loader: LoaderFabric}); !errors.Is(err, ErrInvalidInstall) {
+		t.Errorf("fabric without a version: got %v, want ErrInvalidInstall", err)
+	}
+
+	// A stand-in java writes server.jar as the Fabric installer does, and a
+	// run script as Forge does
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho vanilla > server.jar\necho 'java @libraries/net/minecraftforge/forge/1.21-51.0.0/unix_args.txt \"$@\"' > run.sh\n"
+	if err := os.WriteFile(filepath.Join(bin, "java"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	install, err := sm.InstallLoader(id, owner.ID, InstallOptions{Loader: LoaderForge})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if install.Status != InstallRunning {
+		t.Errorf("status %q, want %q", install.Status, InstallRunning)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for install.Status == InstallRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if install, err = sm.GetLoaderInstall(id, owner.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if install.Status != InstallSucceeded {
+		t.Fatalf("status %q (%s), want %q", install.Status, install.Reason, InstallSucceeded)
+	}
+
+	const want = "java @libraries/net/minecraftforge/forge/1.21-51.0.0/unix_args.txt nogui"
+	if install.ExecutableCommand != want {
+		t.Errorf("command %q, want %q", install.ExecutableCommand, want)
+	}
+	config, err := sm.GetServerConfig(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ExecutableCommand != want {
+		t.Errorf("config command %q, want %q", config.ExecutableCommand, want)
+	}
+	// The installer wrote its own server.jar, not through the shared one
+	shared, err := os.ReadFile(config.JarFile.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shared) != 0 {
+		t.Errorf("shared jar was changed to %q", shared)
+	}
+	if err := sm.exclusiveFileLock(id); err != nil {
+		t.Errorf("files still locked after the install: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// firstStartTimeout is how long the first start after a jar swap or mod
+	// pack upgrade may take to log "Done"
+	firstStartTimeout = 5 * time.Minute
+	// stopTimeout is how long a server gets to shut down before it is killed
+	stopTimeout = 60 * time.Second
+)
+
+// SwapJar replaces the jar attached to a stopped server. Its directory is
+// snapshotted and server.jar relinked. The swap stays
+// pending until the next start reaches "Done"; otherwise it is rolled back.
+func (sm *ServerManager) SwapJar(id uint, userID uint, jarFileID uint) (*model.JarSwap, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+
+	if pending := sm.pendingJarSwap(id); pending != nil {
+		return nil, fmt.Errorf("a jar swap is already pending for server %d", id)
+	}
+
+	jarFile, err := sm.GetJarFileByID(jarFileID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jar_file_id: %w", err)
+	}
+
+	config, err := sm.GetServerConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	if config.JarFileID == jarFileID {
+		return nil, fmt.Errorf("server already uses jar file %d", jarFileID)
+	}
+
+	release, err := sm.lockFiles(id, FileLockJarSwap, srv)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	snapshotPath := fmt.Sprintf("%s.jar-swap-%d", strings.TrimRight(serverModel.Path, string(filepath.Separator)), time.Now().Unix())
+	slog.Info("Snapshotting server before jar swap", "server_id", id, "path", serverModel.Path, "snapshot", snapshotPath)
+	if err := utils.CopyDir(serverModel.Path, snapshotPath); err != nil {
+		os.RemoveAll(snapshotPath)
+		return nil, fmt.Errorf("failed to snapshot server directory: %w", err)
+	}
+
+	if err := sm.linkArtifact(jarFile.Path, filepath.Join(serverModel.Path, "server.jar")); err != nil {
+		os.RemoveAll(snapshotPath)
+		return nil, fmt.Errorf("failed to swap jar: %w", err)
+	}
+
+	swap := &model.JarSwap{
+		ServerID:          id,
+		PreviousJarFileID: config.JarFileID,
+		JarFileID:         jarFileID,
+		SnapshotPath:      snapshotPath,
+		Status:            model.JarSwapPending,
+	}
+	err = sm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(config).Update("jar_file_id", jarFileID).Error; err != nil {
+			return err
+		}
+		return tx.Create(swap).Error
+	})
+	if err != nil {
+		sm.restoreSnapshot(serverModel.Path, snapshotPath)
+		return nil, fmt.Errorf("failed to update server config: %w", err)
+	}
+	srv.InvalidateConfig()
+
+	slog.Info("Swapped jar, pending verification on next start", "server_id", id, "previous_jar_file_id", config.JarFileID, "jar_file_id", jarFileID)
+	return swap, nil
+}
+
+// GetJarSwap returns the most recent jar swap for a server owned by userID
+func (sm *ServerManager) GetJarSwap(id uint, userID uint) (*model.JarSwap, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionFiles); err != nil {
+		return nil, err
+	}
+
+	var swap model.JarSwap
+	err := sm.db.Where("server_id = ?", id).Order("id DESC").First(&swap).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("no jar swap recorded for server %d", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jar swap: %w", err)
+	}
+	return &swap, nil
+}
+
+// pendingJarSwap returns the unverified jar swap for a server, if any
+func (sm *ServerManager) pendingJarSwap(id uint) *model.JarSwap {
+	var swap model.JarSwap
+	err := sm.db.Where("server_id = ? AND status = ?", id, model.JarSwapPending).Order("id DESC").First(&swap).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Error("Failed to fetch pending jar swap", "server_id", id, "error", err)
+		}
+		return nil
+	}
+	return &swap
+}
+
+// removeStaleJarSwapSnapshots deletes the jar swap snapshots next to the
+// directories of servers that no pending swap refers to, left behind when
+// the manager stopped while swapping. Pending swaps keep theirs; their
+// next start is watched as usual.
+func (sm *ServerManager) removeStaleJarSwapSnapshots(servers []model.Server) {
+	var pending []string
+	if err := sm.db.Model(&model.JarSwap{}).Where("status = ?", model.JarSwapPending).Pluck("snapshot_path", &pending).Error; err != nil {
+		slog.Error("Failed to fetch pending jar swaps", "error", err)
+		return
+	}
+	for _, serverModel := range servers {
+		path := strings.TrimRight(serverModel.Path, string(filepath.Separator))
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			continue
+		}
+		prefix := filepath.Base(path) + ".jar-swap-"
+		for _, entry := range entries {
+			snapshot := filepath.Join(filepath.Dir(path), entry.Name())
+			if !entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) || slices.Contains(pending, snapshot) {
+				continue
+			}
+			slog.Info("Removing stale jar swap snapshot", "server_id", serverModel.ID, "snapshot", snapshot)
+			if err := os.RemoveAll(snapshot); err != nil {
+				slog.Error("Failed to remove stale jar swap snapshot", "snapshot", snapshot, "error", err)
+			}
+		}
+	}
+}
+
+// watchJarSwap follows the first start after a jar swap, confirming the swap
+// once the server logs "Done" and rolling it back if it exits or times out.
+func (sm *ServerManager) watchJarSwap(id uint, srv *server.Server, swap *model.JarSwap) {
+	sm.watchFirstStart(id, srv, func() { sm.confirmJarSwap(swap) }, func(reason string) {
+		sm.rollbackJarSwap(srv, swap, reason)
+	})
+}
+
+// watchFirstStart follows a start that proves a change works, calling
+// confirm once the server logs "Done" and rollback if it exits or times out
+func (sm *ServerManager) watchFirstStart(id uint, srv *server.Server, confirm func(), rollback func(reason string)) {
+	output, err := sm.SubscribeOutput(id)
+	if err != nil {
+		slog.Error("Failed to watch server start", "server_id", id, "error", err)
+		return
+	}
+	defer sm.UnsubscribeOutput(id, output)
+
+	timeout := time.After(firstStartTimeout)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line := <-output:
+			if strings.Contains(line, "Done (") {
+				confirm()
+				return
+			}
+		case <-ticker.C:
+			if !srv.IsRunning() {
+				rollback("server exited before finishing startup")
+				return
+			}
+		case <-timeout:
+			rollback(fmt.Sprintf("server did not finish starting within %s", firstStartTimeout))
+			return
+		}
+	}
+}
+
+// confirmJarSwap marks a swap as successful and discards its snapshot
+func (sm *ServerManager) confirmJarSwap(swap *model.JarSwap) {
+	sm.setJarSwapStatus(swap, model.JarSwapConfirmed, "")
+	if err := os.RemoveAll(swap.SnapshotPath); err != nil {
+		slog.Error("Failed to remove jar swap snapshot", "snapshot", swap.SnapshotPath, "error", err)
+	}
+	slog.Info("Jar swap confirmed", "server_id", swap.ServerID)
+}
+
+// rollbackJarSwap stops the server, restores the snapshot and reattaches the previous jar
+func (sm *ServerManager) rollbackJarSwap(srv *server.Server, swap *model.JarSwap, reason string) {
+	slog.Warn("Rolling back jar swap", "server_id", swap.ServerID, "reason", reason)
+
+	if err := srv.StopAndWait(stopTimeout); err != nil {
+		slog.Error("Failed to stop server for rollback", "server_id", swap.ServerID, "error", err)
+	}
+
+	if err := sm.restoreSnapshot(srv.GetPath(), swap.SnapshotPath); err != nil {
+		slog.Error("Failed to restore snapshot", "server_id", swap.ServerID, "error", err)
+	}
+
+	if err := sm.db.Model(&model.ServerConfig{}).
+		Where("server_id = ?", swap.ServerID).
+		Update("jar_file_id", swap.PreviousJarFileID).Error; err != nil {
+		slog.Error("Failed to restore jar file", "server_id", swap.ServerID, "error", err)
+	}
+	srv.InvalidateConfig()
+
+	sm.setJarSwapStatus(swap, model.JarSwapRolledBack, reason)
+}
+
+// setJarSwapStatus records the outcome of a swap
+func (sm *ServerManager) setJarSwapStatus(swap *model.JarSwap, status, reason string) {
+	swap.Status, swap.Reason = status, reason
+	if err := sm.db.Model(swap).Updates(map[string]interface{}{"status": status, "reason": reason}).Error; err != nil {
+		slog.Error("Failed to record jar swap outcome", "server_id", swap.ServerID, "status", status, "error", err)
+	}
+}
+
+// restoreSnapshot replaces a server directory with a previously taken snapshot
+func (sm *ServerManager) restoreSnapshot(serverPath, snapshotPath string) error {
+	if err := os.RemoveAll(serverPath); err != nil {
+		return fmt.Errorf("failed to remove server directory: %w", err)
+	}
+	if err := os.Rename(snapshotPath, serverPath); err != nil {
+		return fmt.Errorf("failed to move snapshot into place: %w", err)
+	}
+	return nil
+}
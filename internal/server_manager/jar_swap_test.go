@@ -0,0 +1,61 @@
+package server_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestJarSwapSurvivesRestart(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "survival")
+	serverModel, _, err := sm.ownedServer(id, owner.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(sm.commonDir, "paper.jar")
+	if err := os.WriteFile(path, []byte("paper"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jar := &model.JarFile{Name: "paper.jar", Version: "1.21", Path: path}
+	if err := sm.db.Create(jar).Error; err != nil {
+		t.Fatal(err)
+	}
+	swap, err := sm.SwapJar(id, owner.ID, jar.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A snapshot of a swap the manager never recorded
+	stale := serverModel.Path + ".jar-swap-1"
+	if err := os.MkdirAll(stale, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewServerManager(sm.db, sm.commonDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := restarted.pendingJarSwap(id)
+	if pending == nil || pending.ID != swap.ID || pending.JarFileID != jar.ID {
+		t.Fatalf("pending swap after restart = %+v, want %+v", pending, swap)
+	}
+	if _, err := os.Stat(swap.SnapshotPath); err != nil {
+		t.Errorf("snapshot of the pending swap: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale snapshot was kept: %v", err)
+	}
+
+	restarted.confirmJarSwap(pending)
+	if swap, err := restarted.GetJarSwap(id, owner.ID); err != nil || swap.Status != model.JarSwapConfirmed {
+		t.Errorf("GetJarSwap = %+v, %v; want confirmed", swap, err)
+	}
+	if _, err := os.Stat(pending.SnapshotPath); !os.IsNotExist(err) {
+		t.Errorf("snapshot of the confirmed swap was kept: %v", err)
+	}
+}
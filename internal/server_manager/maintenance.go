@@ -0,0 +1,491 @@
+package server_manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/placeholder"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// maintenanceSchedulerInterval is how often windows are applied and
+	// lifted, and placeholders reopened after an admin stopped the server
+	maintenanceSchedulerInterval = 30 * time.Second
+	// defaultMaintenanceMessage is shown when a window has no message
+	defaultMaintenanceMessage = "Down for maintenance, back soon"
+)
+
+// ErrMaintenance is returned for power actions on a server during one of
+// its maintenance windows
+var ErrMaintenance = errors.New("server is in maintenance")
+
+type maintenanceCheckKey struct{}
+
+// WithMaintenanceCheck marks ctx as a power action maintenance windows
+// apply to. Actions of admins and of the manager itself are not marked.
+func WithMaintenanceCheck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, maintenanceCheckKey{}, true)
+}
+
+// checkMaintenance fails with ErrMaintenance when ctx is marked and the
+// server is in a maintenance window
+func (sm *ServerManager) checkMaintenance(ctx context.Context, id uint) error {
+	if checked, _ := ctx.Value(maintenanceCheckKey{}).(bool); !checked {
+		return nil
+	}
+	window, err := sm.activeMaintenance(id)
+	if err != nil {
+		return err
+	}
+	if window != nil {
+		return fmt.Errorf("%w until %s", ErrMaintenance, window.EndsAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// activeMaintenance returns the window a server is in, or nil. A window
+// applies from its start until it is lifted, which happens at its end.
+func (sm *ServerManager) activeMaintenance(id uint) (*model.MaintenanceWindow, error) {
+	now := time.Now()
+	var windows []model.MaintenanceWindow
+	err := sm.db.Where("server_id = ? AND ended_at IS NULL AND (begun_at IS NOT NULL OR (starts_at <= ? AND ends_at > ?))", id, now, now).
+		Order("starts_at").Limit(1).Find(&windows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch maintenance windows: %w", err)
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	windows[0].Active = true
+	return &windows[0], nil
+}
+
+// MaintenanceOptions holds the user-editable fields of a maintenance window
+type MaintenanceOptions struct {
+	StartsAt time.Time
+	EndsAt   time.Time
+	Mode     string
+	Message  string
+}
+
+// validate checks the options and returns them normalized
+func (opts MaintenanceOptions) validate() (MaintenanceOptions, error) {
+	if opts.Mode == "" {
+		opts.Mode = model.MaintenanceStop
+	}
+	if opts.Mode != model.MaintenanceStop && opts.Mode != model.MaintenanceWhitelist {
+		return opts, fmt.Errorf("unknown mode %q", opts.Mode)
+	}
+	opts.Message = strings.TrimSpace(opts.Message)
+	if opts.Message == "" {
+		opts.Message = defaultMaintenanceMessage
+	}
+	// The message is also the reason of a kick command
+	if strings.ContainsAny(opts.Message, "\r\n") {
+		return opts, fmt.Errorf("message must be a single line")
+	}
+	if !opts.EndsAt.After(opts.StartsAt) {
+		return opts, fmt.Errorf("the window must end after it starts")
+	}
+	if !opts.EndsAt.After(time.Now()) {
+		return opts, fmt.Errorf("the window must end in the future")
+	}
+	return opts, nil
+}
+
+// CreateMaintenanceWindow schedules a maintenance window on a server.
+// Windows of a server may not overlap.
+func (sm *ServerManager) CreateMaintenanceWindow(id uint, userID uint, opts MaintenanceOptions) (*model.MaintenanceWindow, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionManage); err != nil {
+		return nil, err
+	}
+	opts, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	if err := sm.checkMaintenanceOverlap(id, 0, opts); err != nil {
+		return nil, err
+	}
+	if err := sm.checkMaintenanceMode(id, opts.Mode); err != nil {
+		return nil, err
+	}
+	window := &model.MaintenanceWindow{ServerID: id}
+	applyMaintenanceOptions(window, opts)
+	if err := sm.db.Create(window).Error; err != nil {
+		return nil, fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	return window, nil
+}
+
+// UpdateMaintenanceWindow reschedules a maintenance window. Once a window
+// has begun only its end and message can change.
+func (sm *ServerManager) UpdateMaintenanceWindow(id, windowID uint, userID uint, opts MaintenanceOptions) (*model.MaintenanceWindow, error) {
+	window, err := sm.serverMaintenanceWindow(id, windowID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if window.EndedAt != nil {
+		return nil, fmt.Errorf("the window has already ended")
+	}
+	if window.BegunAt != nil {
+		if opts.Mode != "" && opts.Mode != window.Mode {
+			return nil, fmt.Errorf("the mode of a window that has begun cannot change")
+		}
+		opts.Mode = window.Mode
+		opts.StartsAt = window.StartsAt
+	}
+	if opts, err = opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := sm.checkMaintenanceOverlap(id, window.ID, opts); err != nil {
+		return nil, err
+	}
+	if err := sm.checkMaintenanceMode(id, opts.Mode); err != nil {
+		return nil, err
+	}
+	applyMaintenanceOptions(window, opts)
+	if err := sm.db.Save(window).Error; err != nil {
+		return nil, fmt.Errorf("failed to update maintenance window: %w", err)
+	}
+	return window, nil
+}
+
+func applyMaintenanceOptions(window *model.MaintenanceWindow, opts MaintenanceOptions) {
+	window.StartsAt = opts.StartsAt
+	window.EndsAt = opts.EndsAt
+	window.Mode = opts.Mode
+	window.Message = opts.Message
+}
+
+// checkMaintenanceOverlap fails if opts overlaps a window of the server
+// other than windowID that has not ended
+func (sm *ServerManager) checkMaintenanceOverlap(id, windowID uint, opts MaintenanceOptions) error {
+	var overlapping int64
+	err := sm.db.Model(&model.MaintenanceWindow{}).
+		Where("server_id = ? AND id <> ? AND ended_at IS NULL AND starts_at < ? AND ends_at > ?", id, windowID, opts.EndsAt, opts.StartsAt).
+		Count(&overlapping).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch maintenance windows: %w", err)
+	}
+	if overlapping > 0 {
+		return fmt.Errorf("the window overlaps another maintenance window")
+	}
+	return nil
+}
+
+// checkMaintenanceMode rejects whitelist windows on servers on nodes, whose
+// server.properties the manager cannot edit
+func (sm *ServerManager) checkMaintenanceMode(id uint, mode string) error {
+	if mode != model.MaintenanceWhitelist {
+		return nil
+	}
+	var serverModel model.Server
+	if err := sm.db.First(&serverModel, id).Error; err != nil {
+		return fmt.Errorf("server not found: %w", err)
+	}
+	return checkLocal(&serverModel)
+}
+
+// ListMaintenanceWindows returns the maintenance windows of a server,
+// soonest first
+func (sm *ServerManager) ListMaintenanceWindows(id uint, userID uint) ([]model.MaintenanceWindow, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionView); err != nil {
+		return nil, err
+	}
+	windows := []model.MaintenanceWindow{}
+	if err := sm.db.Where("server_id = ?", id).Order("starts_at").Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch maintenance windows: %w", err)
+	}
+	now := time.Now()
+	for i := range windows {
+		windows[i].Active = windows[i].EndedAt == nil &&
+			(windows[i].BegunAt != nil || !windows[i].StartsAt.After(now) && windows[i].EndsAt.After(now))
+	}
+	return windows, nil
+}
+
+// DeleteMaintenanceWindow removes a maintenance window, lifting it first
+// if it has begun
+func (sm *ServerManager) DeleteMaintenanceWindow(id, windowID uint, userID uint) error {
+	window, err := sm.serverMaintenanceWindow(id, windowID, userID)
+	if err != nil {
+		return err
+	}
+	if window.BegunAt != nil && window.EndedAt == nil {
+		sm.endMaintenance(window)
+	}
+	return sm.db.Unscoped().Delete(window).Error
+}
+
+func (sm *ServerManager) serverMaintenanceWindow(id, windowID uint, userID uint) (*model.MaintenanceWindow, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionManage); err != nil {
+		return nil, err
+	}
+	var window model.MaintenanceWindow
+	if err := sm.db.Where("id = ? AND server_id = ?", windowID, id).First(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// StartMaintenanceScheduler applies and lifts maintenance windows in the
+// background until stop is closed. Windows that began before a restart of
+// the manager get their placeholders back right away.
+func (sm *ServerManager) StartMaintenanceScheduler(stop <-chan struct{}) {
+	go func() {
+		sm.applyMaintenance(time.Now())
+		ticker := time.NewTicker(maintenanceSchedulerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				sm.applyMaintenance(now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// applyMaintenance begins the windows that are due and ends the ones that
+// are over. Windows missed entirely while the manager was down are marked
+// ended without being applied.
+func (sm *ServerManager) applyMaintenance(now time.Time) {
+	var windows []model.MaintenanceWindow
+	if err := sm.db.Where("ended_at IS NULL AND starts_at <= ?", now).Order("starts_at").Find(&windows).Error; err != nil {
+		slog.Error("Failed to fetch maintenance windows", "error", err)
+		return
+	}
+	for i := range windows {
+		window := &windows[i]
+		switch {
+		case window.EndsAt.After(now) && window.BegunAt == nil:
+			sm.beginMaintenance(window)
+		case window.EndsAt.After(now):
+			sm.keepMaintenance(window)
+		case window.BegunAt != nil:
+			sm.endMaintenance(window)
+		default:
+			sm.db.Model(window).Update("ended_at", now)
+		}
+	}
+}
+
+// maintenanceServer returns the server of a window, or nil once the server
+// has been deleted or archived
+func (sm *ServerManager) maintenanceServer(window *model.MaintenanceWindow) *model.Server {
+	var serverModel model.Server
+	if err := sm.db.First(&serverModel, window.ServerID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Error("Failed to fetch server", "server_id", window.ServerID, "error", err)
+		}
+		return nil
+	}
+	if serverModel.ArchivedAt != nil {
+		return nil
+	}
+	return &serverModel
+}
+
+// beginMaintenance applies a window: in stop mode the players are kicked
+// and the server stopped, in whitelist mode the whitelist is enforced
+func (sm *ServerManager) beginMaintenance(window *model.MaintenanceWindow) {
+	serverModel := sm.maintenanceServer(window)
+	if serverModel == nil {
+		sm.db.Model(window).Update("ended_at", time.Now())
+		return
+	}
+	logger := slog.With("server_id", window.ServerID, "window_id", window.ID, "mode", window.Mode)
+	sm.mutex.RLock()
+	srv, ok := sm.servers[window.ServerID]
+	sm.mutex.RUnlock()
+	running := ok && srv.IsRunning()
+
+	now := time.Now()
+	window.BegunAt = &now
+	window.WasRunning = running
+	if window.Mode == model.MaintenanceWhitelist {
+		properties, _ := utils.ReadProperties(filepath.Join(serverModel.Path, "server.properties"))
+		window.PreviousWhitelist = properties["white-list"]
+		window.PreviousEnforceWhitelist = properties["enforce-whitelist"]
+	}
+	if err := sm.db.Save(window).Error; err != nil {
+		logger.Error("Failed to record the start of a maintenance window", "error", err)
+		return
+	}
+	logger.Info("Maintenance window begun")
+
+	if window.Mode == model.MaintenanceWhitelist {
+		if err := sm.enforceWhitelist(serverModel, window.Message); err != nil {
+			logger.Error("Failed to enforce the whitelist for maintenance", "error", err)
+		}
+		return
+	}
+
+	sm.cancelQueuedStart(window.ServerID)
+	if !running {
+		sm.openMaintenancePlaceholder(serverModel, window)
+		return
+	}
+	srv.SendCommand("kick @a " + window.Message)
+	go func() {
+		if err := srv.StopAndWait(stopTimeout); err != nil {
+			logger.Error("Failed to stop server for maintenance", "error", err)
+			return
+		}
+		// The window may have been lifted while the server stopped
+		if active, err := sm.activeMaintenance(window.ServerID); err == nil && active != nil && active.ID == window.ID {
+			sm.openMaintenancePlaceholder(serverModel, window)
+		}
+	}()
+}
+
+// keepMaintenance reopens the placeholder of a window in stop mode once an
+// admin who started the server during the window has stopped it again
+func (sm *ServerManager) keepMaintenance(window *model.MaintenanceWindow) {
+	if window.Mode != model.MaintenanceStop || sm.hasPlaceholder(window.ServerID) {
+		return
+	}
+	sm.mutex.RLock()
+	srv, ok := sm.servers[window.ServerID]
+	sm.mutex.RUnlock()
+	if ok && srv.IsRunning() {
+		return
+	}
+	if serverModel := sm.maintenanceServer(window); serverModel != nil {
+		sm.openMaintenancePlaceholder(serverModel, window)
+	}
+}
+
+func (sm *ServerManager) openMaintenancePlaceholder(serverModel *model.Server, window *model.MaintenanceWindow) {
+	err := sm.openPlaceholder(serverModel, placeholder.Options{
+		MOTD:    window.Message,
+		Version: "Maintenance",
+		Kick:    window.Message,
+	})
+	if err != nil {
+		slog.Warn("Failed to open maintenance placeholder", "server_id", serverModel.ID, "error", err)
+	}
+}
+
+// endMaintenance lifts a window, restoring the whitelist settings or
+// starting the server again if it was running when the window began
+func (sm *ServerManager) endMaintenance(window *model.MaintenanceWindow) {
+	logger := slog.With("server_id", window.ServerID, "window_id", window.ID, "mode", window.Mode)
+	now := time.Now()
+	window.EndedAt = &now
+	if err := sm.db.Model(window).Update("ended_at", now).Error; err != nil {
+		logger.Error("Failed to record the end of a maintenance window", "error", err)
+		return
+	}
+	logger.Info("Maintenance window ended")
+
+	serverModel := sm.maintenanceServer(window)
+	if window.Mode == model.MaintenanceStop {
+		sm.closePlaceholder(window.ServerID)
+		if serverModel != nil && window.WasRunning {
+			if err := sm.StartServer(context.Background(), serverModel.ID, serverModel.UserID); err != nil {
+				logger.Error("Failed to start server after maintenance", "error", err)
+			}
+		} else if serverModel != nil {
+			sm.refreshPlaceholder(serverModel.ID)
+		}
+		return
+	}
+	if serverModel != nil {
+		if err := sm.restoreWhitelist(serverModel, window); err != nil {
+			logger.Error("Failed to restore the whitelist after maintenance", "error", err)
+		}
+	}
+}
+
+// enforceWhitelist turns the whitelist on and kicks the players online
+// who are neither whitelisted nor operators, who may join regardless
+func (sm *ServerManager) enforceWhitelist(serverModel *model.Server, message string) error {
+	if err := checkLocal(serverModel); err != nil {
+		return err
+	}
+	path := filepath.Join(serverModel.Path, "server.properties")
+	if err := utils.SetProperty(path, "white-list", "true"); err != nil {
+		return err
+	}
+	if err := utils.SetProperty(path, "enforce-whitelist", "true"); err != nil {
+		return err
+	}
+
+	sm.mutex.RLock()
+	srv, ok := sm.servers[serverModel.ID]
+	sm.mutex.RUnlock()
+	if !ok || !srv.IsRunning() {
+		return nil
+	}
+	if err := srv.SendCommand("whitelist on"); err != nil {
+		return err
+	}
+	allowed := make(map[string]bool)
+	for _, file := range []string{"whitelist.json", "ops.json"} {
+		for _, name := range playerListNames(filepath.Join(serverModel.Path, file)) {
+			allowed[strings.ToLower(name)] = true
+		}
+	}
+	for _, player := range srv.OnlinePlayers() {
+		if !allowed[strings.ToLower(player)] {
+			srv.SendCommand("kick " + player + " " + message)
+		}
+	}
+	return nil
+}
+
+// restoreWhitelist puts back the whitelist settings a window replaced
+func (sm *ServerManager) restoreWhitelist(serverModel *model.Server, window *model.MaintenanceWindow) error {
+	if err := checkLocal(serverModel); err != nil {
+		return err
+	}
+	path := filepath.Join(serverModel.Path, "server.properties")
+	previous := map[string]string{
+		"white-list":        window.PreviousWhitelist,
+		"enforce-whitelist": window.PreviousEnforceWhitelist,
+	}
+	for key, value := range previous {
+		if value == "" {
+			value = "false"
+		}
+		if err := utils.SetProperty(path, key, value); err != nil {
+			return err
+		}
+	}
+
+	sm.mutex.RLock()
+	srv, ok := sm.servers[serverModel.ID]
+	sm.mutex.RUnlock()
+	if ok && srv.IsRunning() && !strings.EqualFold(window.PreviousWhitelist, "true") {
+		return srv.SendCommand("whitelist off")
+	}
+	return nil
+}
+
+// playerListNames returns the names in a whitelist.json or ops.json
+func playerListNames(path string) []string {
+	data, err := utils.ReadFileIn(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return nil
+	}
+	var entries []struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(data, &entries)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	return names
+}
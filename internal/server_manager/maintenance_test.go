@@ -0,0 +1,40 @@
+package server_manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestMaintenanceOptionsValidate(t *testing.T) {
+	now := time.Now()
+	opts, err := MaintenanceOptions{StartsAt: now, EndsAt: now.Add(time.Hour)}.validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Mode != model.MaintenanceStop || opts.Message != defaultMaintenanceMessage {
+		t.Errorf("got %+v, want the stop mode and default message", opts)
+	}
+
+	invalid := []MaintenanceOptions{
+		{StartsAt: now, EndsAt: now.Add(time.Hour), Mode: "pause"},
+		{StartsAt: now, EndsAt: now},
+		{StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+		{StartsAt: now, EndsAt: now.Add(time.Hour), Message: "Back\nsoon"},
+	}
+	for _, opts := range invalid {
+		if _, err := opts.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestCheckMaintenanceUnmarked(t *testing.T) {
+	// Without the mark the database is not consulted
+	sm := &ServerManager{}
+	if err := sm.checkMaintenance(context.Background(), 1); err != nil {
+		t.Errorf("checkMaintenance = %v for an unmarked context", err)
+	}
+}
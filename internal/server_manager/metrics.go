@@ -0,0 +1,195 @@
+package server_manager
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"gorm.io/gorm"
+)
+
+const (
+	// metricsInterval is how often running servers are sampled
+	metricsInterval = time.Minute
+	// metricsRawRetention is how long samples are kept at full resolution
+	// before they are averaged into hourly samples
+	metricsRawRetention = 24 * time.Hour
+	// metricsRetention is how long hourly samples are kept
+	metricsRetention = 30 * 24 * time.Hour
+	// MaxMetricsRange is the longest range MetricSeries serves
+	MaxMetricsRange = metricsRetention
+)
+
+// StartMetricsSampler records a metric sample of every running server and
+// evaluates the alert rules each minute, and downsamples and expires old
+// samples each hour, until stop is closed
+func (sm *ServerManager) StartMetricsSampler(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(metricsInterval)
+		defer ticker.Stop()
+		lastCompaction := time.Time{}
+		for {
+			select {
+			case now := <-ticker.C:
+				sm.evaluateAlerts(now, sm.sampleMetrics(now))
+				if now.Sub(lastCompaction) >= time.Hour {
+					if err := sm.compactMetrics(now); err != nil {
+						slog.Error("Failed to compact metrics", "error", err)
+					}
+					lastCompaction = now
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// sampleMetrics stores one sample for every running server and returns
+// them by server ID
+func (sm *ServerManager) sampleMetrics(now time.Time) map[uint]model.MetricSample {
+	sm.mutex.RLock()
+	running := make([]*server.Server, 0, len(sm.servers))
+	for _, srv := range sm.servers {
+		if srv.IsRunning() {
+			running = append(running, srv)
+		}
+	}
+	sm.mutex.RUnlock()
+
+	samples := make(map[uint]model.MetricSample, len(running))
+	for _, srv := range running {
+		stats, err := srv.Stats()
+		if err != nil {
+			slog.Warn("Failed to sample server", "server_id", srv.GetServerId(), "error", err)
+			continue
+		}
+		sample := model.MetricSample{
+			ServerID:    uint(srv.GetServerId()),
+			Time:        now,
+			Resolution:  int(metricsInterval.Seconds()),
+			CPUPercent:  stats.CPUPercent,
+			MemoryBytes: stats.RSSBytes,
+			Players:     float64(srv.PlayerCount()),
+		}
+		if tps, at := srv.LastTPS(); !at.IsZero() && now.Sub(at) <= metricsInterval {
+			sample.TPS = &tps
+		}
+		if err := sm.db.Create(&sample).Error; err != nil {
+			slog.Error("Failed to record metrics", "server_id", sample.ServerID, "error", err)
+		}
+		samples[sample.ServerID] = sample
+	}
+	return samples
+}
+
+// compactMetrics averages full resolution samples older than the raw
+// retention into hourly samples and removes samples past the retention
+func (sm *ServerManager) compactMetrics(now time.Time) error {
+	cutoff := now.Add(-metricsRawRetention).Truncate(time.Hour)
+	return sm.db.Transaction(func(tx *gorm.DB) error {
+		var raw []model.MetricSample
+		err := tx.Where("resolution < ? AND time < ?", int(time.Hour.Seconds()), cutoff).
+			Order("server_id, time").Find(&raw).Error
+		if err != nil {
+			return fmt.Errorf("failed to fetch samples: %w", err)
+		}
+		if len(raw) > 0 {
+			if err := tx.Create(downsample(raw, time.Hour)).Error; err != nil {
+				return fmt.Errorf("failed to store hourly samples: %w", err)
+			}
+			ids := make([]uint, len(raw))
+			for i := range raw {
+				ids[i] = raw[i].ID
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&model.MetricSample{}).Error; err != nil {
+				return fmt.Errorf("failed to remove downsampled samples: %w", err)
+			}
+		}
+		if err := tx.Where("time < ?", now.Add(-metricsRetention)).Delete(&model.MetricSample{}).Error; err != nil {
+			return fmt.Errorf("failed to expire samples: %w", err)
+		}
+		return nil
+	})
+}
+
+// downsample averages samples, ordered by server and time, into one sample
+// per server and bucket of the given resolution. TPS is averaged over the
+// samples that have it.
+func downsample(samples []model.MetricSample, resolution time.Duration) []model.MetricSample {
+	var result []model.MetricSample
+	var count, tpsCount int
+	var tpsSum float64
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		bucket := &result[len(result)-1]
+		bucket.CPUPercent /= float64(count)
+		bucket.MemoryBytes /= int64(count)
+		bucket.Players /= float64(count)
+		if tpsCount > 0 {
+			tps := tpsSum / float64(tpsCount)
+			bucket.TPS = &tps
+		}
+		count, tpsCount, tpsSum = 0, 0, 0
+	}
+
+	for _, sample := range samples {
+		start := sample.Time.Truncate(resolution)
+		if count == 0 || result[len(result)-1].ServerID != sample.ServerID || !result[len(result)-1].Time.Equal(start) {
+			flush()
+			result = append(result, model.MetricSample{
+				ServerID:   sample.ServerID,
+				Time:       start,
+				Resolution: int(resolution.Seconds()),
+			})
+		}
+		bucket := &result[len(result)-1]
+		bucket.CPUPercent += sample.CPUPercent
+		bucket.MemoryBytes += sample.MemoryBytes
+		bucket.Players += sample.Players
+		if sample.TPS != nil {
+			tpsSum += *sample.TPS
+			tpsCount++
+		}
+		count++
+	}
+	flush()
+	return result
+}
+
+// MetricSeries returns the values of one metric of a server over the last
+// span, oldest first
+func (sm *ServerManager) MetricSeries(id uint, userID uint, metric string, span time.Duration) ([]model.MetricPoint, error) {
+	if span <= 0 || span > MaxMetricsRange {
+		return nil, fmt.Errorf("range must be between 1m and %s", MaxMetricsRange)
+	}
+	column := map[string]string{
+		model.MetricCPU:     "cpu_percent",
+		model.MetricMemory:  "memory_bytes",
+		model.MetricPlayers: "players",
+		model.MetricTPS:     "tps",
+	}[metric]
+	if column == "" {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+
+	points := []model.MetricPoint{}
+	err = sm.db.Model(&model.MetricSample{}).
+		Select("time, "+column+" AS value").
+		Where("server_id = ? AND time >= ? AND "+column+" IS NOT NULL", serverModel.ID, time.Now().Add(-span)).
+		Order("time").
+		Scan(&points).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metrics: %w", err)
+	}
+	return points, nil
+}
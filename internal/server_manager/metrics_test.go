@@ -0,0 +1,42 @@
+package server_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2024, 11, 10, 12, 0, 0, 0, time.UTC)
+	tps := 18.0
+	samples := []model.MetricSample{
+		{ServerID: 1, Time: base, CPUPercent: 10, MemoryBytes: 100, Players: 2, TPS: &tps},
+		{ServerID: 1, Time: base.Add(30 * time.Minute), CPUPercent: 30, MemoryBytes: 300, Players: 4},
+		{ServerID: 1, Time: base.Add(time.Hour), CPUPercent: 50, MemoryBytes: 500, Players: 6},
+		{ServerID: 2, Time: base.Add(time.Hour), CPUPercent: 5, MemoryBytes: 50, Players: 1},
+	}
+
+	got := downsample(samples, time.Hour)
+	if len(got) != 3 {
+		t.Fatalf("got %d buckets, want 3", len(got))
+	}
+
+	first := got[0]
+	if first.ServerID != 1 || !first.Time.Equal(base) || first.Resolution != 3600 {
+		t.Errorf("unexpected first bucket %+v", first)
+	}
+	if first.CPUPercent != 20 || first.MemoryBytes != 200 || first.Players != 3 {
+		t.Errorf("first bucket averages = %v, %v, %v; want 20, 200, 3", first.CPUPercent, first.MemoryBytes, first.Players)
+	}
+	if first.TPS == nil || *first.TPS != 18 {
+		t.Errorf("first bucket TPS = %v, want 18", first.TPS)
+	}
+
+	if got[1].TPS != nil {
+		t.Errorf("bucket without TPS samples has TPS %v", *got[1].TPS)
+	}
+	if got[2].ServerID != 2 || got[2].CPUPercent != 5 {
+		t.Errorf("unexpected last bucket %+v", got[2])
+	}
+}
@@ -0,0 +1,197 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/gorm"
+)
+
+// Strategies for applying mod pack changes to running servers
+const (
+	// ApplyReload issues the Bukkit-style "reload confirm" command. Plugin
+	// servers pick up most changes this way; modded servers need a restart.
+	ApplyReload = "reload"
+	// ApplyRestart stops and starts running servers
+	ApplyRestart = "restart"
+	// ApplyNextStart only re-provisions files; running servers pick the
+	// changes up the next time they start
+	ApplyNextStart = "next-start"
+)
+
+// Mod pack apply states
+const (
+	ApplyRunning  = "running"
+	ApplyFinished = "finished"
+)
+
+// ErrModPackApplyRunning is returned when the same user is still applying
+// the mod pack
+var ErrModPackApplyRunning = errors.New("mod pack apply already running")
+
+// ModPackApplyResult is the outcome of applying a mod pack to one server
+type ModPackApplyResult struct {
+	ServerID uint   `json:"server_id"`
+	Name     string `json:"name"`
+	Running  bool   `json:"running"`
+	Action   string `json:"action"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ModPackApply tracks applying a mod pack to the servers of one user.
+// Results grow as servers are done.
+type ModPackApply struct {
+	ModPackID  uint                 `json:"mod_pack_id"`
+	Strategy   string               `json:"strategy"`
+	Status     string               `json:"status"`
+	Results    []ModPackApplyResult `json:"results"`
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+}
+
+// modPackApplyKey identifies the applies of one mod pack by one user
+type modPackApplyKey struct {
+	modPackID uint
+	userID    uint
+}
+
+// ApplyModPack starts re-provisioning a mod pack into every server of
+// userID that uses it, applying the change to running servers using
+// strategy. Restarting servers one after another takes minutes, so it runs
+// in the background; GetModPackApply reports the progress. Modded loaders
+// cannot reload mods, so reload is refused when any of the servers runs one.
+func (sm *ServerManager) ApplyModPack(ctx context.Context, modPackID uint, userID uint, strategy string) (*ModPackApply, error) {
+	switch strategy {
+	case "":
+		strategy = ApplyNextStart
+	case ApplyReload, ApplyRestart, ApplyNextStart:
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", strategy)
+	}
+
+	modPack, err := sm.GetModPackByID(modPackID)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []model.Server
+	err = sm.db.Joins("JOIN server_configs ON server_configs.server_id = servers.id").
+		Scopes(sm.serverControl(userID)).
+		Where("server_configs.mod_pack_id = ?", modPackID).
+		Find(&servers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find servers using mod pack: %w", err)
+	}
+	if strategy == ApplyReload {
+		for _, serverModel := range servers {
+			config, err := sm.GetServerConfig(serverModel.ID)
+			if err != nil {
+				return nil, err
+			}
+			if loader := serverLoader(serverModel.Path, config.JarFile); loader != "" {
+				return nil, fmt.Errorf("%s runs %s, which cannot reload mods; use %s", serverModel.Name, loader, ApplyRestart)
+			}
+		}
+	}
+
+	key := modPackApplyKey{modPackID: modPackID, userID: userID}
+	apply := &ModPackApply{
+		ModPackID: modPackID,
+		Strategy:  strategy,
+		Status:    ApplyRunning,
+		Results:   []ModPackApplyResult{},
+		StartedAt: time.Now(),
+	}
+	sm.changeMutex.Lock()
+	if running, exists := sm.modPackApplies[key]; exists && running.Status == ApplyRunning {
+		sm.changeMutex.Unlock()
+		return nil, fmt.Errorf("%w: mod pack %d", ErrModPackApplyRunning, modPackID)
+	}
+	sm.modPackApplies[key] = apply
+	copied := *apply
+	sm.changeMutex.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for _, serverModel := range servers {
+			result := sm.applyModPackToServer(ctx, modPack, serverModel, strategy)
+			sm.changeMutex.Lock()
+			apply.Results = append(apply.Results, result)
+			sm.changeMutex.Unlock()
+		}
+		finished := time.Now()
+		sm.changeMutex.Lock()
+		apply.Status = ApplyFinished
+		apply.FinishedAt = &finished
+		sm.changeMutex.Unlock()
+		slog.InfoContext(ctx, "Applied mod pack", "mod_pack_id", modPackID, "strategy", strategy, "servers", len(servers))
+	}()
+	return &copied, nil
+}
+
+// GetModPackApply returns the most recent apply of a mod pack by userID
+func (sm *ServerManager) GetModPackApply(modPackID uint, userID uint) (*ModPackApply, error) {
+	sm.changeMutex.Lock()
+	defer sm.changeMutex.Unlock()
+	apply, exists := sm.modPackApplies[modPackApplyKey{modPackID: modPackID, userID: userID}]
+	if !exists {
+		return nil, fmt.Errorf("no apply of mod pack %d recorded: %w", modPackID, gorm.ErrRecordNotFound)
+	}
+	copied := *apply
+	copied.Results = slices.Clone(apply.Results)
+	return &copied, nil
+}
+
+func (sm *ServerManager) applyModPackToServer(ctx context.Context, modPack *model.ModPack, serverModel model.Server, strategy string) ModPackApplyResult {
+	result := ModPackApplyResult{ServerID: serverModel.ID, Name: serverModel.Name, Action: "provisioned"}
+	id := serverModel.ID
+
+	if err := checkLocal(&serverModel); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if err := sm.linkArtifact(modPack.Path, filepath.Join(serverModel.Path, "mods")); err != nil {
+		result.Error = fmt.Sprintf("failed to re-provision mod pack: %v", err)
+		return result
+	}
+
+	_, srv, err := sm.ownedServer(id, serverModel.UserID, model.PermissionFiles)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Running = srv.IsRunning()
+	if !result.Running {
+		return result
+	}
+
+	switch strategy {
+	case ApplyReload:
+		if err := srv.SendCommand("reload confirm"); err != nil {
+			result.Error = fmt.Sprintf("failed to send reload: %v", err)
+			return result
+		}
+		result.Action = "reloaded"
+	case ApplyRestart:
+		if err := srv.StopAndWait(stopTimeout); err != nil {
+			result.Error = fmt.Sprintf("failed to stop server: %v", err)
+			return result
+		}
+		if err := sm.StartServer(ctx, id, serverModel.UserID); err != nil {
+			result.Error = fmt.Sprintf("failed to start server: %v", err)
+			return result
+		}
+		result.Action = "restarted"
+	default:
+		result.Action = "pending restart"
+	}
+
+	slog.InfoContext(ctx, "Applied mod pack to server", "mod_pack_id", modPack.ID, "server_id", id, "action", result.Action)
+	return result
+}
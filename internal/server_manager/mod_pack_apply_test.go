@@ -0,0 +1,76 @@
+package server_manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestApplyModPack(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "modded")
+	serverModel, _, err := sm.ownedServer(id, owner.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packPath := filepath.Join(sm.commonDir, "pack")
+	if err := os.MkdirAll(packPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pack := &model.ModPack{Name: "pack", Version: "1.0", Path: packPath, IsCommon: true}
+	if err := sm.db.Create(pack).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.db.Model(&model.ServerConfig{}).Where("server_id = ?", id).Update("mod_pack_id", pack.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sm.ApplyModPack(context.Background(), pack.ID, owner.ID, "hot-swap"); err == nil {
+		t.Error("unknown strategy: got no error")
+	}
+	// Fabric leaves .fabric behind in the servers it installs
+	if err := os.MkdirAll(filepath.Join(serverModel.Path, ".fabric"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.ApplyModPack(context.Background(), pack.ID, owner.ID, ApplyReload); err == nil {
+		t.Error("reload on a fabric server: got no error")
+	}
+	if _, err := sm.GetModPackApply(pack.ID, owner.ID); err == nil {
+		t.Error("a refused apply was recorded")
+	}
+
+	apply, err := sm.ApplyModPack(context.Background(), pack.ID, owner.ID, ApplyRestart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for apply.Status == ApplyRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if apply, err = sm.GetModPackApply(pack.ID, owner.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if apply.Status != ApplyFinished || apply.FinishedAt == nil {
+		t.Fatalf("apply %+v did not finish", apply)
+	}
+	// The server is stopped, so it picks the pack up on its next start
+	want := []ModPackApplyResult{{ServerID: id, Name: "modded", Action: "provisioned"}}
+	if len(apply.Results) != 1 || apply.Results[0] != want[0] {
+		t.Errorf("results %+v, want %+v", apply.Results, want)
+	}
+	if target, err := os.Readlink(filepath.Join(serverModel.Path, "mods")); err != nil || target != packPath {
+		t.Errorf("mods links to %q, %v; want %q", target, err, packPath)
+	}
+
+	// Applies are kept per user
+	other := createTestUser(t, sm, "bob", model.RoleOperator)
+	if _, err := sm.GetModPackApply(pack.ID, other.ID); err == nil {
+		t.Error("another user's apply was returned")
+	}
+}
@@ -0,0 +1,358 @@
+package server_manager
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ErrInvalidUpgrade is returned for mod pack upgrades that cannot be applied
+// to a server, such as to a pack of another name
+var ErrInvalidUpgrade = errors.New("invalid mod pack upgrade")
+
+// Mod pack upgrade states
+const (
+	UpgradePending    = "pending"
+	UpgradeConfirmed  = "confirmed"
+	UpgradeRolledBack = "rolled_back"
+)
+
+// ModPackUpgrade tracks a mod pack upgrade until the next start proves it works
+type ModPackUpgrade struct {
+	ServerID          uint   `json:"server_id"`
+	PreviousModPackID uint   `json:"previous_mod_pack_id"`
+	ModPackID         uint   `json:"mod_pack_id"`
+	FromVersion       string `json:"from_version"`
+	ToVersion         string `json:"to_version"`
+	// Added and Removed list the mod jars that differ between the versions
+	Added        []string  `json:"added"`
+	Removed      []string  `json:"removed"`
+	SnapshotPath string    `json:"snapshot_path"`
+	Status       string    `json:"status"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UpgradeModPack moves a server to another version of its mod pack, the
+// latest one if modPackID is 0. The config directory is snapshotted and
+// mods relinked to the new version; a running server is restarted. The
+// upgrade stays pending until the next start reaches "Done"; otherwise the
+// previous version and configs are restored.
+func (sm *ServerManager) UpgradeModPack(ctx context.Context, id uint, userID uint, modPackID uint) (*ModPackUpgrade, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	if serverModel.ArchivedAt != nil {
+		return nil, ErrServerArchived
+	}
+	if sm.pendingModPackUpgrade(id) != nil || sm.pendingJarSwap(id) != nil {
+		return nil, fmt.Errorf("%w: a change is already pending verification on server %d", ErrInvalidUpgrade, id)
+	}
+
+	config, err := sm.GetServerConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	if config.ModPack == nil {
+		return nil, fmt.Errorf("%w: server %d has no mod pack", ErrInvalidUpgrade, id)
+	}
+	current := config.ModPack
+	target, err := sm.upgradeTarget(current, modPackID)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := sm.lockFiles(id, FileLockModPackUpgrade, nil)
+	if err != nil {
+		return nil, err
+	}
+	upgrade, wasRunning, err := sm.applyModPackUpgrade(serverModel, srv, current, target)
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Upgraded mod pack, pending verification on next start", "server_id", id,
+		"from", current.Version, "to", target.Version, "added", len(upgrade.Added), "removed", len(upgrade.Removed))
+	if wasRunning {
+		if err := sm.StartServer(ctx, id, userID); err != nil {
+			sm.rollbackModPackUpgrade(srv, upgrade, fmt.Sprintf("failed to start server: %v", err))
+		}
+	}
+	return sm.GetModPackUpgrade(id, userID)
+}
+
+// upgradeTarget returns the version of current's pack to upgrade to
+func (sm *ServerManager) upgradeTarget(current *model.ModPack, modPackID uint) (*model.ModPack, error) {
+	var target model.ModPack
+	if modPackID == 0 {
+		if err := sm.db.Where("name = ?", current.Name).Order("created_at DESC, id DESC").First(&target).Error; err != nil {
+			return nil, fmt.Errorf("failed to find latest version: %w", err)
+		}
+	} else if err := sm.db.First(&target, modPackID).Error; err != nil {
+		return nil, fmt.Errorf("%w: mod pack %d not found", ErrInvalidUpgrade, modPackID)
+	}
+
+	switch {
+	case target.ID == current.ID:
+		return nil, fmt.Errorf("%w: server already uses version %q of %s", ErrInvalidUpgrade, current.Version, current.Name)
+	case target.Name != current.Name:
+		return nil, fmt.Errorf("%w: mod pack %d is not a version of %s", ErrInvalidUpgrade, target.ID, current.Name)
+	}
+	return &target, nil
+}
+
+// applyModPackUpgrade stops the server, snapshots its configs and relinks
+// its mods to target, recording the pending upgrade. It reports whether the
+// server was running.
+func (sm *ServerManager) applyModPackUpgrade(serverModel *model.Server, srv *server.Server, current, target *model.ModPack) (*ModPackUpgrade, bool, error) {
+	added, removed, err := diffModPacks(current.Path, target.Path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	wasRunning := srv.IsRunning()
+	if err := srv.StopAndWait(stopTimeout); err != nil {
+		return nil, false, fmt.Errorf("failed to stop server: %w", err)
+	}
+
+	snapshotPath := fmt.Sprintf("%s.modpack-upgrade-%d", strings.TrimRight(serverModel.Path, string(filepath.Separator)), time.Now().Unix())
+	if err := snapshotUpgradeDirs(serverModel.Path, snapshotPath); err != nil {
+		os.RemoveAll(snapshotPath)
+		return nil, false, fmt.Errorf("failed to snapshot server directories: %w", err)
+	}
+
+	modsDir := filepath.Join(serverModel.Path, "mods")
+	if err := sm.linkArtifact(target.Path, modsDir); err != nil {
+		sm.linkArtifact(current.Path, modsDir)
+		os.RemoveAll(snapshotPath)
+		return nil, false, fmt.Errorf("failed to apply mod pack: %w", err)
+	}
+	if err := sm.db.Model(&model.ServerConfig{}).Where("server_id = ?", serverModel.ID).Update("mod_pack_id", target.ID).Error; err != nil {
+		sm.linkArtifact(current.Path, modsDir)
+		os.RemoveAll(snapshotPath)
+		return nil, false, fmt.Errorf("failed to update server config: %w", err)
+	}
+	srv.InvalidateConfig()
+
+	upgrade := &ModPackUpgrade{
+		ServerID:          serverModel.ID,
+		PreviousModPackID: current.ID,
+		ModPackID:         target.ID,
+		FromVersion:       current.Version,
+		ToVersion:         target.Version,
+		Added:             added,
+		Removed:           removed,
+		SnapshotPath:      snapshotPath,
+		Status:            UpgradePending,
+		CreatedAt:         time.Now(),
+	}
+	sm.changeMutex.Lock()
+	sm.modPackUpgrades[serverModel.ID] = upgrade
+	sm.changeMutex.Unlock()
+	return upgrade, wasRunning, nil
+}
+
+// GetModPackUpgrade returns the most recent mod pack upgrade of a server owned by userID
+func (sm *ServerManager) GetModPackUpgrade(id uint, userID uint) (*ModPackUpgrade, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionView); err != nil {
+		return nil, err
+	}
+
+	sm.changeMutex.Lock()
+	defer sm.changeMutex.Unlock()
+	upgrade, exists := sm.modPackUpgrades[id]
+	if !exists {
+		return nil, fmt.Errorf("no mod pack upgrade recorded for server %d", id)
+	}
+	copied := *upgrade
+	return &copied, nil
+}
+
+// pendingModPackUpgrade returns the unverified mod pack upgrade of a server, if any
+func (sm *ServerManager) pendingModPackUpgrade(id uint) *ModPackUpgrade {
+	sm.changeMutex.Lock()
+	defer sm.changeMutex.Unlock()
+	if upgrade, exists := sm.modPackUpgrades[id]; exists && upgrade.Status == UpgradePending {
+		return upgrade
+	}
+	return nil
+}
+
+// watchModPackUpgrade follows the first start after a mod pack upgrade
+func (sm *ServerManager) watchModPackUpgrade(id uint, srv *server.Server, upgrade *ModPackUpgrade) {
+	sm.watchFirstStart(id, srv, func() { sm.confirmModPackUpgrade(upgrade) }, func(reason string) {
+		sm.rollbackModPackUpgrade(srv, upgrade, reason)
+	})
+}
+
+// confirmModPackUpgrade marks an upgrade as successful and discards its snapshot
+func (sm *ServerManager) confirmModPackUpgrade(upgrade *ModPackUpgrade) {
+	sm.changeMutex.Lock()
+	upgrade.Status = UpgradeConfirmed
+	sm.changeMutex.Unlock()
+
+	if err := os.RemoveAll(upgrade.SnapshotPath); err != nil {
+		slog.Error("Failed to remove mod pack upgrade snapshot", "snapshot", upgrade.SnapshotPath, "error", err)
+	}
+	slog.Info("Mod pack upgrade confirmed", "server_id", upgrade.ServerID, "version", upgrade.ToVersion)
+}
+
+// rollbackModPackUpgrade stops the server, relinks the previous version and
+// restores the snapshotted directories
+func (sm *ServerManager) rollbackModPackUpgrade(srv *server.Server, upgrade *ModPackUpgrade, reason string) {
+	slog.Warn("Rolling back mod pack upgrade", "server_id", upgrade.ServerID, "reason", reason)
+
+	if err := srv.StopAndWait(stopTimeout); err != nil {
+		slog.Error("Failed to stop server for rollback", "server_id", upgrade.ServerID, "error", err)
+	}
+
+	if previous, err := sm.GetModPackByID(upgrade.PreviousModPackID); err != nil {
+		slog.Error("Failed to fetch previous mod pack", "server_id", upgrade.ServerID, "error", err)
+	} else if err := sm.linkArtifact(previous.Path, filepath.Join(srv.GetPath(), "mods")); err != nil {
+		slog.Error("Failed to relink previous mod pack", "server_id", upgrade.ServerID, "error", err)
+	}
+	if err := restoreUpgradeDirs(srv.GetPath(), upgrade.SnapshotPath); err != nil {
+		slog.Error("Failed to restore snapshot", "server_id", upgrade.ServerID, "error", err)
+	}
+
+	if err := sm.db.Model(&model.ServerConfig{}).
+		Where("server_id = ?", upgrade.ServerID).
+		Update("mod_pack_id", upgrade.PreviousModPackID).Error; err != nil {
+		slog.Error("Failed to restore mod pack", "server_id", upgrade.ServerID, "error", err)
+	}
+	srv.InvalidateConfig()
+
+	sm.changeMutex.Lock()
+	upgrade.Status = UpgradeRolledBack
+	upgrade.Reason = reason
+	sm.changeMutex.Unlock()
+}
+
+// upgradeDirs are the directories of a server an upgrade may change: mods
+// is only copied if the server has its own rather than a linked pack
+var upgradeDirs = []string{"mods", "config"}
+
+// snapshotUpgradeDirs copies the directories an upgrade may change into snapshotPath
+func snapshotUpgradeDirs(serverPath, snapshotPath string) error {
+	if err := os.MkdirAll(snapshotPath, 0755); err != nil {
+		return err
+	}
+	for _, dir := range upgradeDirs {
+		source := filepath.Join(serverPath, dir)
+		if info, err := os.Stat(source); err != nil || !info.IsDir() || utils.IsLinkedArtifact(source) {
+			continue
+		}
+		if err := utils.CopyDir(source, filepath.Join(snapshotPath, dir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreUpgradeDirs puts the snapshotted directories back and removes the snapshot
+func restoreUpgradeDirs(serverPath, snapshotPath string) error {
+	for _, dir := range upgradeDirs {
+		saved := filepath.Join(snapshotPath, dir)
+		if _, err := os.Stat(saved); err != nil {
+			continue
+		}
+		target := filepath.Join(serverPath, dir)
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+		if err := os.Rename(saved, target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", dir, err)
+		}
+	}
+	return os.RemoveAll(snapshotPath)
+}
+
+// diffModPacks lists the mod jars only in to as added and those only in from as removed
+func diffModPacks(from, to string) (added, removed []string, err error) {
+	before, err := modPackMods(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := modPackMods(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	added, removed = []string{}, []string{}
+	for mod := range after {
+		if !before[mod] {
+			added = append(added, mod)
+		}
+	}
+	for mod := range before {
+		if !after[mod] {
+			removed = append(removed, mod)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, nil
+}
+
+// modPackMods returns the names of the jar files of a mod pack directory or zip file
+func modPackMods(packPath string) (map[string]bool, error) {
+	info, err := os.Stat(packPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mod pack: %w", err)
+	}
+	mods := make(map[string]bool)
+	if !info.IsDir() {
+		archive, err := zip.OpenReader(packPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mod pack: %w", err)
+		}
+		defer archive.Close()
+		for _, entry := range archive.File {
+			if !entry.FileInfo().IsDir() && strings.EqualFold(path.Ext(entry.Name), ".jar") {
+				mods[path.Base(entry.Name)] = true
+			}
+		}
+		return mods, nil
+	}
+
+	err = filepath.WalkDir(packPath, func(file string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(file), ".jar") {
+			mods[entry.Name()] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mod pack: %w", err)
+	}
+	return mods, nil
+}
+
+// versionedFilename inserts version into a mod pack file name so the
+// versions of a pack do not overwrite each other
+func versionedFilename(filename, version string) string {
+	version = strings.NewReplacer("/", "_", "\\", "_").Replace(version)
+	if version == "" || strings.Contains(filename, version) {
+		return filename
+	}
+	extension := filepath.Ext(filename)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(filename, extension), version, extension)
+}
@@ -0,0 +1,72 @@
+package server_manager
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffModPacks(t *testing.T) {
+	from := t.TempDir()
+	for _, name := range []string{"create-0.5.jar", "jei-15.jar", "README.md"} {
+		os.WriteFile(filepath.Join(from, name), nil, 0644)
+	}
+	to := filepath.Join(t.TempDir(), "pack-1.1.zip")
+	file, _ := os.Create(to)
+	zw := zip.NewWriter(file)
+	for _, name := range []string{"mods/create-0.6.jar", "mods/jei-15.jar", "config/create.toml"} {
+		zw.Create(name)
+	}
+	zw.Close()
+	file.Close()
+
+	added, removed, err := diffModPacks(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, []string{"create-0.6.jar"}) || !reflect.DeepEqual(removed, []string{"create-0.5.jar"}) {
+		t.Fatalf("added %v, removed %v", added, removed)
+	}
+}
+
+func TestUpgradeSnapshot(t *testing.T) {
+	serverPath := t.TempDir()
+	snapshotPath := serverPath + ".modpack-upgrade"
+	os.MkdirAll(filepath.Join(serverPath, "config"), 0755)
+	os.WriteFile(filepath.Join(serverPath, "config", "create.toml"), []byte("old"), 0644)
+
+	if err := snapshotUpgradeDirs(serverPath, snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	// The new version rewrites its configs on start
+	os.WriteFile(filepath.Join(serverPath, "config", "create.toml"), []byte("new"), 0644)
+	os.WriteFile(filepath.Join(serverPath, "config", "added.toml"), []byte("new"), 0644)
+
+	if err := restoreUpgradeDirs(serverPath, snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(serverPath, "config", "create.toml")); string(data) != "old" {
+		t.Errorf("config holds %q after rollback", data)
+	}
+	if _, err := os.Stat(filepath.Join(serverPath, "config", "added.toml")); !os.IsNotExist(err) {
+		t.Error("config added by the upgrade survived the rollback")
+	}
+	if _, err := os.Stat(snapshotPath); !os.IsNotExist(err) {
+		t.Error("snapshot was not removed")
+	}
+}
+
+func TestVersionedFilename(t *testing.T) {
+	for _, tt := range []struct{ filename, version, want string }{
+		{"pack.zip", "", "pack.zip"},
+		{"pack.zip", "1.2", "pack-1.2.zip"},
+		{"pack-1.2.zip", "1.2", "pack-1.2.zip"},
+		{"pack.zip", "../1", "pack-.._1.zip"},
+	} {
+		if got := versionedFilename(tt.filename, tt.version); got != tt.want {
+			t.Errorf("versionedFilename(%q, %q) = %q, want %q", tt.filename, tt.version, got, tt.want)
+		}
+	}
+}
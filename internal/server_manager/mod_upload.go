@@ -0,0 +1,175 @@
+package server_manager
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ErrModsProvisioned is returned when mods are uploaded to a server whose
+// mods directory is provisioned from a mod pack; writing into it would
+// change the pack for every server using it
+var ErrModsProvisioned = errors.New("the mods directory of this server is provisioned from a mod pack")
+
+// ModUploadResult is the outcome of one mod of an upload
+type ModUploadResult struct {
+	// File is the name of the mod in the mods directory
+	File string `json:"file" example:"lithium-fabric-0.13.0.jar"`
+	// Source is the uploaded zip file the mod was taken from, if any
+	Source string `json:"source,omitempty" example:"performance-mods.zip"`
+	Size   int64  `json:"size,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// UploadMods writes uploaded jar files into the mods directory of a server.
+// The jar files inside uploaded zip files are written as well. Every mod
+// succeeds or fails on its own; the error is for failures of the whole
+// upload.
+func (sm *ServerManager) UploadMods(ctx context.Context, id, userID uint, files []*multipart.FileHeader) ([]ModUploadResult, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	modsDir := filepath.Join(serverModel.Path, "mods")
+	if utils.IsLinkedArtifact(modsDir) {
+		return nil, ErrModsProvisioned
+	}
+	if err := utils.MkdirAllIn(serverModel.Path, "mods", 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mods directory: %w", err)
+	}
+
+	var total int64
+	for _, header := range files {
+		total += header.Size
+	}
+	if err := sm.checkDiskSpace(modsDir, total); err != nil {
+		return nil, err
+	}
+
+	var results []ModUploadResult
+	for _, header := range files {
+		name := path.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".jar":
+			result := ModUploadResult{File: name}
+			result.Size, err = saveUploadedMod(modsDir, name, func() (io.ReadCloser, error) {
+				return header.Open()
+			})
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+		case ".zip":
+			results = append(results, sm.explodeModZip(modsDir, name, header)...)
+		default:
+			results = append(results, ModUploadResult{File: name, Error: "not a jar or zip file"})
+		}
+	}
+
+	written := 0
+	for _, result := range results {
+		if result.Error == "" {
+			written++
+		}
+	}
+	slog.InfoContext(ctx, "Uploaded mods", "server_id", id, "files", len(files), "mods", written, "failed", len(results)-written)
+	return results, nil
+}
+
+// explodeModZip writes the jar files of an uploaded zip file into modsDir.
+// Directories inside the zip are flattened.
+func (sm *ServerManager) explodeModZip(modsDir, name string, header *multipart.FileHeader) []ModUploadResult {
+	file, err := header.Open()
+	if err != nil {
+		return []ModUploadResult{{File: name, Error: fmt.Sprintf("failed to read upload: %v", err)}}
+	}
+	defer file.Close()
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		return []ModUploadResult{{File: name, Error: "not a valid zip file"}}
+	}
+
+	var jars []*zip.File
+	var size int64
+	for _, entry := range archive.File {
+		if !entry.FileInfo().IsDir() && strings.EqualFold(path.Ext(entry.Name), ".jar") {
+			jars = append(jars, entry)
+			size += int64(entry.UncompressedSize64)
+		}
+	}
+	if len(jars) == 0 {
+		return []ModUploadResult{{File: name, Error: "zip file contains no jar files"}}
+	}
+	if err := sm.checkDiskSpace(modsDir, size); err != nil {
+		return []ModUploadResult{{File: name, Error: err.Error()}}
+	}
+
+	results := make([]ModUploadResult, 0, len(jars))
+	for _, entry := range jars {
+		result := ModUploadResult{File: path.Base(entry.Name), Source: name}
+		result.Size, err = saveUploadedMod(modsDir, result.File, entry.Open)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// saveUploadedMod writes the jar file open returns into modsDir, replacing
+// a mod of the same name only once the new one is complete
+func saveUploadedMod(modsDir, name string, open func() (io.ReadCloser, error)) (int64, error) {
+	target, err := utils.SafeJoin(modsDir, name)
+	if err != nil {
+		return 0, err
+	}
+	in, err := open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read upload: %w", err)
+	}
+	defer in.Close()
+
+	magic := make([]byte, len(zipMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || !bytes.Equal(magic, zipMagic) {
+		return 0, errors.New("not a jar file")
+	}
+
+	// The server may have left anything under the temporary name,
+	// including a link to a file outside its directory
+	tmp := target + ".part"
+	if err := utils.RemoveIn(modsDir, name+".part"); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to create mod: %w", err)
+	}
+	out, err := utils.OpenIn(modsDir, name+".part", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create mod: %w", err)
+	}
+	size, err := io.Copy(out, io.MultiReader(bytes.NewReader(magic), in))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to save mod: %w", err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to save mod: %w", err)
+	}
+	return size, nil
+}
@@ -0,0 +1,88 @@
+package server_manager
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplodeModZip(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, data := range map[string]string{
+		"pack/lithium.jar": "PK\x03\x04lithium",
+		"pack/readme.txt":  "hello",
+		"broken.jar":       "not a jar",
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(data))
+	}
+	zw.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("files", "mods.zip")
+	part.Write(archive.Bytes())
+	mw.Close()
+	form, err := multipart.NewReader(&body, mw.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer form.RemoveAll()
+
+	modsDir := t.TempDir()
+	sm := &ServerManager{}
+	results := sm.explodeModZip(modsDir, "mods.zip", form.File["files"][0])
+	if len(results) != 2 {
+		t.Fatalf("expected a result per jar, got %+v", results)
+	}
+	for _, result := range results {
+		switch result.File {
+		case "lithium.jar":
+			if result.Error != "" || result.Source != "mods.zip" {
+				t.Errorf("unexpected result %+v", result)
+			}
+		case "broken.jar":
+			if result.Error == "" {
+				t.Error("a file that is not a jar was accepted")
+			}
+		default:
+			t.Errorf("unexpected mod %s", result.File)
+		}
+	}
+
+	entries, _ := os.ReadDir(modsDir)
+	if len(entries) != 1 || entries[0].Name() != "lithium.jar" {
+		t.Fatalf("mods directory holds %v", entries)
+	}
+	if data, _ := os.ReadFile(filepath.Join(modsDir, "lithium.jar")); string(data) != "PK\x03\x04lithium" {
+		t.Fatalf("lithium.jar holds %q", data)
+	}
+}
+
+func TestSaveUploadedModIgnoresPlantedLinks(t *testing.T) {
+	modsDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(outside, []byte("secret"), 0644)
+	if err := os.Symlink(outside, filepath.Join(modsDir, "lithium.jar.part")); err != nil {
+		t.Fatal(err)
+	}
+
+	open := func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("PK\x03\x04lithium")), nil
+	}
+	if _, err := saveUploadedMod(modsDir, "lithium.jar", open); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "secret" {
+		t.Errorf("upload was written through the link: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(modsDir, "lithium.jar")); string(data) != "PK\x03\x04lithium" {
+		t.Errorf("lithium.jar holds %q", data)
+	}
+}
@@ -0,0 +1,109 @@
+package server_manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/mods"
+)
+
+// ModValidation is the outcome of checking the mods of a server
+type ModValidation struct {
+	// Loader and MinecraftVersion are what the mods were checked against,
+	// empty when they could not be determined
+	Loader           string         `json:"loader" example:"fabric"`
+	MinecraftVersion string         `json:"minecraft_version" example:"1.20.1"`
+	Mods             []mods.Mod     `json:"mods"`
+	Warnings         []mods.Warning `json:"warnings"`
+}
+
+// ValidateMods reads the metadata of the mods installed in a server and
+// reports missing dependencies, conflicts, duplicate mods and mods for
+// another loader or Minecraft version
+func (sm *ServerManager) ValidateMods(id uint, userID uint) (*ModValidation, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	if serverModel.ArchivedAt != nil {
+		return nil, ErrServerArchived
+	}
+	config, err := sm.GetServerConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	return validateServerMods(serverModel.Path, config.JarFile)
+}
+
+// validateServerMods checks the jars in the mods directory of a server
+func validateServerMods(serverPath string, jarFile model.JarFile) (*ModValidation, error) {
+	validation := &ModValidation{
+		Loader:   serverLoader(serverPath, jarFile),
+		Mods:     []mods.Mod{},
+		Warnings: []mods.Warning{},
+	}
+	if minecraftVersion.MatchString(jarFile.Version) {
+		validation.MinecraftVersion = jarFile.Version
+	}
+
+	modsDir := filepath.Join(serverPath, "mods")
+	entries, err := os.ReadDir(modsDir)
+	if os.IsNotExist(err) {
+		return validation, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mods directory: %w", err)
+	}
+
+	var unreadable []mods.Warning
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".jar") {
+			continue
+		}
+		found, err := mods.ReadJar(filepath.Join(modsDir, entry.Name()))
+		if err != nil {
+			unreadable = append(unreadable, mods.Warning{
+				Type:    mods.WarningUnreadable,
+				Files:   []string{entry.Name()},
+				Message: fmt.Sprintf("%s is not a readable mod: %v", entry.Name(), err),
+			})
+			continue
+		}
+		validation.Mods = append(validation.Mods, found...)
+	}
+	sort.SliceStable(validation.Mods, func(i, j int) bool { return validation.Mods[i].File < validation.Mods[j].File })
+
+	validation.Warnings = append(unreadable, mods.Validate(validation.Mods, validation.Loader, validation.MinecraftVersion)...)
+	return validation, nil
+}
+
+// serverLoader guesses the mod loader of a server from its jar or, for
+// servers set up by an installer, from the files the installer left
+func serverLoader(serverPath string, jarFile model.JarFile) string {
+	if strings.Contains(strings.ToLower(filepath.Base(jarFile.Path)+" "+jarFile.Name), "quilt") {
+		return mods.LoaderQuilt
+	}
+	if loader := detectLoader(jarFile); loader != "" {
+		return loader
+	}
+	markers := []struct{ path, loader string }{
+		{"quilt-server-launch.jar", mods.LoaderQuilt},
+		{"fabric-server-launch.jar", mods.LoaderFabric},
+		{".fabric", mods.LoaderFabric},
+		{"libraries/net/neoforged", mods.LoaderNeoForge},
+		{"libraries/net/minecraftforge", mods.LoaderForge},
+	}
+	for _, marker := range markers {
+		if _, err := os.Stat(filepath.Join(serverPath, filepath.FromSlash(marker.path))); err == nil {
+			return marker.loader
+		}
+	}
+	return ""
+}
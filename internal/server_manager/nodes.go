@@ -0,0 +1,232 @@
+package server_manager
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/node"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"gorm.io/gorm"
+)
+
+// nodeOfflineAfter is how long a node may go without reporting capacity
+// before it is considered offline. Agents report every 30 seconds.
+const nodeOfflineAfter = 2 * time.Minute
+
+var (
+	// ErrNoNodeCapacity is returned when nodes exist but none can fit a server
+	ErrNoNodeCapacity = errors.New("no online node has enough free memory")
+	// ErrServerOnNode is returned for operations that need the files of a
+	// server on a node, which the manager cannot read or edit once pushed
+	ErrServerOnNode = errors.New("server runs on a node; its files cannot be changed from the control plane")
+)
+
+// hashNodeToken returns the stored form of a node token
+func hashNodeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateNode registers a node and returns it with the token its agent
+// authenticates with. Only the hash of the token is stored.
+func (sm *ServerManager) CreateNode(name string) (*model.Node, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("node name must not be empty")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(secret)
+
+	n := &model.Node{Name: name, TokenHash: hashNodeToken(token)}
+	if err := sm.db.Create(n).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create node: %w", err)
+	}
+	slog.Info("Created node", "node_id", n.ID, "name", name)
+	return n, token, nil
+}
+
+// ListNodes returns all nodes with their online state and the memory
+// allocated to their servers
+func (sm *ServerManager) ListNodes() ([]model.Node, error) {
+	var nodes []model.Node
+	if err := sm.db.Order("name").Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch nodes: %w", err)
+	}
+	allocated, err := sm.nodeAllocations()
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		nodes[i].Online = nodeOnline(&nodes[i])
+		nodes[i].AllocatedMemoryMB = allocated[nodes[i].ID]
+	}
+	return nodes, nil
+}
+
+// DeleteNode removes a node. Nodes with servers cannot be removed.
+func (sm *ServerManager) DeleteNode(nodeID uint) error {
+	var count int64
+	if err := sm.db.Model(&model.Server{}).Where("node_id = ?", nodeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count servers: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("node has %d servers", count)
+	}
+	result := sm.db.Unscoped().Delete(&model.Node{}, nodeID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete node: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	slog.Info("Deleted node", "node_id", nodeID)
+	return nil
+}
+
+// AuthenticateNode returns the node a token belongs to
+func (sm *ServerManager) AuthenticateNode(token string) (uint, error) {
+	var n model.Node
+	if err := sm.db.Where("token_hash = ?", hashNodeToken(token)).First(&n).Error; err != nil {
+		return 0, fmt.Errorf("unknown node token")
+	}
+	return n.ID, nil
+}
+
+// RecordNodeCapacity stores the capacity an agent reported
+func (sm *ServerManager) RecordNodeCapacity(nodeID uint, capacity node.Capacity) {
+	now := time.Now()
+	err := sm.db.Model(&model.Node{}).Where("id = ?", nodeID).Updates(map[string]interface{}{
+		"cpu_cores":       capacity.CPUCores,
+		"memory_mb":       capacity.MemoryMB,
+		"free_disk_bytes": capacity.FreeDiskBytes,
+		"last_seen_at":    &now,
+	}).Error
+	if err != nil {
+		slog.Error("Failed to record node capacity", "node_id", nodeID, "error", err)
+	}
+}
+
+func nodeOnline(n *model.Node) bool {
+	return n.LastSeenAt != nil && time.Since(*n.LastSeenAt) < nodeOfflineAfter
+}
+
+// nodeAllocations sums the -Xmx of the servers on each node
+func (sm *ServerManager) nodeAllocations() (map[uint]int64, error) {
+	var rows []struct {
+		NodeID            uint
+		ExecutableCommand string
+	}
+	err := sm.db.Model(&model.Server{}).
+		Select("servers.node_id, server_configs.executable_command").
+		Joins("JOIN server_configs ON server_configs.server_id = servers.id").
+		Where("servers.node_id IS NOT NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch node servers: %w", err)
+	}
+	allocated := make(map[uint]int64)
+	for _, row := range rows {
+		allocated[row.NodeID] += server.CommandMemoryMB(row.ExecutableCommand)
+	}
+	return allocated, nil
+}
+
+// scheduleNode picks the online node with the most free memory for a new
+// server. It returns nil when no nodes are registered, so servers run on
+// the control plane.
+func (sm *ServerManager) scheduleNode(memoryMB int64) (*uint, error) {
+	nodes, err := sm.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+
+	var best *model.Node
+	var bestFree int64
+	for i := range nodes {
+		n := &nodes[i]
+		if !n.Online {
+			continue
+		}
+		free := n.MemoryMB - n.AllocatedMemoryMB
+		if free < memoryMB {
+			continue
+		}
+		if best == nil || free > bestFree {
+			best, bestFree = n, free
+		}
+	}
+	if best == nil {
+		return nil, ErrNoNodeCapacity
+	}
+	return &best.ID, nil
+}
+
+// SetNodeHub sets the hub through which files reach node agents
+func (sm *ServerManager) SetNodeHub(hub *node.Hub) {
+	sm.nodes = hub
+}
+
+// checkLocal rejects servers on nodes, whose files the manager cannot edit
+func checkLocal(serverModel *model.Server) error {
+	if serverModel.NodeID != nil {
+		return fmt.Errorf("server %q: %w", serverModel.Name, ErrServerOnNode)
+	}
+	return nil
+}
+
+// pushToNode copies the files of a new server directory, including the jar
+// and mod pack it links to, to the same path on a node
+func (sm *ServerManager) pushToNode(nodeID uint, dir string) error {
+	if sm.nodes == nil {
+		return fmt.Errorf("node %d: %w", nodeID, node.ErrNodeOffline)
+	}
+	return walkFollowingLinks(dir, func(path string) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return sm.nodes.WriteFile(nodeID, path, f)
+	})
+}
+
+// walkFollowingLinks calls fn with the path of each regular file below dir,
+// following symlinks as filepath.Walk does not
+func walkFollowingLinks(dir string, fn func(path string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			err = walkFollowingLinks(path, fn)
+		} else if info.Mode().IsRegular() {
+			err = fn(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
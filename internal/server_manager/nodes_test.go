@@ -0,0 +1,78 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// createTestNode records a node that reported just now
+func createTestNode(t *testing.T, sm *ServerManager) *model.Node {
+	t.Helper()
+	now := time.Now()
+	n := &model.Node{Name: "node-1", TokenHash: "hash", MemoryMB: 8192, LastSeenAt: &now}
+	if err := sm.db.Create(n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestFailedPushIsDiscarded(t *testing.T) {
+	sm := newTestManager(t)
+	user := createTestUser(t, sm, "alice", model.RoleAdmin)
+	createTestNode(t, sm)
+	jar := createTestJar(t, sm)
+
+	// Without a hub the node is unreachable
+	path := filepath.Join(sm.commonDir, "servers", "survival")
+	if _, err := sm.CreateServer("survival", path, "java -jar server.jar nogui", jar, nil, nil, user.ID); err == nil {
+		t.Fatal("creating a server on an unreachable node succeeded")
+	}
+	var count int64
+	sm.db.Unscoped().Model(&model.Server{}).Where("name = ?", "survival").Count(&count)
+	if count != 0 {
+		t.Error("the server whose push failed was kept")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the directory of the server whose push failed was kept: %v", err)
+	}
+}
+
+func TestNodeServerFilesAreRefused(t *testing.T) {
+	sm := newTestManager(t)
+	user := createTestUser(t, sm, "alice", model.RoleAdmin)
+	id := createTestServer(t, sm, user, "survival")
+	n := createTestNode(t, sm)
+	if err := sm.db.Model(&model.Server{}).Where("id = ?", id).Update("node_id", n.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sm.SetServerIcon(id, user.ID, strings.NewReader("")); !errors.Is(err, ErrServerOnNode) {
+		t.Errorf("SetServerIcon: got %v, want ErrServerOnNode", err)
+	}
+	if _, err := sm.DeleteWorld(id, user.ID); !errors.Is(err, ErrServerOnNode) {
+		t.Errorf("DeleteWorld: got %v, want ErrServerOnNode", err)
+	}
+	if _, err := sm.CreateBackup(context.Background(), id, user.ID, "", ""); !errors.Is(err, ErrServerOnNode) {
+		t.Errorf("CreateBackup: got %v, want ErrServerOnNode", err)
+	}
+	if _, err := sm.CloneServer(id, user.ID, "survival-copy", filepath.Join(sm.commonDir, "servers", "survival-copy"), false); !errors.Is(err, ErrServerOnNode) {
+		t.Errorf("CloneServer: got %v, want ErrServerOnNode", err)
+	}
+
+	// Changes that leave the files alone still work
+	notes := "on a node"
+	if _, err := sm.UpdateServer(id, user.ID, ServerUpdate{Notes: &notes}); err != nil {
+		t.Errorf("UpdateServer: %v", err)
+	}
+	name := "renamed"
+	if _, err := sm.UpdateServer(id, user.ID, ServerUpdate{Name: &name}); !errors.Is(err, ErrServerOnNode) {
+		t.Errorf("renaming: got %v, want ErrServerOnNode", err)
+	}
+}
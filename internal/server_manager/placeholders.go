@@ -0,0 +1,189 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/placeholder"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+const (
+	// defaultOfflineMOTD is the MOTD of an offline placeholder without one
+	defaultOfflineMOTD = "Server is offline"
+	// offlineKick turns away players joining an offline placeholder
+	offlineKick = "Server is offline"
+	// defaultStartupTime is assumed for servers that have not started
+	// since the manager did
+	defaultStartupTime = 30 * time.Second
+)
+
+// StartPlaceholderResponder opens the offline placeholders of stopped
+// servers that have one configured, now and whenever a server stops, until
+// stop is closed
+func (sm *ServerManager) StartPlaceholderResponder(stop <-chan struct{}) {
+	events := sm.SubscribeEvents()
+	go func() {
+		defer sm.UnsubscribeEvents(events)
+		var configs []model.ServerConfig
+		if err := sm.db.Where("placeholder = ?", true).Find(&configs).Error; err != nil {
+			slog.Error("Failed to fetch server configs", "error", err)
+		}
+		for _, config := range configs {
+			sm.refreshPlaceholder(config.ServerID)
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if event.Type == model.EventServerStopped || event.Type == model.EventServerCrashed {
+					sm.refreshPlaceholder(event.ServerID)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// refreshPlaceholder opens or closes the offline placeholder of a server to
+// match its config. Running and queued servers are left alone, as are
+// servers in a stop mode maintenance window, whose placeholder takes over.
+func (sm *ServerManager) refreshPlaceholder(id uint) {
+	if window, err := sm.activeMaintenance(id); err != nil || window != nil && window.Mode == model.MaintenanceStop {
+		return
+	}
+	var serverModel model.Server
+	if err := sm.db.First(&serverModel, id).Error; err != nil {
+		return
+	}
+	config, err := sm.getServerConfig(id)
+	if err != nil {
+		return
+	}
+	if !config.Placeholder || serverModel.NodeID != nil || serverModel.ArchivedAt != nil {
+		sm.closePlaceholder(id)
+		return
+	}
+
+	// Holding the manager lock keeps a start from binding the port between
+	// the check and the placeholder
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	if sm.shuttingDown || sm.StartQueuePosition(id) > 0 {
+		return
+	}
+	if srv, exists := sm.servers[id]; exists && srv.IsRunning() {
+		return
+	}
+	options := placeholder.Options{MOTD: config.PlaceholderMOTD, Kick: offlineKick}
+	if options.MOTD == "" {
+		options.MOTD = defaultOfflineMOTD
+	}
+	if config.StartOnJoin {
+		options.Join = func(player string) string {
+			// The start closes this placeholder, which waits for the
+			// connection of the joining player
+			go sm.startOnJoin(id, serverModel.UserID, player)
+			return startingKick(sm.lastStartupTime(id))
+		}
+	}
+	if err := sm.openPlaceholder(&serverModel, options); err != nil {
+		slog.Warn("Failed to open offline placeholder", "server_id", id, "error", err)
+	}
+}
+
+// startingKick turns away the players whose join starts the server,
+// telling them when to come back: after the last startup time, rounded up
+// to ten seconds
+func startingKick(startup time.Duration) string {
+	if startup <= 0 {
+		startup = defaultStartupTime
+	}
+	seconds := int((startup + 10*time.Second - 1) / (10 * time.Second) * 10)
+	return fmt.Sprintf("Server is starting, try again in %ds", seconds)
+}
+
+// startOnJoin starts a server for a player who tried to join its
+// placeholder
+func (sm *ServerManager) startOnJoin(id, userID uint, player string) {
+	slog.Info("Starting server for joining player", "server_id", id, "player", player)
+	if err := sm.StartServer(context.Background(), id, userID); err != nil {
+		slog.Error("Failed to start server for joining player", "server_id", id, "error", err)
+	}
+}
+
+// openPlaceholder answers pings and joins on the game port of a stopped
+// server, replacing any placeholder already open. Servers on nodes have
+// their port on another machine and get none.
+func (sm *ServerManager) openPlaceholder(serverModel *model.Server, options placeholder.Options) error {
+	if serverModel.NodeID != nil {
+		return errors.New("servers on nodes have no placeholder")
+	}
+	sm.closePlaceholder(serverModel.ID)
+
+	properties, _ := utils.ReadProperties(filepath.Join(serverModel.Path, "server.properties"))
+	if options.MaxPlayers == 0 {
+		options.MaxPlayers = defaultMaxPlayers
+		if maxPlayers, err := strconv.Atoi(properties["max-players"]); err == nil {
+			options.MaxPlayers = maxPlayers
+		}
+	}
+	addr := net.JoinHostPort(properties["server-ip"], strconv.Itoa(server.Port(serverModel.Path)))
+	listener, err := placeholder.Listen(addr, options)
+	if err != nil {
+		return err
+	}
+
+	sm.placeholderMutex.Lock()
+	defer sm.placeholderMutex.Unlock()
+	if sm.placeholders == nil {
+		sm.placeholders = make(map[uint]*placeholder.Listener)
+	}
+	sm.placeholders[serverModel.ID] = listener
+	slog.Info("Opened placeholder", "server_id", serverModel.ID, "addr", addr)
+	return nil
+}
+
+// closePlaceholder frees the game port of a server for the server itself
+func (sm *ServerManager) closePlaceholder(id uint) {
+	sm.placeholderMutex.Lock()
+	listener, ok := sm.placeholders[id]
+	delete(sm.placeholders, id)
+	sm.placeholderMutex.Unlock()
+	if ok {
+		listener.Close()
+		slog.Info("Closed placeholder", "server_id", id)
+	}
+}
+
+// hasPlaceholder reports whether a placeholder is open for a server
+func (sm *ServerManager) hasPlaceholder(id uint) bool {
+	sm.placeholderMutex.Lock()
+	defer sm.placeholderMutex.Unlock()
+	_, ok := sm.placeholders[id]
+	return ok
+}
+
+// closePlaceholders closes every placeholder, for shutdown
+func (sm *ServerManager) closePlaceholders() {
+	sm.placeholderMutex.Lock()
+	ids := make([]uint, 0, len(sm.placeholders))
+	for id := range sm.placeholders {
+		ids = append(ids, id)
+	}
+	sm.placeholderMutex.Unlock()
+	for _, id := range ids {
+		sm.closePlaceholder(id)
+	}
+}
@@ -0,0 +1,20 @@
+package server_manager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartingKick(t *testing.T) {
+	cases := map[time.Duration]string{
+		0:                        "Server is starting, try again in 30s",
+		12 * time.Second:         "Server is starting, try again in 20s",
+		40 * time.Second:         "Server is starting, try again in 40s",
+		95500 * time.Millisecond: "Server is starting, try again in 100s",
+	}
+	for startup, want := range cases {
+		if got := startingKick(startup); got != want {
+			t.Errorf("startingKick(%s) = %q, want %q", startup, got, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package server_manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/mojang"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// Player actions and the console commands they map to
+var playerCommands = map[string]string{
+	"kick":    "kick",
+	"ban":     "ban",
+	"pardon":  "pardon",
+	"op":      "op",
+	"deop":    "deop",
+	"whisper": "tell",
+}
+
+// playerName matches Minecraft account names
+var playerName = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+// ErrInvalidPlayer is returned for lookups of something that is neither a
+// player name nor a UUID
+var ErrInvalidPlayer = errors.New("invalid player name or UUID")
+
+// ListPlayers returns the players online on a server, as seen in its
+// console since it started
+func (sm *ServerManager) ListPlayers(id uint, userID uint) ([]string, error) {
+	_, srv, err := sm.ownedServer(id, userID, model.PermissionConsole)
+	if err != nil {
+		return nil, err
+	}
+	return srv.OnlinePlayers(), nil
+}
+
+// PlayerAction runs a player management command on a running server.
+// message is the kick or ban reason, or the whispered text.
+func (sm *ServerManager) PlayerAction(id uint, userID uint, action, player, message string) error {
+	command, ok := playerCommands[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	if !playerName.MatchString(player) {
+		return fmt.Errorf("invalid player name %q", player)
+	}
+	// A line break would let the message run a second command
+	if strings.ContainsAny(message, "\r\n") {
+		return fmt.Errorf("message must be a single line")
+	}
+	if action == "whisper" && strings.TrimSpace(message) == "" {
+		return fmt.Errorf("whisper needs a message")
+	}
+
+	_, srv, err := sm.ownedServer(id, userID, model.PermissionConsole)
+	if err != nil {
+		return err
+	}
+
+	line := command + " " + player
+	switch action {
+	case "kick", "ban", "whisper":
+		if message != "" {
+			line += " " + message
+		}
+	}
+	return srv.SendCommand(line)
+}
+
+// PlayerProfile looks up the Mojang profile of a player by name or UUID.
+// A name returns the account that holds it now.
+func (sm *ServerManager) PlayerProfile(ctx context.Context, player string) (*mojang.Profile, error) {
+	if !playerName.MatchString(player) && !mojang.IsUUID(player) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPlayer, player)
+	}
+	return sm.profiles.Lookup(ctx, player)
+}
+
+// ListPlayerProfiles returns the profiles of the players online on a
+// server. Offline mode servers get the UUIDs they assign themselves, and
+// players Mojang cannot resolve are returned by name only.
+func (sm *ServerManager) ListPlayerProfiles(ctx context.Context, id uint, userID uint) ([]mojang.Profile, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionConsole)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	offline := server.IsOfflineMode(serverModel.Path)
+
+	profiles := []mojang.Profile{}
+	for _, name := range srv.OnlinePlayers() {
+		if offline {
+			profiles = append(profiles, *mojang.OfflineProfile(name))
+			continue
+		}
+		profile, err := sm.profiles.ByName(ctx, name)
+		if err != nil {
+			slog.DebugContext(ctx, "Failed to look up player", "player", name, "error", err)
+			profile = &mojang.Profile{Name: name}
+		}
+		profiles = append(profiles, *profile)
+	}
+	return profiles, nil
+}
+
+// Ban is an entry of the banned-players.json of a server
+type Ban struct {
+	mojang.Profile
+	// BannedAs is the name the player was banned under, set when the
+	// account has been renamed since
+	BannedAs string `json:"banned_as,omitempty" example:"Griefer"`
+	Created  string `json:"created" example:"2024-11-02 18:04:11 +0000"`
+	Source   string `json:"source" example:"Server"`
+	Expires  string `json:"expires" example:"forever"`
+	Reason   string `json:"reason" example:"Banned by an operator."`
+}
+
+// ListBans returns the players banned on a server with their current
+// names and skins. The server writes banned-players.json with the names
+// players had when banned, which go stale when they rename.
+func (sm *ServerManager) ListBans(ctx context.Context, id uint, userID uint) ([]Ban, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionConsole)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	// The server writes the file, so it may be a link planted to read others
+	data, err := utils.ReadFileIn(serverModel.Path, "banned-players.json")
+	if os.IsNotExist(err) {
+		return []Ban{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ban list: %w", err)
+	}
+	var entries []struct {
+		UUID    string `json:"uuid"`
+		Name    string `json:"name"`
+		Created string `json:"created"`
+		Source  string `json:"source"`
+		Expires string `json:"expires"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse ban list: %w", err)
+	}
+
+	// Offline mode UUIDs are unknown to Mojang
+	resolve := !server.IsOfflineMode(serverModel.Path)
+	bans := make([]Ban, 0, len(entries))
+	for _, entry := range entries {
+		ban := Ban{
+			Profile: mojang.Profile{UUID: entry.UUID, Name: entry.Name},
+			Created: entry.Created,
+			Source:  entry.Source,
+			Expires: entry.Expires,
+			Reason:  entry.Reason,
+		}
+		if resolve {
+			profile, err := sm.profiles.ByUUID(ctx, entry.UUID)
+			switch {
+			case err == nil:
+				ban.Profile = *profile
+				if !strings.EqualFold(profile.Name, entry.Name) {
+					ban.BannedAs = entry.Name
+				}
+			case errors.Is(err, mojang.ErrRateLimited):
+				// The rest would be refused too
+				resolve = false
+			default:
+				slog.DebugContext(ctx, "Failed to look up banned player", "uuid", entry.UUID, "error", err)
+			}
+		}
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
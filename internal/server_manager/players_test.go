@@ -0,0 +1,40 @@
+package server_manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestListBansIgnoresPlantedLinks(t *testing.T) {
+	sm := newTestManager(t)
+	owner := createTestUser(t, sm, "alice", model.RoleOperator)
+	id := createTestServer(t, sm, owner, "survival")
+	serverModel, _, err := sm.ownedServer(id, owner.ID, model.PermissionView)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bans, err := sm.ListBans(context.Background(), id, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans) != 0 {
+		t.Errorf("bans without a ban list: %v", bans)
+	}
+
+	// A file of another server that happens to parse as a ban list
+	outside := filepath.Join(t.TempDir(), "banned-players.json")
+	if err := os.WriteFile(outside, []byte(`[{"uuid":"069a79f4-44e9-4726-a5be-fca90e38aaf5","name":"Notch"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(serverModel.Path, "banned-players.json")); err != nil {
+		t.Fatal(err)
+	}
+	if bans, err := sm.ListBans(context.Background(), id, owner.ID); err == nil {
+		t.Errorf("ListBans followed the link: %v", bans)
+	}
+}
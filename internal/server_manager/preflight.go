@@ -0,0 +1,204 @@
+package server_manager
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// Preflight check outcomes. A failed check stops the server from starting.
+const (
+	PreflightOK   = "ok"
+	PreflightWarn = "warn"
+	PreflightFail = "fail"
+)
+
+const (
+	// minFreeDiskBytes is the free space below which a server may not start
+	minFreeDiskBytes = 100 << 20
+	// lowFreeDiskBytes is the free space below which preflight warns
+	lowFreeDiskBytes = 1 << 30
+	// javaVersionTimeout bounds how long "java -version" may take
+	javaVersionTimeout = 10 * time.Second
+)
+
+var (
+	// openjdk version "17.0.2" 2022-01-18, java version "1.8.0_292"
+	javaVersionLine = regexp.MustCompile(`version "(\d+)(?:\.(\d+))?[^"]*"`)
+	// 1.20.4, 1.21, 1.8.9
+	minecraftVersion = regexp.MustCompile(`^1\.(\d+)(?:\.(\d+))?`)
+)
+
+// PreflightCheck is the outcome of one preflight check
+type PreflightCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// PreflightReport lists the checks run before a server starts. Ready is
+// false when any check failed.
+type PreflightReport struct {
+	Ready  bool             `json:"ready"`
+	Checks []PreflightCheck `json:"checks"`
+}
+
+// Preflight checks whether a server can start: its jar, the EULA, the port,
+// the Java version, its mods and the free disk space
+func (sm *ServerManager) Preflight(id uint, userID uint) (*PreflightReport, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	return sm.preflight(serverModel, srv.IsRunning()), nil
+}
+
+func (sm *ServerManager) preflight(serverModel *model.Server, running bool) *PreflightReport {
+	report := &PreflightReport{Ready: true}
+	add := func(name, status, message string) {
+		report.Checks = append(report.Checks, PreflightCheck{Name: name, Status: status, Message: message})
+		if status == PreflightFail {
+			report.Ready = false
+		}
+	}
+
+	var config model.ServerConfig
+	if err := sm.db.Preload("JarFile").Where("server_id = ?", serverModel.ID).First(&config).Error; err != nil {
+		add("config", PreflightFail, "server config could not be loaded")
+		return report
+	}
+
+	if problem := verifyJar(config.JarFile.Path); problem != "" {
+		add("jar", PreflightFail, problem)
+	} else {
+		add("jar", PreflightOK, config.JarFile.Name)
+	}
+
+	if eulaAccepted(serverModel.Path) {
+		add("eula", PreflightOK, "EULA accepted")
+	} else {
+		add("eula", PreflightFail, "set eula=true in eula.txt to accept the Minecraft EULA")
+	}
+
+	port := server.Port(serverModel.Path)
+	switch {
+	case running:
+		add("port", PreflightOK, fmt.Sprintf("port %d is used by this server", port))
+	case portAvailable(port):
+		add("port", PreflightOK, fmt.Sprintf("port %d is free", port))
+	default:
+		add("port", PreflightFail, fmt.Sprintf("port %d is already in use", port))
+	}
+
+	// Containers bring their own Java, which the host cannot inspect
+	if docker, ok := sm.runtime.(*server.DockerRuntime); ok {
+		add("java", PreflightOK, fmt.Sprintf("runs in the %s image", docker.Image))
+	} else {
+		add(javaCheck(config.ExecutableCommand, config.JarFile.Version))
+	}
+
+	if validation, err := validateServerMods(serverModel.Path, config.JarFile); err != nil {
+		add("mods", PreflightWarn, err.Error())
+	} else if len(validation.Warnings) > 0 {
+		add("mods", PreflightWarn, fmt.Sprintf("%d mod problems, the first: %s", len(validation.Warnings), validation.Warnings[0].Message))
+	} else if len(validation.Mods) > 0 {
+		add("mods", PreflightOK, fmt.Sprintf("%d mods", len(validation.Mods)))
+	}
+
+	if free, err := utils.FreeDiskSpace(serverModel.Path); err != nil {
+		add("disk", PreflightWarn, fmt.Sprintf("free disk space unknown: %v", err))
+	} else if free < minFreeDiskBytes {
+		add("disk", PreflightFail, fmt.Sprintf("only %d MB free", free>>20))
+	} else if free < lowFreeDiskBytes {
+		add("disk", PreflightWarn, fmt.Sprintf("only %d MB free", free>>20))
+	} else {
+		add("disk", PreflightOK, fmt.Sprintf("%d MB free", free>>20))
+	}
+
+	return report
+}
+
+// javaCheck runs the executable of command with -version and compares the
+// result to the Java release the Minecraft version needs
+func javaCheck(command, mcVersion string) (string, string, string) {
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return "java", PreflightFail, "no executable command configured"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), javaVersionTimeout)
+	defer cancel()
+	// java -version prints to stderr
+	output, err := exec.CommandContext(ctx, parts[0], "-version").CombinedOutput()
+	if err != nil {
+		return "java", PreflightFail, fmt.Sprintf("%s -version failed: %v", parts[0], err)
+	}
+	installed, ok := parseJavaVersion(string(output))
+	if !ok {
+		return "java", PreflightWarn, fmt.Sprintf("could not determine the version of %s", parts[0])
+	}
+
+	required, ok := requiredJavaVersion(mcVersion)
+	if !ok {
+		return "java", PreflightOK, fmt.Sprintf("Java %d; Minecraft version %q unknown", installed, mcVersion)
+	}
+	if installed < required {
+		return "java", PreflightFail, fmt.Sprintf("Minecraft %s needs Java %d or newer, found Java %d", mcVersion, required, installed)
+	}
+	return "java", PreflightOK, fmt.Sprintf("Java %d", installed)
+}
+
+// parseJavaVersion returns the feature release from java -version output,
+// mapping the legacy 1.x scheme to x
+func parseJavaVersion(output string) (int, bool) {
+	match := javaVersionLine.FindStringSubmatch(output)
+	if match == nil {
+		return 0, false
+	}
+	major, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	if major == 1 && match[2] != "" {
+		major, err = strconv.Atoi(match[2])
+		if err != nil {
+			return 0, false
+		}
+	}
+	return major, true
+}
+
+// requiredJavaVersion returns the minimum Java release of a Minecraft
+// release version
+func requiredJavaVersion(mcVersion string) (int, bool) {
+	match := minecraftVersion.FindStringSubmatch(mcVersion)
+	if match == nil {
+		return 0, false
+	}
+	minor, _ := strconv.Atoi(match[1])
+	patch := 0
+	if match[2] != "" {
+		patch, _ = strconv.Atoi(match[2])
+	}
+	switch {
+	case minor > 20 || minor == 20 && patch >= 5:
+		return 21, true
+	case minor >= 18:
+		return 17, true
+	case minor == 17:
+		return 16, true
+	default:
+		return 8, true
+	}
+}
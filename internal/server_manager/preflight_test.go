@@ -0,0 +1,42 @@
+package server_manager
+
+import "testing"
+
+func TestParseJavaVersion(t *testing.T) {
+	cases := map[string]int{
+		`openjdk version "17.0.2" 2022-01-18`:        17,
+		`java version "1.8.0_292"`:                   8,
+		`openjdk version "21" 2023-09-19`:            21,
+		`openjdk version "11.0.20.1" 2023-08-24 LTS`: 11,
+	}
+	for output, want := range cases {
+		got, ok := parseJavaVersion(output)
+		if !ok || got != want {
+			t.Errorf("parseJavaVersion(%q) = %d, %v; want %d", output, got, ok, want)
+		}
+	}
+	if _, ok := parseJavaVersion("command not found"); ok {
+		t.Error("expected no version for unrelated output")
+	}
+}
+
+func TestRequiredJavaVersion(t *testing.T) {
+	cases := map[string]int{
+		"1.8.9":  8,
+		"1.16.5": 8,
+		"1.17.1": 16,
+		"1.18":   17,
+		"1.20.4": 17,
+		"1.20.5": 21,
+		"1.21.1": 21,
+	}
+	for version, want := range cases {
+		got, ok := requiredJavaVersion(version)
+		if !ok || got != want {
+			t.Errorf("requiredJavaVersion(%q) = %d, %v; want %d", version, got, ok, want)
+		}
+	}
+	if _, ok := requiredJavaVersion("imported"); ok {
+		t.Error("expected no requirement for an unknown version")
+	}
+}
@@ -0,0 +1,253 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+	"gorm.io/gorm"
+)
+
+const (
+	// pregenMonitorInterval is how often progress is recorded and tasks are
+	// throttled
+	pregenMonitorInterval = 30 * time.Second
+	// pregenHistory is how many tasks ListPregenTasks returns
+	pregenHistory = 20
+)
+
+var (
+	// ErrServerNotRunning is returned for operations that need a running server
+	ErrServerNotRunning = errors.New("server must be running")
+	// ErrNoActivePregen is returned when a server has no unfinished
+	// pre-generation task
+	ErrNoActivePregen = errors.New("server has no active pre-generation task")
+)
+
+// PregenOptions describes a pre-generation task to start
+type PregenOptions struct {
+	// World is the world to generate, the server's level-name if empty
+	World   string
+	CenterX int
+	CenterZ int
+	// Radius is the distance from the center in blocks
+	Radius          int
+	PauseForPlayers bool
+}
+
+// StartPregen starts a Chunky task generating the chunks around a center.
+// A server runs one task at a time.
+func (sm *ServerManager) StartPregen(id uint, userID uint, opts PregenOptions) (*model.PregenTask, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionConsole)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	if !srv.IsRunning() {
+		return nil, ErrServerNotRunning
+	}
+	if serverModel.NodeID == nil && !server.ChunkyInstalled(serverModel.Path) {
+		return nil, fmt.Errorf("install the Chunky plugin or mod to pre-generate worlds")
+	}
+	if opts.Radius <= 0 {
+		return nil, fmt.Errorf("radius must be positive")
+	}
+	if _, err := sm.activePregen(id); err == nil {
+		return nil, fmt.Errorf("a pre-generation task is already active; cancel it first")
+	} else if !errors.Is(err, ErrNoActivePregen) {
+		return nil, err
+	}
+	if opts.World == "" {
+		opts.World = levelName(serverModel.Path)
+	}
+
+	task := &model.PregenTask{
+		ServerID:        id,
+		World:           opts.World,
+		CenterX:         opts.CenterX,
+		CenterZ:         opts.CenterZ,
+		Radius:          opts.Radius,
+		PauseForPlayers: opts.PauseForPlayers,
+		State:           model.PregenRunning,
+	}
+	for _, command := range []string{
+		"chunky world " + task.World,
+		"chunky center " + strconv.Itoa(task.CenterX) + " " + strconv.Itoa(task.CenterZ),
+		"chunky radius " + strconv.Itoa(task.Radius),
+		"chunky start",
+	} {
+		if err := srv.SendCommand(command); err != nil {
+			return nil, fmt.Errorf("failed to start pre-generation: %w", err)
+		}
+	}
+	if err := sm.db.Create(task).Error; err != nil {
+		return nil, fmt.Errorf("failed to record pre-generation task: %w", err)
+	}
+	slog.Info("Started pre-generation", "server_id", id, "world", task.World, "radius", task.Radius)
+	return task, nil
+}
+
+// PausePregen pauses the active pre-generation task of a server
+func (sm *ServerManager) PausePregen(id uint, userID uint) (*model.PregenTask, error) {
+	return sm.controlPregen(id, userID, "pause", model.PregenPaused)
+}
+
+// ResumePregen continues the paused pre-generation task of a server. It is
+// throttled again while players are online if it pauses for players.
+func (sm *ServerManager) ResumePregen(id uint, userID uint) (*model.PregenTask, error) {
+	return sm.controlPregen(id, userID, "continue", model.PregenRunning)
+}
+
+// CancelPregen stops the active pre-generation task of a server for good
+func (sm *ServerManager) CancelPregen(id uint, userID uint) (*model.PregenTask, error) {
+	return sm.controlPregen(id, userID, "cancel", model.PregenCancelled)
+}
+
+// controlPregen sends a Chunky command for the active task of a server and
+// records its new state
+func (sm *ServerManager) controlPregen(id uint, userID uint, command, state string) (*model.PregenTask, error) {
+	_, srv, err := sm.ownedServer(id, userID, model.PermissionConsole)
+	if err != nil {
+		return nil, err
+	}
+	task, err := sm.activePregen(id)
+	if err != nil {
+		return nil, err
+	}
+	if srv.IsRunning() {
+		if err := srv.SendCommand("chunky " + command + " " + task.World); err != nil {
+			return nil, fmt.Errorf("failed to %s pre-generation: %w", command, err)
+		}
+	} else if state == model.PregenRunning {
+		return nil, ErrServerNotRunning
+	}
+	if err := sm.setPregenState(task, state); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// ListPregenTasks returns the most recent pre-generation tasks of a server
+func (sm *ServerManager) ListPregenTasks(id uint, userID uint) ([]model.PregenTask, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionConsole); err != nil {
+		return nil, err
+	}
+	tasks := []model.PregenTask{}
+	if err := sm.db.Where("server_id = ?", id).Order("id DESC").Limit(pregenHistory).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch pre-generation tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// activePregen returns the unfinished task of a server
+func (sm *ServerManager) activePregen(id uint) (*model.PregenTask, error) {
+	var task model.PregenTask
+	err := sm.db.Where("server_id = ? AND state IN ?", id, []string{model.PregenRunning, model.PregenPaused, model.PregenThrottled}).
+		Order("id DESC").First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoActivePregen
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pre-generation task: %w", err)
+	}
+	return &task, nil
+}
+
+func (sm *ServerManager) setPregenState(task *model.PregenTask, state string) error {
+	updates := map[string]interface{}{"state": state}
+	if state == model.PregenCompleted {
+		now := time.Now()
+		task.CompletedAt = &now
+		updates["completed_at"] = now
+	}
+	task.State = state
+	if err := sm.db.Model(task).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update pre-generation task: %w", err)
+	}
+	return nil
+}
+
+// StartPregenMonitor records the progress of running pre-generation tasks
+// and throttles them while players are online, until stop is closed
+func (sm *ServerManager) StartPregenMonitor(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(pregenMonitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sm.monitorPregen()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// monitorPregen updates every running or throttled task. Tasks of servers
+// that stopped are paused; Chunky saves their progress and continues them
+// when resumed.
+func (sm *ServerManager) monitorPregen() {
+	var tasks []model.PregenTask
+	if err := sm.db.Where("state IN ?", []string{model.PregenRunning, model.PregenThrottled}).Find(&tasks).Error; err != nil {
+		slog.Error("Failed to fetch pre-generation tasks", "error", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		sm.mutex.RLock()
+		srv, ok := sm.servers[task.ServerID]
+		sm.mutex.RUnlock()
+
+		var err error
+		if ok && srv.IsRunning() {
+			err = sm.updatePregen(task, srv)
+		} else {
+			err = sm.setPregenState(task, model.PregenPaused)
+		}
+		if err != nil {
+			slog.Error("Failed to update pre-generation task", "task_id", task.ID, "server_id", task.ServerID, "error", err)
+		}
+	}
+}
+
+// updatePregen records the reported progress of a task on a running server
+// and pauses or continues it for players
+func (sm *ServerManager) updatePregen(task *model.PregenTask, srv *server.Server) error {
+	if progress, ok := srv.PregenProgress(task.World); ok && progress.At.After(task.CreatedAt) {
+		task.Chunks, task.Percent = progress.Chunks, progress.Percent
+		if err := sm.db.Model(task).Updates(map[string]interface{}{"chunks": task.Chunks, "percent": task.Percent}).Error; err != nil {
+			return err
+		}
+		if progress.Finished {
+			slog.Info("Pre-generation completed", "server_id", task.ServerID, "world", task.World, "chunks", task.Chunks)
+			return sm.setPregenState(task, model.PregenCompleted)
+		}
+	}
+	if !task.PauseForPlayers {
+		return nil
+	}
+
+	players := srv.PlayerCount()
+	switch {
+	case players > 0 && task.State == model.PregenRunning:
+		if err := srv.SendCommand("chunky pause " + task.World); err != nil {
+			return err
+		}
+		slog.Info("Throttling pre-generation while players are online", "server_id", task.ServerID, "players", players)
+		return sm.setPregenState(task, model.PregenThrottled)
+	case players == 0 && task.State == model.PregenThrottled:
+		if err := srv.SendCommand("chunky continue " + task.World); err != nil {
+			return err
+		}
+		return sm.setPregenState(task, model.PregenRunning)
+	}
+	return nil
+}
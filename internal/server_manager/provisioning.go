@@ -0,0 +1,127 @@
+package server_manager
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// ProvisioningWarnings inspects a freshly provisioned server and reports
+// problems that will not stop creation but are likely to break the first start.
+func (sm *ServerManager) ProvisioningWarnings(id uint) []string {
+	var warnings []string
+
+	var serverModel model.Server
+	if err := sm.db.First(&serverModel, id).Error; err != nil {
+		return []string{fmt.Sprintf("server record could not be loaded: %v", err)}
+	}
+
+	var config model.ServerConfig
+	if err := sm.db.Preload("JarFile").Where("server_id = ?", id).First(&config).Error; err != nil {
+		warnings = append(warnings, "server config could not be loaded")
+	} else if warning := verifyJar(config.JarFile.Path); warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	if !eulaAccepted(serverModel.Path) {
+		warnings = append(warnings, "EULA not accepted: set eula=true in eula.txt before starting")
+	}
+
+	if warning := server.OfflineModeWarning(serverModel.Path); warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	port := server.Port(serverModel.Path)
+	if !portAvailable(port) {
+		warnings = append(warnings, fmt.Sprintf("port %d is already in use", port))
+	}
+
+	return warnings
+}
+
+// verifyJar checks that the jar exists and looks like a zip archive
+func verifyJar(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Sprintf("jar unverified: %s could not be opened", filepath.Base(path))
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	if _, err := file.Read(magic); err != nil || !bytes.Equal(magic, []byte("PK\x03\x04")) {
+		return fmt.Sprintf("jar unverified: %s is not a valid jar archive", filepath.Base(path))
+	}
+	return ""
+}
+
+// eulaAccepted reports whether eula.txt in the server directory contains eula=true
+func eulaAccepted(serverPath string) bool {
+	data, err := os.ReadFile(filepath.Join(serverPath, "eula.txt"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), "eula=true") {
+			return true
+		}
+	}
+	return false
+}
+
+// portAvailable reports whether nothing is listening on the TCP port
+func portAvailable(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// AcknowledgeOfflineMode records the owner's explicit consent to run the
+// server with online-mode=false
+func (sm *ServerManager) AcknowledgeOfflineMode(id uint, userID uint, acknowledged bool) error {
+	result := sm.db.Model(&model.Server{}).
+		Scopes(sm.serverControl(userID)).Where("id = ?", id).
+		Update("offline_mode_acknowledged", acknowledged)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update server: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("server %d not found", id)
+	}
+
+	sm.mutex.RLock()
+	srv, exists := sm.servers[id]
+	sm.mutex.RUnlock()
+	if exists {
+		srv.SetOfflineModeAcknowledged(acknowledged)
+	}
+	return nil
+}
+
+// allocatePort returns the lowest port from the default upwards that no
+// other server is configured for and nothing is listening on
+func (sm *ServerManager) allocatePort() (int, error) {
+	var servers []model.Server
+	if err := sm.db.Find(&servers).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch servers: %w", err)
+	}
+	used := make(map[int]bool)
+	for _, srv := range servers {
+		used[server.Port(srv.Path)] = true
+	}
+
+	for port := server.DefaultPort; port <= 65535; port++ {
+		if !used[port] && portAvailable(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port available")
+}
@@ -0,0 +1,221 @@
+package server_manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// ErrNotProxy is returned when backends are attached to a server that does
+// not run a proxy
+var ErrNotProxy = errors.New("server is not a proxy")
+
+// newForwardingSecret returns a random secret shared by a proxy and its
+// backends
+func newForwardingSecret() (string, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate forwarding secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// SetProxyType turns a server into a proxy of proxyType, generating its
+// forwarding secret, or back into a game server if proxyType is empty.
+// A proxy with attached backends cannot change its type.
+func (sm *ServerManager) SetProxyType(id uint, userID uint, proxyType string) (*model.Server, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	if proxyType == serverModel.ProxyType {
+		return serverModel, nil
+	}
+	if proxyType != "" && proxyType != model.ProxyTypeVelocity && proxyType != model.ProxyTypeBungeeCord {
+		return nil, fmt.Errorf("unknown proxy type %q", proxyType)
+	}
+	if serverModel.ProxyID != nil {
+		return nil, fmt.Errorf("server is attached to proxy %d; detach it first", *serverModel.ProxyID)
+	}
+	var backends int64
+	if err := sm.db.Model(&model.Server{}).Where("proxy_id = ?", id).Count(&backends).Error; err != nil {
+		return nil, fmt.Errorf("failed to count backends: %w", err)
+	}
+	if backends > 0 {
+		return nil, fmt.Errorf("proxy has %d attached servers; detach them first", backends)
+	}
+
+	serverModel.ProxyType = proxyType
+	serverModel.ForwardingSecret = ""
+	if proxyType != "" {
+		if serverModel.ForwardingSecret, err = newForwardingSecret(); err != nil {
+			return nil, err
+		}
+		if err := server.ConfigureProxy(serverModel.Path, proxyType, nil, serverModel.ForwardingSecret); err != nil {
+			return nil, fmt.Errorf("failed to configure proxy: %w", err)
+		}
+	}
+	if err := sm.db.Model(serverModel).Select("ProxyType", "ForwardingSecret").Updates(serverModel).Error; err != nil {
+		return nil, fmt.Errorf("failed to update server: %w", err)
+	}
+	slog.Info("Set proxy type", "server_id", id, "proxy_type", proxyType)
+	return serverModel, nil
+}
+
+// ProxyBackends returns the servers attached to a proxy
+func (sm *ServerManager) ProxyBackends(proxyID uint, userID uint) ([]model.Server, error) {
+	proxy, _, err := sm.ownedServer(proxyID, userID, model.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+	if proxy.ProxyType == "" {
+		return nil, ErrNotProxy
+	}
+	return sm.proxyBackends(proxyID)
+}
+
+func (sm *ServerManager) proxyBackends(proxyID uint) ([]model.Server, error) {
+	var backends []model.Server
+	if err := sm.db.Where("proxy_id = ?", proxyID).Order("id").Find(&backends).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch backends: %w", err)
+	}
+	return backends, nil
+}
+
+// AttachToProxy attaches a backend server to a proxy: the proxy lists it
+// and the backend accepts the players the proxy forwards. address is where
+// the proxy reaches the backend, the backend's port on localhost if empty.
+// Both take the change on their next start.
+func (sm *ServerManager) AttachToProxy(proxyID, backendID uint, userID uint, address string) (*model.Server, error) {
+	proxy, _, err := sm.ownedServer(proxyID, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if proxy.ProxyType == "" {
+		return nil, ErrNotProxy
+	}
+	if err := sm.Authorize(backendID, userID, model.PermissionManage); err != nil {
+		return nil, err
+	}
+	backend, srv, err := sm.ownedServer(backendID, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if backend.ProxyType != "" {
+		return nil, fmt.Errorf("server %q is a proxy itself", backend.Name)
+	}
+	if backend.ProxyID != nil && *backend.ProxyID != proxyID {
+		return nil, fmt.Errorf("server %q is attached to proxy %d already", backend.Name, *backend.ProxyID)
+	}
+	if err := checkLocal(backend); err != nil {
+		return nil, err
+	}
+	if address == "" {
+		address = net.JoinHostPort("127.0.0.1", strconv.Itoa(server.Port(backend.Path)))
+	} else if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("invalid backend address %q: %w", address, err)
+	}
+
+	if err := server.EnableProxyForwarding(backend.Path, proxy.ProxyType, proxy.ForwardingSecret); err != nil {
+		return nil, err
+	}
+	// The proxy authenticates players, so offline mode is expected
+	backend.ProxyID = &proxyID
+	backend.ProxyAddress = address
+	backend.OfflineModeAcknowledged = true
+	if err := sm.db.Model(backend).Select("ProxyID", "ProxyAddress", "OfflineModeAcknowledged").Updates(backend).Error; err != nil {
+		return nil, fmt.Errorf("failed to update server: %w", err)
+	}
+	srv.SetOfflineModeAcknowledged(true)
+
+	if err := sm.syncProxy(proxy); err != nil {
+		return nil, err
+	}
+	slog.Info("Attached server to proxy", "server_id", backendID, "proxy_id", proxyID, "address", address)
+	return backend, nil
+}
+
+// DetachFromProxy removes a backend from its proxy and lets it
+// authenticate players itself again
+func (sm *ServerManager) DetachFromProxy(proxyID, backendID uint, userID uint) error {
+	proxy, _, err := sm.ownedServer(proxyID, userID, model.PermissionManage)
+	if err != nil {
+		return err
+	}
+	var backend model.Server
+	if err := sm.db.Where("id = ? AND proxy_id = ?", backendID, proxyID).First(&backend).Error; err != nil {
+		return fmt.Errorf("server is not attached to this proxy: %w", err)
+	}
+
+	if err := server.DisableProxyForwarding(backend.Path); err != nil {
+		return fmt.Errorf("failed to restore online mode: %w", err)
+	}
+	if err := sm.db.Model(&backend).Select("ProxyID", "ProxyAddress").
+		Updates(map[string]interface{}{"proxy_id": nil, "proxy_address": ""}).Error; err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+	if err := sm.syncProxy(proxy); err != nil {
+		return err
+	}
+	slog.Info("Detached server from proxy", "server_id", backendID, "proxy_id", proxyID)
+	return nil
+}
+
+// RotateForwardingSecret replaces the secret of a proxy on the proxy and
+// all its backends. They need a restart to use it.
+func (sm *ServerManager) RotateForwardingSecret(proxyID uint, userID uint) error {
+	proxy, _, err := sm.ownedServer(proxyID, userID, model.PermissionManage)
+	if err != nil {
+		return err
+	}
+	if proxy.ProxyType == "" {
+		return ErrNotProxy
+	}
+	if proxy.ForwardingSecret, err = newForwardingSecret(); err != nil {
+		return err
+	}
+	if err := sm.db.Model(proxy).Update("forwarding_secret", proxy.ForwardingSecret).Error; err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+
+	backends, err := sm.proxyBackends(proxyID)
+	if err != nil {
+		return err
+	}
+	for _, backend := range backends {
+		if err := server.EnableProxyForwarding(backend.Path, proxy.ProxyType, proxy.ForwardingSecret); err != nil {
+			return fmt.Errorf("failed to update server %q: %w", backend.Name, err)
+		}
+	}
+	if err := sm.syncProxy(proxy); err != nil {
+		return err
+	}
+	slog.Info("Rotated forwarding secret", "proxy_id", proxyID)
+	return nil
+}
+
+// syncProxy writes the current backends of a proxy into its configuration
+func (sm *ServerManager) syncProxy(proxy *model.Server) error {
+	backends, err := sm.proxyBackends(proxy.ID)
+	if err != nil {
+		return err
+	}
+	entries := make([]server.ProxyBackend, 0, len(backends))
+	for _, backend := range backends {
+		entries = append(entries, server.ProxyBackend{Name: backend.Name, Address: backend.ProxyAddress})
+	}
+	if err := server.ConfigureProxy(proxy.Path, proxy.ProxyType, entries, proxy.ForwardingSecret); err != nil {
+		return fmt.Errorf("failed to configure proxy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotPublic is returned when a request to a URL users gave would
+// connect to a loopback, private or link-local address
+var ErrAddressNotPublic = errors.New("address is not public")
+
+// publicClient returns a client that refuses to connect to loopback,
+// private and link-local addresses, including after redirects and whatever
+// the host name resolves to, so URLs users give for imports, webhooks and
+// alerts cannot reach services on the manager's network.
+func publicClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 30 * time.Second,
+				Control: dialPublicOnly,
+			}).DialContext,
+		},
+	}
+}
+
+// dialPublicOnly is a net.Dialer Control refusing addresses that are not
+// public
+func dialPublicOnly(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrAddressNotPublic, host)
+	}
+	return nil
+}
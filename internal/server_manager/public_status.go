@@ -0,0 +1,97 @@
+package server_manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// PublicStatus is what the public status endpoint tells anyone about a
+// server. It must never include anything beyond what a Minecraft client
+// sees in its server list.
+type PublicStatus struct {
+	Online        bool   `json:"online"`
+	MOTD          string `json:"motd" example:"A Minecraft Server"`
+	Version       string `json:"version" example:"1.20.1"`
+	PlayersOnline int    `json:"players_online" example:"3"`
+	MaxPlayers    int    `json:"max_players" example:"20"`
+	UptimeSeconds int64  `json:"uptime_seconds" example:"3600"`
+}
+
+// EnablePublicStatus opts a server into the public status endpoint and
+// returns the token that identifies it there. Enabling it again returns
+// the existing token.
+func (sm *ServerManager) EnablePublicStatus(id uint, userID uint) (string, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return "", err
+	}
+	if serverModel.PublicStatusToken != nil {
+		return *serverModel.PublicStatusToken, nil
+	}
+
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(secret)
+	if err := sm.db.Model(serverModel).Update("public_status_token", token).Error; err != nil {
+		return "", fmt.Errorf("failed to enable public status: %w", err)
+	}
+	slog.Info("Enabled public status", "server_id", id)
+	return token, nil
+}
+
+// DisablePublicStatus takes a server off the public status endpoint. Its
+// token stops working; enabling it again issues a new one.
+func (sm *ServerManager) DisablePublicStatus(id uint, userID uint) error {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return err
+	}
+	if err := sm.db.Model(serverModel).Update("public_status_token", nil).Error; err != nil {
+		return fmt.Errorf("failed to disable public status: %w", err)
+	}
+	slog.Info("Disabled public status", "server_id", id)
+	return nil
+}
+
+// GetPublicStatus returns the public status of the server a token was
+// issued for
+func (sm *ServerManager) GetPublicStatus(token string) (*PublicStatus, error) {
+	var serverModel model.Server
+	if err := sm.db.Where("public_status_token = ?", token).First(&serverModel).Error; err != nil {
+		return nil, err
+	}
+
+	status := &PublicStatus{MOTD: "A Minecraft Server", MaxPlayers: 20}
+	if config, err := sm.GetServerConfig(serverModel.ID); err == nil {
+		status.Version = config.JarFile.Version
+	}
+	if serverModel.ArchivedAt == nil {
+		if properties, err := utils.ReadProperties(filepath.Join(serverModel.Path, "server.properties")); err == nil {
+			if motd, ok := properties["motd"]; ok {
+				status.MOTD = motd
+			}
+			if maxPlayers, err := strconv.Atoi(properties["max-players"]); err == nil {
+				status.MaxPlayers = maxPlayers
+			}
+		}
+	}
+
+	sm.mutex.RLock()
+	srv, exists := sm.servers[serverModel.ID]
+	sm.mutex.RUnlock()
+	if exists && serverModel.Status == model.ServerStatusRunning {
+		status.Online = true
+		status.PlayersOnline = srv.PlayerCount()
+		status.UptimeSeconds = int64(srv.Uptime().Seconds())
+	}
+	return status, nil
+}
@@ -0,0 +1,108 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// ErrQuotaExceeded is returned when a change would take a user past a quota
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaRequest is the additional consumption a change needs
+type QuotaRequest struct {
+	Servers   int64
+	MemoryMB  int64
+	DiskBytes int64
+}
+
+// SetDefaultLimits sets the limits of users without quotas of their own
+func (sm *ServerManager) SetDefaultLimits(limits config.Limits) {
+	sm.limitsMutex.Lock()
+	defer sm.limitsMutex.Unlock()
+	sm.limits = limits
+}
+
+// UserLimits returns the limits of a user: their own quotas where set, the
+// configured defaults otherwise
+func (sm *ServerManager) UserLimits(userID uint) (config.Limits, error) {
+	var user model.User
+	if err := sm.db.First(&user, userID).Error; err != nil {
+		return config.Limits{}, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	sm.limitsMutex.RLock()
+	limits := sm.limits
+	sm.limitsMutex.RUnlock()
+	if user.MaxServers != nil {
+		limits.MaxServers = *user.MaxServers
+	}
+	if user.MaxMemoryMB != nil {
+		limits.MaxMemoryMB = *user.MaxMemoryMB
+	}
+	if user.MaxDiskMB != nil {
+		limits.MaxDiskMB = *user.MaxDiskMB
+	}
+	return limits, nil
+}
+
+// Quota returns the usage of a user against their limits. API calls are
+// counted by the HTTP layer and left for the caller.
+func (sm *ServerManager) Quota(userID uint) (*model.Usage, error) {
+	usage, err := sm.Usage(userID)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := sm.UserLimits(userID)
+	if err != nil {
+		return nil, err
+	}
+	usage.Servers.Limit = limits.MaxServers
+	usage.MemoryMB.Limit = limits.MaxMemoryMB
+	usage.DiskBytes.Limit = limits.MaxDiskMB * 1024 * 1024
+	usage.Backups.Limit = limits.MaxBackups
+	usage.APICalls.Limit = limits.APICallsPerDay
+	return usage, nil
+}
+
+// CheckQuota fails with ErrQuotaExceeded when a user cannot take on req.
+// A new server needs a free server slot; memory and disk must stay within
+// their limits afterwards, so a user already over them is refused too.
+func (sm *ServerManager) CheckQuota(userID uint, req QuotaRequest) error {
+	usage, err := sm.Quota(userID)
+	if err != nil {
+		return err
+	}
+	if req.Servers > 0 && exceeds(usage.Servers, req.Servers) {
+		return fmt.Errorf("%w: %d of %d servers in use", ErrQuotaExceeded, usage.Servers.Used, usage.Servers.Limit)
+	}
+	if exceeds(usage.MemoryMB, req.MemoryMB) {
+		return fmt.Errorf("%w: %d MB of %d MB memory allocated, %d MB more requested",
+			ErrQuotaExceeded, usage.MemoryMB.Used, usage.MemoryMB.Limit, req.MemoryMB)
+	}
+	if exceeds(usage.DiskBytes, req.DiskBytes) {
+		return fmt.Errorf("%w: %d MB of %d MB disk in use, %d MB more requested",
+			ErrQuotaExceeded, usage.DiskBytes.Used>>20, usage.DiskBytes.Limit>>20, req.DiskBytes>>20)
+	}
+	return nil
+}
+
+// checkServerQuota checks the quota of the owner of a server for req
+func (sm *ServerManager) checkServerQuota(id uint, req QuotaRequest) error {
+	var serverModel model.Server
+	if err := sm.db.Select("id", "user_id").First(&serverModel, id).Error; err != nil {
+		return fmt.Errorf("server not found: %w", err)
+	}
+	return sm.CheckQuota(serverModel.UserID, req)
+}
+
+// CheckServerUpload checks that the owner of a server has room for an upload of size bytes
+func (sm *ServerManager) CheckServerUpload(id uint, size int64) error {
+	return sm.checkServerQuota(id, QuotaRequest{DiskBytes: size})
+}
+
+// exceeds reports whether adding more to a meter takes it past its limit
+func exceeds(meter model.UsageMeter, more int64) bool {
+	return meter.Limit > 0 && meter.Used+more > meter.Limit
+}
@@ -0,0 +1,26 @@
+package server_manager
+
+import (
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestExceeds(t *testing.T) {
+	cases := []struct {
+		meter model.UsageMeter
+		more  int64
+		want  bool
+	}{
+		{model.UsageMeter{Used: 5, Limit: 0}, 100, false},
+		{model.UsageMeter{Used: 2, Limit: 3}, 1, false},
+		{model.UsageMeter{Used: 3, Limit: 3}, 1, true},
+		{model.UsageMeter{Used: 3, Limit: 3}, 0, false},
+		{model.UsageMeter{Used: 4, Limit: 3}, 0, true},
+	}
+	for _, c := range cases {
+		if got := exceeds(c.meter, c.more); got != c.want {
+			t.Errorf("exceeds(%+v, %d) = %v, want %v", c.meter, c.more, got, c.want)
+		}
+	}
+}
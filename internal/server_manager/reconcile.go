@@ -0,0 +1,137 @@
+package server_manager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// ServersDir returns the directory new servers are created in
+func ServersDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "game_servers"), nil
+}
+
+// ReconcileOptions selects the repairs Reconcile performs. Without any, it
+// only reports.
+type ReconcileOptions struct {
+	// AdoptOrphans imports orphan directories holding a server jar as
+	// servers owned by AdoptUserID
+	AdoptOrphans bool
+	AdoptUserID  uint
+	// DeleteMissing soft deletes servers whose directory is gone
+	DeleteMissing bool
+}
+
+// OrphanDirectory is a directory in the servers directory no server uses
+type OrphanDirectory struct {
+	Path      string `json:"path"`
+	AdoptedID uint   `json:"adopted_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// MissingServer is a server whose directory does not exist
+type MissingServer struct {
+	ServerID uint   `json:"server_id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	UserID   uint   `json:"user_id"`
+	Deleted  bool   `json:"deleted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReconcileReport lists the differences between the database and the disk
+type ReconcileReport struct {
+	ServersDir        string            `json:"servers_dir"`
+	OrphanDirectories []OrphanDirectory `json:"orphan_directories"`
+	MissingServers    []MissingServer   `json:"missing_servers"`
+}
+
+// Reconcile compares the server records against the directories in
+// serversDir. Directories of deleted servers that have not been purged are
+// not orphans. Orphan directories are never removed; they can only be
+// adopted.
+func (sm *ServerManager) Reconcile(ctx context.Context, serversDir string, opts ReconcileOptions) (*ReconcileReport, error) {
+	var servers []model.Server
+	if err := sm.db.Unscoped().Find(&servers).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch servers: %w", err)
+	}
+
+	report := &ReconcileReport{
+		ServersDir:        serversDir,
+		OrphanDirectories: []OrphanDirectory{},
+		MissingServers:    []MissingServer{},
+	}
+
+	known := make(map[string]bool, len(servers))
+	for _, serverModel := range servers {
+		known[filepath.Clean(serverModel.Path)] = true
+		// Archived servers have no directory until they are thawed
+		if serverModel.DeletedAt.Valid || serverModel.ArchivedAt != nil {
+			continue
+		}
+		if _, err := os.Stat(serverModel.Path); !os.IsNotExist(err) {
+			continue
+		}
+		missing := MissingServer{
+			ServerID: serverModel.ID,
+			Name:     serverModel.Name,
+			Path:     serverModel.Path,
+			UserID:   serverModel.UserID,
+		}
+		if opts.DeleteMissing {
+			if err := sm.DeleteServer(ctx, serverModel.ID, serverModel.UserID, false); err != nil {
+				missing.Error = err.Error()
+			} else {
+				missing.Deleted = true
+			}
+		}
+		report.MissingServers = append(report.MissingServers, missing)
+	}
+
+	entries, err := os.ReadDir(serversDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", serversDir, err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(serversDir, entry.Name())
+		if !entry.IsDir() || known[path] {
+			continue
+		}
+		orphan := OrphanDirectory{Path: path}
+		if opts.AdoptOrphans {
+			id, err := sm.ImportServer(ImportOptions{Name: entry.Name(), Path: path}, []string{serversDir}, opts.AdoptUserID)
+			if err != nil {
+				orphan.Error = err.Error()
+			} else {
+				orphan.AdoptedID = id
+			}
+		}
+		report.OrphanDirectories = append(report.OrphanDirectories, orphan)
+	}
+
+	return report, nil
+}
+
+// LogReconcileReport reports differences between the database and the disk
+// without repairing anything. It is meant to run on startup.
+func (sm *ServerManager) LogReconcileReport(serversDir string) {
+	report, err := sm.Reconcile(context.Background(), serversDir, ReconcileOptions{})
+	if err != nil {
+		slog.Error("Failed to reconcile servers", "dir", serversDir, "error", err)
+		return
+	}
+	for _, orphan := range report.OrphanDirectories {
+		slog.Warn("Reconcile: directory does not belong to any server", "path", orphan.Path)
+	}
+	for _, missing := range report.MissingServers {
+		slog.Warn("Reconcile: server directory is missing", "path", missing.Path, "server_id", missing.ServerID, "name", missing.Name)
+	}
+}
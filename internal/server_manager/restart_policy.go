@@ -0,0 +1,50 @@
+package server_manager
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// restartWindow is how far back crash restarts count against the restart
+// limit of a server
+const restartWindow = 10 * time.Minute
+
+// restartAfterCrash starts a crashed server again if its restart policy asks
+// for it and it has not used up its restarts within the restart window.
+// Servers in the middle of a jar swap or mod pack upgrade are left to its
+// rollback.
+func (sm *ServerManager) restartAfterCrash(id uint) {
+	config, err := sm.getServerConfig(id)
+	if err != nil || config.RestartPolicy != model.RestartPolicyOnFailure {
+		return
+	}
+	if sm.pendingJarSwap(id) != nil || sm.pendingModPackUpgrade(id) != nil {
+		return
+	}
+
+	now := time.Now()
+	recent := sm.crashRestarts[id][:0]
+	for _, at := range sm.crashRestarts[id] {
+		if now.Sub(at) < restartWindow {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= config.MaxRestarts {
+		sm.crashRestarts[id] = recent
+		slog.Warn("Not restarting crashed server; restart limit reached", "server_id", id, "restarts", len(recent), "window", restartWindow)
+		return
+	}
+	sm.crashRestarts[id] = append(recent, now)
+
+	var serverModel model.Server
+	if err := sm.db.Where("id = ?", id).First(&serverModel).Error; err != nil {
+		return
+	}
+	slog.Info("Restarting crashed server", "server_id", id, "attempt", len(recent)+1, "max_restarts", config.MaxRestarts)
+	if err := sm.StartServer(context.Background(), id, serverModel.UserID); err != nil {
+		slog.Error("Failed to restart crashed server", "server_id", id, "error", err)
+	}
+}
@@ -0,0 +1,230 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// ErrInvalidServerConfig is returned for server config documents that fail validation
+var ErrInvalidServerConfig = errors.New("invalid server config")
+
+const (
+	// maxRestarts caps the restart limit of a server config
+	maxRestarts = 100
+	// maxPlaceholderMOTD caps the length of a placeholder MOTD
+	maxPlaceholderMOTD = 256
+	// maxIdleShutdownMinutes caps the idle shutdown delay at a day
+	maxIdleShutdownMinutes = 24 * 60
+)
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// MemorySettings are the heap sizes of a server, read from and written to
+// the -Xms and -Xmx flags of its executable command
+type MemorySettings struct {
+	MinMB int64 `json:"min_mb" example:"1024"`
+	MaxMB int64 `json:"max_mb" example:"4096"`
+}
+
+// PlaceholderSettings decide what answers on the port of a stopped server
+type PlaceholderSettings struct {
+	Enabled bool `json:"enabled"`
+	// MOTD is shown in the server list; empty for the default
+	MOTD string `json:"motd" example:"§7Server is offline"`
+	// StartOnJoin starts the server when a player tries to join
+	StartOnJoin bool `json:"start_on_join"`
+	// IdleShutdownMinutes stops the server after that long without
+	// players, so it only runs on demand; zero keeps it running
+	IdleShutdownMinutes int `json:"idle_shutdown_minutes" example:"15"`
+}
+
+// ServerConfigDocument is everything that decides how a server is started
+type ServerConfigDocument struct {
+	ExecutableCommand string `json:"executable_command" example:"java -Xmx4096M -jar server.jar nogui"`
+	JarFileID         uint   `json:"jar_file_id"`
+	// ModPackID is nil for servers without a mod pack
+	ModPackID     *uint             `json:"mod_pack_id"`
+	Env           map[string]string `json:"env"`
+	Memory        MemorySettings    `json:"memory"`
+	RestartPolicy string            `json:"restart_policy" example:"on-failure"`
+	MaxRestarts   int               `json:"max_restarts" example:"3"`
+	// Placeholder only applies to servers on this host
+	Placeholder PlaceholderSettings `json:"placeholder"`
+}
+
+// configDocument builds the document of a stored server config
+func configDocument(config *model.ServerConfig) *ServerConfigDocument {
+	env := config.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	policy := config.RestartPolicy
+	if policy == "" {
+		policy = model.RestartPolicyNever
+	}
+	return &ServerConfigDocument{
+		ExecutableCommand: config.ExecutableCommand,
+		JarFileID:         config.JarFileID,
+		ModPackID:         config.ModPackID,
+		Env:               env,
+		Memory: MemorySettings{
+			MinMB: server.CommandMinMemoryMB(config.ExecutableCommand),
+			MaxMB: server.CommandMemoryMB(config.ExecutableCommand),
+		},
+		RestartPolicy: policy,
+		MaxRestarts:   config.MaxRestarts,
+		Placeholder: PlaceholderSettings{
+			Enabled:             config.Placeholder,
+			MOTD:                config.PlaceholderMOTD,
+			StartOnJoin:         config.StartOnJoin,
+			IdleShutdownMinutes: config.IdleShutdownMinutes,
+		},
+	}
+}
+
+// GetServerConfigDocument returns the config of a server userID can access
+func (sm *ServerManager) GetServerConfigDocument(id uint, userID uint) (*ServerConfigDocument, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionView); err != nil {
+		return nil, err
+	}
+	config, err := sm.getServerConfig(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server config: %w", err)
+	}
+	return configDocument(config), nil
+}
+
+// UpdateServerConfig replaces the config of a server with doc. Non-zero
+// memory sizes are written into the executable command. Jar and mod pack
+// changes require the server to be stopped; placeholder settings apply right
+// away and everything else on the next start.
+func (sm *ServerManager) UpdateServerConfig(id uint, userID uint, doc ServerConfigDocument) (*ServerConfigDocument, error) {
+	if _, _, err := sm.ownedServer(id, userID, model.PermissionManage); err != nil {
+		return nil, err
+	}
+	if err := validateConfigDocument(&doc); err != nil {
+		return nil, err
+	}
+	config, err := sm.getServerConfig(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server config: %w", err)
+	}
+	if _, err := sm.GetJarFileByID(doc.JarFileID); err != nil {
+		return nil, fmt.Errorf("%w: jar file %d not found", ErrInvalidServerConfig, doc.JarFileID)
+	}
+	if doc.ModPackID != nil {
+		if _, err := sm.GetModPackByID(*doc.ModPackID); err != nil {
+			return nil, fmt.Errorf("%w: mod pack %d not found", ErrInvalidServerConfig, *doc.ModPackID)
+		}
+	}
+
+	command := server.SetCommandMemory(doc.ExecutableCommand, doc.Memory.MinMB, doc.Memory.MaxMB)
+	update := ServerUpdate{ExecutableCommand: &command}
+	if doc.JarFileID != config.JarFileID {
+		update.JarFileID = &doc.JarFileID
+	}
+	if !sameModPack(doc.ModPackID, config.ModPackID) {
+		var modPackID uint
+		if doc.ModPackID != nil {
+			modPackID = *doc.ModPackID
+		}
+		update.ModPackID = &modPackID
+	}
+	if _, err := sm.UpdateServer(id, userID, update); err != nil {
+		return nil, err
+	}
+
+	config.Env = doc.Env
+	config.RestartPolicy = doc.RestartPolicy
+	config.MaxRestarts = doc.MaxRestarts
+	config.Placeholder = doc.Placeholder.Enabled
+	config.PlaceholderMOTD = doc.Placeholder.MOTD
+	config.StartOnJoin = doc.Placeholder.StartOnJoin
+	config.IdleShutdownMinutes = doc.Placeholder.IdleShutdownMinutes
+	err = sm.db.Model(config).
+		Select("Env", "RestartPolicy", "MaxRestarts", "Placeholder", "PlaceholderMOTD", "StartOnJoin", "IdleShutdownMinutes").
+		Updates(config).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update server config: %w", err)
+	}
+	sm.mutex.RLock()
+	if srv, exists := sm.servers[id]; exists {
+		srv.InvalidateConfig()
+	}
+	sm.mutex.RUnlock()
+	sm.refreshPlaceholder(id)
+
+	slog.Info("Updated server config", "server_id", id, "restart_policy", doc.RestartPolicy)
+	return sm.GetServerConfigDocument(id, userID)
+}
+
+// validateConfigDocument checks a config document, filling in defaults
+func validateConfigDocument(doc *ServerConfigDocument) error {
+	if doc.ModPackID != nil && *doc.ModPackID == 0 {
+		doc.ModPackID = nil
+	}
+	if strings.TrimSpace(doc.ExecutableCommand) == "" {
+		return fmt.Errorf("%w: executable command must not be empty", ErrInvalidServerConfig)
+	}
+	if doc.Memory.MinMB < 0 || doc.Memory.MaxMB < 0 {
+		return fmt.Errorf("%w: memory sizes must not be negative", ErrInvalidServerConfig)
+	}
+	maxMB := doc.Memory.MaxMB
+	if maxMB == 0 {
+		maxMB = server.CommandMemoryMB(doc.ExecutableCommand)
+	}
+	minMB := doc.Memory.MinMB
+	if minMB == 0 {
+		minMB = server.CommandMinMemoryMB(doc.ExecutableCommand)
+	}
+	if maxMB > 0 && minMB > maxMB {
+		return fmt.Errorf("%w: minimum memory %d MB exceeds maximum memory %d MB", ErrInvalidServerConfig, minMB, maxMB)
+	}
+	for name, value := range doc.Env {
+		if !envName.MatchString(name) {
+			return fmt.Errorf("%w: invalid environment variable name %q", ErrInvalidServerConfig, name)
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("%w: environment variable %s contains a NUL byte", ErrInvalidServerConfig, name)
+		}
+	}
+	switch doc.RestartPolicy {
+	case "":
+		doc.RestartPolicy = model.RestartPolicyNever
+	case model.RestartPolicyNever, model.RestartPolicyOnFailure:
+	default:
+		return fmt.Errorf("%w: unknown restart policy %q", ErrInvalidServerConfig, doc.RestartPolicy)
+	}
+	if doc.MaxRestarts < 0 || doc.MaxRestarts > maxRestarts {
+		return fmt.Errorf("%w: max restarts must be between 0 and %d", ErrInvalidServerConfig, maxRestarts)
+	}
+	doc.Placeholder.MOTD = strings.TrimSpace(doc.Placeholder.MOTD)
+	if len([]rune(doc.Placeholder.MOTD)) > maxPlaceholderMOTD {
+		return fmt.Errorf("%w: placeholder MOTD must be at most %d characters", ErrInvalidServerConfig, maxPlaceholderMOTD)
+	}
+	if doc.Placeholder.StartOnJoin && !doc.Placeholder.Enabled {
+		return fmt.Errorf("%w: start on join requires the placeholder", ErrInvalidServerConfig)
+	}
+	if doc.Placeholder.IdleShutdownMinutes < 0 || doc.Placeholder.IdleShutdownMinutes > maxIdleShutdownMinutes {
+		return fmt.Errorf("%w: idle shutdown must be between 0 and %d minutes", ErrInvalidServerConfig, maxIdleShutdownMinutes)
+	}
+	// Without start on join nothing would bring an idle server back
+	if doc.Placeholder.IdleShutdownMinutes > 0 && !doc.Placeholder.StartOnJoin {
+		return fmt.Errorf("%w: idle shutdown requires start on join", ErrInvalidServerConfig)
+	}
+	return nil
+}
+
+// sameModPack reports whether two optional mod pack IDs name the same pack
+func sameModPack(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
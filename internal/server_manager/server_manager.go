@@ -2,34 +2,128 @@ package server_manager
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"github.com/olindenbaum/mcgonalds/internal/dns"
 	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/mojang"
+	"github.com/olindenbaum/mcgonalds/internal/node"
+	"github.com/olindenbaum/mcgonalds/internal/placeholder"
 	"github.com/olindenbaum/mcgonalds/internal/server"
+	"github.com/olindenbaum/mcgonalds/internal/storage"
+	"github.com/olindenbaum/mcgonalds/internal/tracing"
 	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 )
 
 type ServerManager struct {
 	db            *gorm.DB
-	servers       map[uint8]*server.Server
+	servers       map[uint]*server.Server
 	mutex         sync.RWMutex
 	commonDir     string
-	outputStreams map[uint8][]chan string
+	streams       sync.WaitGroup
+	shuttingDown  bool
+	changeMutex   sync.Mutex
+	backupStorage storage.Backend
+	runtime       server.Runtime
+	nodes         *node.Hub
+	dns           *dns.Registrar
+	mailer        *utils.Mailer
+	limits        config.Limits
+	diskReserve   int64
+	linkMode      string
+	maxStarts     int
+	limitsMutex   sync.RWMutex
+
+	// consoles holds the recent output of each server; consoleSubs and
+	// outputStreams the subscriptions reading it
+	consoles      map[uint]*consoleBuffer
+	consoleSubs   map[*ConsoleSubscription]struct{}
+	outputStreams map[chan string]*ConsoleSubscription
 	streamMutex   sync.RWMutex
+
+	// starting holds the servers that occupy a start slot; startQueue
+	// waits for one in order. startupTimes holds how long the last start
+	// of each server took.
+	starting     map[uint]bool
+	startQueue   []queuedStart
+	startupTimes map[uint]time.Duration
+	startMutex   sync.Mutex
+
+	// uploads holds the upload sessions by ID
+	uploads     map[string]*UploadSession
+	uploadMutex sync.Mutex
+
+	// modPackUpgrades holds the latest mod pack upgrade of each server,
+	// installs the latest loader install and modPackApplies the latest apply
+	// of each mod pack by each user; all are guarded by changeMutex
+	modPackUpgrades map[uint]*ModPackUpgrade
+	installs        map[uint]*LoaderInstall
+	modPackApplies  map[modPackApplyKey]*ModPackApply
+
+	// fileLocks holds the operations using the files of each server
+	fileLocks     map[uint]*fileLock
+	fileLockMutex sync.Mutex
+
+	eventSubscribers []chan model.Event
+	eventMutex       sync.RWMutex
+
+	// alertPending holds since when the condition of a rule has held on a
+	// server; only the metrics sampler touches it
+	alertPending map[alertKey]time.Time
+
+	// crashRestarts holds when each server was restarted after a crash;
+	// only the crash recorder touches it
+	crashRestarts map[uint][]time.Time
+
+	// idleSince holds since when each server with idle shutdown has had
+	// no players; only the idle monitor touches it
+	idleSince map[uint]time.Time
+
+	// scaleIdleSince holds since when each autoscaled instance has had no
+	// players; only the autoscaler touches it
+	scaleIdleSince map[uint]time.Time
+
+	// profiles looks up and caches the Mojang profiles of players
+	profiles *mojang.Client
+
+	// placeholders holds the listeners answering on the ports of stopped
+	// servers
+	placeholders     map[uint]*placeholder.Listener
+	placeholderMutex sync.Mutex
 }
 
 func NewServerManager(db *gorm.DB, commonDir string) (*ServerManager, error) {
 	sm := &ServerManager{
-		db:            db,
-		servers:       make(map[uint8]*server.Server),
-		commonDir:     commonDir,
-		outputStreams: make(map[uint8][]chan string),
+		db:              db,
+		servers:         make(map[uint]*server.Server),
+		commonDir:       commonDir,
+		consoles:        make(map[uint]*consoleBuffer),
+		consoleSubs:     make(map[*ConsoleSubscription]struct{}),
+		outputStreams:   make(map[chan string]*ConsoleSubscription),
+		modPackUpgrades: make(map[uint]*ModPackUpgrade),
+		installs:        make(map[uint]*LoaderInstall),
+		modPackApplies:  make(map[modPackApplyKey]*ModPackApply),
+		alertPending:    make(map[alertKey]time.Time),
+		starting:        make(map[uint]bool),
+		startupTimes:    make(map[uint]time.Duration),
+		uploads:         make(map[string]*UploadSession),
+		fileLocks:       make(map[uint]*fileLock),
+		crashRestarts:   make(map[uint][]time.Time),
+		idleSince:       make(map[uint]time.Time),
+		scaleIdleSince:  make(map[uint]time.Time),
+		profiles:        mojang.NewClient(),
+		placeholders:    make(map[uint]*placeholder.Listener),
 	}
 
 	// Fetch all existing servers from the database
@@ -38,15 +132,56 @@ func NewServerManager(db *gorm.DB, commonDir string) (*ServerManager, error) {
 		return nil, fmt.Errorf("failed to fetch existing servers: %w", err)
 	}
 
+	// No process survives a manager restart
+	active := []string{model.ServerStatusQueued, model.ServerStatusStarting, model.ServerStatusRunning}
+	if err := db.Model(&model.Server{}).Where("status IN ?", active).
+		Update("status", model.ServerStatusStopped).Error; err != nil {
+		return nil, fmt.Errorf("failed to reset server states: %w", err)
+	}
+
 	// Populate the servers map
 	for _, dbServer := range dbServers {
-		sm.servers[uint8(dbServer.ID)] = server.NewServer(&dbServer)
+		sm.servers[dbServer.ID] = sm.newServer(&dbServer)
 	}
+	sm.removeStaleJarSwapSnapshots(dbServers)
 
 	return sm, nil
 }
 
-func (sm *ServerManager) CreateServer(name, path, executableCommand string, jarFile *model.JarFile, modPack *model.ModPack, additionalFileIDs []uint, userID uint) (uint8, error) {
+func (sm *ServerManager) CreateServer(name, path, executableCommand string, jarFile *model.JarFile, modPack *model.ModPack, additionalFileIDs []uint, userID uint) (uint, error) {
+	return sm.createServer(name, path, executableCommand, jarFile, modPack, additionalFileIDs, userID, nil)
+}
+
+// createServer creates a server and provisions its directory. fill, if not
+// nil, completes the directory before a server on a node is pushed to it.
+// If provisioning fails the server is purged again.
+func (sm *ServerManager) createServer(name, path, executableCommand string, jarFile *model.JarFile, modPack *model.ModPack, additionalFileIDs []uint, userID uint, fill func(id uint) error) (uint, error) {
+	serverModel, err := sm.insertServer(name, path, executableCommand, jarFile, modPack, additionalFileIDs, userID)
+	if err != nil {
+		return 0, err
+	}
+	id := serverModel.ID
+	if err := sm.provisionServer(serverModel, jarFile, modPack, fill); err != nil {
+		sm.discardServer(id)
+		return 0, err
+	}
+
+	// fill may have changed the record
+	if err := sm.db.First(serverModel, id).Error; err != nil {
+		sm.discardServer(id)
+		return 0, fmt.Errorf("failed to fetch server: %w", err)
+	}
+	sm.mutex.Lock()
+	sm.servers[id] = sm.newServer(serverModel)
+	sm.mutex.Unlock()
+	slog.Info("Created server", "server_id", id, "name", name, "user_id", userID)
+
+	return id, nil
+}
+
+// insertServer records a new server and its config, scheduling it on a node
+// when nodes are registered
+func (sm *ServerManager) insertServer(name, path, executableCommand string, jarFile *model.JarFile, modPack *model.ModPack, additionalFileIDs []uint, userID uint) (*model.Server, error) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
@@ -54,29 +189,47 @@ func (sm *ServerManager) CreateServer(name, path, executableCommand string, jarF
 	var existingServer model.Server
 	result := sm.db.Where("name = ?", name).First(&existingServer)
 	if result.Error == nil {
-		return 0, fmt.Errorf("server %s already exists", name)
+		return nil, fmt.Errorf("server %s already exists", name)
 	} else if result.Error != gorm.ErrRecordNotFound {
-		return 0, fmt.Errorf("error checking for existing server: %w", result.Error)
+		return nil, fmt.Errorf("error checking for existing server: %w", result.Error)
+	}
+
+	if err := sm.checkPathNotDeleted(path); err != nil {
+		return nil, err
+	}
+	if err := sm.CheckQuota(userID, QuotaRequest{Servers: 1, MemoryMB: server.CommandMemoryMB(executableCommand)}); err != nil {
+		return nil, err
+	}
+	nodeID, err := sm.scheduleNode(server.CommandMemoryMB(executableCommand))
+	if err != nil {
+		return nil, err
+	}
+	additionalFiles, err := sm.getAdditionalFiles(additionalFileIDs)
+	if err != nil {
+		return nil, err
 	}
 
 	// Start a transaction
 	tx := sm.db.Begin()
 	if tx.Error != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", tx.Error)
+		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
 	}
 
 	// Create server model
 	serverModel := &model.Server{
 		Name:   name,
 		UserID: userID,
-		Status: "stopped",
+		Status: model.ServerStatusStopped,
 		Path:   path,
+		NodeID: nodeID,
+		// Attached in the same insert
+		AdditionalFiles: additionalFiles,
 	}
 
 	// Create server in the database
 	if err := tx.Create(serverModel).Error; err != nil {
 		tx.Rollback()
-		return 0, fmt.Errorf("failed to create server in database: %w", err)
+		return nil, fmt.Errorf("failed to create server in database: %w", err)
 	}
 
 	// Create server config
@@ -93,53 +246,59 @@ func (sm *ServerManager) CreateServer(name, path, executableCommand string, jarF
 	// Create server config in the database
 	if err := tx.Create(serverConfig).Error; err != nil {
 		tx.Rollback()
-		return 0, fmt.Errorf("failed to create server config in database: %w", err)
+		return nil, fmt.Errorf("failed to create server config in database: %w", err)
 	}
 
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
-		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return serverModel, nil
+}
+
+// provisionServer fills the directory of a new server and pushes it to its
+// node, if it has one
+func (sm *ServerManager) provisionServer(serverModel *model.Server, jarFile *model.JarFile, modPack *model.ModPack, fill func(id uint) error) error {
+	path := serverModel.Path
 
 	// Create server directory
-	log.Printf("Creating server directory: %s", path)
+	slog.Debug("Creating server directory", "path", path)
 	if err := os.MkdirAll(path, 0755); err != nil {
-		log.Printf("Failed to create server environment directory: %v", err)
-		return 0, fmt.Errorf("failed to create server environment directory: %w", err)
+		return fmt.Errorf("failed to create server environment directory: %w", err)
 	}
-	log.Printf("Server directory created successfully: %s", path)
 
-	// Handle symbolic link for JAR file
+	// Link the JAR file
 	if jarFile != nil {
 		jarSource := jarFile.Path
 		jarDest := filepath.Join(path, "server.jar")
-		log.Printf("Creating symlink for JAR file: %s -> %s", jarSource, jarDest)
-		if err := utils.CreateSymlink(jarSource, jarDest); err != nil {
-			log.Printf("Failed to create symlink for jar file: %v", err)
-			return 0, fmt.Errorf("failed to create symlink for jar file: %w", err)
+		if err := sm.linkArtifact(jarSource, jarDest); err != nil {
+			return fmt.Errorf("failed to link jar file: %w", err)
 		}
-		log.Printf("Symlink for JAR file created successfully")
 	}
 
-	// Handle symbolic link for Mod Pack
+	// Link the Mod Pack
 	if modPack != nil {
 		modPackSource := modPack.Path
 		modPackDest := filepath.Join(path, "mods")
-		log.Printf("Creating symlink for Mod Pack: %s -> %s", modPackSource, modPackDest)
-		if err := utils.CreateSymlink(modPackSource, modPackDest); err != nil {
-			log.Printf("Failed to create symlink for mod pack: %v", err)
-			return 0, fmt.Errorf("failed to create symlink for mod pack: %w", err)
+		if err := sm.linkArtifact(modPackSource, modPackDest); err != nil {
+			return fmt.Errorf("failed to link mod pack: %w", err)
 		}
-		log.Printf("Symlink for Mod Pack created successfully")
 	}
 
-	// Initialize the server instance
-	log.Printf("Initializing server instance for ID: %d", serverModel.ID)
-	srv := server.NewServer(serverModel)
-	sm.servers[uint8(serverModel.ID)] = srv
-	log.Printf("Server instance initialized successfully")
+	if err := sm.placeAdditionalFiles(path, serverModel.AdditionalFiles); err != nil {
+		return fmt.Errorf("failed to place additional files: %w", err)
+	}
 
-	return uint8(serverModel.ID), nil
+	if fill != nil {
+		if err := fill(serverModel.ID); err != nil {
+			return err
+		}
+	}
+
+	if serverModel.NodeID != nil {
+		return sm.pushToNode(*serverModel.NodeID, path)
+	}
+	return nil
 }
 
 // GetJarFileByID retrieves a JarFile by its ID.
@@ -160,117 +319,252 @@ func (sm *ServerManager) GetModPackByID(id uint) (*model.ModPack, error) {
 	return &modPack, nil
 }
 
-func (sm *ServerManager) GetServer(id uint8, userID uint) (*server.Server, error) {
+func (sm *ServerManager) GetServer(id uint, userID uint) (*server.Server, error) {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
-	fmt.Printf("Getting server with ID: %d", id)
 	srv, exists := sm.servers[id]
-	fmt.Printf("Exists: %v", exists)
 	if !exists {
 		var dbServer model.Server
-		if err := sm.db.Where("id = ? AND user_id = ?", id, userID).First(&dbServer).Error; err != nil {
+		if err := sm.db.Scopes(sm.serverAccess(userID)).Where("id = ?", id).First(&dbServer).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				return nil, fmt.Errorf("server %s not found", id)
+				return nil, fmt.Errorf("server %d not found: %w", id, err)
 			}
 			return nil, fmt.Errorf("failed to fetch server from database: %w", err)
 		}
-		srv = server.NewServer(&dbServer)
+		srv = sm.newServer(&dbServer)
 		sm.servers[id] = srv
 	}
 
-	fmt.Printf("Server: %v\n", srv)
-	details := srv.GetServerDetails()
-	fmt.Printf("Details: %v\n", details)
 	return srv, nil
 }
 
-func (sm *ServerManager) DeleteServer(id uint8, userID uint) error {
+// ownedServer loads a server userID holds permission on from the database and returns it
+// together with its in-memory instance, creating the instance if needed
+func (sm *ServerManager) ownedServer(id uint, userID uint, permission string) (*model.Server, *server.Server, error) {
+	if err := sm.Authorize(id, userID, permission); err != nil {
+		return nil, nil, err
+	}
+	var serverModel model.Server
+	if err := sm.db.Where("id = ?", id).First(&serverModel).Error; err != nil {
+		return nil, nil, fmt.Errorf("server not found: %w", err)
+	}
+
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
+	srv, exists := sm.servers[id]
+	if !exists {
+		srv = sm.newServer(&serverModel)
+		sm.servers[id] = srv
+	}
+	return &serverModel, srv, nil
+}
 
-	if _, exists := sm.servers[id]; !exists {
-		return fmt.Errorf("server %s not found", id)
+// DeleteServer stops a server and marks it deleted. Its files and records
+// are kept so it can be restored until the purge job removes them, unless
+// purgeFiles is set, in which case they are removed right away.
+func (sm *ServerManager) DeleteServer(ctx context.Context, id uint, userID uint, purgeFiles bool) error {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return err
+	}
+	if err := srv.StopAndWait(stopTimeout); err != nil {
+		return fmt.Errorf("failed to stop server: %w", err)
 	}
 
+	sm.mutex.Lock()
 	delete(sm.servers, id)
-	return sm.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Server{}).Error
+	sm.mutex.Unlock()
+
+	if serverModel.DNSName != "" {
+		if err := sm.unregisterDNS(ctx, serverModel); err != nil {
+			slog.WarnContext(ctx, "Failed to remove DNS records", "server_id", id, "dns_name", serverModel.DNSName, "error", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Deleting server", "server_id", id, "user_id", userID, "purge_files", purgeFiles)
+	if purgeFiles {
+		err = sm.purgeServer(serverModel)
+	} else {
+		err = sm.db.Delete(serverModel).Error
+	}
+	// Closed once the server is gone, since its stop may reopen an offline
+	// placeholder until then
+	sm.closePlaceholder(id)
+	return err
 }
-func (sm *ServerManager) StartServer(id uint8, userID uint) error {
+
+// StartServer launches the server process. The span of the start covers
+// verifying the server's files and spawning the process.
+func (sm *ServerManager) StartServer(ctx context.Context, id uint, userID uint) error {
+	ctx, span := tracing.Start(ctx, "server.start", attribute.Int64("server.id", int64(id)))
+	err := sm.startServer(ctx, id, userID)
+	tracing.End(span, err)
+	return err
+}
+
+func (sm *ServerManager) startServer(ctx context.Context, id uint, userID uint) error {
+	if err := sm.Authorize(id, userID, model.PermissionPower); err != nil {
+		return err
+	}
+	if err := sm.checkMaintenance(ctx, id); err != nil {
+		return err
+	}
+
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
+	if sm.shuttingDown {
+		return ErrShuttingDown
+	}
 
-	log.Printf("Starting server with ID: %d for user: %d", id, userID)
+	logger := slog.With("server_id", id, "user_id", userID)
+	logger.InfoContext(ctx, "Starting server")
 
 	srv, exists := sm.servers[id]
 	if !exists {
-		log.Printf("Server %d not found in memory, initializing from database", id)
+		logger.DebugContext(ctx, "Server not in memory, initializing from database")
 		// Initialize the server instance
 		var serverModel model.Server
-		if err := sm.db.Where("id = ? AND user_id = ?", id, userID).First(&serverModel).Error; err != nil {
-			log.Printf("Failed to find server in database: %v", err)
+		if err := sm.db.WithContext(ctx).Scopes(sm.serverAccess(userID)).Where("id = ?", id).First(&serverModel).Error; err != nil {
 			return fmt.Errorf("server not found: %w", err)
 		}
-		srv = server.NewServer(&serverModel)
+		srv = sm.newServer(&serverModel)
 		sm.servers[id] = srv
-		log.Printf("Server %d initialized and added to memory", id)
+	}
+
+	if err := sm.checkServerQuota(id, QuotaRequest{}); err != nil {
+		return err
+	}
+
+	var archived int64
+	if err := sm.db.Model(&model.Server{}).Where("id = ? AND archived_at IS NOT NULL", id).Count(&archived).Error; err != nil {
+		return fmt.Errorf("failed to fetch server: %w", err)
+	}
+	if archived > 0 {
+		return ErrServerArchived
+	}
+	if err := sm.exclusiveFileLock(id); err != nil {
+		return err
 	}
 
 	// Ensure required files are present
-	log.Printf("Verifying required files for server %d", id)
-	if err := sm.verifyRequiredFiles(id); err != nil {
-		log.Printf("Failed to verify required files: %v", err)
+	if err := sm.verifyRequiredFiles(id, srv); err != nil {
+		logger.WarnContext(ctx, "Failed to verify required files", "error", err)
 		return err
 	}
 
+	if !srv.IsRunning() && !sm.acquireStartSlot(id, userID) {
+		srv.SetQueued(true)
+		logger.InfoContext(ctx, "Server queued to start")
+		return nil
+	}
+
 	// Start the server
-	log.Printf("Attempting to start server %d", id)
+	sm.closePlaceholder(id)
 	if err := srv.Start(); err != nil {
-		log.Printf("Failed to start server %d: %v", id, err)
+		sm.releaseStartSlot(id)
+		logger.ErrorContext(ctx, "Failed to start server", "error", err)
 		return fmt.Errorf("failed to start server: %w", err)
 	}
+	go sm.watchStartup(id, srv)
 
 	// Optionally, manage output stream
-	log.Printf("Starting output stream for server %d", id)
-	go sm.streamServerOutput(id, srv)
+	sm.streams.Add(1)
+	go func() {
+		defer sm.streams.Done()
+		sm.streamServerOutput(id, srv)
+	}()
+
+	if swap := sm.pendingJarSwap(id); swap != nil {
+		go sm.watchJarSwap(id, srv, swap)
+	}
+	if upgrade := sm.pendingModPackUpgrade(id); upgrade != nil {
+		go sm.watchModPackUpgrade(id, srv, upgrade)
+	}
 
-	log.Printf("Server %d started successfully", id)
+	logger.InfoContext(ctx, "Server started")
 	return nil
 }
 
-func (sm *ServerManager) StopServer(id uint8, userID uint) error {
+// StartAutoStartServers starts every server flagged for auto start,
+// logging the ones that fail. Servers in a maintenance window that stops
+// them stay down.
+func (sm *ServerManager) StartAutoStartServers() {
+	var servers []model.Server
+	if err := sm.db.Where("auto_start = ?", true).Find(&servers).Error; err != nil {
+		slog.Error("Failed to fetch auto start servers", "error", err)
+		return
+	}
+	for _, serverModel := range servers {
+		if window, err := sm.activeMaintenance(serverModel.ID); err == nil && window != nil && window.Mode == model.MaintenanceStop {
+			slog.Info("Not starting server in maintenance", "server_id", serverModel.ID)
+			continue
+		}
+		if err := sm.StartServer(context.Background(), serverModel.ID, serverModel.UserID); err != nil {
+			slog.Error("Failed to auto start server", "server_id", serverModel.ID, "error", err)
+		}
+	}
+}
+
+func (sm *ServerManager) StopServer(ctx context.Context, id uint, userID uint) error {
+	if err := sm.Authorize(id, userID, model.PermissionPower); err != nil {
+		return err
+	}
+	if err := sm.checkMaintenance(ctx, id); err != nil {
+		return err
+	}
+
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
 	srv, exists := sm.servers[id]
 	if !exists {
-		return fmt.Errorf("server %s not found", id)
+		return fmt.Errorf("server %d not found", id)
 	}
 
+	if sm.cancelQueuedStart(id) {
+		slog.InfoContext(ctx, "Removed server from the start queue", "server_id", id, "user_id", userID)
+		srv.SetQueued(false)
+		return nil
+	}
+	slog.InfoContext(ctx, "Stopping server", "server_id", id, "user_id", userID)
 	return srv.Stop()
 }
 
-func (sm *ServerManager) RestartServer(id uint8) error {
+func (sm *ServerManager) RestartServer(ctx context.Context, id uint, userID uint) error {
+	if err := sm.Authorize(id, userID, model.PermissionPower); err != nil {
+		return err
+	}
+	if err := sm.checkMaintenance(ctx, id); err != nil {
+		return err
+	}
+
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
 	srv, exists := sm.servers[id]
 	if !exists {
-		return fmt.Errorf("server %s not found", id)
+		return fmt.Errorf("server %d not found", id)
 	}
 
+	slog.InfoContext(ctx, "Restarting server", "server_id", id, "user_id", userID)
 	return srv.Restart()
 }
 
-func (sm *ServerManager) SendCommand(id uint8, command string) (string, error) {
+func (sm *ServerManager) SendCommand(ctx context.Context, id uint, userID uint, command string) (string, error) {
+	if err := sm.Authorize(id, userID, model.PermissionConsole); err != nil {
+		return "", err
+	}
+
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
 	srv, exists := sm.servers[id]
 	if !exists {
-		return "", fmt.Errorf("server %s not found", id)
+		return "", fmt.Errorf("server %d not found", id)
 	}
 
+	slog.InfoContext(ctx, "Sending command", "server_id", id, "user_id", userID, "command", command)
 	if err := srv.SendCommand(command); err != nil {
 		return "", err
 	}
@@ -278,7 +572,16 @@ func (sm *ServerManager) SendCommand(id uint8, command string) (string, error) {
 	return "Command executed", nil
 }
 
-func (sm *ServerManager) UploadJarFile(name, version string, file io.Reader, baseName string, size int64, serverID string, isCommon bool) (*model.JarFile, error) {
+// UploadJarFile stores an uploaded jar file, either for one server or as a
+// common jar file
+func (sm *ServerManager) UploadJarFile(ctx context.Context, name, version string, file io.Reader, baseName string, size int64, serverID string, isCommon bool) (*model.JarFile, error) {
+	ctx, span := tracing.Start(ctx, "jar_file.upload", attribute.Int64("file.size", size), attribute.Bool("common", isCommon))
+	jarFile, err := sm.uploadJarFile(ctx, name, version, file, baseName, size, serverID, isCommon)
+	tracing.End(span, err)
+	return jarFile, err
+}
+
+func (sm *ServerManager) uploadJarFile(ctx context.Context, name, version string, file io.Reader, baseName string, size int64, serverID string, isCommon bool) (*model.JarFile, error) {
 	var jarDir string
 	currentDir, err := os.Getwd()
 	if err != nil {
@@ -298,31 +601,21 @@ func (sm *ServerManager) UploadJarFile(name, version string, file io.Reader, bas
 	if err := os.MkdirAll(jarDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create jar directory: %w", err)
 	}
-
-	objectPath := filepath.Join(jarDir, baseName)
-	// Log the jar file upload
-	log.Printf("Uploading JAR file: %s (version: %s, size: %d bytes)", name, version, size)
-	log.Printf("Destination path: %s", objectPath)
-
-	if isCommon {
-		log.Printf("Uploading as common JAR file")
-	} else if serverID != "" {
-		log.Printf("Uploading for server ID: %s", serverID)
+	if err := sm.checkDiskSpace(jarDir, size); err != nil {
+		return nil, err
 	}
 
+	objectPath := filepath.Join(jarDir, baseName)
 	destFile, err := os.Create(objectPath)
 	if err != nil {
-		log.Printf("Error creating JAR file: %v", err)
 		return nil, fmt.Errorf("failed to create jar file: %w", err)
 	}
 	defer destFile.Close()
 
 	bytesWritten, err := io.Copy(destFile, file)
 	if err != nil {
-		log.Printf("Error saving JAR file: %v", err)
 		return nil, fmt.Errorf("failed to save jar file: %w", err)
 	}
-	log.Printf("Successfully wrote %d bytes to %s", bytesWritten, objectPath)
 
 	jarFile := &model.JarFile{
 		Name:     name,
@@ -331,11 +624,11 @@ func (sm *ServerManager) UploadJarFile(name, version string, file io.Reader, bas
 		IsCommon: isCommon,
 	}
 
-	if err := sm.db.Create(jarFile).Error; err != nil {
-		log.Printf("Error creating JAR file record in database: %v", err)
+	if err := sm.db.WithContext(ctx).Create(jarFile).Error; err != nil {
 		return nil, fmt.Errorf("failed to create jar file record: %w", err)
 	}
-	log.Printf("Successfully created JAR file record in database with ID: %d", jarFile.ID)
+	slog.InfoContext(ctx, "Uploaded JAR file", "jar_file_id", jarFile.ID, "name", name, "version", version,
+		"bytes", bytesWritten, "path", objectPath, "common", isCommon, "server_id", serverID)
 
 	return jarFile, nil
 }
@@ -372,7 +665,17 @@ func (sm *ServerManager) UploadAdditionalFile(name, fileType string, file io.Rea
 	return additionalFile, nil
 }
 
-func (sm *ServerManager) UploadModPack(originalFilename string, file io.Reader, size int64, serverID string, isCommon bool) (*model.ModPack, error) {
+// UploadModPack stores an uploaded mod pack, either for one server or as a
+// common mod pack. Mod packs with the same name are versions of one pack;
+// an empty name defaults to the file name.
+func (sm *ServerManager) UploadModPack(ctx context.Context, name, version, originalFilename string, file io.Reader, size int64, serverID string, isCommon bool) (*model.ModPack, error) {
+	ctx, span := tracing.Start(ctx, "mod_pack.upload", attribute.Int64("file.size", size), attribute.Bool("common", isCommon))
+	modPack, err := sm.uploadModPack(ctx, name, version, originalFilename, file, size, serverID, isCommon)
+	tracing.End(span, err)
+	return modPack, err
+}
+
+func (sm *ServerManager) uploadModPack(ctx context.Context, name, version, originalFilename string, file io.Reader, size int64, serverID string, isCommon bool) (*model.ModPack, error) {
 	var modPackDir string
 	currentDir, err := os.Getwd()
 	if err != nil {
@@ -391,8 +694,11 @@ func (sm *ServerManager) UploadModPack(originalFilename string, file io.Reader,
 	if err := os.MkdirAll(modPackDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create mod pack directory: %w", err)
 	}
+	if err := sm.checkDiskSpace(modPackDir, size); err != nil {
+		return nil, err
+	}
 
-	objectPath := filepath.Join(modPackDir, originalFilename)
+	objectPath := filepath.Join(modPackDir, versionedFilename(originalFilename, version))
 
 	destFile, err := os.Create(objectPath)
 	if err != nil {
@@ -404,13 +710,17 @@ func (sm *ServerManager) UploadModPack(originalFilename string, file io.Reader,
 		return nil, fmt.Errorf("failed to save mod pack file: %w", err)
 	}
 
+	if name == "" {
+		name = originalFilename
+	}
 	modPack := &model.ModPack{
-		Name:     originalFilename,
+		Name:     name,
+		Version:  version,
 		Path:     objectPath,
 		IsCommon: isCommon,
 	}
 
-	if err := sm.db.Create(modPack).Error; err != nil {
+	if err := sm.db.WithContext(ctx).Create(modPack).Error; err != nil {
 		return nil, fmt.Errorf("failed to create mod pack record: %w", err)
 	}
 
@@ -422,75 +732,120 @@ func (sm *ServerManager) SetupServer(serverName string) error {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
-	// Log the start of the setup process
-	log.Printf("Starting setup for server: %s", serverName)
-
 	// Fetch server model from database
 	var serverModel model.Server
-	if err := sm.db.Where("name = ?", serverName).First(&serverModel).Error; err != nil {
-		log.Printf("Error fetching server model: %v", err)
+	if err := sm.db.Preload("AdditionalFiles").Where("name = ?", serverName).First(&serverModel).Error; err != nil {
 		return fmt.Errorf("server %s not found: %w", serverName, err)
 	}
-	log.Printf("Fetched server model: %+v", serverModel)
 
 	// Fetch server configuration
 	var config model.ServerConfig
 	if err := sm.db.Preload("JarFile").Preload("ModPack").Where("server_id = ?", serverModel.ID).First(&config).Error; err != nil {
-		log.Printf("Error fetching server config for server %s: %v", serverName, err)
 		return fmt.Errorf("failed to fetch server config: %w", err)
 	}
-	log.Printf("Fetched server config: %+v", config)
 
 	envDir := filepath.Join(serverModel.Path, "env")
 	if err := os.MkdirAll(envDir, 0755); err != nil {
-		log.Printf("Error creating environment directory %s: %v", envDir, err)
 		return fmt.Errorf("failed to create environment directory: %w", err)
 	}
-	log.Printf("Created environment directory: %s", envDir)
 
 	// Symlink or copy JAR file
 	if config.JarFile.ID != 0 {
 		jarSource := config.JarFile.Path
 		jarDest := filepath.Join(envDir, "server.jar")
-		log.Printf("Creating symlink for JAR file from %s to %s", jarSource, jarDest)
-		if err := utils.CreateSymlink(jarSource, jarDest); err != nil {
-			log.Printf("Error symlinking JAR file: %v", err)
-			return fmt.Errorf("failed to symlink jar file: %w", err)
+		if err := sm.linkArtifact(jarSource, jarDest); err != nil {
+			return fmt.Errorf("failed to link jar file: %w", err)
 		}
-		log.Printf("Successfully symlinked JAR file to %s", jarDest)
 	}
 
 	// Symlink or copy Mod Pack
 	if config.ModPack != nil {
 		modPackSource := config.ModPack.Path
 		modPackDest := filepath.Join(envDir, "mods")
-		log.Printf("Creating symlink for Mod Pack from %s to %s", modPackSource, modPackDest)
-		if err := utils.CreateSymlink(modPackSource, modPackDest); err != nil {
-			log.Printf("Error symlinking mod pack: %v", err)
-			return fmt.Errorf("failed to symlink mod pack: %w", err)
+		if err := sm.linkArtifact(modPackSource, modPackDest); err != nil {
+			return fmt.Errorf("failed to link mod pack: %w", err)
 		}
-		log.Printf("Successfully symlinked Mod Pack to %s", modPackDest)
+	}
+
+	// Place additional files by their type: configs in the root, plugins in plugins/ and so on
+	if err := sm.placeAdditionalFiles(envDir, serverModel.AdditionalFiles); err != nil {
+		return fmt.Errorf("failed to place additional files: %w", err)
 	}
 
 	// Create or update the server instance in the servers map
-	srv := server.NewServer(&serverModel)
-	sm.servers[uint8(serverModel.ID)] = srv
-	log.Printf("Server instance for %s created/updated in the servers map", serverName)
+	srv := sm.newServer(&serverModel)
+	sm.servers[serverModel.ID] = srv
+	slog.Info("Set up server", "server_id", serverModel.ID, "name", serverName)
 
 	return nil
 }
 
-// ListServers returns a list of all servers
-func (sm *ServerManager) ListServers(userID uint) ([]model.Server, error) {
-	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
+// ServerListOptions filters, sorts and paginates ListServers. A zero PerPage
+// returns all matching servers.
+type ServerListOptions struct {
+	Page    int
+	PerPage int
+	Status  string
+	// Tag only returns servers with this tag
+	Tag string
+	// Query matches server and tag names case-insensitively
+	Query string
+	// Sort is a column name, prefixed with - for descending order
+	Sort string
+}
+
+// serverSortColumns maps accepted sort keys to columns
+var serverSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"status":     "status",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// ListServers returns the servers of a user, including those shared with
+// their teams, matching opts together with the total number of matches
+// before pagination
+func (sm *ServerManager) ListServers(userID uint, opts ServerListOptions) ([]model.Server, int64, error) {
+	query := sm.db.Model(&model.Server{}).Scopes(sm.serverAccess(userID))
+	if opts.Status != "" {
+		query = query.Where("status = ?", opts.Status)
+	}
+	if opts.Tag != "" {
+		query = query.Where("id IN (?)", sm.serversTagged("tags.name = ?", strings.ToLower(opts.Tag)))
+	}
+	if opts.Query != "" {
+		pattern := "%" + strings.ToLower(opts.Query) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR id IN (?)", pattern, sm.serversTagged("tags.name LIKE ?", pattern))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count servers: %w", err)
+	}
+
+	order, ok := serverSortColumns[strings.TrimPrefix(opts.Sort, "-")]
+	if !ok {
+		order = "id"
+	}
+	if strings.HasPrefix(opts.Sort, "-") {
+		order += " DESC"
+	}
+	query = query.Order(order)
+	if opts.PerPage > 0 {
+		page := opts.Page
+		if page < 1 {
+			page = 1
+		}
+		query = query.Offset((page - 1) * opts.PerPage).Limit(opts.PerPage)
+	}
 
 	var servers []model.Server
-	if err := sm.db.Where("user_id = ?", userID).Find(&servers).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch servers from database: %w", err)
+	if err := query.Preload("Tags").Preload("Config.JarFile").Preload("Config.ModPack").Find(&servers).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch servers from database: %w", err)
 	}
 
-	return servers, nil
+	return servers, total, nil
 }
 
 // GetJarFiles retrieves JAR files. If common is true, only common JAR files are returned.
@@ -519,51 +874,29 @@ func (sm *ServerManager) GetModPacks(common bool) ([]model.ModPack, error) {
 	return modPacks, nil
 }
 
-// UpdateServerCommand updates the executable command for a server
-func (sm *ServerManager) UpdateServerCommand(id uint8, command string) error {
-	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
-
+// verifyRequiredFiles runs the preflight checks of a server and fails with
+// the failed checks
+func (sm *ServerManager) verifyRequiredFiles(id uint, srv *server.Server) error {
 	var serverModel model.Server
 	if err := sm.db.Where("id = ?", id).First(&serverModel).Error; err != nil {
 		return fmt.Errorf("server not found: %w", err)
 	}
 
-	serverConfig, err := sm.getServerConfig(id)
-	if err != nil {
-		return fmt.Errorf("failed to get server config: %w", err)
+	report := sm.preflight(&serverModel, srv.IsRunning())
+	if report.Ready {
+		return nil
 	}
-
-	serverConfig.ExecutableCommand = command
-	if err := sm.db.Save(&serverConfig).Error; err != nil {
-		return fmt.Errorf("failed to update server command: %w", err)
+	var failed []string
+	for _, check := range report.Checks {
+		if check.Status == PreflightFail {
+			failed = append(failed, check.Name+": "+check.Message)
+		}
 	}
-
-	return nil
-}
-
-// verifyRequiredFiles checks if necessary files are present in the server directory
-func (sm *ServerManager) verifyRequiredFiles(id uint8) error {
-	var serverModel model.Server
-	if err := sm.db.Where("id = ?", id).First(&serverModel).Error; err != nil {
-		return fmt.Errorf("server not found: %w", err)
-	}
-
-	// envDir := fmt.Sprintf("%s/env", serverModel.Path)
-	// requiredFiles := []string{"server.jar", "start.sh"}
-
-	// for _, file := range requiredFiles {
-	// 	filePath := fmt.Sprintf("%s/%s", envDir, file)
-	// 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-	// 		return fmt.Errorf("required file %s is missing", file)
-	// 	}
-	// }
-
-	return nil
+	return fmt.Errorf("preflight failed: %s", strings.Join(failed, "; "))
 }
 
 // updateServerOutput appends a new line to the server's output
-func (sm *ServerManager) updateServerOutput(id uint8, line string) {
+func (sm *ServerManager) updateServerOutput(id uint, line string) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
@@ -576,16 +909,21 @@ func (sm *ServerManager) updateServerOutput(id uint8, line string) {
 	// For demonstration, we'll skip persistent storage
 }
 
-// GetServerOutput retrieves the accumulated output for a server
-func (sm *ServerManager) GetServerOutput(id uint8) (string, error) {
-	// Implement a way to retrieve the server's output
-	// This could be from an in-memory buffer, a file, or a database
-	// For simplicity, we'll return a placeholder
-	return "Server output would appear here...", nil
+// GetServerOutput returns the recent console lines of a server, up to the
+// console backlog
+func (sm *ServerManager) GetServerOutput(id uint, userID uint) (string, error) {
+	if err := sm.Authorize(id, userID, model.PermissionConsole); err != nil {
+		return "", err
+	}
+	sm.streamMutex.Lock()
+	buffer := sm.consoleBufferFor(id)
+	sm.streamMutex.Unlock()
+	lines, _, _, _ := buffer.since(0)
+	return strings.Join(lines, "\n"), nil
 }
 
 // getServerConfig retrieves the server's configuration
-func (sm *ServerManager) getServerConfig(id uint8) (*model.ServerConfig, error) {
+func (sm *ServerManager) getServerConfig(id uint) (*model.ServerConfig, error) {
 	var config model.ServerConfig
 	if err := sm.db.Where("server_id = ?", id).First(&config).Error; err != nil {
 		return nil, err
@@ -593,49 +931,60 @@ func (sm *ServerManager) getServerConfig(id uint8) (*model.ServerConfig, error)
 	return &config, nil
 }
 
-// SubscribeOutput allows handlers to receive server output
-func (sm *ServerManager) SubscribeOutput(id uint8) (chan string, error) {
-	sm.streamMutex.Lock()
-	defer sm.streamMutex.Unlock()
-
+// SubscribeOutput delivers server output from now on, line by line, on a
+// channel that is closed once unsubscribed
+func (sm *ServerManager) SubscribeOutput(id uint) (chan string, error) {
+	sub := sm.SubscribeConsole(id, 0)
 	ch := make(chan string, 100)
-	sm.outputStreams[id] = append(sm.outputStreams[id], ch)
+
+	sm.streamMutex.Lock()
+	sm.outputStreams[ch] = sub
+	sm.streamMutex.Unlock()
+
+	go func() {
+		defer close(ch)
+		for {
+			lines, _, err := sub.Next(context.Background())
+			if err != nil {
+				return
+			}
+			for _, line := range lines {
+				select {
+				case ch <- line:
+				case <-sub.closed:
+					return
+				}
+			}
+		}
+	}()
 	return ch, nil
 }
 
 // UnsubscribeOutput removes a handler from receiving server output
-func (sm *ServerManager) UnsubscribeOutput(id uint8, ch chan string) {
+func (sm *ServerManager) UnsubscribeOutput(id uint, ch chan string) {
 	sm.streamMutex.Lock()
-	defer sm.streamMutex.Unlock()
-
-	streams := sm.outputStreams[id]
-	for i, subscriber := range streams {
-		if subscriber == ch {
-			sm.outputStreams[id] = append(streams[:i], streams[i+1:]...)
-			close(ch)
-			break
-		}
+	sub, exists := sm.outputStreams[ch]
+	delete(sm.outputStreams, ch)
+	sm.streamMutex.Unlock()
+
+	if exists {
+		sm.UnsubscribeConsole(sub)
 	}
 }
 
-// streamServerOutput sends server output to all subscribers
-func (sm *ServerManager) streamServerOutput(id uint8, srv *server.Server) {
+// streamServerOutput records server output in the console buffer of the
+// server, from which every subscriber reads at its own pace
+func (sm *ServerManager) streamServerOutput(id uint, srv *server.Server) {
+	sm.streamMutex.Lock()
+	buffer := sm.consoleBufferFor(id)
+	sm.streamMutex.Unlock()
+
 	for line := range srv.GetConsole() {
-		sm.streamMutex.RLock()
-		subscribers := sm.outputStreams[id]
-		sm.streamMutex.RUnlock()
-
-		for _, ch := range subscribers {
-			select {
-			case ch <- line:
-			default:
-				// Handle slow consumers or drop messages
-			}
-		}
+		buffer.append(line)
 	}
 }
 
-func (sm *ServerManager) GetExecutableCommand(id uint8) (string, error) {
+func (sm *ServerManager) GetExecutableCommand(id uint) (string, error) {
 	_, ok := sm.servers[id]
 	if !ok {
 		return "", fmt.Errorf("server %d not found", id)
@@ -649,9 +998,10 @@ func (sm *ServerManager) GetExecutableCommand(id uint8) (string, error) {
 	return config.ExecutableCommand, nil
 }
 
-func (sm *ServerManager) GetServerConfig(id uint8) (*model.ServerConfig, error) {
+// GetServerConfig loads the config of a server with its jar file and mod pack
+func (sm *ServerManager) GetServerConfig(id uint) (*model.ServerConfig, error) {
 	var config model.ServerConfig
-	if err := sm.db.Where("server_id = ?", id).First(&config).Error; err != nil {
+	if err := sm.db.Preload("JarFile").Preload("ModPack").Where("server_id = ?", id).First(&config).Error; err != nil {
 		return nil, fmt.Errorf("failed to get server config: %w", err)
 	}
 	return &config, nil
@@ -0,0 +1,66 @@
+package server_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestManager returns a manager on a fresh SQLite database with every
+// table, keeping its files under a temporary directory
+func newTestManager(t *testing.T) *ServerManager {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(model.All()...); err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewServerManager(db, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sm
+}
+
+// createTestJar records a jar file backed by an empty file
+func createTestJar(t *testing.T, sm *ServerManager) *model.JarFile {
+	t.Helper()
+	path := filepath.Join(sm.commonDir, "server.jar")
+	jar := &model.JarFile{Name: "server.jar", Version: "1.21", Path: path}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.db.Create(jar).Error; err != nil {
+		t.Fatal(err)
+	}
+	return jar
+}
+
+// createTestUser records a user with the given role
+func createTestUser(t *testing.T, sm *ServerManager, username, role string) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Password: "x", Role: role}
+	if err := sm.db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestServer creates a server of user on a test jar
+func createTestServer(t *testing.T, sm *ServerManager, user *model.User, name string) uint {
+	t.Helper()
+	jar := createTestJar(t, sm)
+	id, err := sm.CreateServer(name, filepath.Join(sm.commonDir, "servers", name), "java -jar server.jar nogui", jar, nil, nil, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
@@ -0,0 +1,107 @@
+package server_manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval limits how often a session's last use is written
+const sessionTouchInterval = time.Minute
+
+// maxUserAgent bounds the stored user agent
+const maxUserAgent = 512
+
+// ErrSessionRevoked is returned for tokens whose session no longer exists
+var ErrSessionRevoked = errors.New("session expired or revoked")
+
+// CreateSession records a new login of userID that lasts ttl. The returned
+// session's TokenID goes into the token's jti claim.
+func (sm *ServerManager) CreateSession(userID uint, ip, userAgent string, ttl time.Duration) (*model.Session, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+
+	now := time.Now()
+	session := &model.Session{
+		UserID:     userID,
+		TokenID:    hex.EncodeToString(tokenID),
+		IP:         ip,
+		UserAgent:  userAgent,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err := sm.db.Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	// Expired sessions are dropped whenever the user logs in again
+	sm.db.Where("user_id = ? AND expires_at < ?", userID, now).Delete(&model.Session{})
+	return session, nil
+}
+
+// CheckSession returns ErrSessionRevoked unless tokenID names a live
+// session of userID, and records the session's use
+func (sm *ServerManager) CheckSession(userID uint, tokenID string) error {
+	if tokenID == "" {
+		return ErrSessionRevoked
+	}
+	var session model.Session
+	err := sm.db.Where("token_id = ? AND user_id = ? AND expires_at > ?", tokenID, userID, time.Now()).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		sm.db.Model(&session).UpdateColumn("last_seen_at", time.Now())
+	}
+	return nil
+}
+
+// ListSessions returns the live sessions of userID, most recently used
+// first. The session with currentTokenID is marked as current.
+func (sm *ServerManager) ListSessions(userID uint, currentTokenID string) ([]model.Session, error) {
+	var sessions []model.Session
+	if err := sm.db.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].TokenID == currentTokenID
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one session of userID, so its token stops working
+func (sm *ServerManager) RevokeSession(userID, sessionID uint) error {
+	result := sm.db.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&model.Session{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("session not found: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// RevokeSessions ends every session of userID except the one with
+// keepTokenID, which may be empty, and returns how many were ended
+func (sm *ServerManager) RevokeSessions(userID uint, keepTokenID string) (int64, error) {
+	result := sm.db.Where("user_id = ? AND token_id <> ?", userID, keepTokenID).Delete(&model.Session{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
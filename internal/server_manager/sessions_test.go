@@ -0,0 +1,64 @@
+package server_manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestCheckSession(t *testing.T) {
+	sm := newTestManager(t)
+	alice := createTestUser(t, sm, "alice", model.RoleOperator)
+	bob := createTestUser(t, sm, "bob", model.RoleOperator)
+
+	current, err := sm.CreateSession(alice.ID, "127.0.0.1", "browser", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sm.CreateSession(alice.ID, "127.0.0.1", "cli", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := sm.CreateSession(alice.ID, "127.0.0.1", "old", -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sm.CheckSession(alice.ID, current.TokenID); err != nil {
+		t.Errorf("live session: %v", err)
+	}
+	for name, check := range map[string]error{
+		"no token ID":     sm.CheckSession(alice.ID, ""),
+		"another user":    sm.CheckSession(bob.ID, current.TokenID),
+		"expired session": sm.CheckSession(alice.ID, expired.TokenID),
+		"unknown token":   sm.CheckSession(alice.ID, "unknown"),
+	} {
+		if !errors.Is(check, ErrSessionRevoked) {
+			t.Errorf("%s: got %v, want ErrSessionRevoked", name, check)
+		}
+	}
+
+	// Users can only revoke their own sessions
+	if err := sm.RevokeSession(bob.ID, other.ID); err == nil {
+		t.Error("revoked the session of another user")
+	}
+	if err := sm.RevokeSession(alice.ID, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.CheckSession(alice.ID, other.TokenID); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("revoked session: got %v, want ErrSessionRevoked", err)
+	}
+
+	// Signing out everywhere else keeps the current session
+	if _, err := sm.CreateSession(alice.ID, "127.0.0.1", "phone", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := sm.RevokeSessions(alice.ID, current.TokenID); err != nil || n != 1 {
+		t.Errorf("RevokeSessions = %d, %v, want 1", n, err)
+	}
+	if err := sm.CheckSession(alice.ID, current.TokenID); err != nil {
+		t.Errorf("kept session: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package server_manager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// ErrShuttingDown is returned when a server is started while the manager
+// is shutting down
+var ErrShuttingDown = errors.New("server manager is shutting down")
+
+// Shutdown stops accepting server starts and ends every output and event
+// stream. With stopServers set it first stops all running servers, waiting
+// for their remaining console output to be delivered; otherwise the
+// processes are left running on their own. It returns ctx.Err() if ctx is
+// done before the servers have stopped.
+func (sm *ServerManager) Shutdown(ctx context.Context, stopServers bool) error {
+	sm.mutex.Lock()
+	sm.shuttingDown = true
+	var running []uint
+	for id, srv := range sm.servers {
+		if srv.IsRunning() {
+			running = append(running, id)
+		}
+	}
+	sm.mutex.Unlock()
+
+	var err error
+	if stopServers {
+		err = sm.stopAll(ctx, running)
+	} else if len(running) > 0 {
+		slog.Info("Leaving servers running", "count", len(running))
+	}
+
+	sm.closePlaceholders()
+	sm.closeStreams()
+	return err
+}
+
+// stopAll stops the given servers in parallel and waits until their console
+// output has been streamed out
+func (sm *ServerManager) stopAll(ctx context.Context, ids []uint) error {
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		sm.mutex.RLock()
+		srv := sm.servers[id]
+		sm.mutex.RUnlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slog.Info("Stopping server for shutdown", "server_id", id)
+			if err := srv.StopAndWait(stopTimeout); err != nil {
+				slog.Error("Failed to stop server for shutdown", "server_id", id, "error", err)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		sm.streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeStreams closes every output and event subscription so the
+// connections serving them finish
+func (sm *ServerManager) closeStreams() {
+	sm.streamMutex.Lock()
+	for sub := range sm.consoleSubs {
+		sub.Close()
+	}
+	sm.consoleSubs = make(map[*ConsoleSubscription]struct{})
+	sm.outputStreams = make(map[chan string]*ConsoleSubscription)
+	sm.streamMutex.Unlock()
+
+	sm.eventMutex.Lock()
+	for _, ch := range sm.eventSubscribers {
+		close(ch)
+	}
+	sm.eventSubscribers = nil
+	sm.eventMutex.Unlock()
+}
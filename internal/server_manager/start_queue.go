@@ -0,0 +1,158 @@
+package server_manager
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// startupTimeout is how long a start holds its slot when the server never
+// logs that it finished starting
+const startupTimeout = 5 * time.Minute
+
+// queuedStart is a start waiting for a free slot
+type queuedStart struct {
+	ServerID uint
+	UserID   uint
+}
+
+// SetMaxConcurrentStarts limits how many servers may be starting at once.
+// Further starts wait in a queue. Zero is unlimited.
+func (sm *ServerManager) SetMaxConcurrentStarts(max int) {
+	sm.limitsMutex.Lock()
+	sm.maxStarts = max
+	sm.limitsMutex.Unlock()
+	// A raised limit frees slots for queued servers
+	sm.dispatchQueuedStarts()
+}
+
+// StartQueuePosition returns the 1-based place of a server in the start
+// queue, or zero when it is not queued
+func (sm *ServerManager) StartQueuePosition(id uint) int {
+	sm.startMutex.Lock()
+	defer sm.startMutex.Unlock()
+	for i, start := range sm.startQueue {
+		if start.ServerID == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// acquireStartSlot takes a start slot for a server, or queues the start
+// and returns false when every slot is taken. Queued servers find their
+// slot reserved when their start runs.
+func (sm *ServerManager) acquireStartSlot(id, userID uint) bool {
+	sm.limitsMutex.RLock()
+	max := sm.maxStarts
+	sm.limitsMutex.RUnlock()
+
+	sm.startMutex.Lock()
+	defer sm.startMutex.Unlock()
+	if sm.starting[id] {
+		return true
+	}
+	for _, start := range sm.startQueue {
+		if start.ServerID == id {
+			return false
+		}
+	}
+	if max > 0 && len(sm.starting) >= max {
+		sm.startQueue = append(sm.startQueue, queuedStart{ServerID: id, UserID: userID})
+		return false
+	}
+	sm.starting[id] = true
+	return true
+}
+
+// releaseStartSlot frees the slot of a server and starts the next queued
+// servers
+func (sm *ServerManager) releaseStartSlot(id uint) {
+	sm.startMutex.Lock()
+	delete(sm.starting, id)
+	sm.startMutex.Unlock()
+	sm.dispatchQueuedStarts()
+}
+
+// cancelQueuedStart removes a server from the start queue and reports
+// whether it was queued
+func (sm *ServerManager) cancelQueuedStart(id uint) bool {
+	sm.startMutex.Lock()
+	defer sm.startMutex.Unlock()
+	for i, start := range sm.startQueue {
+		if start.ServerID == id {
+			sm.startQueue = append(sm.startQueue[:i], sm.startQueue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchQueuedStarts starts queued servers while slots are free. The
+// starts run in the background, as callers may hold the manager lock.
+func (sm *ServerManager) dispatchQueuedStarts() {
+	sm.limitsMutex.RLock()
+	max := sm.maxStarts
+	sm.limitsMutex.RUnlock()
+
+	sm.startMutex.Lock()
+	defer sm.startMutex.Unlock()
+	for len(sm.startQueue) > 0 && (max <= 0 || len(sm.starting) < max) {
+		start := sm.startQueue[0]
+		sm.startQueue = sm.startQueue[1:]
+		sm.starting[start.ServerID] = true
+		go sm.runQueuedStart(start)
+	}
+}
+
+// runQueuedStart starts a server that waited in the queue
+func (sm *ServerManager) runQueuedStart(start queuedStart) {
+	if err := sm.StartServer(context.Background(), start.ServerID, start.UserID); err != nil {
+		slog.Error("Failed to start queued server", "server_id", start.ServerID, "error", err)
+		sm.mutex.RLock()
+		srv := sm.servers[start.ServerID]
+		sm.mutex.RUnlock()
+		if srv != nil {
+			srv.SetQueued(false)
+		}
+		sm.releaseStartSlot(start.ServerID)
+	}
+}
+
+// lastStartupTime returns how long the last start of a server took, or
+// zero before it has finished one
+func (sm *ServerManager) lastStartupTime(id uint) time.Duration {
+	sm.startMutex.Lock()
+	defer sm.startMutex.Unlock()
+	return sm.startupTimes[id]
+}
+
+// watchStartup holds the start slot of a server until it finishes
+// starting, exits or runs out of time, and records how long it took
+func (sm *ServerManager) watchStartup(id uint, srv *server.Server) {
+	defer sm.releaseStartSlot(id)
+
+	ready := srv.Ready()
+	timeout := time.After(startupTimeout)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ready:
+			sm.startMutex.Lock()
+			sm.startupTimes[id] = srv.Uptime()
+			sm.startMutex.Unlock()
+			return
+		case <-ticker.C:
+			if !srv.IsRunning() {
+				return
+			}
+		case <-timeout:
+			slog.Warn("Server did not report finishing startup, releasing its start slot", "server_id", id, "timeout", startupTimeout)
+			srv.MarkReady()
+			return
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package server_manager
+
+import "testing"
+
+func TestStartSlots(t *testing.T) {
+	sm := &ServerManager{starting: make(map[uint]bool)}
+	sm.SetMaxConcurrentStarts(2)
+
+	for _, id := range []uint{1, 2} {
+		if !sm.acquireStartSlot(id, 1) {
+			t.Fatalf("server %d was queued with a free slot", id)
+		}
+	}
+	for _, id := range []uint{3, 4} {
+		if sm.acquireStartSlot(id, 1) {
+			t.Fatalf("server %d got a slot while all were taken", id)
+		}
+	}
+	if sm.acquireStartSlot(3, 1) || len(sm.startQueue) != 2 {
+		t.Fatalf("queueing server 3 twice left %d starts queued", len(sm.startQueue))
+	}
+	if pos := sm.StartQueuePosition(4); pos != 2 {
+		t.Errorf("server 4 is at position %d, want 2", pos)
+	}
+
+	if !sm.cancelQueuedStart(3) || sm.cancelQueuedStart(3) {
+		t.Fatal("server 3 was not removed from the queue exactly once")
+	}
+	if pos := sm.StartQueuePosition(4); pos != 1 {
+		t.Errorf("server 4 is at position %d after a cancel, want 1", pos)
+	}
+}
@@ -0,0 +1,33 @@
+package server_manager
+
+import (
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// ServerStats reports the live resource usage of a server. Process figures
+// are zero while the server is stopped.
+type ServerStats struct {
+	Running bool `json:"running"`
+	server.ProcessStats
+	DiskBytes int64 `json:"disk_bytes"`
+}
+
+// ServerStats samples the process and disk usage of a server
+func (sm *ServerManager) ServerStats(id uint, userID uint) (*ServerStats, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ServerStats{DiskBytes: DirSize(serverModel.Path)}
+	if srv.IsRunning() {
+		process, err := srv.Stats()
+		if err != nil {
+			return nil, err
+		}
+		stats.Running = true
+		stats.ProcessStats = *process
+	}
+	return stats, nil
+}
@@ -0,0 +1,68 @@
+package server_manager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/gorm"
+)
+
+var tagName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// SetServerTags replaces the tags of a server. Tags are created on first use
+// and names are normalized to lower case.
+func (sm *ServerManager) SetServerTags(id uint, userID uint, names []string) ([]model.Tag, error) {
+	serverModel, _, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []model.Tag{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			continue
+		}
+		if !tagName.MatchString(name) {
+			return nil, fmt.Errorf("invalid tag %q: use up to 64 lower case letters, digits, - and _", name)
+		}
+		seen[name] = true
+		tags = append(tags, model.Tag{UserID: userID, Name: name})
+	}
+
+	err = sm.db.Transaction(func(tx *gorm.DB) error {
+		for i := range tags {
+			if err := tx.Where(model.Tag{UserID: userID, Name: tags[i].Name}).FirstOrCreate(&tags[i]).Error; err != nil {
+				return fmt.Errorf("failed to create tag %s: %w", tags[i].Name, err)
+			}
+		}
+		return tx.Model(serverModel).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ListTags returns the tags of a user with at least one server, sorted by name
+func (sm *ServerManager) ListTags(userID uint) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := sm.db.Where("user_id = ? AND id IN (?)", userID, sm.db.Table("server_tags").Select("tag_id")).
+		Order("name").Find(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	return tags, nil
+}
+
+// serversTagged returns a subquery of the IDs of servers with a tag whose
+// name matches the condition
+func (sm *ServerManager) serversTagged(condition string, value interface{}) *gorm.DB {
+	return sm.db.Table("server_tags").
+		Select("server_tags.server_id").
+		Joins("JOIN tags ON tags.id = server_tags.tag_id").
+		Where(condition, value)
+}
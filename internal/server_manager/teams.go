@@ -0,0 +1,300 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrTeamPermission is returned when a user's team role does not allow a change
+var ErrTeamPermission = errors.New("insufficient team role")
+
+// serverAccess limits a server query to the servers a user owns, shares
+// through an accepted team membership or holds a grant on. Callers that act
+// on behalf of a grantee check the grant's permissions with Authorize.
+func (sm *ServerManager) serverAccess(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(servers.user_id = ? OR servers.team_id IN (?) OR servers.id IN (?))",
+			userID, sm.memberTeams(userID), sm.grantedServers(userID))
+	}
+}
+
+// serverControl limits a server query to the servers a user owns or shares
+// through a team they manage or own
+func (sm *ServerManager) serverControl(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(servers.user_id = ? OR servers.team_id IN (?))", userID, sm.managedTeams(userID))
+	}
+}
+
+// serverShared limits a server query to the servers a user owns or shares
+// through an accepted team membership of any role
+func (sm *ServerManager) serverShared(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(servers.user_id = ? OR servers.team_id IN (?))", userID, sm.memberTeams(userID))
+	}
+}
+
+// memberTeams returns a subquery of the IDs of the teams userID has joined
+func (sm *ServerManager) memberTeams(userID uint) *gorm.DB {
+	return sm.db.Model(&model.Membership{}).Select("team_id").Where("user_id = ? AND accepted = ?", userID, true)
+}
+
+// managedTeams returns a subquery of the IDs of the teams userID has joined
+// as a manager or owner
+func (sm *ServerManager) managedTeams(userID uint) *gorm.DB {
+	return sm.memberTeams(userID).Where("role IN ?", []string{model.TeamRoleManager, model.TeamRoleOwner})
+}
+
+// UserTeamIDs returns the IDs of the teams userID has joined
+func (sm *ServerManager) UserTeamIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	if err := sm.memberTeams(userID).Pluck("team_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch teams: %w", err)
+	}
+	return ids, nil
+}
+
+// CreateTeam creates a team with userID as its owner
+func (sm *ServerManager) CreateTeam(name string, userID uint) (*model.Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("team name must not be empty")
+	}
+
+	team := &model.Team{Name: name}
+	err := sm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(team).Error; err != nil {
+			return fmt.Errorf("failed to create team: %w", err)
+		}
+		owner := &model.Membership{TeamID: team.ID, UserID: userID, Role: model.TeamRoleOwner, Accepted: true, InvitedBy: userID}
+		if err := tx.Create(owner).Error; err != nil {
+			return fmt.Errorf("failed to add team owner: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Created team", "user_id", userID, "team_id", team.ID, "name", team.Name)
+	return team, nil
+}
+
+// ListTeams returns the memberships of a user, pending invitations included,
+// with their teams
+func (sm *ServerManager) ListTeams(userID uint) ([]model.Membership, error) {
+	var memberships []model.Membership
+	if err := sm.db.Preload("Team").Where("user_id = ?", userID).Order("team_id").Find(&memberships).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch teams: %w", err)
+	}
+	return memberships, nil
+}
+
+// ListTeamMembers returns the members and pending invitations of a team
+// userID has joined
+func (sm *ServerManager) ListTeamMembers(teamID uint, userID uint) ([]model.Membership, error) {
+	if _, err := sm.teamMembership(teamID, userID); err != nil {
+		return nil, err
+	}
+	var memberships []model.Membership
+	if err := sm.db.Where("team_id = ?", teamID).Order("id").Find(&memberships).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch team members: %w", err)
+	}
+	if err := sm.fillUsernames(memberships); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// InviteTeamMember invites a user to a team. Owners and managers can invite;
+// only owners can invite other owners.
+func (sm *ServerManager) InviteTeamMember(teamID uint, userID uint, username, role string) (*model.Membership, error) {
+	if !model.ValidTeamRole(role) {
+		return nil, fmt.Errorf("invalid team role %q", role)
+	}
+	inviter, err := sm.teamMembership(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAssignTeamRole(inviter.Role, role) {
+		return nil, ErrTeamPermission
+	}
+
+	var user model.User
+	if err := sm.db.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user %s not found: %w", username, err)
+	}
+	var existing int64
+	if err := sm.db.Model(&model.Membership{}).Where("team_id = ? AND user_id = ?", teamID, user.ID).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("error checking membership: %w", err)
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%s is already a member or invited", username)
+	}
+
+	membership := &model.Membership{TeamID: teamID, UserID: user.ID, Username: user.Username, Role: role, InvitedBy: userID}
+	if err := sm.db.Create(membership).Error; err != nil {
+		return nil, fmt.Errorf("failed to invite %s: %w", username, err)
+	}
+	slog.Info("Invited team member", "user_id", userID, "invitee", username, "team_id", teamID, "role", role)
+	return membership, nil
+}
+
+// AcceptTeamInvitation makes a pending invitation of userID a membership
+func (sm *ServerManager) AcceptTeamInvitation(teamID uint, userID uint) (*model.Membership, error) {
+	var membership model.Membership
+	if err := sm.db.Preload("Team").Where("team_id = ? AND user_id = ?", teamID, userID).First(&membership).Error; err != nil {
+		return nil, fmt.Errorf("invitation not found: %w", err)
+	}
+	if membership.Accepted {
+		return &membership, nil
+	}
+	if err := sm.db.Model(&membership).Update("accepted", true).Error; err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return &membership, nil
+}
+
+// SetTeamMemberRole changes the role of a team member. Owners can assign any
+// role, managers can only move members between member and manager, and a
+// team always keeps at least one owner.
+func (sm *ServerManager) SetTeamMemberRole(teamID uint, userID uint, memberID uint, role string) (*model.Membership, error) {
+	if !model.ValidTeamRole(role) {
+		return nil, fmt.Errorf("invalid team role %q", role)
+	}
+	actor, err := sm.teamMembership(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var member model.Membership
+	if err := sm.db.Where("team_id = ? AND user_id = ?", teamID, memberID).First(&member).Error; err != nil {
+		return nil, fmt.Errorf("team member not found: %w", err)
+	}
+	if !canAssignTeamRole(actor.Role, role) || !canAssignTeamRole(actor.Role, member.Role) {
+		return nil, ErrTeamPermission
+	}
+	if member.Role == model.TeamRoleOwner && role != model.TeamRoleOwner {
+		if err := sm.checkOtherOwner(teamID, memberID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := sm.db.Model(&member).Update("role", role).Error; err != nil {
+		return nil, fmt.Errorf("failed to update team role: %w", err)
+	}
+	return &member, nil
+}
+
+// RemoveTeamMember removes a member or withdraws an invitation. Anyone can
+// leave a team; removing others needs a role that could have assigned theirs.
+func (sm *ServerManager) RemoveTeamMember(teamID uint, userID uint, memberID uint) error {
+	var member model.Membership
+	if err := sm.db.Where("team_id = ? AND user_id = ?", teamID, memberID).First(&member).Error; err != nil {
+		return fmt.Errorf("team member not found: %w", err)
+	}
+	if memberID != userID {
+		actor, err := sm.teamMembership(teamID, userID)
+		if err != nil {
+			return err
+		}
+		if !canAssignTeamRole(actor.Role, member.Role) {
+			return ErrTeamPermission
+		}
+	}
+	if member.Role == model.TeamRoleOwner && member.Accepted {
+		if err := sm.checkOtherOwner(teamID, memberID); err != nil {
+			return err
+		}
+	}
+
+	if err := sm.db.Unscoped().Delete(&member).Error; err != nil {
+		return fmt.Errorf("failed to remove team member: %w", err)
+	}
+	slog.Info("Removed team member", "user_id", userID, "member_id", memberID, "team_id", teamID)
+	return nil
+}
+
+// SetServerTeam shares a server with a team, or stops sharing it when teamID
+// is nil. Only the user who owns the server can change this, and only to a
+// team they have joined.
+func (sm *ServerManager) SetServerTeam(id uint, userID uint, teamID *uint) (*model.Server, error) {
+	var serverModel model.Server
+	if err := sm.db.Where("id = ? AND user_id = ?", id, userID).First(&serverModel).Error; err != nil {
+		return nil, fmt.Errorf("server not found: %w", err)
+	}
+	if teamID != nil {
+		if _, err := sm.teamMembership(*teamID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := sm.db.Model(&serverModel).Update("team_id", teamID).Error; err != nil {
+		return nil, fmt.Errorf("failed to update server team: %w", err)
+	}
+	serverModel.TeamID = teamID
+
+	// Events carry the team of the cached model
+	sm.mutex.Lock()
+	if srv, exists := sm.servers[id]; !exists || !srv.IsRunning() {
+		sm.servers[id] = sm.newServer(&serverModel)
+	}
+	sm.mutex.Unlock()
+	return &serverModel, nil
+}
+
+// teamMembership returns the accepted membership of userID in a team
+func (sm *ServerManager) teamMembership(teamID uint, userID uint) (*model.Membership, error) {
+	var membership model.Membership
+	err := sm.db.Where("team_id = ? AND user_id = ? AND accepted = ?", teamID, userID, true).First(&membership).Error
+	if err != nil {
+		return nil, fmt.Errorf("team not found: %w", err)
+	}
+	return &membership, nil
+}
+
+// checkOtherOwner fails unless the team has an owner besides userID
+func (sm *ServerManager) checkOtherOwner(teamID uint, userID uint) error {
+	var owners int64
+	err := sm.db.Model(&model.Membership{}).
+		Where("team_id = ? AND user_id <> ? AND role = ? AND accepted = ?", teamID, userID, model.TeamRoleOwner, true).
+		Count(&owners).Error
+	if err != nil {
+		return fmt.Errorf("error checking team owners: %w", err)
+	}
+	if owners == 0 {
+		return fmt.Errorf("a team needs at least one owner; make someone else owner first")
+	}
+	return nil
+}
+
+// fillUsernames sets the usernames of memberships for display
+func (sm *ServerManager) fillUsernames(memberships []model.Membership) error {
+	ids := make([]uint, len(memberships))
+	for i, m := range memberships {
+		ids[i] = m.UserID
+	}
+	var users []model.User
+	if err := sm.db.Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to fetch users: %w", err)
+	}
+	names := make(map[uint]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	for i := range memberships {
+		memberships[i].Username = names[memberships[i].UserID]
+	}
+	return nil
+}
+
+// canAssignTeamRole reports whether a member with role actor may grant or
+// revoke role: owners manage everyone, managers manage members and managers
+func canAssignTeamRole(actor, role string) bool {
+	if actor == model.TeamRoleOwner {
+		return true
+	}
+	return model.TeamRoleAtLeast(actor, model.TeamRoleManager) && role != model.TeamRoleOwner
+}
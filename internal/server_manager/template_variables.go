@@ -0,0 +1,181 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// ErrInvalidTemplateValues is returned when the variables given for a
+// template are missing, unknown or of the wrong type
+var ErrInvalidTemplateValues = errors.New("invalid template variables")
+
+// Built-in template variables. port and name are always set by the manager;
+// motd and max_players can be given and have defaults.
+const (
+	VariablePort       = "port"
+	VariableName       = "name"
+	VariableMOTD       = "motd"
+	VariableMaxPlayers = "max_players"
+)
+
+// defaultMaxPlayers is the max_players of servers created without one
+const defaultMaxPlayers = 20
+
+var (
+	// variablePlaceholder matches {{variable}} in template properties
+	variablePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+	// variableName matches the names of custom template variables
+	variableName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+)
+
+// builtinVariables are the variables every template can use
+var builtinVariables = map[string]string{
+	VariablePort:       model.VariableInt,
+	VariableName:       model.VariableString,
+	VariableMOTD:       model.VariableString,
+	VariableMaxPlayers: model.VariableInt,
+}
+
+// validateTemplateVariables checks the custom variables of a template and
+// that its properties only use known placeholders
+func validateTemplateVariables(template *model.Template) error {
+	declared := make(map[string]bool)
+	for _, variable := range template.Variables {
+		if !variableName.MatchString(variable.Name) {
+			return fmt.Errorf("invalid variable name %q", variable.Name)
+		}
+		if _, ok := builtinVariables[variable.Name]; ok {
+			return fmt.Errorf("variable %s is built in", variable.Name)
+		}
+		if declared[variable.Name] {
+			return fmt.Errorf("variable %s is declared twice", variable.Name)
+		}
+		declared[variable.Name] = true
+		switch variable.Type {
+		case model.VariableString, model.VariableInt, model.VariableBool:
+		default:
+			return fmt.Errorf("variable %s has unknown type %q", variable.Name, variable.Type)
+		}
+		if variable.Default != nil {
+			if _, err := parseVariable(variable.Name, variable.Type, *variable.Default); err != nil {
+				return fmt.Errorf("default of %w", err)
+			}
+		}
+	}
+	for _, match := range variablePlaceholder.FindAllStringSubmatch(template.Properties, -1) {
+		if _, ok := builtinVariables[match[1]]; !ok && !declared[match[1]] {
+			return fmt.Errorf("properties use undeclared variable %s", match[1])
+		}
+	}
+	return nil
+}
+
+// templateUsesPort reports whether a template's properties place the port
+func templateUsesPort(template *model.Template) bool {
+	for _, match := range variablePlaceholder.FindAllStringSubmatch(template.Properties, -1) {
+		if match[1] == VariablePort {
+			return true
+		}
+	}
+	return false
+}
+
+// templateValues resolves the value of every variable of a template for a
+// new server from the given JSON values and the defaults
+func templateValues(template *model.Template, name string, port int, given map[string]interface{}) (map[string]string, error) {
+	types := map[string]string{
+		VariableMOTD:       model.VariableString,
+		VariableMaxPlayers: model.VariableInt,
+	}
+	values := map[string]string{
+		VariablePort:       strconv.Itoa(port),
+		VariableName:       name,
+		VariableMOTD:       name,
+		VariableMaxPlayers: strconv.Itoa(defaultMaxPlayers),
+	}
+	for _, variable := range template.Variables {
+		types[variable.Name] = variable.Type
+		if variable.Default != nil {
+			values[variable.Name] = *variable.Default
+		}
+	}
+
+	for key, value := range given {
+		varType, ok := types[key]
+		if !ok {
+			if _, builtin := builtinVariables[key]; builtin {
+				return nil, fmt.Errorf("%w: %s is set by the manager", ErrInvalidTemplateValues, key)
+			}
+			return nil, fmt.Errorf("%w: unknown variable %s", ErrInvalidTemplateValues, key)
+		}
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case bool:
+			text = strconv.FormatBool(v)
+		case float64:
+			// JSON numbers decode as float64
+			if v != float64(int64(v)) {
+				return nil, fmt.Errorf("%w: %s must be an integer", ErrInvalidTemplateValues, key)
+			}
+			text = strconv.FormatInt(int64(v), 10)
+		default:
+			return nil, fmt.Errorf("%w: %s must be a %s", ErrInvalidTemplateValues, key, varType)
+		}
+		if _, isString := value.(string); isString != (varType == model.VariableString) {
+			return nil, fmt.Errorf("%w: %s must be a %s", ErrInvalidTemplateValues, key, varType)
+		}
+		parsed, err := parseVariable(key, varType, text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTemplateValues, err)
+		}
+		values[key] = parsed
+	}
+
+	for _, variable := range template.Variables {
+		if _, ok := values[variable.Name]; !ok {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidTemplateValues, variable.Name)
+		}
+	}
+	return values, nil
+}
+
+// parseVariable checks text against a variable type and returns it in the
+// form written to server.properties
+func parseVariable(name, varType, text string) (string, error) {
+	switch varType {
+	case model.VariableInt:
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("%s must be an integer", name)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case model.VariableBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return "", fmt.Errorf("%s must be true or false", name)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		// A line break would start another property
+		if strings.ContainsAny(text, "\r\n") {
+			return "", fmt.Errorf("%s must be a single line", name)
+		}
+		// Backslashes start escape sequences in .properties files
+		return strings.ReplaceAll(text, `\`, `\\`), nil
+	}
+}
+
+// renderProperties replaces the placeholders of template properties with
+// the resolved values
+func renderProperties(properties string, values map[string]string) string {
+	return variablePlaceholder.ReplaceAllStringFunc(properties, func(match string) string {
+		return values[variablePlaceholder.FindStringSubmatch(match)[1]]
+	})
+}
@@ -0,0 +1,55 @@
+package server_manager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	viewDistance := "10"
+	template := &model.Template{
+		Properties: "server-port={{port}}\nmotd={{ motd }}\nmax-players={{max_players}}\nview-distance={{view_distance}}\nlevel-seed={{seed}}\n",
+		Variables: []model.TemplateVariable{
+			{Name: "view_distance", Type: model.VariableInt, Default: &viewDistance},
+			{Name: "seed", Type: model.VariableString},
+		},
+	}
+	if err := validateTemplateVariables(template); err != nil {
+		t.Fatalf("validateTemplateVariables: %v", err)
+	}
+	if !templateUsesPort(template) {
+		t.Error("port placeholder not detected")
+	}
+
+	values, err := templateValues(template, "survival", 25566, map[string]interface{}{
+		"max_players": float64(50),
+		"seed":        `abc\def`,
+	})
+	if err != nil {
+		t.Fatalf("templateValues: %v", err)
+	}
+	want := "server-port=25566\nmotd=survival\nmax-players=50\nview-distance=10\nlevel-seed=abc\\\\def\n"
+	if got := renderProperties(template.Properties, values); got != want {
+		t.Errorf("got properties\n%s\nwant\n%s", got, want)
+	}
+
+	for _, given := range []map[string]interface{}{
+		{},                                // seed is required
+		{"seed": "x", "port": float64(1)}, // port is set by the manager
+		{"seed": "x", "max_players": "50"},
+		{"seed": "x", "view_distance": 2.5},
+		{"seed": "x\nonline-mode=false"},
+		{"seed": "x", "unknown": true},
+	} {
+		if _, err := templateValues(template, "survival", 25566, given); !errors.Is(err, ErrInvalidTemplateValues) {
+			t.Errorf("templateValues(%v) = %v, want ErrInvalidTemplateValues", given, err)
+		}
+	}
+
+	template.Properties += "difficulty={{difficulty}}\n"
+	if err := validateTemplateVariables(template); err == nil {
+		t.Error("undeclared placeholder accepted")
+	}
+}
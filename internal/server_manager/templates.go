@@ -0,0 +1,135 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// ErrTemplateExists is returned when a template with the name already exists
+var ErrTemplateExists = errors.New("template already exists")
+
+// CreateTemplate stores a new server template with its additional files
+func (sm *ServerManager) CreateTemplate(template *model.Template, additionalFileIDs []uint) error {
+	if _, err := sm.GetJarFileByID(template.JarFileID); err != nil {
+		return fmt.Errorf("invalid jar_file_id: %w", err)
+	}
+	if template.ModPackID != nil {
+		if _, err := sm.GetModPackByID(*template.ModPackID); err != nil {
+			return fmt.Errorf("invalid mod_pack_id: %w", err)
+		}
+	}
+	if err := validateTemplateVariables(template); err != nil {
+		return err
+	}
+	var existing int64
+	if err := sm.db.Model(&model.Template{}).Where("name = ?", template.Name).Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to check template name: %w", err)
+	}
+	if existing > 0 {
+		return fmt.Errorf("%w: %s", ErrTemplateExists, template.Name)
+	}
+
+	files, err := sm.getAdditionalFiles(additionalFileIDs)
+	if err != nil {
+		return err
+	}
+	template.AdditionalFiles = files
+
+	if err := sm.db.Create(template).Error; err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+	return nil
+}
+
+// ListTemplates returns all server templates
+func (sm *ServerManager) ListTemplates() ([]model.Template, error) {
+	var templates []model.Template
+	if err := sm.db.Preload("JarFile").Preload("ModPack").Preload("AdditionalFiles").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch templates: %w", err)
+	}
+	return templates, nil
+}
+
+// GetTemplate retrieves a template by its ID
+func (sm *ServerManager) GetTemplate(id uint) (*model.Template, error) {
+	var template model.Template
+	if err := sm.db.Preload("JarFile").Preload("ModPack").Preload("AdditionalFiles").First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// DeleteTemplate removes a template. Servers created from it are unaffected.
+func (sm *ServerManager) DeleteTemplate(id uint) error {
+	template, err := sm.GetTemplate(id)
+	if err != nil {
+		return err
+	}
+	if err := sm.db.Model(template).Association("AdditionalFiles").Clear(); err != nil {
+		return fmt.Errorf("failed to detach template files: %w", err)
+	}
+	return sm.db.Delete(template).Error
+}
+
+// CreateServerFromTemplate creates a server using the jar, mod pack, command
+// and server.properties defined by a template. The placeholders of the
+// properties are filled in with variables, their defaults and the built-in
+// values; {{port}} gets a free port.
+func (sm *ServerManager) CreateServerFromTemplate(templateID uint, name, path string, userID uint, variables map[string]interface{}) (uint, error) {
+	template, err := sm.GetTemplate(templateID)
+	if err != nil {
+		return 0, fmt.Errorf("template not found: %w", err)
+	}
+
+	var port int
+	if templateUsesPort(template) {
+		if port, err = sm.allocatePort(); err != nil {
+			return 0, err
+		}
+	}
+	values, err := templateValues(template, name, port, variables)
+	if err != nil {
+		return 0, err
+	}
+
+	command := templateCommand(template)
+
+	var additionalFileIDs []uint
+	for _, file := range template.AdditionalFiles {
+		additionalFileIDs = append(additionalFileIDs, file.ID)
+	}
+
+	id, err := sm.createServer(name, path, command, &template.JarFile, template.ModPack, additionalFileIDs, userID, func(uint) error {
+		if template.Properties == "" {
+			return nil
+		}
+		propertiesPath := filepath.Join(path, "server.properties")
+		if err := os.WriteFile(propertiesPath, []byte(renderProperties(template.Properties, values)), 0644); err != nil {
+			return fmt.Errorf("failed to write server.properties: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Info("Created server from template", "server_id", id, "name", name, "template", template.Name)
+	return id, nil
+}
+
+// templateCommand returns the template's command, deriving one from its
+// memory setting when none was given.
+func templateCommand(template *model.Template) string {
+	if template.ExecutableCommand != "" {
+		return template.ExecutableCommand
+	}
+	if template.MemoryMB > 0 {
+		return fmt.Sprintf("java -Xms%dM -Xmx%dM -jar server.jar nogui", template.MemoryMB, template.MemoryMB)
+	}
+	return "java -jar server.jar nogui"
+}
@@ -0,0 +1,78 @@
+package server_manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestTemplateNameReusableAfterDelete(t *testing.T) {
+	sm := newTestManager(t)
+	jar := createTestJar(t, sm)
+
+	template := &model.Template{Name: "paper", JarFileID: jar.ID}
+	if err := sm.CreateTemplate(template, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.CreateTemplate(&model.Template{Name: "paper", JarFileID: jar.ID}, nil); !errors.Is(err, ErrTemplateExists) {
+		t.Fatalf("got %v for a duplicate name, want ErrTemplateExists", err)
+	}
+	if err := sm.DeleteTemplate(template.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.CreateTemplate(&model.Template{Name: "paper", JarFileID: jar.ID}, nil); err != nil {
+		t.Errorf("re-creating a deleted template's name: %v", err)
+	}
+}
+
+func TestCreateServerFromTemplate(t *testing.T) {
+	sm := newTestManager(t)
+	jar := createTestJar(t, sm)
+	user := createTestUser(t, sm, "alice", model.RoleAdmin)
+
+	difficulty := "normal"
+	template := &model.Template{
+		Name:       "survival",
+		JarFileID:  jar.ID,
+		MemoryMB:   2048,
+		Properties: "motd={{motd}}\ndifficulty={{difficulty}}\n",
+		Variables: []model.TemplateVariable{
+			{Name: "difficulty", Type: model.VariableString, Default: &difficulty},
+		},
+	}
+	if err := sm.CreateTemplate(template, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.CreateTemplate(&model.Template{Name: "broken", JarFileID: jar.ID + 1}, nil); err == nil {
+		t.Error("created a template with an unknown jar")
+	}
+
+	path := filepath.Join(sm.commonDir, "servers", "smp")
+	id, err := sm.CreateServerFromTemplate(template.ID, "smp", path, user.ID, map[string]interface{}{"motd": "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	properties, err := os.ReadFile(filepath.Join(path, "server.properties"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(properties) != "motd=Hello\ndifficulty=normal\n" {
+		t.Errorf("got server.properties\n%s", properties)
+	}
+	config, err := sm.getServerConfig(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(config.ExecutableCommand, "-Xmx2048M") {
+		t.Errorf("got command %q, want the template's memory", config.ExecutableCommand)
+	}
+
+	if _, err := sm.CreateServerFromTemplate(template.ID, "other", filepath.Join(sm.commonDir, "servers", "other"), user.ID,
+		map[string]interface{}{"difficulty": float64(3)}); err == nil {
+		t.Error("created a server with a mistyped variable")
+	}
+}
@@ -0,0 +1,258 @@
+package server_manager
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// ErrServerRunning is returned for changes that need the server stopped
+var ErrServerRunning = errors.New("server must be stopped first")
+
+// ServerUpdate holds the fields of a server to change; nil fields are left as they are
+type ServerUpdate struct {
+	Name              *string
+	ExecutableCommand *string
+	JarFileID         *uint
+	// ModPackID 0 removes the mod pack
+	ModPackID   *uint
+	AutoStart   *bool
+	Description *string
+	Notes       *string
+	// Timezone "" logs in the zone of the host
+	Timezone *string
+}
+
+// Length limits of the free-text server fields
+const (
+	maxDescriptionLength = 1024
+	maxNotesLength       = 64 << 10
+)
+
+// UpdateServer applies an update to a server owned by userID. Renaming stops
+// the server and moves its directory; jar or mod pack changes require the
+// server to be stopped. Command and timezone changes take effect on the next
+// start. Servers on nodes accept only the changes that leave their files alone.
+func (sm *ServerManager) UpdateServer(id uint, userID uint, update ServerUpdate) (*model.Server, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	if (update.JarFileID != nil || update.ModPackID != nil) && srv.IsRunning() {
+		return nil, ErrServerRunning
+	}
+	// Renames and jar or mod pack changes rewrite the server directory
+	if update.JarFileID != nil || update.ModPackID != nil || (update.Name != nil && *update.Name != serverModel.Name) {
+		if err := checkLocal(serverModel); err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := sm.getServerConfig(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server config: %w", err)
+	}
+
+	var jarFile *model.JarFile
+	if update.JarFileID != nil {
+		if jarFile, err = sm.GetJarFileByID(*update.JarFileID); err != nil {
+			return nil, fmt.Errorf("jar file %d not found: %w", *update.JarFileID, err)
+		}
+	}
+	var modPack *model.ModPack
+	if update.ModPackID != nil && *update.ModPackID != 0 {
+		if modPack, err = sm.GetModPackByID(*update.ModPackID); err != nil {
+			return nil, fmt.Errorf("mod pack %d not found: %w", *update.ModPackID, err)
+		}
+	}
+
+	oldPath := serverModel.Path
+	if update.Name != nil && *update.Name != serverModel.Name {
+		name := *update.Name
+		if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return nil, fmt.Errorf("invalid server name %q", name)
+		}
+		newPath := filepath.Join(filepath.Dir(oldPath), name)
+		if err := sm.checkServerUnique(name, newPath); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			return nil, fmt.Errorf("directory %s already exists", newPath)
+		}
+		if err := srv.StopAndWait(stopTimeout); err != nil {
+			return nil, fmt.Errorf("failed to stop server: %w", err)
+		}
+		// Archived servers have no directory to move until they are thawed
+		if serverModel.ArchivedAt == nil {
+			if err := moveDir(oldPath, newPath); err != nil {
+				return nil, fmt.Errorf("failed to move server directory: %w", err)
+			}
+		}
+		serverModel.Name = name
+		serverModel.Path = newPath
+	}
+	if update.AutoStart != nil {
+		serverModel.AutoStart = *update.AutoStart
+	}
+	if update.Description != nil {
+		if len(*update.Description) > maxDescriptionLength {
+			return nil, fmt.Errorf("description must be at most %d bytes", maxDescriptionLength)
+		}
+		serverModel.Description = *update.Description
+	}
+	if update.Notes != nil {
+		if len(*update.Notes) > maxNotesLength {
+			return nil, fmt.Errorf("notes must be at most %d bytes", maxNotesLength)
+		}
+		serverModel.Notes = *update.Notes
+	}
+	if update.Timezone != nil {
+		if err := validateTimezone(*update.Timezone); err != nil {
+			return nil, err
+		}
+		serverModel.Timezone = *update.Timezone
+	}
+	if update.ExecutableCommand != nil {
+		if strings.TrimSpace(*update.ExecutableCommand) == "" {
+			return nil, fmt.Errorf("executable command must not be empty")
+		}
+		config.ExecutableCommand = *update.ExecutableCommand
+	}
+	if jarFile != nil {
+		config.JarFileID = jarFile.ID
+		config.JarFile = *jarFile
+	}
+	if update.ModPackID != nil {
+		config.ModPackID = nil
+		config.ModPack = nil
+		if modPack != nil {
+			config.ModPackID = &modPack.ID
+		}
+	}
+
+	tx := sm.db.Begin()
+	if err := tx.Save(serverModel).Error; err != nil {
+		tx.Rollback()
+		sm.revertMove(oldPath, serverModel.Path)
+		return nil, fmt.Errorf("failed to update server: %w", err)
+	}
+	if err := tx.Model(config).Select("ExecutableCommand", "JarFileID", "ModPackID").Updates(config).Error; err != nil {
+		tx.Rollback()
+		sm.revertMove(oldPath, serverModel.Path)
+		return nil, fmt.Errorf("failed to update server config: %w", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		sm.revertMove(oldPath, serverModel.Path)
+		return nil, fmt.Errorf("failed to commit server update: %w", err)
+	}
+
+	if jarFile != nil && bundledJarPath(serverModel.Path, jarFile.Path) == "server.jar" {
+		if err := sm.linkArtifact(jarFile.Path, filepath.Join(serverModel.Path, "server.jar")); err != nil {
+			return nil, fmt.Errorf("failed to link jar file: %w", err)
+		}
+	}
+	if update.ModPackID != nil {
+		modsPath := filepath.Join(serverModel.Path, "mods")
+		if modPack != nil {
+			if err := sm.linkArtifact(modPack.Path, modsPath); err != nil {
+				return nil, fmt.Errorf("failed to link mod pack: %w", err)
+			}
+		} else if utils.IsLinkedArtifact(modsPath) {
+			os.RemoveAll(modsPath)
+		}
+	}
+
+	// The instance caches the model, including the path, and the config
+	sm.mutex.Lock()
+	if !srv.IsRunning() {
+		sm.servers[id] = sm.newServer(serverModel)
+	} else {
+		srv.InvalidateConfig()
+	}
+	sm.mutex.Unlock()
+
+	slog.Info("Updated server", "server_id", id)
+	return serverModel, nil
+}
+
+// validateTimezone checks that name is an IANA zone. Empty stands for the
+// zone of the host.
+func validateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "Local" {
+		return fmt.Errorf("invalid timezone %q", name)
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid timezone %q", name)
+	}
+	return nil
+}
+
+// revertMove moves a renamed server directory back after a failed update
+func (sm *ServerManager) revertMove(oldPath, newPath string) {
+	if oldPath == newPath {
+		return
+	}
+	if _, err := os.Stat(newPath); os.IsNotExist(err) {
+		return
+	}
+	if err := moveDir(newPath, oldPath); err != nil {
+		slog.Error("Failed to move server directory back", "from", newPath, "to", oldPath, "error", err)
+	}
+}
+
+// moveDir moves a server directory, copying it when the rename fails
+// because newPath is on another volume. Absolute symlinks into the old
+// directory are repointed to the new one.
+func moveDir(oldPath, newPath string) error {
+	if err := os.Rename(oldPath, newPath); err != nil {
+		// The copy goes to a staging directory so newPath only appears
+		// complete
+		staging := newPath + ".moving"
+		os.RemoveAll(staging)
+		if copyErr := utils.CopyDir(oldPath, staging); copyErr != nil {
+			os.RemoveAll(staging)
+			return fmt.Errorf("%w; copying failed too: %w", err, copyErr)
+		}
+		if err := os.Rename(staging, newPath); err != nil {
+			os.RemoveAll(staging)
+			return err
+		}
+		if err := os.RemoveAll(oldPath); err != nil {
+			slog.Warn("Failed to remove old server directory after copying it", "path", oldPath, "error", err)
+		}
+	}
+	return repairLinks(newPath, oldPath)
+}
+
+// repairLinks repoints the absolute symlinks below dir that lead into
+// oldDir, the previous location of dir
+func repairLinks(dir, oldDir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			return err
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(oldDir, target)
+		if !filepath.IsAbs(target) || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		return os.Symlink(filepath.Join(dir, rel), path)
+	})
+}
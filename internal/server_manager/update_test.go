@@ -0,0 +1,65 @@
+package server_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestMoveDirRepairsLinks(t *testing.T) {
+	root := t.TempDir()
+	shared := filepath.Join(root, "shared", "paper.jar")
+	os.MkdirAll(filepath.Dir(shared), 0755)
+	os.WriteFile(shared, []byte("jar"), 0644)
+
+	oldPath := filepath.Join(root, "survival")
+	os.MkdirAll(filepath.Join(oldPath, "world"), 0755)
+	os.WriteFile(filepath.Join(oldPath, "world", "level.dat"), []byte("level"), 0644)
+	os.Symlink(shared, filepath.Join(oldPath, "server.jar"))
+	os.Symlink(filepath.Join(oldPath, "world"), filepath.Join(oldPath, "world_link"))
+	os.Symlink("world", filepath.Join(oldPath, "relative_link"))
+
+	newPath := filepath.Join(root, "creative")
+	if err := moveDir(oldPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Fatalf("old directory still exists: %v", err)
+	}
+
+	for link, want := range map[string]string{
+		"server.jar":    shared,
+		"world_link":    filepath.Join(newPath, "world"),
+		"relative_link": "world",
+	} {
+		target, err := os.Readlink(filepath.Join(newPath, link))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != want {
+			t.Errorf("%s points at %s, want %s", link, target, want)
+		}
+	}
+}
+
+func TestUpdateServerTimezone(t *testing.T) {
+	sm := newTestManager(t)
+	user := createTestUser(t, sm, "alice", model.RoleAdmin)
+	id := createTestServer(t, sm, user, "survival")
+
+	timezone := "Europe/Berlin"
+	serverModel, err := sm.UpdateServer(id, user.ID, ServerUpdate{Timezone: &timezone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverModel.Timezone != timezone {
+		t.Errorf("got timezone %q, want %q", serverModel.Timezone, timezone)
+	}
+	for _, invalid := range []string{"Mars/Olympus", "Local"} {
+		if _, err := sm.UpdateServer(id, user.ID, ServerUpdate{Timezone: &invalid}); err == nil {
+			t.Errorf("accepted timezone %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package server_manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// uploadSessionTTL is how long an upload session is kept after its last
+// progress
+const uploadSessionTTL = time.Hour
+
+// States of an upload session
+const (
+	UploadPending    = "pending"
+	UploadReceiving  = "receiving"
+	UploadValidating = "validating"
+	UploadComplete   = "complete"
+	UploadFailed     = "failed"
+)
+
+// ErrUploadSessionNotFound is returned for unknown or expired upload
+// sessions and for sessions of other users
+var ErrUploadSessionNotFound = errors.New("upload session not found")
+
+// UploadProgress is a snapshot of an upload session
+type UploadProgress struct {
+	ID string `json:"id"`
+	// Total is the size of the upload request, or 0 when unknown
+	Total    int64  `json:"total"`
+	Received int64  `json:"received"`
+	State    string `json:"state" example:"receiving"`
+	// ETASeconds estimates the time left to receive the upload
+	ETASeconds float64   `json:"eta_seconds,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+}
+
+// UploadSession follows the progress of one upload so it can be watched
+// while the upload request is still running
+type UploadSession struct {
+	userID   uint
+	mutex    sync.Mutex
+	progress UploadProgress
+	updated  time.Time
+	// wake is closed and replaced whenever the progress changes
+	wake chan struct{}
+}
+
+// Progress returns the current progress and a channel closed when it
+// changes
+func (s *UploadSession) Progress() (UploadProgress, <-chan struct{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	progress := s.progress
+	if progress.State == UploadReceiving && progress.Total > progress.Received && progress.Received > 0 {
+		elapsed := time.Since(progress.StartedAt).Seconds()
+		rate := float64(progress.Received) / elapsed
+		progress.ETASeconds = float64(progress.Total-progress.Received) / rate
+	}
+	return progress, s.wake
+}
+
+// Done reports whether the upload has finished, successfully or not
+func (p UploadProgress) Done() bool {
+	return p.State == UploadComplete || p.State == UploadFailed
+}
+
+// update changes the progress under the lock and wakes watchers
+func (s *UploadSession) update(change func(p *UploadProgress)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	change(&s.progress)
+	s.updated = time.Now()
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// Reader counts the bytes read from body, a request of size bytes or -1
+// when unknown, as received. The session moves to validating once body is
+// exhausted.
+func (s *UploadSession) Reader(body io.Reader, size int64) io.Reader {
+	s.update(func(p *UploadProgress) {
+		p.State = UploadReceiving
+		p.Received = 0
+		p.StartedAt = time.Now()
+		if size > 0 {
+			p.Total = size
+		}
+	})
+	return &uploadReader{session: s, body: body}
+}
+
+// Finish marks the upload complete, or failed with err
+func (s *UploadSession) Finish(err error) {
+	s.update(func(p *UploadProgress) {
+		if err != nil {
+			p.State = UploadFailed
+			p.Error = err.Error()
+			return
+		}
+		p.State = UploadComplete
+	})
+}
+
+type uploadReader struct {
+	session *UploadSession
+	body    io.Reader
+}
+
+func (r *uploadReader) Read(buf []byte) (int, error) {
+	n, err := r.body.Read(buf)
+	r.session.update(func(p *UploadProgress) {
+		p.Received += int64(n)
+		// Form parsers stop at the closing boundary without reading EOF
+		if (err == io.EOF || p.Total > 0 && p.Received >= p.Total) && p.State == UploadReceiving {
+			p.State = UploadValidating
+		}
+	})
+	return n, err
+}
+
+// CreateUploadSession starts an upload session of userID for an upload of
+// total bytes, which may be 0 when unknown
+func (sm *ServerManager) CreateUploadSession(userID uint, total int64) *UploadSession {
+	id := make([]byte, 16)
+	rand.Read(id)
+	session := &UploadSession{
+		userID:   userID,
+		progress: UploadProgress{ID: hex.EncodeToString(id), Total: total, State: UploadPending},
+		updated:  time.Now(),
+		wake:     make(chan struct{}),
+	}
+
+	sm.uploadMutex.Lock()
+	defer sm.uploadMutex.Unlock()
+	for id, existing := range sm.uploads {
+		existing.mutex.Lock()
+		expired := time.Since(existing.updated) > uploadSessionTTL
+		existing.mutex.Unlock()
+		if expired {
+			delete(sm.uploads, id)
+		}
+	}
+	sm.uploads[session.progress.ID] = session
+	return session
+}
+
+// GetUploadSession returns an upload session of userID
+func (sm *ServerManager) GetUploadSession(id string, userID uint) (*UploadSession, error) {
+	sm.uploadMutex.Lock()
+	defer sm.uploadMutex.Unlock()
+	session, exists := sm.uploads[id]
+	if !exists || session.userID != userID {
+		return nil, ErrUploadSessionNotFound
+	}
+	return session, nil
+}
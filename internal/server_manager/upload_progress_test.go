@@ -0,0 +1,34 @@
+package server_manager
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestUploadSessionProgress(t *testing.T) {
+	sm := &ServerManager{uploads: make(map[string]*UploadSession)}
+	session := sm.CreateUploadSession(1, 0)
+	progress, changed := session.Progress()
+	if _, err := sm.GetUploadSession(progress.ID, 2); !errors.Is(err, ErrUploadSessionNotFound) {
+		t.Fatalf("another user found the session: %v", err)
+	}
+
+	body := session.Reader(strings.NewReader(strings.Repeat("x", 100)), 100)
+	<-changed
+	buf := make([]byte, 60)
+	io.ReadFull(body, buf)
+	if progress, _ := session.Progress(); progress.State != UploadReceiving || progress.Received != 60 || progress.Total != 100 {
+		t.Fatalf("unexpected progress after 60 bytes: %+v", progress)
+	}
+	io.ReadFull(body, buf[:40])
+	if progress, _ := session.Progress(); progress.State != UploadValidating {
+		t.Fatalf("session is %s after the whole body was read", progress.State)
+	}
+
+	session.Finish(errors.New("invalid mod pack"))
+	if progress, _ := session.Progress(); !progress.Done() || progress.Error != "invalid mod pack" {
+		t.Fatalf("unexpected progress after failing: %+v", progress)
+	}
+}
@@ -0,0 +1,57 @@
+package server_manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/server"
+)
+
+// Usage computes the servers, memory, disk and backups a user consumes.
+// Limits and API call counts are filled in by the caller.
+func (sm *ServerManager) Usage(userID uint) (*model.Usage, error) {
+	var servers []model.Server
+	if err := sm.db.Where("user_id = ?", userID).Find(&servers).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch servers: %w", err)
+	}
+
+	usage := &model.Usage{}
+	usage.Servers.Used = int64(len(servers))
+
+	for _, srv := range servers {
+		var config model.ServerConfig
+		if err := sm.db.Where("server_id = ?", srv.ID).First(&config).Error; err == nil {
+			usage.MemoryMB.Used += server.CommandMemoryMB(config.ExecutableCommand)
+		}
+		usage.DiskBytes.Used += DirSize(srv.Path)
+	}
+
+	var backups []model.Backup
+	if err := sm.db.Where("user_id = ? AND status = ?", userID, model.BackupStatusCompleted).Find(&backups).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch backups: %w", err)
+	}
+	usage.Backups.Used = int64(len(backups))
+	for _, backup := range backups {
+		usage.BackupBytes += backup.SizeBytes
+	}
+
+	return usage, nil
+}
+
+// DirSize returns the total size of regular files below path without
+// following symlinks, so shared jars and mod packs are not counted
+func DirSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
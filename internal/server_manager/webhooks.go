@@ -0,0 +1,251 @@
+package server_manager
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+const (
+	// webhookAttempts is how often a delivery is tried before giving up
+	webhookAttempts = 5
+	// webhookRetryDelay is the delay before the first retry; it doubles
+	// with every further attempt
+	webhookRetryDelay = 10 * time.Second
+	// webhookTimeout bounds a single delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookDeliveryHistory is how many deliveries ListWebhookDeliveries returns
+	webhookDeliveryHistory = 50
+)
+
+// webhookClient delivers webhooks without reaching the manager's network
+var webhookClient = publicClient(webhookTimeout)
+
+// WebhookOptions describes a webhook to create
+type WebhookOptions struct {
+	URL string
+	// ServerID limits the webhook to one server; nil covers all servers
+	ServerID *uint
+	// Events limits the event types delivered; empty delivers all
+	Events []string
+}
+
+// CreateWebhook registers a webhook and returns it with its signing secret
+func (sm *ServerManager) CreateWebhook(userID uint, opts WebhookOptions) (*model.Webhook, string, error) {
+	parsed, err := url.Parse(opts.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "", fmt.Errorf("invalid webhook URL %q", opts.URL)
+	}
+	if opts.ServerID != nil {
+		if _, _, err := sm.ownedServer(*opts.ServerID, userID, model.PermissionManage); err != nil {
+			return nil, "", err
+		}
+	}
+	for _, event := range opts.Events {
+		if !slices.Contains(model.EventTypes, event) {
+			return nil, "", fmt.Errorf("unknown event %q", event)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	webhook := &model.Webhook{
+		UserID:   userID,
+		ServerID: opts.ServerID,
+		URL:      opts.URL,
+		Secret:   hex.EncodeToString(secret),
+		Events:   strings.Join(opts.Events, ","),
+		Enabled:  true,
+	}
+	if err := sm.db.Create(webhook).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, webhook.Secret, nil
+}
+
+// ListWebhooks returns the webhooks of a user
+func (sm *ServerManager) ListWebhooks(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := sm.db.Where("user_id = ?", userID).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery history
+func (sm *ServerManager) DeleteWebhook(id uint, userID uint) error {
+	webhook, err := sm.ownedWebhook(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := sm.db.Where("webhook_id = ?", webhook.ID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return fmt.Errorf("failed to delete deliveries: %w", err)
+	}
+	return sm.db.Delete(webhook).Error
+}
+
+// ListWebhookDeliveries returns the most recent deliveries of a webhook
+func (sm *ServerManager) ListWebhookDeliveries(id uint, userID uint) ([]model.WebhookDelivery, error) {
+	webhook, err := sm.ownedWebhook(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []model.WebhookDelivery
+	err = sm.db.Where("webhook_id = ?", webhook.ID).Order("id DESC").
+		Limit(webhookDeliveryHistory).Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (sm *ServerManager) ownedWebhook(id uint, userID uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := sm.db.Where("id = ? AND user_id = ?", id, userID).First(&webhook).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// StartWebhookDispatcher delivers every event to the matching webhooks of
+// the server's owner until stop is closed. Deliveries that fail are retried
+// with exponential backoff; retries pending at shutdown are lost.
+func (sm *ServerManager) StartWebhookDispatcher(stop <-chan struct{}) {
+	events := sm.SubscribeEvents()
+	go func() {
+		defer sm.UnsubscribeEvents(events)
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				sm.dispatchWebhooks(event)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// dispatchWebhooks records a delivery for each webhook subscribed to event
+// and sends them in the background
+func (sm *ServerManager) dispatchWebhooks(event model.Event) {
+	var webhooks []model.Webhook
+	query := sm.db.Where("enabled = ? AND (server_id IS NULL OR server_id = ?)", true, event.ServerID)
+	if event.TeamID != nil {
+		// Team members receive events of the shared server on webhooks bound
+		// to it. Grantees cannot bind webhooks to a server they do not manage.
+		members := sm.db.Model(&model.Membership{}).Select("user_id").Where("team_id = ? AND accepted = ?", *event.TeamID, true)
+		query = query.Where("(user_id = ? OR (server_id IS NOT NULL AND user_id IN (?)))", event.UserID, members)
+	} else {
+		query = query.Where("user_id = ?", event.UserID)
+	}
+	err := query.Find(&webhooks).Error
+	if err != nil {
+		slog.Error("Failed to fetch webhooks", "event", event.Type, "error", err)
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to encode event", "event", event.Type, "error", err)
+		return
+	}
+
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if webhook.Events != "" && !slices.Contains(strings.Split(webhook.Events, ","), event.Type) {
+			continue
+		}
+		delivery := &model.WebhookDelivery{
+			WebhookID: webhook.ID,
+			Event:     event.Type,
+			Payload:   string(payload),
+		}
+		if err := sm.db.Create(delivery).Error; err != nil {
+			slog.Error("Failed to record webhook delivery", "webhook_id", webhook.ID, "error", err)
+			continue
+		}
+		go sm.deliverWebhook(webhook, delivery)
+	}
+}
+
+// deliverWebhook posts a delivery until it succeeds or runs out of attempts,
+// recording each attempt
+func (sm *ServerManager) deliverWebhook(webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	delay := webhookRetryDelay
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		status, err := postWebhook(webhook, delivery)
+
+		updates := map[string]interface{}{
+			"attempts":    attempt,
+			"status_code": status,
+			"error":       "",
+		}
+		if err != nil {
+			updates["error"] = err.Error()
+		} else {
+			updates["delivered_at"] = time.Now()
+		}
+		if dbErr := sm.db.Model(delivery).Updates(updates).Error; dbErr != nil {
+			slog.Error("Failed to record webhook delivery", "delivery_id", delivery.ID, "error", dbErr)
+		}
+		if err == nil {
+			return
+		}
+
+		slog.Warn("Webhook delivery failed", "delivery_id", delivery.ID, "webhook_id", webhook.ID, "attempt", attempt, "error", err)
+		if attempt < webhookAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+// postWebhook sends one delivery attempt. The body is signed with
+// HMAC-SHA256 of the webhook secret in the X-Mcgonalds-Signature header.
+func postWebhook(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Mcgonalds-Event", delivery.Event)
+	req.Header.Set("X-Mcgonalds-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Mcgonalds-Signature", "sha256="+SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of body keyed with secret
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,65 @@
+package server_manager
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestPostWebhookSignsPayload(t *testing.T) {
+	var signature, event string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Mcgonalds-Signature")
+		event = r.Header.Get("X-Mcgonalds-Event")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	webhook := &model.Webhook{URL: srv.URL, Secret: "s3cret"}
+	delivery := &model.WebhookDelivery{Event: model.EventServerCrashed, Payload: `{"type":"server.crashed"}`}
+
+	// The default client never reaches a loopback address
+	if _, err := postWebhook(webhook, delivery); !errors.Is(err, ErrAddressNotPublic) {
+		t.Fatalf("loopback delivery returned %v", err)
+	}
+	if body != nil {
+		t.Fatal("loopback webhook received a delivery")
+	}
+
+	defer func(client *http.Client) { webhookClient = client }(webhookClient)
+	webhookClient = srv.Client()
+
+	status, err := postWebhook(webhook, delivery)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("postWebhook = %d, %v", status, err)
+	}
+
+	if string(body) != delivery.Payload {
+		t.Errorf("got body %q, want %q", body, delivery.Payload)
+	}
+	if event != model.EventServerCrashed {
+		t.Errorf("got event header %q", event)
+	}
+	if want := "sha256=" + SignWebhookPayload("s3cret", body); signature != want {
+		t.Errorf("got signature %q, want %q", signature, want)
+	}
+}
+
+func TestPostWebhookRejectsErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	defer func(client *http.Client) { webhookClient = client }(webhookClient)
+	webhookClient = srv.Client()
+
+	status, err := postWebhook(&model.Webhook{URL: srv.URL}, &model.WebhookDelivery{Payload: "{}"})
+	if err == nil || status != http.StatusInternalServerError {
+		t.Errorf("postWebhook = %d, %v; want 500 and an error", status, err)
+	}
+}
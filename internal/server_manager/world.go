@@ -0,0 +1,54 @@
+package server_manager
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+)
+
+// DeleteWorld deletes the world of a stopped server: the directory of its
+// level-name and those of its nether and end dimensions. The server
+// generates a new world on its next start. It returns the directories
+// deleted.
+func (sm *ServerManager) DeleteWorld(id uint, userID uint) ([]string, error) {
+	serverModel, srv, err := sm.ownedServer(id, userID, model.PermissionFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocal(serverModel); err != nil {
+		return nil, err
+	}
+	if serverModel.ArchivedAt != nil {
+		return nil, ErrServerArchived
+	}
+
+	release, err := sm.lockFiles(id, FileLockWorldDelete, srv)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	deleted := []string{}
+	for dir := range worldDirs(serverModel.Path) {
+		// level-name comes from server.properties, which users edit
+		path, err := utils.SafeJoin(serverModel.Path, dir)
+		if err != nil {
+			return deleted, err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", dir, err)
+		}
+		deleted = append(deleted, dir)
+	}
+	sort.Strings(deleted)
+
+	slog.Info("Deleted world", "server_id", id, "directories", deleted)
+	return deleted, nil
+}
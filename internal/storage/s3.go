@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/olindenbaum/mcgonalds/internal/config"
+)
+
+// uploadPartSize is the multipart chunk buffered per upload. Without it the
+// client sizes parts for the largest possible object, about 560 MiB each.
+// S3 allows 10000 parts, so objects can reach 320 GiB.
+const uploadPartSize = 32 << 20
+
+// S3 stores objects in an S3-compatible bucket (AWS S3, MinIO, Backblaze B2)
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 connects to the bucket described by cfg and checks that it exists
+func NewS3(cfg *config.S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(context.Background(), cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %s does not exist", cfg.Bucket)
+	}
+
+	return &S3{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix}, nil
+}
+
+func (s *S3) Name() string {
+	return "s3"
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, objectTags map[string]string) (int64, error) {
+	opts := minio.PutObjectOptions{ContentType: "application/gzip", PartSize: uploadPartSize}
+	if len(objectTags) > 0 {
+		if _, err := tags.NewTags(objectTags, true); err != nil {
+			return 0, fmt.Errorf("invalid object tags: %w", err)
+		}
+		opts.UserTags = objectTags
+	}
+
+	// An unknown size makes the client upload in multipart chunks as data arrives
+	info, err := s.client.PutObject(ctx, s.bucket, s.objectName(key), r, -1, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return info.Size, nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, s.objectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return object, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, s.objectName(key), minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) objectName(key string) string {
+	return path.Join(s.prefix, key)
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// Backend stores objects under string keys. Implementations stream data and
+// must not buffer whole objects in memory.
+type Backend interface {
+	// Name identifies the backend in backup records, e.g. "s3"
+	Name() string
+	// Put uploads r under key and returns the number of bytes written.
+	// Tags are attached to the object where the backend supports it.
+	Put(ctx context.Context, key string, r io.Reader, tags map[string]string) (int64, error)
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,24 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HTTPMiddleware starts a span for every request, continuing a trace passed
+// in by the client. Spans are named after the matched route template so
+// requests for different servers are grouped together.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewMiddleware("http", otelhttp.WithSpanNameFormatter(routeSpanName))(next)
+}
+
+func routeSpanName(_ string, r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return r.Method + " " + template
+		}
+	}
+	return r.Method
+}
@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CreateTarGz archives srcDir into a gzip-compressed tarball at dest and
+// returns the size of the written archive. Paths for which skip returns true
+// are left out; skip receives slash-separated paths relative to srcDir.
+func CreateTarGz(srcDir, dest string, skip func(rel string, info os.FileInfo) bool) (int64, error) {
+	out, err := os.Create(dest)
+	if err != nil {
+		return 0, err
+	}
+
+	writeErr := WriteTarGz(srcDir, out, skip)
+	if err := out.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		os.Remove(dest)
+		return 0, writeErr
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// WriteTarGz streams a gzip-compressed tarball of srcDir to w
+func WriteTarGz(srcDir string, w io.Writer, skip func(rel string, info os.FileInfo) bool) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	walkErr := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if skip != nil && skip(rel, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return addTarEntry(tw, path, rel, info)
+	})
+
+	if err := tw.Close(); err != nil && walkErr == nil {
+		walkErr = err
+	}
+	if err := gz.Close(); err != nil && walkErr == nil {
+		walkErr = err
+	}
+	return walkErr
+}
+
+func addTarEntry(tw *tar.Writer, path, rel string, info os.FileInfo) error {
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		link = target
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = rel
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// ExtractTarGz unpacks a gzip-compressed tarball the manager wrote itself,
+// such as a backup, into destDir, see RestoreTarGz
+func ExtractTarGz(src, destDir string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return RestoreTarGz(in, destDir)
+}
+
+// RestoreTarGz unpacks a gzip-compressed tarball the manager wrote itself,
+// such as a backup, streamed from r into destDir. Symlinks, including those
+// to shared jars and mod packs, are recreated as they were archived, but no
+// entry is ever written through one.
+func RestoreTarGz(r io.Reader, destDir string) error {
+	return readTarGz(r, destDir, func(name, linkname string) error { return nil })
+}
+
+// ReadTarGz unpacks a gzip-compressed tarball from elsewhere, such as an
+// uploaded bundle, streamed from r into destDir. Entries that would escape
+// destDir are rejected, and so are symlinks that are absolute or point
+// outside it.
+func ReadTarGz(r io.Reader, destDir string) error {
+	return readTarGz(r, destDir, func(name, linkname string) error {
+		if filepath.IsAbs(linkname) {
+			return fmt.Errorf("symlink %q points to absolute path %q", name, linkname)
+		}
+		target := filepath.Join(filepath.Dir(filepath.FromSlash(name)), filepath.FromSlash(linkname))
+		if _, err := SafeJoin(destDir, filepath.ToSlash(target)); err != nil {
+			return fmt.Errorf("symlink %q points outside the archive", name)
+		}
+		return nil
+	})
+}
+
+// readTarGz unpacks a tarball into destDir without following symlinks
+// in it, recreating the symlinks checkLink accepts
+func readTarGz(r io.Reader, destDir string, checkLink func(name, linkname string) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := SafeJoin(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(destDir, target)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := MkdirAllIn(destDir, rel, os.FileMode(header.Mode).Perm()|0700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := checkLink(header.Name, header.Linkname); err != nil {
+				return err
+			}
+			if err := SymlinkIn(destDir, rel, header.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			file, err := OpenIn(destDir, rel, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tr); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SafeJoin joins an untrusted relative path onto base, refusing paths that
+// resolve outside of base
+func SafeJoin(base, rel string) (string, error) {
+	target := filepath.Join(base, filepath.FromSlash(rel))
+	cleanBase := filepath.Clean(base)
+	if target != cleanBase && !strings.HasPrefix(target, cleanBase+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes %s", rel, base)
+	}
+	return target, nil
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarGzRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "world", "region"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "world", "region", "r.0.0.mca"), []byte("region"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "world", "session.lock"), []byte("lock"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/shared/jar_files/paper.jar", filepath.Join(src, "server.jar")); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	skip := func(rel string, info os.FileInfo) bool { return filepath.Base(rel) == "session.lock" }
+	if _, err := CreateTarGz(src, archive, skip); err != nil {
+		t.Fatalf("CreateTarGz: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "restored")
+	if err := ExtractTarGz(archive, dest); err != nil {
+		t.Fatalf("ExtractTarGz: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "world", "region", "r.0.0.mca"))
+	if err != nil || string(data) != "region" {
+		t.Errorf("region file not restored: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "world", "session.lock")); !os.IsNotExist(err) {
+		t.Errorf("session.lock should have been skipped")
+	}
+	if link, err := os.Readlink(filepath.Join(dest, "server.jar")); err != nil || link != "/shared/jar_files/paper.jar" {
+		t.Errorf("symlink not restored: %q, %v", link, err)
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	if _, err := SafeJoin("/srv/game", "../etc/passwd"); err == nil {
+		t.Error("expected traversal to be rejected")
+	}
+	if got, err := SafeJoin("/srv/game", "world/level.dat"); err != nil || got != "/srv/game/world/level.dat" {
+		t.Errorf("SafeJoin() = %q, %v", got, err)
+	}
+}
+
+// writeTestTarGz writes a tarball of the given headers, with body as the
+// contents of regular files
+func writeTestTarGz(t *testing.T, headers []*tar.Header, body string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, header := range headers {
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(body))
+		}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			tw.Write([]byte(body))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestReadTarGzRejectsMaliciousLinks(t *testing.T) {
+	outside := t.TempDir()
+	victim := filepath.Join(outside, "passwd")
+	if err := os.WriteFile(victim, []byte("root:x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bundles := map[string][]*tar.Header{
+		"absolute link": {
+			{Name: "server/evil", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "server/evil/passwd", Typeflag: tar.TypeReg},
+		},
+		"link escaping": {
+			{Name: "server/evil", Typeflag: tar.TypeSymlink, Linkname: "../../../../../../../../" + outside},
+		},
+		"file through link": {
+			{Name: "server/world/", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "server/data", Typeflag: tar.TypeSymlink, Linkname: "world"},
+			{Name: "server/data/level.dat", Typeflag: tar.TypeReg},
+		},
+	}
+	for name, headers := range bundles {
+		t.Run(name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "staging")
+			if err := ReadTarGz(writeTestTarGz(t, headers, "pwned"), dest); err == nil {
+				t.Error("the bundle was extracted")
+			}
+			if _, err := os.Stat(filepath.Join(dest, "server", "world", "level.dat")); !os.IsNotExist(err) {
+				t.Error("a file was written through a symlink")
+			}
+		})
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "root:x" {
+		t.Fatalf("the file outside was changed to %q", data)
+	}
+}
+
+func TestRestoreTarGzKeepsLinksButNeverWritesThroughThem(t *testing.T) {
+	outside := t.TempDir()
+	headers := []*tar.Header{
+		{Name: "server.jar", Typeflag: tar.TypeSymlink, Linkname: "/shared/jar_files/paper.jar"},
+		{Name: "plugins", Typeflag: tar.TypeSymlink, Linkname: outside},
+		{Name: "plugins/evil.jar", Typeflag: tar.TypeReg},
+	}
+	dest := filepath.Join(t.TempDir(), "restored")
+	if err := RestoreTarGz(writeTestTarGz(t, headers, "pwned"), dest); err == nil {
+		t.Error("a file was written through a symlink")
+	}
+	if link, err := os.Readlink(filepath.Join(dest, "server.jar")); err != nil || link != "/shared/jar_files/paper.jar" {
+		t.Errorf("symlink not restored: %q, %v", link, err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.jar")); !os.IsNotExist(err) {
+		t.Error("the file was written outside the directory")
+	}
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin || freebsd
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCopyDirSkipsSpecialFiles(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "server")
+	os.MkdirAll(filepath.Join(src, "world"), 0755)
+	os.WriteFile(filepath.Join(src, "world", "level.dat"), []byte("level"), 0644)
+	os.Symlink("world/level.dat", filepath.Join(src, "level"))
+	// Nothing ever writes to the FIFO, so opening it would hang the copy
+	if err := syscall.Mkfifo(filepath.Join(src, "console"), 0644); err != nil {
+		t.Skip("mkfifo:", err)
+	}
+
+	dst := filepath.Join(root, "copy")
+	if err := CopyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "world", "level.dat")); err != nil || string(data) != "level" {
+		t.Errorf("level.dat = %q, %v", data, err)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "level")); err != nil || link != "world/level.dat" {
+		t.Errorf("link = %q, %v", link, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "console")); !os.IsNotExist(err) {
+		t.Errorf("FIFO was copied: %v", err)
+	}
+}
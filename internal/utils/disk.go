@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+
+package utils
+
+import "syscall"
+
+// FreeDiskSpace returns the bytes available to unprivileged users on the
+// filesystem holding path
+func FreeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// DiskUsage returns the percentage of the filesystem holding path that is
+// in use, counting like df does
+func DiskUsage(path string) (float64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	used := uint64(stat.Blocks) - uint64(stat.Bfree)
+	total := used + uint64(stat.Bavail)
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(used) / float64(total) * 100, nil
+}
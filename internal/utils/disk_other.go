@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd)
+
+package utils
+
+import "errors"
+
+// FreeDiskSpace is not supported on this platform
+func FreeDiskSpace(path string) (uint64, error) {
+	return 0, errors.New("free disk space is not supported on this platform")
+}
+
+// DiskUsage is not supported on this platform
+func DiskUsage(path string) (float64, error) {
+	return 0, errors.New("disk usage is not supported on this platform")
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// HostMemory is the physical memory of the machine in bytes
+type HostMemory struct {
+	TotalBytes     uint64
+	AvailableBytes uint64
+}
+
+// ReadHostMemory reads the memory of the machine from /proc/meminfo, which
+// only exists on Linux
+func ReadHostMemory() (*HostMemory, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseMeminfo(f)
+}
+
+func parseMeminfo(r io.Reader) (*HostMemory, error) {
+	var memory HostMemory
+	var total, available bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			memory.TotalBytes, total = kb<<10, true
+		case "MemAvailable:":
+			memory.AvailableBytes, available = kb<<10, true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !total || !available {
+		return nil, errors.New("meminfo lacks MemTotal or MemAvailable")
+	}
+	return &memory, nil
+}
+
+// LoadAverage reads the 1, 5 and 15 minute load averages from
+// /proc/loadavg, which only exists on Linux
+func LoadAverage() ([3]float64, error) {
+	var load [3]float64
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return load, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return load, fmt.Errorf("malformed loadavg %q", strings.TrimSpace(string(data)))
+	}
+	for i := range load {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return load, fmt.Errorf("malformed loadavg: %w", err)
+		}
+	}
+	return load, nil
+}
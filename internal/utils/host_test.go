@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMeminfo(t *testing.T) {
+	meminfo := "MemTotal:       16303832 kB\nMemFree:          712348 kB\nMemAvailable:    9825332 kB\nBuffers:          402836 kB\n"
+	memory, err := parseMeminfo(strings.NewReader(meminfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if memory.TotalBytes != 16303832<<10 || memory.AvailableBytes != 9825332<<10 {
+		t.Errorf("got %+v", memory)
+	}
+
+	if _, err := parseMeminfo(strings.NewReader("MemTotal: 1024 kB\n")); err == nil {
+		t.Error("expected an error without MemAvailable")
+	}
+}
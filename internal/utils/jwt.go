@@ -1,49 +1,267 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/olindenbaum/mcgonalds/internal/config"
 )
 
+const (
+	defaultJWTIssuer     = "mcgonalds"
+	defaultJWTExpiration = 24 * time.Hour
+)
+
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	// Purpose marks single-purpose tokens, such as password reset links,
+	// which never authenticate API requests
+	Purpose string `json:"purpose,omitempty"`
+	// Fingerprint binds a purpose token to account state, so it stops
+	// working once that state changes
+	Fingerprint string `json:"fingerprint,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT generates a JWT token for a user
-func GenerateJWT(userID uint, username string) (string, error) {
-	cfg, err := config.LoadConfig()
+// Purposes of single-purpose tokens
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeVerifyEmail   = "verify_email"
+	PurposeOAuthState    = "oauth_state"
+)
+
+// verificationKey is a key tokens may be signed with, identified by its kid
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// JWTIssuer signs and validates tokens with keys loaded once at startup
+type JWTIssuer struct {
+	method     jwt.SigningMethod
+	keyID      string
+	signingKey interface{}
+	keys       map[string]verificationKey
+	issuer     string
+	audience   string
+	// expiration may be changed by a config reload while tokens are issued
+	expiration atomic.Int64
+}
+
+// NewJWTIssuer loads the signing key and any previous verification keys
+// described by the config
+func NewJWTIssuer(cfg *config.JWTConfig) (*JWTIssuer, error) {
+	issuer := &JWTIssuer{
+		keyID:    cfg.KeyID,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		keys:     make(map[string]verificationKey),
+	}
+	if issuer.issuer == "" {
+		issuer.issuer = defaultJWTIssuer
+	}
+	if err := issuer.SetExpiration(cfg.Expiration); err != nil {
+		return nil, err
+	}
+
+	method, signingKey, verifyKey, err := loadJWTKey(cfg.Algorithm, cfg.Secret, cfg.PrivateKeyFile, cfg.PublicKeyFile)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "mcgonalds",
-		},
+	if signingKey == nil {
+		return nil, fmt.Errorf("jwt: no signing key configured for %s", method.Alg())
 	}
+	issuer.method = method
+	issuer.signingKey = signingKey
+	issuer.keys[cfg.KeyID] = verificationKey{method: method, key: verifyKey}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.JWTConfig.Secret))
+	// Tokens issued before key_id was set carry no kid, so one previous key
+	// may leave it empty once the current key has one
+	for _, previous := range cfg.PreviousKeys {
+		if _, taken := issuer.keys[previous.KeyID]; taken {
+			return nil, fmt.Errorf("jwt: previous keys need a unique key_id")
+		}
+		method, _, verifyKey, err := loadJWTKey(previous.Algorithm, previous.Secret, "", previous.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt previous key %s: %w", previous.KeyID, err)
+		}
+		issuer.keys[previous.KeyID] = verificationKey{method: method, key: verifyKey}
+	}
+
+	return issuer, nil
+}
+
+// loadJWTKey resolves the signing method and keys for an algorithm. The
+// signing key is nil when only a public key is given.
+func loadJWTKey(algorithm, secret, privateKeyFile, publicKeyFile string) (jwt.SigningMethod, interface{}, interface{}, error) {
+	switch algorithm {
+	case "", "HS256":
+		if secret == "" {
+			return nil, nil, nil, errors.New("jwt: HS256 requires a secret")
+		}
+		return jwt.SigningMethodHS256, []byte(secret), []byte(secret), nil
+
+	case "RS256":
+		var private *rsa.PrivateKey
+		var public *rsa.PublicKey
+		if privateKeyFile != "" {
+			data, err := os.ReadFile(privateKeyFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to read private key: %w", err)
+			}
+			if private, err = jwt.ParseRSAPrivateKeyFromPEM(data); err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid RSA private key: %w", err)
+			}
+			public = &private.PublicKey
+		}
+		if publicKeyFile != "" {
+			data, err := os.ReadFile(publicKeyFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to read public key: %w", err)
+			}
+			if public, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid RSA public key: %w", err)
+			}
+		}
+		if public == nil {
+			return nil, nil, nil, errors.New("jwt: RS256 requires a private or public key file")
+		}
+		if private == nil {
+			return jwt.SigningMethodRS256, nil, public, nil
+		}
+		return jwt.SigningMethodRS256, private, public, nil
+
+	case "EdDSA":
+		var private crypto.PrivateKey
+		var public crypto.PublicKey
+		if privateKeyFile != "" {
+			data, err := os.ReadFile(privateKeyFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to read private key: %w", err)
+			}
+			if private, err = jwt.ParseEdPrivateKeyFromPEM(data); err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid Ed25519 private key: %w", err)
+			}
+			public = private.(ed25519.PrivateKey).Public()
+		}
+		if publicKeyFile != "" {
+			data, err := os.ReadFile(publicKeyFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to read public key: %w", err)
+			}
+			if public, err = jwt.ParseEdPublicKeyFromPEM(data); err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid Ed25519 public key: %w", err)
+			}
+		}
+		if public == nil {
+			return nil, nil, nil, errors.New("jwt: EdDSA requires a private or public key file")
+		}
+		return jwt.SigningMethodEdDSA, private, public, nil
+	}
+
+	return nil, nil, nil, fmt.Errorf("jwt: unsupported algorithm %q", algorithm)
 }
 
-// ValidateJWT validates a JWT token and returns the claims
-func ValidateJWT(tokenStr string) (*Claims, error) {
-	cfg, err := config.LoadConfig()
+// GenerateJWT generates a JWT token for a user's session. The session ID
+// is carried in the jti claim.
+func (i *JWTIssuer) GenerateJWT(userID uint, username, sessionID string) (string, error) {
+	claims := &Claims{UserID: userID, Username: username}
+	claims.ID = sessionID
+	return i.sign(claims, i.Expiration())
+}
+
+// Expiration returns how long session tokens are valid
+func (i *JWTIssuer) Expiration() time.Duration {
+	return time.Duration(i.expiration.Load())
+}
+
+// SetExpiration changes how long newly issued session tokens are valid. An
+// empty value restores the default.
+func (i *JWTIssuer) SetExpiration(value string) error {
+	expiration := defaultJWTExpiration
+	if value != "" {
+		var err error
+		if expiration, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid jwt.expiration: %w", err)
+		}
+	}
+	i.expiration.Store(int64(expiration))
+	return nil
+}
+
+// GeneratePurposeToken generates a token that is only accepted by
+// ValidatePurposeToken for the same purpose
+func (i *JWTIssuer) GeneratePurposeToken(userID uint, username, purpose, fingerprint string, ttl time.Duration) (string, error) {
+	return i.sign(&Claims{UserID: userID, Username: username, Purpose: purpose, Fingerprint: fingerprint}, ttl)
+}
+
+// sign fills in the registered claims and signs them with the current key
+func (i *JWTIssuer) sign(claims *Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        claims.ID,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    i.issuer,
+	}
+	if i.audience != "" {
+		claims.Audience = jwt.ClaimStrings{i.audience}
+	}
+
+	token := jwt.NewWithClaims(i.method, claims)
+	if i.keyID != "" {
+		token.Header["kid"] = i.keyID
+	}
+	return token.SignedString(i.signingKey)
+}
+
+// ValidateJWT validates a session token and returns the claims. The kid
+// header selects the verification key, so tokens signed with a previous key
+// stay valid until they expire.
+func (i *JWTIssuer) ValidateJWT(tokenStr string) (*Claims, error) {
+	claims, err := i.parse(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// ValidatePurposeToken validates a token generated for purpose
+func (i *JWTIssuer) ValidatePurposeToken(tokenStr, purpose string) (*Claims, error) {
+	claims, err := i.parse(tokenStr)
 	if err != nil {
 		return nil, err
 	}
+	if claims.Purpose != purpose {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// parse verifies the signature, issuer and audience of a token
+func (i *JWTIssuer) parse(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.JWTConfig.Secret), nil
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := i.keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", keyID)
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return key.key, nil
 	})
 
 	if err != nil {
@@ -56,6 +274,12 @@ func ValidateJWT(tokenStr string) (*Claims, error) {
 	if !token.Valid {
 		return nil, errors.New("invalid token")
 	}
+	if claims.Issuer != i.issuer {
+		return nil, errors.New("invalid token issuer")
+	}
+	if i.audience != "" && !claims.VerifyAudience(i.audience, true) {
+		return nil, errors.New("invalid token audience")
+	}
 
 	return claims, nil
 }
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+)
+
+func TestJWTRoundTrip(t *testing.T) {
+	issuer, err := NewJWTIssuer(&config.JWTConfig{Secret: "secret", Audience: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.GenerateJWT(7, "steve", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := issuer.ValidateJWT(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || claims.Username != "steve" || claims.ID != "session-1" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	other, _ := NewJWTIssuer(&config.JWTConfig{Secret: "secret", Audience: "other"})
+	if _, err := other.ValidateJWT(token); err == nil {
+		t.Fatal("token accepted for the wrong audience")
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "ed25519.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := NewJWTIssuer(&config.JWTConfig{KeyID: "old", Secret: "old-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := old.GenerateJWT(1, "alex", "session-1")
+
+	rotated, err := NewJWTIssuer(&config.JWTConfig{
+		Algorithm:      "EdDSA",
+		KeyID:          "new",
+		PrivateKeyFile: keyFile,
+		PreviousKeys:   []config.JWTKey{{KeyID: "old", Secret: "old-secret"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.ValidateJWT(token); err != nil {
+		t.Fatalf("token signed with previous key rejected: %v", err)
+	}
+	fresh, err := rotated.GenerateJWT(1, "alex", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.ValidateJWT(fresh); err != nil {
+		t.Fatalf("token signed with current key rejected: %v", err)
+	}
+	if _, err := old.ValidateJWT(fresh); err == nil {
+		t.Fatal("old issuer accepted a token from an unknown key")
+	}
+}
+
+func TestJWTRotationFromKeylessConfig(t *testing.T) {
+	old, err := NewJWTIssuer(&config.JWTConfig{Secret: "old-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := old.GenerateJWT(1, "alex", "session-1")
+
+	previous := config.JWTKey{Secret: "old-secret"}
+	rotated, err := NewJWTIssuer(&config.JWTConfig{KeyID: "new", Secret: "new-secret", PreviousKeys: []config.JWTKey{previous}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.ValidateJWT(token); err != nil {
+		t.Fatalf("token without a kid rejected after rotation: %v", err)
+	}
+
+	// Without a kid of its own, the current key already takes kid-less tokens
+	if _, err := NewJWTIssuer(&config.JWTConfig{Secret: "new-secret", PreviousKeys: []config.JWTKey{previous}}); err == nil {
+		t.Error("accepted a kid-less previous key next to a kid-less current key")
+	}
+	if _, err := NewJWTIssuer(&config.JWTConfig{KeyID: "new", Secret: "new-secret", PreviousKeys: []config.JWTKey{previous, previous}}); err == nil {
+		t.Error("accepted two kid-less previous keys")
+	}
+}
+
+func TestPurposeTokensDoNotAuthenticate(t *testing.T) {
+	issuer, err := NewJWTIssuer(&config.JWTConfig{Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.GeneratePurposeToken(7, "steve", PurposePasswordReset, "abc", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := issuer.ValidateJWT(token); err == nil {
+		t.Fatal("reset token accepted as a session token")
+	}
+	if _, err := issuer.ValidatePurposeToken(token, PurposeVerifyEmail); err == nil {
+		t.Fatal("reset token accepted for email verification")
+	}
+	claims, err := issuer.ValidatePurposeToken(token, PurposePasswordReset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || claims.Fingerprint != "abc" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	session, _ := issuer.GenerateJWT(7, "steve", "session-1")
+	if _, err := issuer.ValidatePurposeToken(session, PurposePasswordReset); err == nil {
+		t.Fatal("session token accepted as a reset token")
+	}
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+)
+
+// ErrMailDisabled is returned when no mail server is configured
+var ErrMailDisabled = errors.New("email is not configured")
+
+// Mailer sends plain text mail through the configured SMTP server
+type Mailer struct {
+	cfg config.SMTPConfig
+}
+
+// NewMailer returns a mailer for cfg; it is disabled when cfg has no host
+func NewMailer(cfg config.SMTPConfig) *Mailer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &Mailer{cfg: cfg}
+}
+
+// Enabled reports whether a mail server is configured
+func (m *Mailer) Enabled() bool {
+	return m.cfg.Host != ""
+}
+
+// Send mails a plain text message to one recipient. STARTTLS is used when
+// the server offers it.
+func (m *Mailer) Send(to, subject, body string) error {
+	if !m.Enabled() {
+		return ErrMailDisabled
+	}
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("invalid mail header")
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	msg := "From: " + m.cfg.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + strings.ReplaceAll(body, "\n", "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port)
+	if err := smtp.SendMail(addr, auth, m.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send mail to %s: %w", to, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"golang.org/x/oauth2"
+)
+
+// oauthPreset holds the endpoints and defaults of a well-known provider
+type oauthPreset struct {
+	endpoint    oauth2.Endpoint
+	userInfoURL string
+	scopes      []string
+	// Profile fields in the user info response
+	subject, username, email, verified string
+}
+
+var oauthPresets = map[string]oauthPreset{
+	"discord": {
+		endpoint: oauth2.Endpoint{
+			AuthURL:  "https://discord.com/oauth2/authorize",
+			TokenURL: "https://discord.com/api/oauth2/token",
+		},
+		userInfoURL: "https://discord.com/api/users/@me",
+		scopes:      []string{"identify", "email"},
+		subject:     "id", username: "username", email: "email", verified: "verified",
+	},
+	"github": {
+		endpoint: oauth2.Endpoint{
+			AuthURL:  "https://github.com/login/oauth/authorize",
+			TokenURL: "https://github.com/login/oauth/access_token",
+		},
+		userInfoURL: "https://api.github.com/user",
+		scopes:      []string{"read:user"},
+		// GitHub does not say whether the public email is verified
+		subject: "id", username: "login", email: "email",
+	},
+	"google": {
+		endpoint: oauth2.Endpoint{
+			AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+		},
+		userInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		scopes:      []string{"openid", "email", "profile"},
+		subject:     "sub", username: "name", email: "email", verified: "email_verified",
+	},
+	"oidc": {
+		scopes:  []string{"openid", "email", "profile"},
+		subject: "sub", username: "preferred_username", email: "email", verified: "email_verified",
+	},
+}
+
+// OAuthProfile is the identity a provider reports for a user
+type OAuthProfile struct {
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+}
+
+// OAuthProvider runs the authorization code flow against one provider
+type OAuthProvider struct {
+	Name   string
+	oauth  *oauth2.Config
+	preset oauthPreset
+}
+
+// NewOAuthProviders builds the configured providers, keyed by name.
+// Callbacks are expected at <publicURL><callbackPath>/<name>/callback.
+func NewOAuthProviders(cfgs []config.OAuthProvider, publicURL, callbackPath string) (map[string]*OAuthProvider, error) {
+	providers := make(map[string]*OAuthProvider, len(cfgs))
+	for _, cfg := range cfgs {
+		kind := cfg.Type
+		if kind == "" {
+			kind = cfg.Name
+		}
+		preset, ok := oauthPresets[kind]
+		if !ok {
+			return nil, fmt.Errorf("oauth provider %s: unknown type %q", cfg.Name, kind)
+		}
+		if cfg.Name == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("oauth provider %q: name and client_id are required", cfg.Name)
+		}
+		if _, dup := providers[cfg.Name]; dup {
+			return nil, fmt.Errorf("oauth provider %s is configured twice", cfg.Name)
+		}
+		if cfg.AuthURL != "" {
+			preset.endpoint.AuthURL = cfg.AuthURL
+		}
+		if cfg.TokenURL != "" {
+			preset.endpoint.TokenURL = cfg.TokenURL
+		}
+		if cfg.UserInfoURL != "" {
+			preset.userInfoURL = cfg.UserInfoURL
+		}
+		if preset.endpoint.AuthURL == "" || preset.endpoint.TokenURL == "" || preset.userInfoURL == "" {
+			return nil, fmt.Errorf("oauth provider %s: auth_url, token_url and userinfo_url are required", cfg.Name)
+		}
+		scopes := cfg.Scopes
+		if len(scopes) == 0 {
+			scopes = preset.scopes
+		}
+
+		providers[cfg.Name] = &OAuthProvider{
+			Name: cfg.Name,
+			oauth: &oauth2.Config{
+				ClientID:     cfg.ClientID,
+				ClientSecret: cfg.ClientSecret,
+				Endpoint:     preset.endpoint,
+				RedirectURL:  strings.TrimSuffix(publicURL, "/") + callbackPath + "/" + cfg.Name + "/callback",
+				Scopes:       scopes,
+			},
+			preset: preset,
+		}
+	}
+	return providers, nil
+}
+
+// AuthCodeURL returns the provider's consent page URL carrying state
+func (p *OAuthProvider) AuthCodeURL(state string) string {
+	return p.oauth.AuthCodeURL(state)
+}
+
+// Exchange trades an authorization code for the user's profile
+func (p *OAuthProvider) Exchange(ctx context.Context, code string) (*OAuthProfile, error) {
+	token, err := p.oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.preset.userInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.oauth.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user info: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user info request failed with status %d", resp.StatusCode)
+	}
+	return p.preset.parseProfile(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parseProfile picks the profile fields out of a user info response
+func (preset oauthPreset) parseProfile(r io.Reader) (*OAuthProfile, error) {
+	var info map[string]interface{}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&info); err != nil {
+		return nil, fmt.Errorf("invalid user info response: %w", err)
+	}
+
+	field := func(name string) string {
+		if v, ok := info[name]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	profile := &OAuthProfile{
+		Subject:  field(preset.subject),
+		Username: field(preset.username),
+		Email:    strings.ToLower(field(preset.email)),
+	}
+	if verified, ok := info[preset.verified].(bool); ok && preset.verified != "" {
+		profile.EmailVerified = verified
+	}
+	if profile.Subject == "" {
+		return nil, errors.New("user info response has no subject")
+	}
+	return profile, nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+)
+
+func TestParseOAuthProfile(t *testing.T) {
+	github := oauthPresets["github"]
+	profile, err := github.parseProfile(strings.NewReader(`{"id": 583231, "login": "octocat", "email": "Octo@Example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Subject != "583231" || profile.Username != "octocat" || profile.Email != "octo@example.com" || profile.EmailVerified {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+
+	discord := oauthPresets["discord"]
+	profile, err = discord.parseProfile(strings.NewReader(`{"id": "80351110224678912", "username": "nelly", "email": "nelly@example.com", "verified": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Subject != "80351110224678912" || !profile.EmailVerified {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+
+	if _, err := discord.parseProfile(strings.NewReader(`{"username": "nelly"}`)); err == nil {
+		t.Fatal("profile without subject accepted")
+	}
+}
+
+func TestNewOAuthProviders(t *testing.T) {
+	providers, err := NewOAuthProviders([]config.OAuthProvider{{Name: "discord", ClientID: "id"}}, "https://mc.example.com/", "/api/v1/auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := providers["discord"].oauth.RedirectURL; got != "https://mc.example.com/api/v1/auth/discord/callback" {
+		t.Fatalf("unexpected redirect URL %s", got)
+	}
+
+	if _, err := NewOAuthProviders([]config.OAuthProvider{{Name: "corp", Type: "oidc", ClientID: "id"}}, "", "/auth"); err == nil {
+		t.Fatal("oidc provider without endpoints accepted")
+	}
+}
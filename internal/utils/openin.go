@@ -0,0 +1,122 @@
+//go:build linux || darwin || freebsd
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// OpenIn opens name, a path relative to dir, like os.OpenFile, but fails
+// instead of following a symlink in any component of name. Servers own
+// their directories and may replace any file or directory in them with a
+// link; this keeps the manager, which may run as root, inside dir. With
+// os.O_CREATE missing parent directories are created as well.
+func OpenIn(dir, name string, flag int, perm os.FileMode) (*os.File, error) {
+	parts, err := splitRelative(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := openParentIn(dir, parts, flag&os.O_CREATE != 0)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	path := filepath.Join(dir, name)
+	file, err := unix.Openat(fd, parts[len(parts)-1], flag|unix.O_NOFOLLOW|unix.O_CLOEXEC, uint32(perm.Perm()))
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(file), path), nil
+}
+
+// RemoveIn removes the file name below dir like os.Remove without
+// following symlinks in its parent directories
+func RemoveIn(dir, name string) error {
+	parts, err := splitRelative(dir, name)
+	if err != nil {
+		return err
+	}
+	fd, err := openParentIn(dir, parts, false)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	if err := unix.Unlinkat(fd, parts[len(parts)-1], 0); err != nil {
+		return &os.PathError{Op: "remove", Path: filepath.Join(dir, name), Err: err}
+	}
+	return nil
+}
+
+// MkdirAllIn creates the directory name below dir and any missing parents
+// like os.MkdirAll, failing if any component is a symlink
+func MkdirAllIn(dir, name string, perm os.FileMode) error {
+	parts, err := splitRelative(dir, name)
+	if err != nil {
+		return err
+	}
+	fd, err := openParentIn(dir, parts, true)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	path := filepath.Join(dir, name)
+	last := parts[len(parts)-1]
+	if err := unix.Mkdirat(fd, last, uint32(perm.Perm())); err != nil && err != unix.EEXIST {
+		return &os.PathError{Op: "mkdir", Path: path, Err: err}
+	}
+	// An existing entry must be a directory, not a link to one
+	sub, err := unix.Openat(fd, last, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "mkdir", Path: path, Err: err}
+	}
+	return unix.Close(sub)
+}
+
+// SymlinkIn creates name below dir as a symlink to target, creating missing
+// parent directories without following symlinks
+func SymlinkIn(dir, name, target string) error {
+	parts, err := splitRelative(dir, name)
+	if err != nil {
+		return err
+	}
+	fd, err := openParentIn(dir, parts, true)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	if err := unix.Symlinkat(target, fd, parts[len(parts)-1]); err != nil {
+		return &os.LinkError{Op: "symlink", Old: target, New: filepath.Join(dir, name), Err: err}
+	}
+	return nil
+}
+
+// openParentIn opens the directory holding the last of parts below dir one
+// component at a time, refusing symlinks, and optionally creating missing
+// directories
+func openParentIn(dir string, parts []string, create bool) (int, error) {
+	fd, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, &os.PathError{Op: "open", Path: dir, Err: err}
+	}
+	path := dir
+	for _, part := range parts[:len(parts)-1] {
+		path = filepath.Join(path, part)
+		next, err := unix.Openat(fd, part, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		if err == unix.ENOENT && create {
+			if err = unix.Mkdirat(fd, part, 0o755); err == nil || err == unix.EEXIST {
+				next, err = unix.Openat(fd, part, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+			}
+		}
+		unix.Close(fd)
+		if err != nil {
+			return -1, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+		fd = next
+	}
+	return fd, nil
+}
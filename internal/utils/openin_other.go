@@ -0,0 +1,89 @@
+//go:build !(linux || darwin || freebsd)
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// OpenIn opens name, a path relative to dir, like os.OpenFile, but fails
+// instead of following a symlink in any component of name. Without openat
+// the components are checked before opening, which leaves a race.
+func OpenIn(dir, name string, flag int, perm os.FileMode) (*os.File, error) {
+	path, err := checkNoLinksIn(dir, name, flag&os.O_CREATE != 0)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, flag, perm)
+}
+
+// RemoveIn removes the file name below dir like os.Remove without
+// following symlinks in its parent directories
+func RemoveIn(dir, name string) error {
+	path, err := checkNoLinksIn(dir, name, false)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// MkdirAllIn creates the directory name below dir and any missing parents
+// like os.MkdirAll, failing if any component is a symlink
+func MkdirAllIn(dir, name string, perm os.FileMode) error {
+	path, err := checkNoLinksIn(dir, name, true)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(path, perm); err != nil && !os.IsExist(err) {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &os.PathError{Op: "mkdir", Path: path, Err: fmt.Errorf("not a directory")}
+	}
+	return nil
+}
+
+// SymlinkIn creates name below dir as a symlink to target, creating missing
+// parent directories without following symlinks
+func SymlinkIn(dir, name, target string) error {
+	path, err := checkNoLinksIn(dir, name, true)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(target, path)
+}
+
+// checkNoLinksIn fails if a component of name below dir is a symlink and
+// optionally creates missing parent directories
+func checkNoLinksIn(dir, name string, create bool) (string, error) {
+	parts, err := splitRelative(dir, name)
+	if err != nil {
+		return "", err
+	}
+	path := dir
+	for i, part := range parts {
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) && create {
+			if i < len(parts)-1 {
+				if err := os.Mkdir(path, 0o755); err != nil && !os.IsExist(err) {
+					return "", err
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", &os.PathError{Op: "open", Path: path, Err: fmt.Errorf("is a symlink")}
+		}
+	}
+	return path, nil
+}
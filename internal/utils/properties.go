@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadProperties parses a Java .properties file such as server.properties
+// into a key/value map, skipping comments and blank lines. The file itself
+// must not be a symlink.
+func ReadProperties(path string) (map[string]string, error) {
+	file, err := OpenIn(filepath.Dir(path), filepath.Base(path), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+// SetProperty sets key to value in a .properties file, replacing an existing
+// entry in place or appending a new one. The file is created if missing and
+// is never written through a symlink.
+func SetProperty(path, key, value string) error {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	data, err := ReadFileIn(dir, name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "!") {
+			continue
+		}
+		if k, _, found := strings.Cut(trimmed, "="); found && strings.TrimSpace(k) == key {
+			lines[i] = key + "=" + value
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, key+"="+value)
+	}
+
+	return WriteFileIn(dir, name, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+// Codes of errors that carry more than their status
+const (
+	// CodeValidationFailed is the code of errors listing invalid fields
+	CodeValidationFailed = "validation_failed"
+	// CodeFilesLocked is the code of errors naming the operation holding
+	// the files of a server
+	CodeFilesLocked = "files_locked"
+	// CodeStartFailed is the code of errors diagnosing why a server process
+	// could not start
+	CodeStartFailed = "start_failed"
+)
+
+// errorCodes names the statuses whose code is not derived from their text
+var errorCodes = map[int]string{
+	http.StatusInternalServerError: "internal_error",
+	http.StatusTooManyRequests:     "rate_limited",
+	http.StatusServiceUnavailable:  "unavailable",
+}
+
+// ErrorCode returns the default code of an HTTP error status, e.g.
+// not_found for 404
+func ErrorCode(status int) string {
+	if code, ok := errorCodes[status]; ok {
+		return code
+	}
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
+
+// WriteError replies with a JSON model.ErrorResponse carrying message. It
+// takes the arguments of http.Error, which it replaces in handlers.
+func WriteError(w http.ResponseWriter, message string, status int) {
+	WriteErrorResponse(w, model.ErrorResponse{Status: status, Error: message})
+}
+
+// WriteValidationError replies 400 with the fields that failed validation
+func WriteValidationError(w http.ResponseWriter, fields ...model.FieldError) {
+	WriteErrorResponse(w, model.ErrorResponse{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Error:  "Invalid request fields",
+		Fields: fields,
+	})
+}
+
+// WriteErrorResponse replies with resp, filling in its code and message
+// from the status when they are empty
+func WriteErrorResponse(w http.ResponseWriter, resp model.ErrorResponse) {
+	if resp.Code == "" {
+		resp.Code = ErrorCode(resp.Status)
+	}
+	if resp.Message == "" {
+		resp.Message = http.StatusText(resp.Status)
+	}
+	h := w.Header()
+	// A handler may have set these for the response it meant to send
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(resp.Status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/model"
+)
+
+func TestWriteError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, "Server not found", http.StatusNotFound)
+
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var resp model.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	want := model.ErrorResponse{Status: 404, Code: "not_found", Message: "Not Found", Error: "Server not found"}
+	if resp.Status != want.Status || resp.Code != want.Code || resp.Message != want.Message || resp.Error != want.Error {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+}
+
+func TestWriteValidationError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteValidationError(rec, model.FieldError{Field: "name", Message: "is required"})
+
+	var resp model.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadRequest || resp.Code != CodeValidationFailed || len(resp.Fields) != 1 || resp.Fields[0].Field != "name" {
+		t.Errorf("status %d, response %+v", rec.Code, resp)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	for status, want := range map[int]string{
+		http.StatusBadRequest:            "bad_request",
+		http.StatusRequestEntityTooLarge: "request_entity_too_large",
+		http.StatusInternalServerError:   "internal_error",
+		http.StatusTooManyRequests:       "rate_limited",
+		599:                              "error",
+	} {
+		if got := ErrorCode(status); got != want {
+			t.Errorf("ErrorCode(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// splitRelative splits name into its components, failing unless it is a
+// path below dir
+func splitRelative(dir, name string) ([]string, error) {
+	clean := filepath.Clean(name)
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is not a path below %s", name, dir)
+	}
+	return strings.Split(clean, string(filepath.Separator)), nil
+}
+
+// ReadFileIn reads name below dir like os.ReadFile without following
+// symlinks, see OpenIn
+func ReadFileIn(dir, name string) ([]byte, error) {
+	file, err := OpenIn(dir, name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// WriteFileIn writes name below dir like os.WriteFile without following
+// symlinks, creating missing parent directories, see OpenIn
+func WriteFileIn(dir, name string, data []byte, perm os.FileMode) error {
+	file, err := OpenIn(dir, name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// OpenRegularIn opens the regular file name below dir for reading without
+// following symlinks, see OpenIn. FIFOs and devices are refused without
+// blocking on them.
+func OpenRegularIn(dir, name string) (*os.File, error) {
+	file, err := OpenIn(dir, name, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = fmt.Errorf("%s is not a regular file", file.Name())
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileInRefusesLinks(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(root, "etc")
+	os.MkdirAll(outside, 0755)
+	secret := filepath.Join(outside, "shadow")
+	os.WriteFile(secret, []byte("root:x"), 0600)
+
+	server := filepath.Join(root, "server")
+	os.MkdirAll(server, 0755)
+	os.Symlink(secret, filepath.Join(server, "server.properties"))
+	os.Symlink(outside, filepath.Join(server, "config"))
+
+	if err := SetProperty(filepath.Join(server, "server.properties"), "motd", "hi"); err == nil {
+		t.Error("SetProperty wrote through a symlinked file")
+	}
+	if _, err := ReadProperties(filepath.Join(server, "server.properties")); err == nil {
+		t.Error("ReadProperties read through a symlinked file")
+	}
+	if err := WriteFileIn(server, filepath.Join("config", "shadow"), []byte("x"), 0644); err == nil {
+		t.Error("WriteFileIn wrote through a symlinked directory")
+	}
+	if _, err := ReadFileIn(server, filepath.Join("config", "shadow")); err == nil {
+		t.Error("ReadFileIn read through a symlinked directory")
+	}
+	if err := RemoveIn(server, filepath.Join("config", "shadow")); err == nil {
+		t.Error("RemoveIn removed through a symlinked directory")
+	}
+	if _, err := ReadFileIn(server, filepath.Join("..", "etc", "shadow")); err == nil {
+		t.Error("ReadFileIn left the directory")
+	}
+	if data, _ := os.ReadFile(secret); string(data) != "root:x" {
+		t.Fatalf("the file outside was changed to %q", data)
+	}
+
+	// Missing parents are created
+	if err := WriteFileIn(server, filepath.Join("plugins", "Geyser", "config.yml"), []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ReadFileIn(server, filepath.Join("plugins", "Geyser", "config.yml")); err != nil || string(data) != "a: 1\n" {
+		t.Fatalf("read back %q, %v", data, err)
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LinkSnapshot copies srcDir into destDir, hard-linking files that are
+// unchanged since the snapshot in baseDir instead of copying them again.
+// A file counts as unchanged when size and modification time match. It
+// returns the number of bytes actually copied. baseDir may be empty.
+func LinkSnapshot(srcDir, destDir, baseDir string, skip func(rel string, info os.FileInfo) bool) (int64, error) {
+	var copied int64
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && skip != nil && skip(filepath.ToSlash(rel), info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(destDir, rel)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+
+		if baseDir != "" {
+			base := filepath.Join(baseDir, rel)
+			if baseInfo, err := os.Lstat(base); err == nil && baseInfo.Mode().IsRegular() &&
+				baseInfo.Size() == info.Size() && baseInfo.ModTime().Equal(info.ModTime()) {
+				if err := os.Link(base, target); err == nil {
+					return nil
+				}
+			}
+		}
+
+		if err := CopyFile(path, target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		copied += info.Size()
+		return os.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+	if err != nil {
+		os.RemoveAll(destDir)
+		return 0, err
+	}
+	return copied, nil
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLinkSnapshot(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "world")
+	os.MkdirAll(filepath.Join(src, "region"), 0755)
+	os.WriteFile(filepath.Join(src, "region", "r.0.0.mca"), []byte("unchanged"), 0644)
+	os.WriteFile(filepath.Join(src, "region", "r.0.1.mca"), []byte("before"), 0644)
+
+	first := filepath.Join(root, "first")
+	copied, err := LinkSnapshot(src, first, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != int64(len("unchanged")+len("before")) {
+		t.Fatalf("first snapshot copied %d bytes", copied)
+	}
+
+	later := time.Now().Add(time.Minute)
+	changed := filepath.Join(src, "region", "r.0.1.mca")
+	os.WriteFile(changed, []byte("after!"), 0644)
+	os.Chtimes(changed, later, later)
+
+	second := filepath.Join(root, "second")
+	copied, err = LinkSnapshot(src, second, first, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != int64(len("after!")) {
+		t.Fatalf("second snapshot copied %d bytes, want only the changed file", copied)
+	}
+
+	a, _ := os.Stat(filepath.Join(first, "region", "r.0.0.mca"))
+	b, _ := os.Stat(filepath.Join(second, "region", "r.0.0.mca"))
+	if !os.SameFile(a, b) {
+		t.Fatal("unchanged file was not hard-linked")
+	}
+	if data, _ := os.ReadFile(filepath.Join(first, "region", "r.0.1.mca")); string(data) != "before" {
+		t.Fatalf("base snapshot was modified: %q", data)
+	}
+}
@@ -1,33 +1,150 @@
 package utils
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 )
 
-// CreateSymlink creates a symbolic link from source to destination
-func CreateSymlink(source, destination string) error {
+// Ways shared artifacts such as server.jar and mods are placed into server
+// directories
+const (
+	// LinkAuto symlinks, falling back to hard links and then copies where
+	// symlinks cannot be created
+	LinkAuto     = "auto"
+	LinkSymlink  = "symlink"
+	LinkHardlink = "hardlink"
+	LinkCopy     = "copy"
+	// LinkAutoCopy symlinks, falling back to copies. It is used instead of
+	// LinkAuto for servers that are handed their files, as a hard link
+	// shares its owner with the shared artifact.
+	LinkAutoCopy = "auto-copy"
+)
+
+// provisionedMarker is written into directories placed by hard links or
+// copies so they can be replaced like a symlink
+const provisionedMarker = ".mcgonalds-provisioned"
+
+// LinkArtifact places source at destination using mode, replacing a
+// previously placed artifact. Hard links fall back to copies for files on
+// another volume. Directories that were not placed by LinkArtifact are
+// never replaced.
+func LinkArtifact(source, destination, mode string) error {
 	// Ensure the destination directory exists
 	destDir := filepath.Dir(destination)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
-		log.Fatalf("Failed to create destination directory: %v", err)
+		slog.Error("Failed to create destination directory", "dir", destDir, "error", err)
 		return err
 	}
 
-	// Remove existing symlink if it exists
-	if _, err := os.Lstat(destination); err == nil {
-		os.Remove(destination)
+	if err := removeArtifact(destination); err != nil {
+		slog.Error("Failed to remove existing file", "path", destination, "error", err)
 		return err
 	}
 
-	// Create the symlink
-	err := os.Symlink(source, destination)
+	var err error
+	switch mode {
+	case LinkSymlink:
+		err = os.Symlink(source, destination)
+	case LinkHardlink:
+		err = placeArtifact(source, destination, true)
+	case LinkCopy:
+		err = placeArtifact(source, destination, false)
+	case LinkAuto, "":
+		if err = os.Symlink(source, destination); err != nil {
+			slog.Debug("Symlinks unavailable, linking artifact instead", "destination", destination, "error", err)
+			err = placeArtifact(source, destination, true)
+		}
+	case LinkAutoCopy:
+		if err = os.Symlink(source, destination); err != nil {
+			slog.Debug("Symlinks unavailable, copying artifact instead", "destination", destination, "error", err)
+			err = placeArtifact(source, destination, false)
+		}
+	default:
+		err = fmt.Errorf("unknown link mode %q", mode)
+	}
 	if err != nil {
-		log.Fatalf("Failed to create symlink from %s to %s: %v", source, destination, err)
+		slog.Error("Failed to place artifact", "source", source, "destination", destination, "mode", mode, "error", err)
+		os.RemoveAll(destination)
 		return err
 	}
 
-	log.Printf("Symlink created: %s -> %s", destination, source)
+	slog.Debug("Artifact placed", "source", source, "destination", destination, "mode", mode)
 	return nil
 }
+
+// IsLinkedArtifact reports whether path is a symlink or a directory placed
+// by LinkArtifact
+func IsLinkedArtifact(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+	_, err = os.Stat(filepath.Join(path, provisionedMarker))
+	return info.IsDir() && err == nil
+}
+
+// removeArtifact removes what LinkArtifact placed at path. Other
+// directories are only removed when empty.
+func removeArtifact(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() && IsLinkedArtifact(path) {
+		return os.RemoveAll(path)
+	}
+	return os.Remove(path)
+}
+
+// placeArtifact hard links or copies source to destination. Directories are
+// recreated and marked as provisioned.
+func placeArtifact(source, destination string, hardlink bool) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return linkOrCopy(source, destination, info.Mode().Perm(), hardlink)
+	}
+
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			return linkOrCopy(path, target, info.Mode().Perm(), hardlink)
+		default:
+			// Mod packs hold regular files only
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(destination, provisionedMarker), []byte(source+"\n"), 0644)
+}
+
+func linkOrCopy(source, destination string, perm os.FileMode, hardlink bool) error {
+	if hardlink {
+		if err := os.Link(source, destination); err == nil {
+			return nil
+		}
+	}
+	return CopyFile(source, destination, perm)
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkArtifact(t *testing.T) {
+	root := t.TempDir()
+	pack := filepath.Join(root, "shared", "pack")
+	os.MkdirAll(filepath.Join(pack, "config"), 0755)
+	os.WriteFile(filepath.Join(pack, "a.jar"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(pack, "config", "a.toml"), []byte("x=1"), 0644)
+	jar := filepath.Join(root, "shared", "server.jar")
+	os.WriteFile(jar, []byte("jar"), 0644)
+
+	mods := filepath.Join(root, "server", "mods")
+	if err := LinkArtifact(pack, mods, LinkCopy); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(mods, "config", "a.toml")); string(data) != "x=1" {
+		t.Fatalf("copied mod pack holds %q", data)
+	}
+	if !IsLinkedArtifact(mods) {
+		t.Fatal("copied mod pack is not recognised as provisioned")
+	}
+
+	// A provisioned copy is replaced like a symlink
+	if err := LinkArtifact(pack, mods, LinkSymlink); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(mods); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("mods was not replaced by a symlink: %v", err)
+	}
+
+	serverJar := filepath.Join(root, "server", "server.jar")
+	if err := LinkArtifact(jar, serverJar, LinkHardlink); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.Stat(jar)
+	b, _ := os.Stat(serverJar)
+	if !os.SameFile(a, b) {
+		t.Fatal("jar was not hard-linked")
+	}
+
+	// Directories the manager did not place are left alone
+	own := filepath.Join(root, "server", "plugins")
+	os.MkdirAll(own, 0755)
+	os.WriteFile(filepath.Join(own, "p.jar"), []byte("p"), 0644)
+	if err := LinkArtifact(pack, own, LinkCopy); err == nil {
+		t.Fatal("replaced a directory that was not provisioned")
+	}
+	if _, err := os.Stat(filepath.Join(own, "p.jar")); err != nil {
+		t.Fatalf("unprovisioned directory was modified: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// defaultAutocertCacheDir is where autocert stores certificates when no
+// cache_dir is configured
+const defaultAutocertCacheDir = "autocert"
+
+// NewTLSConfig builds the TLS configuration of the API server. It returns
+// nil when TLS is not configured. In autocert mode it also returns the
+// handler that answers HTTP challenges and redirects everything else to
+// HTTPS.
+func NewTLSConfig(cfg *config.TLSConfig) (*tls.Config, http.Handler, error) {
+	staticCert := cfg.CertFile != "" || cfg.KeyFile != ""
+	switch {
+	case staticCert && cfg.Autocert.Hostname != "":
+		return nil, nil, errors.New("tls.cert_file and tls.autocert.hostname are mutually exclusive")
+	case staticCert:
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, nil, errors.New("tls.cert_file and tls.key_file must both be set")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		}, nil, nil
+	case cfg.Autocert.Hostname != "":
+		cacheDir := cfg.Autocert.CacheDir
+		if cacheDir == "" {
+			cacheDir = defaultAutocertCacheDir
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Autocert.Hostname),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      cfg.Autocert.Email,
+		}
+		tlsConfig := m.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, m.HTTPHandler(nil), nil
+	default:
+		return nil, nil, nil
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/olindenbaum/mcgonalds/internal/config"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	tlsConfig, handler, err := NewTLSConfig(&config.TLSConfig{})
+	if err != nil || tlsConfig != nil || handler != nil {
+		t.Fatalf("empty config should disable TLS, got %v %v %v", tlsConfig, handler, err)
+	}
+
+	if _, _, err := NewTLSConfig(&config.TLSConfig{CertFile: "cert.pem"}); err == nil {
+		t.Fatal("certificate without key accepted")
+	}
+	if _, _, err := NewTLSConfig(&config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", Autocert: config.AutocertConfig{Hostname: "mc.example.com"}}); err == nil {
+		t.Fatal("certificate and autocert accepted together")
+	}
+
+	tlsConfig, handler, err = NewTLSConfig(&config.TLSConfig{Autocert: config.AutocertConfig{Hostname: "mc.example.com", CacheDir: t.TempDir()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig == nil || tlsConfig.GetCertificate == nil || handler == nil {
+		t.Fatal("autocert mode should fetch certificates on demand")
+	}
+}
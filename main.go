@@ -1,24 +1,41 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	_ "github.com/olindenbaum/mcgonalds/docs" // This line is important
 	"github.com/olindenbaum/mcgonalds/internal/config"
 	"github.com/olindenbaum/mcgonalds/internal/db"
+	"github.com/olindenbaum/mcgonalds/internal/dns"
 	"github.com/olindenbaum/mcgonalds/internal/handlers"
+	"github.com/olindenbaum/mcgonalds/internal/logging"
 	"github.com/olindenbaum/mcgonalds/internal/middleware"
+	"github.com/olindenbaum/mcgonalds/internal/node"
+	"github.com/olindenbaum/mcgonalds/internal/server"
 	"github.com/olindenbaum/mcgonalds/internal/server_manager"
+	"github.com/olindenbaum/mcgonalds/internal/storage"
+	"github.com/olindenbaum/mcgonalds/internal/tracing"
+	"github.com/olindenbaum/mcgonalds/internal/utils"
+	"github.com/olindenbaum/mcgonalds/migrations"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
 // @title Minecraft Server Manager API
-// @version 1.0
-// @description This is a Minecraft server management service API
+// @version 2.0
+// @description This is a Minecraft server management service API.
+// @description
+// @description Every JSON response below /api/v2 is wrapped in an envelope. A success holds the documented response in data, `{"data": ..., "meta": {"request_id": "...", "api_version": "v2"}}`, and a failure holds a model.ErrorResponse in error, `{"error": {"status": 404, "code": "not_found", ...}, "meta": {...}}`. Downloads, empty responses and WebSocket upgrades are not wrapped.
+// @description
+// @description The same routes are served below /api/v1 with the responses unwrapped. v1 is deprecated: its responses carry a Deprecation header and a Link header to the v2 path.
 // @termsOfService http://swagger.io/terms/
 
 // @contact.name API Support
@@ -29,38 +46,152 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @host localhost:8080
-// @BasePath /api/v1
+// @BasePath /api/v2
 func main() {
-	cfg, err := config.LoadConfig()
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// loadConfig reads the configuration and sets up logging from it
+func loadConfig(path string) *config.Config {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		fatal("Failed to load config", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.Server.LogLevel, cfg.Server.LogFormat); err != nil {
+		fatal("Failed to configure logging", err)
+	}
+	return cfg
+}
+
+// serve runs the API and the servers it manages until a signal arrives
+func serve(configPath string) {
+	cfg := loadConfig(configPath)
+	var err error
+	shutdownTimeout := defaultShutdownTimeout
+	if cfg.Server.ShutdownTimeout != "" {
+		if shutdownTimeout, err = time.ParseDuration(cfg.Server.ShutdownTimeout); err != nil {
+			fatal("Invalid server.shutdown_timeout", err)
+		}
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to configure tracing", err)
 	}
 
 	err = db.InitDatabase(&cfg.Database)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 
 	database := db.GetDB()
+	count, err := db.Migrate(database, migrations.FS)
+	if err != nil {
+		fatal("Failed to migrate database", err)
+	}
+	slog.Info("Database migrated", "applied", count)
+	if err := database.Use(tracing.GormPlugin{}); err != nil {
+		fatal("Failed to instrument database", err)
+	}
 	// Initialize ServerManager with local storage directory (e.g., "/game_servers/shared")
-	sharedDir := "/game_servers/shared"
-	sm, err := server_manager.NewServerManager(database, sharedDir)
+	sm, err := server_manager.NewServerManager(database, cfg.Storage.CommonDir)
+	if err != nil {
+		fatal("Failed to create server manager", err)
+	}
+	if serversDir, err := server_manager.ServersDir(); err == nil {
+		sm.LogReconcileReport(serversDir)
+	}
+	if cfg.Storage.BackupTarget == "s3" {
+		backend, err := storage.NewS3(&cfg.Storage.S3)
+		if err != nil {
+			fatal("Failed to connect to backup storage", err)
+		}
+		sm.SetBackupStorage(backend)
+	}
+	registrar, err := dns.New(cfg.DNS)
 	if err != nil {
-		log.Fatalf("Failed to create server manager: %v", err)
+		fatal("Failed to configure DNS records", err)
+	}
+	if registrar != nil {
+		sm.SetDNS(registrar)
+	}
+	runtime, err := server.NewRuntime(cfg.Runtime, cfg.Storage.CommonDir)
+	if err != nil {
+		fatal("Failed to configure server runtime", err)
+	}
+	nodes := node.NewHub(sm.AuthenticateNode, sm.RecordNodeCapacity)
+	sm.SetNodeHub(nodes)
+	sm.SetMailer(utils.NewMailer(cfg.SMTP))
+	sm.SetRuntime(nodes.Runtime(runtime))
+	sm.SetDefaultLimits(cfg.Limits)
+	sm.SetDiskReserve(cfg.Storage.DiskReserveMB)
+	sm.SetLinkMode(linkMode(cfg))
+	sm.SetMaxConcurrentStarts(cfg.Server.MaxConcurrentStarts)
+	stopJobs := make(chan struct{})
+	sm.StartBackupScheduler(stopJobs)
+	sm.StartAnnouncementScheduler(stopJobs)
+	sm.StartMaintenanceScheduler(stopJobs)
+	sm.StartPregenMonitor(stopJobs)
+	sm.StartMetricsSampler(stopJobs)
+	sm.StartWebhookDispatcher(stopJobs)
+	sm.StartCrashRecorder(stopJobs)
+	sm.StartPlaceholderResponder(stopJobs)
+	sm.StartIdleShutdown(stopJobs)
+	sm.StartAutoscaler(stopJobs)
+	if days := cfg.Storage.DeletedServerRetentionDays; days > 0 {
+		sm.StartPurgeJob(time.Duration(days)*24*time.Hour, stopJobs)
+	}
+	sm.StartAutoStartServers()
+
+	jwtIssuer, err := utils.NewJWTIssuer(&cfg.JWTConfig)
+	if err != nil {
+		fatal("Failed to load JWT keys", err)
+	}
+	tlsConfig, challengeHandler, err := utils.NewTLSConfig(&cfg.TLS)
+	if err != nil {
+		fatal("Failed to configure TLS", err)
 	}
 
-	h := handlers.NewHandler(database, sm, cfg)
+	h := handlers.NewHandler(database, sm, cfg, jwtIssuer)
+	h.OAuth, err = utils.NewOAuthProviders(cfg.OAuthProviders, cfg.Server.PublicURL, handlers.APIPrefix+handlers.OAuthCallbackPath)
+	if err != nil {
+		fatal("Failed to configure OAuth providers", err)
+	}
+	h.ReloadConfig = func() error {
+		return reloadConfig(configPath, sm, jwtIssuer)
+	}
+	h.Nodes = nodes
 
 	r := mux.NewRouter()
+	r.Use(tracing.HTTPMiddleware)
+	r.Use(middleware.RequestID)
 	r.Use(middleware.DebugMiddleware)
-	// API routes
-	authApi := r.PathPrefix("/api/v1").Subrouter()
-	authApi.Use(middleware.AuthMiddleware(&cfg.JWTConfig))
-	h.RegisterAuthenticatedRoutes(authApi)
+	r.Use(middleware.Gzip)
+	// API routes. Both versions serve the same routes; v2 envelopes its
+	// responses and v1 points clients at it.
+	apiVersions := []struct {
+		prefix  string
+		respond mux.MiddlewareFunc
+	}{
+		{handlers.APIPrefix, middleware.Deprecated(handlers.APIPrefix, handlers.APIPrefixV2)},
+		{handlers.APIPrefixV2, middleware.Enveloped("v2")},
+	}
+	for _, version := range apiVersions {
+		authApi := r.PathPrefix(version.prefix).Subrouter()
+		authApi.Use(version.respond)
+		authApi.Use(middleware.AuthMiddleware(jwtIssuer, sm.CheckSession))
+		authApi.Use(h.Usage.Middleware)
+		authApi.Use(middleware.Audit(sm.RecordAudit))
+		authApi.Use(h.RBAC)
+		authApi.Use(h.ServerPermissions)
+		h.RegisterAuthenticatedRoutes(authApi)
 
-	// Create a separate subrouter for unauthenticated routes
-	unauthApi := r.PathPrefix("/api/v1").Subrouter()
-	h.RegisterUnauthenticatedRoutes(unauthApi)
+		// Create a separate subrouter for unauthenticated routes
+		unauthApi := r.PathPrefix(version.prefix).Subrouter()
+		unauthApi.Use(version.respond)
+		h.RegisterUnauthenticatedRoutes(unauthApi)
+	}
 
 	// Serve Swagger UI
 	r.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
@@ -70,37 +201,157 @@ func main() {
 		httpSwagger.DomID("swagger-ui"),
 	)).Methods(http.MethodGet)
 
-	log.Printf("Starting server on port %s", cfg.Server.Port)
-	log.Printf("API documentation available at http://localhost:%s/swagger/index.html", cfg.Server.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Server.Port, r))
-
 	err = r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 		pathTemplate, err := route.GetPathTemplate()
-		if err == nil {
-			fmt.Println("ROUTE:", pathTemplate)
-		}
-		pathRegexp, err := route.GetPathRegexp()
-		if err == nil {
-			fmt.Println("Path regexp:", pathRegexp)
+		if err != nil {
+			return nil
 		}
-		queriesTemplates, err := route.GetQueriesTemplates()
-		if err == nil {
-			fmt.Println("Queries templates:", strings.Join(queriesTemplates, ","))
+		methods, _ := route.GetMethods()
+		slog.Debug("Route", "path", pathTemplate, "methods", strings.Join(methods, ","))
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to list routes", "error", err)
+	}
+
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	slog.Info("Starting server", "port", cfg.Server.Port, "tls", tlsConfig != nil)
+	slog.Info("API documentation available", "url", scheme+"://localhost:"+cfg.Server.Port+"/swagger/index.html")
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	httpServer := &http.Server{Addr: ":" + cfg.Server.Port, Handler: r, TLSConfig: tlsConfig}
+	go func() {
+		var err error
+		if tlsConfig != nil {
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
 		}
-		queriesRegexps, err := route.GetQueriesRegexp()
-		if err == nil {
-			fmt.Println("Queries regexps:", strings.Join(queriesRegexps, ","))
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal("Server stopped", err)
 		}
-		methods, err := route.GetMethods()
-		if err == nil {
-			fmt.Println("Methods:", strings.Join(methods, ","))
+	}()
+
+	// In autocert mode a plain HTTP port answers ACME challenges and
+	// redirects to HTTPS
+	var challengeServer *http.Server
+	if challengeHandler != nil && cfg.TLS.Autocert.HTTPPort != "" {
+		challengeServer = &http.Server{Addr: ":" + cfg.TLS.Autocert.HTTPPort, Handler: challengeHandler}
+		go func() {
+			if err := challengeServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("Challenge server stopped", err)
+			}
+		}()
+	}
+
+	// SIGHUP reloads the settings that can change at runtime
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := h.ReloadConfig(); err != nil {
+				slog.Error("Failed to reload config", "error", err)
+			}
 		}
-		fmt.Println()
-		return nil
-	})
+	}()
+
+	<-ctx.Done()
+	// A second signal kills the process right away
+	stopSignals()
+	slog.Info("Shutting down", "timeout", shutdownTimeout, "stop_servers", cfg.Server.StopServersOnShutdown)
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to drain HTTP connections", "error", err)
+	}
+	if challengeServer != nil {
+		challengeServer.Close()
+	}
+	close(stopJobs)
+	if err := sm.Shutdown(shutdownCtx, cfg.Server.StopServersOnShutdown); err != nil {
+		slog.Error("Failed to stop servers", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	if err := db.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
+	}
+	slog.Info("Shutdown complete")
+}
+
+// reloadConfig re-reads the configuration and applies the settings that
+// can change without a restart. Everything else keeps its startup value.
+func reloadConfig(path string, sm *server_manager.ServerManager, jwtIssuer *utils.JWTIssuer) error {
+	cfg, err := config.LoadConfig(path)
 	if err != nil {
-		fmt.Println(err)
+		return err
+	}
+	if err := jwtIssuer.SetExpiration(cfg.JWTConfig.Expiration); err != nil {
+		return err
+	}
+	if err := logging.SetLevel(cfg.Server.LogLevel); err != nil {
+		return err
+	}
+	sm.SetDefaultLimits(cfg.Limits)
+	sm.SetDiskReserve(cfg.Storage.DiskReserveMB)
+	sm.SetLinkMode(linkMode(cfg))
+	sm.SetMaxConcurrentStarts(cfg.Server.MaxConcurrentStarts)
+	slog.Info("Config reloaded", "log_level", cfg.Server.LogLevel, "jwt_expiration", jwtIssuer.Expiration())
+	return nil
+}
+
+// linkMode is how artifacts are placed into server directories. Isolated
+// servers are handed everything in their directory, so auto falls back to
+// copies rather than hard links to the shared files.
+func linkMode(cfg *config.Config) string {
+	if cfg.Storage.LinkMode == utils.LinkAuto && cfg.IsolatesUsers() {
+		return utils.LinkAutoCopy
+	}
+	return cfg.Storage.LinkMode
+}
+
+// runAgent serves the control plane as a node agent until a signal arrives.
+// Servers it runs are interrupted when it exits.
+func runAgent(configPath string) {
+	cfg := loadConfig(configPath)
+	if cfg.Agent.ControlURL == "" || cfg.Agent.Token == "" {
+		fatal("Failed to start agent", errors.New("agent.control_url and agent.token must be set"))
 	}
+	root := cfg.Agent.ServersRoot
+	if root == "" {
+		dir, err := server_manager.ServersDir()
+		if err != nil {
+			fatal("Failed to resolve servers directory", err)
+		}
+		root = dir
+	}
+	runtime, err := server.NewRuntime(cfg.Runtime, cfg.Storage.CommonDir)
+	if err != nil {
+		fatal("Failed to configure server runtime", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	a := &node.Agent{URL: cfg.Agent.ControlURL, Token: cfg.Agent.Token, Runtime: runtime, Root: root}
+	slog.Info("Starting node agent", "control_url", cfg.Agent.ControlURL, "servers_root", root)
+	if err := a.Run(ctx); err != nil {
+		fatal("Agent stopped", err)
+	}
+	slog.Info("Agent stopped")
+}
+
+// defaultShutdownTimeout is used when server.shutdown_timeout is not set
+const defaultShutdownTimeout = 2 * time.Minute
 
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
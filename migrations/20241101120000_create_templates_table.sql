@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    description TEXT,
+    jar_file_id INTEGER NOT NULL,
+    mod_pack_id INTEGER,
+    executable_command TEXT,
+    memory_mb INTEGER NOT NULL DEFAULT 0,
+    properties TEXT,
+    user_id INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (jar_file_id) REFERENCES jar_files(id),
+    FOREIGN KEY (mod_pack_id) REFERENCES mod_packs(id)
+);
+
+CREATE TABLE template_additional_files (
+    template_id INTEGER NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    additional_file_id INTEGER NOT NULL REFERENCES additional_files(id) ON DELETE CASCADE,
+    PRIMARY KEY (template_id, additional_file_id)
+);
+
+-- +goose Down
+DROP TABLE template_additional_files;
+DROP TABLE templates;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN offline_mode_acknowledged BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN offline_mode_acknowledged;
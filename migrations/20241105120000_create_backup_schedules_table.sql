@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE backups ADD COLUMN scheduled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE backup_schedules (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL UNIQUE REFERENCES servers(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    cron VARCHAR(255) NOT NULL,
+    keep_last INTEGER NOT NULL DEFAULT 0,
+    keep_daily_days INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- +goose Down
+DROP TABLE backup_schedules;
+ALTER TABLE backups DROP COLUMN scheduled;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE backups ADD COLUMN target VARCHAR(20) NOT NULL DEFAULT 'local';
+
+-- +goose Down
+ALTER TABLE backups DROP COLUMN target;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE backups ADD COLUMN mode VARCHAR(20) NOT NULL DEFAULT 'full';
+ALTER TABLE backups ADD COLUMN base_id INTEGER REFERENCES backups(id) ON DELETE SET NULL;
+ALTER TABLE backup_schedules ADD COLUMN incremental BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE backup_schedules DROP COLUMN incremental;
+ALTER TABLE backups DROP COLUMN base_id;
+ALTER TABLE backups DROP COLUMN mode;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE metric_samples (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    time TIMESTAMP WITH TIME ZONE NOT NULL,
+    resolution INTEGER NOT NULL,
+    cpu_percent DOUBLE PRECISION NOT NULL DEFAULT 0,
+    memory_bytes BIGINT NOT NULL DEFAULT 0,
+    players DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tps DOUBLE PRECISION
+);
+
+CREATE INDEX idx_metric_samples_server_time ON metric_samples (server_id, time);
+
+-- +goose Down
+DROP TABLE metric_samples;
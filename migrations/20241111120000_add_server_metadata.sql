@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE servers ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN notes;
+ALTER TABLE servers DROP COLUMN description;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE audit_logs (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    user_id INTEGER NOT NULL,
+    server_id INTEGER,
+    action VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    status_code INTEGER NOT NULL DEFAULT 0,
+    summary TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX idx_audit_logs_server_id ON audit_logs (server_id);
+
+-- +goose Down
+DROP TABLE audit_logs;
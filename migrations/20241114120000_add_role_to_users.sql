@@ -0,0 +1,7 @@
+-- +goose Up
+-- Existing users keep full access; new users default to viewer
+ALTER TABLE users ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'admin';
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'viewer';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN role;
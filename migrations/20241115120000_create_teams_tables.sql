@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE memberships (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL DEFAULT 'member',
+    accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    invited_by INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_memberships_team_user ON memberships (team_id, user_id);
+
+ALTER TABLE servers ADD COLUMN team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL;
+CREATE INDEX idx_servers_team_id ON servers (team_id);
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN team_id;
+DROP TABLE memberships;
+DROP TABLE teams;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email VARCHAR(255);
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+
+-- +goose Down
+DROP INDEX idx_users_email;
+ALTER TABLE users DROP COLUMN email_verified;
+ALTER TABLE users DROP COLUMN email;
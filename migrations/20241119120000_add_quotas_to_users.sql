@@ -0,0 +1,10 @@
+-- +goose Up
+-- NULL falls back to the limits in the configuration
+ALTER TABLE users ADD COLUMN max_servers BIGINT;
+ALTER TABLE users ADD COLUMN max_memory_mb BIGINT;
+ALTER TABLE users ADD COLUMN max_disk_mb BIGINT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN max_disk_mb;
+ALTER TABLE users DROP COLUMN max_memory_mb;
+ALTER TABLE users DROP COLUMN max_servers;
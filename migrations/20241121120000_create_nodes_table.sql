@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE nodes (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    name VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    cpu_cores INTEGER NOT NULL DEFAULT 0,
+    memory_mb BIGINT NOT NULL DEFAULT 0,
+    free_disk_bytes BIGINT NOT NULL DEFAULT 0,
+    last_seen_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_nodes_name ON nodes (name);
+CREATE UNIQUE INDEX idx_nodes_token_hash ON nodes (token_hash);
+CREATE INDEX idx_nodes_deleted_at ON nodes (deleted_at);
+
+-- NULL runs the server on the control plane
+ALTER TABLE servers ADD COLUMN node_id INTEGER REFERENCES nodes(id);
+CREATE INDEX idx_servers_node_id ON servers (node_id);
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN node_id;
+DROP TABLE nodes;
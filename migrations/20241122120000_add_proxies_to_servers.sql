@@ -0,0 +1,14 @@
+-- +goose Up
+-- Servers with a proxy type run a velocity or bungeecord proxy
+ALTER TABLE servers ADD COLUMN proxy_type VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE servers ADD COLUMN forwarding_secret VARCHAR(255) NOT NULL DEFAULT '';
+-- Backends point at the proxy they are attached to
+ALTER TABLE servers ADD COLUMN proxy_id INTEGER REFERENCES servers(id);
+ALTER TABLE servers ADD COLUMN proxy_address VARCHAR(255) NOT NULL DEFAULT '';
+CREATE INDEX idx_servers_proxy_id ON servers (proxy_id);
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN proxy_address;
+ALTER TABLE servers DROP COLUMN proxy_id;
+ALTER TABLE servers DROP COLUMN forwarding_secret;
+ALTER TABLE servers DROP COLUMN proxy_type;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE addons (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    server_id INTEGER NOT NULL REFERENCES servers(id),
+    name VARCHAR(64) NOT NULL,
+    platform VARCHAR(32) NOT NULL,
+    version VARCHAR(64) NOT NULL DEFAULT '',
+    path VARCHAR(255) NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_addons_server_name ON addons (server_id, name);
+CREATE INDEX idx_addons_deleted_at ON addons (deleted_at);
+
+-- +goose Down
+DROP TABLE addons;
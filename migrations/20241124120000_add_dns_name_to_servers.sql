@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN dns_name VARCHAR(255) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN dns_name;
@@ -0,0 +1,54 @@
+-- +goose Up
+CREATE TABLE alert_channels (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    target TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_alert_channels_user_id ON alert_channels (user_id);
+
+CREATE TABLE alert_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    server_id INTEGER REFERENCES servers(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    condition VARCHAR(32) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL DEFAULT 0,
+    for_seconds INTEGER NOT NULL DEFAULT 0,
+    channels TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_alert_rules_user_id ON alert_rules (user_id);
+
+CREATE TABLE alerts (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    state VARCHAR(16) NOT NULL,
+    value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    message TEXT NOT NULL DEFAULT '',
+    fired_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_alerts_rule_id ON alerts (rule_id);
+CREATE INDEX idx_alerts_server_id ON alerts (server_id);
+CREATE INDEX idx_alerts_user_id ON alerts (user_id);
+
+-- +goose Down
+DROP TABLE alerts;
+DROP TABLE alert_rules;
+DROP TABLE alert_channels;
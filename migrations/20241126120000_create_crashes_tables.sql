@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE crashes (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    exit_error TEXT NOT NULL DEFAULT '',
+    findings TEXT,
+    stderr TEXT,
+    console_tail TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_crashes_server_id ON crashes (server_id);
+
+CREATE TABLE crash_reports (
+    id SERIAL PRIMARY KEY,
+    crash_id INTEGER NOT NULL REFERENCES crashes(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    content TEXT
+);
+
+CREATE INDEX idx_crash_reports_crash_id ON crash_reports (crash_id);
+
+-- +goose Down
+DROP TABLE crash_reports;
+DROP TABLE crashes;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE announcements (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    format VARCHAR(16) NOT NULL DEFAULT 'say',
+    cron VARCHAR(255) NOT NULL DEFAULT '',
+    interval_seconds INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_announcements_server_id ON announcements (server_id);
+
+-- +goose Down
+DROP TABLE announcements;
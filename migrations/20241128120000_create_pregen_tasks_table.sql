@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE pregen_tasks (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    world VARCHAR(255) NOT NULL,
+    center_x INTEGER NOT NULL DEFAULT 0,
+    center_z INTEGER NOT NULL DEFAULT 0,
+    radius INTEGER NOT NULL,
+    pause_for_players BOOLEAN NOT NULL DEFAULT TRUE,
+    state VARCHAR(16) NOT NULL,
+    chunks BIGINT NOT NULL DEFAULT 0,
+    percent DOUBLE PRECISION NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_pregen_tasks_server_id ON pregen_tasks (server_id);
+
+-- +goose Down
+DROP TABLE pregen_tasks;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS variables TEXT;
+
+-- +goose Down
+ALTER TABLE templates DROP COLUMN IF EXISTS variables;
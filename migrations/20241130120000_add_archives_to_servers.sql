@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS archive_target VARCHAR(32);
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS archive_path TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS archive_size_bytes BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN IF EXISTS archive_size_bytes;
+ALTER TABLE servers DROP COLUMN IF EXISTS archive_path;
+ALTER TABLE servers DROP COLUMN IF EXISTS archive_target;
+ALTER TABLE servers DROP COLUMN IF EXISTS archived_at;
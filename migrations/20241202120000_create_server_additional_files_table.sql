@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE server_additional_files (
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    additional_file_id INTEGER NOT NULL REFERENCES additional_files(id) ON DELETE CASCADE,
+    PRIMARY KEY (server_id, additional_file_id)
+);
+
+-- +goose Down
+DROP TABLE server_additional_files;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE server_configs ADD COLUMN IF NOT EXISTS env TEXT;
+ALTER TABLE server_configs ADD COLUMN IF NOT EXISTS restart_policy VARCHAR(32) NOT NULL DEFAULT 'never';
+ALTER TABLE server_configs ADD COLUMN IF NOT EXISTS max_restarts INTEGER NOT NULL DEFAULT 3;
+
+-- +goose Down
+ALTER TABLE server_configs DROP COLUMN IF EXISTS max_restarts;
+ALTER TABLE server_configs DROP COLUMN IF EXISTS restart_policy;
+ALTER TABLE server_configs DROP COLUMN IF EXISTS env;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS public_status_token VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_servers_public_status_token ON servers (public_status_token);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_servers_public_status_token;
+ALTER TABLE servers DROP COLUMN IF EXISTS public_status_token;
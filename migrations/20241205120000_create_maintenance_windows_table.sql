@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE maintenance_windows (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    mode VARCHAR(16) NOT NULL DEFAULT 'stop',
+    message TEXT NOT NULL,
+    begun_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    was_running BOOLEAN NOT NULL DEFAULT FALSE,
+    previous_whitelist VARCHAR(16) NOT NULL DEFAULT '',
+    previous_enforce_whitelist VARCHAR(16) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_maintenance_windows_server_id ON maintenance_windows (server_id);
+
+-- +goose Down
+DROP TABLE maintenance_windows;
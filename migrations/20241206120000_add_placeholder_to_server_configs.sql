@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE server_configs ADD COLUMN IF NOT EXISTS placeholder BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE server_configs ADD COLUMN IF NOT EXISTS placeholder_motd TEXT;
+ALTER TABLE server_configs ADD COLUMN IF NOT EXISTS start_on_join BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE server_configs DROP COLUMN IF EXISTS start_on_join;
+ALTER TABLE server_configs DROP COLUMN IF EXISTS placeholder_motd;
+ALTER TABLE server_configs DROP COLUMN IF EXISTS placeholder;
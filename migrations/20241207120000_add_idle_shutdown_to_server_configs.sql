@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE server_configs ADD COLUMN IF NOT EXISTS idle_shutdown_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE server_configs DROP COLUMN IF EXISTS idle_shutdown_minutes;
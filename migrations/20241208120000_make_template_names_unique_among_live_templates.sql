@@ -0,0 +1,8 @@
+-- +goose Up
+-- Deleted templates keep their rows, so their names must be free to reuse
+ALTER TABLE templates DROP CONSTRAINT IF EXISTS templates_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_templates_name ON templates (name) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_templates_name;
+ALTER TABLE templates ADD CONSTRAINT templates_name_key UNIQUE (name);
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE jar_swaps (
+    id SERIAL PRIMARY KEY,
+    server_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    previous_jar_file_id INTEGER NOT NULL,
+    jar_file_id INTEGER NOT NULL,
+    snapshot_path TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_jar_swaps_server_id ON jar_swaps (server_id);
+
+-- +goose Down
+DROP TABLE jar_swaps;
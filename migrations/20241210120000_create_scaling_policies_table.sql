@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE scaling_policies (
+    id SERIAL PRIMARY KEY,
+    proxy_id INTEGER NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    template_id INTEGER NOT NULL REFERENCES templates(id),
+    min_instances INTEGER NOT NULL,
+    max_instances INTEGER NOT NULL,
+    scale_up_players INTEGER NOT NULL,
+    idle_minutes INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    user_id INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_scaling_policies_proxy_id ON scaling_policies (proxy_id) WHERE deleted_at IS NULL;
+
+-- Autoscaled backends were created by the scaling policy of their proxy
+ALTER TABLE servers ADD COLUMN autoscaled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN autoscaled;
+DROP TABLE scaling_policies;
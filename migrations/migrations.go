@@ -0,0 +1,10 @@
+// Package migrations embeds the goose SQL migrations so the server can
+// apply them at startup
+package migrations
+
+import "embed"
+
+// FS holds every migration file
+//
+//go:embed *.sql
+var FS embed.FS